@@ -0,0 +1,143 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"tidbyt.dev/pixlet/api"
+)
+
+type request struct {
+	method string
+	path   string
+	auth   string
+	body   string
+}
+
+// startServer serves handler, and records the requests it gets.
+func startServer(t *testing.T, handler http.HandlerFunc) (*api.Client, *[]request) {
+	var requests []request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, request{r.Method, r.URL.EscapedPath(), r.Header.Get("Authorization"), string(body)})
+		handler(w, r)
+	}))
+	t.Cleanup(ts.Close)
+
+	return api.NewClient("token", api.WithBaseURL(ts.URL+"/")), &requests
+}
+
+func TestDevices(t *testing.T) {
+	client, requests := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v0/devices":
+			w.Write([]byte(`{"devices": [{"id": "a", "displayName": "Kitchen"}, {"id": "b", "displayName": "Office"}]}`))
+		default:
+			w.Write([]byte(`{"id": "a", "displayName": "Kitchen", "brightness": 50, "autoDim": true}`))
+		}
+	})
+
+	devices, err := client.Devices(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []api.Device{{ID: "a", DisplayName: "Kitchen"}, {ID: "b", DisplayName: "Office"}}, devices)
+
+	device, err := client.Device(context.Background(), "a")
+	require.NoError(t, err)
+	assert.Equal(t, &api.Device{ID: "a", DisplayName: "Kitchen", Brightness: 50, AutoDim: true}, device)
+
+	assert.Equal(t, []request{
+		{"GET", "/v0/devices", "Bearer token", ""},
+		{"GET", "/v0/devices/a", "Bearer token", ""},
+	}, *requests)
+}
+
+func TestPush(t *testing.T) {
+	client, requests := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{}"))
+	})
+
+	err := client.Push(context.Background(), &api.Push{
+		DeviceID:       "my/device",
+		Image:          []byte("webp"),
+		InstallationID: "clock",
+		Background:     true,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, *requests, 1)
+	req := (*requests)[0]
+	assert.Equal(t, "POST", req.method)
+	assert.Equal(t, "/v0/devices/my%2Fdevice/push", req.path)
+	assert.JSONEq(t, `{"deviceID": "my/device", "image": "d2VicA==", "installationID": "clock", "background": true}`, req.body)
+}
+
+func TestInstallations(t *testing.T) {
+	client, requests := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"installations": [{"id": "clock", "appID": "digital-clock"}]}`))
+	})
+
+	installations, err := client.Installations(context.Background(), "a")
+	require.NoError(t, err)
+	assert.Equal(t, []api.Installation{{ID: "clock", AppID: "digital-clock"}}, installations)
+
+	require.NoError(t, client.UpdateInstallation(context.Background(), "a", "clock", map[string]string{"tz": "UTC"}))
+	require.NoError(t, client.DeleteInstallation(context.Background(), "a", "clock"))
+
+	assert.Equal(t, []request{
+		{"GET", "/v0/devices/a/installations", "Bearer token", ""},
+		{"PATCH", "/v0/devices/a/installations/clock", "Bearer token", `{"config":{"tz":"UTC"}}`},
+		{"DELETE", "/v0/devices/a/installations/clock", "Bearer token", ""},
+	}, *requests)
+}
+
+func TestError(t *testing.T) {
+	client, _ := startServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" {
+			http.Error(w, "no such installation", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Retry-After", "7")
+		http.Error(w, "slow down", http.StatusTooManyRequests)
+	})
+
+	err := client.Push(context.Background(), &api.Push{DeviceID: "a"})
+	var apiErr *api.Error
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusTooManyRequests, apiErr.StatusCode)
+	assert.Equal(t, 7*time.Second, apiErr.RetryAfter)
+	assert.True(t, apiErr.Temporary())
+	assert.Contains(t, err.Error(), "returned status 429 Too Many Requests: slow down")
+
+	err = client.DeleteInstallation(context.Background(), "a", "clock")
+	require.True(t, errors.As(err, &apiErr))
+	assert.False(t, apiErr.Temporary())
+}
+
+func TestTokenSource(t *testing.T) {
+	var auth []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = append(auth, r.Header.Get("Authorization"))
+		json.NewEncoder(w).Encode(map[string]interface{}{"devices": []interface{}{}})
+	}))
+	defer ts.Close()
+
+	client := api.NewClient("", api.WithBaseURL(ts.URL))
+	_, err := client.Devices(context.Background())
+	require.NoError(t, err)
+
+	client = api.NewClient("", api.WithBaseURL(ts.URL), api.WithTokenSource(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "fresh"})))
+	_, err = client.Devices(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"", "Bearer fresh"}, auth)
+}
@@ -0,0 +1,211 @@
+// Package api is a client for the Tidbyt API, for Go programs that
+// manage devices without running the pixlet binary. It lists devices and
+// the apps installed on them, pushes images, and updates and deletes
+// installations:
+//
+//	client := api.NewClient(os.Getenv(api.TokenEnv))
+//	devices, err := client.Devices(ctx)
+//	...
+//	err = client.Push(ctx, &api.Push{DeviceID: devices[0].ID, Image: webp})
+//
+// Tokens come from the Tidbyt mobile app, or from logging in with OAuth2
+// using OAuthEndpoint, in which case WithTokenSource keeps them fresh.
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	// DefaultBaseURL is where the Tidbyt API is served.
+	DefaultBaseURL = "https://api.tidbyt.com"
+
+	// TokenEnv is the environment variable pixlet reads API tokens
+	// from.
+	TokenEnv = "TIDBYT_API_TOKEN"
+)
+
+// OAuthEndpoint is where to log in to the Tidbyt API with OAuth2.
+var OAuthEndpoint = oauth2.Endpoint{
+	AuthURL:  "https://login.tidbyt.com/oauth2/auth",
+	TokenURL: "https://login.tidbyt.com/oauth2/token",
+}
+
+// Client makes requests to the Tidbyt API. It's safe for concurrent use.
+type Client struct {
+	baseURL string
+	tokens  oauth2.TokenSource
+	http    *http.Client
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithBaseURL sends requests to a server other than the Tidbyt API,
+// like a device on the local network or a self hosted server that
+// accepts the same requests.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithTokenSource authenticates requests with tokens from ts, in place
+// of the token given to NewClient. Use it with an oauth2.Config's
+// TokenSource to have tokens refreshed as they expire.
+func WithTokenSource(ts oauth2.TokenSource) Option {
+	return func(c *Client) {
+		c.tokens = ts
+	}
+}
+
+// WithHTTPClient makes requests with hc instead of
+// http.DefaultClient.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.http = hc
+	}
+}
+
+// NewClient returns a client authenticating with token. Requests are
+// sent without authentication if token is empty, which devices on the
+// local network accept.
+func NewClient(token string, opts ...Option) *Client {
+	c := &Client{
+		baseURL: DefaultBaseURL,
+		http:    http.DefaultClient,
+	}
+	if token != "" {
+		c.tokens = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// BaseURL returns the URL requests are sent relative to.
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// Error is a response from the server other than success.
+type Error struct {
+	// Server names who responded, "Tidbyt API" or the host of the base
+	// URL.
+	Server string
+
+	StatusCode int
+	Status     string
+
+	// Body is the response body, which usually says what went wrong.
+	Body string
+
+	// RetryAfter is how long the server asked to wait before retrying,
+	// if it did.
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("%s returned status %s", e.Server, e.Status)
+	}
+	return fmt.Sprintf("%s returned status %s: %s", e.Server, e.Status, e.Body)
+}
+
+// Temporary reports whether the request might succeed if retried later,
+// like after rate limiting or a server error.
+func (e *Error) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// server names the server in errors.
+func (c *Client) server() string {
+	if c.baseURL == DefaultBaseURL {
+		return "Tidbyt API"
+	}
+	if u, err := url.Parse(c.baseURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return c.baseURL
+}
+
+// do sends a request to path, with in as the JSON body if it isn't nil,
+// and decodes the JSON response into out if it isn't nil.
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		payload, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("marshaling request: %w", err)
+		}
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("creating %s request: %w", method, err)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if c.tokens != nil {
+		tok, err := c.tokens.Token()
+		if err != nil {
+			return fmt.Errorf("getting API token: %w", err)
+		}
+		tok.SetAuthHeader(req)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		apiErr := &Error{
+			Server:     c.server(),
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Body:       strings.TrimSpace(string(respBody)),
+		}
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			apiErr.RetryAfter = time.Duration(secs) * time.Second
+		}
+		return apiErr
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding %s response: %w", c.server(), err)
+	}
+	return nil
+}
+
+// devicePath returns the path to a device's resource, like
+// /v0/devices/<id>/push.
+func devicePath(deviceID string, elems ...string) string {
+	path := "/v0/devices/" + url.PathEscape(deviceID)
+	for _, e := range elems {
+		path += "/" + url.PathEscape(e)
+	}
+	return path
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"context"
+)
+
+// Device is a Tidbyt in the account.
+type Device struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"displayName"`
+
+	// Brightness is from 0 to 100.
+	Brightness int  `json:"brightness"`
+	AutoDim    bool `json:"autoDim"`
+}
+
+// Devices lists the devices in the account.
+func (c *Client) Devices(ctx context.Context) ([]Device, error) {
+	var body struct {
+		Devices []Device `json:"devices"`
+	}
+	if err := c.do(ctx, "GET", "/v0/devices", nil, &body); err != nil {
+		return nil, err
+	}
+	return body.Devices, nil
+}
+
+// Device returns a device's info.
+func (c *Client) Device(ctx context.Context, deviceID string) (*Device, error) {
+	var d Device
+	if err := c.do(ctx, "GET", devicePath(deviceID), nil, &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"context"
+)
+
+// Installation is an app installed on a device, including images pushed
+// with an installation ID.
+type Installation struct {
+	ID    string `json:"id"`
+	AppID string `json:"appID"`
+}
+
+// Installations lists the apps installed on a device.
+func (c *Client) Installations(ctx context.Context, deviceID string) ([]Installation, error) {
+	var body struct {
+		Installations []Installation `json:"installations"`
+	}
+	if err := c.do(ctx, "GET", devicePath(deviceID, "installations"), nil, &body); err != nil {
+		return nil, err
+	}
+	return body.Installations, nil
+}
+
+// UpdateInstallation replaces an installation's config.
+func (c *Client) UpdateInstallation(ctx context.Context, deviceID, installationID string, config map[string]string) error {
+	body := struct {
+		Config map[string]string `json:"config"`
+	}{config}
+	return c.do(ctx, "PATCH", devicePath(deviceID, "installations", installationID), body, nil)
+}
+
+// DeleteInstallation removes an installation from a device.
+func (c *Client) DeleteInstallation(ctx context.Context, deviceID, installationID string) error {
+	return c.do(ctx, "DELETE", devicePath(deviceID, "installations", installationID), nil, nil)
+}
@@ -0,0 +1,28 @@
+package api
+
+import (
+	"context"
+)
+
+// Push is an image to show on a device.
+type Push struct {
+	DeviceID string `json:"deviceID"`
+
+	// Image is a WebP image, as rendered by pixlet. It's base64 encoded
+	// in JSON.
+	Image []byte `json:"image"`
+
+	// InstallationID keeps the image in the device's rotation, replacing
+	// the last image pushed with the same ID. Without one, the image is
+	// shown once.
+	InstallationID string `json:"installationID"`
+
+	// Background adds the image to the rotation without showing it right
+	// away. It needs an InstallationID.
+	Background bool `json:"background"`
+}
+
+// Push sends an image to a device.
+func (c *Client) Push(ctx context.Context, push *Push) error {
+	return c.do(ctx, "POST", devicePath(push.DeviceID, "push"), push, nil)
+}
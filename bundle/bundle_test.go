@@ -1,9 +1,12 @@
 package bundle_test
 
 import (
+	"bytes"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"tidbyt.dev/pixlet/bundle"
@@ -108,3 +111,25 @@ func TestLoadBundleExcessData(t *testing.T) {
 	assert.Equal(t, "test-app", ab.Manifest.ID)
 	assert.NotNil(t, ab.Source)
 }
+
+func TestBundleWriteIsReproducible(t *testing.T) {
+	ab, err := bundle.FromDir("testdata/testapp")
+	assert.NoError(t, err)
+
+	var first bytes.Buffer
+	assert.NoError(t, ab.WriteBundle(&first))
+
+	// Touch every file, which shouldn't change the bundle.
+	later := time.Now().Add(time.Hour)
+	err = filepath.WalkDir("testdata/testapp", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.Chtimes(path, later, later)
+	})
+	assert.NoError(t, err)
+
+	var second bytes.Buffer
+	assert.NoError(t, ab.WriteBundle(&second))
+	assert.Equal(t, first.Bytes(), second.Bytes())
+}
@@ -0,0 +1,97 @@
+package bundle
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// AppSignatureName is the standard name for the signature of a bundle
+// written next to it.
+const AppSignatureName = AppBundleName + ".sig"
+
+// ErrBadSignature is returned when a bundle doesn't match its signature.
+var ErrBadSignature = errors.New("bundle signature is not valid")
+
+// GenerateKey creates a new key pair for signing bundles, encoded as PEM.
+// The private key is for whoever builds bundles, the public key for
+// whoever needs to check them.
+func GenerateKey() (privateKey []byte, publicKey []byte, err error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generating key: %w", err)
+	}
+
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshaling private key: %w", err)
+	}
+
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshaling public key: %w", err)
+	}
+
+	privateKey = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER})
+	publicKey = pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})
+	return privateKey, publicKey, nil
+}
+
+// Sign signs a written bundle with a PEM encoded private key, as created
+// by GenerateKey. The signature is base64 text, so that it can be stored
+// or sent alongside the bundle as is.
+func Sign(bundle []byte, privateKey []byte) ([]byte, error) {
+	block, _ := pem.Decode(privateKey)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("private key is not a PEM encoded PRIVATE KEY")
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing private key: %w", err)
+	}
+
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key is a %T, not an Ed25519 key", key)
+	}
+
+	sig := ed25519.Sign(priv, bundle)
+	return []byte(base64.StdEncoding.EncodeToString(sig) + "\n"), nil
+}
+
+// Verify checks that a bundle was signed with the private key that
+// belongs to the PEM encoded public key. It returns ErrBadSignature if the
+// bundle was changed since it was signed, or signed with another key.
+func Verify(bundle []byte, signature []byte, publicKey []byte) error {
+	block, _ := pem.Decode(publicKey)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return fmt.Errorf("public key is not a PEM encoded PUBLIC KEY")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("parsing public key: %w", err)
+	}
+
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return fmt.Errorf("public key is a %T, not an Ed25519 key", key)
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return fmt.Errorf("decoding signature: %w", err)
+	}
+
+	if !ed25519.Verify(pub, bundle, sig) {
+		return ErrBadSignature
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package bundle_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"tidbyt.dev/pixlet/bundle"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	ab, err := bundle.FromDir("testdata/testapp")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, ab.WriteBundle(&buf))
+	data := buf.Bytes()
+
+	priv, pub, err := bundle.GenerateKey()
+	require.NoError(t, err)
+
+	sig, err := bundle.Sign(data, priv)
+	require.NoError(t, err)
+	assert.NoError(t, bundle.Verify(data, sig, pub))
+
+	// A changed bundle doesn't verify.
+	tampered := append([]byte{}, data...)
+	tampered[len(tampered)-1] ^= 0xff
+	assert.ErrorIs(t, bundle.Verify(tampered, sig, pub), bundle.ErrBadSignature)
+
+	// Neither does one signed with another key.
+	_, otherPub, err := bundle.GenerateKey()
+	require.NoError(t, err)
+	assert.ErrorIs(t, bundle.Verify(data, sig, otherPub), bundle.ErrBadSignature)
+}
+
+func TestSignWithPublicKey(t *testing.T) {
+	_, pub, err := bundle.GenerateKey()
+	require.NoError(t, err)
+
+	_, err = bundle.Sign([]byte("bundle"), pub)
+	assert.ErrorContains(t, err, "PRIVATE KEY")
+}
@@ -0,0 +1,368 @@
+package bundle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+	"time"
+
+	"go.starlark.net/syntax"
+
+	"tidbyt.dev/pixlet/encode"
+	"tidbyt.dev/pixlet/icons"
+	"tidbyt.dev/pixlet/manifest"
+	"tidbyt.dev/pixlet/runtime"
+)
+
+const (
+	// DefaultMaxFileSize is the largest file a bundle may hold, unless
+	// ValidateOptions say otherwise.
+	DefaultMaxFileSize = 1 << 20
+
+	// DefaultMaxTotalSize is the most a bundle's files may add up to,
+	// unless ValidateOptions say otherwise.
+	DefaultMaxTotalSize = 4 << 20
+
+	// DefaultMaxRenderTime is how long the dry run render may take,
+	// unless ValidateOptions say otherwise.
+	DefaultMaxRenderTime = 5 * time.Second
+)
+
+// Checks made by Validate, as found in Finding.Check.
+const (
+	CheckManifest = "manifest"
+	CheckSize     = "size"
+	CheckModule   = "module"
+	CheckLoad     = "load"
+	CheckSchema   = "schema"
+	CheckRender   = "render"
+)
+
+// Severity is how bad a finding is.
+type Severity string
+
+const (
+	// SeverityError findings keep an app from being published.
+	SeverityError Severity = "error"
+
+	// SeverityWarning findings are worth a reviewer's look, but don't
+	// keep an app from being published.
+	SeverityWarning Severity = "warning"
+)
+
+// Finding is a problem Validate found with a bundle.
+type Finding struct {
+	Check    string   `json:"check"`
+	Severity Severity `json:"severity"`
+
+	// Path and Line are where in the bundle the problem is, if it's in
+	// a file.
+	Path string `json:"path,omitempty"`
+	Line int    `json:"line,omitempty"`
+
+	// Field is the manifest or schema field the problem is with, if
+	// any.
+	Field string `json:"field,omitempty"`
+
+	Message string `json:"message"`
+}
+
+func (f Finding) String() string {
+	where := ""
+	switch {
+	case f.Path != "" && f.Line > 0:
+		where = fmt.Sprintf("%s:%d: ", f.Path, f.Line)
+	case f.Path != "":
+		where = f.Path + ": "
+	case f.Field != "":
+		where = f.Field + ": "
+	}
+	return fmt.Sprintf("%s%s: %s (%s)", where, f.Severity, f.Message, f.Check)
+}
+
+// Report is what Validate found.
+type Report struct {
+	// App is the ID in the manifest.
+	App string `json:"app"`
+
+	Findings []Finding `json:"findings"`
+
+	// Size is what the bundle's files add up to, in bytes.
+	Size int64 `json:"size"`
+
+	// RenderTime is how long the dry run render took, if the app got as
+	// far as rendering.
+	RenderTime time.Duration `json:"render_time,omitempty"`
+}
+
+// OK reports whether the bundle has no findings of SeverityError.
+func (r *Report) OK() bool {
+	return r.Err() == nil
+}
+
+// Err returns an error listing the findings of SeverityError, or nil
+// if there are none.
+func (r *Report) Err() error {
+	var problems []string
+	for _, f := range r.Findings {
+		if f.Severity == SeverityError {
+			problems = append(problems, f.String())
+		}
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s has %d problems:\n%s", r.App, len(problems), strings.Join(problems, "\n"))
+}
+
+func (r *Report) add(check string, severity Severity, f Finding) {
+	f.Check = check
+	f.Severity = severity
+	r.Findings = append(r.Findings, f)
+}
+
+// ValidateOptions configures Validate. The zero value uses the
+// defaults.
+type ValidateOptions struct {
+	// MaxFileSize is the largest file the bundle may hold, in bytes. It
+	// defaults to DefaultMaxFileSize.
+	MaxFileSize int64
+
+	// MaxTotalSize is the most the bundle's files may add up to, in
+	// bytes. It defaults to DefaultMaxTotalSize.
+	MaxTotalSize int64
+
+	// BannedModules are modules the app may not load, like
+	// "secret.star".
+	BannedModules []string
+
+	// MaxRenderTime is how long the dry run render may take. It
+	// defaults to DefaultMaxRenderTime.
+	MaxRenderTime time.Duration
+
+	// AppletOptions are what the app is loaded with for the dry run.
+	AppletOptions []runtime.AppletOption
+}
+
+// Validate checks the bundle end to end, the way an app store reviews
+// apps before publishing them: the manifest's fields, the size of the
+// files, the modules loaded, the schema, and a dry run render with the
+// schema's default config. Problems are reported as findings, rather
+// than stopping at the first, so that they can all be fixed at once.
+// The error is only for when the bundle couldn't be read.
+func (ab *AppBundle) Validate(ctx context.Context, opts ValidateOptions) (*Report, error) {
+	report := &Report{App: ab.Manifest.ID, Findings: []Finding{}}
+
+	validateManifest(report, ab.Manifest)
+
+	if err := validateFiles(report, ab.Source, opts); err != nil {
+		return nil, err
+	}
+
+	// the rest needs the app to load
+	loadOpts := append([]runtime.AppletOption{runtime.WithPrintDisabled()}, opts.AppletOptions...)
+	applet, err := runtime.NewAppletFromFS(ab.Manifest.ID, ab.Source, loadOpts...)
+	if err != nil {
+		report.add(CheckLoad, SeverityError, Finding{Message: err.Error()})
+		return report, nil
+	}
+
+	validateSchema(report, applet)
+	validateRender(ctx, report, applet, opts)
+	return report, nil
+}
+
+func validateManifest(report *Report, m *manifest.Manifest) {
+	for _, field := range []struct {
+		name     string
+		validate func(string) error
+		value    string
+	}{
+		{"id", manifest.ValidateID, m.ID},
+		{"name", manifest.ValidateName, m.Name},
+		{"summary", manifest.ValidateSummary, m.Summary},
+		{"desc", manifest.ValidateDesc, m.Desc},
+		{"author", manifest.ValidateAuthor, m.Author},
+	} {
+		if err := field.validate(field.value); err != nil {
+			report.add(CheckManifest, SeverityError, Finding{
+				Path:    manifest.ManifestFileName,
+				Field:   field.name,
+				Message: err.Error(),
+			})
+		}
+	}
+}
+
+// validateFiles checks the size of every file, and the modules loaded
+// by Starlark files.
+func validateFiles(report *Report, fsys fs.FS, opts ValidateOptions) error {
+	maxFile := opts.MaxFileSize
+	if maxFile <= 0 {
+		maxFile = DefaultMaxFileSize
+	}
+	maxTotal := opts.MaxTotalSize
+	if maxTotal <= 0 {
+		maxTotal = DefaultMaxTotalSize
+	}
+
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		report.Size += info.Size()
+		if info.Size() > maxFile {
+			report.add(CheckSize, SeverityError, Finding{
+				Path:    path,
+				Message: fmt.Sprintf("file is %d bytes, more than the limit of %d", info.Size(), maxFile),
+			})
+		}
+
+		if strings.HasSuffix(path, ".star") && len(opts.BannedModules) > 0 {
+			src, err := fs.ReadFile(fsys, path)
+			if err != nil {
+				return err
+			}
+			validateLoads(report, path, src, opts.BannedModules)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("reading bundle: %w", err)
+	}
+
+	if report.Size > maxTotal {
+		report.add(CheckSize, SeverityError, Finding{
+			Message: fmt.Sprintf("files add up to %d bytes, more than the limit of %d", report.Size, maxTotal),
+		})
+	}
+	return nil
+}
+
+// validateLoads reports loads of banned modules. Files that don't parse
+// are left for loading the app to report.
+func validateLoads(report *Report, path string, src []byte, banned []string) {
+	opts := &syntax.FileOptions{Set: true, Recursion: true}
+	f, err := opts.Parse(path, src, 0)
+	if err != nil {
+		return
+	}
+
+	for _, stmt := range f.Stmts {
+		load, ok := stmt.(*syntax.LoadStmt)
+		if !ok {
+			continue
+		}
+		module := load.ModuleName()
+		if slices.Contains(banned, module) {
+			report.add(CheckModule, SeverityError, Finding{
+				Path:    path,
+				Line:    int(load.Load.Line),
+				Message: fmt.Sprintf("loads %s, which isn't allowed", module),
+			})
+		}
+	}
+}
+
+// validateSchema checks what loading the schema doesn't: that field IDs
+// are unique, that icons exist in the mobile app, that handlers and
+// the fields visibility depends on exist, and that the defaults are
+// valid.
+func validateSchema(report *Report, applet *runtime.Applet) {
+	s := applet.Schema
+	if s == nil {
+		return
+	}
+
+	seen := map[string]bool{}
+	for _, field := range s.Fields {
+		if seen[field.ID] {
+			report.add(CheckSchema, SeverityError, Finding{Field: field.ID, Message: "more than one field has this ID"})
+		}
+		seen[field.ID] = true
+
+		if field.Icon != "" {
+			if _, ok := icons.IconsMap[field.Icon]; !ok {
+				report.add(CheckSchema, SeverityError, Finding{
+					Field:   field.ID,
+					Message: fmt.Sprintf("icon %q isn't available in the mobile app", field.Icon),
+				})
+			}
+		}
+
+		if _, ok := s.FieldHandler(field); field.Handler != "" && !ok {
+			report.add(CheckSchema, SeverityError, Finding{
+				Field:   field.ID,
+				Message: fmt.Sprintf("handler %s doesn't exist", field.Handler),
+			})
+		}
+	}
+
+	for _, field := range s.Fields {
+		if v := field.Visibility; v != nil && !seen[v.Variable] {
+			report.add(CheckSchema, SeverityError, Finding{
+				Field:   field.ID,
+				Message: fmt.Sprintf("visibility depends on %s, which isn't a field", v.Variable),
+			})
+		}
+	}
+
+	for _, err := range s.ValidateConfig(s.Defaults()) {
+		// required fields without a default are for users to fill in
+		if f, ok := s.Field(err.Field); ok && f.Required && f.Default == "" {
+			continue
+		}
+		report.add(CheckSchema, SeverityError, Finding{
+			Field:   err.Field,
+			Message: "default " + err.Message,
+		})
+	}
+}
+
+// validateRender renders the app with the schema's default config, and
+// encodes it like a device would be sent it.
+func validateRender(ctx context.Context, report *Report, applet *runtime.Applet, opts ValidateOptions) {
+	maxTime := opts.MaxRenderTime
+	if maxTime <= 0 {
+		maxTime = DefaultMaxRenderTime
+	}
+	ctx, cancel := context.WithTimeout(ctx, maxTime)
+	defer cancel()
+
+	config := map[string]string{}
+	if applet.Schema != nil {
+		config = applet.Schema.Defaults()
+	}
+
+	start := time.Now()
+	roots, err := applet.RunWithConfig(ctx, config)
+	report.RenderTime = time.Since(start)
+
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		report.add(CheckRender, SeverityError, Finding{
+			Message: fmt.Sprintf("app didn't render within %s", maxTime),
+		})
+		return
+	case err != nil:
+		report.add(CheckRender, SeverityError, Finding{Message: err.Error()})
+		return
+	case len(roots) == 0:
+		report.add(CheckRender, SeverityWarning, Finding{
+			Message: "app returned no roots with the default config, so devices would skip it",
+		})
+		return
+	}
+
+	if _, err := encode.EncodeContext(ctx, roots, encode.Options{}); err != nil {
+		report.add(CheckRender, SeverityError, Finding{Message: fmt.Sprintf("encoding: %v", err)})
+	}
+}
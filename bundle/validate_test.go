@@ -0,0 +1,181 @@
+package bundle_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tidbyt.dev/pixlet/bundle"
+)
+
+const validManifest = `---
+id: test-app
+name: Test App
+summary: For Testing
+desc: It's an app for testing.
+author: Test Dev
+`
+
+const validApp = `
+load("render.star", "render")
+load("schema.star", "schema")
+
+def main(config):
+    return render.Root(child = render.Text(config.get("msg")))
+
+def get_schema():
+    return schema.Schema(
+        version = "1",
+        fields = [
+            schema.Text(id = "msg", name = "Message", desc = "What to show", icon = "message", default = "hi"),
+        ],
+    )
+`
+
+func validateApp(t *testing.T, files fstest.MapFS, opts bundle.ValidateOptions) *bundle.Report {
+	if _, ok := files["manifest.yaml"]; !ok {
+		files["manifest.yaml"] = &fstest.MapFile{Data: []byte(validManifest)}
+	}
+	ab, err := bundle.FromFS(files)
+	require.NoError(t, err)
+
+	report, err := ab.Validate(context.Background(), opts)
+	require.NoError(t, err)
+	return report
+}
+
+func TestValidate(t *testing.T) {
+	report := validateApp(t, fstest.MapFS{"app.star": {Data: []byte(validApp)}}, bundle.ValidateOptions{})
+	assert.NoError(t, report.Err())
+	assert.True(t, report.OK())
+	assert.Empty(t, report.Findings)
+	assert.Equal(t, "test-app", report.App)
+	assert.Positive(t, report.Size)
+	assert.Positive(t, report.RenderTime)
+}
+
+func TestValidateManifest(t *testing.T) {
+	report := validateApp(t, fstest.MapFS{
+		"manifest.yaml": {Data: []byte("---\nid: Test_App\nname: test app\nsummary: For Testing\ndesc: It's an app for testing.\nauthor: Test Dev\n")},
+		"app.star":      {Data: []byte(validApp)},
+	}, bundle.ValidateOptions{})
+
+	require.Len(t, report.Findings, 2)
+	assert.Equal(t, "id", report.Findings[0].Field)
+	assert.Equal(t, "name", report.Findings[1].Field)
+	for _, f := range report.Findings {
+		assert.Equal(t, bundle.CheckManifest, f.Check)
+		assert.Equal(t, bundle.SeverityError, f.Severity)
+	}
+	assert.False(t, report.OK())
+}
+
+func TestValidateSize(t *testing.T) {
+	report := validateApp(t, fstest.MapFS{
+		"app.star":  {Data: []byte(validApp)},
+		"big.txt":   {Data: make([]byte, 200)},
+		"small.txt": {Data: make([]byte, 50)},
+	}, bundle.ValidateOptions{MaxFileSize: 150, MaxTotalSize: 1000})
+
+	require.Len(t, report.Findings, 2)
+	// app.star is over the limit too
+	assert.Equal(t, "app.star", report.Findings[0].Path)
+	assert.Equal(t, "big.txt", report.Findings[1].Path)
+
+	report = validateApp(t, fstest.MapFS{
+		"app.star": {Data: []byte(validApp)},
+		"big.txt":  {Data: make([]byte, 2000)},
+	}, bundle.ValidateOptions{MaxTotalSize: 1000})
+	require.Len(t, report.Findings, 1)
+	assert.Equal(t, bundle.CheckSize, report.Findings[0].Check)
+	assert.Contains(t, report.Findings[0].Message, "add up to")
+}
+
+func TestValidateModules(t *testing.T) {
+	report := validateApp(t, fstest.MapFS{
+		"app.star": {Data: []byte(`
+load("render.star", "render")
+load("secret.star", "secret")
+
+def main():
+    return render.Root(child = render.Text(secret.decrypt("x") or "none"))
+`)},
+	}, bundle.ValidateOptions{BannedModules: []string{"secret.star", "http.star"}})
+
+	require.Len(t, report.Findings, 1)
+	f := report.Findings[0]
+	assert.Equal(t, bundle.CheckModule, f.Check)
+	assert.Equal(t, "app.star", f.Path)
+	assert.Equal(t, 3, f.Line)
+	assert.Equal(t, "app.star:3: error: loads secret.star, which isn't allowed (module)", f.String())
+}
+
+func TestValidateLoad(t *testing.T) {
+	report := validateApp(t, fstest.MapFS{"app.star": {Data: []byte("def main(:")}}, bundle.ValidateOptions{})
+	require.Len(t, report.Findings, 1)
+	assert.Equal(t, bundle.CheckLoad, report.Findings[0].Check)
+}
+
+func TestValidateSchema(t *testing.T) {
+	report := validateApp(t, fstest.MapFS{"app.star": {Data: []byte(`
+load("render.star", "render")
+load("schema.star", "schema")
+
+def main(config):
+    return render.Root(child = render.Text("hi"))
+
+def get_schema():
+    return schema.Schema(
+        version = "1",
+        fields = [
+            schema.Text(id = "msg", name = "Message", desc = "What to show", icon = "notAnIcon"),
+            schema.Text(id = "msg", name = "Again", desc = "What to show", icon = "message"),
+            schema.Text(id = "name", name = "Name", desc = "Who to greet", icon = "user", required = True),
+            schema.Dropdown(id = "size", name = "Size", desc = "How big", icon = "user", default = "small", options = [
+                schema.Option(display = "Large", value = "large"),
+            ]),
+        ],
+    )
+`)}}, bundle.ValidateOptions{})
+
+	var fields, messages []string
+	for _, f := range report.Findings {
+		assert.Equal(t, bundle.CheckSchema, f.Check)
+		fields = append(fields, f.Field)
+		messages = append(messages, f.Message)
+	}
+	assert.Equal(t, []string{"msg", "msg", "size"}, fields, messages)
+	assert.Contains(t, messages[0], "notAnIcon")
+	assert.Contains(t, messages[1], "more than one field")
+	assert.Contains(t, messages[2], "default")
+}
+
+func TestValidateRender(t *testing.T) {
+	report := validateApp(t, fstest.MapFS{"app.star": {Data: []byte(`
+def main():
+    return []
+`)}}, bundle.ValidateOptions{})
+	require.Len(t, report.Findings, 1)
+	assert.Equal(t, bundle.SeverityWarning, report.Findings[0].Severity)
+	assert.True(t, report.OK())
+
+	report = validateApp(t, fstest.MapFS{"app.star": {Data: []byte(`
+def main():
+    fail("oops")
+`)}}, bundle.ValidateOptions{})
+	require.Len(t, report.Findings, 1)
+	assert.Equal(t, bundle.CheckRender, report.Findings[0].Check)
+	assert.Contains(t, report.Findings[0].Message, "oops")
+
+	report = validateApp(t, fstest.MapFS{"app.star": {Data: []byte(`
+def main():
+    for i in range(100000000):
+        pass
+`)}}, bundle.ValidateOptions{MaxRenderTime: 50 * time.Millisecond})
+	require.Len(t, report.Findings, 1)
+	assert.Contains(t, report.Findings[0].Message, "didn't render within 50ms")
+}
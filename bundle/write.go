@@ -11,6 +11,8 @@ import (
 	"os"
 	"path/filepath"
 	"slices"
+	"sort"
+	"time"
 
 	"tidbyt.dev/pixlet/manifest"
 	"tidbyt.dev/pixlet/runtime"
@@ -18,6 +20,11 @@ import (
 
 type WriteOption interface{}
 
+// modTime is the modification time of every file in a bundle. Bundles
+// don't depend on when or where they were written, so that building the
+// same app twice gives the same bytes.
+var modTime = time.Unix(0, 0)
+
 type withoutRuntimeOption struct{}
 
 // WithoutRuntime is a WriteOption that can be used to write the bundle without
@@ -75,6 +82,7 @@ func (ab *AppBundle) WriteBundle(out io.Writer, opts ...WriteOption) error {
 		}
 		bundleFiles = app.PathsForBundle()
 	}
+	sort.Strings(bundleFiles)
 
 	// Setup writers.
 	gzw := gzip.NewWriter(out)
@@ -92,9 +100,10 @@ func (ab *AppBundle) WriteBundle(out io.Writer, opts ...WriteOption) error {
 	b := buff.Bytes()
 
 	hdr := &tar.Header{
-		Name: manifest.ManifestFileName,
-		Mode: 0600,
-		Size: int64(len(b)),
+		Name:    manifest.ManifestFileName,
+		Mode:    0600,
+		Size:    int64(len(b)),
+		ModTime: modTime,
 	}
 	err = tw.WriteHeader(hdr)
 	if err != nil {
@@ -112,11 +121,18 @@ func (ab *AppBundle) WriteBundle(out io.Writer, opts ...WriteOption) error {
 			return fmt.Errorf("could not stat %s: %w", path, err)
 		}
 
-		hdr, err := tar.FileInfoHeader(stat, "")
-		if err != nil {
-			return fmt.Errorf("creating header for %s: %w", path, err)
+		// leave out owners and times, which depend on the machine
+		hdr := &tar.Header{
+			Name:    filepath.ToSlash(path),
+			Mode:    0644,
+			Size:    stat.Size(),
+			ModTime: modTime,
+		}
+		if stat.IsDir() {
+			hdr.Typeflag = tar.TypeDir
+			hdr.Mode = 0755
+			hdr.Size = 0
 		}
-		hdr.Name = filepath.ToSlash(path)
 
 		err = tw.WriteHeader(hdr)
 		if err != nil {
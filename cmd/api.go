@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"tidbyt.dev/pixlet/api"
+	"tidbyt.dev/pixlet/cmd/config"
+)
+
+// resolveAPIToken finds the API token to use, from --api-token,
+// $TIDBYT_API_TOKEN or pixlet login, in that order.
+func resolveAPIToken(cmd *cobra.Command) error {
+	if apiToken == "" {
+		apiToken = os.Getenv(APITokenEnv)
+	}
+
+	if apiToken == "" {
+		apiToken = config.OAuthTokenFromConfig(cmd.Context())
+	}
+
+	if apiToken == "" {
+		return fmt.Errorf("blank Tidbyt API token (use `pixlet login`, set $%s or pass with --api-token)", APITokenEnv)
+	}
+
+	return nil
+}
+
+// apiClient returns a Tidbyt API client using the token found by
+// resolveAPIToken.
+func apiClient(cmd *cobra.Command) (*api.Client, error) {
+	if err := resolveAPIToken(cmd); err != nil {
+		return nil, err
+	}
+
+	return api.NewClient(apiToken), nil
+}
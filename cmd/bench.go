@@ -0,0 +1,374 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	goruntime "runtime"
+	"runtime/metrics"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"tidbyt.dev/pixlet/encode"
+	pixlet_render "tidbyt.dev/pixlet/render"
+	"tidbyt.dev/pixlet/runtime"
+	"tidbyt.dev/pixlet/tools"
+)
+
+var (
+	benchRuns       int
+	benchWarmup     int
+	benchMatrix     string
+	benchConfigFile string
+	benchGif        bool
+	benchJSON       bool
+)
+
+func init() {
+	BenchCmd.Flags().IntVarP(&benchRuns, "runs", "n", 20, "Number of measured runs for each config set")
+	BenchCmd.Flags().IntVarP(&benchWarmup, "warmup", "", 1, "Number of runs before measuring, to fill caches")
+	BenchCmd.Flags().StringVarP(&benchMatrix, "matrix", "", "", "Benchmark every config set in this JSON or YAML file")
+	BenchCmd.Flags().StringVarP(&benchConfigFile, "config-file", "c", "", "Read config from this JSON or YAML file")
+	BenchCmd.Flags().BoolVarP(&benchGif, "gif", "", false, "Encode GIF instead of WebP")
+	BenchCmd.Flags().BoolVarP(&benchJSON, "json", "", false, "Print results as JSON")
+}
+
+var BenchCmd = &cobra.Command{
+	Use:   "bench <path> [<key>=value>]...",
+	Short: "Benchmark rendering a Pixlet app",
+	Example: `  pixlet bench examples/clock
+  pixlet bench --runs 50 --matrix configs.yaml --json app.star`,
+	Long: `Benchmark rendering a Pixlet app.
+
+The app is rendered --runs times, after --warmup runs that aren't
+measured. Each render is timed in three phases: running the app's
+Starlark code, painting the frames, and encoding the image. For each
+phase and in total, the median (p50) and 95th percentile (p95) are
+reported, along with the frame count, the encoded size, and memory use.
+
+With --matrix, every config set in the file is benchmarked in turn, the
+same way as with pixlet render --matrix. --json prints the results in
+a stable format, for tracking regressions in CI.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: bench,
+}
+
+// benchDurations summarizes the time a phase took over all runs.
+type benchDurations struct {
+	P50  time.Duration `json:"p50_ns"`
+	P95  time.Duration `json:"p95_ns"`
+	Mean time.Duration `json:"mean_ns"`
+}
+
+type benchResult struct {
+	Config string         `json:"config,omitempty"`
+	Runs   int            `json:"runs"`
+	Run    benchDurations `json:"run"`
+	Paint  benchDurations `json:"paint"`
+	Encode benchDurations `json:"encode"`
+	Total  benchDurations `json:"total"`
+	Frames int            `json:"frames"`
+	Bytes  int            `json:"bytes"`
+
+	// PeakHeapBytes is the most heap in use while benchmarking, as
+	// sampled every millisecond. AllocBytes is how much was allocated
+	// per run.
+	PeakHeapBytes uint64 `json:"peak_heap_bytes"`
+	AllocBytes    uint64 `json:"alloc_bytes_per_run"`
+}
+
+func bench(cmd *cobra.Command, args []string) error {
+	path := args[0]
+
+	if benchRuns < 1 {
+		return fmt.Errorf("--runs must be at least 1")
+	}
+
+	config := map[string]string{}
+	if benchConfigFile != "" {
+		var err error
+		config, err = readConfigFile(benchConfigFile)
+		if err != nil {
+			return err
+		}
+	}
+	for _, param := range args[1:] {
+		split := strings.SplitN(param, "=", 2)
+		if len(split) != 2 {
+			return fmt.Errorf("parameters must be on form <key>=<value>, found %s", param)
+		}
+		config[split[0]] = split[1]
+	}
+
+	sets := map[string]map[string]string{"": config}
+	if benchMatrix != "" {
+		matrix, err := readMatrix(benchMatrix)
+		if err != nil {
+			return err
+		}
+
+		sets = map[string]map[string]string{}
+		for name, values := range matrix {
+			set := map[string]string{}
+			for k, v := range config {
+				set[k] = v
+			}
+			for k, v := range values {
+				set[k] = v
+			}
+			sets[name] = set
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	var fsys fs.FS
+	if info.IsDir() {
+		fsys = os.DirFS(path)
+	} else {
+		if !strings.HasSuffix(path, ".star") {
+			return fmt.Errorf("script file must have suffix .star: %s", path)
+		}
+
+		fsys = tools.NewSingleFileFS(path)
+	}
+
+	cache := runtime.NewInMemoryCache()
+	runtime.InitHTTP(cache)
+	runtime.InitCache(cache)
+
+	applet, err := runtime.NewAppletFromFS(path, fsys, runtime.WithPrintDisabled())
+	if err != nil {
+		return fmt.Errorf("failed to load applet: %w", err)
+	}
+
+	names := make([]string, 0, len(sets))
+	for name := range sets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := []benchResult{}
+	for _, name := range names {
+		result, err := benchConfig(applet, sets[name])
+		if err != nil {
+			if name != "" {
+				return fmt.Errorf("config set %s: %w", name, err)
+			}
+			return err
+		}
+		result.Config = name
+		results = append(results, result)
+	}
+
+	if benchJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if benchMatrix != "" {
+		fmt.Fprint(w, "config\t")
+	}
+	fmt.Fprintln(w, "p50\tp95\trun p50\tpaint p50\tencode p50\tframes\tbytes\tpeak heap\talloc/run")
+	for _, r := range results {
+		if benchMatrix != "" {
+			fmt.Fprintf(w, "%s\t", r.Config)
+		}
+		fmt.Fprintf(
+			w, "%s\t%s\t%s\t%s\t%s\t%d\t%d\t%s\t%s\n",
+			r.Total.P50, r.Total.P95,
+			r.Run.P50, r.Paint.P50, r.Encode.P50,
+			r.Frames, r.Bytes,
+			formatBytes(r.PeakHeapBytes), formatBytes(r.AllocBytes),
+		)
+	}
+	return w.Flush()
+}
+
+// benchConfig renders the app with config the configured number of
+// times, and measures each phase.
+func benchConfig(applet *runtime.Applet, config map[string]string) (benchResult, error) {
+	result := benchResult{Runs: benchRuns}
+
+	var runs, paints, encodes, totals []time.Duration
+
+	for i := 0; i < benchWarmup; i++ {
+		if _, _, _, err := benchOnce(applet, config, &result); err != nil {
+			return result, err
+		}
+	}
+
+	// start from a clean heap, so that the peak is this config's
+	goruntime.GC()
+	sampler := startHeapSampler()
+	allocStart := readMetric("/gc/heap/allocs:bytes")
+
+	for i := 0; i < benchRuns; i++ {
+		run, paint, enc, err := benchOnce(applet, config, &result)
+		if err != nil {
+			sampler.stop()
+			return result, err
+		}
+		runs = append(runs, run)
+		paints = append(paints, paint)
+		encodes = append(encodes, enc)
+		totals = append(totals, run+paint+enc)
+	}
+
+	result.AllocBytes = (readMetric("/gc/heap/allocs:bytes") - allocStart) / uint64(benchRuns)
+	result.PeakHeapBytes = sampler.stop()
+
+	result.Run = summarize(runs)
+	result.Paint = summarize(paints)
+	result.Encode = summarize(encodes)
+	result.Total = summarize(totals)
+
+	return result, nil
+}
+
+// benchOnce renders the app once, recording the frame count and size
+// in result, and returns how long each phase took.
+func benchOnce(applet *runtime.Applet, config map[string]string, result *benchResult) (time.Duration, time.Duration, time.Duration, error) {
+	start := time.Now()
+	roots, err := applet.RunWithConfig(context.Background(), config)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("error running script: %w", err)
+	}
+	run := time.Since(start)
+
+	start = time.Now()
+	images := pixlet_render.PaintRoots(true, roots...)
+	paint := time.Since(start)
+
+	screens := encode.ScreensFromImages(images...)
+	if len(roots) > 0 {
+		screens.ShowFullAnimation = roots[0].ShowFullAnimation
+	}
+
+	duration := 15000
+	if screens.ShowFullAnimation {
+		duration = 0
+	}
+
+	start = time.Now()
+	var buf []byte
+	if benchGif {
+		buf, err = screens.EncodeGIF(duration)
+	} else {
+		buf, err = screens.EncodeWebP(duration)
+	}
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("error rendering: %w", err)
+	}
+	enc := time.Since(start)
+
+	// like servers do, so that later runs reuse the frames
+	pixlet_render.ReleaseFrames(images...)
+
+	result.Frames = len(images)
+	result.Bytes = len(buf)
+
+	return run, paint, enc, nil
+}
+
+func summarize(durations []time.Duration) benchDurations {
+	sorted := append([]time.Duration{}, durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var sum time.Duration
+	for _, d := range sorted {
+		sum += d
+	}
+
+	return benchDurations{
+		P50:  percentile(sorted, 0.50),
+		P95:  percentile(sorted, 0.95),
+		Mean: sum / time.Duration(len(sorted)),
+	}
+}
+
+// percentile returns the nearest-rank percentile q of sorted.
+func percentile(sorted []time.Duration, q float64) time.Duration {
+	i := int(math.Ceil(q*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
+
+func formatBytes(b uint64) string {
+	switch {
+	case b >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(b)/(1<<20))
+	case b >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(b)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", b)
+	}
+}
+
+func readMetric(name string) uint64 {
+	sample := []metrics.Sample{{Name: name}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return sample[0].Value.Uint64()
+}
+
+// heapSampler keeps track of the most heap in use, until stopped.
+type heapSampler struct {
+	done chan struct{}
+	wg   sync.WaitGroup
+	peak uint64
+}
+
+func startHeapSampler() *heapSampler {
+	s := &heapSampler{done: make(chan struct{})}
+	s.peak = readMetric("/memory/classes/heap/objects:bytes")
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.done:
+				return
+			case <-ticker.C:
+				if heap := readMetric("/memory/classes/heap/objects:bytes"); heap > s.peak {
+					s.peak = heap
+				}
+			}
+		}
+	}()
+
+	return s
+}
+
+// stop stops sampling and returns the peak.
+func (s *heapSampler) stop() uint64 {
+	close(s.done)
+	s.wg.Wait()
+
+	if heap := readMetric("/memory/classes/heap/objects:bytes"); heap > s.peak {
+		s.peak = heap
+	}
+	return s.peak
+}
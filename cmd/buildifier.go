@@ -29,6 +29,7 @@ import (
 	"github.com/bazelbuild/buildtools/differ"
 	"github.com/bazelbuild/buildtools/warn"
 	"github.com/bazelbuild/buildtools/wspace"
+	"github.com/pmezard/go-difflib/difflib"
 )
 
 var (
@@ -214,6 +215,7 @@ func processFile(filename string, data []byte, lint string, displayFileNames boo
 	}
 	fileDiagnostics := utils.NewFileDiagnostics(f.DisplayPath(), warnings)
 
+	sortLoads(f)
 	ndata := build.Format(f)
 
 	switch mode {
@@ -252,6 +254,31 @@ func processFile(filename string, data []byte, lint string, displayFileNames boo
 			return fileDiagnostics, 4
 		}
 
+	case "unified":
+		// unified mode: print a unified diff of old and new to stdout,
+		// without relying on an external diff program.
+		if bytes.Equal(data, ndata) {
+			return fileDiagnostics, exitCode
+		}
+		name := f.DisplayPath()
+		if filename == "" {
+			name = "stdin"
+		}
+		out, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(string(data)),
+			B:        difflib.SplitLines(string(ndata)),
+			FromFile: "a/" + name,
+			ToFile:   "b/" + name,
+			Context:  3,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "buildifier: %v\n", err)
+			return fileDiagnostics, 3
+		}
+		fmt.Print(out)
+		fileDiagnostics.Formatted = false
+		return fileDiagnostics, 4
+
 	case "pipe":
 		// pipe mode - reading from stdin, writing to stdout.
 		// ("pipe" is not from the command line; it is set above in main.)
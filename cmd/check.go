@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
 	"io/fs"
 	"os"
@@ -10,9 +11,11 @@ import (
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"tidbyt.dev/pixlet/bundle"
 	"tidbyt.dev/pixlet/cmd/community"
 	"tidbyt.dev/pixlet/manifest"
 	"tidbyt.dev/pixlet/tools"
+	"tidbyt.dev/pixlet/tools/lint"
 )
 
 var maxRenderTime = time.Duration(1 * time.Second)
@@ -20,12 +23,14 @@ var maxRenderTime = time.Duration(1 * time.Second)
 func init() {
 	CheckCmd.Flags().BoolVarP(&rflag, "recursive", "r", false, "find apps recursively")
 	CheckCmd.Flags().DurationVarP(&maxRenderTime, "max-render-time", "", maxRenderTime, "override the default max render time")
+	CheckCmd.Flags().BoolVarP(&fixFlag, "fix", "", false, "fix formatting and the problems that don't need a person to look at them")
 }
 
 var CheckCmd = &cobra.Command{
-	Use:     "check <path>...",
-	Example: `pixlet check examples/clock`,
-	Short:   "Check if an app is ready to publish",
+	Use: "check <path>...",
+	Example: `  pixlet check examples/clock
+  pixlet check --fix examples/clock`,
+	Short: "Check if an app is ready to publish",
 	Long: `Check if an app is ready to publish.
 
 The path argument should be the path to the Pixlet app to check. The
@@ -35,7 +40,23 @@ containing multiple Starlark files and resources.
 The check command runs a series of checks to ensure your app is ready
 to publish in the community repo. Every failed check will have a solution
 provided. If your app fails a check, try the provided solution and reach out on
-Discord if you get stuck.`,
+Discord if you get stuck.
+
+The app is validated the way bundles are reviewed for publishing: the
+manifest's fields, the schema's icons, handlers and defaults, the size
+of the app's files, and a dry run render with the default config.
+
+Besides formatting and buildifier's lint warnings, check looks for
+mistakes that Starlark accepts but that break apps or hold up review:
+symbols that are loaded but never used, cache.set and HTTP requests
+without ttl_seconds, deprecated widgets, schema handlers that don't
+exist, and fonts that don't exist.
+
+With --fix, check formats the app's files and fixes the problems that
+don't need a person to look at them, before checking: it removes unused
+loads, caches HTTP requests and cache.set calls for 5 minutes where no
+ttl_seconds is given, and loads modules from their current path. What
+can't be fixed this way is reported as usual.`,
 	Args: cobra.MinimumNArgs(1),
 	RunE: checkCmd,
 }
@@ -64,6 +85,16 @@ func checkCmd(cmd *cobra.Command, args []string) error {
 			baseDir = filepath.Dir(path)
 		}
 
+		// Fix what can be fixed first, since some problems, like a
+		// module loaded from an old path, keep the app from loading.
+		if fixFlag {
+			if err := fixApp(cmd, fsys, baseDir); err != nil {
+				foundIssue = true
+				failure(path, err, "resolve the problem, then try again")
+				continue
+			}
+		}
+
 		// Check if an app can load.
 		err = community.LoadApp(cmd, []string{path})
 		if err != nil {
@@ -72,14 +103,6 @@ func checkCmd(cmd *cobra.Command, args []string) error {
 			continue
 		}
 
-		// Ensure icons are valid.
-		err = community.ValidateIcons(cmd, []string{path})
-		if err != nil {
-			foundIssue = true
-			failure(path, fmt.Errorf("app has invalid icons: %w", err), "try `pixlet community list-icons` for the full list of valid icons")
-			continue
-		}
-
 		// Check app manifest exists
 		if !doesManifestExist(baseDir) {
 			foundIssue = true
@@ -87,13 +110,15 @@ func checkCmd(cmd *cobra.Command, args []string) error {
 			continue
 		}
 
-		// Validate manifest.
-		manifestFile := filepath.Join(baseDir, manifest.ManifestFileName)
-		community.ValidateManifestAppFileName = filepath.Base(path)
-		err = community.ValidateManifest(cmd, []string{manifestFile})
+		// Validate the app like it's reviewed for publishing: the
+		// manifest, schema, file sizes and a dry run render.
+		report, err := validateBundle(cmd, fsys, filepath.Join(baseDir, manifest.ManifestFileName))
 		if err != nil {
+			return err
+		}
+		if err := report.Err(); err != nil {
 			foundIssue = true
-			failure(path, fmt.Errorf("manifest didn't validate: %w", err), "try correcting the validation issue by updating your manifest")
+			failure(path, err, "resolve each of the problems listed. For icons, try `pixlet community list-icons` for the full list of valid icons")
 			continue
 		}
 
@@ -154,6 +179,11 @@ func checkCmd(cmd *cobra.Command, args []string) error {
 				failure(p, fmt.Errorf("app has lint warnings: %w", err), fmt.Sprintf("try `pixlet lint --fix %s`", realPath))
 			}
 
+			if err := checkSemantics(realPath); err != nil {
+				foundIssue = true
+				failure(p, err, fmt.Sprintf("resolve each of the problems listed, they tend to break apps or hold up review. Those marked fixable can be fixed with `pixlet check --fix %s`", path))
+			}
+
 			return nil
 		})
 
@@ -168,6 +198,99 @@ func checkCmd(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// checkSemantics looks for mistakes that Starlark accepts, like unused
+// loads, uncached HTTP requests and fonts that don't exist.
+func checkSemantics(path string) error {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	findings, err := lint.File(path, src)
+	if err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	if len(findings) == 0 {
+		return nil
+	}
+
+	problems := []string{fmt.Sprintf("app has %d problems:", len(findings))}
+	for _, f := range findings {
+		if f.Fixable() {
+			problems = append(problems, f.String()+" [fixable]")
+		} else {
+			problems = append(problems, f.String())
+		}
+	}
+
+	return fmt.Errorf("%s", strings.Join(problems, "\n"))
+}
+
+// fixApp fixes and formats every Starlark file in fsys.
+func fixApp(cmd *cobra.Command, fsys fs.FS, baseDir string) error {
+	return fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() || !strings.HasSuffix(p, ".star") {
+			return nil
+		}
+
+		realPath := filepath.Join(baseDir, p)
+		if err := fixSemantics(realPath); err != nil {
+			return err
+		}
+
+		dryRunFlag = false
+		return formatCmd(cmd, []string{realPath})
+	})
+}
+
+// fixSemantics fixes the problems checkSemantics finds that don't need a
+// person to look at them. The file may need formatting afterwards.
+func fixSemantics(path string) error {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	fixed, _, err := lint.Fix(path, src)
+	if err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	if bytes.Equal(fixed, src) {
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, fixed, info.Mode())
+}
+
+// validateBundle validates the app in fsys as a bundle with the
+// manifest at manifestFile.
+func validateBundle(cmd *cobra.Command, fsys fs.FS, manifestFile string) (*bundle.Report, error) {
+	f, err := os.Open(manifestFile)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't open manifest: %w", err)
+	}
+	defer f.Close()
+
+	m, err := manifest.LoadManifest(f)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't load manifest: %w", err)
+	}
+
+	ab := &bundle.AppBundle{Manifest: m, Source: fsys}
+	return ab.Validate(cmd.Context(), bundle.ValidateOptions{})
+}
+
 func doesManifestExist(dir string) bool {
 	file := filepath.Join(dir, manifest.ManifestFileName)
 	_, err := os.Stat(file)
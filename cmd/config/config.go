@@ -8,6 +8,8 @@ import (
 
 	"github.com/spf13/viper"
 	"golang.org/x/oauth2"
+
+	"tidbyt.dev/pixlet/api"
 )
 
 const (
@@ -18,12 +20,9 @@ var (
 	PrivateConfig = viper.New()
 
 	OAuthConf = &oauth2.Config{
-		ClientID: "d8ae7ea0-4a1a-46b0-b556-6d742687223a",
-		Scopes:   []string{"device", "offline_access", "app-admin"},
-		Endpoint: oauth2.Endpoint{
-			AuthURL:  "https://login.tidbyt.com/oauth2/auth",
-			TokenURL: "https://login.tidbyt.com/oauth2/token",
-		},
+		ClientID:    "d8ae7ea0-4a1a-46b0-b556-6d742687223a",
+		Scopes:      []string{"device", "offline_access", "app-admin"},
+		Endpoint:    api.OAuthEndpoint,
 		RedirectURL: fmt.Sprintf("http://%s", OAuthCallbackAddr),
 	}
 )
@@ -46,14 +45,25 @@ func init() {
 }
 
 func OAuthTokenFromConfig(ctx context.Context) string {
+	token, err := OAuthToken(ctx)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	return token
+}
+
+// OAuthToken returns the API token saved by pixlet login, refreshing it
+// if it has expired. It returns an empty string if not logged in.
+func OAuthToken(ctx context.Context) (string, error) {
 	if !PrivateConfig.IsSet("token") {
-		return ""
+		return "", nil
 	}
 
 	var tok oauth2.Token
 	if err := PrivateConfig.UnmarshalKey("token", &tok); err != nil {
-		fmt.Println("unmarshaling API token from config:", err)
-		os.Exit(1)
+		return "", fmt.Errorf("unmarshaling API token from config: %w", err)
 	}
 
 	if !tok.Valid() {
@@ -61,8 +71,7 @@ func OAuthTokenFromConfig(ctx context.Context) string {
 		ts := OAuthConf.TokenSource(ctx, &tok)
 		refreshed, err := ts.Token()
 		if err != nil {
-			fmt.Println("refreshing API token:", err)
-			os.Exit(1)
+			return "", fmt.Errorf("refreshing API token: %w", err)
 		}
 
 		tok = *refreshed
@@ -70,5 +79,18 @@ func OAuthTokenFromConfig(ctx context.Context) string {
 		PrivateConfig.WriteConfig()
 	}
 
-	return tok.AccessToken
+	return tok.AccessToken, nil
+}
+
+// SelectedDevice returns the device chosen with pixlet devices --select,
+// or an empty string if none was.
+func SelectedDevice() string {
+	return PrivateConfig.GetString("device")
+}
+
+// SelectDevice saves device as the selected device. It may be a device
+// ID, a comma separated list of them, or a @group.
+func SelectDevice(device string) error {
+	PrivateConfig.Set("device", device)
+	return PrivateConfig.WriteConfig()
 }
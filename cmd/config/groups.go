@@ -0,0 +1,95 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// GroupsPath returns the path of the file defining device groups. It
+// maps group names to lists of device IDs:
+//
+//	office:
+//	  - first-device-id
+//	  - second-device-id
+func GroupsPath() (string, error) {
+	ucd, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(ucd, "tidbyt", "groups.yaml"), nil
+}
+
+// DeviceGroups reads the device groups. A missing file just means that
+// no groups are defined.
+func DeviceGroups() (map[string][]string, error) {
+	path, err := GroupsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string][]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading device groups: %w", err)
+	}
+
+	groups := map[string][]string{}
+	if err := yaml.Unmarshal(data, &groups); err != nil {
+		return nil, fmt.Errorf("parsing device groups %s: %w", path, err)
+	}
+
+	return groups, nil
+}
+
+// ResolveDevices expands a comma separated list of device IDs and
+// @group references into device IDs, without duplicates.
+func ResolveDevices(spec string) ([]string, error) {
+	var groups map[string][]string
+
+	seen := map[string]bool{}
+	devices := []string{}
+	add := func(id string) {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			devices = append(devices, id)
+		}
+	}
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if !strings.HasPrefix(part, "@") {
+			add(part)
+			continue
+		}
+
+		if groups == nil {
+			var err error
+			if groups, err = DeviceGroups(); err != nil {
+				return nil, err
+			}
+		}
+
+		members, ok := groups[part[1:]]
+		if !ok {
+			return nil, fmt.Errorf("no device group named %s", part[1:])
+		}
+		for _, id := range members {
+			add(strings.TrimSpace(id))
+		}
+	}
+
+	if len(devices) == 0 {
+		return nil, fmt.Errorf("no devices given")
+	}
+
+	return devices, nil
+}
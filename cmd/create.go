@@ -7,17 +7,37 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
 	"tidbyt.dev/pixlet/cmd/community"
 	"tidbyt.dev/pixlet/tools/generator"
 	"tidbyt.dev/pixlet/tools/repo"
 )
 
+var createTemplate string
+
+func init() {
+	CreateCmd.Flags().StringVarP(&createTemplate, "template", "t", "", "Template to start the app from, instead of picking one")
+}
+
 // CreateCmd prompts the user for info and generates a new app.
 var CreateCmd = &cobra.Command{
 	Use:   "create",
 	Short: "Creates a new app",
-	Long:  `This command will prompt for all of the information we need to generate a new Tidbyt app.`,
+	Example: `  pixlet create
+  pixlet create --template clock`,
+	Long: `This command will prompt for all of the information we need to generate a new Tidbyt app.
+
+Apps start from a template, which you pick from a list or with --template:
+
+  hello      Says hello to whoever is configured
+  api        Shows data from a JSON API, with caching
+  clock      Shows the time in a configured location
+  sports     Shows the scores of today's games in a league
+  slideshow  Shows a series of images from URLs
+
+Every template comes with a schema to configure the app, a manifest, and
+a test file that pixlet test runs.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Get the current working directory.
 		cwd, err := os.Getwd()
@@ -45,6 +65,24 @@ var CreateCmd = &cobra.Command{
 			root = cwd
 		}
 
+		// Check the template before prompting, so a typo doesn't waste the
+		// user's answers.
+		g, err := generator.NewGenerator(appType, root)
+		if err != nil {
+			return fmt.Errorf("app creation failed %w", err)
+		}
+
+		template := createTemplate
+		if template == "" {
+			template, err = templatePrompt()
+			if err != nil {
+				return fmt.Errorf("app creation, couldn't get user input: %w", err)
+			}
+		}
+		if err := g.UseTemplate(template); err != nil {
+			return fmt.Errorf("app creation failed: %w", err)
+		}
+
 		// Prompt the user for input.
 		app, err := community.ManifestPrompt()
 		if err != nil {
@@ -52,10 +90,6 @@ var CreateCmd = &cobra.Command{
 		}
 
 		// Generate app.
-		g, err := generator.NewGenerator(appType, root)
-		if err != nil {
-			return fmt.Errorf("app creation failed %w", err)
-		}
 		absolutePath, err := g.GenerateApp(app)
 		if err != nil {
 			return fmt.Errorf("app creation failed: %w", err)
@@ -76,8 +110,31 @@ var CreateCmd = &cobra.Command{
 		fmt.Println("To start the app, run:")
 		fmt.Printf("\tpixlet serve %s\n", relativePath)
 		fmt.Println("")
+		fmt.Println("To run its tests, run:")
+		fmt.Printf("\tpixlet test %s\n", filepath.Dir(relativePath))
+		fmt.Println("")
 		fmt.Println("For docs, head to:")
 		fmt.Printf("\thttps://tidbyt.dev\n")
 		return nil
 	},
 }
+
+// templatePrompt asks the user which template to start from.
+func templatePrompt() (string, error) {
+	prompt := promptui.Select{
+		Label: "Template (what kind of app is this?)",
+		Items: generator.Templates,
+		Templates: &promptui.SelectTemplates{
+			Active:   "▸ {{ .Name | cyan }} {{ .Desc | faint }}",
+			Inactive: "  {{ .Name }} {{ .Desc | faint }}",
+			Selected: "Template: {{ .Name }}",
+		},
+	}
+
+	i, _, err := prompt.Run()
+	if err != nil {
+		return "", err
+	}
+
+	return generator.Templates[i].Name, nil
+}
@@ -0,0 +1,845 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"maps"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/spf13/cobra"
+	"go.starlark.net/starlark"
+	"golang.org/x/sync/errgroup"
+	"gopkg.in/yaml.v3"
+
+	"tidbyt.dev/pixlet/api"
+	"tidbyt.dev/pixlet/cmd/config"
+	"tidbyt.dev/pixlet/configstore"
+	"tidbyt.dev/pixlet/encode"
+	"tidbyt.dev/pixlet/runtime"
+	"tidbyt.dev/pixlet/scheduler"
+	"tidbyt.dev/pixlet/server"
+	"tidbyt.dev/pixlet/server/webhook"
+	"tidbyt.dev/pixlet/tools"
+	"tidbyt.dev/pixlet/tools/mqtt"
+)
+
+const (
+	// defaultDaemonInterval is how often apps are pushed if their config
+	// doesn't say.
+	defaultDaemonInterval = 15 * time.Minute
+
+	// defaultAlertAfter is how many times in a row an app has to fail
+	// before an alert is sent.
+	defaultAlertAfter = 3
+
+	// defaultMQTTMagnify is how much frames published to MQTT cameras
+	// are scaled up, if the config doesn't say.
+	defaultMQTTMagnify = 10
+)
+
+var daemonOnce bool
+
+func init() {
+	DaemonCmd.Flags().BoolVarP(&daemonOnce, "once", "", false, "Push every app once and exit, instead of running forever")
+	DaemonCmd.Flags().StringVarP(&apiToken, "api-token", "t", "", "Tidbyt API token")
+	DaemonCmd.Flags().IntVarP(&pushRetries, "retries", "", 3, "Number of times to retry pushes that fail for transient reasons")
+	DaemonCmd.Flags().StringVarP(&queueDir, "queue-dir", "", "", "Queue pushes that keep failing in this directory, and retry them on the next push")
+	DaemonCmd.Flags().BoolVarP(&pushLocal, "local", "l", false, "Push straight to devices on the local network, instead of through the API")
+	DaemonCmd.Flags().StringVarP(&localURL, "local-url", "", "", "With --local, push to the server at this URL instead of discovering devices")
+	DaemonCmd.Flags().BoolVarP(&localFallback, "local-fallback", "", false, "Push to devices on the local network when pushing through the API fails")
+	addRateLimitFlags(DaemonCmd)
+	addSecretsFlag(DaemonCmd)
+}
+
+var DaemonCmd = &cobra.Command{
+	Use:   "daemon <config>",
+	Short: "Keep apps on your devices up to date",
+	Example: `  pixlet daemon daemon.yaml
+  pixlet daemon --once daemon.yaml`,
+	Long: `Keep apps on your devices up to date.
+
+The daemon renders apps and pushes them to devices, again every
+interval and whenever an app's files change, until it's stopped. It's
+configured with a YAML file:
+
+  jitter: 30s
+  concurrency: 4
+  config_store: sqlite:config.db
+  webhooks:
+    listen: 127.0.0.1:8090
+    secret: a-long-random-string
+  mqtt:
+    broker: tcp://homeassistant.local:1883
+    username: pixlet
+  alerts:
+    after: 3
+    command: notify-send "pixlet" "$PIXLET_APP is $PIXLET_EVENT"
+    webhook: https://example.com/hooks/pixlet
+  apps:
+    - path: apps/clock
+      devices: "@home"
+      installation_id: clock
+      interval: 1m
+      config:
+        timezone: Europe/Oslo
+    - path: apps/weather.star
+      devices: first-device-id,second-device-id
+      installation_id: weather
+      background: true
+      schedule: "*/10 6-22 * * *"
+      config_file: weather.yaml
+    - path: apps/stocks
+      output_dir: images
+
+Paths are relative to the config file. Apps are named after their
+path in logs and alerts, unless they're given a name. Devices are given as for pixlet
+push, as IDs or @groups. The interval defaults to 15m. Instead of an
+interval, apps can be given a cron schedule, like "0 7 * * mon-fri",
+in the local time zone unless it starts with another, like
+"TZ=Europe/Oslo 0 7 * * *". Each push is delayed by a random amount up
+to jitter, so that apps don't all push at once, and at most concurrency
+apps render at once. Images that haven't changed since the last push
+aren't pushed again, and apps that return no roots aren't pushed at
+all. With output_dir, the image is also written to <name>.webp in that
+directory, and the app doesn't need any devices.
+
+When a push can't start on time, like after the computer was asleep,
+it happens as soon as it can. Set missed_runs to skip to wait for the
+next one instead.
+
+When an app fails to render or push as many times in a row as alerts
+after, which defaults to 3, the alert command is run and the webhook
+is sent a POST. Both happen again when the app recovers. The command is
+run by sh, with $PIXLET_APP, $PIXLET_EVENT (failing or recovered),
+$PIXLET_ERROR and $PIXLET_FAILURES set. The webhook gets the same as
+JSON, like {"app": "clock", "event": "failing", "error": "...",
+"failures": 3}.
+
+Pushes are retried and queued as with pixlet push, and --local and
+--local-fallback work the same way too.
+
+With mqtt, every app is also published to the MQTT broker, and
+announced to Home Assistant with MQTT discovery. Each app becomes an
+image entity showing its animation, and a camera showing a frame
+scaled up by mqtt magnify, which defaults to 10. Apps don't need any
+devices then. The password can also be given in $PIXLET_MQTT_PASSWORD.
+Set client_id to tell several daemons sharing a broker apart, and
+topic_prefix and discovery_prefix to publish images under another
+topic than pixlet, or discovery messages under another than
+homeassistant.
+
+With webhooks, the daemon listens for POSTs to /apps/<name>, and
+renders and pushes that app right away, for apps that show events like
+a doorbell ringing or a build failing. The request's query parameters
+and its JSON or form body are merged into the app's config for that
+render only. Add push=false to the query to render without pushing.
+The response is JSON, like {"app": "clock", "pushed": true, "image":
+"..."}, with the image base64 encoded. If a secret is set, requests
+must pass it as a bearer token or in the secret query parameter, or
+sign their body with it the way GitHub does. Webhooks aren't served
+with --once.
+
+With config_store, config is also kept in a database, over the config
+in this file, and read again before every push, so it can be changed
+without restarting the daemon. Use sqlite:PATH to share the database
+with pixlet serve --config-store, or bolt:PATH for a database only the
+daemon uses. Each app's config is kept under its name and installation
+ID, or "default" if it has none, which is where pixlet serve keeps it.
+With webhooks too, GET /apps/<name>/config returns an app's config, and
+PUT replaces what's stored with the request's JSON or form body, and
+pushes the app again.`,
+	Args: cobra.ExactArgs(1),
+	RunE: daemon,
+}
+
+// daemonConfig is the file that configures the daemon.
+type daemonConfig struct {
+	Jitter      time.Duration  `yaml:"jitter"`
+	Concurrency int            `yaml:"concurrency"`
+	MissedRuns  string         `yaml:"missed_runs"`
+	ConfigStore string         `yaml:"config_store"`
+	Webhooks    daemonWebhooks `yaml:"webhooks"`
+	MQTT        daemonMQTT     `yaml:"mqtt"`
+	Alerts      daemonAlerts   `yaml:"alerts"`
+	Apps        []daemonApp    `yaml:"apps"`
+}
+
+type daemonMQTT struct {
+	Broker          string `yaml:"broker"`
+	Username        string `yaml:"username"`
+	Password        string `yaml:"password"`
+	ClientID        string `yaml:"client_id"`
+	TopicPrefix     string `yaml:"topic_prefix"`
+	DiscoveryPrefix string `yaml:"discovery_prefix"`
+	Magnify         int    `yaml:"magnify"`
+}
+
+type daemonWebhooks struct {
+	Listen string `yaml:"listen"`
+	Secret string `yaml:"secret"`
+}
+
+type daemonAlerts struct {
+	After   int    `yaml:"after"`
+	Command string `yaml:"command"`
+	Webhook string `yaml:"webhook"`
+}
+
+type daemonApp struct {
+	Name           string                 `yaml:"name"`
+	Path           string                 `yaml:"path"`
+	Devices        string                 `yaml:"devices"`
+	InstallationID string                 `yaml:"installation_id"`
+	Background     bool                   `yaml:"background"`
+	Interval       time.Duration          `yaml:"interval"`
+	Schedule       string                 `yaml:"schedule"`
+	OutputDir      string                 `yaml:"output_dir"`
+	Config         map[string]interface{} `yaml:"config"`
+	ConfigFile     string                 `yaml:"config_file"`
+}
+
+// daemonAlert is sent to the alert webhook.
+type daemonAlert struct {
+	App      string `json:"app"`
+	Event    string `json:"event"`
+	Error    string `json:"error,omitempty"`
+	Failures int    `json:"failures"`
+}
+
+// daemonTriggerResult is sent in response to webhooks.
+type daemonTriggerResult struct {
+	App    string `json:"app"`
+	Pushed bool   `json:"pushed"`
+	Image  []byte `json:"image,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// daemonJob keeps one app up to date.
+type daemonJob struct {
+	name      string
+	path      string
+	devices   []string
+	outputDir string
+	config    map[string]string
+	app       daemonApp
+	schedule  scheduler.Schedule
+	alerts    daemonAlerts
+	limits    runtime.Limits
+	sched     *scheduler.Scheduler
+	store     configstore.Store
+
+	// sentTo says where the app is sent, in logs
+	sentTo []string
+
+	mu       sync.Mutex
+	failures int
+}
+
+func daemon(cmd *cobra.Command, args []string) error {
+	jobs, c, err := readDaemonConfig(args[0])
+	if err != nil {
+		return err
+	}
+
+	if localURL != "" && !localFallback {
+		pushLocal = true
+	}
+
+	pushing := false
+	for _, j := range jobs {
+		pushing = pushing || len(j.devices) > 0
+	}
+
+	switch {
+	case !pushing:
+		// apps that are only published to MQTT or written to files
+		// don't need a token
+	case pushLocal:
+		resolveLocalPushToken(cmd)
+	default:
+		if err := resolveAPIToken(cmd); err != nil {
+			return err
+		}
+	}
+
+	var publisher *mqtt.Publisher
+	if c.MQTT.Broker != "" {
+		if c.MQTT.Password == "" {
+			c.MQTT.Password = os.Getenv("PIXLET_MQTT_PASSWORD")
+		}
+		if c.MQTT.Magnify <= 0 {
+			c.MQTT.Magnify = defaultMQTTMagnify
+		}
+
+		publisher, err = mqtt.Connect(mqtt.Config{
+			Broker:          c.MQTT.Broker,
+			Username:        c.MQTT.Username,
+			Password:        c.MQTT.Password,
+			ClientID:        c.MQTT.ClientID,
+			TopicPrefix:     c.MQTT.TopicPrefix,
+			DiscoveryPrefix: c.MQTT.DiscoveryPrefix,
+			Version:         Version,
+
+			// entities would go unavailable as soon as --once is done
+			SkipAvailability: daemonOnce,
+		})
+		if err != nil {
+			return err
+		}
+		defer publisher.Close()
+	}
+
+	limits, err := appLimits()
+	if err != nil {
+		return err
+	}
+
+	if err := initRateLimit(); err != nil {
+		return err
+	}
+
+	if err := initSecrets(); err != nil {
+		return err
+	}
+
+	stopTracing, err := initTracing(cmd)
+	if err != nil {
+		return err
+	}
+	defer stopTracing()
+
+	cache := runtime.NewInMemoryCache()
+	runtime.InitHTTP(cache)
+	runtime.InitCache(cache)
+
+	missed, err := scheduler.ParseMissedRunPolicy(c.MissedRuns)
+	if err != nil {
+		return err
+	}
+
+	var store configstore.Store
+	if c.ConfigStore != "" {
+		if store, err = configstore.Open(c.ConfigStore); err != nil {
+			return err
+		}
+		defer store.Close()
+	}
+
+	byName := map[string]*daemonJob{}
+	s := &scheduler.Scheduler{
+		Jitter:        c.Jitter,
+		MaxConcurrent: c.Concurrency,
+		Missed:        missed,
+		RunAtStart:    true,
+		MaxDuration:   maxDuration,
+		OnResult: func(res scheduler.Result) {
+			byName[res.Job].report(res)
+		},
+	}
+	for _, j := range jobs {
+		j.limits = limits
+		j.sched = s
+		j.store = store
+		byName[j.name] = j
+		if err := s.Add(j.job(publisher, c.MQTT.Magnify)); err != nil {
+			return err
+		}
+	}
+
+	if daemonOnce {
+		return s.RunAll(cmd.Context())
+	}
+
+	g := errgroup.Group{}
+	if c.Webhooks.Listen != "" {
+		h := daemonWebhookHandler(jobs, c.Webhooks.Secret, store != nil)
+		g.Go(func() error {
+			log.Printf("listening for webhooks at http://%s/apps/<name>", c.Webhooks.Listen)
+			return http.ListenAndServe(c.Webhooks.Listen, h)
+		})
+	}
+	for _, j := range jobs {
+		j := j
+		g.Go(func() error {
+			return j.watch(cmd.Context())
+		})
+	}
+	g.Go(func() error {
+		return s.Run(cmd.Context())
+	})
+
+	return g.Wait()
+}
+
+// readDaemonConfig reads the config file at path, and returns a job for
+// each app in it, along with the rest of the config.
+func readDaemonConfig(path string) ([]*daemonJob, *daemonConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading daemon config: %w", err)
+	}
+
+	var c daemonConfig
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, nil, fmt.Errorf("parsing daemon config %s: %w", path, err)
+	}
+
+	if len(c.Apps) == 0 {
+		return nil, nil, fmt.Errorf("daemon config %s has no apps", path)
+	}
+	if c.Alerts.After <= 0 {
+		c.Alerts.After = defaultAlertAfter
+	}
+
+	dir := filepath.Dir(path)
+	names := map[string]bool{}
+
+	if kind, storePath, ok := strings.Cut(c.ConfigStore, ":"); ok && storePath != "" && !filepath.IsAbs(storePath) {
+		c.ConfigStore = kind + ":" + filepath.Join(dir, storePath)
+	}
+
+	var jobs []*daemonJob
+	for i, app := range c.Apps {
+		if app.Path == "" {
+			return nil, nil, fmt.Errorf("app %d has no path", i+1)
+		}
+		appPath := app.Path
+		if !filepath.IsAbs(appPath) {
+			appPath = filepath.Join(dir, appPath)
+		}
+
+		name := app.Name
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(appPath), ".star")
+		}
+		if names[name] {
+			return nil, nil, fmt.Errorf("there's more than one app named %s, give them names", name)
+		}
+		names[name] = true
+
+		outputDir := app.OutputDir
+		if outputDir != "" && !filepath.IsAbs(outputDir) {
+			outputDir = filepath.Join(dir, outputDir)
+		}
+
+		var devices []string
+		if app.Devices != "" {
+			devices, err = config.ResolveDevices(app.Devices)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", name, err)
+			}
+		} else if c.MQTT.Broker == "" && outputDir == "" {
+			return nil, nil, fmt.Errorf("%s: no devices to push to", name)
+		}
+
+		appConfig := map[string]string{}
+		if app.ConfigFile != "" {
+			configPath := app.ConfigFile
+			if !filepath.IsAbs(configPath) {
+				configPath = filepath.Join(dir, configPath)
+			}
+			if appConfig, err = readConfigFile(configPath); err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		values, err := configValues(app.Config)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", name, err)
+		}
+		for k, v := range values {
+			appConfig[k] = v
+		}
+
+		var schedule scheduler.Schedule
+		switch {
+		case app.Schedule != "" && app.Interval > 0:
+			return nil, nil, fmt.Errorf("%s: give an interval or a schedule, not both", name)
+		case app.Schedule != "":
+			if schedule, err = scheduler.ParseSchedule(app.Schedule); err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", name, err)
+			}
+		case app.Interval > 0:
+			schedule = scheduler.Every(app.Interval)
+		default:
+			schedule = scheduler.Every(defaultDaemonInterval)
+		}
+
+		jobs = append(jobs, &daemonJob{
+			name:      name,
+			path:      appPath,
+			devices:   devices,
+			outputDir: outputDir,
+			config:    appConfig,
+			app:       app,
+			schedule:  schedule,
+			alerts:    c.Alerts,
+		})
+	}
+
+	return jobs, &c, nil
+}
+
+// job returns the scheduler job for the app, which sends it to MQTT if
+// publisher isn't nil, to its output directory and to its devices.
+func (j *daemonJob) job(publisher *mqtt.Publisher, magnify int) *scheduler.Job {
+	var sinks []scheduler.Sink
+	j.sentTo = nil
+
+	if publisher != nil {
+		sinks = append(sinks, &scheduler.MQTTSink{Publisher: publisher, Magnify: magnify})
+		j.sentTo = append(j.sentTo, "MQTT")
+	}
+	if j.outputDir != "" {
+		sinks = append(sinks, &scheduler.FileSink{Dir: j.outputDir})
+		j.sentTo = append(j.sentTo, j.outputDir)
+	}
+	if len(j.devices) > 0 {
+		sinks = append(sinks, scheduler.SinkFunc(j.push))
+		j.sentTo = append(j.sentTo, j.devices...)
+	}
+
+	render := func(ctx context.Context) (*encode.Screens, error) {
+		return j.render(ctx, j.config)
+	}
+	if j.store != nil {
+		render = scheduler.StoredConfig(j.store, j.configKey(), j.config, j.render)
+	}
+
+	return &scheduler.Job{
+		ID:       j.name,
+		Schedule: j.schedule,
+		Render:   render,
+		Sinks:    sinks,
+	}
+}
+
+// configKey is where the app's config is kept in the config store.
+func (j *daemonJob) configKey() configstore.Key {
+	installation := j.app.InstallationID
+	if installation == "" {
+		installation = configstore.DefaultInstallation
+	}
+	return configstore.Key{App: j.name, Installation: installation}
+}
+
+// currentConfig returns the app's config, with what's kept in the config
+// store, if there is one, over what's in the config file.
+func (j *daemonJob) currentConfig(ctx context.Context) (map[string]string, error) {
+	if j.store == nil {
+		return j.config, nil
+	}
+	return configstore.Load(ctx, j.store, j.configKey(), j.config)
+}
+
+// watch updates the app when its files change, until ctx is done.
+func (j *daemonJob) watch(ctx context.Context) error {
+	changes := make(chan bool, 100)
+	go func() {
+		if err := server.NewWatcher(j.path, changes).Run(); err != nil {
+			log.Printf("[%s] not watching for changes: %v", j.name, err)
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-changes:
+			// editors often save in several steps, so let them finish
+			time.Sleep(100 * time.Millisecond)
+			for len(changes) > 0 {
+				<-changes
+			}
+			log.Printf("[%s] changed", j.name)
+			j.sched.Trigger(j.name)
+		}
+	}
+}
+
+// report logs what happened when the app ran, and keeps track of
+// failures.
+func (j *daemonJob) report(res scheduler.Result) {
+	switch {
+	case res.Missed:
+		// missing a run isn't the app failing
+		log.Printf("[%s] %v", j.name, res.Err)
+		return
+	case res.Err != nil:
+	case res.Skipped:
+		log.Printf("[%s] returned no roots, not pushing", j.name)
+	case res.Unchanged:
+		log.Printf("[%s] unchanged, not pushing", j.name)
+	case len(j.sentTo) > 0:
+		log.Printf("[%s] sent to %s", j.name, strings.Join(j.sentTo, ", "))
+	}
+
+	// failures are reported and alerted on, and the daemon keeps going
+	j.track(res.Err)
+}
+
+// track keeps track of failures, and alerts when the app starts failing
+// or recovers. It returns err.
+func (j *daemonJob) track(err error) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err != nil {
+		j.failures++
+		log.Printf("[%s] failed: %v", j.name, err)
+
+		if j.failures == j.alerts.After {
+			j.alert("failing", err)
+		}
+		return err
+	}
+
+	if j.failures >= j.alerts.After {
+		log.Printf("[%s] recovered after %d failures", j.name, j.failures)
+		j.alert("recovered", nil)
+	}
+	j.failures = 0
+
+	return nil
+}
+
+// trigger renders the app with config from a webhook merged into its
+// own, and sends it like a scheduled update if push is set.
+func (j *daemonJob) trigger(ctx context.Context, config map[string]string, push bool) daemonTriggerResult {
+	res := daemonTriggerResult{App: j.name}
+
+	merged, err := j.currentConfig(ctx)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	merged = maps.Clone(merged)
+	for k, v := range config {
+		merged[k] = v
+	}
+
+	screens, err := j.render(ctx, merged)
+	switch {
+	case err != nil && push:
+		j.track(err)
+	case err != nil || screens == nil:
+	case push:
+		r := j.sched.Dispatch(ctx, j.name, screens)
+		res.Image, res.Pushed, err = r.Image, r.Sent, r.Err
+	default:
+		res.Image, err = j.sched.Encode(screens)
+	}
+	if err != nil {
+		res.Error = err.Error()
+	}
+
+	return res
+}
+
+// push pushes the output to the app's devices.
+func (j *daemonJob) push(ctx context.Context, out *scheduler.Output) error {
+	var failed []string
+	for _, id := range j.devices {
+		err := sendPush(&api.Push{
+			DeviceID:       id,
+			Image:          out.WebP,
+			InstallationID: j.app.InstallationID,
+			Background:     j.app.Background,
+		})
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", id, err))
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("pushing failed for %d of %d devices: %s", len(failed), len(j.devices), strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// render runs the app with config. The screens are nil if the app
+// returned no roots.
+func (j *daemonJob) render(ctx context.Context, config map[string]string) (*encode.Screens, error) {
+	info, err := os.Stat(j.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", j.path, err)
+	}
+
+	var fsys fs.FS
+	if info.IsDir() {
+		fsys = os.DirFS(j.path)
+	} else {
+		fsys = tools.NewSingleFileFS(j.path)
+	}
+
+	applet, err := runtime.NewAppletFromFS(
+		j.name,
+		fsys,
+		runtime.WithLimits(j.limits),
+		runtime.WithPrintFunc(func(thread *starlark.Thread, msg string) {
+			log.Printf("[%s] %s", j.name, msg)
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load applet: %w", err)
+	}
+
+	roots, err := applet.RunWithConfig(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("error running script: %w", err)
+	}
+	if len(roots) == 0 {
+		return nil, nil
+	}
+
+	return encode.ScreensFromRoots(roots), nil
+}
+
+// alert runs the alert command and sends the webhook, if they're
+// configured. Problems with alerting are only logged.
+func (j *daemonJob) alert(event string, err error) {
+	a := daemonAlert{App: j.name, Event: event, Failures: j.failures}
+	if err != nil {
+		a.Error = err.Error()
+	}
+
+	if j.alerts.Command != "" {
+		c := exec.Command("sh", "-c", j.alerts.Command)
+		c.Env = append(os.Environ(),
+			"PIXLET_APP="+a.App,
+			"PIXLET_EVENT="+a.Event,
+			"PIXLET_ERROR="+a.Error,
+			fmt.Sprintf("PIXLET_FAILURES=%d", a.Failures),
+		)
+		c.Stdout = os.Stdout
+		c.Stderr = os.Stderr
+		if err := c.Run(); err != nil {
+			log.Printf("[%s] alert command failed: %v", j.name, err)
+		}
+	}
+
+	if j.alerts.Webhook != "" {
+		body, _ := json.Marshal(a)
+		resp, err := http.Post(j.alerts.Webhook, "application/json", bytes.NewReader(body))
+		if err != nil {
+			log.Printf("[%s] alert webhook failed: %v", j.name, err)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			log.Printf("[%s] alert webhook returned %s", j.name, resp.Status)
+		}
+	}
+}
+
+// daemonWebhookHandler serves webhooks that trigger jobs, and if the
+// daemon has a config store, reads and replaces the config of apps. If
+// secret isn't empty, requests must prove they know it.
+func daemonWebhookHandler(jobs []*daemonJob, secret string, stored bool) http.Handler {
+	byName := map[string]*daemonJob{}
+	for _, j := range jobs {
+		byName[j.name] = j
+	}
+
+	// withJob checks requests are authorized, and passes handlers the
+	// job they're for and the request body
+	withJob := func(handle func(w http.ResponseWriter, r *http.Request, j *daemonJob, body []byte)) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(io.LimitReader(r.Body, webhook.MaxBodySize))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			if secret != "" && !webhook.Authorized(r, body, secret) {
+				http.Error(w, "bad or missing secret", http.StatusUnauthorized)
+				return
+			}
+
+			j, ok := byName[mux.Vars(r)["name"]]
+			if !ok {
+				http.Error(w, "no such app", http.StatusNotFound)
+				return
+			}
+
+			handle(w, r, j, body)
+		}
+	}
+
+	r := mux.NewRouter()
+	r.HandleFunc("/apps/{name}", withJob(func(w http.ResponseWriter, r *http.Request, j *daemonJob, body []byte) {
+		config, err := webhookConfig(r, body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		log.Printf("[%s] triggered by a webhook", j.name)
+		res := j.trigger(r.Context(), config, r.URL.Query().Get("push") != "false")
+
+		w.Header().Set("Content-Type", "application/json")
+		if res.Error != "" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		json.NewEncoder(w).Encode(res)
+	})).Methods("POST")
+
+	if !stored {
+		return r
+	}
+
+	r.HandleFunc("/apps/{name}/config", withJob(func(w http.ResponseWriter, r *http.Request, j *daemonJob, body []byte) {
+		config, err := j.currentConfig(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(config)
+	})).Methods("GET")
+
+	r.HandleFunc("/apps/{name}/config", withJob(func(w http.ResponseWriter, r *http.Request, j *daemonJob, body []byte) {
+		config, err := webhookConfig(r, body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := j.store.Put(r.Context(), j.configKey(), config); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// show the new config right away
+		log.Printf("[%s] config changed by a webhook", j.name)
+		j.sched.Trigger(j.name)
+		w.WriteHeader(http.StatusNoContent)
+	})).Methods("PUT")
+
+	return r
+}
+
+// webhookConfig returns the config values in a webhook request, as
+// read by webhook.Values. The parameters that control the webhook
+// itself are left out.
+func webhookConfig(r *http.Request, body []byte) (map[string]string, error) {
+	config, err := webhook.Values(r, body)
+	if err != nil {
+		return nil, err
+	}
+	if r.URL.Query().Has("push") {
+		maps.DeleteFunc(config, func(k, _ string) bool { return k == "push" })
+	}
+	return config, nil
+}
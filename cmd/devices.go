@@ -1,67 +1,135 @@
 package cmd
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io/ioutil"
-	"net/http"
+	"net"
 	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
+	"tidbyt.dev/pixlet/api"
 	"tidbyt.dev/pixlet/cmd/config"
+	"tidbyt.dev/pixlet/tools/mdns"
 )
 
 const (
-	TidbytAPIListDevices = "https://api.tidbyt.com/v0/devices"
+	TidbytAPIListDevices = api.DefaultBaseURL + "/v0/devices"
+	TidbytMDNSService    = "_tidbyt._tcp"
 )
 
+var (
+	discoverLocal    bool
+	discoverTimeout  time.Duration
+	discoverServices []string
+	selectDevice     string
+)
+
+func init() {
+	DevicesCmd.Flags().BoolVarP(&discoverLocal, "local", "l", false, "Discover devices on the local network instead")
+	DevicesCmd.Flags().DurationVarP(&discoverTimeout, "timeout", "", 3*time.Second, "How long to wait for devices to answer with --local")
+	DevicesCmd.Flags().StringSliceVarP(&discoverServices, "service", "", []string{TidbytMDNSService}, "mDNS service types to look for with --local")
+	DevicesCmd.Flags().StringVarP(&selectDevice, "select", "", "", "Select a device ID or @group for plugins to use")
+}
+
 var DevicesCmd = &cobra.Command{
 	Use:   "devices",
 	Short: "List devices in your Tidbyt account",
 	Run:   devices,
+	Long: `List devices in your Tidbyt account.
+
+With --local, devices are instead discovered on the local network using
+mDNS, without logging in. Tidbyts and compatible DIY devices that
+advertise the _tidbyt._tcp service are listed with their ID, if they
+publish one as "id" in their TXT record, and their address.
+
+With --select, the given device ID or @group is saved as the selected
+device, which is passed to plugins in $TIDBYT_DEVICE_ID.`,
 }
 
 func devices(cmd *cobra.Command, args []string) {
+	if selectDevice != "" {
+		if _, err := config.ResolveDevices(selectDevice); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if err := config.SelectDevice(selectDevice); err != nil {
+			fmt.Println("saving selected device:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("selected %s\n", selectDevice)
+		return
+	}
+
+	if discoverLocal {
+		if err := discoverDevices(cmd.Context()); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	apiToken = config.OAuthTokenFromConfig(cmd.Context())
 	if apiToken == "" {
 		fmt.Println("login with `pixlet login`")
 		os.Exit(1)
 	}
 
-	client := &http.Client{}
-	req, err := http.NewRequest("GET", TidbytAPIListDevices, nil)
+	devices, err := api.NewClient(apiToken).Devices(cmd.Context())
 	if err != nil {
-		fmt.Printf("creating GET request: %v\n", err)
+		fmt.Printf("listing devices from API: %v\n", err)
 		os.Exit(1)
 	}
 
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", apiToken))
-
-	resp, err := client.Do(req)
-	if err != nil {
-		fmt.Printf("listing devices from API: %v\n", err)
-		os.Exit(1)
+	for _, d := range devices {
+		fmt.Printf("%s (%s)\n", d.ID, d.DisplayName)
 	}
+}
 
-	if resp.StatusCode != 200 {
-		fmt.Printf("Tidbyt API returned status %s\n", resp.Status)
-		body, _ := ioutil.ReadAll(resp.Body)
-		fmt.Println(string(body))
-		os.Exit(1)
+func discoverDevices(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, discoverTimeout)
+	defer cancel()
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 2, ' ', 0)
+	defer w.Flush()
+
+	found := 0
+	for _, service := range discoverServices {
+		services, err := mdns.Browse(ctx, service)
+		if err != nil {
+			return fmt.Errorf("discovering %s: %w", service, err)
+		}
+
+		for _, s := range services {
+			id := s.Text["id"]
+			if id == "" {
+				id = "-"
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\n", id, s.Instance, serviceAddr(s))
+			found++
+		}
 	}
 
-	body := struct {
-		Devices []struct {
-			ID          string `json:"id"`
-			DisplayName string `json:"displayName"`
-		} `json:"devices"`
-	}{}
-	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
-		fmt.Println("decoding API response:", err)
-		os.Exit(1)
+	if found == 0 {
+		return fmt.Errorf("no devices found on the local network")
 	}
 
-	for _, d := range body.Devices {
-		fmt.Printf("%s (%s)\n", d.ID, d.DisplayName)
+	return nil
+}
+
+// serviceAddr returns the address to reach a discovered service at.
+func serviceAddr(s mdns.Service) string {
+	addr := s.Host
+	if len(s.Addrs) > 0 {
+		addr = s.Addrs[0].String()
+	}
+	if s.Port != 0 {
+		addr = net.JoinHostPort(addr, strconv.Itoa(s.Port))
 	}
+
+	return addr
 }
@@ -0,0 +1,277 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"net/http"
+	"os"
+	goruntime "runtime"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"golang.org/x/oauth2"
+
+	"tidbyt.dev/pixlet/api"
+	"tidbyt.dev/pixlet/cmd/config"
+	"tidbyt.dev/pixlet/encode"
+	pixlet_render "tidbyt.dev/pixlet/render"
+	"tidbyt.dev/pixlet/tools/mdns"
+)
+
+// maxClockSkew is how far the local clock may be from the API's before
+// doctor complains. Tokens and cache TTLs start going wrong past this.
+const maxClockSkew = time.Minute
+
+var doctorTimeout time.Duration
+
+func init() {
+	DoctorCmd.Flags().DurationVarP(&doctorTimeout, "timeout", "", 5*time.Second, "How long to wait for the network and devices in each check")
+}
+
+var DoctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check your environment for common problems",
+	Long: `Check your environment for common problems.
+
+Doctor checks that pixlet can encode images and load its fonts, that
+the Tidbyt API can be reached and your API token is valid, that your
+devices are in your account and can be found on the local network, and
+that your clock is set correctly. Each problem is printed with how to
+fix it.`,
+	Args: cobra.NoArgs,
+	RunE: doctor,
+}
+
+// doctorCheck is one of the checks doctor runs. It returns a description
+// of what it found, or an error and how to fix it.
+type doctorCheck struct {
+	name string
+	run  func(ctx context.Context) (string, error, string)
+}
+
+// doctorNotice is returned by checks that found nothing wrong, but
+// something worth knowing.
+type doctorNotice struct {
+	msg string
+}
+
+func (n doctorNotice) Error() string {
+	return n.msg
+}
+
+func doctor(cmd *cobra.Command, args []string) error {
+	fmt.Printf("Pixlet version: %s (%s/%s, %s)\n\n", Version, goruntime.GOOS, goruntime.GOARCH, goruntime.Version())
+
+	// later checks need the token found by an earlier one
+	var token string
+
+	checks := []doctorCheck{
+		{"WebP encoding", checkWebP},
+		{"Fonts", checkFonts},
+		{"API token", func(ctx context.Context) (string, error, string) {
+			var detail, fix string
+			var err error
+			token, detail, err, fix = checkAPIToken(ctx)
+			return detail, err, fix
+		}},
+		{"Devices", func(ctx context.Context) (string, error, string) {
+			return checkDevices(ctx, token)
+		}},
+		{"Clock", checkClock},
+	}
+
+	failed := 0
+	for _, c := range checks {
+		ctx, cancel := context.WithTimeout(cmd.Context(), doctorTimeout)
+		detail, err, fix := c.run(ctx)
+		cancel()
+
+		if notice, ok := err.(doctorNotice); ok {
+			color.New(color.FgYellow).Printf("• %s: %s\n", c.name, notice.msg)
+			if fix != "" {
+				fmt.Printf("  ▪️ Solution: %v\n", fix)
+			}
+			continue
+		}
+
+		if err != nil {
+			failure(c.name, err, fix)
+			failed++
+			continue
+		}
+
+		success(fmt.Sprintf("%s: %s", c.name, detail))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks found problems", failed, len(checks))
+	}
+
+	return nil
+}
+
+func checkWebP(ctx context.Context) (string, error, string) {
+	fix := "pixlet needs libwebp to encode images. Install it (brew install webp, or apt install libwebp-dev) and reinstall pixlet"
+
+	var buf []byte
+	var err error
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("encoder crashed: %v", r)
+			}
+		}()
+		buf, err = encode.ScreensFromImages(image.NewRGBA(image.Rect(0, 0, 64, 32))).EncodeWebP(0)
+	}()
+	if err != nil {
+		return "", err, fix
+	}
+
+	if !bytes.HasPrefix(buf, []byte("RIFF")) {
+		return "", fmt.Errorf("encoded image is not a WebP"), fix
+	}
+
+	return "libwebp works", nil, ""
+}
+
+func checkFonts(ctx context.Context) (string, error, string) {
+	fix := "the fonts built into pixlet are broken. Reinstall pixlet, or if you built it yourself, run go generate ./render and build again"
+
+	names := pixlet_render.GetFontList()
+	if len(names) == 0 {
+		return "", fmt.Errorf("no fonts are built in"), fix
+	}
+
+	for _, name := range names {
+		if _, err := pixlet_render.GetFont(name); err != nil {
+			return "", err, fix
+		}
+	}
+
+	if _, err := pixlet_render.GetFont(pixlet_render.DefaultFontFace); err != nil {
+		return "", fmt.Errorf("default font %s is missing", pixlet_render.DefaultFontFace), fix
+	}
+
+	return fmt.Sprintf("%d fonts load", len(names)), nil, ""
+}
+
+// checkAPIToken finds the API token the same way push does, and checks
+// that the API accepts it.
+func checkAPIToken(ctx context.Context) (string, string, error, string) {
+	token := os.Getenv(APITokenEnv)
+	source := fmt.Sprintf("$%s", APITokenEnv)
+
+	if token == "" {
+		if !config.PrivateConfig.IsSet("token") {
+			return "", "", doctorNotice{"not logged in, so pushing needs --api-token"}, "run pixlet login"
+		}
+
+		var tok oauth2.Token
+		if err := config.PrivateConfig.UnmarshalKey("token", &tok); err != nil {
+			return "", "", fmt.Errorf("reading token from config: %w", err), "run pixlet login again"
+		}
+
+		if !tok.Valid() {
+			refreshed, err := config.OAuthConf.TokenSource(ctx, &tok).Token()
+			if err != nil {
+				return "", "", fmt.Errorf("token expired, and refreshing it failed: %w", err), "run pixlet login again"
+			}
+			tok = *refreshed
+			config.PrivateConfig.Set("token", tok)
+			config.PrivateConfig.WriteConfig()
+		}
+
+		token = tok.AccessToken
+		source = "pixlet login"
+	}
+
+	_, err := api.NewClient(token).Devices(ctx)
+
+	var apiErr *api.Error
+	switch {
+	case errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden):
+		fix := "run pixlet login again"
+		if source != "pixlet login" {
+			fix = fmt.Sprintf("get a new token from the Tidbyt mobile app and set %s to it", source)
+		}
+		return "", "", fmt.Errorf("the API rejected the token from %s (%s)", source, apiErr.Status), fix
+	case errors.As(err, &apiErr):
+		return "", "", fmt.Errorf("the API returned %s", apiErr.Status), "try again later"
+	case err != nil:
+		return "", "", fmt.Errorf("can't reach the Tidbyt API: %w", err), "check your network connection, and any proxy set in $HTTPS_PROXY"
+	}
+
+	return token, fmt.Sprintf("valid, from %s", source), nil, ""
+}
+
+func checkDevices(ctx context.Context, token string) (string, error, string) {
+	account := 0
+	if token != "" {
+		devices, err := api.NewClient(token).Devices(ctx)
+
+		var apiErr *api.Error
+		if err != nil && !errors.As(err, &apiErr) {
+			return "", fmt.Errorf("listing devices: %w", err), "check your network connection"
+		}
+		account = len(devices)
+	}
+
+	local := 0
+	services, err := mdns.Browse(ctx, TidbytMDNSService)
+	if err == nil {
+		local = len(services)
+	}
+
+	switch {
+	case token != "" && account == 0:
+		return "", fmt.Errorf("there are no devices in your account"), "set up your Tidbyt with the mobile app, signed in to the same account as pixlet login"
+	case local == 0 && err != nil:
+		return "", doctorNotice{fmt.Sprintf("%d in your account, local network discovery failed: %v", account, err)}, "allow pixlet to use multicast (mDNS) on your network, to push to devices locally"
+	case local == 0 && token == "":
+		return "", doctorNotice{"none found on the local network"}, "check that your device is on and on the same network as this computer"
+	case local == 0:
+		return "", doctorNotice{fmt.Sprintf("%d in your account, none found on the local network", account)}, "pushing through the API works anyway. To push locally, check that the device is on the same network as this computer"
+	case token == "":
+		return fmt.Sprintf("%d found on the local network", local), nil, ""
+	}
+
+	return fmt.Sprintf("%d in your account, %d found on the local network", account, local), nil, ""
+}
+
+// checkClock compares the local clock to the API server's.
+func checkClock(ctx context.Context) (string, error, string) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, TidbytAPIListDevices, nil)
+	if err != nil {
+		return "", err, ""
+	}
+
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", doctorNotice{fmt.Sprintf("couldn't check, the API can't be reached: %v", err)}, ""
+	}
+	resp.Body.Close()
+	elapsed := time.Since(start)
+
+	server, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return "", doctorNotice{"couldn't check, the API didn't send the time"}, ""
+	}
+
+	// the server's time was taken somewhere during the request
+	skew := start.Add(elapsed / 2).Sub(server)
+	if skew < 0 {
+		skew = -skew
+	}
+	skew = skew.Round(time.Second)
+
+	if skew > maxClockSkew {
+		return "", fmt.Errorf("your clock is off by %s", skew), "set your clock automatically, using network time (NTP). Logins and caching fail with a wrong clock"
+	}
+
+	return fmt.Sprintf("within %s of the API", maxClockSkew), nil, ""
+}
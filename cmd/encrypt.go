@@ -1,13 +1,17 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
-	"log"
+	"os"
+	"sort"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"go.starlark.net/starlark"
 
 	"tidbyt.dev/pixlet/runtime"
+	"tidbyt.dev/pixlet/tools/secrets"
 )
 
 const PublicKeysetJSON = `{
@@ -26,31 +30,279 @@ const PublicKeysetJSON = `{
   ]
 }`
 
+var (
+	encryptEnvFile       string
+	encryptWrite         string
+	encryptVerify        string
+	encryptPublicKeyset  string
+	encryptPrivateKeyset string
+)
+
+func init() {
+	EncryptCmd.Flags().StringVarP(&encryptEnvFile, "env-file", "e", "", "Encrypt every secret in this .env file")
+	EncryptCmd.Flags().StringVarP(&encryptWrite, "write", "w", "", "With --env-file, update the secrets block in this .star file, or this .json secrets manifest")
+	EncryptCmd.Flags().StringVarP(&encryptVerify, "verify", "", "", "Check the encrypted secrets in this .star file or .json secrets manifest")
+	EncryptCmd.Flags().StringVarP(&encryptPublicKeyset, "public-keyset", "", "", "Encrypt with this Tink public keyset instead of Tidbyt's")
+	EncryptCmd.Flags().StringVarP(&encryptPrivateKeyset, "private-keyset", "", "", "With --verify, decrypt the secrets with this cleartext Tink keyset")
+}
+
 var EncryptCmd = &cobra.Command{
-	Use:     "encrypt [app ID] [secret value]...",
-	Short:   "Encrypt a secret for use in the Tidbyt community repo",
-	Example: "encrypt weather my-top-secretweather-api-key-123456",
-	Args:    cobra.MinimumNArgs(2),
-	Run:     encrypt,
+	Use:   "encrypt [app ID] [secret value]...",
+	Short: "Encrypt a secret for use in the Tidbyt community repo",
+	Example: `  pixlet encrypt weather my-top-secretweather-api-key-123456
+  pixlet encrypt weather --env-file .env --write weather.star
+  pixlet encrypt weather --env-file .env --verify weather.star`,
+	Long: `Encrypt a secret for use in the Tidbyt community repo.
+
+Each secret value is encrypted for the app with the given ID, and printed
+as a Starlark string to pass to secret.decrypt.
+
+With --env-file, every secret in a .env file of NAME=value lines is
+encrypted, and printed as a block of Starlark constants named after
+them. --write puts the block in a .star file, replacing the block from
+an earlier run, or adds the secrets to a .json secrets manifest. Secrets
+that are in the block or manifest but not in the .env file are kept.
+
+--verify checks the secrets in a .star file or secrets manifest: that
+they are well formed and were encrypted with the public key. With
+--env-file, it also checks that every secret in the .env file is there.
+Only the holder of the private key can decrypt secrets, so by default
+their values can't be checked. If you encrypted with your own keyset
+using --public-keyset, pass the private keyset with --private-keyset to
+decrypt them and compare them to the .env file.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if encryptEnvFile != "" || encryptVerify != "" {
+			return cobra.ExactArgs(1)(cmd, args)
+		}
+		return cobra.MinimumNArgs(2)(cmd, args)
+	},
+	RunE: encrypt,
 }
 
-func encrypt(cmd *cobra.Command, args []string) {
+func encrypt(cmd *cobra.Command, args []string) error {
+	publicKeyset := []byte(PublicKeysetJSON)
+	if encryptPublicKeyset != "" {
+		var err error
+		publicKeyset, err = os.ReadFile(encryptPublicKeyset)
+		if err != nil {
+			return fmt.Errorf("reading public keyset: %w", err)
+		}
+	}
+
 	sek := &runtime.SecretEncryptionKey{
-		PublicKeysetJSON: []byte(PublicKeysetJSON),
+		PublicKeysetJSON: publicKeyset,
 	}
 
 	appID := args[0]
+
+	if encryptVerify != "" {
+		return verifySecrets(sek, appID)
+	}
+	if encryptWrite != "" && encryptEnvFile == "" {
+		return fmt.Errorf("--write needs --env-file")
+	}
+	if encryptEnvFile != "" {
+		return encryptEnv(sek, appID)
+	}
+
 	encrypted := make([]string, len(args)-1)
 
 	for i, val := range args[1:] {
 		var err error
 		encrypted[i], err = sek.Encrypt(appID, val)
 		if err != nil {
-			log.Fatalf("encrypting value: %v", err)
+			return fmt.Errorf("encrypting value: %w", err)
 		}
 	}
 
 	for _, val := range encrypted {
 		fmt.Println(starlark.String(val).String())
 	}
+
+	return nil
+}
+
+func readEnvFile(path string) ([]secrets.Secret, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening env file: %w", err)
+	}
+	defer f.Close()
+
+	env, err := secrets.ParseEnv(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return env, nil
+}
+
+// encryptEnv encrypts every secret in the env file, and prints them as a
+// block or writes them to --write.
+func encryptEnv(sek *runtime.SecretEncryptionKey, appID string) error {
+	env, err := readEnvFile(encryptEnvFile)
+	if err != nil {
+		return err
+	}
+
+	encrypted := make([]secrets.Secret, len(env))
+	for i, s := range env {
+		val, err := sek.Encrypt(appID, s.Value)
+		if err != nil {
+			return fmt.Errorf("encrypting %s: %w", s.Name, err)
+		}
+		encrypted[i] = secrets.Secret{Name: s.Name, Value: val}
+	}
+
+	if encryptWrite == "" {
+		os.Stdout.Write(secrets.FormatBlock(encrypted))
+		return nil
+	}
+
+	if strings.HasSuffix(encryptWrite, ".json") {
+		m := &secrets.Manifest{AppID: appID}
+		if f, err := os.Open(encryptWrite); err == nil {
+			m, err = secrets.ReadManifest(f)
+			f.Close()
+			if err != nil {
+				return fmt.Errorf("%s: %w", encryptWrite, err)
+			}
+			if m.AppID != appID {
+				return fmt.Errorf("%s holds secrets for app %s, not %s", encryptWrite, m.AppID, appID)
+			}
+		} else if !os.IsNotExist(err) {
+			return fmt.Errorf("opening secrets manifest: %w", err)
+		}
+		m.Set(encrypted)
+
+		var buf bytes.Buffer
+		if err := m.Write(&buf); err != nil {
+			return err
+		}
+		if err := os.WriteFile(encryptWrite, buf.Bytes(), 0644); err != nil {
+			return fmt.Errorf("writing secrets manifest: %w", err)
+		}
+	} else {
+		src, err := os.ReadFile(encryptWrite)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", encryptWrite, err)
+		}
+
+		out, err := secrets.UpdateBlock(src, encrypted)
+		if err != nil {
+			return fmt.Errorf("%s: %w", encryptWrite, err)
+		}
+
+		if err := os.WriteFile(encryptWrite, out, 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", encryptWrite, err)
+		}
+	}
+
+	fmt.Printf("Encrypted %d secrets to %s\n", len(encrypted), encryptWrite)
+	return nil
+}
+
+// verifySecrets checks the encrypted secrets in --verify, and compares
+// them to the env file if there is one.
+func verifySecrets(sek *runtime.SecretEncryptionKey, appID string) error {
+	var stored []secrets.Secret
+
+	if strings.HasSuffix(encryptVerify, ".json") {
+		f, err := os.Open(encryptVerify)
+		if err != nil {
+			return fmt.Errorf("opening secrets manifest: %w", err)
+		}
+		m, err := secrets.ReadManifest(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", encryptVerify, err)
+		}
+		if m.AppID != appID {
+			return fmt.Errorf("%s holds secrets for app %s, not %s", encryptVerify, m.AppID, appID)
+		}
+		stored = m.List()
+	} else {
+		src, err := os.ReadFile(encryptVerify)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", encryptVerify, err)
+		}
+		stored, err = secrets.ReadBlock(src)
+		if err != nil {
+			return fmt.Errorf("%s: %w", encryptVerify, err)
+		}
+		if stored == nil {
+			return fmt.Errorf("%s has no secrets block, create one with --env-file and --write", encryptVerify)
+		}
+	}
+
+	var sdk *runtime.SecretDecryptionKey
+	if encryptPrivateKeyset != "" {
+		keyset, err := os.ReadFile(encryptPrivateKeyset)
+		if err != nil {
+			return fmt.Errorf("reading private keyset: %w", err)
+		}
+		sdk = &runtime.SecretDecryptionKey{EncryptedKeysetJSON: keyset}
+	}
+
+	var env map[string]string
+	if encryptEnvFile != "" {
+		parsed, err := readEnvFile(encryptEnvFile)
+		if err != nil {
+			return err
+		}
+		env = map[string]string{}
+		for _, s := range parsed {
+			env[s.Name] = s.Value
+		}
+	}
+
+	problems := 0
+	for _, s := range stored {
+		status := "ok"
+
+		if err := sek.CheckEncrypted(s.Value); err != nil {
+			status = err.Error()
+		} else if sdk != nil {
+			decrypted, err := sdk.Decrypt(appID, s.Value)
+			if err != nil {
+				status = err.Error()
+			} else if want, ok := env[s.Name]; ok && decrypted != want {
+				status = "doesn't match the env file"
+			} else {
+				status = "ok, decrypted"
+			}
+		}
+
+		if env != nil {
+			if _, ok := env[s.Name]; !ok {
+				status += ", not in the env file"
+			}
+		}
+
+		if !strings.HasPrefix(status, "ok") {
+			problems++
+		}
+		fmt.Printf("%s: %s\n", s.Name, status)
+	}
+
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		found := false
+		for _, s := range stored {
+			found = found || s.Name == name
+		}
+		if !found {
+			problems++
+			fmt.Printf("%s: missing, encrypt it with --env-file and --write\n", name)
+		}
+	}
+
+	if problems > 0 {
+		return fmt.Errorf("%d secrets have problems", problems)
+	}
+	return nil
 }
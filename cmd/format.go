@@ -2,15 +2,24 @@ package cmd
 
 import (
 	"fmt"
+	"sort"
 
+	"github.com/bazelbuild/buildtools/build"
 	"github.com/bazelbuild/buildtools/differ"
 	"github.com/spf13/cobra"
 )
 
+var (
+	formatCheck bool
+	formatDiff  bool
+)
+
 func init() {
 	FormatCmd.Flags().BoolVarP(&vflag, "verbose", "v", false, "print verbose information to standard error")
 	FormatCmd.Flags().BoolVarP(&rflag, "recursive", "r", false, "find starlark files recursively")
 	FormatCmd.Flags().BoolVarP(&dryRunFlag, "dry-run", "d", false, "display a diff of formatting changes without modification")
+	FormatCmd.Flags().BoolVarP(&formatCheck, "check", "", false, "list files that need formatting, and exit non-zero if there are any")
+	FormatCmd.Flags().BoolVarP(&formatDiff, "diff", "", false, "print a unified diff of formatting changes to stdout without modification")
 }
 
 var FormatCmd = &cobra.Command{
@@ -18,10 +27,19 @@ var FormatCmd = &cobra.Command{
 	Short: "Formats Tidbyt apps",
 	Example: `  pixlet format app.star
   pixlet format app.star --dry-run
-  pixlet format --recursive ./`,
+  pixlet format --recursive ./
+  pixlet format --recursive --check --diff ./`,
 	Long: `The format command provides a code formatter for Tidbyt apps. By default, it
 will format your starlark source code in line. If you wish you see the output
-before applying, add the --dry-run flag.`,
+before applying, add the --dry-run flag.
+
+Formatting also sorts each group of load statements by path, in the
+order that lint expects.
+
+For CI, --check lists the files that need formatting and exits with a
+non-zero status if there are any. --diff prints the changes as a
+unified diff on stdout, which needs no diff program to be installed.
+Combine the two to see the changes and fail the build.`,
 	Args: cobra.MinimumNArgs(1),
 	RunE: formatCmd,
 }
@@ -36,7 +54,12 @@ func formatCmd(cmd *cobra.Command, args []string) error {
 	// resolvable issue by default and provide a dry run flag to be able to
 	// diff the changes before fixing them.
 	mode := "fix"
-	if dryRunFlag {
+	switch {
+	case formatDiff:
+		mode = "unified"
+	case formatCheck:
+		mode = "check"
+	case dryRunFlag:
 		mode = "diff"
 	}
 
@@ -47,9 +70,46 @@ func formatCmd(cmd *cobra.Command, args []string) error {
 
 	// Run buildifier and exit with the returned exit code.
 	exitCode := runBuildifier(args, lint, mode, "", rflag, vflag)
+
+	// Files that need formatting exit with status 4. Printing a diff only
+	// fails when --check is given as well.
+	if exitCode == 4 && mode == "unified" && !formatCheck {
+		exitCode = 0
+	}
+
+	if exitCode == 4 && (mode == "check" || mode == "unified") {
+		return fmt.Errorf("some files need formatting, run `pixlet format` on them")
+	}
+
 	if exitCode != 0 {
 		return fmt.Errorf("formatting returned non-zero exit status: %d", exitCode)
 	}
 
 	return nil
 }
+
+// sortLoads sorts each run of load statements in f by path, the same
+// way buildifier's out-of-order-load warning does. Comments move along
+// with the statement they're attached to.
+func sortLoads(f *build.File) {
+	for start := 0; start < len(f.Stmt); start++ {
+		if _, ok := f.Stmt[start].(*build.LoadStmt); !ok {
+			continue
+		}
+
+		end := start
+		for end < len(f.Stmt) {
+			if _, ok := f.Stmt[end].(*build.LoadStmt); !ok {
+				break
+			}
+			end++
+		}
+
+		loads := f.Stmt[start:end]
+		sort.SliceStable(loads, func(i, j int) bool {
+			return loads[i].(*build.LoadStmt).Module.Value < loads[j].(*build.LoadStmt).Module.Value
+		})
+
+		start = end
+	}
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+
+	"tidbyt.dev/pixlet/metrics"
+	"tidbyt.dev/pixlet/runtime"
+	"tidbyt.dev/pixlet/server/rpc"
+)
+
+var (
+	grpcPort    int
+	metricsAddr string
+)
+
+func init() {
+	GRPCCmd.Flags().StringVarP(&host, "host", "i", "127.0.0.1", "Host interface to listen on")
+	GRPCCmd.Flags().IntVarP(&grpcPort, "port", "p", 50051, "Port to listen on")
+	GRPCCmd.Flags().IntVarP(&timeout, "timeout", "", 30000, "Timeout for execution (ms)")
+	GRPCCmd.Flags().IntVarP(&maxFrames, "max-frames", "", 0, "Fail renders with more frames than this")
+	GRPCCmd.Flags().StringVarP(&maxMemory, "max-memory", "", "", "Stop apps that use more memory than this, like 256MB")
+	GRPCCmd.Flags().StringVarP(&metricsAddr, "metrics-addr", "", "", "Serve Prometheus metrics over HTTP at this address, like :9090")
+	GRPCCmd.Flags().StringVarP(&replayPath, "replay", "", "", "Answer the apps' HTTP requests from this cassette file, without a network")
+	addRateLimitFlags(GRPCCmd)
+	addSecretsFlag(GRPCCmd)
+}
+
+var GRPCCmd = &cobra.Command{
+	Use:   "grpc",
+	Short: "Serve the render API over gRPC",
+	Args:  cobra.NoArgs,
+	RunE:  serveGRPC,
+	Long: `Serve the render API over gRPC.
+
+Backends that aren't written in Go can use pixlet as a service: load an
+app by sending its files, then render it with any config, read its
+schema and call its schema handlers. Frames can be streamed one by one
+as PNGs, for hosts that drive displays themselves.
+
+The service is defined in server/rpc/pixlet.proto, in the pixlet
+repository. Generate a client from it for your language, or explore
+the service with a tool like grpcurl, since server reflection is on.
+
+There's no authentication, so only listen on interfaces that untrusted
+clients can't reach.`,
+}
+
+func serveGRPC(cmd *cobra.Command, args []string) error {
+	limits, err := appLimits()
+	if err != nil {
+		return err
+	}
+
+	if err := initCassette(); err != nil {
+		return err
+	}
+
+	if err := initRateLimit(); err != nil {
+		return err
+	}
+
+	if err := initSecrets(); err != nil {
+		return err
+	}
+
+	stopTracing, err := initTracing(cmd)
+	if err != nil {
+		return err
+	}
+	defer stopTracing()
+
+	cache := runtime.NewInMemoryCache()
+	runtime.InitHTTP(cache)
+	runtime.InitCache(cache)
+
+	addr := net.JoinHostPort(host, fmt.Sprint(grpcPort))
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listening on %s: %w", addr, err)
+	}
+
+	s := grpc.NewServer()
+	rpc.RegisterPixletServer(s, rpc.NewServer(limits))
+	reflection.Register(s)
+
+	if metricsAddr != "" {
+		go func() {
+			log.Printf("serving metrics at http://%s/metrics\n", metricsAddr)
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", metrics.Handler())
+			log.Fatal(http.ListenAndServe(metricsAddr, mux))
+		}()
+	}
+
+	log.Printf("serving gRPC at %s\n", addr)
+	return s.Serve(lis)
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	installationConfigFile string
+)
+
+var InstallationsListCmd = &cobra.Command{
+	Use:   "list [device ID]",
+	Short: "List the apps installed on a Tidbyt",
+	Args:  cobra.ExactArgs(1),
+	RunE:  listInstallations,
+}
+
+var InstallationsDeleteCmd = &cobra.Command{
+	Use:   "delete [device ID] [installation ID]",
+	Short: "Delete an installation from a Tidbyt",
+	Args:  cobra.ExactArgs(2),
+	RunE:  delete,
+}
+
+var InstallationsUpdateCmd = &cobra.Command{
+	Use:   "update [device ID] [installation ID] [<key>=<value>]...",
+	Short: "Update the config of an installation",
+	Args:  cobra.MinimumNArgs(2),
+	RunE:  updateInstallation,
+	Long: `Update the config of an installation on a Tidbyt.
+
+Config is given as key=value pairs, or read from a JSON or YAML file with
+--config-file, in the same format as for pixlet render. Pairs given on
+the command line override the file. The new config replaces the
+installation's config as a whole.`,
+}
+
+var InstallationsCmd = &cobra.Command{
+	Use:   "installations",
+	Short: "Manage the apps installed on a Tidbyt",
+}
+
+func init() {
+	for _, c := range []*cobra.Command{InstallationsListCmd, InstallationsDeleteCmd, InstallationsUpdateCmd} {
+		c.Flags().StringVarP(&apiToken, "api-token", "t", "", "Tidbyt API token")
+		InstallationsCmd.AddCommand(c)
+	}
+
+	InstallationsUpdateCmd.Flags().StringVarP(&installationConfigFile, "config-file", "c", "", "Read config from this JSON or YAML file")
+}
+
+func updateInstallation(cmd *cobra.Command, args []string) error {
+	deviceID := args[0]
+	installationID := args[1]
+
+	cfg := map[string]string{}
+	if installationConfigFile != "" {
+		var err error
+		if cfg, err = readConfigFile(installationConfigFile); err != nil {
+			return err
+		}
+	}
+
+	for _, param := range args[2:] {
+		key, value, ok := strings.Cut(param, "=")
+		if !ok {
+			return fmt.Errorf("parameters must be on form <key>=<value>, found %s", param)
+		}
+		cfg[key] = value
+	}
+
+	if len(cfg) == 0 {
+		return fmt.Errorf("no config given, pass <key>=<value> pairs or --config-file")
+	}
+
+	client, err := apiClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	if err := client.UpdateInstallation(cmd.Context(), deviceID, installationID, cfg); err != nil {
+		return fmt.Errorf("updating via API: %w", err)
+	}
+
+	return nil
+}
@@ -2,7 +2,9 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/bazelbuild/buildtools/buildifier/utils"
 	"github.com/bazelbuild/buildtools/differ"
 	"github.com/spf13/cobra"
 )
@@ -21,7 +23,12 @@ var LintCmd = &cobra.Command{
 	Short: "Lints Tidbyt apps",
 	Long: `The lint command provides a linter for Tidbyt apps. It's capable of linting a
 file, a list of files, or directory with the recursive option. Additionally, it
-provides an option to automatically fix resolvable linter issues.`,
+provides an option to automatically fix resolvable linter issues.
+
+Besides buildifier's fixes, --fix also fixes the problems pixlet check
+finds that don't need a person to look at them: unused loads, HTTP
+requests and cache.set calls without ttl_seconds, and modules loaded
+from an old path.`,
 	Args: cobra.MinimumNArgs(1),
 	RunE: lintCmd,
 }
@@ -41,6 +48,25 @@ func lintCmd(cmd *cobra.Command, args []string) error {
 	if fixFlag {
 		mode = "fix"
 		lint = "fix"
+
+		// Our own fixes go first, since buildifier formats the files
+		// after them.
+		files := args
+		if rflag {
+			var err error
+			files, err = utils.ExpandDirectories(&args)
+			if err != nil {
+				return fmt.Errorf("finding files: %w", err)
+			}
+		}
+		for _, file := range files {
+			if !strings.HasSuffix(file, ".star") {
+				continue
+			}
+			if err := fixSemantics(file); err != nil {
+				return err
+			}
+		}
 	}
 
 	// Copied from the buildifier source, we need to supply a diff program for
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"tidbyt.dev/pixlet/tools/lsp"
+)
+
+var LSPCmd = &cobra.Command{
+	Use:   "lsp",
+	Short: "Run a language server for Tidbyt apps",
+	Long: `Run a language server for Tidbyt apps.
+
+Editors start the server and talk to it over standard input and output,
+using the Language Server Protocol. It completes load paths, module
+members, and the attributes of widgets and schema fields, and shows
+their docs on hover. As you type, it reports syntax errors, the
+problems pixlet check finds, and uses of modules that don't match what
+they contain.
+
+See docs/editors.md in the pixlet repository for how to set up your
+editor.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return lsp.NewServer(os.Stdin, os.Stdout, Version).Run()
+	},
+}
@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	goruntime "runtime"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"tidbyt.dev/pixlet/cmd/config"
+)
+
+// PluginPrefix starts the name of executables on $PATH that pixlet runs
+// as subcommands. pixlet-deploy is run by pixlet deploy.
+const PluginPrefix = "pixlet-"
+
+// builtinCommands are added by cobra when the root command runs, so
+// they can't be found before then.
+var builtinCommands = map[string]bool{
+	"help":             true,
+	"completion":       true,
+	"__complete":       true,
+	"__completeNoDesc": true,
+}
+
+var PluginsCmd = &cobra.Command{
+	Use:   "plugins",
+	Short: "List plugins installed on your PATH",
+	Args:  cobra.NoArgs,
+	RunE:  listPlugins,
+	Long: `List plugins installed on your PATH.
+
+Any executable on your PATH named pixlet-<name> can be run as
+pixlet <name>, with the rest of the arguments passed to it. Commands
+built into pixlet take precedence over plugins with the same name.
+
+Plugins are run with these environment variables set, so that they can
+act on the same account and devices as pixlet:
+
+  TIDBYT_API_TOKEN   the token from $TIDBYT_API_TOKEN or pixlet login
+  TIDBYT_DEVICE_ID   the device from $TIDBYT_DEVICE_ID or
+                     pixlet devices --select
+  PIXLET_BIN         the path of the pixlet executable
+  PIXLET_VERSION     the version of pixlet
+  PIXLET_CONFIG_DIR  the Tidbyt config directory, with groups.yaml`,
+}
+
+// FindPlugin returns the path of the plugin that args, without the
+// program name, should run. It returns false if args run a built in
+// command instead, or name no plugin on $PATH.
+func FindPlugin(root *cobra.Command, args []string) (string, bool) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") || builtinCommands[args[0]] {
+		return "", false
+	}
+
+	if strings.ContainsAny(args[0], `/\`) {
+		return "", false
+	}
+
+	if c, _, err := root.Find(args); err == nil && c != root {
+		return "", false
+	}
+
+	path, err := exec.LookPath(PluginPrefix + args[0])
+	if err != nil {
+		return "", false
+	}
+
+	return path, true
+}
+
+// RunPlugin runs the plugin at path with args, and returns the code it
+// exited with.
+func RunPlugin(ctx context.Context, path string, args []string) int {
+	env, err := pluginEnv(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	c := exec.CommandContext(ctx, path, args...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	c.Env = append(os.Environ(), env...)
+
+	// the plugin gets interrupts from the terminal too, and decides
+	// itself what to do with them
+	signal.Ignore(os.Interrupt)
+	defer signal.Reset(os.Interrupt)
+
+	if err := c.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode()
+		}
+
+		fmt.Fprintf(os.Stderr, "running %s: %v\n", path, err)
+		return 1
+	}
+
+	return 0
+}
+
+// pluginEnv returns the environment variables, as key=value, that
+// plugins get in addition to pixlet's own.
+func pluginEnv(ctx context.Context) ([]string, error) {
+	var env []string
+
+	if bin, err := os.Executable(); err == nil {
+		env = append(env, "PIXLET_BIN="+bin)
+	}
+	env = append(env, "PIXLET_VERSION="+Version)
+
+	if ucd, err := os.UserConfigDir(); err == nil {
+		env = append(env, "PIXLET_CONFIG_DIR="+filepath.Join(ucd, "tidbyt"))
+	}
+
+	if os.Getenv(APITokenEnv) == "" {
+		token, err := config.OAuthToken(ctx)
+		if err != nil {
+			// not every plugin needs the API, so don't fail those
+			// that don't
+			fmt.Fprintf(os.Stderr, "warning: %v, so plugins won't get an API token\n", err)
+		} else if token != "" {
+			env = append(env, fmt.Sprintf("%s=%s", APITokenEnv, token))
+		}
+	}
+
+	if os.Getenv("TIDBYT_DEVICE_ID") == "" {
+		if device := config.SelectedDevice(); device != "" {
+			devices, err := config.ResolveDevices(device)
+			if err != nil {
+				return nil, fmt.Errorf("selected device: %w", err)
+			}
+			env = append(env, "TIDBYT_DEVICE_ID="+strings.Join(devices, ","))
+		}
+	}
+
+	return env, nil
+}
+
+// plugins returns the plugins on $PATH by name. Where several share a
+// name, the first on $PATH is the one that runs.
+func plugins() map[string]string {
+	found := map[string]string{}
+
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" {
+			dir = "."
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, e := range entries {
+			if !strings.HasPrefix(e.Name(), PluginPrefix) || e.IsDir() {
+				continue
+			}
+
+			path := filepath.Join(dir, e.Name())
+			if _, err := exec.LookPath(path); err != nil {
+				// not executable
+				continue
+			}
+
+			name := strings.TrimPrefix(e.Name(), PluginPrefix)
+			if goruntime.GOOS == "windows" {
+				name = strings.TrimSuffix(name, filepath.Ext(name))
+			}
+			if _, ok := found[name]; !ok {
+				found[name] = path
+			}
+		}
+	}
+
+	return found
+}
+
+func listPlugins(cmd *cobra.Command, args []string) error {
+	found := plugins()
+	if len(found) == 0 {
+		fmt.Printf("no plugins found. Plugins are executables on your PATH named %s<name>\n", PluginPrefix)
+		return nil
+	}
+
+	names := make([]string, 0, len(found))
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 2, ' ', 0)
+	defer w.Flush()
+
+	for _, name := range names {
+		note := ""
+		if _, ok := FindPlugin(cmd.Root(), []string{name}); !ok {
+			note = "(hidden by built in command)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", name, found[name], note)
+	}
+
+	return nil
+}
@@ -1,26 +1,67 @@
 package private
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/spf13/cobra"
 	"tidbyt.dev/pixlet/bundle"
 )
 
-var bundleOutput string
+var (
+	bundleOutput    string
+	bundleSignKey   string
+	verifyKey       string
+	verifySignature string
+
+	bundleValidate     bool
+	validateJSON       bool
+	validateBanModules []string
+	validateRenderTime time.Duration
+)
 
 func init() {
 	BundleCmd.Flags().StringVarP(&bundleOutput, "output", "o", "./", "output directory for the bundle")
+	BundleCmd.Flags().StringVarP(&bundleSignKey, "sign-key", "", "", "private key to sign the bundle with")
+	BundleCmd.Flags().BoolVarP(&bundleValidate, "validate", "", false, "validate the app first, and don't bundle it if it has problems")
+	BundleCmd.Flags().StringSliceVarP(&validateBanModules, "ban-module", "", nil, "with --validate, fail apps that load this module, like http.star")
+
+	ValidateCmd.Flags().BoolVarP(&validateJSON, "json", "", false, "print the findings as JSON")
+	ValidateCmd.Flags().StringSliceVarP(&validateBanModules, "ban-module", "", nil, "fail apps that load this module, like http.star")
+	ValidateCmd.Flags().DurationVarP(&validateRenderTime, "max-render-time", "", bundle.DefaultMaxRenderTime, "how long the dry run render may take")
+
+	VerifyCmd.Flags().StringVarP(&verifyKey, "key", "k", "", "public key the bundle should be signed with")
+	VerifyCmd.Flags().StringVarP(&verifySignature, "signature", "s", "", "signature file, defaults to the bundle path with .sig appended")
+	VerifyCmd.MarkFlagRequired("key")
+
+	BundleCmd.AddCommand(KeygenCmd)
+	BundleCmd.AddCommand(VerifyCmd)
+	BundleCmd.AddCommand(ValidateCmd)
 }
 
 var BundleCmd = &cobra.Command{
-	Use:     "bundle",
-	Short:   "Creates a new app bundle",
-	Example: `  pixlet bundle ./my-app`,
+	Use:   "bundle",
+	Short: "Creates a new app bundle",
+	Example: `  pixlet private bundle ./my-app
+  pixlet private bundle --sign-key bundle.key ./my-app`,
 	Long: `This command will create a new app bundle from an app directory. The directory
 should contain an app manifest and source file. The output of this command will
-be a gzip compressed tar file that can be uploaded to Tidbyt for deployment.`,
+be a gzip compressed tar file that can be uploaded to Tidbyt for deployment.
+
+Bundles are reproducible: the same app always gives the same bundle,
+byte for byte, no matter when or where it's built.
+
+With --validate, the app is checked like pixlet private bundle validate
+does first, and isn't bundled if it has problems.
+
+With --sign-key, the bundle is signed with a private key made by
+pixlet private bundle keygen, and the signature is written next to the
+bundle as bundle.tar.gz.sig. Anyone with the public key can check that
+the bundle wasn't changed since, with pixlet private bundle verify.`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		bundleInput := args[0]
@@ -47,6 +88,196 @@ be a gzip compressed tar file that can be uploaded to Tidbyt for deployment.`,
 			return fmt.Errorf("could not init bundle: %w", err)
 		}
 
-		return ab.WriteBundleToPath(bundleOutput)
+		if bundleValidate {
+			report, err := validate(cmd, ab)
+			if err != nil {
+				return err
+			}
+			if err := report.Err(); err != nil {
+				return err
+			}
+		}
+
+		err = ab.WriteBundleToPath(bundleOutput)
+		if err != nil {
+			return err
+		}
+
+		if bundleSignKey == "" {
+			return nil
+		}
+
+		key, err := os.ReadFile(bundleSignKey)
+		if err != nil {
+			return fmt.Errorf("could not read signing key: %w", err)
+		}
+
+		data, err := os.ReadFile(filepath.Join(bundleOutput, bundle.AppBundleName))
+		if err != nil {
+			return fmt.Errorf("could not read bundle to sign: %w", err)
+		}
+
+		sig, err := bundle.Sign(data, key)
+		if err != nil {
+			return fmt.Errorf("could not sign bundle: %w", err)
+		}
+
+		return os.WriteFile(filepath.Join(bundleOutput, bundle.AppSignatureName), sig, 0644)
+	},
+}
+
+var KeygenCmd = &cobra.Command{
+	Use:     "keygen <name>",
+	Short:   "Creates a key pair for signing bundles",
+	Example: `  pixlet private bundle keygen bundle`,
+	Long: `This command will create a key pair for signing bundles. The private key is
+written to <name>.key, and should be kept secret, for example in your CI
+system's secrets. The public key is written to <name>.pub, and can be shared
+with whoever needs to verify bundles.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		privPath := args[0] + ".key"
+		pubPath := args[0] + ".pub"
+
+		for _, path := range []string{privPath, pubPath} {
+			if _, err := os.Stat(path); err == nil {
+				return fmt.Errorf("%s already exists, not overwriting it", path)
+			}
+		}
+
+		priv, pub, err := bundle.GenerateKey()
+		if err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(privPath, priv, 0600); err != nil {
+			return fmt.Errorf("could not write private key: %w", err)
+		}
+
+		if err := os.WriteFile(pubPath, pub, 0644); err != nil {
+			return fmt.Errorf("could not write public key: %w", err)
+		}
+
+		fmt.Printf("private key written to %s\n", privPath)
+		fmt.Printf("public key written to %s\n", pubPath)
+		return nil
+	},
+}
+
+var VerifyCmd = &cobra.Command{
+	Use:     "verify <bundle>",
+	Short:   "Verifies the signature of an app bundle",
+	Example: `  pixlet private bundle verify --key bundle.pub bundle.tar.gz`,
+	Long: `This command will check that an app bundle was signed with the private key
+belonging to the public key passed with --key, and that it hasn't changed since.
+It exits with a non-zero status if the bundle doesn't verify.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		bundlePath := args[0]
+
+		sigPath := verifySignature
+		if sigPath == "" {
+			sigPath = bundlePath + ".sig"
+		}
+
+		data, err := os.ReadFile(bundlePath)
+		if err != nil {
+			return fmt.Errorf("could not read bundle: %w", err)
+		}
+
+		sig, err := os.ReadFile(sigPath)
+		if err != nil {
+			return fmt.Errorf("could not read signature: %w", err)
+		}
+
+		key, err := os.ReadFile(verifyKey)
+		if err != nil {
+			return fmt.Errorf("could not read public key: %w", err)
+		}
+
+		err = bundle.Verify(data, sig, key)
+		if errors.Is(err, bundle.ErrBadSignature) {
+			return fmt.Errorf("%s: %w, it was changed since it was signed or signed with another key", bundlePath, err)
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s: signature verified\n", bundlePath)
+		return nil
 	},
 }
+
+var ValidateCmd = &cobra.Command{
+	Use:   "validate <bundle or directory>",
+	Short: "Validates an app bundle for publishing",
+	Example: `  pixlet private bundle validate bundle.tar.gz
+  pixlet private bundle validate --json --ban-module http.star ./my-app`,
+	Long: `This command will check an app bundle, or an app directory with a manifest, the
+way apps are reviewed before they're published: the manifest's fields, the
+schema's icons, handlers and defaults, the size of the files, banned modules,
+and a dry run render with the schema's default config.
+
+Every problem is listed, as an error or a warning. With --json, they're printed
+as JSON for automated review. It exits with a non-zero status if there are any
+errors.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := args[0]
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("could not read bundle: %w", err)
+		}
+
+		var ab *bundle.AppBundle
+		if info.IsDir() {
+			ab, err = bundle.FromDir(path)
+		} else {
+			var f *os.File
+			if f, err = os.Open(path); err != nil {
+				return fmt.Errorf("could not read bundle: %w", err)
+			}
+			defer f.Close()
+			ab, err = bundle.LoadBundle(f)
+		}
+		if err != nil {
+			return fmt.Errorf("could not load bundle: %w", err)
+		}
+
+		report, err := validate(cmd, ab)
+		if err != nil {
+			return err
+		}
+
+		if validateJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(report); err != nil {
+				return err
+			}
+		} else {
+			for _, f := range report.Findings {
+				fmt.Println(f)
+			}
+		}
+
+		if !report.OK() {
+			return fmt.Errorf("%s has problems that keep it from being published", report.App)
+		}
+		if !validateJSON {
+			fmt.Printf("%s: valid, rendered in %s\n", report.App, report.RenderTime.Round(time.Millisecond))
+		}
+		return nil
+	},
+}
+
+func validate(cmd *cobra.Command, ab *bundle.AppBundle) (*bundle.Report, error) {
+	report, err := ab.Validate(cmd.Context(), bundle.ValidateOptions{
+		BannedModules: validateBanModules,
+		MaxRenderTime: validateRenderTime,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not validate bundle: %w", err)
+	}
+	return report, nil
+}
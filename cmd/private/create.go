@@ -32,6 +32,8 @@ type TidbytCreateAppReply struct {
 }
 
 func init() {
+	PrivateCmd.AddCommand(CreateCmd)
+
 	CreateCmd.Flags().StringVarP(&createOrg, "org", "o", "", "organization to create the app in")
 	CreateCmd.Flags().StringVarP(&createURL, "url", "u", "https://api.tidbyt.com", "base URL of Tidbyt API")
 	CreateCmd.Flags().StringVarP(&createDir, "app-dir", "d", ".", "directory to create the app in")
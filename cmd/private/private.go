@@ -5,7 +5,6 @@ import (
 )
 
 func init() {
-	PrivateCmd.AddCommand(CreateCmd)
 	PrivateCmd.AddCommand(BundleCmd)
 	PrivateCmd.AddCommand(UploadCmd)
 	PrivateCmd.AddCommand(DeployCmd)
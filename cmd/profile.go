@@ -4,9 +4,14 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
+	"regexp"
+	rtpprof "runtime/pprof"
+	"sort"
 	"strings"
+	"text/tabwriter"
 	"time"
 
 	pprof_driver "github.com/google/pprof/driver"
@@ -14,25 +19,47 @@ import (
 	"github.com/spf13/cobra"
 	"go.starlark.net/starlark"
 
+	pixlet_render "tidbyt.dev/pixlet/render"
 	"tidbyt.dev/pixlet/runtime"
 	"tidbyt.dev/pixlet/tools"
 )
 
 var (
-	pprof_cmd string
+	pprof_cmd      string
+	profileRuns    int
+	profileOutput  string
+	profileFolded  string
+	profileNoPaint bool
 )
 
 func init() {
 	ProfileCmd.Flags().StringVarP(
 		&pprof_cmd, "pprof", "", "top 10", "Command to call pprof with",
 	)
+	ProfileCmd.Flags().IntVarP(&profileRuns, "runs", "n", 10, "Number of times to run and paint the app")
+	ProfileCmd.Flags().StringVarP(&profileOutput, "output", "o", "", "Write the Starlark CPU profile to this file, in pprof format")
+	ProfileCmd.Flags().StringVarP(&profileFolded, "folded", "", "", "Write the Starlark CPU profile to this file as folded stacks, for flame graph tools")
+	ProfileCmd.Flags().BoolVarP(&profileNoPaint, "no-paint", "", false, "Skip the breakdown of paint time by widget")
 }
 
 var ProfileCmd = &cobra.Command{
 	Use:   "profile <path> [<key>=value>]...",
 	Short: "Run a Pixlet app and print its execution-time profile",
-	Args:  cobra.MinimumNArgs(1),
-	RunE:  profile,
+	Long: `Run a Pixlet app and print its execution-time profile.
+
+The app is run --runs times, and the time its Starlark code takes is
+profiled. The top of the profile is printed with pprof; pass another
+pprof command with --pprof. To dig deeper, write the profile with
+--output and open it with "go tool pprof -http=: <file>", which has a
+flame graph view. --folded writes the same profile as folded stacks,
+which flamegraph.pl, speedscope and similar tools read.
+
+Then the widgets the app returned are painted --runs times, and the time
+spent painting is broken down by widget. Each widget is only charged
+for its own painting, not for that of its children. The breakdown is
+sampled, so use more runs for apps that paint quickly.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: profile,
 }
 
 // We save the profile into an in-memory buffer, which is simpler than the tool expects.
@@ -55,7 +82,7 @@ var pprof_printed = false
 
 func (u printUI) ReadLine(prompt string) (string, error) {
 	if pprof_printed {
-		os.Exit(0)
+		return "", io.EOF
 	}
 	pprof_printed = true
 	return pprof_cmd, nil
@@ -78,11 +105,33 @@ func profile(cmd *cobra.Command, args []string) error {
 		config[split[0]] = split[1]
 	}
 
-	profile, err := ProfileApp(path, config)
+	if profileRuns < 1 {
+		return fmt.Errorf("--runs must be at least 1")
+	}
+
+	profile, roots, err := profileApp(path, config, profileRuns)
 	if err != nil {
 		return err
 	}
 
+	fmt.Printf(
+		"ran %d times, %s per run on average\n\n",
+		profileRuns,
+		time.Duration(profile.DurationNanos/int64(profileRuns)),
+	)
+
+	if profileOutput != "" {
+		if err := writeProfile(profileOutput, profile); err != nil {
+			return err
+		}
+	}
+
+	if profileFolded != "" {
+		if err := writeFolded(profileFolded, profile); err != nil {
+			return err
+		}
+	}
+
 	options := &pprof_driver.Options{
 		Fetch: MakeFetchFunc(profile),
 		UI:    printUI{},
@@ -91,13 +140,24 @@ func profile(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("could not start pprof driver: %w", err)
 	}
 
-	return nil
+	if profileNoPaint {
+		return nil
+	}
+
+	return profilePaint(roots, profileRuns)
 }
 
 func ProfileApp(path string, config map[string]string) (*pprof_profile.Profile, error) {
+	profile, _, err := profileApp(path, config, 1)
+	return profile, err
+}
+
+// profileApp runs the app the given number of times, profiling its
+// Starlark code. It also returns the roots of the last run.
+func profileApp(path string, config map[string]string, runs int) (*pprof_profile.Profile, []pixlet_render.Root, error) {
 	info, err := os.Stat(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to stat %s: %w", path, err)
+		return nil, nil, fmt.Errorf("failed to stat %s: %w", path, err)
 	}
 
 	var fsys fs.FS
@@ -105,7 +165,7 @@ func ProfileApp(path string, config map[string]string) (*pprof_profile.Profile,
 		fsys = os.DirFS(path)
 	} else {
 		if !strings.HasSuffix(path, ".star") {
-			return nil, fmt.Errorf("script file must have suffix .star: %s", path)
+			return nil, nil, fmt.Errorf("script file must have suffix .star: %s", path)
 		}
 
 		fsys = tools.NewSingleFileFS(path)
@@ -117,28 +177,155 @@ func ProfileApp(path string, config map[string]string) (*pprof_profile.Profile,
 
 	applet, err := runtime.NewAppletFromFS(path, fsys, runtime.WithPrintDisabled())
 	if err != nil {
-		return nil, fmt.Errorf("failed to load applet: %w", err)
+		return nil, nil, fmt.Errorf("failed to load applet: %w", err)
 	}
 
 	buf := new(bytes.Buffer)
 	if err = starlark.StartProfile(buf); err != nil {
-		return nil, fmt.Errorf("error starting profiler: %w", err)
+		return nil, nil, fmt.Errorf("error starting profiler: %w", err)
 	}
 
-	_, err = applet.RunWithConfig(context.Background(), config)
-	if err != nil {
-		_ = starlark.StopProfile()
-		return nil, fmt.Errorf("error running script: %w", err)
+	var roots []pixlet_render.Root
+	for i := 0; i < runs; i++ {
+		roots, err = applet.RunWithConfig(context.Background(), config)
+		if err != nil {
+			_ = starlark.StopProfile()
+			return nil, nil, fmt.Errorf("error running script: %w", err)
+		}
 	}
 
 	if err = starlark.StopProfile(); err != nil {
-		return nil, fmt.Errorf("error stopping profiler: %w", err)
+		return nil, nil, fmt.Errorf("error stopping profiler: %w", err)
 	}
 
 	profile, err := pprof_profile.ParseData(buf.Bytes())
 	if err != nil {
-		return nil, fmt.Errorf("could not parse pprof profile: %w", err)
+		return nil, nil, fmt.Errorf("could not parse pprof profile: %w", err)
+	}
+
+	return profile, roots, nil
+}
+
+func writeProfile(path string, profile *pprof_profile.Profile) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating profile: %w", err)
+	}
+	defer f.Close()
+
+	if err := profile.Write(f); err != nil {
+		return fmt.Errorf("writing profile: %w", err)
+	}
+
+	return nil
+}
+
+// writeFolded writes the profile as folded stacks: one line per stack,
+// with the functions from the root down separated by semicolons, and
+// the time spent in it.
+func writeFolded(path string, profile *pprof_profile.Profile) error {
+	stacks := map[string]int64{}
+	for _, sample := range profile.Sample {
+		funcs := []string{}
+		for i := len(sample.Location) - 1; i >= 0; i-- {
+			lines := sample.Location[i].Line
+			for j := len(lines) - 1; j >= 0; j-- {
+				funcs = append(funcs, lines[j].Function.Name)
+			}
+		}
+		if len(sample.Value) > 0 {
+			stacks[strings.Join(funcs, ";")] += sample.Value[len(sample.Value)-1]
+		}
+	}
+
+	keys := make([]string, 0, len(stacks))
+	for k := range stacks {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	out := new(bytes.Buffer)
+	for _, k := range keys {
+		fmt.Fprintf(out, "%s %d\n", k, stacks[k])
+	}
+
+	if err := os.WriteFile(path, out.Bytes(), 0644); err != nil {
+		return fmt.Errorf("writing folded stacks: %w", err)
+	}
+
+	return nil
+}
+
+// paintFunc matches the Paint methods of widgets, and the functions
+// that paint the root.
+var paintFunc = regexp.MustCompile(`^tidbyt\.dev/pixlet/render(?:/animation)?\.\(?\*?(\w+)\)?\.(?:Paint|paintFrame)$`)
+
+// profilePaint paints roots the given number of times with the CPU
+// profiler on, and prints how the time splits between widgets.
+func profilePaint(roots []pixlet_render.Root, runs int) error {
+	buf := new(bytes.Buffer)
+	if err := rtpprof.StartCPUProfile(buf); err != nil {
+		return fmt.Errorf("error starting paint profiler: %w", err)
+	}
+
+	start := time.Now()
+	for i := 0; i < runs; i++ {
+		for _, r := range roots {
+			pixlet_render.ReleaseFrames(r.Paint(true)...)
+		}
+	}
+	elapsed := time.Since(start)
+	rtpprof.StopCPUProfile()
+
+	prof, err := pprof_profile.Parse(buf)
+	if err != nil {
+		return fmt.Errorf("could not parse paint profile: %w", err)
 	}
 
-	return profile, nil
+	// charge each sample to the innermost widget painting
+	self := map[string]int64{}
+	total := int64(0)
+	for _, sample := range prof.Sample {
+		value := sample.Value[len(sample.Value)-1]
+		total += value
+
+		widget := "(other)"
+	find:
+		for _, loc := range sample.Location {
+			for _, line := range loc.Line {
+				if m := paintFunc.FindStringSubmatch(line.Function.Name); m != nil {
+					widget = m[1]
+					break find
+				}
+			}
+		}
+		self[widget] += value
+	}
+
+	fmt.Printf("\npainted %d times, %s per paint on average\n", runs, elapsed/time.Duration(runs))
+
+	if total == 0 {
+		fmt.Println("painting was too quick to sample, try more --runs")
+		return nil
+	}
+
+	widgets := make([]string, 0, len(self))
+	for w := range self {
+		widgets = append(widgets, w)
+	}
+	sort.Slice(widgets, func(i, j int) bool {
+		return self[widgets[i]] > self[widgets[j]]
+	})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "widget\tself\tshare")
+	for _, widget := range widgets {
+		fmt.Fprintf(
+			w, "%s\t%s\t%.1f%%\n",
+			widget,
+			time.Duration(self[widget])/time.Duration(runs),
+			100*float64(self[widget])/float64(total),
+		)
+	}
+	return w.Flush()
 }
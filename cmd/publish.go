@@ -0,0 +1,227 @@
+//go:build !js && !wasm
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/gitsight/go-vcsurl"
+	"github.com/spf13/cobra"
+
+	"tidbyt.dev/pixlet/manifest"
+	"tidbyt.dev/pixlet/tools/repo"
+)
+
+var (
+	publishDryRun bool
+	publishRemote string
+	publishBase   string
+	publishRepo   string
+)
+
+func init() {
+	PublishCmd.Flags().BoolVarP(&publishDryRun, "dry-run", "", false, "Check the app and render its preview, without pushing or opening a pull request")
+	PublishCmd.Flags().StringVarP(&publishRemote, "remote", "", "origin", "Git remote to push the app's branch to, usually your fork")
+	PublishCmd.Flags().StringVarP(&publishBase, "base", "", "main", "Branch of the community repo to submit the app to")
+	PublishCmd.Flags().StringVarP(&publishRepo, "repo", "", "tidbyt/community", "GitHub repository to open the pull request on")
+}
+
+// previewName is the name of the preview image written to the app's
+// directory, and linked from the pull request.
+const previewName = "preview.gif"
+
+var PublishCmd = &cobra.Command{
+	Use:   "publish <path>",
+	Short: "Submit an app to the community repo",
+	Example: `  pixlet publish apps/myapp
+  pixlet publish --dry-run apps/myapp`,
+	Long: `Submit an app to the community repo.
+
+Run this from a clone of your fork of the community repo. The path is
+the app's directory, like apps/myapp. Publishing:
+
+  1. Runs pixlet check on the app, and stops if any check fails.
+  2. Renders a preview of the app with its schema defaults, to
+     preview.gif in the app's directory.
+  3. Commits the app to the branch publish/<app id>, and pushes it to
+     --remote.
+  4. Opens a pull request for the branch, or if there already is one,
+     updates it with the push.
+
+Publishing again after changing the app updates the same pull request.
+The git and gh programs need to be installed, and gh logged in with
+gh auth login. With --dry-run, only the first two steps run.`,
+	Args: cobra.ExactArgs(1),
+	RunE: publish,
+}
+
+func publish(cmd *cobra.Command, args []string) error {
+	dir := args[0]
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory, apps are published from their directory", dir)
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("resolving %s: %w", dir, err)
+	}
+
+	if !repo.IsInRepo(absDir, "community") {
+		return fmt.Errorf("%s is not in a clone of the community repo, clone your fork of it and move the app to its apps directory", dir)
+	}
+
+	root, err := repo.RepoRoot(absDir)
+	if err != nil {
+		return fmt.Errorf("finding the community repo: %w", err)
+	}
+
+	relDir, err := filepath.Rel(root, absDir)
+	if err != nil {
+		return fmt.Errorf("finding %s in the community repo: %w", dir, err)
+	}
+	relDir = filepath.ToSlash(relDir)
+
+	f, err := os.Open(filepath.Join(absDir, manifest.ManifestFileName))
+	if err != nil {
+		return fmt.Errorf("couldn't open app manifest: %w", err)
+	}
+	m, err := manifest.LoadManifest(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("couldn't load app manifest: %w", err)
+	}
+
+	// 1. check
+	fmt.Println("Checking app...")
+	if err := checkCmd(cmd, []string{dir}); err != nil {
+		return fmt.Errorf("fix the problems above and publish again")
+	}
+
+	// 2. preview
+	fmt.Println("Rendering preview...")
+	output = filepath.Join(absDir, previewName)
+	renderGif = true
+	useDefaults = true
+	silenceOutput = true
+	if err := render(cmd, []string{dir}); err != nil {
+		return fmt.Errorf("rendering preview: %w", err)
+	}
+	fmt.Printf("Preview written to %s\n", filepath.Join(dir, previewName))
+
+	if publishDryRun {
+		return nil
+	}
+
+	if _, err := exec.LookPath("gh"); err != nil {
+		return fmt.Errorf("the GitHub CLI is needed to open pull requests, install it from https://cli.github.com")
+	}
+
+	// 3. commit and push
+	branch := fmt.Sprintf("publish/%s", m.ID)
+
+	verb := "Add"
+	if _, err := runGit(root, "cat-file", "-e", fmt.Sprintf("%s/%s:%s", publishRemote, publishBase, relDir)); err == nil {
+		verb = "Update"
+	}
+	title := fmt.Sprintf("%s: %s app", m.Name, strings.ToLower(verb))
+
+	if _, err := runGit(root, "checkout", "-B", branch); err != nil {
+		return err
+	}
+
+	if _, err := runGit(root, "add", "--", relDir); err != nil {
+		return err
+	}
+
+	if _, err := runGit(root, "diff", "--cached", "--quiet", "--", relDir); err != nil {
+		// there are staged changes
+		if _, err := runGit(root, "commit", "-m", title, "--", relDir); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Pushing %s to %s...\n", branch, publishRemote)
+	if _, err := runGit(root, "push", "--force-with-lease", "--set-upstream", publishRemote, branch); err != nil {
+		return err
+	}
+
+	// 4. pull request
+	remoteURL, err := runGit(root, "remote", "get-url", publishRemote)
+	if err != nil {
+		return err
+	}
+	head, err := vcsurl.Parse(remoteURL)
+	if err != nil {
+		return fmt.Errorf("parsing URL of remote %s: %w", publishRemote, err)
+	}
+	headRef := fmt.Sprintf("%s:%s", head.Username, branch)
+
+	url, err := runGH(root, "pr", "view", headRef, "--repo", publishRepo, "--json", "url", "--jq", ".url")
+	if err == nil && url != "" {
+		fmt.Printf("Updated pull request %s\n", url)
+		return nil
+	}
+
+	previewURL := fmt.Sprintf(
+		"https://raw.githubusercontent.com/%s/%s/%s/%s/%s",
+		head.Username, head.Name, branch, relDir, previewName,
+	)
+	body := fmt.Sprintf(
+		"## %s\n\n%s\n\n%s\n\nAuthor: %s\n\n![Preview of %s](%s)\n\nSubmitted with `pixlet publish`, after `pixlet check` passed.\n",
+		m.Name, m.Summary, m.Desc, m.Author, m.Name, previewURL,
+	)
+
+	url, err = runGH(
+		root, "pr", "create",
+		"--repo", publishRepo,
+		"--base", publishBase,
+		"--head", headRef,
+		"--title", title,
+		"--body", body,
+	)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Opened pull request %s\n", url)
+	return nil
+}
+
+// runGit runs git in dir, and returns what it printed.
+func runGit(dir string, args ...string) (string, error) {
+	return runTool(dir, "git", args...)
+}
+
+// runGH runs the GitHub CLI in dir, and returns what it printed.
+func runGH(dir string, args ...string) (string, error) {
+	return runTool(dir, "gh", args...)
+}
+
+func runTool(dir string, name string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	c := exec.Command(name, args...)
+	c.Dir = dir
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+
+	if err := c.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("%s %s: %s", name, args[0], msg)
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
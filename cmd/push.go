@@ -1,50 +1,107 @@
 package cmd
 
 import (
-	"bytes"
-	"encoding/base64"
+	"context"
+	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
-	"net/http"
 	"os"
+	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/spf13/cobra"
+	"tidbyt.dev/pixlet/api"
 	"tidbyt.dev/pixlet/cmd/config"
+	"tidbyt.dev/pixlet/tools/mdns"
 )
 
 const (
-	TidbytAPIPush = "https://api.tidbyt.com/v0/devices/%s/push"
-	APITokenEnv   = "TIDBYT_API_TOKEN"
+	APITokenEnv = api.TokenEnv
 )
 
 var (
 	apiToken       string
 	installationID string
 	background     bool
+	pushRetries    int
+	queueDir       string
+	flushQueue     bool
+	pushLocal      bool
+	localURL       string
+	localFallback  bool
 )
 
-type TidbytPushJSON struct {
-	DeviceID       string `json:"deviceID"`
-	Image          string `json:"image"`
-	InstallationID string `json:"installationID"`
-	Background     bool   `json:"background"`
-}
-
 func init() {
 	PushCmd.Flags().StringVarP(&apiToken, "api-token", "t", "", "Tidbyt API token")
 	PushCmd.Flags().StringVarP(&installationID, "installation-id", "i", "", "Give your installation an ID to keep it in the rotation")
 	PushCmd.Flags().BoolVarP(&background, "background", "b", false, "Don't immediately show the image on the device")
+	PushCmd.Flags().IntVarP(&pushRetries, "retries", "", 3, "Number of times to retry pushes that fail for transient reasons")
+	PushCmd.Flags().StringVarP(&queueDir, "queue-dir", "", "", "Queue pushes that keep failing in this directory, and retry them on the next push")
+	PushCmd.Flags().BoolVarP(&flushQueue, "flush-queue", "", false, "Only retry the pushes queued in --queue-dir")
+	PushCmd.Flags().BoolVarP(&pushLocal, "local", "l", false, "Push straight to devices on the local network, instead of through the API")
+	PushCmd.Flags().StringVarP(&localURL, "local-url", "", "", "With --local, push to the server at this URL instead of discovering devices")
+	PushCmd.Flags().BoolVarP(&localFallback, "local-fallback", "", false, "Push to devices on the local network when pushing through the API fails")
 }
 
 var PushCmd = &cobra.Command{
 	Use:   "push [device ID] [webp image]",
 	Short: "Render a Pixlet script and push the WebP output to a Tidbyt",
-	Args:  cobra.MinimumNArgs(2),
-	RunE:  push,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if flushQueue {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.MinimumNArgs(2)(cmd, args)
+	},
+	RunE: push,
+	Long: `Push a WebP image to a Tidbyt.
+
+To push to several devices at once, separate their IDs with commas.
+Devices can also be pushed to as a group, written @<group>, with groups
+defined in groups.yaml in your Tidbyt config directory:
+
+  office:
+    - first-device-id
+    - second-device-id
+
+For example, pixlet push @office,another-device-id image.webp pushes to
+all three devices, and reports on each.
+
+Pushes that fail because of network trouble, rate limiting or server
+errors are retried with increasing delays, up to --retries times. If
+they still fail and --queue-dir is given, the push is saved there
+instead, and retried by the next push using the same directory. This
+suits pushing from cron on a flaky connection. Only the latest image
+for each device and installation is kept. To retry the queue without
+pushing anything new, pass --flush-queue.
+
+With --local, the image is sent straight to the device on the local
+network, without going through the Tidbyt API, for devices and DIY
+receivers that accept local pushes. Devices are found using mDNS, by the
+"id" in the TXT record of their _tidbyt._tcp service, and are sent the
+same request as the API gets, at /v0/devices/<id>/push. To push to a
+server that receives pushes for several devices, like a self hosted
+Tronbyt server, give its address with --local-url instead. Local pushes
+don't need an API token, but send one if you have it.
+
+With --local-fallback, pushes go through the API as usual, but are sent
+to the device locally if that fails, like when the API is down or rate
+limiting you.`,
 }
 
 func push(cmd *cobra.Command, args []string) error {
+	if flushQueue {
+		if queueDir == "" {
+			return fmt.Errorf("--flush-queue needs --queue-dir")
+		}
+		if err := resolveAPIToken(cmd); err != nil {
+			return err
+		}
+		return flushPushQueue()
+	}
+
 	deviceID := args[0]
 	image := args[1]
 
@@ -59,16 +116,21 @@ func push(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("Background push won't do anything unless you also specify an installation ID")	
 	}
 
-	if apiToken == "" {
-		apiToken = os.Getenv(APITokenEnv)
+	if localURL != "" && !localFallback {
+		pushLocal = true
 	}
 
-	if apiToken == "" {
-		apiToken = config.OAuthTokenFromConfig(cmd.Context())
+	if pushLocal {
+		resolveLocalPushToken(cmd)
+	} else if err := resolveAPIToken(cmd); err != nil {
+		return err
 	}
 
-	if apiToken == "" {
-		return fmt.Errorf("blank Tidbyt API token (use `pixlet login`, set $%s or pass with --api-token)", APITokenEnv)
+	// retry whatever earlier runs couldn't push, before it goes stale
+	if queueDir != "" {
+		if err := flushPushQueue(); err != nil {
+			fmt.Println(err)
+		}
 	}
 
 	imageData, err := ioutil.ReadFile(image)
@@ -76,41 +138,287 @@ func push(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to read file %s: %w", image, err)
 	}
 
-	payload, err := json.Marshal(
-		TidbytPushJSON{
-			DeviceID:       deviceID,
-			Image:          base64.StdEncoding.EncodeToString(imageData),
-			InstallationID: installationID,
-			Background:     background,
-		},
-	)
+	deviceIDs, err := config.ResolveDevices(deviceID)
 	if err != nil {
-		return fmt.Errorf("failed to marshal json: %w", err)
+		return err
+	}
+
+	if len(deviceIDs) == 1 {
+		return pushImage(deviceIDs[0], imageData)
+	}
+
+	// push to all devices at once, and report on each when done
+	errs := make([]error, len(deviceIDs))
+	var wg sync.WaitGroup
+	for i, id := range deviceIDs {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			errs[i] = pushImage(id, imageData)
+		}(i, id)
+	}
+	wg.Wait()
+
+	failed := 0
+	for i, id := range deviceIDs {
+		if errs[i] != nil {
+			fmt.Printf("%s: failed: %v\n", id, errs[i])
+			failed++
+		} else {
+			fmt.Printf("%s: ok\n", id)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("push failed for %d of %d devices", failed, len(deviceIDs))
+	}
+
+	return nil
+}
+
+// resolveLocalPushToken finds the API token to send with local pushes,
+// if there is one. Local pushes don't need one, so it's not an error if
+// there isn't.
+func resolveLocalPushToken(cmd *cobra.Command) {
+	if apiToken == "" {
+		apiToken = os.Getenv(APITokenEnv)
+	}
+
+	if apiToken == "" {
+		apiToken, _ = config.OAuthToken(cmd.Context())
+	}
+}
+
+// pushImage pushes imageData to a single device. If the push fails for
+// a transient reason and a queue directory is set, it's queued instead.
+func pushImage(deviceID string, imageData []byte) error {
+	return sendPush(&api.Push{
+		DeviceID:       deviceID,
+		Image:          imageData,
+		InstallationID: installationID,
+		Background:     background,
+	})
+}
+
+// sendPush sends push, locally or through the API as asked for by the
+// flags, retrying and queueing it if that fails.
+func sendPush(push *api.Push) error {
+	deviceID := push.DeviceID
+
+	post := postPush
+	if pushLocal {
+		post = postLocalPush
+	}
+
+	err := postPushWithRetries(push, post)
+
+	var transient *transientPushError
+	if err != nil && localFallback && !pushLocal && errors.As(err, &transient) {
+		lerr := postPushWithRetries(push, postLocalPush)
+		if lerr == nil {
+			fmt.Printf("%s: pushed locally, since pushing through the API failed: %v\n", deviceID, err)
+			return nil
+		}
+		err = fmt.Errorf("%w (and pushing locally failed: %v)", err, lerr)
+	}
+
+	if err != nil && queueDir != "" && errors.As(err, &transient) {
+		if qerr := queuePush(push); qerr != nil {
+			return fmt.Errorf("%w (and queueing failed: %v)", err, qerr)
+		}
+		fmt.Printf("%s: queued for later, since push failed: %v\n", deviceID, err)
+		return nil
+	}
+
+	return err
+}
+
+// transientPushError is a push failure that might go away when retried.
+type transientPushError struct {
+	err error
+	// retryAfter is how long the API asked us to wait, if it did.
+	retryAfter time.Duration
+}
+
+func (e *transientPushError) Error() string { return e.err.Error() }
+func (e *transientPushError) Unwrap() error { return e.err }
+
+// postPushWithRetries pushes, retrying transient failures with
+// exponential backoff.
+func postPushWithRetries(push *api.Push, post func(*api.Push) error) error {
+	delay := time.Second
+	for attempt := 0; ; attempt++ {
+		err := post(push)
+
+		var transient *transientPushError
+		if err == nil || !errors.As(err, &transient) || attempt >= pushRetries {
+			return err
+		}
+
+		wait := delay
+		if transient.retryAfter > wait {
+			wait = transient.retryAfter
+		}
+		time.Sleep(wait)
+		delay *= 2
 	}
+}
+
+func postPush(push *api.Push) error {
+	return postPushTo(api.NewClient(apiToken), push)
+}
+
+// postPushTo sends push with client, sorting out which failures are
+// worth retrying.
+func postPushTo(client *api.Client, push *api.Push) error {
+	err := client.Push(context.Background(), push)
 
-	client := &http.Client{}
-	req, err := http.NewRequest(
-		"POST",
-		fmt.Sprintf(TidbytAPIPush, deviceID),
-		bytes.NewReader(payload),
-	)
+	var apiErr *api.Error
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &apiErr):
+		if apiErr.Temporary() {
+			return &transientPushError{err: err, retryAfter: apiErr.RetryAfter}
+		}
+		return err
+	default:
+		// couldn't reach the server
+		return &transientPushError{err: fmt.Errorf("pushing to %s: %w", client.BaseURL(), err)}
+	}
+}
+
+// queuePush saves a push to retry later. Pushes are keyed by device and
+// installation, so a newer image replaces one still waiting.
+func queuePush(push *api.Push) error {
+	if err := os.MkdirAll(queueDir, 0700); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(push)
 	if err != nil {
-		return fmt.Errorf("creating POST request: %w", err)
+		return err
 	}
 
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", apiToken))
+	key := sha256.Sum256([]byte(push.DeviceID + "\x00" + push.InstallationID))
+	name := filepath.Join(queueDir, fmt.Sprintf("%x.json", key[:8]))
 
-	resp, err := client.Do(req)
+	// write and rename, so a crash never leaves half a push behind
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
+
+// flushPushQueue retries the queued pushes. Pushes that succeed, or fail
+// for good, are removed from the queue.
+func flushPushQueue() error {
+	paths, err := filepath.Glob(filepath.Join(queueDir, "*.json"))
 	if err != nil {
-		return fmt.Errorf("pushing to API: %w", err)
+		return err
+	}
+
+	failed := 0
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading queued push: %w", err)
+		}
+
+		push := &api.Push{}
+		if err := json.Unmarshal(data, push); err != nil {
+			fmt.Printf("dropping unreadable queued push %s: %v\n", path, err)
+			os.Remove(path)
+			continue
+		}
+
+		err = postPushWithRetries(push, postPush)
+
+		var transient *transientPushError
+		if errors.As(err, &transient) {
+			failed++
+			continue
+		}
+
+		if err != nil {
+			fmt.Printf("%s: dropping queued push: %v\n", push.DeviceID, err)
+		} else {
+			fmt.Printf("%s: pushed queued image\n", push.DeviceID)
+		}
+		os.Remove(path)
 	}
 
-	if resp.StatusCode != 200 {
-		fmt.Printf("Tidbyt API returned status %s\n", resp.Status)
-		body, _ := ioutil.ReadAll(resp.Body)
-		fmt.Println(string(body))
-		return fmt.Errorf("Tidbyt API returned status: %s", resp.Status)
+	if failed > 0 {
+		return fmt.Errorf("%d queued pushes still failing, keeping them queued", failed)
 	}
 
 	return nil
 }
+
+var (
+	localDevicesMu sync.Mutex
+	localDevices   map[string]string
+)
+
+// postLocalPush sends push straight to the device on the local network,
+// or to --local-url.
+func postLocalPush(push *api.Push) error {
+	if localURL != "" {
+		return postPushTo(api.NewClient(apiToken, api.WithBaseURL(localURL)), push)
+	}
+
+	addr, err := localDeviceAddr(push.DeviceID)
+	if err != nil {
+		return err
+	}
+
+	// devices accept the same requests as the API
+	return postPushTo(api.NewClient(apiToken, api.WithBaseURL("http://"+addr)), push)
+}
+
+// localDeviceAddr returns the address of a device on the local network.
+// Devices found are remembered for other pushes, and looked for again
+// when a device isn't among them, since it may have just come online.
+func localDeviceAddr(deviceID string) (string, error) {
+	localDevicesMu.Lock()
+	defer localDevicesMu.Unlock()
+
+	if addr, ok := localDevices[deviceID]; ok {
+		return addr, nil
+	}
+
+	found, err := discoverDeviceAddrs()
+	if err != nil {
+		return "", &transientPushError{err: err}
+	}
+	localDevices = found
+
+	addr, ok := localDevices[deviceID]
+	if !ok {
+		return "", &transientPushError{err: fmt.Errorf("device %s not found on the local network", deviceID)}
+	}
+
+	return addr, nil
+}
+
+// discoverDeviceAddrs finds devices on the local network, and returns
+// their addresses by device ID.
+func discoverDeviceAddrs() (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), discoverTimeout)
+	defer cancel()
+
+	services, err := mdns.Browse(ctx, TidbytMDNSService)
+	if err != nil {
+		return nil, fmt.Errorf("discovering devices: %w", err)
+	}
+
+	addrs := map[string]string{}
+	for _, s := range services {
+		if id := s.Text["id"]; id != "" {
+			addrs[id] = serviceAddr(s)
+		}
+	}
+
+	return addrs, nil
+}
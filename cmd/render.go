@@ -1,21 +1,41 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
-	"image"
+	"image/png"
+	"io"
 	"io/fs"
+	"log"
+	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"testing/fstest"
 	"time"
 
+	"github.com/dustin/go-humanize"
+	"github.com/gorilla/websocket"
 	"github.com/spf13/cobra"
+	"go.starlark.net/starlark"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/text/language"
+	"gopkg.in/yaml.v3"
 
 	"tidbyt.dev/pixlet/encode"
 	"tidbyt.dev/pixlet/globals"
 	"tidbyt.dev/pixlet/runtime"
+	"tidbyt.dev/pixlet/runtime/modules/device"
+	"tidbyt.dev/pixlet/runtime/modules/starlarkhttp"
+	"tidbyt.dev/pixlet/server"
+	"tidbyt.dev/pixlet/server/fanout"
 	"tidbyt.dev/pixlet/tools"
+	"tidbyt.dev/pixlet/tools/secrets"
+	"tidbyt.dev/pixlet/tracing"
 )
 
 var (
@@ -27,12 +47,54 @@ var (
 	width         int
 	height        int
 	timeout       int
+	dither        string
+	renderPNG     bool
+	pngFrame      string
+	useDefaults   bool
+	deviceModel   string
+	locale        string
+	timezone      string
+	brightness    int
+	configFile    string
+	matrixPath    string
+	parallel      int
+	renderWatch   bool
+	notifyPort    int
+	maxFrames     int
+	maxMemory     string
+	framesDir     string
+	recordPath    string
+	replayPath    string
+
+	httpRateLimit       string
+	httpRateLimitPolicy string
+	httpRateLimitPerApp bool
+
+	secretsSpec string
 )
 
 func init() {
 	RenderCmd.Flags().StringVarP(&output, "output", "o", "", "Path for rendered image")
 	RenderCmd.Flags().BoolVarP(&renderGif, "gif", "", false, "Generate GIF instead of WebP")
+	RenderCmd.Flags().BoolVarP(&renderPNG, "png", "", false, "Generate a single frame PNG instead of WebP")
+	RenderCmd.Flags().StringVarP(&pngFrame, "frame", "", "midpoint", "Frame to render with --png (index or 'midpoint')")
 	RenderCmd.Flags().BoolVarP(&silenceOutput, "silent", "", false, "Silence print statements when rendering app")
+	RenderCmd.Flags().BoolVarP(&useDefaults, "defaults", "", false, "Use schema defaults for config parameters that aren't provided")
+	RenderCmd.Flags().StringVarP(&dither, "dither", "", "", "Dither frames for low bit depth displays (ordered or diffusion)")
+	RenderCmd.Flags().StringVarP(&deviceModel, "device-model", "", "", "Device model to report to the app")
+	RenderCmd.Flags().StringVarP(&locale, "locale", "", "", "Locale to report to the app, like en-US")
+	RenderCmd.Flags().StringVarP(&timezone, "timezone", "", "", "Timezone to report to the app, like America/New_York")
+	RenderCmd.Flags().IntVarP(&brightness, "brightness", "", -1, "Display brightness in percent to report to the app")
+	RenderCmd.Flags().StringVarP(&configFile, "config-file", "c", "", "Read config from this JSON or YAML file")
+	RenderCmd.Flags().StringVarP(&matrixPath, "matrix", "", "", "Render every config set in this JSON or YAML file to its own image")
+	RenderCmd.Flags().BoolVarP(&renderWatch, "watch", "", false, "Render again whenever the app changes")
+	RenderCmd.Flags().IntVarP(&notifyPort, "notify-port", "", 0, "With --watch, send each new image to websocket clients on this port")
+	RenderCmd.Flags().IntVarP(&parallel, "parallel", "", 1, "Number of config sets to render at once with --matrix")
+	RenderCmd.Flags().StringVarP(&framesDir, "frames", "", "", "Write every frame as a PNG to this directory, with their delays in frames.json")
+	RenderCmd.Flags().StringVarP(&recordPath, "record", "", "", "Record the app's HTTP requests and responses to this cassette file")
+	RenderCmd.Flags().StringVarP(&replayPath, "replay", "", "", "Answer the app's HTTP requests from this cassette file, without a network")
+	RenderCmd.Flags().IntVarP(&maxFrames, "max-frames", "", 0, "Fail if the app renders more frames than this")
+	RenderCmd.Flags().StringVarP(&maxMemory, "max-memory", "", "", "Stop the app if it uses more memory than this, like 256MB")
 	RenderCmd.Flags().IntVarP(
 		&magnify,
 		"magnify",
@@ -68,6 +130,7 @@ func init() {
 		30000,
 		"Timeout for execution (ms)",
 	)
+	addSecretsFlag(RenderCmd)
 }
 
 var RenderCmd = &cobra.Command{
@@ -79,46 +142,141 @@ var RenderCmd = &cobra.Command{
 
 The path argument should be the path to the Pixlet app to run. The
 app can be a single file with the .star extension, or a directory
-containing multiple Starlark files and resources.
+containing multiple Starlark files and resources. Pass - to read a
+single file app from stdin.
+
+The image is written next to the app, or to the path given by --output.
+Pass --output - to write it to stdout, which is also the default when
+the app is read from stdin. It's a WebP, unless --gif or --png is given.
+
+Config can also be read from a JSON or YAML file with --config-file,
+which keeps long values and secrets out of your shell history.
+Structured values, like a location, can be written out in the file and
+are passed to the app as JSON:
+
+  units: metric
+  location:
+    lat: "40.7"
+    lng: "-74.0"
+
+Config given on the command line overrides the file.
+
+With --matrix, the app is rendered once for each named config set in
+the given file, which maps names to config:
+
+  sunny:
+    location: '{"lat": "40.7", "lng": "-74.0"}'
+  snowy:
+    location: '{"lat": "64.1", "lng": "-21.9"}'
+
+Each set is rendered to <app>-<name>.webp, or to <name>.webp in the
+directory given by --output. Config passed on the command line applies
+to every set, unless the set overrides it.
+
+With --watch, render keeps running and renders the app again whenever
+it changes, for use with your own image viewer. Add --notify-port to
+also send each image to websocket clients at ws://127.0.0.1:<port>/ws,
+as JSON messages like {"type": "img", "img_type": "webp", "message":
+"<base64 image>"}. Errors are sent with type "error".
+
+With --frames, every frame is written as a numbered PNG to the given
+directory instead, like frame-0000.png, along with frames.json, which
+lists the frames and how long each is shown:
+
+  {"width": 64, "height": 32, "frames": [{"file": "frame-0000.png",
+  "delay_ms": 50}, ...]}
+
+PNGs left in the directory from an earlier render are removed first.
+With --matrix, each config set gets its own subdirectory.
+
+For developing offline, --record saves every HTTP request the app
+makes and its response to a cassette file, and --replay answers the
+app's requests from the cassette instead of the network. Requests that
+weren't recorded fail. Request headers and response cookies aren't
+recorded, and neither are the values of query parameters that look
+like credentials, like api_key, or of form and JSON fields like
+client_secret and access_token, so cassettes can be committed and
+replayed in CI without live keys.
+
+To cap misbehaving apps, like in CI, --timeout stops an app that runs
+too long, --max-frames fails a render with too many frames, and
+--max-memory stops an app that allocates too much. Memory is measured
+for the whole process, so it's approximate.
 	`,
 }
 
 func render(cmd *cobra.Command, args []string) error {
 	path := args[0]
 
-	// check if path exists, and whether it is a directory or a file
-	info, err := os.Stat(path)
-	if err != nil {
-		return fmt.Errorf("failed to stat %s: %w", path, err)
+	if renderGif && renderPNG {
+		return fmt.Errorf("--gif and --png can't be combined")
+	}
+
+	ext := ".webp"
+	if renderGif {
+		ext = ".gif"
+	} else if renderPNG {
+		ext = ".png"
 	}
 
 	var fs fs.FS
 	var outPath string
-	if info.IsDir() {
-		fs = os.DirFS(path)
-		outPath = filepath.Join(path, filepath.Base(path))
+	appID := filepath.Base(path)
+	if path == "-" {
+		src, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("reading stdin: %w", err)
+		}
+
+		// an app read from stdin is written to stdout, unless told
+		// otherwise, so that it can be used in a pipeline
+		fs = fstest.MapFS{"stdin.star": {Data: src}}
+		appID = "stdin"
+		outPath = "-"
 	} else {
-		if !strings.HasSuffix(path, ".star") {
-			return fmt.Errorf("script file must have suffix .star: %s", path)
+		// check if path exists, and whether it is a directory or a file
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("failed to stat %s: %w", path, err)
 		}
 
-		fs = tools.NewSingleFileFS(path)
-		outPath = strings.TrimSuffix(path, ".star")
-	}
+		if info.IsDir() {
+			fs = os.DirFS(path)
+			outPath = filepath.Join(path, filepath.Base(path)) + ext
+		} else {
+			if !strings.HasSuffix(path, ".star") {
+				return fmt.Errorf("script file must have suffix .star: %s", path)
+			}
 
-	if renderGif {
-		outPath += ".gif"
-	} else {
-		outPath += ".webp"
+			fs = tools.NewSingleFileFS(path)
+			outPath = strings.TrimSuffix(path, ".star") + ext
+		}
 	}
+
 	if output != "" {
 		outPath = output
 	}
 
-	globals.Width = width
-	globals.Height = height
+	ditherMethod, err := encode.ParseDitherMethod(dither)
+	if err != nil {
+		return err
+	}
+
+	frameIdx, err := encode.ParseFrameIndex(pngFrame)
+	if err != nil {
+		return err
+	}
+
+	globals.SetSize(width, height)
 
 	config := map[string]string{}
+	if configFile != "" {
+		config, err = readConfigFile(configFile)
+		if err != nil {
+			return err
+		}
+	}
+
 	for _, param := range args[1:] {
 		split := strings.Split(param, "=")
 		if len(split) < 2 {
@@ -127,93 +285,596 @@ func render(cmd *cobra.Command, args []string) error {
 		config[split[0]] = strings.Join(split[1:], "=")
 	}
 
+	if locale != "" {
+		if _, err := language.Parse(locale); err != nil {
+			return fmt.Errorf("invalid locale %q: %w", locale, err)
+		}
+	}
+	if timezone != "" {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return fmt.Errorf("invalid timezone %q: %w", timezone, err)
+		}
+	}
+	if brightness > 100 {
+		return fmt.Errorf("brightness must be a percentage, found %d", brightness)
+	}
+
+	limits, err := appLimits()
+	if err != nil {
+		return err
+	}
+
+	opts := []runtime.AppletOption{
+		runtime.WithLimits(limits),
+		runtime.WithDevice(device.Device{
+			Width:      width,
+			Height:     height,
+			Model:      deviceModel,
+			Locale:     locale,
+			Timezone:   timezone,
+			Brightness: brightness,
+		}),
+	}
+
 	// Remove the print function from the starlark thread if the silent flag is
-	// passed.
-	var opts []runtime.AppletOption
+	// passed. When the image goes to stdout, print to stderr instead so
+	// the two don't mix.
 	if silenceOutput {
 		opts = append(opts, runtime.WithPrintDisabled())
+	} else if outPath == "-" && matrixPath == "" {
+		opts = append(opts, runtime.WithPrintFunc(func(thread *starlark.Thread, msg string) {
+			fmt.Fprintf(os.Stderr, "[%s] %s\n", appID, msg)
+		}))
+	}
+
+	if err := initCassette(); err != nil {
+		return err
+	}
+
+	if err := initSecrets(); err != nil {
+		return err
 	}
 
-	ctx := context.Background()
-	if timeout > 0 {
-		ctx, _ = context.WithTimeoutCause(
-			ctx,
-			time.Duration(timeout)*time.Millisecond,
-			fmt.Errorf("timeout after %dms", timeout),
-		)
+	stopTracing, err := initTracing(cmd)
+	if err != nil {
+		return err
 	}
+	defer stopTracing()
 
 	cache := runtime.NewInMemoryCache()
 	runtime.InitHTTP(cache)
 	runtime.InitCache(cache)
 
-	applet, err := runtime.NewAppletFromFS(filepath.Base(path), fs, opts...)
+	filters := renderFilters(ditherMethod)
+
+	renderOnce := func() ([]byte, error) {
+		applet, err := runtime.NewAppletFromFS(appID, fs, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load applet: %w", err)
+		}
+
+		if matrixPath != "" {
+			return nil, renderMatrix(applet, config, filters, frameIdx, outPath, output, ext)
+		}
+
+		if framesDir != "" {
+			return nil, renderFrames(applet, config, filters, framesDir)
+		}
+
+		buf, err := renderConfig(applet, config, filters, frameIdx)
+		if err != nil {
+			return nil, err
+		}
+
+		if outPath == "-" {
+			_, err = os.Stdout.Write(buf)
+		} else {
+			err = os.WriteFile(outPath, buf, 0644)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("writing %s: %s", outPath, err)
+		}
+
+		return buf, nil
+	}
+
+	if !renderWatch {
+		_, err := renderOnce()
+		return err
+	}
+
+	if path == "-" {
+		return fmt.Errorf("--watch can't be used when reading the app from stdin")
+	}
+	if notifyPort > 0 && matrixPath != "" {
+		return fmt.Errorf("--notify-port can't be combined with --matrix")
+	}
+	if notifyPort > 0 && framesDir != "" {
+		return fmt.Errorf("--notify-port can't be combined with --frames")
+	}
+
+	return watchRender(path, strings.TrimPrefix(ext, "."), renderOnce)
+}
+
+// watchRender renders the app at path, and renders it again every time
+// it changes. Errors are reported without stopping. If --notify-port is
+// given, each new image is also sent to websocket clients, in the same
+// format as serve uses.
+func watchRender(path string, imageType string, renderOnce func() ([]byte, error)) error {
+	fo := fanout.NewFanout()
+	defer fo.Quit()
+
+	g := errgroup.Group{}
+
+	if notifyPort > 0 {
+		addr := fmt.Sprintf("127.0.0.1:%d", notifyPort)
+		mux := http.NewServeMux()
+		mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+			upgrader := websocket.Upgrader{
+				ReadBufferSize:  1024,
+				WriteBufferSize: 1024,
+			}
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				log.Printf("error establishing a new connection %v\n", err)
+				return
+			}
+			fo.NewClient(conn)
+		})
+
+		g.Go(func() error {
+			log.Printf("notifying websocket clients at ws://%s/ws\n", addr)
+			return http.ListenAndServe(addr, mux)
+		})
+	}
+
+	fileChanges := make(chan bool, 100)
+	g.Go(server.NewWatcher(path, fileChanges).Run)
+
+	g.Go(func() error {
+		for {
+			buf, err := renderOnce()
+			if err != nil {
+				log.Printf("error rendering: %v", err)
+				fo.Broadcast(fanout.WebsocketEvent{
+					Type:    fanout.EventTypeErr,
+					Message: err.Error(),
+				})
+			} else {
+				log.Println("rendered")
+				if buf != nil {
+					fo.Broadcast(fanout.WebsocketEvent{
+						Type:      fanout.EventTypeImage,
+						Message:   base64.StdEncoding.EncodeToString(buf),
+						ImageType: imageType,
+					})
+				}
+			}
+
+			<-fileChanges
+
+			// editors often save in several steps, so let them finish
+			// and render once for all of them
+			time.Sleep(100 * time.Millisecond)
+			for len(fileChanges) > 0 {
+				<-fileChanges
+			}
+		}
+	})
+
+	return g.Wait()
+}
+
+// renderConfig runs the applet with config and encodes the result as
+// asked for by the flags.
+func renderConfig(applet *runtime.Applet, config map[string]string, filters []encode.ImageFilter, frameIdx int) ([]byte, error) {
+	screens, err := runConfig(applet, config)
+	if err != nil {
+		return nil, err
+	}
+	defer screens.Release()
+
+	format := encode.FormatWebP
+	if renderGif {
+		format = encode.FormatGIF
+	} else if renderPNG {
+		format = encode.FormatPNG
+	}
+
+	return screens.Encode(encode.Options{
+		Format:      format,
+		MaxDuration: maxDuration,
+		Frame:       frameIdx,
+		Filters:     filters,
+	})
+}
+
+// framesMetadata is written to frames.json by --frames.
+type framesMetadata struct {
+	Width             int             `json:"width"`
+	Height            int             `json:"height"`
+	MaxAge            int32           `json:"max_age"`
+	ShowFullAnimation bool            `json:"show_full_animation"`
+	DurationMS        int             `json:"duration_ms"`
+	Frames            []frameMetadata `json:"frames"`
+}
+
+type frameMetadata struct {
+	File    string `json:"file"`
+	DelayMS int    `json:"delay_ms"`
+}
+
+// renderFrames runs the applet with config and writes each frame as a
+// PNG to dir, along with frames.json.
+func renderFrames(applet *runtime.Applet, config map[string]string, filters []encode.ImageFilter, dir string) error {
+	screens, err := runConfig(applet, config)
 	if err != nil {
-		return fmt.Errorf("failed to load applet: %w", err)
+		return err
 	}
 
-	roots, err := applet.RunWithConfig(ctx, config)
+	duration := maxDuration
+	if screens.ShowFullAnimation {
+		duration = 0
+	}
+
+	frames, err := screens.Frames(duration, filters...)
 	if err != nil {
-		return fmt.Errorf("error running script: %w", err)
+		return fmt.Errorf("error rendering: %w", err)
 	}
-	screens := encode.ScreensFromRoots(roots)
 
-	filter := func(input image.Image) (image.Image, error) {
-		if magnify <= 1 {
-			return input, nil
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("creating %s: %w", dir, err)
+	}
+
+	// frames from an earlier render would be mistaken for this one's
+	stale, err := filepath.Glob(filepath.Join(dir, "frame-*.png"))
+	if err != nil {
+		return err
+	}
+	for _, path := range stale {
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("removing %s: %w", path, err)
 		}
-		in, ok := input.(*image.RGBA)
-		if !ok {
-			return nil, fmt.Errorf("image not RGBA, very weird")
+	}
+
+	meta := framesMetadata{
+		MaxAge:            screens.MaxAge,
+		ShowFullAnimation: screens.ShowFullAnimation,
+		Frames:            []frameMetadata{},
+	}
+
+	for i, frame := range frames {
+		name := fmt.Sprintf("frame-%04d.png", i)
+
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, frame.Image); err != nil {
+			return fmt.Errorf("encoding %s: %w", name, err)
+		}
+
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", path, err)
+		}
+
+		meta.Width = frame.Image.Bounds().Dx()
+		meta.Height = frame.Image.Bounds().Dy()
+		meta.DurationMS += frame.Delay
+		meta.Frames = append(meta.Frames, frameMetadata{File: name, DelayMS: frame.Delay})
+	}
+
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, "frames.json")
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// runConfig runs the applet with config, filling in schema defaults if
+// asked for by the flags.
+func runConfig(applet *runtime.Applet, config map[string]string) (*encode.Screens, error) {
+	if useDefaults && applet.Schema != nil {
+		withDefaults := map[string]string{}
+		for id, value := range applet.Schema.Defaults() {
+			withDefaults[id] = value
+		}
+		for id, value := range config {
+			withDefaults[id] = value
+		}
+		config = withDefaults
+	}
+
+	roots, err := applet.RunWithConfig(context.Background(), config)
+	if err != nil {
+		return nil, fmt.Errorf("error running script: %w", err)
+	}
+
+	return encode.ScreensFromRoots(roots), nil
+}
+
+// renderFilters returns the filters for postprocessing frames, as asked
+// for by the flags.
+func renderFilters(ditherMethod encode.DitherMethod) []encode.ImageFilter {
+	filters := []encode.ImageFilter{encode.MagnifyFilter(magnify)}
+	if ditherMethod != encode.DitherNone {
+		filters = append([]encode.ImageFilter{
+			encode.DitherFilter(ditherMethod, encode.DefaultDitherBits),
+		}, filters...)
+	}
+
+	return filters
+}
+
+// configValues converts config read from a file to the strings apps
+// get. Structured values, like a location, are encoded as JSON, and
+// null values are left out.
+func configValues(values map[string]interface{}) (map[string]string, error) {
+	config := map[string]string{}
+	for k, v := range values {
+		switch v := v.(type) {
+		case nil:
+			continue
+		case string:
+			config[k] = v
+		case bool, int, int64, uint64, float64:
+			config[k] = fmt.Sprint(v)
+		default:
+			data, err := json.Marshal(v)
+			if err != nil {
+				return nil, fmt.Errorf("encoding %s: %w", k, err)
+			}
+			config[k] = string(data)
+		}
+	}
+
+	return config, nil
+}
+
+// readConfigFile reads config from a JSON or YAML file. Since YAML is a
+// superset of JSON, both can be parsed as YAML.
+func readConfigFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("parsing config %s: %w", path, err)
+	}
+
+	return configValues(values)
+}
+
+// readMatrix reads a file of named config sets, in the same format as
+// --config-file.
+func readMatrix(path string) (map[string]map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading matrix: %w", err)
+	}
+
+	sets := map[string]map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &sets); err != nil {
+		return nil, fmt.Errorf("parsing matrix %s: %w", path, err)
+	}
+
+	if len(sets) == 0 {
+		return nil, fmt.Errorf("matrix %s has no config sets", path)
+	}
+
+	matrix := map[string]map[string]string{}
+	for name, values := range sets {
+		if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+			return nil, fmt.Errorf("config set name %q can't be used as a file name", name)
+		}
+
+		config, err := configValues(values)
+		if err != nil {
+			return nil, fmt.Errorf("config set %s: %w", name, err)
+		}
+		matrix[name] = config
+	}
+
+	return matrix, nil
+}
+
+// renderMatrix renders each config set in the matrix file to its own
+// image. Sets are rendered on top of base, and up to --parallel at once.
+func renderMatrix(applet *runtime.Applet, base map[string]string, filters []encode.ImageFilter, frameIdx int, outPath string, outDir string, ext string) error {
+	matrix, err := readMatrix(matrixPath)
+	if err != nil {
+		return err
+	}
+
+	prefix := strings.TrimSuffix(outPath, ext) + "-"
+	if outDir != "" {
+		if err := os.MkdirAll(outDir, 0755); err != nil {
+			return fmt.Errorf("creating %s: %w", outDir, err)
 		}
+		prefix = outDir + string(filepath.Separator)
+	} else if outPath == "-" && framesDir == "" {
+		return fmt.Errorf("--matrix needs --output to be a directory when reading from stdin")
+	}
+
+	names := make([]string, 0, len(matrix))
+	for name := range matrix {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if parallel < 1 {
+		parallel = 1
+	}
 
-		out := image.NewRGBA(
-			image.Rect(
-				0, 0,
-				in.Bounds().Dx()*magnify,
-				in.Bounds().Dy()*magnify),
-		)
-		for x := 0; x < in.Bounds().Dx(); x++ {
-			for y := 0; y < in.Bounds().Dy(); y++ {
-				for xx := 0; xx < magnify; xx++ {
-					for yy := 0; yy < magnify; yy++ {
-						out.SetRGBA(
-							x*magnify+xx,
-							y*magnify+yy,
-							in.RGBAAt(x, y),
-						)
-					}
+	errs := make([]error, len(names))
+	g := errgroup.Group{}
+	g.SetLimit(parallel)
+	for i, name := range names {
+		i, name := i, name
+		g.Go(func() error {
+			config := map[string]string{}
+			for k, v := range base {
+				config[k] = v
+			}
+			for k, v := range matrix[name] {
+				config[k] = v
+			}
+
+			if framesDir != "" {
+				errs[i] = renderFrames(applet, config, filters, filepath.Join(framesDir, name))
+				return nil
+			}
+
+			buf, err := renderConfig(applet, config, filters, frameIdx)
+			if err == nil {
+				path := prefix + name + ext
+				if err = os.WriteFile(path, buf, 0644); err != nil {
+					err = fmt.Errorf("writing %s: %w", path, err)
 				}
 			}
+			errs[i] = err
+			return nil
+		})
+	}
+	g.Wait()
+
+	failed := 0
+	for i, err := range errs {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", names[i], err)
+			failed++
 		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d config sets failed to render", failed, len(names))
+	}
+
+	return nil
+}
+
+// appLimits returns the resource limits given by --timeout, --max-frames
+// and --max-memory.
+func appLimits() (runtime.Limits, error) {
+	limits := runtime.Limits{
+		Timeout:   time.Duration(timeout) * time.Millisecond,
+		MaxFrames: maxFrames,
+	}
 
-		return out, nil
+	if maxMemory != "" {
+		b, err := humanize.ParseBytes(maxMemory)
+		if err != nil {
+			return limits, fmt.Errorf("invalid --max-memory %q: %w", maxMemory, err)
+		}
+		limits.MaxMemory = b
 	}
 
-	var buf []byte
+	return limits, nil
+}
 
-	if screens.ShowFullAnimation {
-		maxDuration = 0
+// initCassette records or replays the HTTP requests apps make, as asked
+// for by --record and --replay. Call it before runtime.InitHTTP.
+func initCassette() error {
+	if recordPath != "" && replayPath != "" {
+		return fmt.Errorf("--record and --replay can't be used together")
 	}
 
-	if renderGif {
-		buf, err = screens.EncodeGIF(maxDuration, filter)
-	} else {
-		buf, err = screens.EncodeWebP(maxDuration, filter)
+	if recordPath != "" {
+		c, err := runtime.LoadCassette(recordPath)
+		if err != nil {
+			return err
+		}
+		runtime.SetHTTPTransport(runtime.RecordingTransport(c, http.DefaultTransport))
 	}
+
+	if replayPath != "" {
+		if _, err := os.Stat(replayPath); err != nil {
+			return fmt.Errorf("reading cassette: %w", err)
+		}
+		c, err := runtime.LoadCassette(replayPath)
+		if err != nil {
+			return err
+		}
+		runtime.SetHTTPTransport(runtime.ReplayingTransport(c))
+	}
+
+	return nil
+}
+
+// initRateLimit limits the HTTP requests apps make, as asked for by
+// --http-rate-limit. Call it before apps are loaded.
+func initRateLimit() error {
+	if httpRateLimit == "" {
+		return nil
+	}
+
+	limit, err := starlarkhttp.ParseRateLimit(httpRateLimit)
 	if err != nil {
-		return fmt.Errorf("error rendering: %w", err)
+		return fmt.Errorf("invalid --http-rate-limit: %w", err)
 	}
 
-	if outPath == "-" {
-		_, err = os.Stdout.Write(buf)
-	} else {
-		err = os.WriteFile(outPath, buf, 0644)
+	policy, err := starlarkhttp.ParseRateLimitPolicy(httpRateLimitPolicy)
+	if err != nil {
+		return fmt.Errorf("invalid --http-rate-limit-policy: %w", err)
+	}
+
+	starlarkhttp.StarlarkHTTPRateLimiter = &starlarkhttp.HostRateLimiter{
+		Default: limit,
+		PerApp:  httpRateLimitPerApp,
+		Policy:  policy,
 	}
+	return nil
+}
+
+// addRateLimitFlags adds the flags initRateLimit reads to cmd.
+func addRateLimitFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&httpRateLimit, "http-rate-limit", "", "", "Limit the HTTP requests apps make to each host, like 60/m")
+	cmd.Flags().StringVarP(&httpRateLimitPolicy, "http-rate-limit-policy", "", "queue", "What to do with requests over --http-rate-limit: queue or fail")
+	cmd.Flags().BoolVarP(&httpRateLimitPerApp, "http-rate-limit-per-app", "", false, "Apply --http-rate-limit to each app separately, rather than to all apps together")
+}
 
+// initSecrets sets where secret.get looks for secrets that aren't
+// embedded in apps, as asked for by --secrets. Call it before apps are
+// loaded.
+func initSecrets() error {
+	if secretsSpec == "" {
+		return nil
+	}
+
+	provider, err := secrets.ParseProvider(secretsSpec)
 	if err != nil {
-		return fmt.Errorf("writing %s: %s", outPath, err)
+		return fmt.Errorf("invalid --secrets: %w", err)
 	}
 
+	runtime.InitSecrets(provider)
 	return nil
 }
+
+// addSecretsFlag adds the flag initSecrets reads to cmd.
+func addSecretsFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&secretsSpec, "secrets", "", "", "Look up secrets apps don't embed here: env[:PREFIX], vault[:PATH] or aws[:SECRET_ID], comma separated")
+}
+
+// initTracing exports spans over OTLP if an endpoint is set with
+// OTEL_EXPORTER_OTLP_ENDPOINT. Call the returned function before the
+// command returns, so that the last spans are sent.
+func initTracing(cmd *cobra.Command) (func(), error) {
+	shutdown, err := tracing.Init(cmd.Context(), "pixlet")
+	if err != nil {
+		return nil, fmt.Errorf("setting up tracing: %w", err)
+	}
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			log.Printf("sending spans: %v\n", err)
+		}
+	}, nil
+}
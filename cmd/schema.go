@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"tidbyt.dev/pixlet/runtime"
+	"tidbyt.dev/pixlet/tools"
+)
+
+var schemaFormat string
+
+func init() {
+	SchemaCmd.Flags().StringVarP(
+		&schemaFormat,
+		"format",
+		"",
+		"pixlet",
+		"Output format (pixlet or jsonschema)",
+	)
+}
+
+var SchemaCmd = &cobra.Command{
+	Use:     "schema <path>",
+	Short:   "Print the config schema of a Pixlet app",
+	Example: `pixlet schema examples/clock --format jsonschema`,
+	Long: `Print the config schema of a Pixlet app.
+
+The path argument should be the path to the Pixlet app. The app can be a
+single file with the .star extension, or a directory containing multiple
+Starlark files and resources.
+
+By default, the schema is printed in the format used by the Tidbyt mobile
+app. Use --format jsonschema to convert it to a JSON Schema describing the
+app's config instead, for use with form generators and validation tools.`,
+	Args: cobra.ExactArgs(1),
+	RunE: printSchema,
+}
+
+func printSchema(cmd *cobra.Command, args []string) error {
+	path := args[0]
+
+	if schemaFormat != "pixlet" && schemaFormat != "jsonschema" {
+		return fmt.Errorf("unknown format %q, expected pixlet or jsonschema", schemaFormat)
+	}
+
+	// check if path exists, and whether it is a directory or a file
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	var fsys fs.FS
+	if info.IsDir() {
+		fsys = os.DirFS(path)
+	} else {
+		if !strings.HasSuffix(path, ".star") {
+			return fmt.Errorf("script file must have suffix .star: %s", path)
+		}
+
+		fsys = tools.NewSingleFileFS(path)
+	}
+
+	cache := runtime.NewInMemoryCache()
+	runtime.InitHTTP(cache)
+	runtime.InitCache(cache)
+
+	applet, err := runtime.NewAppletFromFS(filepath.Base(path), fsys, runtime.WithPrintDisabled())
+	if err != nil {
+		return fmt.Errorf("failed to load applet: %w", err)
+	}
+
+	if applet.Schema == nil {
+		return fmt.Errorf("%s doesn't have a schema", path)
+	}
+
+	js := applet.SchemaJSON
+	if schemaFormat == "jsonschema" {
+		js, err = applet.Schema.JSONSchema()
+		if err != nil {
+			return fmt.Errorf("converting schema: %w", err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, js, "", "  "); err != nil {
+		return fmt.Errorf("formatting schema: %w", err)
+	}
+	out.WriteByte('\n')
+
+	_, err = os.Stdout.Write(out.Bytes())
+	return err
+}
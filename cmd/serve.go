@@ -2,10 +2,15 @@ package cmd
 
 import (
 	"fmt"
+	"net"
+	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"tidbyt.dev/pixlet/configstore"
 	"tidbyt.dev/pixlet/server"
+	"tidbyt.dev/pixlet/server/auth"
 )
 
 var (
@@ -13,6 +18,16 @@ var (
 	port  int
 	watch bool
 	serveGif bool
+	workspace bool
+	rotationMode bool
+	dwell time.Duration
+	serveConfig string
+	serveConfigStore string
+	noSaveConfig bool
+	authConfig auth.Config
+	tlsCert string
+	tlsKey string
+	webhookSecret string
 )
 
 func init() {
@@ -21,7 +36,29 @@ func init() {
 	ServeCmd.Flags().BoolVarP(&watch, "watch", "w", true, "Reload scripts on change. Does not recurse sub-directories.")
 	ServeCmd.Flags().IntVarP(&maxDuration, "max_duration", "d", 15000, "Maximum allowed animation duration (ms)")
 	ServeCmd.Flags().IntVarP(&timeout, "timeout", "", 30000, "Timeout for execution (ms)")
+	ServeCmd.Flags().StringVarP(&recordPath, "record", "", "", "Record the apps' HTTP requests and responses to this cassette file")
+	ServeCmd.Flags().StringVarP(&replayPath, "replay", "", "", "Answer the apps' HTTP requests from this cassette file, without a network")
+	ServeCmd.Flags().IntVarP(&maxFrames, "max-frames", "", 0, "Fail renders with more frames than this")
+	ServeCmd.Flags().StringVarP(&maxMemory, "max-memory", "", "", "Stop apps that use more memory than this, like 256MB")
 	ServeCmd.Flags().BoolVarP(&serveGif, "gif", "", false, "Generate GIF instead of WebP")
+	ServeCmd.Flags().StringVarP(&serveConfig, "config", "c", "", "File to save config entered in the browser to (a directory with --workspace)")
+	ServeCmd.Flags().StringVarP(&serveConfigStore, "config-store", "", "", "Keep config entered in the browser in this store instead, like sqlite:config.db")
+	ServeCmd.Flags().BoolVarP(&noSaveConfig, "no-save-config", "", false, "Don't save config entered in the browser")
+	ServeCmd.Flags().StringVarP(&authConfig.Username, "auth-user", "", "", "Require signing in with this username, using basic auth")
+	ServeCmd.Flags().StringVarP(&authConfig.Password, "auth-password", "", "", "Password for --auth-user (or set PIXLET_SERVE_PASSWORD)")
+	ServeCmd.Flags().StringVarP(&authConfig.OIDCIssuer, "oidc-issuer", "", "", "Require signing in with this OpenID Connect provider")
+	ServeCmd.Flags().StringVarP(&authConfig.OIDCClientID, "oidc-client-id", "", "", "Client ID registered with the OpenID Connect provider")
+	ServeCmd.Flags().StringVarP(&authConfig.OIDCClientSecret, "oidc-client-secret", "", "", "Client secret for --oidc-client-id (or set PIXLET_OIDC_CLIENT_SECRET)")
+	ServeCmd.Flags().StringVarP(&authConfig.OIDCRedirectURL, "oidc-redirect-url", "", "", "Callback URL registered with the provider, if it differs from the URL serve is reached at")
+	ServeCmd.Flags().StringSliceVarP(&authConfig.AllowedEmails, "allow-email", "", nil, "Only let these email addresses sign in with OpenID Connect")
+	ServeCmd.Flags().StringVarP(&tlsCert, "tls-cert", "", "", "Serve HTTPS with this certificate file")
+	ServeCmd.Flags().StringVarP(&tlsKey, "tls-key", "", "", "Private key file for --tls-cert")
+	ServeCmd.Flags().StringVarP(&webhookSecret, "webhook-secret", "", "", "Require webhook requests to pass this secret (or set PIXLET_WEBHOOK_SECRET)")
+	ServeCmd.Flags().BoolVarP(&workspace, "workspace", "", false, "Serve every app in the directory, with a dashboard listing them")
+	ServeCmd.Flags().BoolVarP(&rotationMode, "rotation", "", false, "Cycle through every app in the directory like a device rotation (implies --workspace)")
+	ServeCmd.Flags().DurationVarP(&dwell, "dwell", "", 15*time.Second, "How long each app is shown with --rotation")
+	addRateLimitFlags(ServeCmd)
+	addSecretsFlag(ServeCmd)
 }
 
 var ServeCmd = &cobra.Command{
@@ -33,7 +70,42 @@ var ServeCmd = &cobra.Command{
 
 The path argument should be the path to the Pixlet program to run. The
 program can be a single file with the .star extension, or a directory
-containing multiple Starlark files and resources.`,
+containing multiple Starlark files and resources.
+
+With --workspace, the path should be a directory of apps instead. Each
+subdirectory holding .star files is served as an app, and so is each
+.star file at the top level. The root page lists all apps with their
+previews.
+
+With --rotation, serve also cycles through the apps like a device
+rotation, showing each for --dwell, and serves it at /rotation. Apps
+are rendered with the config entered for them, and apps that return no
+roots are skipped, the same as on a device.
+
+Config entered in the browser is saved, and restored the next time the
+app is served. It goes to a file in your user config directory unless
+--config picks another. Pass --no-save-config to keep it in memory only.
+
+--config-store keeps config in a store instead, one of memory,
+bolt:PATH or sqlite:PATH. An SQLite store can be shared with pixlet
+daemon, by giving it the same file as config_store, so that config
+entered in the browser is what the daemon renders with.
+
+By default, anyone who can reach serve can use it. When serving beyond
+localhost, require signing in with --auth-user and --auth-password, or
+with an OpenID Connect provider using --oidc-issuer and its client
+flags. The provider should allow <url>/auth/callback as a redirect URL.
+Add --tls-cert and --tls-key to serve over HTTPS.
+
+--webhook-secret makes /api/v1/webhook require requests to pass the
+secret as a bearer token or in the secret query parameter, or to sign
+their body with it the way GitHub does, the same as pixlet daemon.
+
+--record and --replay save the apps' HTTP requests to a cassette file,
+and answer them from it, the same as with pixlet render.
+
+For hosted previews, --timeout, --max-frames and --max-memory cap what
+each render may use, the same as with pixlet render.`,
 }
 
 func serve(cmd *cobra.Command, args []string) error {
@@ -41,9 +113,118 @@ func serve(cmd *cobra.Command, args []string) error {
 		fmt.Printf("explicitly setting --watch is unnecessary, since it's the default\n\n")
 	}
 
-	s, err := server.NewServer(host, port, watch, args[0], maxDuration, timeout, serveGif)
+	a, err := serveAuth(cmd)
+	if err != nil {
+		return err
+	}
+	if webhookSecret == "" {
+		webhookSecret = os.Getenv("PIXLET_WEBHOOK_SECRET")
+	}
+	if (tlsCert == "") != (tlsKey == "") {
+		return fmt.Errorf("--tls-cert and --tls-key must be used together")
+	}
+
+	limits, err := appLimits()
 	if err != nil {
 		return err
 	}
+
+	if err := initCassette(); err != nil {
+		return err
+	}
+
+	if err := initRateLimit(); err != nil {
+		return err
+	}
+
+	if err := initSecrets(); err != nil {
+		return err
+	}
+
+	stopTracing, err := initTracing(cmd)
+	if err != nil {
+		return err
+	}
+	defer stopTracing()
+
+	if serveConfigStore != "" && (noSaveConfig || serveConfig != "") {
+		return fmt.Errorf("--config-store can't be used with --config or --no-save-config")
+	}
+
+	var store configstore.Store
+	if serveConfigStore != "" {
+		store, err = configstore.Open(serveConfigStore)
+		if err != nil {
+			return fmt.Errorf("opening config store: %w", err)
+		}
+		defer store.Close()
+	}
+
+	if rotationMode {
+		workspace = true
+		if dwell <= 0 {
+			return fmt.Errorf("--dwell must be positive")
+		}
+	}
+
+	if workspace {
+		ws, err := server.NewWorkspace(host, port, watch, args[0], maxDuration, limits, serveGif, nil)
+		if err != nil {
+			return err
+		}
+		if store != nil {
+			if err := ws.UseConfigStore(store); err != nil {
+				return err
+			}
+		} else if !noSaveConfig {
+			if err := ws.PersistConfig(serveConfig); err != nil {
+				return err
+			}
+		}
+		if rotationMode {
+			if err := ws.EnableRotation(dwell); err != nil {
+				return err
+			}
+		}
+		ws.SetWebhookSecret(webhookSecret)
+		ws.Secure(a, tlsCert, tlsKey)
+		return ws.Run()
+	}
+
+	s, err := server.NewServer(host, port, watch, args[0], maxDuration, limits, serveGif, nil)
+	if err != nil {
+		return err
+	}
+	if store != nil {
+		if err := s.UseConfigStore(store); err != nil {
+			return err
+		}
+	} else if !noSaveConfig {
+		if err := s.PersistConfig(serveConfig); err != nil {
+			return err
+		}
+	}
+	s.SetWebhookSecret(webhookSecret)
+	s.Secure(a, tlsCert, tlsKey)
 	return s.Run()
 }
+
+// serveAuth sets up authentication as asked for by the flags. It returns
+// nil if none was asked for.
+func serveAuth(cmd *cobra.Command) (*auth.Auth, error) {
+	if authConfig.Password == "" {
+		authConfig.Password = os.Getenv("PIXLET_SERVE_PASSWORD")
+	}
+	if authConfig.OIDCClientSecret == "" {
+		authConfig.OIDCClientSecret = os.Getenv("PIXLET_OIDC_CLIENT_SECRET")
+	}
+
+	if !authConfig.Enabled() {
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			fmt.Printf("warning: serving on %s without authentication, anyone who can reach it can use it\n\n", host)
+		}
+		return nil, nil
+	}
+
+	return auth.New(cmd.Context(), authConfig)
+}
@@ -0,0 +1,243 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"tidbyt.dev/pixlet/render/rendertest"
+	"tidbyt.dev/pixlet/runtime"
+	"tidbyt.dev/pixlet/tools"
+)
+
+var (
+	snapshotDir       string
+	snapshotUpdate    bool
+	snapshotTolerance uint8
+	snapshotMagnify   int
+)
+
+func init() {
+	SnapshotCmd.Flags().StringVarP(&snapshotDir, "dir", "", "", "Directory with the snapshot configs and golden images (default: snapshots next to the app)")
+	SnapshotCmd.Flags().BoolVarP(&snapshotUpdate, "update", "u", false, "Write the rendered images as the new golden images")
+	SnapshotCmd.Flags().Uint8VarP(&snapshotTolerance, "tolerance", "", 0, "Maximum difference in any color channel before a pixel counts as changed")
+	SnapshotCmd.Flags().IntVarP(&snapshotMagnify, "diff-magnify", "", 4, "Scale up the diff images written for failed snapshots by this factor")
+	SnapshotCmd.Flags().BoolVarP(&useDefaults, "defaults", "", false, "Use schema defaults for config parameters that aren't provided")
+	SnapshotCmd.Flags().StringVarP(&replayPath, "replay", "", "", "Answer the app's HTTP requests from this cassette file, without a network")
+	SnapshotCmd.Flags().IntVarP(&timeout, "timeout", "", 30000, "Timeout for execution (ms)")
+}
+
+var SnapshotCmd = &cobra.Command{
+	Use:   "snapshot <path>",
+	Short: "Compare an app's rendered output against golden images",
+	Example: `  pixlet snapshot examples/clock
+  pixlet snapshot --update examples/clock
+  pixlet snapshot --tolerance 8 --replay testdata/api.json app.star`,
+	Long: `Compare an app's rendered output against golden images.
+
+The app is rendered once for every config set in configs.yaml, in the
+snapshot directory, and each image is compared pixel by pixel, frame by
+frame, with <name>.webp in the same directory. The config sets are in
+the same format as pixlet render --matrix:
+
+  sunny:
+    location: '{"lat": "40.7", "lng": "-74.0"}'
+  snowy:
+    location: '{"lat": "64.1", "lng": "-21.9"}'
+
+Without configs.yaml, the app is rendered once with no config, as the
+set named default. The snapshot directory is snapshots, next to the
+app, unless --dir is given.
+
+When a snapshot doesn't match, <name>.diff.png is written next to the
+golden image, showing the expected frame, the rendered frame and the
+pixels that differ. Small differences, like from dithering or a changed
+encoder, can be allowed with --tolerance.
+
+With --update, the rendered images are written as the new golden
+images instead, which is how they're created in the first place. Review
+and commit them along with the change that caused them.
+
+Apps that make HTTP requests render differently as the data changes,
+so replay recorded responses with --replay. See pixlet render --help.`,
+	Args: cobra.ExactArgs(1),
+	RunE: snapshot,
+}
+
+// snapshotConfigsFile names the file in the snapshot directory with the
+// config sets to render.
+const snapshotConfigsFile = "configs.yaml"
+
+func snapshot(cmd *cobra.Command, args []string) error {
+	path := args[0]
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	var fsys fs.FS
+	dir := path
+	if info.IsDir() {
+		fsys = os.DirFS(path)
+	} else {
+		if !strings.HasSuffix(path, ".star") {
+			return fmt.Errorf("script file must have suffix .star: %s", path)
+		}
+
+		fsys = tools.NewSingleFileFS(path)
+		dir = filepath.Dir(path)
+	}
+
+	if snapshotDir == "" {
+		snapshotDir = filepath.Join(dir, "snapshots")
+	}
+
+	configs, err := readSnapshotConfigs(snapshotDir)
+	if err != nil {
+		return err
+	}
+
+	if err := initCassette(); err != nil {
+		return err
+	}
+
+	cache := runtime.NewInMemoryCache()
+	runtime.InitHTTP(cache)
+	runtime.InitCache(cache)
+
+	limits, err := appLimits()
+	if err != nil {
+		return err
+	}
+
+	applet, err := runtime.NewAppletFromFS(filepath.Base(path), fsys, runtime.WithLimits(limits), runtime.WithPrintDisabled())
+	if err != nil {
+		return fmt.Errorf("failed to load applet: %w", err)
+	}
+
+	if snapshotUpdate {
+		if err := os.MkdirAll(snapshotDir, 0755); err != nil {
+			return fmt.Errorf("creating %s: %w", snapshotDir, err)
+		}
+	}
+
+	names := make([]string, 0, len(configs))
+	for name := range configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	failed := 0
+	for _, name := range names {
+		status, err := checkSnapshot(applet, name, configs[name])
+		if err != nil {
+			fmt.Printf("FAIL  %s: %s\n", name, strings.ReplaceAll(err.Error(), "\n", "\n      "))
+			failed++
+			continue
+		}
+		fmt.Printf("%-5s %s\n", status, name)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d snapshots failed", failed, len(names))
+	}
+
+	return nil
+}
+
+// readSnapshotConfigs reads the config sets in dir. Without a configs
+// file, there's a single set with no config.
+func readSnapshotConfigs(dir string) (map[string]map[string]string, error) {
+	path := filepath.Join(dir, snapshotConfigsFile)
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return map[string]map[string]string{"default": {}}, nil
+	}
+
+	return readMatrix(path)
+}
+
+// checkSnapshot renders the config set called name and compares it with
+// its golden image, or updates the golden image with --update. It
+// returns how the snapshot passed.
+func checkSnapshot(applet *runtime.Applet, name string, config map[string]string) (string, error) {
+	screens, err := runConfig(applet, config)
+	if err != nil {
+		return "", err
+	}
+
+	duration := maxDuration
+	if screens.ShowFullAnimation {
+		duration = 0
+	}
+
+	actual, err := screens.EncodeWebP(duration)
+	if err != nil {
+		return "", fmt.Errorf("error rendering: %w", err)
+	}
+
+	goldenPath := filepath.Join(snapshotDir, name+".webp")
+	diffPath := filepath.Join(snapshotDir, name+".diff.png")
+
+	golden, err := os.ReadFile(goldenPath)
+	if errors.Is(err, os.ErrNotExist) {
+		if !snapshotUpdate {
+			return "", fmt.Errorf("no golden image at %s, run with --update to create it", goldenPath)
+		}
+		if err := os.WriteFile(goldenPath, actual, 0644); err != nil {
+			return "", fmt.Errorf("writing %s: %w", goldenPath, err)
+		}
+		return "new", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading golden image: %w", err)
+	}
+
+	opts := rendertest.Options{Tolerance: snapshotTolerance}
+	result, err := rendertest.Compare(golden, actual, opts)
+	if err != nil {
+		return "", err
+	}
+
+	if result.Equal() {
+		// a diff from an earlier failure is out of date
+		os.Remove(diffPath)
+		return "ok", nil
+	}
+
+	if snapshotUpdate {
+		if err := os.WriteFile(goldenPath, actual, 0644); err != nil {
+			return "", fmt.Errorf("writing %s: %w", goldenPath, err)
+		}
+		os.Remove(diffPath)
+		return "updated", nil
+	}
+
+	if err := writeSnapshotDiff(diffPath, golden, actual, opts); err != nil {
+		return "", fmt.Errorf("doesn't match %s, and writing the diff failed: %w", goldenPath, err)
+	}
+
+	return "", fmt.Errorf("doesn't match %s, see %s\n%s", goldenPath, diffPath, strings.TrimSpace(result.String()))
+}
+
+// writeSnapshotDiff writes an image showing how the rendered image
+// differs from the golden image.
+func writeSnapshotDiff(path string, golden, actual []byte, opts rendertest.Options) error {
+	expectedFrames, err := rendertest.DecodeFrames(golden)
+	if err != nil {
+		return err
+	}
+
+	actualFrames, err := rendertest.DecodeFrames(actual)
+	if err != nil {
+		return err
+	}
+
+	return rendertest.WriteDiffFile(path, expectedFrames, actualFrames, opts, snapshotMagnify)
+}
@@ -0,0 +1,284 @@
+package cmd
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.starlark.net/starlark"
+
+	"tidbyt.dev/pixlet/runtime"
+	"tidbyt.dev/pixlet/tools"
+	"tidbyt.dev/pixlet/tools/coverage"
+)
+
+var (
+	testRun     string
+	testVerbose bool
+	testJUnit   string
+	testCover   bool
+	testTimeout time.Duration
+)
+
+func init() {
+	TestCmd.Flags().StringVarP(&testRun, "run", "", "", "Only run tests whose file/name matches this regular expression")
+	TestCmd.Flags().BoolVarP(&testVerbose, "verbose", "v", false, "Print every test, with its output")
+	TestCmd.Flags().StringVarP(&testJUnit, "junit", "", "", "Write a JUnit XML report to this file")
+	TestCmd.Flags().BoolVarP(&testCover, "cover", "", false, "Report which statements the tests ran")
+	TestCmd.Flags().StringVarP(&recordPath, "record", "", "", "Record the app's HTTP requests and responses to this cassette file")
+	TestCmd.Flags().StringVarP(&replayPath, "replay", "", "", "Answer the app's HTTP requests from this cassette file, without a network")
+	TestCmd.Flags().DurationVarP(&testTimeout, "timeout", "", 30*time.Second, "Fail tests that run longer than this")
+}
+
+var TestCmd = &cobra.Command{
+	Use:   "test <path>",
+	Short: "Run the tests in a Pixlet app",
+	Example: `  pixlet test examples/clock
+  pixlet test --run 'test_format_.*' -v app.star
+  pixlet test --junit report.xml --cover ./`,
+	Long: `Run the tests in a Pixlet app.
+
+Tests are functions whose names start with test_, in any Starlark file
+of the app. They check results with the assert module:
+
+  load("assert.star", "assert")
+
+  def test_format_price():
+      assert.eq(format_price(1.5), "$1.50")
+
+A test fails when an assertion fails or it stops with an error. Output
+from print() is shown for failed tests, or for every test with -v.
+
+Tests that make HTTP requests can run offline with --replay, from a
+cassette recorded with --record. See pixlet render --help.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAppTests,
+}
+
+// testResult is the outcome of a single test.
+type testResult struct {
+	name     string
+	file     string
+	failures []string
+	output   []string
+	duration time.Duration
+}
+
+func (r *testResult) Error(args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprint(args...))
+}
+
+func runAppTests(cmd *cobra.Command, args []string) error {
+	path := args[0]
+
+	var filter *regexp.Regexp
+	if testRun != "" {
+		var err error
+		filter, err = regexp.Compile(testRun)
+		if err != nil {
+			return fmt.Errorf("invalid --run pattern: %w", err)
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	var fsys fs.FS
+	if info.IsDir() {
+		fsys = os.DirFS(path)
+	} else {
+		if !strings.HasSuffix(path, ".star") {
+			return fmt.Errorf("script file must have suffix .star: %s", path)
+		}
+
+		fsys = tools.NewSingleFileFS(path)
+	}
+
+	if err := initCassette(); err != nil {
+		return err
+	}
+
+	cache := runtime.NewInMemoryCache()
+	runtime.InitHTTP(cache)
+	runtime.InitCache(cache)
+
+	// print output is collected for the test that's running
+	var current *testResult
+	opts := []runtime.AppletOption{
+		runtime.WithPrintFunc(func(thread *starlark.Thread, msg string) {
+			if current != nil {
+				current.output = append(current.output, msg)
+			} else {
+				fmt.Fprintln(os.Stderr, msg)
+			}
+		}),
+	}
+
+	var profile *coverage.Profile
+	if testCover {
+		profile = coverage.NewProfile()
+		fsys, err = profile.Instrument(fsys)
+		if err != nil {
+			return fmt.Errorf("instrumenting for coverage: %w", err)
+		}
+		opts = append(opts, runtime.WithPredeclared(profile.Predeclared()))
+	}
+
+	applet, err := runtime.NewAppletFromFS(path, fsys, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to load applet: %w", err)
+	}
+
+	start := time.Now()
+	results := []*testResult{}
+	failed := 0
+
+	for _, test := range applet.TestFuncs() {
+		name := fmt.Sprintf("%s/%s", test.File, test.Name)
+		if filter != nil && !filter.MatchString(name) {
+			continue
+		}
+
+		if testVerbose {
+			fmt.Printf("=== RUN   %s\n", name)
+		}
+
+		result := &testResult{name: test.Name, file: test.File}
+		current = result
+
+		ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+		testStart := time.Now()
+		err := applet.RunTest(ctx, test, result)
+		result.duration = time.Since(testStart)
+		cancel()
+
+		current = nil
+		if err != nil {
+			result.failures = append(result.failures, err.Error())
+		}
+		results = append(results, result)
+
+		status := "PASS"
+		if len(result.failures) > 0 {
+			status = "FAIL"
+			failed++
+		}
+
+		if testVerbose || status == "FAIL" {
+			fmt.Printf("--- %s: %s (%.2fs)\n", status, name, result.duration.Seconds())
+			for _, line := range append(result.output, result.failures...) {
+				fmt.Printf("    %s\n", strings.ReplaceAll(line, "\n", "\n    "))
+			}
+		}
+	}
+
+	elapsed := time.Since(start)
+
+	if len(results) == 0 {
+		fmt.Println("no tests to run")
+	} else if failed > 0 {
+		fmt.Printf("FAIL\t%s\t%.3fs\n", path, elapsed.Seconds())
+	} else {
+		fmt.Printf("ok\t%s\t%.3fs\t%d tests\n", path, elapsed.Seconds(), len(results))
+	}
+
+	if profile != nil {
+		printCoverage(profile)
+	}
+
+	if testJUnit != "" {
+		if err := writeJUnit(testJUnit, path, results, elapsed); err != nil {
+			return fmt.Errorf("writing JUnit report: %w", err)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d tests failed", failed, len(results))
+	}
+
+	return nil
+}
+
+func printCoverage(profile *coverage.Profile) {
+	fmt.Printf("coverage: %.1f%% of statements\n", profile.Total().Percent())
+
+	for _, fc := range profile.Files() {
+		fmt.Printf("  %s: %.1f%%", fc.File, fc.Percent())
+		if len(fc.Missed) > 0 {
+			missed := make([]string, len(fc.Missed))
+			for i, line := range fc.Missed {
+				missed[i] = fmt.Sprint(line)
+			}
+			fmt.Printf(" (missed lines %s)", strings.Join(missed, ", "))
+		}
+		fmt.Println()
+	}
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func writeJUnit(file string, app string, results []*testResult, elapsed time.Duration) error {
+	suite := junitTestSuite{
+		Name:  app,
+		Tests: len(results),
+		Time:  fmt.Sprintf("%.3f", elapsed.Seconds()),
+	}
+
+	for _, r := range results {
+		tc := junitTestCase{
+			Name:      r.name,
+			Classname: r.file,
+			Time:      fmt.Sprintf("%.3f", r.duration.Seconds()),
+			SystemOut: strings.Join(r.output, "\n"),
+		}
+		if len(r.failures) > 0 {
+			suite.Failures++
+			// the message is the gist, usually the last line of a backtrace
+			lines := strings.Split(strings.TrimSpace(r.failures[0]), "\n")
+			tc.Failure = &junitFailure{
+				Message: lines[len(lines)-1],
+				Text:    strings.Join(r.failures, "\n"),
+			}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	data, err := xml.MarshalIndent(junitTestSuites{Suites: []junitTestSuite{suite}}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(file, append([]byte(xml.Header), append(data, '\n')...), 0644)
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"tidbyt.dev/pixlet/metrics"
+	"tidbyt.dev/pixlet/runtime"
+	"tidbyt.dev/pixlet/worker"
+)
+
+var (
+	queueURL           string
+	workerName         string
+	workerCacheSize    int
+	coordinatorPort    int
+	coordinatorTimeout time.Duration
+)
+
+func init() {
+	WorkerCmd.Flags().StringVarP(&queueURL, "queue", "q", "", "URL of the queue to take jobs from, like redis://localhost:6379 or nats://localhost:4222")
+	WorkerCmd.Flags().StringVarP(&workerName, "name", "", "", "Name to send with results (default the host name)")
+	WorkerCmd.Flags().IntVarP(&workerCacheSize, "cache-size", "", worker.DefaultCacheSize, "How many apps to keep loaded")
+	WorkerCmd.Flags().IntVarP(&timeout, "timeout", "", 30000, "Timeout for execution (ms)")
+	WorkerCmd.Flags().IntVarP(&maxFrames, "max-frames", "", 0, "Fail renders with more frames than this")
+	WorkerCmd.Flags().StringVarP(&maxMemory, "max-memory", "", "", "Stop apps that use more memory than this, like 256MB")
+	WorkerCmd.Flags().StringVarP(&metricsAddr, "metrics-addr", "", "", "Serve Prometheus metrics over HTTP at this address, like :9090")
+	WorkerCmd.Flags().StringVarP(&replayPath, "replay", "", "", "Answer the apps' HTTP requests from this cassette file, without a network")
+	WorkerCmd.MarkFlagRequired("queue")
+	addRateLimitFlags(WorkerCmd)
+	addSecretsFlag(WorkerCmd)
+
+	CoordinatorCmd.Flags().StringVarP(&queueURL, "queue", "q", "", "URL of the queue to put jobs on, like redis://localhost:6379 or nats://localhost:4222")
+	CoordinatorCmd.Flags().StringVarP(&host, "host", "i", "127.0.0.1", "Host interface to listen on")
+	CoordinatorCmd.Flags().IntVarP(&coordinatorPort, "port", "p", 8090, "Port to listen on")
+	CoordinatorCmd.Flags().DurationVarP(&coordinatorTimeout, "timeout", "", worker.DefaultTimeout, "How long to wait for a worker to render each app")
+	CoordinatorCmd.MarkFlagRequired("queue")
+}
+
+var WorkerCmd = &cobra.Command{
+	Use:   "worker",
+	Short: "Render apps from a queue",
+	Args:  cobra.NoArgs,
+	RunE:  runWorker,
+	Long: `Render apps from a queue, for a coordinator.
+
+Large deployments can render on as many machines as they need: each
+runs pixlet worker, taking render jobs off a shared Redis or NATS queue
+and pushing back the images. Jobs are put on the queue by pixlet
+coordinator, or by programs using the worker package.
+
+A worker renders one app at a time, so run several on machines with
+cores to spare.`,
+}
+
+var CoordinatorCmd = &cobra.Command{
+	Use:   "coordinator",
+	Short: "Serve an HTTP API rendering apps on workers",
+	Args:  cobra.NoArgs,
+	RunE:  runCoordinator,
+	Long: `Serve an HTTP API rendering apps on workers.
+
+POST an app bundle, as made by pixlet bundle, to /render, with the
+config as query parameters, and the coordinator puts it on the queue
+for a worker (see pixlet worker) and responds with the image:
+
+  curl --data-binary @bundle.tar.gz \
+    'http://localhost:8090/render?_format=gif&timezone=UTC' > app.gif
+
+There's no authentication, so only listen on interfaces that untrusted
+clients can't reach.`,
+}
+
+func runWorker(cmd *cobra.Command, args []string) error {
+	limits, err := appLimits()
+	if err != nil {
+		return err
+	}
+
+	if err := initCassette(); err != nil {
+		return err
+	}
+
+	if err := initRateLimit(); err != nil {
+		return err
+	}
+
+	if err := initSecrets(); err != nil {
+		return err
+	}
+
+	stopTracing, err := initTracing(cmd)
+	if err != nil {
+		return err
+	}
+	defer stopTracing()
+
+	cache := runtime.NewInMemoryCache()
+	runtime.InitHTTP(cache)
+	runtime.InitCache(cache)
+
+	q, err := worker.Open(queueURL)
+	if err != nil {
+		return err
+	}
+	defer q.Close()
+
+	if metricsAddr != "" {
+		go func() {
+			log.Printf("serving metrics at http://%s/metrics\n", metricsAddr)
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", metrics.Handler())
+			log.Fatal(http.ListenAndServe(metricsAddr, mux))
+		}()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	w := &worker.Worker{
+		Queue:         q,
+		Name:          workerName,
+		AppletOptions: []runtime.AppletOption{runtime.WithLimits(limits), runtime.WithPrintDisabled()},
+		CacheSize:     workerCacheSize,
+	}
+
+	log.Printf("rendering jobs from %s\n", queueURL)
+	err = w.Run(ctx)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+func runCoordinator(cmd *cobra.Command, args []string) error {
+	q, err := worker.Open(queueURL)
+	if err != nil {
+		return err
+	}
+	defer q.Close()
+
+	mux := http.NewServeMux()
+	mux.Handle("/render", &worker.Coordinator{Queue: q, Timeout: coordinatorTimeout})
+
+	addr := net.JoinHostPort(host, fmt.Sprint(coordinatorPort))
+	log.Printf("serving render API at http://%s/render\n", addr)
+	return http.ListenAndServe(addr, mux)
+}
@@ -0,0 +1,129 @@
+//go:build !js && !wasm
+
+package configstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var boltBucket = []byte("configs")
+
+// Bolt is a Store that keeps config in a bbolt database file. Only one
+// process can have the file open at a time.
+type Bolt struct {
+	db *bolt.DB
+}
+
+// boltEntry is how an entry is kept in the database.
+type boltEntry struct {
+	Config  map[string]string `json:"config"`
+	Updated time.Time         `json:"updated"`
+}
+
+// OpenBolt opens the bbolt database at path, creating it if it doesn't
+// exist.
+func OpenBolt(path string) (*Bolt, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("opening config store %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("opening config store %s: %w", path, err)
+	}
+
+	return &Bolt{db: db}, nil
+}
+
+// boltKey is the key an installation's config is kept under. Entries of
+// an app share a prefix, so that they can be listed together.
+func boltKey(key Key) []byte {
+	return []byte(key.App + "\x00" + key.Installation)
+}
+
+// Get implements Store.
+func (b *Bolt) Get(ctx context.Context, key Key) (map[string]string, error) {
+	var e boltEntry
+	err := b.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(boltBucket).Get(boltKey(key))
+		if data == nil {
+			return fmt.Errorf("config for %s: %w", key, ErrNotFound)
+		}
+		return json.Unmarshal(data, &e)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return e.Config, nil
+}
+
+// Put implements Store.
+func (b *Bolt) Put(ctx context.Context, key Key, config map[string]string) error {
+	if config == nil {
+		config = map[string]string{}
+	}
+	data, err := json.Marshal(boltEntry{Config: config, Updated: time.Now()})
+	if err != nil {
+		return err
+	}
+
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Put(boltKey(key), data)
+	})
+}
+
+// Delete implements Store.
+func (b *Bolt) Delete(ctx context.Context, key Key) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Delete(boltKey(key))
+	})
+}
+
+// List implements Store.
+func (b *Bolt) List(ctx context.Context, app string) ([]Entry, error) {
+	var prefix []byte
+	if app != "" {
+		prefix = []byte(app + "\x00")
+	}
+
+	entries := []Entry{}
+	err := b.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(boltBucket).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			app, installation, _ := bytes.Cut(k, []byte{0})
+
+			var e boltEntry
+			if err := json.Unmarshal(v, &e); err != nil {
+				return fmt.Errorf("reading config for %s/%s: %w", app, installation, err)
+			}
+			entries = append(entries, Entry{
+				Key:     Key{App: string(app), Installation: string(installation)},
+				Config:  e.Config,
+				Updated: e.Updated,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sortEntries(entries)
+	return entries, nil
+}
+
+// Close implements Store.
+func (b *Bolt) Close() error {
+	return b.db.Close()
+}
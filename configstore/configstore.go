@@ -0,0 +1,185 @@
+// Package configstore keeps the config of apps, so that what users enter
+// in pixlet serve, or give the daemon, lasts and can be looked up by
+// other programs. Config is kept for each installation of an app, like
+// on several devices with different settings.
+package configstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultInstallation is the installation config is kept under when an
+// app has only one, like in pixlet serve.
+const DefaultInstallation = "default"
+
+// ErrNotFound is returned by Get when there's no config for an
+// installation. It's fs.ErrNotExist, so that errors.Is works with
+// either.
+var ErrNotFound = fs.ErrNotExist
+
+// Key identifies the config of one installation of an app.
+type Key struct {
+	App          string
+	Installation string
+}
+
+func (k Key) String() string {
+	return k.App + "/" + k.Installation
+}
+
+// Entry is the config of an installation, as listed by a Store.
+type Entry struct {
+	Key
+	Config  map[string]string
+	Updated time.Time
+}
+
+// Store keeps the config of apps. Stores are safe for concurrent use.
+type Store interface {
+	// Get returns the config of an installation, or an error wrapping
+	// ErrNotFound if there isn't any.
+	Get(ctx context.Context, key Key) (map[string]string, error)
+
+	// Put replaces the config of an installation.
+	Put(ctx context.Context, key Key, config map[string]string) error
+
+	// Delete removes the config of an installation. Deleting config
+	// that doesn't exist isn't an error.
+	Delete(ctx context.Context, key Key) error
+
+	// List returns the config of every installation of app, or of
+	// every app if app is empty, sorted by key.
+	List(ctx context.Context, app string) ([]Entry, error)
+
+	Close() error
+}
+
+// Load returns defaults overridden by the config kept in store for key,
+// which is how apps are rendered when some of their config comes from
+// elsewhere, like a daemon's config file.
+func Load(ctx context.Context, store Store, key Key, defaults map[string]string) (map[string]string, error) {
+	config := make(map[string]string, len(defaults))
+	for k, v := range defaults {
+		config[k] = v
+	}
+
+	stored, err := store.Get(ctx, key)
+	if errors.Is(err, ErrNotFound) {
+		return config, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range stored {
+		config[k] = v
+	}
+	return config, nil
+}
+
+// Open opens the store described by spec:
+//
+//	memory          kept in memory, and lost on exit
+//	bolt:PATH       a bbolt database file, used by one process at a time
+//	sqlite:PATH     an SQLite database file, which several processes can
+//	                share, with config as JSON in the configs table
+func Open(spec string) (Store, error) {
+	kind, path, _ := strings.Cut(spec, ":")
+	switch kind {
+	case "memory":
+		return NewMemory(), nil
+	case "bolt", "sqlite":
+		if path == "" {
+			return nil, fmt.Errorf("%s config store needs a path, like %s:config.db", kind, kind)
+		}
+		return openFile(kind, path)
+	default:
+		return nil, fmt.Errorf("unknown config store: %q, use memory, bolt:PATH or sqlite:PATH", spec)
+	}
+}
+
+// Memory is a Store that keeps config in memory.
+type Memory struct {
+	mu      sync.Mutex
+	entries map[Key]Entry
+}
+
+// NewMemory returns an empty Memory store.
+func NewMemory() *Memory {
+	return &Memory{entries: map[Key]Entry{}}
+}
+
+// Get implements Store.
+func (m *Memory) Get(ctx context.Context, key Key) (map[string]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[key]
+	if !ok {
+		return nil, fmt.Errorf("config for %s: %w", key, ErrNotFound)
+	}
+	return copyConfig(e.Config), nil
+}
+
+// Put implements Store.
+func (m *Memory) Put(ctx context.Context, key Key, config map[string]string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries[key] = Entry{Key: key, Config: copyConfig(config), Updated: time.Now()}
+	return nil
+}
+
+// Delete implements Store.
+func (m *Memory) Delete(ctx context.Context, key Key) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+	return nil
+}
+
+// List implements Store.
+func (m *Memory) List(ctx context.Context, app string) ([]Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entries := []Entry{}
+	for key, e := range m.entries {
+		if app == "" || key.App == app {
+			e.Config = copyConfig(e.Config)
+			entries = append(entries, e)
+		}
+	}
+	sortEntries(entries)
+	return entries, nil
+}
+
+// Close implements Store.
+func (m *Memory) Close() error {
+	return nil
+}
+
+func copyConfig(config map[string]string) map[string]string {
+	c := make(map[string]string, len(config))
+	for k, v := range config {
+		c[k] = v
+	}
+	return c
+}
+
+func sortEntries(entries []Entry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].App != entries[j].App {
+			return entries[i].App < entries[j].App
+		}
+		return entries[i].Installation < entries[j].Installation
+	})
+}
@@ -0,0 +1,158 @@
+package configstore
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStores(t *testing.T) {
+	for name, open := range map[string]func(t *testing.T) Store{
+		"memory": func(t *testing.T) Store { return NewMemory() },
+		"bolt": func(t *testing.T) Store {
+			s, err := OpenBolt(filepath.Join(t.TempDir(), "config.db"))
+			require.NoError(t, err)
+			return s
+		},
+		"sqlite": func(t *testing.T) Store {
+			s, err := OpenSQLite(filepath.Join(t.TempDir(), "config.db"))
+			require.NoError(t, err)
+			return s
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			s := open(t)
+			defer s.Close()
+			testStore(t, s)
+		})
+	}
+}
+
+func testStore(t *testing.T, s Store) {
+	ctx := context.Background()
+	clock := Key{App: "clock", Installation: "kitchen"}
+
+	_, err := s.Get(ctx, clock)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	start := time.Now().Add(-time.Second)
+	require.NoError(t, s.Put(ctx, clock, map[string]string{"timezone": "Europe/Oslo"}))
+	require.NoError(t, s.Put(ctx, Key{App: "clock", Installation: "office"}, map[string]string{"timezone": "UTC"}))
+	require.NoError(t, s.Put(ctx, Key{App: "weather", Installation: DefaultInstallation}, nil))
+
+	config, err := s.Get(ctx, clock)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"timezone": "Europe/Oslo"}, config)
+
+	// puts replace the whole config
+	require.NoError(t, s.Put(ctx, clock, map[string]string{"24h": "true"}))
+	config, err = s.Get(ctx, clock)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"24h": "true"}, config)
+
+	entries, err := s.List(ctx, "clock")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "kitchen", entries[0].Installation)
+	assert.Equal(t, "office", entries[1].Installation)
+	assert.Equal(t, map[string]string{"timezone": "UTC"}, entries[1].Config)
+	assert.True(t, entries[0].Updated.After(start))
+
+	entries, err = s.List(ctx, "")
+	require.NoError(t, err)
+	assert.Len(t, entries, 3)
+
+	require.NoError(t, s.Delete(ctx, clock))
+	require.NoError(t, s.Delete(ctx, clock))
+	_, err = s.Get(ctx, clock)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestBoltKeepsConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.db")
+	key := Key{App: "clock", Installation: DefaultInstallation}
+
+	s, err := OpenBolt(path)
+	require.NoError(t, err)
+	require.NoError(t, s.Put(context.Background(), key, map[string]string{"a": "b"}))
+	require.NoError(t, s.Close())
+
+	s, err = OpenBolt(path)
+	require.NoError(t, err)
+	defer s.Close()
+	config, err := s.Get(context.Background(), key)
+	require.NoError(t, err)
+	assert.Equal(t, "b", config["a"])
+}
+
+func TestSQLiteShared(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.db")
+	key := Key{App: "clock", Installation: DefaultInstallation}
+
+	// like serve and the daemon sharing a file
+	a, err := OpenSQLite(path)
+	require.NoError(t, err)
+	defer a.Close()
+	b, err := OpenSQLite(path)
+	require.NoError(t, err)
+	defer b.Close()
+
+	require.NoError(t, a.Put(context.Background(), key, map[string]string{"a": "b"}))
+	config, err := b.Get(context.Background(), key)
+	require.NoError(t, err)
+	assert.Equal(t, "b", config["a"])
+
+	// config is JSON, so it can be queried
+	var tz string
+	require.NoError(t, b.db.QueryRow(`SELECT json_extract(config, '$.a') FROM configs`).Scan(&tz))
+	assert.Equal(t, "b", tz)
+}
+
+func TestLoad(t *testing.T) {
+	s := NewMemory()
+	key := Key{App: "clock", Installation: DefaultInstallation}
+	defaults := map[string]string{"timezone": "UTC", "24h": "false"}
+
+	config, err := Load(context.Background(), s, key, defaults)
+	require.NoError(t, err)
+	assert.Equal(t, defaults, config)
+
+	require.NoError(t, s.Put(context.Background(), key, map[string]string{"24h": "true"}))
+	config, err = Load(context.Background(), s, key, defaults)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"timezone": "UTC", "24h": "true"}, config)
+	assert.Equal(t, "false", defaults["24h"])
+}
+
+// failing is a store whose reads fail.
+type failing struct{ *Memory }
+
+func (failing) Get(ctx context.Context, key Key) (map[string]string, error) {
+	return nil, errors.New("disk on fire")
+}
+
+func TestLoadError(t *testing.T) {
+	_, err := Load(context.Background(), failing{NewMemory()}, Key{App: "clock"}, nil)
+	assert.ErrorContains(t, err, "disk on fire")
+}
+
+func TestOpen(t *testing.T) {
+	s, err := Open("memory")
+	require.NoError(t, err)
+	assert.IsType(t, &Memory{}, s)
+
+	s, err = Open("sqlite:" + filepath.Join(t.TempDir(), "config.db"))
+	require.NoError(t, err)
+	assert.IsType(t, &SQLite{}, s)
+	s.Close()
+
+	for _, spec := range []string{"", "bolt", "sqlite:", "postgres:db"} {
+		_, err := Open(spec)
+		assert.Error(t, err, spec)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !js && !wasm
+
+package configstore
+
+func openFile(kind, path string) (Store, error) {
+	if kind == "bolt" {
+		return OpenBolt(path)
+	}
+	return OpenSQLite(path)
+}
@@ -0,0 +1,9 @@
+//go:build js && wasm
+
+package configstore
+
+import "fmt"
+
+func openFile(kind, path string) (Store, error) {
+	return nil, fmt.Errorf("%s config stores are not supported in WASM", kind)
+}
@@ -0,0 +1,140 @@
+//go:build !js && !wasm
+
+package configstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS configs (
+	app          TEXT NOT NULL,
+	installation TEXT NOT NULL,
+	config       TEXT NOT NULL,
+	updated_at   INTEGER NOT NULL,
+	PRIMARY KEY (app, installation)
+)`
+
+// SQLite is a Store that keeps config in an SQLite database file, in
+// the configs table. Config is kept as a JSON object, so that it can be
+// queried with SQLite's JSON functions, and updated_at is in Unix
+// seconds. Several processes can share the file.
+type SQLite struct {
+	db *sql.DB
+}
+
+// OpenSQLite opens the SQLite database at path, creating it if it
+// doesn't exist.
+func OpenSQLite(path string) (*SQLite, error) {
+	// wait for other processes' writes rather than failing
+	db, err := sql.Open("sqlite3", "file:"+path+"?_busy_timeout=5000&_journal_mode=WAL")
+	if err != nil {
+		return nil, fmt.Errorf("opening config store %s: %w", path, err)
+	}
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("opening config store %s: %w", path, err)
+	}
+
+	return &SQLite{db: db}, nil
+}
+
+// Get implements Store.
+func (s *SQLite) Get(ctx context.Context, key Key) (map[string]string, error) {
+	var data string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT config FROM configs WHERE app = ? AND installation = ?`,
+		key.App, key.Installation,
+	).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("config for %s: %w", key, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading config for %s: %w", key, err)
+	}
+
+	config := map[string]string{}
+	if err := json.Unmarshal([]byte(data), &config); err != nil {
+		return nil, fmt.Errorf("reading config for %s: %w", key, err)
+	}
+	return config, nil
+}
+
+// Put implements Store.
+func (s *SQLite) Put(ctx context.Context, key Key, config map[string]string) error {
+	if config == nil {
+		config = map[string]string{}
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO configs (app, installation, config, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (app, installation) DO UPDATE SET config = excluded.config, updated_at = excluded.updated_at`,
+		key.App, key.Installation, string(data), time.Now().Unix(),
+	)
+	if err != nil {
+		return fmt.Errorf("saving config for %s: %w", key, err)
+	}
+	return nil
+}
+
+// Delete implements Store.
+func (s *SQLite) Delete(ctx context.Context, key Key) error {
+	_, err := s.db.ExecContext(ctx,
+		`DELETE FROM configs WHERE app = ? AND installation = ?`,
+		key.App, key.Installation,
+	)
+	if err != nil {
+		return fmt.Errorf("deleting config for %s: %w", key, err)
+	}
+	return nil
+}
+
+// List implements Store.
+func (s *SQLite) List(ctx context.Context, app string) ([]Entry, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT app, installation, config, updated_at FROM configs
+		WHERE ? = '' OR app = ?
+		ORDER BY app, installation`,
+		app, app,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("listing config: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []Entry{}
+	for rows.Next() {
+		var (
+			e       Entry
+			data    string
+			updated int64
+		)
+		if err := rows.Scan(&e.App, &e.Installation, &data, &updated); err != nil {
+			return nil, fmt.Errorf("listing config: %w", err)
+		}
+		if err := json.Unmarshal([]byte(data), &e.Config); err != nil {
+			return nil, fmt.Errorf("reading config for %s: %w", e.Key, err)
+		}
+		e.Updated = time.Unix(updated, 0)
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// Close implements Store.
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
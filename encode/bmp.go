@@ -0,0 +1,25 @@
+package encode
+
+import (
+	"bytes"
+	"fmt"
+
+	"golang.org/x/image/bmp"
+)
+
+// Renders a single frame of the screen to BMP, for displays like e-ink
+// panels that can't decode anything more involved. frameIdx is picked
+// as in EncodePNG. Optionally pass filters for postprocessing the frame.
+func (s *Screens) EncodeBMP(frameIdx int, filters ...ImageFilter) ([]byte, error) {
+	im, err := s.filteredFrame(frameIdx, filters)
+	if err != nil || im == nil {
+		return []byte{}, err
+	}
+
+	buf := &bytes.Buffer{}
+	if err := bmp.Encode(buf, im); err != nil {
+		return nil, fmt.Errorf("encoding: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
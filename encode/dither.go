@@ -0,0 +1,152 @@
+package encode
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+)
+
+const (
+	// DefaultDitherBits is the number of bits per color channel that
+	// frames are reduced to when dithering. LED panels typically have
+	// far less usable color depth than the 8 bits we render at.
+	DefaultDitherBits = 4
+)
+
+// DitherMethod selects the algorithm used by DitherFilter.
+type DitherMethod int
+
+const (
+	DitherNone DitherMethod = iota
+	DitherOrdered
+	DitherDiffusion
+)
+
+// ParseDitherMethod maps a method name, as used on the command line,
+// to a DitherMethod.
+func ParseDitherMethod(name string) (DitherMethod, error) {
+	switch name {
+	case "", "none":
+		return DitherNone, nil
+	case "ordered", "bayer":
+		return DitherOrdered, nil
+	case "diffusion", "floyd-steinberg":
+		return DitherDiffusion, nil
+	default:
+		return DitherNone, fmt.Errorf("unknown dither method '%s'", name)
+	}
+}
+
+// 4x4 Bayer threshold matrix, in units of 1/16.
+var bayer4x4 = [4][4]int{
+	{0, 8, 2, 10},
+	{12, 4, 14, 6},
+	{3, 11, 1, 9},
+	{15, 7, 13, 5},
+}
+
+// DitherFilter returns an ImageFilter that reduces each frame to
+// `bits` bits per color channel, using dithering to preserve
+// gradients that would otherwise band. Alpha is left untouched.
+//
+// Ordered dithering is stable across frames, which avoids shimmer in
+// animations. Error diffusion (Floyd-Steinberg) gives the best result
+// for photos and still images.
+func DitherFilter(method DitherMethod, bits int) ImageFilter {
+	if bits <= 0 || bits > 8 {
+		bits = DefaultDitherBits
+	}
+	levels := (1 << bits) - 1
+
+	return func(im image.Image) (image.Image, error) {
+		if method == DitherNone || bits == 8 {
+			return im, nil
+		}
+
+		src, ok := im.(*image.RGBA)
+		if !ok {
+			src = image.NewRGBA(im.Bounds())
+			draw.Draw(src, src.Bounds(), im, im.Bounds().Min, draw.Src)
+		}
+
+		switch method {
+		case DitherOrdered:
+			return ditherOrdered(src, levels), nil
+		case DitherDiffusion:
+			return ditherDiffusion(src, levels), nil
+		default:
+			return nil, fmt.Errorf("unsupported dither method %d", method)
+		}
+	}
+}
+
+// Rounds v (0-255 scale) to the nearest of `levels`+1 evenly spaced
+// values, and returns it on the 0-255 scale.
+func quantizeChannel(v float64, levels int) uint8 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= 255 {
+		return 255
+	}
+	q := int(v*float64(levels)/255 + 0.5)
+	return uint8(q * 255 / levels)
+}
+
+func ditherOrdered(src *image.RGBA, levels int) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(b)
+	step := 255 / float64(levels)
+
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			threshold := (float64(bayer4x4[y&3][x&3])+0.5)/16 - 0.5
+			si := src.PixOffset(x, y)
+			di := dst.PixOffset(x, y)
+			for c := 0; c < 3; c++ {
+				v := float64(src.Pix[si+c]) + threshold*step
+				dst.Pix[di+c] = quantizeChannel(v, levels)
+			}
+			dst.Pix[di+3] = src.Pix[si+3]
+		}
+	}
+
+	return dst
+}
+
+func ditherDiffusion(src *image.RGBA, levels int) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(b)
+	w := b.Dx()
+
+	// accumulated error for the current and next row, per channel
+	cur := make([]float64, (w+2)*3)
+	next := make([]float64, (w+2)*3)
+
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			si := src.PixOffset(x, y)
+			di := dst.PixOffset(x, y)
+			ei := (x - b.Min.X + 1) * 3
+			for c := 0; c < 3; c++ {
+				v := float64(src.Pix[si+c]) + cur[ei+c]
+				q := quantizeChannel(v, levels)
+				dst.Pix[di+c] = q
+
+				e := v - float64(q)
+				cur[ei+3+c] += e * 7 / 16
+				next[ei-3+c] += e * 3 / 16
+				next[ei+c] += e * 5 / 16
+				next[ei+3+c] += e * 1 / 16
+			}
+			dst.Pix[di+3] = src.Pix[si+3]
+		}
+
+		cur, next = next, cur
+		for i := range next {
+			next[i] = 0
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,101 @@
+package encode
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gradient(w, h int) *image.RGBA {
+	im := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			v := uint8(x * 255 / (w - 1))
+			im.SetRGBA(x, y, color.RGBA{v, v / 2, 255 - v, 0xff})
+		}
+	}
+	return im
+}
+
+func TestParseDitherMethod(t *testing.T) {
+	for name, expected := range map[string]DitherMethod{
+		"":                DitherNone,
+		"none":            DitherNone,
+		"ordered":         DitherOrdered,
+		"bayer":           DitherOrdered,
+		"diffusion":       DitherDiffusion,
+		"floyd-steinberg": DitherDiffusion,
+	} {
+		m, err := ParseDitherMethod(name)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, m, name)
+	}
+
+	_, err := ParseDitherMethod("sparkles")
+	assert.Error(t, err)
+}
+
+func TestDitherNone(t *testing.T) {
+	im := gradient(64, 32)
+	out, err := DitherFilter(DitherNone, 4)(im)
+	require.NoError(t, err)
+	assert.Equal(t, im, out)
+}
+
+func TestDitherQuantizes(t *testing.T) {
+	im := gradient(64, 32)
+
+	// with 2 bits per channel, every channel must land on one of
+	// 0, 85, 170 and 255
+	allowed := map[uint8]bool{0: true, 85: true, 170: true, 255: true}
+
+	for _, method := range []DitherMethod{DitherOrdered, DitherDiffusion} {
+		out, err := DitherFilter(method, 2)(im)
+		require.NoError(t, err)
+
+		rgba := out.(*image.RGBA)
+		assert.Equal(t, im.Bounds(), rgba.Bounds())
+		for i, v := range rgba.Pix {
+			if i%4 == 3 {
+				assert.Equal(t, uint8(0xff), v)
+				continue
+			}
+			assert.True(t, allowed[v], "method %d: unexpected channel value %d", method, v)
+		}
+	}
+}
+
+func TestDitherPreservesAverage(t *testing.T) {
+	// a flat field of a color that falls between two quantization
+	// levels should dither to roughly the same average brightness
+	im := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	for i := 0; i < len(im.Pix); i += 4 {
+		im.Pix[i+0] = 100
+		im.Pix[i+1] = 100
+		im.Pix[i+2] = 100
+		im.Pix[i+3] = 0xff
+	}
+
+	for _, method := range []DitherMethod{DitherOrdered, DitherDiffusion} {
+		out, err := DitherFilter(method, 2)(im)
+		require.NoError(t, err)
+
+		rgba := out.(*image.RGBA)
+		sum := 0
+		for i := 0; i < len(rgba.Pix); i += 4 {
+			sum += int(rgba.Pix[i])
+		}
+		avg := float64(sum) / float64(16*16)
+		assert.InDelta(t, 100, avg, 8, "method %d", method)
+
+		// and it should actually mix more than one level
+		seen := map[uint8]bool{}
+		for i := 0; i < len(rgba.Pix); i += 4 {
+			seen[rgba.Pix[i]] = true
+		}
+		assert.Greater(t, len(seen), 1, "method %d", method)
+	}
+}
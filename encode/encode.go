@@ -1,12 +1,27 @@
+// Package encode turns the render roots that apps return into WebP,
+// GIF and PNG images. It's the supported way for Go programs embedding
+// pixlet to produce images, without going through the pixlet command:
+//
+//	roots, err := applet.RunWithConfig(ctx, config)
+//	if err != nil {
+//		return err
+//	}
+//	img, err := encode.Encode(roots, encode.Options{
+//		Format:      encode.FormatGIF,
+//		MaxDuration: 15000,
+//		Magnify:     10,
+//	})
 package encode
 
 import (
 	"crypto/sha256"
 	"fmt"
 	"image"
+	"time"
 
 	"github.com/vmihailenco/msgpack/v5"
 
+	"tidbyt.dev/pixlet/metrics"
 	"tidbyt.dev/pixlet/render"
 )
 
@@ -23,6 +38,11 @@ type Screens struct {
 	delay             int32
 	MaxAge            int32
 	ShowFullAnimation bool
+
+	// Width and Height are the size the roots are painted at. If zero,
+	// the default size from globals is used.
+	Width  int
+	Height int
 }
 
 type ImageFilter func(image.Image) (image.Image, error)
@@ -84,9 +104,31 @@ func (s *Screens) Hash() ([]byte, error) {
 	return h[:], nil
 }
 
+// Release hands the frames painted for the screen back to package
+// render, for their buffers to be reused by later renders. The screen
+// can still be encoded afterwards, but is painted again. Frames
+// returned by Frames must not be used after the screen is released.
+func (s *Screens) Release() {
+	if len(s.roots) == 0 {
+		// the images are the caller's
+		return
+	}
+	render.ReleaseFrames(s.images...)
+	s.images = nil
+}
+
+// frameSize returns the option painting roots at the screen's size.
+func (s *Screens) frameSize() render.RootPaintOption {
+	return render.WithFrameSize(s.Width, s.Height)
+}
+
 func (s *Screens) render(filters ...ImageFilter) ([]image.Image, error) {
 	if s.images == nil {
-		s.images = render.PaintRoots(true, s.roots...)
+		start := time.Now()
+		for _, r := range s.roots {
+			s.images = append(s.images, r.Paint(true, s.frameSize())...)
+		}
+		metrics.ObserveRender(time.Since(start))
 	}
 
 	if len(s.images) == 0 {
@@ -3,6 +3,7 @@ package encode
 import (
 	"bytes"
 	"context"
+	"image"
 	"image/gif"
 	"strings"
 	"testing"
@@ -229,6 +230,27 @@ func TestScreensFromRoots(t *testing.T) {
 	assert.Equal(t, int32(42), s.MaxAge)
 }
 
+func TestRelease(t *testing.T) {
+	text := &render.Text{Content: "hi"}
+	require.NoError(t, text.Init())
+	s := ScreensFromRoots([]render.Root{{Child: text}})
+	want, err := s.EncodeGIF(0)
+	require.NoError(t, err)
+
+	// released screens are painted again
+	s.Release()
+	assert.Nil(t, s.images)
+	got, err := s.EncodeGIF(0)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	// images from the caller are left alone
+	im := image.NewRGBA(image.Rect(0, 0, 64, 32))
+	s = ScreensFromImages(im)
+	s.Release()
+	assert.Equal(t, []image.Image{im}, s.images)
+}
+
 func TestShowFullAnimation(t *testing.T) {
 	requestFull := `
 load("render.star", "render")
@@ -280,7 +302,7 @@ def main():
 	assert.NoError(t, err)
 
 	// Source above will produce a 70 frame animation
-	assert.Equal(t, 70, roots[0].Child.FrameCount())
+	assert.Equal(t, 70, roots[0].Child.FrameCount(image.Rect(0, 0, 64, 32)))
 
 	// These decode gif/webp and return all frame delays and
 	// their sum in milliseconds.
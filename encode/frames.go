@@ -0,0 +1,45 @@
+package encode
+
+import (
+	"image"
+)
+
+// Frame is a single frame of a screen, and how long it's shown.
+type Frame struct {
+	Image image.Image
+
+	// Delay is how long the frame is shown, in milliseconds.
+	Delay int
+}
+
+// Frames renders every frame of the screen, along with how long each is
+// shown. Like EncodeWebP and EncodeGIF, frames past maxDuration are left
+// out, and the last frame is cut short to fit. Optionally pass filters
+// for postprocessing each individual frame.
+func (s *Screens) Frames(maxDuration int, filters ...ImageFilter) ([]Frame, error) {
+	images, err := s.render(filters...)
+	if err != nil {
+		return nil, err
+	}
+
+	frames := []Frame{}
+
+	remainingDuration := maxDuration
+	for _, im := range images {
+		frameDelay := int(s.delay)
+		if maxDuration > 0 {
+			if frameDelay > remainingDuration {
+				frameDelay = remainingDuration
+			}
+			remainingDuration -= frameDelay
+		}
+
+		frames = append(frames, Frame{Image: im, Delay: frameDelay})
+
+		if maxDuration > 0 && remainingDuration <= 0 {
+			break
+		}
+	}
+
+	return frames, nil
+}
@@ -0,0 +1,43 @@
+package encode
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tidbyt.dev/pixlet/render"
+)
+
+func TestFrames(t *testing.T) {
+	root := boxAnimation(pngRed, pngGreen, pngBlue)
+	root.Delay = 100
+
+	frames, err := ScreensFromRoots([]render.Root{root}).Frames(0)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(frames))
+
+	for i, c := range []color.RGBA{pngRed, pngGreen, pngBlue} {
+		assert.Equal(t, 100, frames[i].Delay)
+		assert.Equal(t, c, color.RGBAModel.Convert(frames[i].Image.At(0, 0)))
+	}
+}
+
+func TestFramesMaxDuration(t *testing.T) {
+	root := boxAnimation(pngRed, pngGreen, pngBlue)
+	root.Delay = 100
+
+	// the second frame is cut short, and the third left out
+	frames, err := ScreensFromRoots([]render.Root{root}).Frames(150)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(frames))
+	assert.Equal(t, 100, frames[0].Delay)
+	assert.Equal(t, 50, frames[1].Delay)
+}
+
+func TestFramesEmpty(t *testing.T) {
+	frames, err := ScreensFromRoots(nil).Frames(0)
+	require.NoError(t, err)
+	assert.Empty(t, frames)
+}
@@ -0,0 +1,249 @@
+package encode
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"tidbyt.dev/pixlet/metrics"
+	"tidbyt.dev/pixlet/render"
+	"tidbyt.dev/pixlet/tracing"
+)
+
+// Format is an image format that screens can be encoded to.
+type Format string
+
+const (
+	FormatWebP Format = "webp"
+	FormatGIF  Format = "gif"
+	FormatPNG  Format = "png"
+	FormatBMP  Format = "bmp"
+)
+
+// formats are the formats screens can be encoded to, in order of
+// preference, with their content types.
+var formats = []struct {
+	format      Format
+	contentType string
+}{
+	{FormatWebP, "image/webp"},
+	{FormatGIF, "image/gif"},
+	{FormatPNG, "image/png"},
+	{FormatBMP, "image/bmp"},
+}
+
+// ParseFormat maps a format name, or a file extension like ".gif", to
+// a Format.
+func ParseFormat(name string) (Format, error) {
+	f := Format(strings.TrimPrefix(strings.ToLower(name), "."))
+	for _, known := range formats {
+		if known.format == f {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported format: %s", name)
+}
+
+// ContentType returns the MIME type of images in the format.
+func (f Format) ContentType() string {
+	for _, known := range formats {
+		if known.format == f {
+			return known.contentType
+		}
+	}
+	return "application/octet-stream"
+}
+
+// NegotiateFormat picks the format to encode to from an HTTP Accept
+// header, preferring types with higher quality values. It returns def
+// when any image will do, including when accept is empty, and an empty
+// Format if none of the accepted types can be produced.
+func NegotiateFormat(accept string, def Format) Format {
+	if strings.TrimSpace(accept) == "" {
+		return def
+	}
+
+	type mediaRange struct {
+		typ string
+		q   float64
+	}
+
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mr := mediaRange{typ: strings.ToLower(strings.TrimSpace(params[0])), q: 1}
+		for _, p := range params[1:] {
+			k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+			if k == "q" {
+				if q, err := strconv.ParseFloat(v, 64); err == nil {
+					mr.q = q
+				}
+			}
+		}
+		ranges = append(ranges, mr)
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+
+	for _, mr := range ranges {
+		if mr.q <= 0 {
+			continue
+		}
+		if mr.typ == "*/*" || mr.typ == "image/*" {
+			return def
+		}
+		for _, known := range formats {
+			if known.contentType == mr.typ {
+				return known.format
+			}
+		}
+	}
+
+	return ""
+}
+
+// Options controls how Encode turns render roots into an image. The zero
+// value encodes every frame to WebP at the default size.
+type Options struct {
+	// Format is the image format to encode to. It defaults to WebP.
+	Format Format
+
+	// Width and Height are the size of the display the roots are
+	// painted for. If zero, the default size from globals is used,
+	// which is 64x32 unless changed. Apps often lay themselves out according to
+	// the size too, so usually the app is also run at this size. See
+	// globals.ContextWithSize.
+	Width  int
+	Height int
+
+	// MaxDuration is the longest the animation can be, in milliseconds.
+	// Frames past it are left out, and the last frame is cut short to
+	// fit. If zero, or if the app asked to show its full animation,
+	// every frame is encoded.
+	MaxDuration int
+
+	// Frame picks the frame to encode for PNG and BMP, as in EncodePNG.
+	// It's ignored for other formats.
+	Frame int
+
+	// Magnify scales up each frame by this factor, so that every pixel
+	// becomes a Magnify by Magnify square. Values below 2 leave frames
+	// at their size.
+	Magnify int
+
+	// Dither reduces the frames to DefaultDitherBits per color channel
+	// with the given method, before they're magnified.
+	Dither DitherMethod
+
+	// Filters postprocess each frame, after dithering and magnifying.
+	Filters []ImageFilter
+}
+
+// Encode paints roots and encodes them as asked for by opts. It's what
+// pixlet render does once it has run an app. If the roots have no
+// frames, Encode returns an empty slice.
+func Encode(roots []render.Root, opts Options) ([]byte, error) {
+	return EncodeContext(context.Background(), roots, opts)
+}
+
+// EncodeContext is like Encode, but records a span for encoding as a
+// child of any span in ctx. See package tracing.
+func EncodeContext(ctx context.Context, roots []render.Root, opts Options) (img []byte, err error) {
+	_, span := tracing.Start(ctx, "pixlet.encode", attribute.String("pixlet.format", string(opts.Format)))
+	defer func() { tracing.End(span, err) }()
+
+	screens := ScreensFromRoots(roots)
+	defer screens.Release()
+	screens.Width, screens.Height = opts.Width, opts.Height
+
+	return screens.Encode(opts)
+}
+
+// Encode encodes the screen as asked for by opts. The size in opts is
+// ignored, since the screen may already be painted; set the screen's
+// Width and Height, or use the package level Encode, to paint roots at
+// a given size.
+func (s *Screens) Encode(opts Options) ([]byte, error) {
+	maxDuration := opts.MaxDuration
+	if s.ShowFullAnimation {
+		maxDuration = 0
+	}
+
+	filters := opts.filters()
+
+	format := opts.Format
+	if format == "" {
+		format = FormatWebP
+	}
+
+	start := time.Now()
+	var img []byte
+	var err error
+	switch format {
+	case FormatWebP:
+		img, err = s.EncodeWebP(maxDuration, filters...)
+	case FormatGIF:
+		img, err = s.EncodeGIF(maxDuration, filters...)
+	case FormatPNG:
+		img, err = s.EncodePNG(opts.Frame, filters...)
+	case FormatBMP:
+		img, err = s.EncodeBMP(opts.Frame, filters...)
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error rendering: %w", err)
+	}
+	metrics.ObserveEncode(string(format), time.Since(start))
+
+	return img, nil
+}
+
+// filters returns the filters to apply to each frame, in order.
+func (opts Options) filters() []ImageFilter {
+	filters := []ImageFilter{}
+	if opts.Dither != DitherNone {
+		filters = append(filters, DitherFilter(opts.Dither, DefaultDitherBits))
+	}
+	if opts.Magnify > 1 {
+		filters = append(filters, MagnifyFilter(opts.Magnify))
+	}
+	return append(filters, opts.Filters...)
+}
+
+// MagnifyFilter returns an ImageFilter that scales up frames by factor,
+// without smoothing, so that every pixel stays sharp.
+func MagnifyFilter(factor int) ImageFilter {
+	return func(input image.Image) (image.Image, error) {
+		if factor <= 1 {
+			return input, nil
+		}
+
+		in, ok := input.(*image.RGBA)
+		if !ok {
+			return nil, fmt.Errorf("image is %T, require RGBA", input)
+		}
+
+		bounds := in.Bounds()
+		out := image.NewRGBA(image.Rect(0, 0, bounds.Dx()*factor, bounds.Dy()*factor))
+		for x := 0; x < bounds.Dx(); x++ {
+			for y := 0; y < bounds.Dy(); y++ {
+				c := in.RGBAAt(bounds.Min.X+x, bounds.Min.Y+y)
+				for xx := 0; xx < factor; xx++ {
+					for yy := 0; yy < factor; yy++ {
+						out.SetRGBA(x*factor+xx, y*factor+yy, c)
+					}
+				}
+			}
+		}
+
+		return out, nil
+	}
+}
@@ -0,0 +1,165 @@
+package encode
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidbyt/go-libwebp/webp"
+	"golang.org/x/image/bmp"
+
+	"tidbyt.dev/pixlet/globals"
+	"tidbyt.dev/pixlet/render"
+)
+
+func TestParseFormat(t *testing.T) {
+	for in, expected := range map[string]Format{
+		"webp":  FormatWebP,
+		".gif":  FormatGIF,
+		"PNG":   FormatPNG,
+		".webp": FormatWebP,
+		"bmp":   FormatBMP,
+	} {
+		f, err := ParseFormat(in)
+		assert.NoError(t, err, in)
+		assert.Equal(t, expected, f, in)
+	}
+
+	_, err := ParseFormat("jpeg")
+	assert.Error(t, err)
+}
+
+func TestNegotiateFormat(t *testing.T) {
+	for accept, expected := range map[string]Format{
+		"":                                   FormatGIF,
+		"*/*":                                FormatGIF,
+		"image/png":                          FormatPNG,
+		"image/bmp, image/png;q=0.5":         FormatBMP,
+		"image/bmp;q=0.2, image/webp":        FormatWebP,
+		"text/html, image/*;q=0.8":           FormatGIF,
+		"image/png;q=0, image/gif":           FormatGIF,
+		"text/html":                          "",
+		"image/jpeg, application/json;q=0.1": "",
+	} {
+		assert.Equal(t, expected, NegotiateFormat(accept, FormatGIF), accept)
+	}
+
+	assert.Equal(t, "image/bmp", FormatBMP.ContentType())
+}
+
+func TestEncodeFormats(t *testing.T) {
+	roots := []render.Root{boxAnimation(pngRed, pngGreen, pngBlue)}
+
+	img, err := Encode(roots, Options{})
+	require.NoError(t, err)
+	decoder, err := webp.NewAnimationDecoder(img)
+	require.NoError(t, err)
+	anim, err := decoder.Decode()
+	require.NoError(t, err)
+	assert.Equal(t, 3, len(anim.Image))
+
+	img, err = Encode(roots, Options{Format: FormatGIF, MaxDuration: 100})
+	require.NoError(t, err)
+	g, err := gif.DecodeAll(bytes.NewReader(img))
+	require.NoError(t, err)
+	assert.Equal(t, 2, len(g.Image))
+
+	img, err = Encode(roots, Options{Format: FormatPNG, Frame: 2})
+	require.NoError(t, err)
+	assert.Equal(t, pngBlue, pngColorAt(t, img, 0, 0))
+
+	img, err = Encode(roots, Options{Format: FormatBMP, Frame: 1})
+	require.NoError(t, err)
+	im, err := bmp.Decode(bytes.NewReader(img))
+	require.NoError(t, err)
+	assert.Equal(t, pngGreen, color.RGBAModel.Convert(im.At(0, 0)))
+
+	_, err = Encode(roots, Options{Format: "jpeg"})
+	assert.Error(t, err)
+}
+
+func TestEncodeSize(t *testing.T) {
+	roots := []render.Root{{Child: render.Box{Color: pngRed}}}
+
+	img, err := Encode(roots, Options{Format: FormatPNG, Width: 128, Height: 64, Magnify: 2})
+	require.NoError(t, err)
+	im, err := png.Decode(bytes.NewReader(img))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 256, 128), im.Bounds())
+
+	// the default size is left alone
+	width, height := globals.DefaultSize()
+	assert.Equal(t, 64, width)
+	assert.Equal(t, 32, height)
+}
+
+func TestEncodeSizeConcurrent(t *testing.T) {
+	roots := []render.Root{{Child: render.Box{Color: pngRed}}}
+
+	var wg sync.WaitGroup
+	for _, size := range []image.Point{{64, 32}, {128, 64}, {0, 64}} {
+		want := image.Rect(0, 0, size.X, size.Y)
+		if size.X == 0 {
+			want.Max.X = 64
+		}
+
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				img, err := Encode(roots, Options{Format: FormatPNG, Width: size.X, Height: size.Y})
+				if !assert.NoError(t, err) {
+					return
+				}
+				im, err := png.Decode(bytes.NewReader(img))
+				if assert.NoError(t, err) {
+					assert.Equal(t, want, im.Bounds())
+				}
+			}()
+		}
+	}
+	wg.Wait()
+}
+
+func TestEncodeFilters(t *testing.T) {
+	roots := []render.Root{{Child: render.Box{Color: pngRed}}}
+
+	invert := func(input image.Image) (image.Image, error) {
+		in := input.(*image.RGBA)
+		out := image.NewRGBA(in.Bounds())
+		for x := 0; x < in.Bounds().Dx(); x++ {
+			for y := 0; y < in.Bounds().Dy(); y++ {
+				c := in.RGBAAt(x, y)
+				out.SetRGBA(x, y, color.RGBA{0xff - c.R, 0xff - c.G, 0xff - c.B, c.A})
+			}
+		}
+		return out, nil
+	}
+
+	img, err := Encode(roots, Options{Format: FormatPNG, Filters: []ImageFilter{invert}})
+	require.NoError(t, err)
+	assert.Equal(t, color.RGBA{0, 0xff, 0xff, 0xff}, pngColorAt(t, img, 10, 10))
+}
+
+func TestMagnifyFilter(t *testing.T) {
+	in := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	in.SetRGBA(0, 0, pngRed)
+	in.SetRGBA(1, 0, pngBlue)
+
+	out, err := MagnifyFilter(3)(in)
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 6, 3), out.Bounds())
+	assert.Equal(t, pngRed, out.(*image.RGBA).RGBAAt(2, 2))
+	assert.Equal(t, pngBlue, out.(*image.RGBA).RGBAAt(3, 0))
+
+	out, err = MagnifyFilter(1)(in)
+	require.NoError(t, err)
+	assert.Same(t, in, out)
+}
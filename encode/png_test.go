@@ -0,0 +1,110 @@
+package encode
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tidbyt.dev/pixlet/render"
+)
+
+var (
+	pngRed   = color.RGBA{0xff, 0, 0, 0xff}
+	pngGreen = color.RGBA{0, 0xff, 0, 0xff}
+	pngBlue  = color.RGBA{0, 0, 0xff, 0xff}
+)
+
+func pngColorAt(t *testing.T, data []byte, x, y int) color.RGBA {
+	im, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 64, 32), im.Bounds())
+	return color.RGBAModel.Convert(im.At(x, y)).(color.RGBA)
+}
+
+func boxAnimation(colors ...color.Color) render.Root {
+	children := []render.Widget{}
+	for _, c := range colors {
+		children = append(children, render.Box{Color: c})
+	}
+	return render.Root{Child: render.Animation{Children: children}}
+}
+
+func TestParseFrameIndex(t *testing.T) {
+	for in, expected := range map[string]int{
+		"":         FrameMidpoint,
+		"midpoint": FrameMidpoint,
+		"Middle":   FrameMidpoint,
+		"0":        0,
+		"12":       12,
+	} {
+		idx, err := ParseFrameIndex(in)
+		assert.NoError(t, err, in)
+		assert.Equal(t, expected, idx, in)
+	}
+
+	_, err := ParseFrameIndex("-2")
+	assert.Error(t, err)
+	_, err = ParseFrameIndex("first")
+	assert.Error(t, err)
+}
+
+func TestEncodePNG(t *testing.T) {
+	s := ScreensFromRoots([]render.Root{boxAnimation(pngRed, pngGreen, pngBlue)})
+
+	data, err := s.EncodePNG(0)
+	require.NoError(t, err)
+	assert.Equal(t, pngRed, pngColorAt(t, data, 0, 0))
+
+	data, err = s.EncodePNG(FrameMidpoint)
+	require.NoError(t, err)
+	assert.Equal(t, pngGreen, pngColorAt(t, data, 0, 0))
+
+	// out of range indices select the last frame
+	data, err = s.EncodePNG(100)
+	require.NoError(t, err)
+	assert.Equal(t, pngBlue, pngColorAt(t, data, 0, 0))
+}
+
+func TestEncodePNGMultipleRoots(t *testing.T) {
+	s := ScreensFromRoots([]render.Root{
+		boxAnimation(pngRed, pngRed),
+		boxAnimation(pngGreen, pngBlue),
+	})
+
+	data, err := s.EncodePNG(3)
+	require.NoError(t, err)
+	assert.Equal(t, pngBlue, pngColorAt(t, data, 0, 0))
+
+	data, err = s.EncodePNG(FrameMidpoint)
+	require.NoError(t, err)
+	assert.Equal(t, pngGreen, pngColorAt(t, data, 0, 0))
+}
+
+func TestEncodePNGFromImagesAndFilters(t *testing.T) {
+	im := image.NewRGBA(image.Rect(0, 0, 64, 32))
+	im.SetRGBA(0, 0, pngRed)
+
+	invert := func(im image.Image) (image.Image, error) {
+		out := image.NewRGBA(im.Bounds())
+		for y := 0; y < im.Bounds().Dy(); y++ {
+			for x := 0; x < im.Bounds().Dx(); x++ {
+				c := color.RGBAModel.Convert(im.At(x, y)).(color.RGBA)
+				out.SetRGBA(x, y, color.RGBA{0xff - c.R, 0xff - c.G, 0xff - c.B, 0xff})
+			}
+		}
+		return out, nil
+	}
+
+	data, err := ScreensFromImages(im).EncodePNG(FrameMidpoint, invert)
+	require.NoError(t, err)
+	assert.Equal(t, color.RGBA{0, 0xff, 0xff, 0xff}, pngColorAt(t, data, 0, 0))
+
+	data, err = ScreensFromImages().EncodePNG(0)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(data))
+}
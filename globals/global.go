@@ -1,4 +1,65 @@
 package globals
 
-var Width = 64
-var Height = 32
+import (
+	"context"
+	"sync"
+)
+
+// width and height are the size apps are rendered at by default, which
+// pixlet render changes with its --width and --height flags.
+var (
+	sizeMu sync.Mutex
+	width  = 64
+	height = 32
+)
+
+// SetSize sets the size apps are rendered at by default. A width or
+// height of 0 leaves that one as it is. Hosts rendering at sizes of
+// their own pass them to each render instead, with ContextWithSize and
+// render.WithFrameSize.
+func SetSize(w, h int) {
+	sizeMu.Lock()
+	defer sizeMu.Unlock()
+
+	if w > 0 {
+		width = w
+	}
+	if h > 0 {
+		height = h
+	}
+}
+
+// DefaultSize returns the size apps are rendered at by default.
+func DefaultSize() (int, int) {
+	sizeMu.Lock()
+	defer sizeMu.Unlock()
+
+	return width, height
+}
+
+// sizeKey is the context key of the size given to ContextWithSize.
+type sizeKey struct{}
+
+type size struct{ width, height int }
+
+// ContextWithSize returns a copy of ctx that tells apps run with it the
+// size they're rendered at. A width or height of 0 leaves that one at
+// the default size.
+func ContextWithSize(ctx context.Context, width, height int) context.Context {
+	return context.WithValue(ctx, sizeKey{}, size{width, height})
+}
+
+// Size returns the size apps run with ctx are rendered at: the one
+// given to ContextWithSize, or else the default size.
+func Size(ctx context.Context) (width, height int) {
+	width, height = DefaultSize()
+	if s, ok := ctx.Value(sizeKey{}).(size); ok {
+		if s.width > 0 {
+			width = s.width
+		}
+		if s.height > 0 {
+			height = s.height
+		}
+	}
+	return width, height
+}
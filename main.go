@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -20,21 +21,34 @@ var (
 func init() {
 	rootCmd.AddCommand(cmd.RenderCmd)
 	rootCmd.AddCommand(cmd.PushCmd)
+	rootCmd.AddCommand(cmd.DaemonCmd)
 	rootCmd.AddCommand(cmd.EncryptCmd)
 	rootCmd.AddCommand(cmd.VersionCmd)
 	rootCmd.AddCommand(cmd.ProfileCmd)
+	rootCmd.AddCommand(cmd.BenchCmd)
 	rootCmd.AddCommand(cmd.LoginCmd)
 	rootCmd.AddCommand(cmd.DevicesCmd)
 	rootCmd.AddCommand(cmd.ListCmd)
 	rootCmd.AddCommand(cmd.DeleteCmd)
+	rootCmd.AddCommand(cmd.InstallationsCmd)
 	rootCmd.AddCommand(cmd.FormatCmd)
 	rootCmd.AddCommand(cmd.LintCmd)
 	rootCmd.AddCommand(cmd.CheckCmd)
+	rootCmd.AddCommand(cmd.TestCmd)
+	rootCmd.AddCommand(cmd.SnapshotCmd)
 	rootCmd.AddCommand(cmd.SetAuthCmd)
+	rootCmd.AddCommand(cmd.SchemaCmd)
+	rootCmd.AddCommand(cmd.DoctorCmd)
+	rootCmd.AddCommand(cmd.LSPCmd)
+	rootCmd.AddCommand(cmd.PluginsCmd)
 	rootCmd.AddCommand(community.CommunityCmd)
 }
 
 func main() {
+	if path, ok := cmd.FindPlugin(rootCmd, os.Args[1:]); ok {
+		os.Exit(cmd.RunPlugin(context.Background(), path, os.Args[2:]))
+	}
+
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
 	}
@@ -10,5 +10,9 @@ import (
 func init() {
 	rootCmd.AddCommand(private.PrivateCmd)
 	rootCmd.AddCommand(cmd.CreateCmd)
+	rootCmd.AddCommand(cmd.PublishCmd)
 	rootCmd.AddCommand(cmd.ServeCmd)
+	rootCmd.AddCommand(cmd.GRPCCmd)
+	rootCmd.AddCommand(cmd.WorkerCmd)
+	rootCmd.AddCommand(cmd.CoordinatorCmd)
 }
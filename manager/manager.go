@@ -0,0 +1,473 @@
+// Package manager keeps a set of apps loaded, for Go programs that show
+// apps like a device does. A Manager loads every app in a directory, or
+// apps it's given, tracks the config of each, reloads apps as their
+// files change, and cycles through them like a device rotation.
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"tidbyt.dev/pixlet/bundle"
+	"tidbyt.dev/pixlet/encode"
+	"tidbyt.dev/pixlet/runtime"
+	"tidbyt.dev/pixlet/tools"
+)
+
+var (
+	// ErrSkipped is returned by Render when the app returned no roots,
+	// which tells a device to skip it in the rotation.
+	ErrSkipped = errors.New("app returned no roots, so it's skipped")
+
+	// ErrNoApps is returned by Next when there are no apps.
+	ErrNoApps = errors.New("no apps")
+)
+
+// settle is how long Watch waits after a change before reloading, since
+// editors often save in several steps.
+const settle = 100 * time.Millisecond
+
+// App is an app kept by a Manager.
+type App struct {
+	ID string
+
+	// Path is where the app was loaded from, or empty if it was added
+	// with Add or AddBundle.
+	Path string
+
+	// Err is why the app failed to load, if it did. Apps that failed to
+	// load are skipped by Next.
+	Err error
+
+	applet *runtime.Applet
+}
+
+// Option configures a Manager.
+type Option func(*Manager)
+
+// WithAppletOptions loads every app with opts.
+func WithAppletOptions(opts ...runtime.AppletOption) Option {
+	return func(m *Manager) {
+		m.appletOpts = append(m.appletOpts, opts...)
+	}
+}
+
+// WithOnChange calls f with the ID of each app that's loaded, reloaded
+// or removed by Watch.
+func WithOnChange(f func(id string)) Option {
+	return func(m *Manager) {
+		m.onChange = f
+	}
+}
+
+// Manager keeps a set of apps loaded. It's safe to use from several
+// goroutines.
+type Manager struct {
+	appletOpts []runtime.AppletOption
+	onChange   func(id string)
+
+	mu      sync.Mutex
+	dir     string
+	apps    map[string]*App
+	configs map[string]map[string]string
+	last    string
+}
+
+// New returns a Manager without any apps.
+func New(opts ...Option) *Manager {
+	m := &Manager{
+		apps:    map[string]*App{},
+		configs: map[string]map[string]string{},
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// FindApps lists the apps in dir, keyed by ID. Subdirectories holding
+// .star files are apps, and so are .star files and bundles (.tar.gz
+// files) at the top level.
+func FindApps(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", dir, err)
+	}
+
+	apps := map[string]string{}
+	for _, e := range entries {
+		name := e.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+
+		path := filepath.Join(dir, name)
+		if !e.IsDir() {
+			if id := appID(name); id != name {
+				apps[id] = path
+			}
+			continue
+		}
+
+		stars, err := filepath.Glob(filepath.Join(path, "*.star"))
+		if err != nil {
+			return nil, err
+		}
+		if len(stars) > 0 {
+			apps[name] = path
+		}
+	}
+
+	return apps, nil
+}
+
+// appID returns the ID of the app at the top level of a directory with
+// the given name.
+func appID(name string) string {
+	for _, ext := range []string{".star", ".tar.gz"} {
+		if id := strings.TrimSuffix(name, ext); id != name && id != "" {
+			return id
+		}
+	}
+	return name
+}
+
+// LoadDir loads every app found in dir by FindApps, and remembers dir
+// for Watch. Apps that fail to load are kept, so that they can be
+// reloaded once they're fixed, and their errors are returned together.
+func (m *Manager) LoadDir(dir string) error {
+	found, err := FindApps(dir)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.dir = dir
+	m.mu.Unlock()
+
+	errs := []error{}
+	for _, id := range sortedKeys(found) {
+		if err := m.load(id, found[id]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// load loads the app at path, which is a directory, a .star file or a
+// bundle.
+func (m *Manager) load(id, path string) error {
+	fsys, err := openApp(path)
+	if err == nil {
+		return m.set(id, path, fsys)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.apps[id] = &App{ID: id, Path: path, Err: err}
+	return fmt.Errorf("%s: %w", id, err)
+}
+
+func openApp(path string) (fs.FS, error) {
+	switch {
+	case strings.HasSuffix(path, ".star"):
+		return tools.NewSingleFileFS(path), nil
+
+	case strings.HasSuffix(path, ".tar.gz"):
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		b, err := bundle.LoadBundle(f)
+		if err != nil {
+			return nil, fmt.Errorf("loading bundle: %w", err)
+		}
+		return b.Source, nil
+
+	default:
+		return os.DirFS(path), nil
+	}
+}
+
+// Add loads the app in fsys with the given ID, replacing any app with
+// that ID. If it fails to load, it's kept anyway and the error is
+// returned.
+func (m *Manager) Add(id string, fsys fs.FS) error {
+	return m.set(id, "", fsys)
+}
+
+// AddBundle loads the app in b with the given ID, like Add.
+func (m *Manager) AddBundle(id string, b *bundle.AppBundle) error {
+	return m.set(id, "", b.Source)
+}
+
+func (m *Manager) set(id, path string, fsys fs.FS) error {
+	app := &App{ID: id, Path: path}
+	app.applet, app.Err = runtime.NewAppletFromFS(id, fsys, m.appletOpts...)
+
+	m.mu.Lock()
+	m.apps[id] = app
+	m.mu.Unlock()
+
+	if app.Err != nil {
+		return fmt.Errorf("%s: %w", id, app.Err)
+	}
+	return nil
+}
+
+// Remove removes the app with the given ID. Its config is kept, in case
+// it's added again.
+func (m *Manager) Remove(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.apps, id)
+}
+
+// Apps returns the apps, sorted by ID.
+func (m *Manager) Apps() []App {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	apps := make([]App, 0, len(m.apps))
+	for _, id := range sortedKeys(m.apps) {
+		apps = append(apps, *m.apps[id])
+	}
+	return apps
+}
+
+// Config returns the config of the app with the given ID.
+func (m *Manager) Config(id string) map[string]string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return copyConfig(m.configs[id])
+}
+
+// SetConfig sets the config the app with the given ID is rendered with.
+func (m *Manager) SetConfig(id string, config map[string]string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.apps[id]; !ok {
+		return fmt.Errorf("no such app: %s", id)
+	}
+	m.configs[id] = copyConfig(config)
+	return nil
+}
+
+// Render runs the app with the given ID with its config. If the app
+// returns no roots, ErrSkipped is returned.
+func (m *Manager) Render(ctx context.Context, id string) (*encode.Screens, error) {
+	m.mu.Lock()
+	app, ok := m.apps[id]
+	config := copyConfig(m.configs[id])
+	m.mu.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("no such app: %s", id)
+	}
+	if app.Err != nil {
+		return nil, fmt.Errorf("loading %s: %w", id, app.Err)
+	}
+
+	roots, err := app.applet.RunWithConfig(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("running %s: %w", id, err)
+	}
+	if len(roots) == 0 {
+		return nil, ErrSkipped
+	}
+
+	return encode.ScreensFromRoots(roots), nil
+}
+
+// Next renders the app after the one Next rendered last, in order of
+// ID, like a device moving through its rotation. Apps that return no
+// roots, or that fail, are passed over. If every app is passed over, the
+// reasons why are returned together.
+func (m *Manager) Next(ctx context.Context) (string, *encode.Screens, error) {
+	m.mu.Lock()
+	ids := sortedKeys(m.apps)
+	last := m.last
+	m.mu.Unlock()
+
+	if len(ids) == 0 {
+		return "", nil, ErrNoApps
+	}
+
+	// start after the last app, even if it's been removed since
+	start := sort.SearchStrings(ids, last)
+	if start < len(ids) && ids[start] == last {
+		start++
+	}
+
+	errs := []error{}
+	for i := 0; i < len(ids); i++ {
+		id := ids[(start+i)%len(ids)]
+
+		screens, err := m.Render(ctx, id)
+		if err != nil {
+			if ctx.Err() != nil {
+				return "", nil, ctx.Err()
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", id, err))
+			continue
+		}
+
+		m.mu.Lock()
+		m.last = id
+		m.mu.Unlock()
+
+		return id, screens, nil
+	}
+
+	return "", nil, errors.Join(errs...)
+}
+
+// Watch reloads apps in the directory given to LoadDir as their files
+// change, loads apps added to it, and removes apps removed from it,
+// until ctx is done.
+func (m *Manager) Watch(ctx context.Context) error {
+	m.mu.Lock()
+	dir := m.dir
+	m.mu.Unlock()
+
+	if dir == "" {
+		return fmt.Errorf("no directory to watch, call LoadDir first")
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("watching for changes: %w", err)
+	}
+	defer watcher.Close()
+
+	if err := watchDir(watcher, dir); err != nil {
+		return err
+	}
+
+	changed := map[string]bool{}
+	timer := time.NewTimer(settle)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return fmt.Errorf("watcher events channel closed unexpectedly")
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+
+			rel, err := filepath.Rel(dir, event.Name)
+			if err != nil || rel == "." {
+				continue
+			}
+			name := strings.Split(filepath.ToSlash(rel), "/")[0]
+			changed[appID(name)] = true
+
+			// new app directories are watched too
+			if info, err := os.Stat(event.Name); err == nil && info.IsDir() && filepath.Dir(event.Name) == dir {
+				watcher.Add(event.Name)
+			}
+
+			timer.Reset(settle)
+
+		case <-timer.C:
+			m.reload(dir, changed)
+			changed = map[string]bool{}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return fmt.Errorf("watcher errors channel closed unexpectedly")
+			}
+			return fmt.Errorf("watcher: %w", err)
+		}
+	}
+}
+
+// watchDir watches dir and the app directories in it.
+func watchDir(watcher *fsnotify.Watcher, dir string) error {
+	if err := watcher.Add(dir); err != nil {
+		return fmt.Errorf("watching %s: %w", dir, err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", dir, err)
+	}
+	for _, e := range entries {
+		if e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+			watcher.Add(filepath.Join(dir, e.Name()))
+		}
+	}
+
+	return nil
+}
+
+// reload reloads the apps in dir with the given IDs, and removes those
+// that are gone.
+func (m *Manager) reload(dir string, ids map[string]bool) {
+	found, err := FindApps(dir)
+	if err != nil {
+		return
+	}
+
+	for _, id := range sortedKeys(ids) {
+		path, ok := found[id]
+		if ok {
+			// errors are kept with the app
+			m.load(id, path)
+		} else {
+			m.mu.Lock()
+			app, loaded := m.apps[id]
+			fromDir := loaded && app.Path != ""
+			if fromDir {
+				delete(m.apps, id)
+			}
+			m.mu.Unlock()
+
+			if !fromDir {
+				continue
+			}
+		}
+
+		if m.onChange != nil {
+			m.onChange(id)
+		}
+	}
+}
+
+func copyConfig(config map[string]string) map[string]string {
+	c := make(map[string]string, len(config))
+	for k, v := range config {
+		c[k] = v
+	}
+	return c
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
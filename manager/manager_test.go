@@ -0,0 +1,176 @@
+package manager_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tidbyt.dev/pixlet/bundle"
+	"tidbyt.dev/pixlet/manager"
+)
+
+const textApp = `
+load("render.star", "render")
+
+def main(config):
+    return render.Root(child = render.Text(config.get("msg", "hi")))
+`
+
+const skippedApp = `
+def main():
+    return []
+`
+
+func writeApps(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(src), 0644))
+	}
+	return dir
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := writeApps(t, map[string]string{
+		"clock/clock.star": textApp,
+		"weather.star":     textApp,
+		"broken.star":      "def main(:",
+		"notes.txt":        "not an app",
+		".hidden/x.star":   textApp,
+	})
+
+	b, err := os.ReadFile("../bundle/testdata/bundle.tar.gz")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bundled.tar.gz"), b, 0644))
+
+	m := manager.New()
+	err = m.LoadDir(dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "broken")
+
+	ids := []string{}
+	for _, app := range m.Apps() {
+		ids = append(ids, app.ID)
+		if app.ID == "broken" {
+			assert.Error(t, app.Err)
+		} else {
+			assert.NoError(t, app.Err, app.ID)
+		}
+	}
+	assert.Equal(t, []string{"broken", "bundled", "clock", "weather"}, ids)
+
+	screens, err := m.Render(context.Background(), "clock")
+	require.NoError(t, err)
+	_, err = screens.EncodeWebP(0)
+	assert.NoError(t, err)
+
+	_, err = m.Render(context.Background(), "missing")
+	assert.Error(t, err)
+}
+
+func TestConfig(t *testing.T) {
+	m := manager.New()
+	require.NoError(t, m.Add("app", fstest.MapFS{"app.star": {Data: []byte(textApp)}}))
+
+	config := map[string]string{"msg": "hello"}
+	require.NoError(t, m.SetConfig("app", config))
+	config["msg"] = "changed"
+	assert.Equal(t, map[string]string{"msg": "hello"}, m.Config("app"))
+
+	// config is kept when the app is reloaded
+	require.NoError(t, m.Add("app", fstest.MapFS{"app.star": {Data: []byte(textApp)}}))
+	assert.Equal(t, map[string]string{"msg": "hello"}, m.Config("app"))
+
+	assert.Error(t, m.SetConfig("missing", config))
+}
+
+func TestNext(t *testing.T) {
+	m := manager.New()
+	_, _, err := m.Next(context.Background())
+	assert.ErrorIs(t, err, manager.ErrNoApps)
+
+	for id, src := range map[string]string{
+		"a": textApp,
+		"b": skippedApp,
+		"c": textApp,
+		"d": "def main(:",
+	} {
+		m.Add(id, fstest.MapFS{"app.star": {Data: []byte(src)}})
+	}
+
+	seen := []string{}
+	for i := 0; i < 4; i++ {
+		id, screens, err := m.Next(context.Background())
+		require.NoError(t, err)
+		assert.NotNil(t, screens)
+		seen = append(seen, id)
+	}
+	assert.Equal(t, []string{"a", "c", "a", "c"}, seen)
+
+	// the rotation carries on after an app is removed
+	m.Remove("a")
+	id, _, err := m.Next(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "c", id)
+
+	m.Remove("c")
+	_, _, err = m.Next(context.Background())
+	assert.ErrorIs(t, err, manager.ErrSkipped)
+	assert.Contains(t, err.Error(), "d: loading d")
+}
+
+func TestAddBundle(t *testing.T) {
+	b, err := bundle.FromDir("../bundle/testdata/testapp")
+	require.NoError(t, err)
+
+	m := manager.New()
+	require.NoError(t, m.AddBundle("test", b))
+	assert.Equal(t, "test", m.Apps()[0].ID)
+}
+
+func TestWatch(t *testing.T) {
+	dir := writeApps(t, map[string]string{
+		"clock/clock.star": textApp,
+	})
+
+	changes := make(chan string, 10)
+	m := manager.New(manager.WithOnChange(func(id string) { changes <- id }))
+	require.NoError(t, m.LoadDir(dir))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go m.Watch(ctx)
+
+	// give the watcher time to start
+	time.Sleep(50 * time.Millisecond)
+
+	waitFor := func(want string) {
+		select {
+		case id := <-changes:
+			assert.Equal(t, want, id)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no change for %s", want)
+		}
+	}
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "clock", "clock.star"), []byte(skippedApp), 0644))
+	waitFor("clock")
+	_, err := m.Render(ctx, "clock")
+	assert.ErrorIs(t, err, manager.ErrSkipped)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "weather.star"), []byte(textApp), 0644))
+	waitFor("weather")
+	_, err = m.Render(ctx, "weather")
+	assert.NoError(t, err)
+
+	require.NoError(t, os.RemoveAll(filepath.Join(dir, "clock")))
+	waitFor("clock")
+	assert.Len(t, m.Apps(), 1)
+}
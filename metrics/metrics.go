@@ -0,0 +1,160 @@
+// Package metrics collects Prometheus metrics about running apps: how
+// long they take and how often they fail, their use of the cache and
+// HTTP, and how long rendering and encoding their output takes.
+//
+// Metrics are always collected. Serve them with Handler, or add them to
+// a registry of your own with Register.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "pixlet"
+
+var (
+	appletRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "applet_runs_total",
+		Help:      "Runs of apps' main functions, by app and whether they failed.",
+	}, []string{"applet", "result"})
+
+	appletRunDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "applet_run_duration_seconds",
+		Help:      "How long apps' main functions take to run.",
+		Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"applet"})
+
+	appletRunSteps = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "applet_run_steps",
+		Help:      "Starlark execution steps taken by apps' main functions.",
+		Buckets:   prometheus.ExponentialBuckets(1000, 4, 10),
+	}, []string{"applet"})
+
+	cacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Cache lookups, by cache and whether they hit. The app cache is cache.star, the http cache holds http.star responses, and the image cache holds decoded images.",
+	}, []string{"cache", "result"})
+
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Requests made by apps with http.star that weren't answered from the cache, by method and status code.",
+	}, []string{"method", "code"})
+
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "How long requests made by apps with http.star take, when they aren't answered from the cache.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	renderDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "render_duration_seconds",
+		Help:      "How long painting the frames of apps' output takes.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	encodeDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "encode_duration_seconds",
+		Help:      "How long encoding apps' output takes, by format. Includes painting frames that weren't painted yet.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"format"})
+)
+
+var all = []prometheus.Collector{
+	appletRuns,
+	appletRunDuration,
+	appletRunSteps,
+	cacheRequests,
+	httpRequests,
+	httpRequestDuration,
+	renderDuration,
+	encodeDuration,
+}
+
+// registry holds pixlet's metrics, and those of the Go runtime, for
+// Handler.
+var registry = prometheus.NewRegistry()
+
+func init() {
+	registry.MustRegister(all...)
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Register adds pixlet's metrics to reg, like prometheus.DefaultRegisterer,
+// so that they're served along with a program's own.
+func Register(reg prometheus.Registerer) error {
+	for _, c := range all {
+		if err := reg.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Handler serves pixlet's metrics, along with the Go runtime's, in the
+// Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRun records a run of an app's main function that took d and
+// steps Starlark execution steps, and failed if err isn't nil.
+func ObserveRun(applet string, d time.Duration, steps uint64, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+
+	appletRuns.WithLabelValues(applet, result).Inc()
+	appletRunDuration.WithLabelValues(applet).Observe(d.Seconds())
+	appletRunSteps.WithLabelValues(applet).Observe(float64(steps))
+}
+
+// ObserveCache records a lookup in cache, which is "app", "http" or
+// "image".
+func ObserveCache(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+
+	cacheRequests.WithLabelValues(cache, result).Inc()
+}
+
+// ObserveHTTP records a request made by an app that took d. code is the
+// response's status code, or 0 if there was no response.
+func ObserveHTTP(method string, code int, d time.Duration) {
+	c := "error"
+	if code > 0 {
+		c = strconv.Itoa(code)
+	}
+
+	httpRequests.WithLabelValues(method, c).Inc()
+	httpRequestDuration.WithLabelValues(method).Observe(d.Seconds())
+}
+
+// ObserveRender records painting frames that took d.
+func ObserveRender(d time.Duration) {
+	renderDuration.Observe(d.Seconds())
+}
+
+// ObserveEncode records encoding an image in format that took d.
+func ObserveEncode(format string, d time.Duration) {
+	encodeDuration.WithLabelValues(format).Observe(d.Seconds())
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserveRun(t *testing.T) {
+	ok := testutil.ToFloat64(appletRuns.WithLabelValues("test-run", "ok"))
+	failed := testutil.ToFloat64(appletRuns.WithLabelValues("test-run", "error"))
+
+	ObserveRun("test-run", 10*time.Millisecond, 1234, nil)
+	ObserveRun("test-run", 20*time.Millisecond, 5678, errors.New("boom"))
+	ObserveRun("test-run", 30*time.Millisecond, 9012, nil)
+
+	assert.Equal(t, ok+2, testutil.ToFloat64(appletRuns.WithLabelValues("test-run", "ok")))
+	assert.Equal(t, failed+1, testutil.ToFloat64(appletRuns.WithLabelValues("test-run", "error")))
+}
+
+func TestObserveHTTP(t *testing.T) {
+	ObserveHTTP("GET", 200, time.Millisecond)
+	ObserveHTTP("GET", 0, time.Millisecond)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(httpRequests.WithLabelValues("GET", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(httpRequests.WithLabelValues("GET", "error")))
+}
+
+func TestRegister(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	require.NoError(t, Register(reg))
+
+	// registering twice is a mistake
+	assert.Error(t, Register(reg))
+}
+
+func TestHandler(t *testing.T) {
+	ObserveCache("app", true)
+	ObserveEncode("webp", time.Millisecond)
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := io.ReadAll(w.Result().Body)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(body), `pixlet_cache_requests_total{cache="app",result="hit"}`)
+	assert.Contains(t, string(body), `pixlet_encode_duration_seconds_count{format="webp"}`)
+	assert.Contains(t, string(body), "go_goroutines")
+}
@@ -0,0 +1,308 @@
+package animation
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"tidbyt.dev/pixlet/render"
+)
+
+func init() {
+	render.RegisterWidget("AnimatedPositioned", &AnimatedPositioned{})
+	render.RegisterWidget("Transformation", &Transformation{})
+}
+
+type animatedPositionedJSON struct {
+	Child    json.RawMessage `json:"child"`
+	XStart   int             `json:"x_start,omitempty"`
+	XEnd     int             `json:"x_end,omitempty"`
+	YStart   int             `json:"y_start,omitempty"`
+	YEnd     int             `json:"y_end,omitempty"`
+	Duration int             `json:"duration"`
+	Curve    string          `json:"curve"`
+	Delay    int             `json:"delay,omitempty"`
+	Hold     int             `json:"hold,omitempty"`
+}
+
+type transformationJSON struct {
+	Child        json.RawMessage `json:"child"`
+	Keyframes    []keyframeJSON  `json:"keyframes"`
+	Duration     int             `json:"duration"`
+	Delay        int             `json:"delay,omitempty"`
+	Width        int             `json:"width,omitempty"`
+	Height       int             `json:"height,omitempty"`
+	Origin       *[2]float64     `json:"origin,omitempty"`
+	Direction    string          `json:"direction,omitempty"`
+	FillMode     string          `json:"fill_mode,omitempty"`
+	Rounding     string          `json:"rounding,omitempty"`
+	WaitForChild bool            `json:"wait_for_child,omitempty"`
+}
+
+type keyframeJSON struct {
+	Percentage float64         `json:"percentage"`
+	Transforms []transformJSON `json:"transforms"`
+	Curve      string          `json:"curve,omitempty"`
+}
+
+type transformJSON struct {
+	Type  string  `json:"type"`
+	X     float64 `json:"x,omitempty"`
+	Y     float64 `json:"y,omitempty"`
+	Angle float64 `json:"angle,omitempty"`
+}
+
+// MarshalJSON encodes the widget for render.MarshalWidget.
+func (o *AnimatedPositioned) MarshalJSON() ([]byte, error) {
+	child, err := render.MarshalWidget(o.Child)
+	if err != nil {
+		return nil, err
+	}
+
+	curve, err := curveName(o.Curve)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(animatedPositionedJSON{
+		Child:    child,
+		XStart:   o.XStart,
+		XEnd:     o.XEnd,
+		YStart:   o.YStart,
+		YEnd:     o.YEnd,
+		Duration: o.Duration,
+		Curve:    curve,
+		Delay:    o.Delay,
+		Hold:     o.Hold,
+	})
+}
+
+// UnmarshalJSON decodes a widget encoded by MarshalJSON.
+func (o *AnimatedPositioned) UnmarshalJSON(data []byte) error {
+	v := animatedPositionedJSON{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	child, err := render.UnmarshalWidget(v.Child)
+	if err != nil {
+		return fmt.Errorf("child: %w", err)
+	}
+
+	curve, err := parseCurveName(v.Curve)
+	if err != nil {
+		return err
+	}
+
+	*o = AnimatedPositioned{
+		Child:    child,
+		XStart:   v.XStart,
+		XEnd:     v.XEnd,
+		YStart:   v.YStart,
+		YEnd:     v.YEnd,
+		Duration: v.Duration,
+		Curve:    curve,
+		Delay:    v.Delay,
+		Hold:     v.Hold,
+	}
+
+	return nil
+}
+
+// MarshalJSON encodes the widget for render.MarshalWidget.
+func (self *Transformation) MarshalJSON() ([]byte, error) {
+	child, err := render.MarshalWidget(self.Child)
+	if err != nil {
+		return nil, err
+	}
+
+	v := transformationJSON{
+		Child:        child,
+		Keyframes:    make([]keyframeJSON, 0, len(self.Keyframes)),
+		Duration:     self.Duration,
+		Delay:        self.Delay,
+		Width:        self.Width,
+		Height:       self.Height,
+		Origin:       &[2]float64{self.Origin.X.Value, self.Origin.Y.Value},
+		WaitForChild: self.WaitForChild,
+	}
+
+	for i, kf := range self.Keyframes {
+		k := keyframeJSON{
+			Percentage: kf.Percentage.Value,
+			Transforms: make([]transformJSON, 0, len(kf.Transforms)),
+		}
+
+		if kf.Curve != nil {
+			if k.Curve, err = curveName(kf.Curve); err != nil {
+				return nil, fmt.Errorf("keyframe %d: %w", i, err)
+			}
+		}
+
+		for _, t := range kf.Transforms {
+			switch t := t.(type) {
+			case Translate:
+				k.Transforms = append(k.Transforms, transformJSON{Type: "translate", X: t.X, Y: t.Y})
+			case Scale:
+				k.Transforms = append(k.Transforms, transformJSON{Type: "scale", X: t.X, Y: t.Y})
+			case Rotate:
+				k.Transforms = append(k.Transforms, transformJSON{Type: "rotate", Angle: t.Angle})
+			default:
+				return nil, fmt.Errorf("keyframe %d: can't serialize transform %T", i, t)
+			}
+		}
+
+		v.Keyframes = append(v.Keyframes, k)
+	}
+
+	switch self.Direction {
+	case nil, DirectionNormal:
+		v.Direction = "normal"
+	case DirectionReverse:
+		v.Direction = "reverse"
+	case DirectionAlternate:
+		v.Direction = "alternate"
+	case DirectionAlternateReverse:
+		v.Direction = "alternate-reverse"
+	default:
+		return nil, fmt.Errorf("can't serialize direction %T", self.Direction)
+	}
+
+	switch self.FillMode.(type) {
+	case nil, FillModeForwards:
+		v.FillMode = "forwards"
+	case FillModeBackwards:
+		v.FillMode = "backwards"
+	default:
+		return nil, fmt.Errorf("can't serialize fill mode %T", self.FillMode)
+	}
+
+	switch self.Rounding.(type) {
+	case nil, Round:
+		v.Rounding = "round"
+	case RoundFloor:
+		v.Rounding = "floor"
+	case RoundCeil:
+		v.Rounding = "ceil"
+	case RoundNone:
+		v.Rounding = "none"
+	default:
+		return nil, fmt.Errorf("can't serialize rounding %T", self.Rounding)
+	}
+
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON decodes a widget encoded by MarshalJSON.
+func (self *Transformation) UnmarshalJSON(data []byte) error {
+	v := transformationJSON{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	child, err := render.UnmarshalWidget(v.Child)
+	if err != nil {
+		return fmt.Errorf("child: %w", err)
+	}
+
+	t := Transformation{
+		Child:        child,
+		Keyframes:    make([]Keyframe, 0, len(v.Keyframes)),
+		Duration:     v.Duration,
+		Delay:        v.Delay,
+		Width:        v.Width,
+		Height:       v.Height,
+		Origin:       DefaultOrigin,
+		WaitForChild: v.WaitForChild,
+	}
+
+	if v.Origin != nil {
+		t.Origin = Origin{X: Percentage{v.Origin[0]}, Y: Percentage{v.Origin[1]}}
+	}
+
+	for i, k := range v.Keyframes {
+		kf := Keyframe{
+			Percentage: Percentage{k.Percentage},
+			Transforms: make([]Transform, 0, len(k.Transforms)),
+		}
+
+		if kf.Curve, err = parseCurveName(k.Curve); err != nil {
+			return fmt.Errorf("keyframe %d: %w", i, err)
+		}
+
+		for _, tr := range k.Transforms {
+			switch tr.Type {
+			case "translate":
+				kf.Transforms = append(kf.Transforms, Translate{Vec2f{tr.X, tr.Y}})
+			case "scale":
+				kf.Transforms = append(kf.Transforms, Scale{Vec2f{tr.X, tr.Y}})
+			case "rotate":
+				kf.Transforms = append(kf.Transforms, Rotate{tr.Angle})
+			default:
+				return fmt.Errorf("keyframe %d: unknown transform '%s'", i, tr.Type)
+			}
+		}
+
+		t.Keyframes = append(t.Keyframes, kf)
+	}
+
+	switch v.Direction {
+	case "", "normal":
+		t.Direction = DirectionNormal
+	case "reverse":
+		t.Direction = DirectionReverse
+	case "alternate":
+		t.Direction = DirectionAlternate
+	case "alternate-reverse":
+		t.Direction = DirectionAlternateReverse
+	default:
+		return fmt.Errorf("unknown direction '%s'", v.Direction)
+	}
+
+	switch v.FillMode {
+	case "", "forwards":
+		t.FillMode = FillModeForwards{}
+	case "backwards":
+		t.FillMode = FillModeBackwards{}
+	default:
+		return fmt.Errorf("unknown fill mode '%s'", v.FillMode)
+	}
+
+	switch v.Rounding {
+	case "", "round":
+		t.Rounding = Round{}
+	case "floor":
+		t.Rounding = RoundFloor{}
+	case "ceil":
+		t.Rounding = RoundCeil{}
+	case "none":
+		t.Rounding = RoundNone{}
+	default:
+		return fmt.Errorf("unknown rounding '%s'", v.Rounding)
+	}
+
+	*self = t
+	return nil
+}
+
+// Returns the curve string, as understood by ParseCurve, for a curve.
+func curveName(c Curve) (string, error) {
+	switch c := c.(type) {
+	case nil, LinearCurve:
+		return "linear", nil
+	case CubicBezierCurve:
+		f := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+		return fmt.Sprintf("cubic-bezier(%s, %s, %s, %s)", f(c.a), f(c.b), f(c.c), f(c.d)), nil
+	case CustomCurve:
+		return "", fmt.Errorf("custom curve functions can't be serialized")
+	default:
+		return "", fmt.Errorf("can't serialize curve %T", c)
+	}
+}
+
+func parseCurveName(name string) (Curve, error) {
+	if name == "" {
+		return DefaultCurve, nil
+	}
+	return ParseCurve(name)
+}
@@ -0,0 +1,89 @@
+package animation
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"tidbyt.dev/pixlet/render"
+)
+
+func TestTransformationJSONRoundTrip(t *testing.T) {
+	o := &Transformation{
+		Child: render.Box{Width: 2, Height: 2, Color: color.RGBA{0xff, 0, 0, 0xff}},
+		Keyframes: []Keyframe{
+			{
+				Percentage: Percentage{0.0},
+				Curve:      EaseInOut,
+				Transforms: []Transform{Translate{Vec2f{X: 0, Y: 0}}, Scale{Vec2f{X: 1, Y: 1}}},
+			},
+			{
+				Percentage: Percentage{1.0},
+				Transforms: []Transform{Translate{Vec2f{X: 3, Y: 2}}, Rotate{90}},
+			},
+		},
+		Duration:  8,
+		Delay:     2,
+		Width:     6,
+		Height:    6,
+		Origin:    Origin{X: Percentage{0.25}, Y: Percentage{0.75}},
+		Direction: DirectionAlternateReverse,
+		FillMode:  FillModeBackwards{},
+		Rounding:  RoundFloor{},
+	}
+	require.NoError(t, o.Init())
+
+	data, err := render.MarshalWidget(o)
+	require.NoError(t, err)
+
+	w, err := render.UnmarshalWidget(data)
+	require.NoError(t, err)
+
+	o2, ok := w.(*Transformation)
+	require.True(t, ok)
+	assert.Equal(t, o.Duration, o2.Duration)
+	assert.Equal(t, o.Origin, o2.Origin)
+	assert.Equal(t, o.Direction, o2.Direction)
+	assert.Equal(t, o.FillMode, o2.FillMode)
+	assert.Equal(t, o.Rounding, o2.Rounding)
+	assert.Equal(t, EaseInOut, o2.Keyframes[0].Curve)
+
+	require.Equal(t, o.FrameCount(image.Rect(0, 0, 64, 32)), o2.FrameCount(image.Rect(0, 0, 64, 32)))
+	for i := 0; i < o.FrameCount(image.Rect(0, 0, 64, 32)); i++ {
+		im := render.PaintWidget(o, image.Rect(0, 0, 6, 6), i).(*image.RGBA)
+		im2 := render.PaintWidget(o2, image.Rect(0, 0, 6, 6), i).(*image.RGBA)
+		assert.Equal(t, im.Pix, im2.Pix, "frame %d", i)
+	}
+}
+
+func TestAnimatedPositionedJSONRoundTrip(t *testing.T) {
+	o := &AnimatedPositioned{
+		Child:    render.Box{Width: 1, Height: 1, Color: color.RGBA{0, 0xff, 0, 0xff}},
+		XStart:   0,
+		XEnd:     4,
+		Duration: 5,
+		Curve:    EaseIn,
+		Hold:     1,
+	}
+
+	data, err := render.MarshalWidget(o)
+	require.NoError(t, err)
+
+	w, err := render.UnmarshalWidget(data)
+	require.NoError(t, err)
+	assert.Equal(t, o.FrameCount(image.Rect(0, 0, 64, 32)), w.FrameCount(image.Rect(0, 0, 64, 32)))
+	assert.Equal(t, EaseIn, w.(*AnimatedPositioned).Curve)
+}
+
+func TestCustomCurveNotSerializable(t *testing.T) {
+	o := &AnimatedPositioned{
+		Child:    render.Box{Width: 1, Height: 1},
+		Duration: 5,
+		Curve:    CustomCurve{},
+	}
+
+	_, err := render.MarshalWidget(o)
+	assert.Error(t, err)
+}
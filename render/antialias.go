@@ -0,0 +1,101 @@
+package render
+
+import (
+	"image"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// AntialiasAlpha is the coverage given to the pixels filled in when
+// smoothing diagonal edges of glyphs.
+var AntialiasAlpha uint8 = 0x60
+
+var antialiasCache = map[font.Face]font.Face{}
+var antialiasMutex = &sync.Mutex{}
+
+// getTextFace returns the face used by the text widgets.
+func getTextFace(name string, fallback []string, antialias bool) (font.Face, error) {
+	face, err := GetFontWithFallback(name, fallbackFonts(fallback))
+	if err != nil || !antialias {
+		return face, err
+	}
+
+	antialiasMutex.Lock()
+	defer antialiasMutex.Unlock()
+
+	if aa, ok := antialiasCache[face]; ok {
+		return aa, nil
+	}
+
+	aa := &antialiasFace{Face: face, masks: map[rune]*image.Alpha{}}
+	antialiasCache[face] = aa
+	return aa, nil
+}
+
+// antialiasFace smooths the jagged diagonal edges of bitmap glyphs.
+// Gaps in diagonal runs of pixels are partially filled in, while
+// horizontal and vertical strokes are left crisp.
+type antialiasFace struct {
+	font.Face
+
+	mu    sync.Mutex
+	masks map[rune]*image.Alpha
+}
+
+func (f *antialiasFace) HasGlyph(r rune) bool {
+	return hasGlyph(f.Face, r)
+}
+
+func (f *antialiasFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	dr, mask, maskp, advance, ok := f.Face.Glyph(dot, r)
+	if !ok || dr.Empty() {
+		return dr, mask, maskp, advance, ok
+	}
+
+	f.mu.Lock()
+	smooth, found := f.masks[r]
+	if !found {
+		smooth = smoothMask(mask, maskp, dr.Size())
+		f.masks[r] = smooth
+	}
+	f.mu.Unlock()
+
+	return dr, smooth, image.Point{}, advance, true
+}
+
+// smoothMask returns a copy of the size.X by size.Y region of mask
+// at maskp, with diagonal steps filled in.
+func smoothMask(mask image.Image, maskp image.Point, size image.Point) *image.Alpha {
+	on := func(x, y int) bool {
+		if x < 0 || y < 0 || x >= size.X || y >= size.Y {
+			return false
+		}
+		_, _, _, a := mask.At(maskp.X+x, maskp.Y+y).RGBA()
+		return a >= 0x8000
+	}
+
+	out := image.NewAlpha(image.Rect(0, 0, size.X, size.Y))
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			if on(x, y) {
+				out.Pix[y*out.Stride+x] = 0xff
+				continue
+			}
+
+			// An empty pixel with one horizontal and one vertical
+			// neighbor set, but not the pixel diagonally between
+			// them, sits in the step of a diagonal line.
+			for _, dx := range []int{-1, 1} {
+				for _, dy := range []int{-1, 1} {
+					if on(x+dx, y) && on(x, y+dy) && !on(x+dx, y+dy) {
+						out.Pix[y*out.Stride+x] = AntialiasAlpha
+					}
+				}
+			}
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,77 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func maskFromStrings(rows []string) *image.Alpha {
+	m := image.NewAlpha(image.Rect(0, 0, len(rows[0]), len(rows)))
+	for y, row := range rows {
+		for x, c := range row {
+			if c == 'x' {
+				m.SetAlpha(x, y, color.Alpha{0xff})
+			}
+		}
+	}
+	return m
+}
+
+func TestSmoothMask(t *testing.T) {
+	m := smoothMask(maskFromStrings([]string{
+		"x..x",
+		".x.x",
+		"...x",
+		"xxxx",
+	}), image.Point{}, image.Pt(4, 4))
+
+	expected := [][]uint8{
+		{0xff, AntialiasAlpha, 0, 0xff},
+		{AntialiasAlpha, 0xff, 0, 0xff},
+		{0, 0, 0, 0xff},
+		{0xff, 0xff, 0xff, 0xff},
+	}
+
+	// the diagonal gets smoothed, but the solid corner in the
+	// bottom right is left alone
+	for y, row := range expected {
+		for x, a := range row {
+			assert.Equal(t, a, m.AlphaAt(x, y).A, "%d,%d", x, y)
+		}
+	}
+}
+
+func TestTextAntialias(t *testing.T) {
+	plain := &Text{Content: "/"}
+	require.NoError(t, plain.Init())
+	aa := &Text{Content: "/", Antialias: true}
+	require.NoError(t, aa.Init())
+
+	w, h := plain.Size()
+	w2, h2 := aa.Size()
+	assert.Equal(t, w, w2)
+	assert.Equal(t, h, h2)
+
+	im := PaintWidget(plain, image.Rect(0, 0, w, h), 0)
+	im2 := PaintWidget(aa, image.Rect(0, 0, w, h), 0)
+
+	partial := 0
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, _, _, _ := im.At(x, y).RGBA()
+			r2, _, _, _ := im2.At(x, y).RGBA()
+			if r == 0xffff {
+				// pixels set in the plain glyph stay set
+				assert.Equal(t, uint32(0xffff), r2)
+			} else if r2 != 0 {
+				assert.Less(t, r2, uint32(0xffff))
+				partial++
+			}
+		}
+	}
+	assert.Greater(t, partial, 0)
+}
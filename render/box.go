@@ -59,9 +59,7 @@ func (b Box) Paint(dc *gg.Context, bounds image.Rectangle, frameIdx int) {
 	}
 
 	if b.Color != nil {
-		dc.SetColor(b.Color)
-		dc.DrawRectangle(0, 0, float64(w), float64(h))
-		dc.Fill()
+		fillRect(dc, 0, 0, w, h, b.Color)
 	}
 
 	if b.Child != nil {
@@ -71,16 +69,6 @@ func (b Box) Paint(dc *gg.Context, bounds image.Rectangle, frameIdx int) {
 		if chW < 0 || chH < 0 {
 			// padding makes the child invisible, no point painting it
 		} else {
-			dc.Push()
-
-			dc.DrawRectangle(
-				float64(b.Padding),
-				float64(b.Padding),
-				float64(chW),
-				float64(chH),
-			)
-			dc.Clip()
-
 			childBounds := b.Child.PaintBounds(image.Rect(0, 0, chW, chH), frameIdx)
 
 			// This is a bit convoluted to obtain the same rounding behavior as with the old
@@ -90,16 +78,17 @@ func (b Box) Paint(dc *gg.Context, bounds image.Rectangle, frameIdx int) {
 			x -= int(0.5 * float64(childBounds.Size().X))
 			y -= int(0.5 * float64(childBounds.Size().Y))
 
-			dc.Translate(float64(x), float64(y))
-			b.Child.Paint(dc, image.Rect(0, 0, chW, chH), frameIdx)
-			dc.Pop()
+			withClip(dc, b.Padding, b.Padding, chW, chH, func() {
+				dc.Translate(float64(x), float64(y))
+				b.Child.Paint(dc, image.Rect(0, 0, chW, chH), frameIdx)
+			})
 		}
 	}
 }
 
-func (b Box) FrameCount() int {
+func (b Box) FrameCount(bounds image.Rectangle) int {
 	if b.Child != nil {
-		return b.Child.FrameCount()
+		return b.Child.FrameCount(bounds)
 	}
 	return 1
 }
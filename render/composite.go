@@ -0,0 +1,169 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+
+	"github.com/tidbyt/gg"
+)
+
+// Widgets are mostly drawn at whole pixel offsets, inside rectangles
+// they've been clipped to. gg draws every fill and clip with its path
+// rasterizer, and every image with a bilinear transform, going pixel
+// by pixel through the clipping mask. For whole pixel offsets,
+// fillRect, withClip and drawImage draw the same pixels with the fast
+// paths of image/draw instead, and fall back to gg otherwise.
+//
+// gg keeps its clipping mask to itself, so the fast paths are only
+// taken on canvases Root paints, where widgets clip with withClip and
+// the mask is known. Elsewhere, everything is drawn by gg.
+
+// canvas is what's known about a context Root paints a frame with.
+type canvas struct {
+	// mask is the clip set by withClip, or nil if nothing is clipped.
+	mask *image.Alpha
+
+	// known is false while the context is clipped in a way the fast
+	// paths can't follow, like in a rotated context.
+	known bool
+}
+
+// canvases are the contexts Root is painting frames with. Each is only
+// used by the goroutine painting its frame.
+var canvases sync.Map
+
+// newCanvas returns a context for painting on im, on which the fast
+// paths can be taken. Call done once painting is over.
+//
+// The context is made with NewContextForRGBA rather than NewContext,
+// so that gg blends images drawn on it with what the fast paths drew,
+// rather than copying them over it.
+func newCanvas(im *image.RGBA) (dc *gg.Context, done func()) {
+	dc = gg.NewContextForRGBA(im)
+	canvases.Store(dc, &canvas{known: true})
+	return dc, func() { canvases.Delete(dc) }
+}
+
+// fastCanvas returns the canvas of dc, its image, and where the origin
+// is on it, if the fast paths can be taken: dc is painted by Root, its
+// clip is known, and its transform is a translation by whole pixels.
+func fastCanvas(dc *gg.Context) (*canvas, *image.RGBA, image.Point, bool) {
+	v, ok := canvases.Load(dc)
+	if !ok {
+		return nil, nil, image.Point{}, false
+	}
+	c := v.(*canvas)
+	if !c.known {
+		return nil, nil, image.Point{}, false
+	}
+
+	im, off, ok := pixelOffset(dc)
+	return c, im, off, ok
+}
+
+// pixelOffset returns the canvas and where the origin is on it, if the
+// context's transform is a translation by whole pixels.
+func pixelOffset(dc *gg.Context) (*image.RGBA, image.Point, bool) {
+	im, ok := dc.Image().(*image.RGBA)
+	if !ok || im.Rect.Min != (image.Point{}) {
+		return nil, image.Point{}, false
+	}
+
+	x0, y0 := dc.TransformPoint(0, 0)
+	x1, y1 := dc.TransformPoint(1, 0)
+	x2, y2 := dc.TransformPoint(0, 1)
+	if x1 != x0+1 || y1 != y0 || x2 != x0 || y2 != y0+1 {
+		return nil, image.Point{}, false
+	}
+
+	off := image.Pt(int(x0), int(y0))
+	if float64(off.X) != x0 || float64(off.Y) != y0 {
+		return nil, image.Point{}, false
+	}
+
+	return im, off, true
+}
+
+// fillRect fills the rectangle with col, like DrawRectangle and Fill.
+func fillRect(dc *gg.Context, x, y, w, h int, col color.Color) {
+	dc.SetColor(col)
+
+	c, im, off, ok := fastCanvas(dc)
+	if !ok {
+		dc.DrawRectangle(float64(x), float64(y), float64(w), float64(h))
+		dc.Fill()
+		return
+	}
+
+	r := image.Rect(x, y, x+w, y+h).Add(off).Intersect(im.Rect)
+	if c.mask != nil {
+		draw.DrawMask(im, r, image.NewUniform(col), image.Point{}, c.mask, r.Min, draw.Over)
+	} else {
+		draw.Draw(im, r, image.NewUniform(col), image.Point{}, draw.Over)
+	}
+}
+
+// withClip calls paint with what's drawn clipped to the rectangle, like
+// DrawRectangle and Clip between Push and Pop. Widgets in this package
+// clip with it, rather than with gg, so that the fast paths know the
+// clip.
+func withClip(dc *gg.Context, x, y, w, h int, paint func()) {
+	dc.Push()
+	defer dc.Pop()
+
+	c, im, off, ok := fastCanvas(dc)
+	if !ok {
+		if v, painting := canvases.Load(dc); painting {
+			c := v.(*canvas)
+			saved := *c
+			c.known = false
+			defer func() { *c = saved }()
+		}
+
+		dc.DrawRectangle(float64(x), float64(y), float64(w), float64(h))
+		dc.Clip()
+		paint()
+		return
+	}
+
+	r := image.Rect(x, y, x+w, y+h).Add(off).Intersect(im.Rect)
+	clip := image.NewAlpha(im.Rect)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		row := clip.Pix[clip.PixOffset(r.Min.X, y):clip.PixOffset(r.Max.X, y)]
+		if c.mask != nil {
+			copy(row, c.mask.Pix[c.mask.PixOffset(r.Min.X, y):])
+		} else {
+			for i := range row {
+				row[i] = 0xff
+			}
+		}
+	}
+
+	saved := *c
+	defer func() { *c = saved }()
+	c.mask = clip
+	dc.SetMask(clip)
+
+	paint()
+}
+
+// drawImage draws src with its origin at x, y, like DrawImage.
+func drawImage(dc *gg.Context, src image.Image, x, y int) {
+	c, im, off, ok := fastCanvas(dc)
+	rgba, isRGBA := src.(*image.RGBA)
+	if !ok || !isRGBA {
+		dc.DrawImage(src, x, y)
+		return
+	}
+
+	// masks made by withClip are all on or off, so image/draw blends
+	// through them the same as gg
+	r := rgba.Rect.Add(off).Add(image.Pt(x, y))
+	if c.mask != nil {
+		draw.DrawMask(im, r, rgba, rgba.Rect.Min, c.mask, r.Min, draw.Over)
+	} else {
+		draw.Draw(im, r, rgba, rgba.Rect.Min, draw.Over)
+	}
+}
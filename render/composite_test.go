@@ -0,0 +1,267 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidbyt/gg"
+)
+
+// compositeSteps draws a bit of everything, either with gg or with the
+// fast paths, so that the two can be compared.
+func compositeSteps(dc *gg.Context, fast bool, src image.Image) {
+	fill := func(x, y, w, h int, col color.Color) {
+		if fast {
+			fillRect(dc, x, y, w, h, col)
+		} else {
+			dc.SetColor(col)
+			dc.DrawRectangle(float64(x), float64(y), float64(w), float64(h))
+			dc.Fill()
+		}
+	}
+	clip := func(x, y, w, h int, paint func()) {
+		if fast {
+			withClip(dc, x, y, w, h, paint)
+		} else {
+			dc.Push()
+			dc.DrawRectangle(float64(x), float64(y), float64(w), float64(h))
+			dc.Clip()
+			paint()
+			dc.Pop()
+		}
+	}
+	drawIm := func(x, y int) {
+		if fast {
+			drawImage(dc, src, x, y)
+		} else {
+			dc.DrawImage(src, x, y)
+		}
+	}
+
+	drawIm(-3, 1)
+	fill(2, 2, 20, 10, color.RGBA{0x40, 0x20, 0x10, 0x80})
+
+	dc.Push()
+	dc.Translate(3, 2)
+	clip(1, 1, 30, -8, func() {
+		fill(-5, -5, 50, 50, color.NRGBA{0x10, 0xff, 0x30, 0xc0})
+		drawIm(4, -2)
+
+		dc.Push()
+		dc.Translate(5, 1)
+		clip(20, -1, 12, 9, func() {
+			drawIm(22, -4)
+			fill(18, 0, 10, 3, color.White)
+		})
+		dc.Pop()
+
+		dc.RotateAbout(gg.Radians(30), 10, 5)
+		clip(0, 0, 15, 10, func() {
+			fill(0, 0, 20, 20, color.RGBA{0, 0, 0xff, 0xff})
+			drawIm(1, 1)
+
+			// back to whole pixels, but with a mask with soft edges
+			dc.Identity()
+			fill(5, 0, 20, 20, color.RGBA{0x80, 0, 0x80, 0x80})
+			drawIm(8, 2)
+			clip(6, 1, 5, 5, func() {
+				fill(0, 0, 64, 32, color.RGBA{0xff, 0xff, 0, 0xff})
+			})
+		})
+	})
+	dc.Pop()
+
+	// and the clip is gone again
+	fill(0, 20, 10, 10, color.RGBA{0, 0x80, 0x80, 0xff})
+
+	dc.Push()
+	dc.Translate(0.5, 0)
+	fill(40, 0, 4, 4, color.RGBA{0xff, 0, 0, 0xff})
+	drawIm(40, 5)
+	dc.Pop()
+
+	drawIm(30, 20)
+}
+
+func testImage() *image.RGBA {
+	src := image.NewRGBA(image.Rect(0, 0, 9, 7))
+	for i := range src.Pix {
+		src.Pix[i] = uint8(i * 37)
+	}
+	for i := 3; i < len(src.Pix); i += 4 {
+		src.Pix[i] = max(src.Pix[i-3], src.Pix[i-2], src.Pix[i-1])
+	}
+	return src
+}
+
+func TestCompositeMatchesGG(t *testing.T) {
+	src := testImage()
+
+	// both a blank canvas and one that has been drawn on
+	for _, blank := range []bool{true, false} {
+		want := gg.NewContextForRGBA(image.NewRGBA(image.Rect(0, 0, 64, 32)))
+		got, done := newCanvas(image.NewRGBA(image.Rect(0, 0, 64, 32)))
+		if !blank {
+			want.SetColor(color.RGBA{0x10, 0x10, 0x10, 0x10})
+			want.Clear()
+			got.SetColor(color.RGBA{0x10, 0x10, 0x10, 0x10})
+			got.Clear()
+		}
+
+		compositeSteps(want, false, src)
+		compositeSteps(got, true, src)
+		done()
+		assert.Equal(t, want.Image(), got.Image())
+	}
+
+	// contexts render doesn't own are drawn on by gg, whatever it
+	// knows about them
+	want := gg.NewContext(64, 32)
+	got := gg.NewContext(64, 32)
+	compositeSteps(want, false, src)
+	compositeSteps(got, true, src)
+	assert.Equal(t, want.Image(), got.Image())
+}
+
+func TestCompositeStackedImages(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 4, 3))
+	for i := range src.Pix {
+		src.Pix[i] = 0x80
+	}
+
+	// gg draws the last, which isn't RGBA, itself
+	nrgba := image.NewNRGBA(image.Rect(0, 0, 4, 3))
+	for i := range nrgba.Pix {
+		nrgba.Pix[i] = 0x80
+	}
+
+	// later images are blended with the first, on a canvas nothing
+	// else has been drawn on
+	want := gg.NewContext(10, 10)
+	want.DrawImage(src, 1, 1)
+	want.DrawImage(src, 3, 2)
+	want.DrawImage(nrgba, 2, 3)
+
+	got, done := newCanvas(image.NewRGBA(image.Rect(0, 0, 10, 10)))
+	defer done()
+	drawImage(got, src, 1, 1)
+	drawImage(got, src, 3, 2)
+	drawImage(got, nrgba, 2, 3)
+	assert.Equal(t, want.Image(), got.Image())
+
+	// and the same for a Stack of Texts, which draw their glyphs as
+	// images, painted like Root paints them
+	a := &Text{Content: "AB", Color: color.RGBA{0xff, 0, 0, 0xff}}
+	b := &Text{Content: "BA", Color: color.RGBA{0, 0, 0xff, 0xff}}
+	require.NoError(t, a.Init())
+	require.NoError(t, b.Init())
+
+	w, h := a.Size()
+	want = gg.NewContextForRGBA(image.NewRGBA(image.Rect(0, 0, w, h)))
+	want.DrawImage(a.img, 0, 0)
+	want.DrawImage(b.img, 0, 0)
+	assert.Equal(t, want.Image(), PaintWidget(Stack{Children: []Widget{a, b}}, image.Rect(0, 0, 64, 32), 0))
+
+	want = gg.NewContextForRGBA(image.NewRGBA(image.Rect(0, 0, 64, 32)))
+	want.DrawImage(a.img, 0, 0)
+	want.DrawImage(b.img, 0, 0)
+	frame := Root{Child: Stack{Children: []Widget{a, b}}}.PaintFrame(false, 0)
+	defer ReleaseFrames(frame)
+	assert.Equal(t, want.Image(), frame)
+}
+
+func TestCompositeFastPaths(t *testing.T) {
+	dc := gg.NewContext(10, 10)
+	dc.Translate(2, 3)
+	im, off, ok := pixelOffset(dc)
+	assert.True(t, ok)
+	assert.Same(t, dc.Image(), im)
+	assert.Equal(t, image.Pt(2, 3), off)
+
+	// but gg's clip isn't known, so the fast paths aren't taken
+	_, _, _, ok = fastCanvas(dc)
+	assert.False(t, ok)
+
+	dc.Translate(0.5, 0)
+	_, _, ok = pixelOffset(dc)
+	assert.False(t, ok)
+
+	dc = gg.NewContext(10, 10)
+	dc.Scale(2, 2)
+	_, _, ok = pixelOffset(dc)
+	assert.False(t, ok)
+
+	dc, done := newCanvas(image.NewRGBA(image.Rect(0, 0, 10, 10)))
+	dc.Translate(2, 3)
+	c, _, _, ok := fastCanvas(dc)
+	require.True(t, ok)
+	assert.Nil(t, c.mask)
+
+	withClip(dc, 0, 0, 4, 4, func() {
+		_, _, _, ok := fastCanvas(dc)
+		require.True(t, ok)
+		assert.Equal(t, uint8(0xff), c.mask.AlphaAt(2, 3).A)
+		assert.Equal(t, uint8(0), c.mask.AlphaAt(6, 3).A)
+
+		dc.Rotate(1)
+		withClip(dc, 0, 0, 2, 2, func() {
+			dc.Identity()
+			_, _, _, ok := fastCanvas(dc)
+			assert.False(t, ok)
+		})
+		assert.True(t, c.known)
+	})
+	assert.Nil(t, c.mask)
+	assert.True(t, c.known)
+
+	done()
+	_, _, _, ok = fastCanvas(dc)
+	assert.False(t, ok)
+}
+
+func benchmarkScene(b *testing.B, width, height int) {
+	rows := []Widget{}
+	for i := 0; i < height/10; i++ {
+		text := &Text{Content: "Hello, World! 12:34", Color: color.RGBA{0xff, 0x80, 0, 0xff}}
+		require.NoError(b, text.Init())
+		rows = append(rows, &Box{Height: 10, Color: color.RGBA{0, 0, 0x40, 0xff}, Child: text})
+	}
+	r := Root{Child: &Box{
+		Color: color.RGBA{0x20, 0x20, 0x20, 0xff},
+		Child: &Padding{Pad: Insets{1, 1, 1, 1}, Child: &Column{Children: rows}},
+	}}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ReleaseFrames(r.PaintFrame(true, 0, WithFrameSize(width, height)))
+	}
+}
+
+func BenchmarkPaintFrame(b *testing.B) {
+	b.Run("64x32", func(b *testing.B) { benchmarkScene(b, 64, 32) })
+	b.Run("128x64", func(b *testing.B) { benchmarkScene(b, 128, 64) })
+}
+
+func BenchmarkComposite(b *testing.B) {
+	src := image.NewRGBA(image.Rect(0, 0, 60, 20))
+	for i := range src.Pix {
+		src.Pix[i] = 0x80
+	}
+
+	for _, fast := range []bool{false, true} {
+		name := "gg"
+		if fast {
+			name = "fast"
+		}
+		b.Run(name, func(b *testing.B) {
+			dc, done := newCanvas(image.NewRGBA(image.Rect(0, 0, 128, 64)))
+			defer done()
+			for i := 0; i < b.N; i++ {
+				compositeSteps(dc, fast, src)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package render
+
+import (
+	"image"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// DefaultFontFallback is the chain of fonts consulted for characters
+// missing from a widget's font, unless the widget sets its own. It's
+// empty, so that characters a font lacks are drawn as its default
+// character, as they always were; widgets opt in with `fallback`.
+var DefaultFontFallback = []string{}
+
+var fallbackCache = map[string]font.Face{}
+var fallbackMutex = &sync.Mutex{}
+
+// GetFontWithFallback returns a face that draws with the named font,
+// but falls back through the fonts in `fallback`, in order, for any
+// character the font doesn't have. Metrics are always those of the
+// named font, so glyphs from taller fallback fonts may be cropped.
+func GetFontWithFallback(name string, fallback []string) (font.Face, error) {
+	primary, err := GetFont(name)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, fb := range fallback {
+		if fb != name {
+			names = append(names, fb)
+		}
+	}
+	if len(names) == 0 {
+		return primary, nil
+	}
+
+	key := name + "|" + strings.Join(names, "|")
+
+	fallbackMutex.Lock()
+	defer fallbackMutex.Unlock()
+
+	if face, ok := fallbackCache[key]; ok {
+		return face, nil
+	}
+
+	face := &fallbackFace{faces: []font.Face{primary}}
+	for _, fb := range names {
+		f, err := GetFont(fb)
+		if err != nil {
+			return nil, err
+		}
+		face.faces = append(face.faces, f)
+	}
+
+	fallbackCache[key] = face
+	return face, nil
+}
+
+// fallbackFace draws each glyph with the first of its faces that
+// has it.
+type fallbackFace struct {
+	faces []font.Face
+}
+
+// faceFor returns the face to draw r with. If none of the faces has
+// a glyph for r, the first face is used, so that its default
+// character is drawn.
+func (f *fallbackFace) faceFor(r rune) font.Face {
+	for _, face := range f.faces {
+		if hasGlyph(face, r) {
+			return face
+		}
+	}
+	return f.faces[0]
+}
+
+func (f *fallbackFace) HasGlyph(r rune) bool {
+	for _, face := range f.faces {
+		if hasGlyph(face, r) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fallbackFace) Close() error {
+	return nil
+}
+
+func (f *fallbackFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	return f.faceFor(r).Glyph(dot, r)
+}
+
+func (f *fallbackFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+	return f.faceFor(r).GlyphBounds(r)
+}
+
+func (f *fallbackFace) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+	return f.faceFor(r).GlyphAdvance(r)
+}
+
+func (f *fallbackFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	return f.faceFor(r1).Kern(r0, r1)
+}
+
+func (f *fallbackFace) Metrics() font.Metrics {
+	return f.faces[0].Metrics()
+}
+
+// fallbackFonts returns the fallback chain for a widget, which is
+// DefaultFontFallback unless the widget sets one. An empty, non-nil
+// chain disables fallback even if DefaultFontFallback is changed.
+func fallbackFonts(fallback []string) []string {
+	if fallback == nil {
+		return DefaultFontFallback
+	}
+	return fallback
+}
@@ -0,0 +1,76 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFallbackFace(t *testing.T) {
+	face, err := GetFontWithFallback("tb-8", []string{"6x13", "cjk-12"})
+	require.NoError(t, err)
+
+	primary, err := GetFont("tb-8")
+	require.NoError(t, err)
+
+	// latin is drawn by tb-8 (where '!' is narrow), runes aren't in
+	// tb-8 but in 6x13, and CJK is only found in cjk-12
+	adv, ok := face.GlyphAdvance('!')
+	assert.True(t, ok)
+	primaryAdv, _ := primary.GlyphAdvance('!')
+	assert.Equal(t, primaryAdv, adv)
+	adv, _ = face.GlyphAdvance('ᚠ')
+	assert.Equal(t, 6, adv.Round())
+	adv, _ = face.GlyphAdvance('日')
+	assert.Equal(t, 12, adv.Round())
+
+	assert.True(t, hasGlyph(face, '日'))
+	assert.False(t, hasGlyph(face, '😀'))
+
+	// metrics are always those of the primary font
+	assert.Equal(t, primary.Metrics(), face.Metrics())
+
+	// faces are cached
+	face2, err := GetFontWithFallback("tb-8", []string{"6x13", "cjk-12"})
+	require.NoError(t, err)
+	assert.Equal(t, face, face2)
+
+	// and the primary font is used as is without fallbacks
+	face, err = GetFontWithFallback("tb-8", []string{"tb-8"})
+	require.NoError(t, err)
+	assert.Equal(t, primary, face)
+
+	_, err = GetFontWithFallback("tb-8", []string{"missing"})
+	assert.Error(t, err)
+}
+
+func TestTextFallback(t *testing.T) {
+	// with a chain, CJK characters are drawn with cjk-12
+	text := &Text{Content: "a日", Fallback: []string{"6x13", "10x20", "cjk-12"}}
+	require.NoError(t, text.Init())
+	w, h := text.Size()
+	assert.Equal(t, 5+12, w)
+	assert.Equal(t, 8, h)
+
+	// and by default, as the font's default character
+	text = &Text{Content: "a日"}
+	require.NoError(t, text.Init())
+	w, _ = text.Size()
+	assert.Equal(t, 5+5, w)
+
+	text = &Text{Content: "a日", Fallback: []string{}}
+	require.NoError(t, text.Init())
+	w, _ = text.Size()
+	assert.Equal(t, 5+5, w)
+
+	text = &Text{Content: "a日", Fallback: []string{"missing"}}
+	assert.Error(t, text.Init())
+}
+
+func TestFallbackShaping(t *testing.T) {
+	// Arabic presentation forms from a fallback font are used too
+	face, err := GetFontWithFallback("tb-8", []string{"10x20"})
+	require.NoError(t, err)
+	assert.Equal(t, "ﺳﻼﻡ", shapeArabic(face, "سلام"))
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/zachomedia/go-bdf"
 	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
 )
 
 var fontCache = map[string]font.Face{}
@@ -45,6 +46,31 @@ func GetFont(name string) (font.Face, error) {
 		return nil, fmt.Errorf("parsing font '%s': %w", name, err)
 	}
 
-	fontCache[name] = f.NewFace()
+	fontCache[name] = &bdfFace{Face: f.NewFace(), font: f}
 	return fontCache[name], nil
 }
+
+// bdfFace wraps the face of a BDF font. Nonspacing marks (combining
+// diacritics) are drawn on top of the preceding glyph instead of next
+// to it. The bundled fonts draw marks in a cell of their own, meant
+// to be overstruck onto the previous one, so we simply kern them back
+// by their own advance.
+type bdfFace struct {
+	font.Face
+	font *bdf.Font
+}
+
+// HasGlyph returns true if the font has a glyph for r. Unlike
+// GlyphAdvance, this doesn't consider the font's default character.
+func (f *bdfFace) HasGlyph(r rune) bool {
+	_, ok := f.font.CharMap[r]
+	return ok
+}
+
+func (f *bdfFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	if isMark(r1) && f.HasGlyph(r1) {
+		adv, _ := f.Face.GlyphAdvance(r1)
+		return -adv
+	}
+	return f.Face.Kern(r0, r1)
+}
@@ -0,0 +1,52 @@
+package render
+
+import (
+	"image"
+	"sync"
+)
+
+// framePools hold the buffers of released frames, by frame size, for
+// painting later frames into. Services rendering many apps otherwise
+// spend much of their time collecting the garbage of frames that are
+// encoded once and thrown away.
+var (
+	framePoolsMutex sync.Mutex
+	framePools      = map[image.Point]*sync.Pool{}
+)
+
+func framePool(size image.Point) *sync.Pool {
+	framePoolsMutex.Lock()
+	defer framePoolsMutex.Unlock()
+
+	pool, ok := framePools[size]
+	if !ok {
+		pool = &sync.Pool{}
+		framePools[size] = pool
+	}
+	return pool
+}
+
+// newFrame returns a transparent frame of the given size, reusing the
+// buffer of a released frame if there is one.
+func newFrame(width, height int) *image.RGBA {
+	size := image.Pt(width, height)
+	if im, ok := framePool(size).Get().(*image.RGBA); ok {
+		clear(im.Pix)
+		return im
+	}
+	return image.NewRGBA(image.Rectangle{Max: size})
+}
+
+// ReleaseFrames hands frames returned by Root.Paint and Root.PaintFrame
+// back, for their buffers to be reused by later renders. The frames
+// must not be used after they're released, and only frames painted by
+// a Root may be released: other images could still be in use.
+func ReleaseFrames(frames ...image.Image) {
+	for _, frame := range frames {
+		im, ok := frame.(*image.RGBA)
+		if !ok || im.Rect.Min != (image.Point{}) || im.Stride != 4*im.Rect.Dx() {
+			continue
+		}
+		framePool(im.Rect.Size()).Put(im)
+	}
+}
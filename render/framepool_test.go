@@ -0,0 +1,49 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFramePool(t *testing.T) {
+	// pools may drop what's put in them, so try a few times
+	reused := false
+	for i := 0; i < 10 && !reused; i++ {
+		frame := newFrame(4, 2)
+		frame.Set(1, 1, color.White)
+		ReleaseFrames(frame)
+
+		again := newFrame(4, 2)
+		reused = again == frame
+		assert.Equal(t, make([]uint8, 4*4*2), again.Pix)
+	}
+	assert.True(t, reused)
+
+	// frames are only reused at the same size
+	frame := newFrame(4, 2)
+	ReleaseFrames(frame)
+	assert.Equal(t, image.Rect(0, 0, 2, 4), newFrame(2, 4).Bounds())
+
+	// and sub-images are never reused
+	sub := image.NewRGBA(image.Rect(0, 0, 8, 4)).SubImage(image.Rect(0, 0, 4, 2))
+	ReleaseFrames(sub, image.NewAlpha(image.Rect(0, 0, 4, 2)))
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, 4*4, newFrame(4, 2).Stride)
+	}
+}
+
+func TestRootReleaseFrames(t *testing.T) {
+	r := Root{Child: &Box{Width: 2, Height: 2, Color: color.White}}
+
+	frames := r.Paint(false)
+	want := slices.Clone(frames[0].(*image.RGBA).Pix)
+	for i := 0; i < 10; i++ {
+		ReleaseFrames(frames...)
+		frames = r.Paint(false)
+		assert.Equal(t, want, frames[0].(*image.RGBA).Pix)
+	}
+}
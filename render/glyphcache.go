@@ -0,0 +1,98 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/f64"
+	"golang.org/x/image/math/fixed"
+)
+
+// GlyphCacheSize is how many bytes of glyphs, drawn in the colors text
+// is shown in, are kept in memory. Drawing text copies glyphs from the
+// cache, rather than drawing each from the font. The cache is shared by
+// all renders in the process. Set it to 0 to turn the cache off.
+var GlyphCacheSize int64 = 4 << 20
+
+type glyphKey struct {
+	face  font.Face
+	r     rune
+	color color.RGBA64
+}
+
+var glyphCache = newLRU[glyphKey, *image.RGBA]("")
+
+// drawString draws s with its baseline starting at x, y. It's
+// gg.Context.DrawString for a context without transforms or clipping,
+// but with glyphs taken from the glyph cache.
+func drawString(dst *image.RGBA, face font.Face, col color.Color, s string, x, y float64) {
+	r, g, b, a := col.RGBA()
+	c := color.RGBA64{uint16(r), uint16(g), uint16(b), uint16(a)}
+
+	eachGlyph(face, s, x, y, func(ch rune, dr image.Rectangle, mask image.Image, maskp image.Point) {
+		glyph := glyphImage(glyphKey{face, ch, c}, dr.Size(), mask, maskp)
+		draw.Draw(dst, dr, glyph, image.Point{}, draw.Over)
+	})
+}
+
+// stringBounds returns the area drawString draws s in.
+func stringBounds(face font.Face, s string, x, y float64) image.Rectangle {
+	var bounds image.Rectangle
+	eachGlyph(face, s, x, y, func(ch rune, dr image.Rectangle, mask image.Image, maskp image.Point) {
+		bounds = bounds.Union(dr)
+	})
+	return bounds
+}
+
+// eachGlyph calls f with each glyph of s that has pixels, and where it
+// goes, laying the glyphs out the way gg does.
+func eachGlyph(face font.Face, s string, x, y float64, f func(ch rune, dr image.Rectangle, mask image.Image, maskp image.Point)) {
+	dot := fixed.Point26_6{X: fix(x), Y: fix(y)}
+	prev := rune(-1)
+	for _, ch := range s {
+		if prev >= 0 {
+			dot.X += face.Kern(prev, ch)
+		}
+		dr, mask, maskp, advance, ok := face.Glyph(dot, ch)
+		if !ok {
+			continue
+		}
+
+		if !dr.Empty() {
+			f(ch, dr, mask, maskp)
+		}
+
+		dot.X += advance
+		prev = ch
+	}
+}
+
+// glyphImage returns the glyph drawn in its color, from the cache if
+// it's there. The glyph is drawn the way gg draws glyphs, so that text
+// looks the same either way.
+func glyphImage(key glyphKey, size image.Point, mask image.Image, maskp image.Point) *image.RGBA {
+	if GlyphCacheSize > 0 {
+		if glyph, ok := glyphCache.get(key); ok && glyph.Rect.Size() == size {
+			return glyph
+		}
+	}
+
+	glyph := image.NewRGBA(image.Rectangle{Max: size})
+	draw.BiLinear.Transform(glyph, f64.Aff3{1, 0, 0, 0, 1, 0}, image.NewUniform(key.color), glyph.Rect, draw.Over, &draw.Options{
+		SrcMask:  mask,
+		SrcMaskP: maskp,
+	})
+
+	if GlyphCacheSize > 0 {
+		glyphCache.add(key, glyph, int64(len(glyph.Pix)), GlyphCacheSize)
+	}
+	return glyph
+}
+
+// fix converts a coordinate to fixed point, rounding like gg does.
+func fix(x float64) fixed.Int26_6 {
+	return fixed.Int26_6(math.Round(x * 64))
+}
@@ -0,0 +1,113 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidbyt/gg"
+	"golang.org/x/image/math/fixed"
+)
+
+func resetGlyphCache(t testing.TB, size int64) {
+	glyphCache.reset()
+
+	oldSize := GlyphCacheSize
+	GlyphCacheSize = size
+	t.Cleanup(func() {
+		GlyphCacheSize = oldSize
+		glyphCache.reset()
+	})
+}
+
+func TestDrawStringMatchesGG(t *testing.T) {
+	resetGlyphCache(t, 1<<20)
+
+	for _, tc := range []struct {
+		font      string
+		color     color.Color
+		antialias bool
+	}{
+		{"tb-8", color.White, false},
+		{"6x13", color.RGBA{0xff, 0, 0xaa, 0xff}, false},
+		{"6x13", color.NRGBA{0, 0xff, 0xff, 0x80}, true},
+		{"tom-thumb", color.RGBA{0x10, 0x20, 0x30, 0xff}, true},
+	} {
+		face, err := getTextFace(tc.font, nil, tc.antialias)
+		require.NoError(t, err)
+
+		// twice, to draw from the cache the second time
+		for i := 0; i < 2; i++ {
+			want := gg.NewContext(100, 20)
+			want.SetFontFace(face)
+			want.SetColor(tc.color)
+			want.DrawString("Hello, World! 123", 1, 15)
+
+			got := image.NewRGBA(image.Rect(0, 0, 100, 20))
+			drawString(got, face, tc.color, "Hello, World! 123", 1, 15)
+
+			assert.Equal(t, want.Image().(*image.RGBA).Pix, got.Pix, tc.font)
+		}
+	}
+}
+
+func TestGlyphCache(t *testing.T) {
+	resetGlyphCache(t, 1<<20)
+
+	face, err := GetFont("tb-8")
+	require.NoError(t, err)
+
+	img := image.NewRGBA(image.Rect(0, 0, 50, 10))
+	drawString(img, face, color.White, "aab", 0, 8)
+	assert.Len(t, glyphCache.items, 2)
+
+	// glyphs are cached by color
+	drawString(img, face, color.Black, "a", 0, 8)
+	assert.Len(t, glyphCache.items, 3)
+
+	// and by face
+	other, err := GetFont("6x13")
+	require.NoError(t, err)
+	drawString(img, other, color.White, "a", 0, 8)
+	assert.Len(t, glyphCache.items, 4)
+}
+
+func TestGlyphCacheEviction(t *testing.T) {
+	face, err := GetFont("tb-8")
+	require.NoError(t, err)
+	_, glyph, _, _, _ := face.Glyph(fixed.Point26_6{X: fix(0), Y: fix(8)}, 'a')
+	size := int64(glyph.Bounds().Dx() * glyph.Bounds().Dy() * 4)
+
+	resetGlyphCache(t, 2*size)
+
+	img := image.NewRGBA(image.Rect(0, 0, 50, 10))
+	for _, c := range []color.Color{color.White, color.Black, color.RGBA{0xff, 0, 0, 0xff}} {
+		drawString(img, face, c, "a", 0, 8)
+	}
+	assert.Len(t, glyphCache.items, 2)
+	assert.Equal(t, 2*size, glyphCache.size)
+
+	resetGlyphCache(t, 0)
+	drawString(img, face, color.White, "a", 0, 8)
+	assert.Empty(t, glyphCache.items)
+}
+
+func BenchmarkTextInit(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		text := &Text{Content: "Hello, World! 12:34 PM"}
+		if err := text.Init(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkWrappedTextPaint(b *testing.B) {
+	text := &WrappedText{Content: "Hello, World! This is a longer text that wraps", Width: 64}
+	require.NoError(b, text.Init())
+
+	for i := 0; i < b.N; i++ {
+		PaintWidget(text, image.Rect(0, 0, 64, 32), 0)
+	}
+}
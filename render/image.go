@@ -2,13 +2,14 @@ package render
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"image"
-	"image/color"
 	"image/draw"
 	"image/gif"
 	"image/jpeg"
+	"slices"
 
 	// register image formats
 	_ "image/jpeg"
@@ -49,14 +50,14 @@ func (p *Image) PaintBounds(bounds image.Rectangle, frameIdx int) image.Rectangl
 }
 
 func (p *Image) Paint(dc *gg.Context, bounds image.Rectangle, frameIdx int) {
-	dc.DrawImage(p.imgs[ModInt(frameIdx, len(p.imgs))], 0, 0)
+	drawImage(dc, p.imgs[ModInt(frameIdx, len(p.imgs))], 0, 0)
 }
 
 func (p *Image) Size() (int, int) {
 	return p.imgs[0].Bounds().Dx(), p.imgs[0].Bounds().Dy()
 }
 
-func (p *Image) FrameCount() int {
+func (p *Image) FrameCount(bounds image.Rectangle) int {
 	return len(p.imgs)
 }
 
@@ -107,11 +108,7 @@ func (p *Image) InitFromGIF(data []byte) error {
 		// if the frame is DisposalBackground
 		// remove the frame pixels
 		if disposal_method == gif.DisposalBackground {
-			for x := bounds.Min.X; x < bounds.Max.X; x++ {
-				for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-					last.Set(x, y, color.Transparent)
-				}
-			}
+			draw.Draw(last, bounds, image.Transparent, image.Point{}, draw.Src)
 		}
 
 		p.imgs = append(p.imgs, &frame)
@@ -158,7 +155,33 @@ func (p *Image) InitFromSVG(data []byte) error {
 	return nil
 }
 
+// Init decodes the image, or takes it from the image cache if the same
+// source was decoded at the same size before. See ImageCacheSize.
 func (p *Image) Init() error {
+	key := imageKey{
+		hash:   sha256.Sum256([]byte(p.Src)),
+		width:  p.Width,
+		height: p.Height,
+	}
+	if cached, ok := cachedImage(key); ok {
+		p.imgs = cached.imgs
+		p.Delay = cached.delay
+		return nil
+	}
+
+	if err := p.decode(); err != nil {
+		return err
+	}
+
+	if ImageCacheSize > 0 {
+		cacheImage(key, &decodedImage{imgs: slices.Clip(p.imgs), delay: p.Delay})
+	}
+	return nil
+}
+
+// decode decodes the image data, and scales it to the width and height
+// asked for.
+func (p *Image) decode() error {
 	err := p.InitFromWebP([]byte(p.Src))
 	if err != nil {
 		err = p.InitFromGIF([]byte(p.Src))
@@ -0,0 +1,64 @@
+package render
+
+import (
+	"crypto/sha256"
+	"image"
+)
+
+// ImageCacheSize is how many bytes of decoded images are kept in
+// memory, so that an image shown in many frames, or in every run of an
+// app, is only decoded and resized once. The cache is shared by all
+// renders in the process. Set it to 0 to turn the cache off.
+var ImageCacheSize int64 = 32 << 20
+
+// imageKey identifies a decoded image by the hash of its source and the
+// size it was scaled to.
+type imageKey struct {
+	hash          [sha256.Size]byte
+	width, height int
+}
+
+// decodedImage is what Image.Init makes of its source. The images are
+// shared by every Image with the same key, so are never modified.
+type decodedImage struct {
+	imgs  []image.Image
+	delay int
+}
+
+var imageCache = newLRU[imageKey, *decodedImage]("image")
+
+// cachedImage returns the decoded image for key, if it's cached.
+func cachedImage(key imageKey) (*decodedImage, bool) {
+	if ImageCacheSize <= 0 {
+		return nil, false
+	}
+	return imageCache.get(key)
+}
+
+// cacheImage adds a decoded image to the cache.
+func cacheImage(key imageKey, img *decodedImage) {
+	size := int64(0)
+	for _, im := range img.imgs {
+		size += imageBytes(im)
+	}
+	imageCache.add(key, img, size, ImageCacheSize)
+}
+
+// imageBytes estimates the memory held by a decoded image.
+func imageBytes(im image.Image) int64 {
+	switch im := im.(type) {
+	case *image.RGBA:
+		return int64(len(im.Pix))
+	case *image.NRGBA:
+		return int64(len(im.Pix))
+	case *image.Paletted:
+		return int64(len(im.Pix) + 4*len(im.Palette))
+	case *image.Gray:
+		return int64(len(im.Pix))
+	case *image.YCbCr:
+		return int64(len(im.Y) + len(im.Cb) + len(im.Cr))
+	}
+
+	b := im.Bounds()
+	return int64(b.Dx()) * int64(b.Dy()) * 4
+}
@@ -0,0 +1,96 @@
+package render
+
+import (
+	"encoding/base64"
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// resetImageCache empties the image cache, and sets its size for the
+// rest of the test.
+func resetImageCache(t *testing.T, size int64) {
+	imageCache.reset()
+
+	oldSize := ImageCacheSize
+	ImageCacheSize = size
+	t.Cleanup(func() {
+		ImageCacheSize = oldSize
+		imageCache.reset()
+	})
+}
+
+func TestImageCache(t *testing.T) {
+	resetImageCache(t, 1<<20)
+	raw, _ := base64.StdEncoding.DecodeString(testPNG)
+
+	first := &Image{Src: string(raw)}
+	require.NoError(t, first.Init())
+	second := &Image{Src: string(raw)}
+	require.NoError(t, second.Init())
+
+	// decoded once, and shared
+	assert.Same(t, first.imgs[0], second.imgs[0])
+	assert.Len(t, imageCache.items, 1)
+
+	// scaled images are cached separately
+	scaled := &Image{Src: string(raw), Width: 20}
+	require.NoError(t, scaled.Init())
+	assert.NotSame(t, first.imgs[0], scaled.imgs[0])
+	w, h := scaled.Size()
+	assert.Equal(t, 20, w)
+	assert.Equal(t, 24, h)
+	assert.Len(t, imageCache.items, 2)
+	assert.Equal(t, int64(10*12*4+20*24*4), imageCache.size)
+}
+
+func TestImageCacheAnimated(t *testing.T) {
+	resetImageCache(t, 1<<20)
+	const testGIF = "R0lGODlhBQAEAPAAAAAAAAAAACH5BAF7AAAAIf8LTkVUU0NBUEUyLjADAQAAACwAAAAABQAEAAACBgRiaLmLBQAh+QQBewAAACwAAAAABQAEAAACBYRzpqhXACH5BAF7AAAALAAAAAAFAAQAAAIGDG6Qp8wFACH5BAF7AAAALAAAAAAFAAQAAAIGRIBnyMoFADs="
+	raw, _ := base64.StdEncoding.DecodeString(testGIF)
+
+	first := &Image{Src: string(raw)}
+	require.NoError(t, first.Init())
+	second := &Image{Src: string(raw)}
+	require.NoError(t, second.Init())
+
+	assert.Equal(t, first.FrameCount(image.Rect(0, 0, 64, 32)), second.FrameCount(image.Rect(0, 0, 64, 32)))
+	assert.Equal(t, first.Delay, second.Delay)
+	assert.Same(t, first.imgs[1], second.imgs[1])
+}
+
+func TestImageCacheEviction(t *testing.T) {
+	// room for the image scaled to 20x24 and 30x36
+	resetImageCache(t, 20*24*4+30*36*4)
+	raw, _ := base64.StdEncoding.DecodeString(testPNG)
+
+	for _, width := range []int{0, 20, 30} {
+		require.NoError(t, (&Image{Src: string(raw), Width: width}).Init())
+	}
+
+	// the least recently used one was evicted
+	assert.Len(t, imageCache.items, 2)
+	assert.Equal(t, ImageCacheSize, imageCache.size)
+	for key := range imageCache.items {
+		assert.NotZero(t, key.width)
+	}
+
+	// images too big for the cache aren't cached
+	require.NoError(t, (&Image{Src: string(raw), Width: 100}).Init())
+	assert.Len(t, imageCache.items, 2)
+}
+
+func TestImageCacheDisabled(t *testing.T) {
+	resetImageCache(t, 0)
+	raw, _ := base64.StdEncoding.DecodeString(testPNG)
+
+	first := &Image{Src: string(raw)}
+	require.NoError(t, first.Init())
+	second := &Image{Src: string(raw)}
+	require.NoError(t, second.Init())
+
+	assert.NotSame(t, first.imgs[0], second.imgs[0])
+	assert.Empty(t, imageCache.items)
+}
@@ -0,0 +1,457 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// WidgetTypeKey is the JSON object key holding the widget type name
+// in serialized widget trees.
+const WidgetTypeKey = "type"
+
+var (
+	widgetTypes      = map[string]reflect.Type{}
+	widgetTypeNames  = map[reflect.Type]string{}
+	widgetTypesMutex = &sync.RWMutex{}
+
+	widgetType      = reflect.TypeOf((*Widget)(nil)).Elem()
+	colorType       = reflect.TypeOf((*color.Color)(nil)).Elem()
+	marshalerType   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+func init() {
+	RegisterWidget("Animation", &Animation{})
+	RegisterWidget("Box", &Box{})
+	RegisterWidget("Circle", &Circle{})
+	RegisterWidget("Column", &Column{})
+	RegisterWidget("Image", &Image{})
+	RegisterWidget("Marquee", &Marquee{})
+	RegisterWidget("Padding", &Padding{})
+	RegisterWidget("PieChart", &PieChart{})
+	RegisterWidget("Plot", &Plot{})
+	RegisterWidget("Row", &Row{})
+	RegisterWidget("Sequence", &Sequence{})
+	RegisterWidget("Stack", &Stack{})
+	RegisterWidget("Text", &Text{})
+	RegisterWidget("Vector", &Vector{})
+	RegisterWidget("WrappedText", &WrappedText{})
+}
+
+// RegisterWidget makes a widget type known to MarshalWidget and
+// UnmarshalWidget under the given name. Widgets defined outside of
+// this package (e.g. in render/animation) must register themselves
+// to be serializable.
+//
+// Exported fields are serialized using their `starlark` tag name, so
+// the JSON mirrors the attributes available in Starlark. Widgets that
+// need full control over their representation can implement
+// json.Marshaler and json.Unmarshaler.
+func RegisterWidget(name string, w Widget) {
+	t := reflect.TypeOf(w)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	widgetTypesMutex.Lock()
+	defer widgetTypesMutex.Unlock()
+
+	widgetTypes[name] = t
+	widgetTypeNames[t] = name
+}
+
+// MarshalRoot serializes a Root and its entire widget tree to JSON.
+func MarshalRoot(r Root) ([]byte, error) {
+	v, err := encodeStruct(reflect.ValueOf(r))
+	if err != nil {
+		return nil, fmt.Errorf("marshaling root: %w", err)
+	}
+
+	return json.Marshal(v)
+}
+
+// UnmarshalRoot deserializes a Root previously serialized with
+// MarshalRoot. All widgets in the tree are initialized, so the
+// returned Root can be painted right away.
+func UnmarshalRoot(data []byte) (Root, error) {
+	r := Root{}
+	if err := decodeStruct(data, reflect.ValueOf(&r).Elem()); err != nil {
+		return Root{}, fmt.Errorf("unmarshaling root: %w", err)
+	}
+
+	if r.Child == nil {
+		return Root{}, fmt.Errorf("unmarshaling root: missing child")
+	}
+
+	return r, nil
+}
+
+// MarshalWidget serializes a single widget, and all its children, to
+// JSON.
+func MarshalWidget(w Widget) ([]byte, error) {
+	v, err := encodeWidget(w)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(v)
+}
+
+// UnmarshalWidget deserializes a widget previously serialized with
+// MarshalWidget, and initializes it.
+func UnmarshalWidget(data []byte) (Widget, error) {
+	var header map[string]json.RawMessage
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("widget must be a JSON object: %w", err)
+	}
+
+	var name string
+	if err := json.Unmarshal(header[WidgetTypeKey], &name); err != nil || name == "" {
+		return nil, fmt.Errorf("widget is missing '%s'", WidgetTypeKey)
+	}
+
+	widgetTypesMutex.RLock()
+	t, ok := widgetTypes[name]
+	widgetTypesMutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown widget type '%s'", name)
+	}
+
+	ptr := reflect.New(t)
+	if u, ok := ptr.Interface().(json.Unmarshaler); ok {
+		if err := u.UnmarshalJSON(data); err != nil {
+			return nil, fmt.Errorf("unmarshaling %s: %w", name, err)
+		}
+	} else if err := decodeStruct(data, ptr.Elem()); err != nil {
+		return nil, fmt.Errorf("unmarshaling %s: %w", name, err)
+	}
+
+	w, ok := ptr.Interface().(Widget)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a widget", name)
+	}
+
+	if i, ok := w.(WidgetWithInit); ok {
+		if err := i.Init(); err != nil {
+			return nil, fmt.Errorf("initializing %s: %w", name, err)
+		}
+	}
+
+	return w, nil
+}
+
+func encodeWidget(w Widget) (map[string]interface{}, error) {
+	v := reflect.ValueOf(w)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, fmt.Errorf("nil widget")
+		}
+		v = v.Elem()
+	}
+
+	widgetTypesMutex.RLock()
+	name, ok := widgetTypeNames[v.Type()]
+	widgetTypesMutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("widget type %s is not serializable", v.Type())
+	}
+
+	var fields map[string]interface{}
+	if m, ok := w.(json.Marshaler); ok {
+		data, err := m.MarshalJSON()
+		if err != nil {
+			return nil, fmt.Errorf("marshaling %s: %w", name, err)
+		}
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return nil, fmt.Errorf("marshaling %s: must produce a JSON object", name)
+		}
+	} else {
+		var err error
+		fields, err = encodeStruct(v)
+		if err != nil {
+			return nil, fmt.Errorf("marshaling %s: %w", name, err)
+		}
+	}
+
+	fields[WidgetTypeKey] = name
+	return fields, nil
+}
+
+func encodeStruct(v reflect.Value) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key, ok := jsonFieldKey(t.Field(i))
+		if !ok {
+			continue
+		}
+
+		val, err := encodeValue(v.Field(i))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		if val != nil {
+			fields[key] = val
+		}
+	}
+
+	return fields, nil
+}
+
+func encodeValue(v reflect.Value) (interface{}, error) {
+	if v.Type().Implements(marshalerType) && v.Kind() != reflect.Interface {
+		return v.Interface(), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if v.IsNil() {
+			return nil, nil
+		}
+		if w, ok := v.Interface().(Widget); ok && v.Type() == widgetType {
+			return encodeWidget(w)
+		}
+		if c, ok := v.Interface().(color.Color); ok {
+			return colorToHex(c), nil
+		}
+		if v.Kind() == reflect.Ptr {
+			return encodeValue(v.Elem())
+		}
+		return nil, fmt.Errorf("can't serialize %s", v.Elem().Type())
+
+	case reflect.Float32, reflect.Float64:
+		// NaN is used to mark unset values, e.g. in Plot limits
+		f := v.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, nil
+		}
+		return f, nil
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+		list := make([]interface{}, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			val, err := encodeValue(v.Index(i))
+			if err != nil {
+				return nil, fmt.Errorf("index %d: %w", i, err)
+			}
+			list[i] = val
+		}
+		return list, nil
+
+	case reflect.Struct:
+		return encodeStruct(v)
+
+	default:
+		return v.Interface(), nil
+	}
+}
+
+func decodeStruct(data []byte, v reflect.Value) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key, ok := jsonFieldKey(t.Field(i))
+		if !ok {
+			continue
+		}
+
+		raw, found := fields[key]
+		if !found {
+			// missing floats are unset rather than zero
+			setUnsetFloats(v.Field(i))
+			continue
+		}
+
+		if err := decodeValue(raw, v.Field(i)); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+func setUnsetFloats(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Float64 {
+			for i := 0; i < v.Len(); i++ {
+				v.Index(i).SetFloat(math.NaN())
+			}
+		}
+	}
+}
+
+func decodeValue(raw json.RawMessage, v reflect.Value) error {
+	isNull := bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+
+	if v.Kind() != reflect.Interface && reflect.PointerTo(v.Type()).Implements(unmarshalerType) {
+		return v.Addr().Interface().(json.Unmarshaler).UnmarshalJSON(raw)
+	}
+
+	switch {
+	case v.Type() == widgetType:
+		if isNull {
+			return nil
+		}
+		w, err := UnmarshalWidget(raw)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(w))
+		return nil
+
+	case v.Type() == colorType:
+		if isNull {
+			return nil
+		}
+		var hex string
+		if err := json.Unmarshal(raw, &hex); err != nil {
+			return err
+		}
+		c, err := ParseColor(hex)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(c))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		if isNull {
+			v.SetFloat(math.NaN())
+			return nil
+		}
+		return json.Unmarshal(raw, v.Addr().Interface())
+
+	case reflect.Slice, reflect.Array:
+		if isNull {
+			setUnsetFloats(v)
+			return nil
+		}
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return err
+		}
+		if v.Kind() == reflect.Array {
+			if len(items) != v.Len() {
+				return fmt.Errorf("expected %d elements, found %d", v.Len(), len(items))
+			}
+		} else {
+			v.Set(reflect.MakeSlice(v.Type(), len(items), len(items)))
+		}
+		for i, item := range items {
+			if err := decodeValue(item, v.Index(i)); err != nil {
+				return fmt.Errorf("index %d: %w", i, err)
+			}
+		}
+		return nil
+
+	case reflect.Struct:
+		if isNull {
+			return nil
+		}
+		return decodeStruct(raw, v)
+
+	case reflect.Interface:
+		if isNull {
+			return nil
+		}
+		return fmt.Errorf("can't deserialize %s", v.Type())
+
+	default:
+		return json.Unmarshal(raw, v.Addr().Interface())
+	}
+}
+
+// Returns the JSON key for a struct field, or false if the field
+// shouldn't be serialized.
+func jsonFieldKey(f reflect.StructField) (string, bool) {
+	if f.Anonymous || !f.IsExported() {
+		return "", false
+	}
+
+	if tag, ok := f.Tag.Lookup("starlark"); ok {
+		parts := strings.Split(tag, ",")
+		for _, p := range parts[1:] {
+			if strings.TrimSpace(p) == "readonly" {
+				return "", false
+			}
+		}
+		if name := strings.TrimSpace(parts[0]); name != "" {
+			return name, true
+		}
+	}
+
+	return toSnakeCase(f.Name), true
+}
+
+func toSnakeCase(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteRune('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func colorToHex(c color.Color) string {
+	nrgba := color.NRGBAModel.Convert(c).(color.NRGBA)
+	if nrgba.A == 0xff {
+		return fmt.Sprintf("#%02x%02x%02x", nrgba.R, nrgba.G, nrgba.B)
+	}
+	return fmt.Sprintf("#%02x%02x%02x%02x", nrgba.R, nrgba.G, nrgba.B, nrgba.A)
+}
+
+// MarshalJSON encodes the Image. Image sources are binary, so they're
+// base64 encoded.
+func (p *Image) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Src    []byte `json:"src"`
+		Width  int    `json:"width,omitempty"`
+		Height int    `json:"height,omitempty"`
+	}{
+		Src:    []byte(p.Src),
+		Width:  p.Width,
+		Height: p.Height,
+	})
+}
+
+// UnmarshalJSON decodes an Image encoded with MarshalJSON.
+func (p *Image) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Src    []byte `json:"src"`
+		Width  int    `json:"width"`
+		Height int    `json:"height"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	p.Src = string(v.Src)
+	p.Width = v.Width
+	p.Height = v.Height
+	return nil
+}
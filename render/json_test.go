@@ -0,0 +1,143 @@
+package render
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRoot(t *testing.T) Root {
+	png, err := base64.StdEncoding.DecodeString("iVBORw0KGgoAAAANSUhEUgAAAAEAAAABAQMAAAAl21bKAAAAA1BMVEX/AAAZ4gk3AAAACklEQVR4nGNiAAAABgADNjd8qAAAAABJRU5ErkJggg==")
+	require.NoError(t, err)
+
+	img := &Image{Src: string(png), Width: 3, Height: 3}
+	require.NoError(t, img.Init())
+
+	text := &Text{Content: "hey", Color: color.RGBA{0, 0xff, 0, 0xff}}
+	require.NoError(t, text.Init())
+
+	wrapped := &WrappedText{Content: "wrap this", Width: 20}
+	require.NoError(t, wrapped.Init())
+
+	return Root{
+		Delay:  75,
+		MaxAge: 30,
+		Child: &Column{
+			MainAlign: "space_between",
+			Children: []Widget{
+				&Row{
+					CrossAlign: "center",
+					Children: []Widget{
+						img,
+						&Padding{Pad: Insets{1, 0, 1, 0}, Child: text},
+						&Circle{Diameter: 5, Color: color.RGBA{0xff, 0, 0, 0xff}},
+					},
+				},
+				&Marquee{Width: 20, Child: wrapped},
+				&Stack{
+					Children: []Widget{
+						&Box{Width: 10, Height: 4, Color: color.NRGBA{0, 0, 0xff, 0x80}},
+						&Plot{
+							Data:   [][2]float64{{0, 1}, {1, 3}, {2, 2}},
+							Width:  10,
+							Height: 4,
+							XLim:   [2]float64{math.NaN(), math.NaN()},
+							YLim:   [2]float64{0, math.NaN()},
+						},
+					},
+				},
+				&PieChart{
+					Colors:   []color.Color{color.RGBA{0xff, 0, 0, 0xff}, color.RGBA{0, 0xff, 0, 0xff}},
+					Weights:  []float64{1, 2},
+					Diameter: 6,
+				},
+			},
+		},
+	}
+}
+
+func TestMarshalRootRoundTrip(t *testing.T) {
+	r := testRoot(t)
+
+	data, err := MarshalRoot(r)
+	require.NoError(t, err)
+
+	r2, err := UnmarshalRoot(data)
+	require.NoError(t, err)
+
+	assert.Equal(t, r.Delay, r2.Delay)
+	assert.Equal(t, r.MaxAge, r2.MaxAge)
+
+	// the re-hydrated tree must paint exactly like the original
+	frames := r.Paint(true)
+	frames2 := r2.Paint(true)
+	require.Equal(t, len(frames), len(frames2))
+	for i := range frames {
+		assert.Equal(t, frames[i].(*image.RGBA).Pix, frames2[i].(*image.RGBA).Pix, "frame %d", i)
+	}
+
+	// and serialize to the same JSON again
+	data2, err := MarshalRoot(r2)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(data), string(data2))
+}
+
+func TestMarshalWidgetFormat(t *testing.T) {
+	data, err := MarshalWidget(&Box{
+		Width: 3,
+		Color: color.RGBA{0xff, 0, 0, 0xff},
+		Child: &Text{Content: "x", Font: "tb-8"},
+	})
+	require.NoError(t, err)
+
+	var v map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &v))
+
+	assert.Equal(t, "Box", v["type"])
+	assert.Equal(t, float64(3), v["width"])
+	assert.Equal(t, "#ff0000", v["color"])
+
+	child := v["child"].(map[string]interface{})
+	assert.Equal(t, "Text", child["type"])
+	assert.Equal(t, "x", child["content"])
+	assert.Equal(t, "tb-8", child["font"])
+}
+
+func TestUnmarshalWidgetErrors(t *testing.T) {
+	_, err := UnmarshalWidget([]byte(`[]`))
+	assert.Error(t, err)
+
+	_, err = UnmarshalWidget([]byte(`{"width": 3}`))
+	assert.Error(t, err)
+
+	_, err = UnmarshalWidget([]byte(`{"type": "Hologram"}`))
+	assert.Error(t, err)
+
+	_, err = UnmarshalWidget([]byte(`{"type": "Box", "color": "purple"}`))
+	assert.Error(t, err)
+
+	_, err = UnmarshalRoot([]byte(`{"delay": 100}`))
+	assert.Error(t, err)
+}
+
+func TestUnmarshalRootFromHandwrittenJSON(t *testing.T) {
+	r, err := UnmarshalRoot([]byte(`{
+		"child": {
+			"type": "Row",
+			"children": [
+				{"type": "Box", "width": 2, "height": 1, "color": "#f00"},
+				{"type": "Box", "width": 1, "height": 1, "color": "#00f"}
+			]
+		}
+	}`))
+	require.NoError(t, err)
+
+	im := PaintWidget(r.Child, image.Rect(0, 0, 10, 10), 0)
+	assert.NoError(t, checkImage([]string{"rrb"}, im))
+}
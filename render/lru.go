@@ -0,0 +1,90 @@
+package render
+
+import (
+	"container/list"
+	"sync"
+
+	"tidbyt.dev/pixlet/metrics"
+)
+
+// lru is a cache of values shared by all renders in the process. Once
+// the sizes of the values add up to more than the limit, the least
+// recently used are evicted.
+type lru[K comparable, V any] struct {
+	// name is the cache's name in metrics, or empty to not record
+	// lookups
+	name string
+
+	mu    sync.Mutex
+	items map[K]*list.Element
+	order *list.List
+	size  int64
+}
+
+type lruEntry[K comparable, V any] struct {
+	key   K
+	value V
+	size  int64
+}
+
+func newLRU[K comparable, V any](name string) *lru[K, V] {
+	return &lru[K, V]{
+		name:  name,
+		items: map[K]*list.Element{},
+		order: list.New(),
+	}
+}
+
+// get returns the value for key, if it's cached.
+func (c *lru[K, V]) get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if c.name != "" {
+		metrics.ObserveCache(c.name, ok)
+	}
+	if !ok {
+		var zero V
+		return zero, false
+	}
+
+	c.order.MoveToFront(el)
+	return el.Value.(*lruEntry[K, V]).value, true
+}
+
+// add caches value, which takes size bytes, evicting values until the
+// cache holds at most limit bytes. Values bigger than limit aren't
+// cached.
+func (c *lru[K, V]) add(key K, value V, size, limit int64) {
+	if size > limit {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.items[key]; ok {
+		// added by someone else in the meantime
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&lruEntry[K, V]{key: key, value: value, size: size})
+	c.size += size
+
+	for c.size > limit {
+		oldest := c.order.Remove(c.order.Back()).(*lruEntry[K, V])
+		delete(c.items, oldest.key)
+		c.size -= oldest.size
+	}
+}
+
+// reset empties the cache.
+func (c *lru[K, V]) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = map[K]*list.Element{}
+	c.order = list.New()
+	c.size = 0
+}
@@ -72,16 +72,16 @@ func (m Marquee) PaintBounds(bounds image.Rectangle, frameIdx int) image.Rectang
 	}
 }
 
-func (m Marquee) FrameCount() int {
+func (m Marquee) FrameCount(bounds image.Rectangle) int {
 	var cb image.Rectangle
 	var cw int
 	var size int
 	if m.isVertical() {
-		cb = m.Child.PaintBounds(image.Rect(0, 0, FrameWidth, m.Height*10), 0)
+		cb = m.Child.PaintBounds(image.Rect(0, 0, bounds.Dx(), m.Height*10), 0)
 		cw = cb.Dy()
 		size = m.Height
 	} else {
-		cb = m.Child.PaintBounds(image.Rect(0, 0, m.Width*10, FrameHeight), 0)
+		cb = m.Child.PaintBounds(image.Rect(0, 0, m.Width*10, bounds.Dy()), 0)
 		cw = cb.Dx()
 		size = m.Width
 	}
@@ -173,20 +173,16 @@ func (m Marquee) Paint(dc *gg.Context, bounds image.Rectangle, frameIdx int) {
 
 	if m.isVertical() {
 		offset -= int(align * float64(cb.Dy()))
-		dc.Push()
-		dc.DrawRectangle(0, 0, float64(pb.Dx()), float64(pb.Dy()))
-		dc.Clip()
-		dc.Translate(0, float64(offset))
-		m.Child.Paint(dc, image.Rect(0, 0, bounds.Dx(), m.Height*10), 0)
-		dc.Pop()
+		withClip(dc, 0, 0, pb.Dx(), pb.Dy(), func() {
+			dc.Translate(0, float64(offset))
+			m.Child.Paint(dc, image.Rect(0, 0, bounds.Dx(), m.Height*10), 0)
+		})
 	} else {
 		offset -= int(align * float64(cb.Dx()))
-		dc.Push()
-		dc.DrawRectangle(0, 0, float64(pb.Dx()), float64(pb.Dy()))
-		dc.Clip()
-		dc.Translate(float64(offset), 0)
-		m.Child.Paint(dc, image.Rect(0, 0, m.Width*10, bounds.Dy()), 0)
-		dc.Pop()
+		withClip(dc, 0, 0, pb.Dx(), pb.Dy(), func() {
+			dc.Translate(float64(offset), 0)
+			m.Child.Paint(dc, image.Rect(0, 0, m.Width*10, bounds.Dy()), 0)
+		})
 	}
 }
 
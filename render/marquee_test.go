@@ -33,8 +33,8 @@ func TestMarqueeNoScrollHorizontal(t *testing.T) {
 	}
 
 	// Child fits so there's just 1 single frame
-	assert.Equal(t, 1, m.FrameCount())
-	assert.Equal(t, 1, mv.FrameCount())
+	assert.Equal(t, 1, m.FrameCount(image.Rect(0, 0, 64, 32)))
+	assert.Equal(t, 1, mv.FrameCount(image.Rect(0, 0, 64, 32)))
 	im := PaintWidget(m, image.Rect(0, 0, 100, 100), 0)
 	imv := PaintWidget(mv, image.Rect(0, 0, 100, 100), 0)
 	assert.Equal(t, nil, checkImage([]string{
@@ -76,8 +76,8 @@ func TestMarqueeNoScrollAlignCenter(t *testing.T) {
 	}
 
 	// Child fits so there's just 1 single frame
-	assert.Equal(t, 1, m.FrameCount())
-	assert.Equal(t, 1, mv.FrameCount())
+	assert.Equal(t, 1, m.FrameCount(image.Rect(0, 0, 64, 32)))
+	assert.Equal(t, 1, mv.FrameCount(image.Rect(0, 0, 64, 32)))
 	im := PaintWidget(m, image.Rect(0, 0, 100, 100), 0)
 	imv := PaintWidget(mv, image.Rect(0, 0, 100, 100), 0)
 	assert.Equal(t, nil, checkImage([]string{
@@ -121,8 +121,8 @@ func TestMarqueeNoScrollAlignEnd(t *testing.T) {
 	}
 
 	// Child fits so there's just 1 single frame
-	assert.Equal(t, 1, m.FrameCount())
-	assert.Equal(t, 1, mv.FrameCount())
+	assert.Equal(t, 1, m.FrameCount(image.Rect(0, 0, 64, 32)))
+	assert.Equal(t, 1, mv.FrameCount(image.Rect(0, 0, 64, 32)))
 	im := PaintWidget(m, image.Rect(0, 0, 100, 100), 0)
 	imv := PaintWidget(mv, image.Rect(0, 0, 100, 100), 0)
 	assert.Equal(t, nil, checkImage([]string{
@@ -159,7 +159,7 @@ func TestMarqueeOldBehavior(t *testing.T) {
 	// The child's 9 pixels will be scrolled into view (7 frames),
 	// scrolled out of view (9 frames) and then finally scrolled
 	// back into view again (6 frames). 22 frames in total.
-	assert.Equal(t, 22, m.FrameCount())
+	assert.Equal(t, 22, m.FrameCount(image.Rect(0, 0, 64, 32)))
 
 	// Scrolling into view
 	assert.Equal(t, nil, checkImage([]string{
@@ -264,7 +264,7 @@ func TestMarqueeOffsetIdentical(t *testing.T) {
 	assert.Equal(t, nil, checkImage([]string{"...rgg"}, PaintWidget(m, im, 10)))
 	assert.Equal(t, nil, checkImage([]string{"..rggb"}, PaintWidget(m, im, 11)))
 	assert.Equal(t, nil, checkImage([]string{".rggbb"}, PaintWidget(m, im, 12)))
-	assert.Equal(t, 13, m.FrameCount())
+	assert.Equal(t, 13, m.FrameCount(image.Rect(0, 0, 64, 32)))
 
 	m.OffsetStart = 3
 	m.OffsetEnd = 3
@@ -281,7 +281,7 @@ func TestMarqueeOffsetIdentical(t *testing.T) {
 	assert.Equal(t, nil, checkImage([]string{"......"}, PaintWidget(m, im, 10)))
 	assert.Equal(t, nil, checkImage([]string{".....r"}, PaintWidget(m, im, 11)))
 	assert.Equal(t, nil, checkImage([]string{"....rg"}, PaintWidget(m, im, 12)))
-	assert.Equal(t, 13, m.FrameCount())
+	assert.Equal(t, 13, m.FrameCount(image.Rect(0, 0, 64, 32)))
 }
 
 func TestMarqueeOffsetStart(t *testing.T) {
@@ -317,7 +317,7 @@ func TestMarqueeOffsetStart(t *testing.T) {
 	assert.Equal(t, nil, checkImage([]string{"..rggb"}, PaintWidget(m, im, 13)))
 	assert.Equal(t, nil, checkImage([]string{".rggbb"}, PaintWidget(m, im, 14)))
 	assert.Equal(t, nil, checkImage([]string{"rggbbb"}, PaintWidget(m, im, 15)))
-	assert.Equal(t, 16, m.FrameCount())
+	assert.Equal(t, 16, m.FrameCount(image.Rect(0, 0, 64, 32)))
 
 	// Negative OffsetStart
 	m.OffsetStart = -2
@@ -333,27 +333,27 @@ func TestMarqueeOffsetStart(t *testing.T) {
 	assert.Equal(t, nil, checkImage([]string{"..rggb"}, PaintWidget(m, im, 9)))
 	assert.Equal(t, nil, checkImage([]string{".rggbb"}, PaintWidget(m, im, 10)))
 	assert.Equal(t, nil, checkImage([]string{"rggbbb"}, PaintWidget(m, im, 11)))
-	assert.Equal(t, 12, m.FrameCount())
+	assert.Equal(t, 12, m.FrameCount(image.Rect(0, 0, 64, 32)))
 
 	// Overly negative OffsetStart is truncated to child width
 	m.OffsetStart = -1000
 	assert.Equal(t, nil, checkImage([]string{"......"}, PaintWidget(m, im, 0)))
 	assert.Equal(t, nil, checkImage([]string{".....r"}, PaintWidget(m, im, 1)))
 	assert.Equal(t, nil, checkImage([]string{"....rg"}, PaintWidget(m, im, 2)))
-	assert.Equal(t, 7, m.FrameCount())
+	assert.Equal(t, 7, m.FrameCount(image.Rect(0, 0, 64, 32)))
 	m.OffsetStart = -7
 	assert.Equal(t, nil, checkImage([]string{"......"}, PaintWidget(m, im, 0)))
 	assert.Equal(t, nil, checkImage([]string{".....r"}, PaintWidget(m, im, 1)))
-	assert.Equal(t, 7, m.FrameCount())
+	assert.Equal(t, 7, m.FrameCount(image.Rect(0, 0, 64, 32)))
 	m.OffsetStart = -8
 	assert.Equal(t, nil, checkImage([]string{"......"}, PaintWidget(m, im, 0)))
 	assert.Equal(t, nil, checkImage([]string{".....r"}, PaintWidget(m, im, 1)))
-	assert.Equal(t, 7, m.FrameCount())
+	assert.Equal(t, 7, m.FrameCount(image.Rect(0, 0, 64, 32)))
 	m.OffsetStart = -6
 	assert.Equal(t, nil, checkImage([]string{"b....."}, PaintWidget(m, im, 0)))
 	assert.Equal(t, nil, checkImage([]string{"......"}, PaintWidget(m, im, 1)))
 	assert.Equal(t, nil, checkImage([]string{".....r"}, PaintWidget(m, im, 2)))
-	assert.Equal(t, 8, m.FrameCount())
+	assert.Equal(t, 8, m.FrameCount(image.Rect(0, 0, 64, 32)))
 }
 
 func TestMarqueeOffsetEnd(t *testing.T) {
@@ -384,7 +384,7 @@ func TestMarqueeOffsetEnd(t *testing.T) {
 	assert.Equal(t, nil, checkImage([]string{"....rg"}, PaintWidget(m, im, 9)))
 	assert.Equal(t, nil, checkImage([]string{"...rgg"}, PaintWidget(m, im, 10)))
 	assert.Equal(t, nil, checkImage([]string{"..rggb"}, PaintWidget(m, im, 11)))
-	assert.Equal(t, 12, m.FrameCount())
+	assert.Equal(t, 12, m.FrameCount(image.Rect(0, 0, 64, 32)))
 	assert.Equal(t, nil, checkImage([]string{"..rggb"}, PaintWidget(m, im, 12)))
 	assert.Equal(t, nil, checkImage([]string{"..rggb"}, PaintWidget(m, im, 13)))
 	assert.Equal(t, nil, checkImage([]string{"..rggb"}, PaintWidget(m, im, 1024)))
@@ -402,7 +402,7 @@ func TestMarqueeOffsetEnd(t *testing.T) {
 	assert.Equal(t, nil, checkImage([]string{"gbbbb."}, PaintWidget(m, im, 15)))
 	assert.Equal(t, nil, checkImage([]string{"bbbb.."}, PaintWidget(m, im, 16)))
 	assert.Equal(t, nil, checkImage([]string{"bbb..."}, PaintWidget(m, im, 17)))
-	assert.Equal(t, 18, m.FrameCount())
+	assert.Equal(t, 18, m.FrameCount(image.Rect(0, 0, 64, 32)))
 	assert.Equal(t, nil, checkImage([]string{"bbb..."}, PaintWidget(m, im, 18)))
 	assert.Equal(t, nil, checkImage([]string{"bbb..."}, PaintWidget(m, im, 19)))
 	assert.Equal(t, nil, checkImage([]string{"bbb..."}, PaintWidget(m, im, 1024)))
@@ -414,7 +414,7 @@ func TestMarqueeOffsetEnd(t *testing.T) {
 	assert.Equal(t, nil, checkImage([]string{"bb...."}, PaintWidget(m, im, 18)))
 	assert.Equal(t, nil, checkImage([]string{"b....."}, PaintWidget(m, im, 19)))
 	assert.Equal(t, nil, checkImage([]string{"......"}, PaintWidget(m, im, 20)))
-	assert.Equal(t, 21, m.FrameCount())
+	assert.Equal(t, 21, m.FrameCount(image.Rect(0, 0, 64, 32)))
 	assert.Equal(t, nil, checkImage([]string{"......"}, PaintWidget(m, im, 21)))
 	assert.Equal(t, nil, checkImage([]string{"......"}, PaintWidget(m, im, 22)))
 	assert.Equal(t, nil, checkImage([]string{"......"}, PaintWidget(m, im, 23)))
@@ -424,7 +424,7 @@ func TestMarqueeOffsetEnd(t *testing.T) {
 	assert.Equal(t, nil, checkImage([]string{"rggbbb"}, PaintWidget(m, im, 0)))
 	assert.Equal(t, nil, checkImage([]string{"b....."}, PaintWidget(m, im, 6)))
 	assert.Equal(t, nil, checkImage([]string{"......"}, PaintWidget(m, im, 7)))
-	assert.Equal(t, 8, m.FrameCount())
+	assert.Equal(t, 8, m.FrameCount(image.Rect(0, 0, 64, 32)))
 	assert.Equal(t, nil, checkImage([]string{"......"}, PaintWidget(m, im, 8)))
 	assert.Equal(t, nil, checkImage([]string{"......"}, PaintWidget(m, im, 9)))
 	assert.Equal(t, nil, checkImage([]string{"......"}, PaintWidget(m, im, 1024)))
@@ -467,7 +467,7 @@ func TestMarqueeDelayScrollOffsetStart(t *testing.T) {
 	assert.Equal(t, nil, checkImage([]string{"..rggb"}, PaintWidget(m, im, 15)))
 	assert.Equal(t, nil, checkImage([]string{".rggbb"}, PaintWidget(m, im, 16)))
 	assert.Equal(t, nil, checkImage([]string{"rggbbb"}, PaintWidget(m, im, 17)))
-	assert.Equal(t, 18, m.FrameCount())
+	assert.Equal(t, 18, m.FrameCount(image.Rect(0, 0, 64, 32)))
 
 	// // Negative OffsetStart
 	m.OffsetStart = -2
@@ -485,7 +485,7 @@ func TestMarqueeDelayScrollOffsetStart(t *testing.T) {
 	assert.Equal(t, nil, checkImage([]string{"..rggb"}, PaintWidget(m, im, 11)))
 	assert.Equal(t, nil, checkImage([]string{".rggbb"}, PaintWidget(m, im, 12)))
 	assert.Equal(t, nil, checkImage([]string{"rggbbb"}, PaintWidget(m, im, 13)))
-	assert.Equal(t, 14, m.FrameCount())
+	assert.Equal(t, 14, m.FrameCount(image.Rect(0, 0, 64, 32)))
 
 	// // Overly negative OffsetStart is truncated to child width
 	m.OffsetStart = -1000
@@ -494,26 +494,26 @@ func TestMarqueeDelayScrollOffsetStart(t *testing.T) {
 	assert.Equal(t, nil, checkImage([]string{"......"}, PaintWidget(m, im, 2)))
 	assert.Equal(t, nil, checkImage([]string{".....r"}, PaintWidget(m, im, 3)))
 	assert.Equal(t, nil, checkImage([]string{"....rg"}, PaintWidget(m, im, 4)))
-	assert.Equal(t, 9, m.FrameCount())
+	assert.Equal(t, 9, m.FrameCount(image.Rect(0, 0, 64, 32)))
 	m.OffsetStart = -7
 	assert.Equal(t, nil, checkImage([]string{"......"}, PaintWidget(m, im, 0)))
 	assert.Equal(t, nil, checkImage([]string{"......"}, PaintWidget(m, im, 1)))
 	assert.Equal(t, nil, checkImage([]string{"......"}, PaintWidget(m, im, 2)))
 	assert.Equal(t, nil, checkImage([]string{".....r"}, PaintWidget(m, im, 3)))
-	assert.Equal(t, 9, m.FrameCount())
+	assert.Equal(t, 9, m.FrameCount(image.Rect(0, 0, 64, 32)))
 	m.OffsetStart = -8
 	assert.Equal(t, nil, checkImage([]string{"......"}, PaintWidget(m, im, 0)))
 	assert.Equal(t, nil, checkImage([]string{"......"}, PaintWidget(m, im, 1)))
 	assert.Equal(t, nil, checkImage([]string{"......"}, PaintWidget(m, im, 2)))
 	assert.Equal(t, nil, checkImage([]string{".....r"}, PaintWidget(m, im, 3)))
-	assert.Equal(t, 9, m.FrameCount())
+	assert.Equal(t, 9, m.FrameCount(image.Rect(0, 0, 64, 32)))
 	m.OffsetStart = -6
 	assert.Equal(t, nil, checkImage([]string{"b....."}, PaintWidget(m, im, 0)))
 	assert.Equal(t, nil, checkImage([]string{"b....."}, PaintWidget(m, im, 1)))
 	assert.Equal(t, nil, checkImage([]string{"b....."}, PaintWidget(m, im, 2)))
 	assert.Equal(t, nil, checkImage([]string{"......"}, PaintWidget(m, im, 3)))
 	assert.Equal(t, nil, checkImage([]string{".....r"}, PaintWidget(m, im, 4)))
-	assert.Equal(t, 10, m.FrameCount())
+	assert.Equal(t, 10, m.FrameCount(image.Rect(0, 0, 64, 32)))
 }
 
 func TestMarqueeVerticalScroll(t *testing.T) {
@@ -619,7 +619,7 @@ func TestMarqueeVerticalScroll(t *testing.T) {
 	assert.Equal(t, nil, checkImage([]string{".", ".", "r", "g", "g", "b"}, PaintWidget(m, im, 13)))
 	assert.Equal(t, nil, checkImage([]string{".", "r", "g", "g", "b", "b"}, PaintWidget(m, im, 14)))
 	assert.Equal(t, nil, checkImage([]string{"r", "g", "g", "b", "b", "b"}, PaintWidget(m, im, 15)))
-	assert.Equal(t, 16, m.FrameCount())
+	assert.Equal(t, 16, m.FrameCount(image.Rect(0, 0, 64, 32)))
 
 	// Negative OffsetStart
 	m.OffsetStart = -2
@@ -635,27 +635,27 @@ func TestMarqueeVerticalScroll(t *testing.T) {
 	assert.Equal(t, nil, checkImage([]string{".", ".", "r", "g", "g", "b"}, PaintWidget(m, im, 9)))
 	assert.Equal(t, nil, checkImage([]string{".", "r", "g", "g", "b", "b"}, PaintWidget(m, im, 10)))
 	assert.Equal(t, nil, checkImage([]string{"r", "g", "g", "b", "b", "b"}, PaintWidget(m, im, 11)))
-	assert.Equal(t, 12, m.FrameCount())
+	assert.Equal(t, 12, m.FrameCount(image.Rect(0, 0, 64, 32)))
 
 	// Overly negative OffsetStart is truncated to child width
 	m.OffsetStart = -1000
 	assert.Equal(t, nil, checkImage([]string{".", ".", ".", ".", ".", "."}, PaintWidget(m, im, 0)))
 	assert.Equal(t, nil, checkImage([]string{".", ".", ".", ".", ".", "r"}, PaintWidget(m, im, 1)))
 	assert.Equal(t, nil, checkImage([]string{".", ".", ".", ".", "r", "g"}, PaintWidget(m, im, 2)))
-	assert.Equal(t, 7, m.FrameCount())
+	assert.Equal(t, 7, m.FrameCount(image.Rect(0, 0, 64, 32)))
 	m.OffsetStart = -7
 	assert.Equal(t, nil, checkImage([]string{".", ".", ".", ".", ".", "."}, PaintWidget(m, im, 0)))
 	assert.Equal(t, nil, checkImage([]string{".", ".", ".", ".", ".", "r"}, PaintWidget(m, im, 1)))
-	assert.Equal(t, 7, m.FrameCount())
+	assert.Equal(t, 7, m.FrameCount(image.Rect(0, 0, 64, 32)))
 	m.OffsetStart = -8
 	assert.Equal(t, nil, checkImage([]string{".", ".", ".", ".", ".", "."}, PaintWidget(m, im, 0)))
 	assert.Equal(t, nil, checkImage([]string{".", ".", ".", ".", ".", "r"}, PaintWidget(m, im, 1)))
-	assert.Equal(t, 7, m.FrameCount())
+	assert.Equal(t, 7, m.FrameCount(image.Rect(0, 0, 64, 32)))
 	m.OffsetStart = -6
 	assert.Equal(t, nil, checkImage([]string{"b", ".", ".", ".", ".", "."}, PaintWidget(m, im, 0)))
 	assert.Equal(t, nil, checkImage([]string{".", ".", ".", ".", ".", "."}, PaintWidget(m, im, 1)))
 	assert.Equal(t, nil, checkImage([]string{".", ".", ".", ".", ".", "r"}, PaintWidget(m, im, 2)))
-	assert.Equal(t, 8, m.FrameCount())
+	assert.Equal(t, 8, m.FrameCount(image.Rect(0, 0, 64, 32)))
 
 	// OffsetEnd affects the final position of the child
 	m.OffsetStart = 0
@@ -672,7 +672,7 @@ func TestMarqueeVerticalScroll(t *testing.T) {
 	assert.Equal(t, nil, checkImage([]string{".", ".", ".", ".", "r", "g"}, PaintWidget(m, im, 9)))
 	assert.Equal(t, nil, checkImage([]string{".", ".", ".", "r", "g", "g"}, PaintWidget(m, im, 10)))
 	assert.Equal(t, nil, checkImage([]string{".", ".", "r", "g", "g", "b"}, PaintWidget(m, im, 11)))
-	assert.Equal(t, 12, m.FrameCount())
+	assert.Equal(t, 12, m.FrameCount(image.Rect(0, 0, 64, 32)))
 	assert.Equal(t, nil, checkImage([]string{".", ".", "r", "g", "g", "b"}, PaintWidget(m, im, 12)))
 	assert.Equal(t, nil, checkImage([]string{".", ".", "r", "g", "g", "b"}, PaintWidget(m, im, 13)))
 	assert.Equal(t, nil, checkImage([]string{".", ".", "r", "g", "g", "b"}, PaintWidget(m, im, 1024)))
@@ -690,7 +690,7 @@ func TestMarqueeVerticalScroll(t *testing.T) {
 	assert.Equal(t, nil, checkImage([]string{"g", "b", "b", "b", "b", "."}, PaintWidget(m, im, 15)))
 	assert.Equal(t, nil, checkImage([]string{"b", "b", "b", "b", ".", "."}, PaintWidget(m, im, 16)))
 	assert.Equal(t, nil, checkImage([]string{"b", "b", "b", ".", ".", "."}, PaintWidget(m, im, 17)))
-	assert.Equal(t, 18, m.FrameCount())
+	assert.Equal(t, 18, m.FrameCount(image.Rect(0, 0, 64, 32)))
 	assert.Equal(t, nil, checkImage([]string{"b", "b", "b", ".", ".", "."}, PaintWidget(m, im, 18)))
 	assert.Equal(t, nil, checkImage([]string{"b", "b", "b", ".", ".", "."}, PaintWidget(m, im, 19)))
 	assert.Equal(t, nil, checkImage([]string{"b", "b", "b", ".", ".", "."}, PaintWidget(m, im, 1024)))
@@ -700,7 +700,7 @@ func TestMarqueeVerticalScroll(t *testing.T) {
 	assert.Equal(t, nil, checkImage([]string{"r", "g", "g", "b", "b", "b"}, PaintWidget(m, im, 0)))
 	assert.Equal(t, nil, checkImage([]string{"b", ".", ".", ".", ".", "."}, PaintWidget(m, im, 6)))
 	assert.Equal(t, nil, checkImage([]string{".", ".", ".", ".", ".", "."}, PaintWidget(m, im, 7)))
-	assert.Equal(t, 8, m.FrameCount())
+	assert.Equal(t, 8, m.FrameCount(image.Rect(0, 0, 64, 32)))
 	assert.Equal(t, nil, checkImage([]string{".", ".", ".", ".", ".", "."}, PaintWidget(m, im, 8)))
 	assert.Equal(t, nil, checkImage([]string{".", ".", ".", ".", ".", "."}, PaintWidget(m, im, 9)))
 	assert.Equal(t, nil, checkImage([]string{".", ".", ".", ".", ".", "."}, PaintWidget(m, im, 1024)))
@@ -68,13 +68,9 @@ func (p Padding) Paint(dc *gg.Context, bounds image.Rectangle, frameIdx int) {
 	}
 
 	if p.Color != nil {
-		dc.SetColor(p.Color)
-		dc.DrawRectangle(0, 0, float64(width), float64(height))
-		dc.Fill()
+		fillRect(dc, 0, 0, width, height, p.Color)
 	}
 
-	dc.Push()
-
 	// Some apps use negative padding as a positioning hack.
 	clipLeft := p.Pad.Left
 	clipTop := p.Pad.Top
@@ -85,24 +81,17 @@ func (p Padding) Paint(dc *gg.Context, bounds image.Rectangle, frameIdx int) {
 		clipTop = 0
 	}
 
-	dc.DrawRectangle(
-		float64(clipLeft),
-		float64(clipTop),
-		float64(width-p.Pad.Left-p.Pad.Right),
-		float64(height-p.Pad.Top-p.Pad.Bottom),
-	)
-	dc.Clip()
-
-	dc.Translate(float64(p.Pad.Left), float64(p.Pad.Top))
+	withClip(dc, clipLeft, clipTop, width-p.Pad.Left-p.Pad.Right, height-p.Pad.Top-p.Pad.Bottom, func() {
+		dc.Translate(float64(p.Pad.Left), float64(p.Pad.Top))
 
-	p.Child.Paint(dc, image.Rect(0, 0, bounds.Dx()-p.Pad.Left-p.Pad.Right, bounds.Dy()-p.Pad.Top-p.Pad.Bottom),
-		frameIdx)
-	dc.Pop()
+		p.Child.Paint(dc, image.Rect(0, 0, bounds.Dx()-p.Pad.Left-p.Pad.Right, bounds.Dy()-p.Pad.Top-p.Pad.Bottom),
+			frameIdx)
+	})
 }
 
-func (p Padding) FrameCount() int {
+func (p Padding) FrameCount(bounds image.Rectangle) int {
 	if p.Child != nil {
-		return p.Child.FrameCount()
+		return p.Child.FrameCount(bounds)
 	}
 	return 1
 }
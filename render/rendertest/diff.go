@@ -0,0 +1,298 @@
+// Package rendertest provides helpers for regression testing rendered
+// output, such as comparing encoded images against golden files.
+package rendertest
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"os"
+
+	"tidbyt.dev/pixlet/render"
+)
+
+var (
+	// DiffColor marks pixels that differ in images produced by
+	// DiffImage.
+	DiffColor = color.RGBA{0xff, 0, 0xff, 0xff}
+
+	// SeparatorColor is used between the expected, actual and diff
+	// panels of images written by WriteDiff.
+	SeparatorColor = color.RGBA{0x40, 0x40, 0x40, 0xff}
+)
+
+// Options controls how images are compared.
+type Options struct {
+	// Tolerance is the maximum difference allowed in any color
+	// channel before a pixel is considered different.
+	Tolerance uint8
+}
+
+// FrameDiff describes the differences found in a single frame.
+type FrameDiff struct {
+	// Index of the frame in the animation.
+	Index int
+
+	// Number of pixels that differ.
+	DiffPixels int
+
+	// Smallest rectangle containing all differing pixels.
+	Bounds image.Rectangle
+}
+
+// Result holds the outcome of comparing two animations.
+type Result struct {
+	ExpectedFrames int
+	ActualFrames   int
+	ExpectedSize   image.Point
+	ActualSize     image.Point
+
+	// Frames that differ. Frames present in only one of the
+	// animations are reported as entirely different.
+	Frames []FrameDiff
+
+	// Total number of differing pixels across all frames.
+	DiffPixels int
+}
+
+// Equal returns true if no differences were found.
+func (r *Result) Equal() bool {
+	return r.ExpectedFrames == r.ActualFrames &&
+		r.ExpectedSize == r.ActualSize &&
+		len(r.Frames) == 0
+}
+
+// String summarizes the result in a human readable way.
+func (r *Result) String() string {
+	if r.Equal() {
+		return "images are identical"
+	}
+
+	s := ""
+	if r.ExpectedFrames != r.ActualFrames {
+		s += fmt.Sprintf("frame count differs: expected %d, found %d\n", r.ExpectedFrames, r.ActualFrames)
+	}
+	if r.ExpectedSize != r.ActualSize {
+		s += fmt.Sprintf("size differs: expected %v, found %v\n", r.ExpectedSize, r.ActualSize)
+	}
+	for _, f := range r.Frames {
+		s += fmt.Sprintf("frame %d: %d pixels differ within %v\n", f.Index, f.DiffPixels, f.Bounds)
+	}
+	return s
+}
+
+// DecodeFrames decodes encoded image data (WebP, GIF or any other
+// format supported by the Image widget) into its individual frames.
+func DecodeFrames(data []byte) ([]image.Image, error) {
+	im := &render.Image{Src: string(data)}
+	if err := im.Init(); err != nil {
+		return nil, fmt.Errorf("decoding image: %w", err)
+	}
+
+	w, h := im.Size()
+	bounds := image.Rect(0, 0, w, h)
+
+	frames := make([]image.Image, im.FrameCount(bounds))
+	for i := range frames {
+		frames[i] = render.PaintWidget(im, bounds, i)
+	}
+
+	return frames, nil
+}
+
+// Compare decodes two encoded images and compares them frame by frame.
+func Compare(expected, actual []byte, opts Options) (*Result, error) {
+	expectedFrames, err := DecodeFrames(expected)
+	if err != nil {
+		return nil, fmt.Errorf("expected: %w", err)
+	}
+
+	actualFrames, err := DecodeFrames(actual)
+	if err != nil {
+		return nil, fmt.Errorf("actual: %w", err)
+	}
+
+	return CompareFrames(expectedFrames, actualFrames, opts), nil
+}
+
+// CompareFrames compares two sequences of frames.
+func CompareFrames(expected, actual []image.Image, opts Options) *Result {
+	r := &Result{
+		ExpectedFrames: len(expected),
+		ActualFrames:   len(actual),
+	}
+	if len(expected) > 0 {
+		r.ExpectedSize = expected[0].Bounds().Size()
+	}
+	if len(actual) > 0 {
+		r.ActualSize = actual[0].Bounds().Size()
+	}
+
+	n := len(expected)
+	if len(actual) > n {
+		n = len(actual)
+	}
+
+	for i := 0; i < n; i++ {
+		var fd FrameDiff
+		switch {
+		case i >= len(expected):
+			fd = FrameDiff{DiffPixels: area(actual[i].Bounds()), Bounds: actual[i].Bounds()}
+		case i >= len(actual):
+			fd = FrameDiff{DiffPixels: area(expected[i].Bounds()), Bounds: expected[i].Bounds()}
+		default:
+			fd = compareFrame(expected[i], actual[i], opts)
+		}
+
+		if fd.DiffPixels > 0 {
+			fd.Index = i
+			r.Frames = append(r.Frames, fd)
+			r.DiffPixels += fd.DiffPixels
+		}
+	}
+
+	return r
+}
+
+func area(r image.Rectangle) int {
+	return r.Dx() * r.Dy()
+}
+
+func compareFrame(a, b image.Image, opts Options) FrameDiff {
+	fd := FrameDiff{}
+	bounds := a.Bounds().Union(b.Bounds())
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if pixelsEqual(a, b, x, y, opts.Tolerance) {
+				continue
+			}
+
+			fd.DiffPixels++
+			fd.Bounds = fd.Bounds.Union(image.Rect(x, y, x+1, y+1))
+		}
+	}
+
+	return fd
+}
+
+func pixelsEqual(a, b image.Image, x, y int, tolerance uint8) bool {
+	p := image.Pt(x, y)
+	if !p.In(a.Bounds()) || !p.In(b.Bounds()) {
+		return false
+	}
+
+	ca := color.NRGBAModel.Convert(a.At(x, y)).(color.NRGBA)
+	cb := color.NRGBAModel.Convert(b.At(x, y)).(color.NRGBA)
+
+	return channelClose(ca.R, cb.R, tolerance) &&
+		channelClose(ca.G, cb.G, tolerance) &&
+		channelClose(ca.B, cb.B, tolerance) &&
+		channelClose(ca.A, cb.A, tolerance)
+}
+
+func channelClose(a, b, tolerance uint8) bool {
+	if a > b {
+		return a-b <= tolerance
+	}
+	return b-a <= tolerance
+}
+
+// DiffImage produces an image highlighting the differences between a
+// and b. Matching pixels are drawn as a dimmed grayscale version of
+// a, and differing pixels are drawn in DiffColor.
+func DiffImage(a, b image.Image, opts Options) *image.RGBA {
+	bounds := a.Bounds().Union(b.Bounds())
+	out := image.NewRGBA(bounds)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if !pixelsEqual(a, b, x, y, opts.Tolerance) {
+				out.SetRGBA(x, y, DiffColor)
+				continue
+			}
+
+			g := color.GrayModel.Convert(a.At(x, y)).(color.Gray)
+			out.SetRGBA(x, y, color.RGBA{g.Y / 3, g.Y / 3, g.Y / 3, 0xff})
+		}
+	}
+
+	return out
+}
+
+// WriteDiff writes a PNG showing every differing frame as a row of
+// three panels: expected, actual and the output of DiffImage. Panels
+// are scaled up by `magnify`.
+func WriteDiff(w io.Writer, expected, actual []image.Image, opts Options, magnify int) error {
+	if magnify < 1 {
+		magnify = 1
+	}
+
+	r := CompareFrames(expected, actual, opts)
+	if len(r.Frames) == 0 {
+		return fmt.Errorf("no differences to write")
+	}
+
+	size := r.ExpectedSize
+	if r.ActualSize.X > size.X {
+		size.X = r.ActualSize.X
+	}
+	if r.ActualSize.Y > size.Y {
+		size.Y = r.ActualSize.Y
+	}
+
+	panelW, panelH := size.X*magnify, size.Y*magnify
+	out := image.NewRGBA(image.Rect(0, 0, panelW*3+2, (panelH+1)*len(r.Frames)-1))
+	draw.Draw(out, out.Bounds(), image.NewUniform(SeparatorColor), image.Point{}, draw.Src)
+
+	blank := image.NewRGBA(image.Rect(0, 0, size.X, size.Y))
+	frameAt := func(frames []image.Image, i int) image.Image {
+		if i < len(frames) {
+			return frames[i]
+		}
+		return blank
+	}
+
+	for row, fd := range r.Frames {
+		e := frameAt(expected, fd.Index)
+		a := frameAt(actual, fd.Index)
+		panels := []image.Image{e, a, DiffImage(e, a, opts)}
+
+		for col, p := range panels {
+			origin := image.Pt(col*(panelW+1), row*(panelH+1))
+			drawMagnified(out, p, origin, magnify)
+		}
+	}
+
+	return png.Encode(w, out)
+}
+
+// WriteDiffFile is like WriteDiff, but writes to a file.
+func WriteDiffFile(path string, expected, actual []image.Image, opts Options, magnify int) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return WriteDiff(f, expected, actual, opts, magnify)
+}
+
+func drawMagnified(dst *image.RGBA, src image.Image, origin image.Point, magnify int) {
+	b := src.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := src.At(x, y)
+			r := image.Rect(
+				origin.X+(x-b.Min.X)*magnify,
+				origin.Y+(y-b.Min.Y)*magnify,
+				origin.X+(x-b.Min.X+1)*magnify,
+				origin.Y+(y-b.Min.Y+1)*magnify,
+			)
+			draw.Draw(dst, r, image.NewUniform(c), image.Point{}, draw.Src)
+		}
+	}
+}
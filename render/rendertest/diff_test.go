@@ -0,0 +1,116 @@
+package rendertest
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tidbyt.dev/pixlet/encode"
+	"tidbyt.dev/pixlet/render"
+)
+
+func encodeBoxes(t *testing.T, colors ...color.Color) []byte {
+	children := []render.Widget{}
+	for _, c := range colors {
+		children = append(children, render.Box{Width: 4, Height: 2, Color: c})
+	}
+
+	webp, err := encode.ScreensFromRoots([]render.Root{{
+		Child: render.Animation{Children: children},
+	}}).EncodeWebP(0)
+	require.NoError(t, err)
+
+	return webp
+}
+
+func TestCompareIdentical(t *testing.T) {
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	blue := color.RGBA{0, 0, 0xff, 0xff}
+	a := encodeBoxes(t, red, blue)
+	b := encodeBoxes(t, red, blue)
+
+	r, err := Compare(a, b, Options{})
+	require.NoError(t, err)
+	assert.True(t, r.Equal())
+	assert.Equal(t, 2, r.ExpectedFrames)
+	assert.Equal(t, image.Pt(64, 32), r.ExpectedSize)
+	assert.Equal(t, "images are identical", r.String())
+}
+
+func TestCompareDifferentFrame(t *testing.T) {
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	green := color.RGBA{0, 0xff, 0, 0xff}
+	blue := color.RGBA{0, 0, 0xff, 0xff}
+	a := encodeBoxes(t, red, blue)
+	b := encodeBoxes(t, red, green)
+
+	r, err := Compare(a, b, Options{})
+	require.NoError(t, err)
+	assert.False(t, r.Equal())
+	require.Equal(t, 1, len(r.Frames))
+	assert.Equal(t, 1, r.Frames[0].Index)
+	assert.Equal(t, 8, r.Frames[0].DiffPixels)
+	assert.Equal(t, image.Rect(0, 0, 4, 2), r.Frames[0].Bounds)
+	assert.Equal(t, 8, r.DiffPixels)
+}
+
+func TestCompareFrameCount(t *testing.T) {
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	green := color.RGBA{0, 0xff, 0, 0xff}
+	a := encodeBoxes(t, red)
+	b := encodeBoxes(t, red, green)
+
+	r, err := Compare(a, b, Options{})
+	require.NoError(t, err)
+	assert.False(t, r.Equal())
+	assert.Equal(t, 1, r.ExpectedFrames)
+	assert.Equal(t, 2, r.ActualFrames)
+	require.Equal(t, 1, len(r.Frames))
+	assert.Equal(t, 64*32, r.Frames[0].DiffPixels)
+}
+
+func TestCompareTolerance(t *testing.T) {
+	a := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	b := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	a.SetRGBA(1, 1, color.RGBA{100, 100, 100, 0xff})
+	b.SetRGBA(1, 1, color.RGBA{103, 100, 100, 0xff})
+	a.SetRGBA(0, 0, color.RGBA{0, 0, 0, 0xff})
+	b.SetRGBA(0, 0, color.RGBA{0, 0, 0, 0xff})
+
+	r := CompareFrames([]image.Image{a}, []image.Image{b}, Options{})
+	assert.False(t, r.Equal())
+	assert.Equal(t, image.Rect(1, 1, 2, 2), r.Frames[0].Bounds)
+
+	r = CompareFrames([]image.Image{a}, []image.Image{b}, Options{Tolerance: 3})
+	assert.True(t, r.Equal())
+}
+
+func TestWriteDiff(t *testing.T) {
+	a := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	b := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	b.SetRGBA(2, 1, color.RGBA{0xff, 0xff, 0xff, 0xff})
+
+	buf := &bytes.Buffer{}
+	err := WriteDiff(buf, []image.Image{a}, []image.Image{b}, Options{}, 2)
+	require.NoError(t, err)
+
+	im, err := png.Decode(buf)
+	require.NoError(t, err)
+
+	// three 6x4 panels with 1px separators
+	assert.Equal(t, image.Rect(0, 0, 20, 4), im.Bounds())
+
+	// the differing pixel is highlighted in the diff panel
+	r, g, b2, _ := im.At(14+4, 2).RGBA()
+	dr, dg, db, _ := DiffColor.RGBA()
+	assert.Equal(t, []uint32{dr, dg, db}, []uint32{r, g, b2})
+
+	// and there's nothing to write for identical frames
+	err = WriteDiff(&bytes.Buffer{}, []image.Image{a}, []image.Image{a}, Options{}, 1)
+	assert.Error(t, err)
+}
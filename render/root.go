@@ -6,7 +6,6 @@ import (
 	"runtime"
 	"sync"
 
-	"github.com/tidbyt/gg"
 	"tidbyt.dev/pixlet/globals"
 )
 
@@ -21,9 +20,6 @@ const (
 	DefaultMaxFrameCount = 2000
 )
 
-var FrameWidth = DefaultFrameWidth
-var FrameHeight = DefaultFrameHeight
-
 // Every Widget tree has a Root.
 //
 // The child widget, and all its descendants, will be drawn on a 64x32
@@ -50,6 +46,8 @@ type Root struct {
 
 	maxParallelFrames int
 	maxFrameCount     int
+	width             int
+	height            int
 }
 
 type RootPaintOption func(*Root)
@@ -74,22 +72,37 @@ func WithMaxFrameCount(max int) RootPaintOption {
 	}
 }
 
-// Paint renders the child widget onto the frame. It doesn't do
-// any resizing or alignment.
-func (r Root) Paint(solidBackground bool, opts ...RootPaintOption) []image.Image {
-	for _, opt := range opts {
-		opt(&r)
+// WithFrameSize sets the size of the frames to paint. A width or height
+// of 0 leaves that one at the default from globals.DefaultSize.
+func WithFrameSize(width, height int) RootPaintOption {
+	return func(r *Root) {
+		r.width = width
+		r.height = height
 	}
+}
 
-	if r.maxFrameCount <= 0 {
-		r.maxFrameCount = DefaultMaxFrameCount
+// bounds returns the bounds of the frames to paint.
+func (r Root) bounds() image.Rectangle {
+	width, height := globals.DefaultSize()
+	if r.width > 0 {
+		width = r.width
 	}
+	if r.height > 0 {
+		height = r.height
+	}
+	return image.Rect(0, 0, width, height)
+}
 
-	numFrames := r.Child.FrameCount()
-	if numFrames > r.maxFrameCount {
-		numFrames = r.maxFrameCount
+// Paint renders the child widget onto the frame. It doesn't do
+// any resizing or alignment. Frames that are no longer needed can be
+// handed back with ReleaseFrames.
+func (r Root) Paint(solidBackground bool, opts ...RootPaintOption) []image.Image {
+	for _, opt := range opts {
+		opt(&r)
 	}
 
+	numFrames := r.FrameCount()
+	bounds := r.bounds()
 	frames := make([]image.Image, numFrames)
 
 	parallelism := r.maxParallelFrames
@@ -97,13 +110,6 @@ func (r Root) Paint(solidBackground bool, opts ...RootPaintOption) []image.Image
 		parallelism = runtime.NumCPU()
 	}
 
-	if globals.Width != DefaultFrameWidth {
-		FrameWidth = globals.Width
-	}
-	if globals.Height != DefaultFrameHeight {
-		FrameHeight = globals.Height
-	}
-
 	var wg sync.WaitGroup
 	sem := make(chan bool, parallelism)
 	for i := 0; i < numFrames; i++ {
@@ -116,16 +122,7 @@ func (r Root) Paint(solidBackground bool, opts ...RootPaintOption) []image.Image
 				wg.Done()
 			}()
 
-			dc := gg.NewContext(FrameWidth, FrameHeight)
-			if solidBackground {
-				dc.SetColor(color.Black)
-				dc.Clear()
-			}
-
-			dc.Push()
-			r.Child.Paint(dc, image.Rect(0, 0, FrameWidth, FrameHeight), i)
-			dc.Pop()
-			frames[i] = dc.Image()
+			frames[i] = r.paintFrame(solidBackground, bounds, i)
 		}(i)
 	}
 
@@ -133,6 +130,51 @@ func (r Root) Paint(solidBackground bool, opts ...RootPaintOption) []image.Image
 	return frames
 }
 
+// FrameCount returns the number of frames that will be rendered when
+// calling `Paint`.
+func (r Root) FrameCount(opts ...RootPaintOption) int {
+	for _, opt := range opts {
+		opt(&r)
+	}
+
+	if r.maxFrameCount <= 0 {
+		r.maxFrameCount = DefaultMaxFrameCount
+	}
+
+	numFrames := r.Child.FrameCount(r.bounds())
+	if numFrames > r.maxFrameCount {
+		numFrames = r.maxFrameCount
+	}
+
+	return numFrames
+}
+
+// PaintFrame renders a single frame of the child widget. This is a lot
+// cheaper than calling `Paint` when only one frame is needed, e.g. for
+// a still preview.
+func (r Root) PaintFrame(solidBackground bool, frameIdx int, opts ...RootPaintOption) image.Image {
+	for _, opt := range opts {
+		opt(&r)
+	}
+
+	return r.paintFrame(solidBackground, r.bounds(), frameIdx)
+}
+
+func (r Root) paintFrame(solidBackground bool, bounds image.Rectangle, frameIdx int) image.Image {
+	dc, done := newCanvas(newFrame(bounds.Dx(), bounds.Dy()))
+	defer done()
+
+	if solidBackground {
+		dc.SetColor(color.Black)
+		dc.Clear()
+	}
+
+	dc.Push()
+	r.Child.Paint(dc, bounds, frameIdx)
+	dc.Pop()
+	return dc.Image()
+}
+
 // PaintRoots draws >=1 Roots which must all have the same dimensions.
 func PaintRoots(solidBackground bool, roots ...Root) []image.Image {
 	var images []image.Image
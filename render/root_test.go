@@ -0,0 +1,69 @@
+package render
+
+import (
+	"image"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tidbyt.dev/pixlet/globals"
+)
+
+func TestRootFrameSize(t *testing.T) {
+	r := Root{Child: Box{}}
+
+	frames := r.Paint(true, WithFrameSize(128, 64))
+	assert.Equal(t, image.Rect(0, 0, 128, 64), frames[0].Bounds())
+	assert.Equal(t, image.Rect(0, 0, 128, 64), r.PaintFrame(true, 0, WithFrameSize(128, 64)).Bounds())
+
+	// a size left out is the default one
+	assert.Equal(t, image.Rect(0, 0, 128, 32), r.PaintFrame(true, 0, WithFrameSize(128, 0)).Bounds())
+	assert.Equal(t, image.Rect(0, 0, 64, 32), r.PaintFrame(true, 0).Bounds())
+}
+
+func TestRootFrameSizeFollowsGlobals(t *testing.T) {
+	defer globals.SetSize(DefaultFrameWidth, DefaultFrameHeight)
+
+	r := Root{Child: Box{}}
+
+	globals.SetSize(128, 64)
+	frames := r.Paint(true)
+	assert.Equal(t, image.Rect(0, 0, 128, 64), frames[0].Bounds())
+
+	// going back to the default size has to work too
+	globals.SetSize(DefaultFrameWidth, DefaultFrameHeight)
+	frames = r.Paint(true)
+	assert.Equal(t, image.Rect(0, 0, 64, 32), frames[0].Bounds())
+	assert.Equal(t, image.Rect(0, 0, 64, 32), r.PaintFrame(true, 0).Bounds())
+}
+
+func TestRootFrameSizeConcurrent(t *testing.T) {
+	text := &WrappedText{Content: "This is quite a long text, which wraps onto more lines on narrower frames"}
+	require.NoError(t, text.Init())
+
+	// how long the marquee scrolls depends on the size it's painted at
+	r := Root{Child: Marquee{Height: 16, ScrollDirection: "vertical", Child: text}}
+	assert.NotEqual(t, r.FrameCount(WithFrameSize(64, 32)), r.FrameCount(WithFrameSize(128, 64)))
+
+	var wg sync.WaitGroup
+	for _, size := range []image.Point{{64, 32}, {128, 64}} {
+		want := r.FrameCount(WithFrameSize(size.X, size.Y))
+
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				frames := r.Paint(true, WithFrameSize(size.X, size.Y))
+				defer ReleaseFrames(frames...)
+				assert.Len(t, frames, want)
+				for _, f := range frames {
+					assert.Equal(t, image.Rect(0, 0, size.X, size.Y), f.Bounds())
+				}
+			}()
+		}
+	}
+	wg.Wait()
+}
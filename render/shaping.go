@@ -0,0 +1,490 @@
+package render
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/image/font"
+	"golang.org/x/text/unicode/bidi"
+	"golang.org/x/text/unicode/norm"
+)
+
+// glyphChecker is implemented by faces that can tell whether they
+// have a glyph for a rune, rather than substituting a default one.
+type glyphChecker interface {
+	HasGlyph(r rune) bool
+}
+
+func isMark(r rune) bool {
+	return unicode.Is(unicode.Mn, r)
+}
+
+func hasGlyph(face font.Face, r rune) bool {
+	if gc, ok := face.(glyphChecker); ok {
+		return gc.HasGlyph(r)
+	}
+	_, ok := face.GlyphAdvance(r)
+	return ok
+}
+
+func hasGlyphs(face font.Face, s string) bool {
+	for _, r := range s {
+		if !hasGlyph(face, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// shapeText prepares a single line of text for drawing with face. The
+// returned string is in visual order, i.e. it should be drawn left to
+// right.
+func shapeText(face font.Face, s string) string {
+	return reorderBidi(shapeLogical(face, s))
+}
+
+// shapeLogical does everything shapeText does except reordering, so
+// that the result can still be word wrapped.
+func shapeLogical(face font.Face, s string) string {
+	return shapeArabic(face, composeMarks(face, s))
+}
+
+// composeMarks replaces base characters followed by combining marks
+// with their precomposed forms, as long as the font has a glyph for
+// them. Precomposed glyphs look a lot better than overstruck marks.
+func composeMarks(face font.Face, s string) string {
+	if norm.NFC.IsNormalString(s) {
+		return s
+	}
+
+	var b strings.Builder
+	runes := []rune(s)
+	for i := 0; i < len(runes); {
+		j := i + 1
+		for j < len(runes) && isMark(runes[j]) {
+			j++
+		}
+
+		cluster := string(runes[i:j])
+		composed := norm.NFC.String(cluster)
+		if composed != cluster && hasGlyphs(face, composed) {
+			cluster = composed
+		}
+		b.WriteString(cluster)
+		i = j
+	}
+
+	return b.String()
+}
+
+// Arabic presentation forms, indexed by base letter. The forms are
+// isolated, final, initial and medial. Letters without initial and
+// medial forms only join to the preceding letter.
+var arabicForms = map[rune][4]rune{
+	0x0621: {0xfe80, 0, 0, 0},
+	0x0622: {0xfe81, 0xfe82, 0, 0},
+	0x0623: {0xfe83, 0xfe84, 0, 0},
+	0x0624: {0xfe85, 0xfe86, 0, 0},
+	0x0625: {0xfe87, 0xfe88, 0, 0},
+	0x0626: {0xfe89, 0xfe8a, 0xfe8b, 0xfe8c},
+	0x0627: {0xfe8d, 0xfe8e, 0, 0},
+	0x0628: {0xfe8f, 0xfe90, 0xfe91, 0xfe92},
+	0x0629: {0xfe93, 0xfe94, 0, 0},
+	0x062a: {0xfe95, 0xfe96, 0xfe97, 0xfe98},
+	0x062b: {0xfe99, 0xfe9a, 0xfe9b, 0xfe9c},
+	0x062c: {0xfe9d, 0xfe9e, 0xfe9f, 0xfea0},
+	0x062d: {0xfea1, 0xfea2, 0xfea3, 0xfea4},
+	0x062e: {0xfea5, 0xfea6, 0xfea7, 0xfea8},
+	0x062f: {0xfea9, 0xfeaa, 0, 0},
+	0x0630: {0xfeab, 0xfeac, 0, 0},
+	0x0631: {0xfead, 0xfeae, 0, 0},
+	0x0632: {0xfeaf, 0xfeb0, 0, 0},
+	0x0633: {0xfeb1, 0xfeb2, 0xfeb3, 0xfeb4},
+	0x0634: {0xfeb5, 0xfeb6, 0xfeb7, 0xfeb8},
+	0x0635: {0xfeb9, 0xfeba, 0xfebb, 0xfebc},
+	0x0636: {0xfebd, 0xfebe, 0xfebf, 0xfec0},
+	0x0637: {0xfec1, 0xfec2, 0xfec3, 0xfec4},
+	0x0638: {0xfec5, 0xfec6, 0xfec7, 0xfec8},
+	0x0639: {0xfec9, 0xfeca, 0xfecb, 0xfecc},
+	0x063a: {0xfecd, 0xfece, 0xfecf, 0xfed0},
+	0x0641: {0xfed1, 0xfed2, 0xfed3, 0xfed4},
+	0x0642: {0xfed5, 0xfed6, 0xfed7, 0xfed8},
+	0x0643: {0xfed9, 0xfeda, 0xfedb, 0xfedc},
+	0x0644: {0xfedd, 0xfede, 0xfedf, 0xfee0},
+	0x0645: {0xfee1, 0xfee2, 0xfee3, 0xfee4},
+	0x0646: {0xfee5, 0xfee6, 0xfee7, 0xfee8},
+	0x0647: {0xfee9, 0xfeea, 0xfeeb, 0xfeec},
+	0x0648: {0xfeed, 0xfeee, 0, 0},
+	0x0649: {0xfeef, 0xfef0, 0, 0},
+	0x064a: {0xfef1, 0xfef2, 0xfef3, 0xfef4},
+}
+
+// Lam-alef ligatures, indexed by alef variant. The forms are isolated
+// and final.
+var arabicLamAlef = map[rune][2]rune{
+	0x0622: {0xfef5, 0xfef6},
+	0x0623: {0xfef7, 0xfef8},
+	0x0625: {0xfef9, 0xfefa},
+	0x0627: {0xfefb, 0xfefc},
+}
+
+const (
+	arabicLam     = 0x0644
+	arabicTatweel = 0x0640
+)
+
+const (
+	formIsolated = iota
+	formFinal
+	formInitial
+	formMedial
+)
+
+// Returns true if r connects to the letter following it.
+func joinsNext(r rune) bool {
+	if r == arabicTatweel {
+		return true
+	}
+	forms, ok := arabicForms[r]
+	return ok && forms[formInitial] != 0
+}
+
+// Returns true if r connects to the letter preceding it.
+func joinsPrev(r rune) bool {
+	if r == arabicTatweel {
+		return true
+	}
+	forms, ok := arabicForms[r]
+	return ok && forms[formFinal] != 0
+}
+
+// shapeArabic replaces Arabic letters with the presentation form
+// matching their position in the word. Fonts rarely have all of these,
+// so letters are left alone unless face has the glyph.
+func shapeArabic(face font.Face, s string) string {
+	runes := []rune(s)
+
+	hasArabic := false
+	for _, r := range runes {
+		if _, ok := arabicForms[r]; ok {
+			hasArabic = true
+			break
+		}
+	}
+	if !hasArabic {
+		return s
+	}
+
+	// neighbor returns the closest letter in direction dir, skipping
+	// over marks, or -1 if there is none
+	neighbor := func(i, dir int) rune {
+		for j := i + dir; j >= 0 && j < len(runes); j += dir {
+			if !isMark(runes[j]) {
+				return runes[j]
+			}
+		}
+		return -1
+	}
+
+	out := make([]rune, 0, len(runes))
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		forms, ok := arabicForms[r]
+		if !ok {
+			out = append(out, r)
+			continue
+		}
+
+		prev := neighbor(i, -1)
+		connectPrev := joinsPrev(r) && prev >= 0 && joinsNext(prev)
+
+		if r == arabicLam && i+1 < len(runes) {
+			if lig, ok := arabicLamAlef[runes[i+1]]; ok {
+				form := lig[0]
+				if connectPrev {
+					form = lig[1]
+				}
+				if hasGlyphs(face, string(form)) {
+					out = append(out, form)
+					i++
+					continue
+				}
+			}
+		}
+
+		next := neighbor(i, 1)
+		connectNext := joinsNext(r) && next >= 0 && joinsPrev(next)
+
+		form := formIsolated
+		switch {
+		case connectPrev && connectNext:
+			form = formMedial
+		case connectPrev:
+			form = formFinal
+		case connectNext:
+			form = formInitial
+		}
+
+		if forms[form] != 0 && hasGlyphs(face, string(forms[form])) {
+			out = append(out, forms[form])
+		} else {
+			out = append(out, r)
+		}
+	}
+
+	return string(out)
+}
+
+// reorderBidi converts a single line of text from logical to visual
+// order. This is a simplified version of the Unicode Bidirectional
+// Algorithm (UAX #9) that handles mixed left-to-right and
+// right-to-left text, numbers and neutrals, but ignores explicit
+// embedding and isolate controls.
+func reorderBidi(s string) string {
+	runes := []rune(s)
+	classes := make([]bidi.Class, len(runes))
+
+	hasRTL := false
+	for i, r := range runes {
+		p, _ := bidi.LookupRune(r)
+		classes[i] = p.Class()
+		if classes[i] == bidi.R || classes[i] == bidi.AL || classes[i] == bidi.AN {
+			hasRTL = true
+		}
+	}
+	if !hasRTL {
+		return s
+	}
+
+	levels := resolveBidiLevels(classes)
+
+	// Group marks with their base character, so that they stay
+	// on top of it after reordering.
+	type cluster struct {
+		runes []rune
+		level int
+	}
+	clusters := []cluster{}
+	for i, r := range runes {
+		if len(clusters) > 0 && classes[i] == bidi.NSM {
+			c := &clusters[len(clusters)-1]
+			c.runes = append(c.runes, r)
+			continue
+		}
+		clusters = append(clusters, cluster{runes: []rune{r}, level: levels[i]})
+	}
+
+	// Rule L2: from the highest level down to the lowest odd
+	// level, reverse any contiguous sequence of clusters at that
+	// level or higher.
+	maxLevel, minOdd := 0, -1
+	for _, c := range clusters {
+		if c.level > maxLevel {
+			maxLevel = c.level
+		}
+		if c.level%2 == 1 && (minOdd < 0 || c.level < minOdd) {
+			minOdd = c.level
+		}
+	}
+	for level := maxLevel; minOdd > 0 && level >= minOdd; level-- {
+		for i := 0; i < len(clusters); {
+			if clusters[i].level < level {
+				i++
+				continue
+			}
+			j := i
+			for j < len(clusters) && clusters[j].level >= level {
+				j++
+			}
+			for a, b := i, j-1; a < b; a, b = a+1, b-1 {
+				clusters[a], clusters[b] = clusters[b], clusters[a]
+			}
+			i = j
+		}
+	}
+
+	// Rule L4: brackets in right-to-left text are mirrored.
+	var b strings.Builder
+	for _, c := range clusters {
+		for _, r := range c.runes {
+			if c.level%2 == 1 {
+				if p, _ := bidi.LookupRune(r); p.IsBracket() {
+					b.WriteString(bidi.ReverseString(string(r)))
+					continue
+				}
+			}
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// resolveBidiLevels assigns an embedding level to each character
+// class, following the weak, neutral and implicit rules of UAX #9.
+func resolveBidiLevels(classes []bidi.Class) []int {
+	n := len(classes)
+	types := make([]bidi.Class, n)
+	copy(types, classes)
+
+	// Rule P2/P3: paragraph level from the first strong character.
+	paraLevel := 0
+	for _, t := range types {
+		if t == bidi.L {
+			break
+		}
+		if t == bidi.R || t == bidi.AL {
+			paraLevel = 1
+			break
+		}
+	}
+	sos := bidi.L
+	if paraLevel == 1 {
+		sos = bidi.R
+	}
+
+	// W1: marks take the type of the preceding character.
+	for i, t := range types {
+		if t == bidi.NSM {
+			if i == 0 {
+				types[i] = sos
+			} else {
+				types[i] = types[i-1]
+			}
+		}
+	}
+
+	// W2: European numbers following Arabic letters are Arabic
+	// numbers. W3: Arabic letters are right-to-left.
+	lastStrong := sos
+	for i, t := range types {
+		switch t {
+		case bidi.L, bidi.R, bidi.AL:
+			lastStrong = t
+		case bidi.EN:
+			if lastStrong == bidi.AL {
+				types[i] = bidi.AN
+			}
+		}
+	}
+	for i, t := range types {
+		if t == bidi.AL {
+			types[i] = bidi.R
+		}
+	}
+
+	// W4: a single separator between two numbers of the same type
+	// becomes part of the number.
+	for i := 1; i+1 < n; i++ {
+		prev, next := types[i-1], types[i+1]
+		switch types[i] {
+		case bidi.ES:
+			if prev == bidi.EN && next == bidi.EN {
+				types[i] = bidi.EN
+			}
+		case bidi.CS:
+			if prev == next && (prev == bidi.EN || prev == bidi.AN) {
+				types[i] = prev
+			}
+		}
+	}
+
+	// W5: terminators (currency symbols, %, etc) adjacent to
+	// European numbers become part of the number.
+	for i := 0; i < n; i++ {
+		if types[i] != bidi.ET {
+			continue
+		}
+		j := i
+		for j < n && types[j] == bidi.ET {
+			j++
+		}
+		if (i > 0 && types[i-1] == bidi.EN) || (j < n && types[j] == bidi.EN) {
+			for k := i; k < j; k++ {
+				types[k] = bidi.EN
+			}
+		}
+		i = j
+	}
+
+	// W6: remaining separators and terminators are neutral. W7:
+	// European numbers in left-to-right context are left-to-right.
+	lastStrong = sos
+	for i, t := range types {
+		switch t {
+		case bidi.ES, bidi.ET, bidi.CS:
+			types[i] = bidi.ON
+		case bidi.L, bidi.R:
+			lastStrong = t
+		case bidi.EN:
+			if lastStrong == bidi.L {
+				types[i] = bidi.L
+			}
+		}
+	}
+
+	// N1/N2: runs of neutrals take the direction of the surrounding
+	// text if both sides agree, and the paragraph direction
+	// otherwise. Numbers count as right-to-left here.
+	strongDir := func(t bidi.Class) (bidi.Class, bool) {
+		switch t {
+		case bidi.L:
+			return bidi.L, true
+		case bidi.R, bidi.EN, bidi.AN:
+			return bidi.R, true
+		}
+		return 0, false
+	}
+	for i := 0; i < n; i++ {
+		if _, ok := strongDir(types[i]); ok {
+			continue
+		}
+		j := i
+		for j < n {
+			if _, ok := strongDir(types[j]); ok {
+				break
+			}
+			j++
+		}
+
+		before, after := sos, sos
+		if i > 0 {
+			before, _ = strongDir(types[i-1])
+		}
+		if j < n {
+			after, _ = strongDir(types[j])
+		}
+
+		dir := sos
+		if before == after {
+			dir = before
+		}
+		for k := i; k < j; k++ {
+			types[k] = dir
+		}
+		i = j
+	}
+
+	// I1/I2: implicit levels.
+	levels := make([]int, n)
+	for i, t := range types {
+		levels[i] = paraLevel
+		if paraLevel%2 == 0 {
+			switch t {
+			case bidi.R:
+				levels[i]++
+			case bidi.EN, bidi.AN:
+				levels[i] += 2
+			}
+		} else if t == bidi.L || t == bidi.EN || t == bidi.AN {
+			levels[i]++
+		}
+	}
+
+	// L1: trailing whitespace is reset to the paragraph level.
+	for i := n - 1; i >= 0 && classes[i] == bidi.WS; i-- {
+		levels[i] = paraLevel
+	}
+
+	return levels
+}
@@ -0,0 +1,120 @@
+package render
+
+import (
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReorderBidi(t *testing.T) {
+	for in, expected := range map[string]string{
+		"hello world":      "hello world",
+		"שלום":             "םולש",
+		"abc שלום def":     "abc םולש def",
+		"שלום abc":         "abc םולש",
+		"שלום 123":         "123 םולש",
+		"(שלום)":           "(םולש)",
+		"price: 5% שלום":   "price: 5% םולש",
+		"שלום עולם!":       "!םלוע םולש",
+		"אָב":              "באָ",
+		"abc שלום 12.5 ok": "abc 12.5 םולש ok",
+	} {
+		assert.Equal(t, expected, reorderBidi(in), in)
+	}
+}
+
+func TestShapeArabic(t *testing.T) {
+	face, err := GetFont("10x20")
+	require.NoError(t, err)
+
+	// seen, lam-alef ligature and meem
+	assert.Equal(t, "ﺳﻼﻡ", shapeArabic(face, "سلام"))
+
+	// beh in all four positions
+	assert.Equal(t, "ﺏ ﺑﺐ ﺑﺒﺐ", shapeArabic(face, "ب بب ببب"))
+
+	// fonts without presentation forms keep the base letters
+	face, err = GetFont("tb-8")
+	require.NoError(t, err)
+	assert.Equal(t, "سلام", shapeArabic(face, "سلام"))
+}
+
+func TestComposeMarks(t *testing.T) {
+	face, err := GetFont("tb-8")
+	require.NoError(t, err)
+
+	assert.Equal(t, "é", composeMarks(face, "é"))
+	assert.Equal(t, "x́", composeMarks(face, "x́"))
+	assert.Equal(t, "plain", composeMarks(face, "plain"))
+}
+
+func TestTextCombiningMarks(t *testing.T) {
+	// decomposed accents render just like precomposed ones
+	decomposed := &Text{Content: "é"}
+	require.NoError(t, decomposed.Init())
+	precomposed := &Text{Content: "é"}
+	require.NoError(t, precomposed.Init())
+
+	im := PaintWidget(decomposed, image.Rect(0, 0, 10, 8), 0)
+	im2 := PaintWidget(precomposed, image.Rect(0, 0, 10, 8), 0)
+	assert.Equal(t, im2.(*image.RGBA).Pix, im.(*image.RGBA).Pix)
+
+	// marks without a precomposed form are drawn on top of the
+	// preceding glyph rather than next to it
+	text := &Text{Content: "x́"}
+	require.NoError(t, text.Init())
+	w, _ := text.Size()
+	assert.Equal(t, 5, w)
+	im = PaintWidget(text, image.Rect(0, 0, 5, 8), 0)
+	assert.Equal(t, nil, checkImage([]string{
+		"..w..",
+		".w...",
+		".....",
+		"w..w.",
+		".ww..",
+		".ww..",
+		"w..w.",
+		".....",
+	}, im))
+}
+
+func TestTextRightToLeft(t *testing.T) {
+	text := &Text{Content: "אב"}
+	require.NoError(t, text.Init())
+	alef := &Text{Content: "א"}
+	require.NoError(t, alef.Init())
+	bet := &Text{Content: "ב"}
+	require.NoError(t, bet.Init())
+
+	// the first letter of the word ends up on the right
+	im := PaintWidget(text, image.Rect(0, 0, 10, 8), 0)
+	imAlef := PaintWidget(alef, image.Rect(0, 0, 5, 8), 0)
+	imBet := PaintWidget(bet, image.Rect(0, 0, 5, 8), 0)
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 5; x++ {
+			assert.Equal(t, imBet.At(x, y), im.At(x, y))
+			assert.Equal(t, imAlef.At(x, y), im.At(x+5, y))
+		}
+	}
+}
+
+func TestWrappedTextRightToLeft(t *testing.T) {
+	// each line is reordered separately, with the first words on
+	// the first line
+	wt := &WrappedText{Content: "אב גד", Width: 10}
+	require.NoError(t, wt.Init())
+	first := &Text{Content: "אב"}
+	require.NoError(t, first.Init())
+
+	im := PaintWidget(wt, image.Rect(0, 0, 10, 16), 0)
+	assert.Equal(t, image.Rect(0, 0, 10, 16), im.Bounds())
+
+	imFirst := PaintWidget(first, image.Rect(0, 0, 10, 8), 0)
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 10; x++ {
+			assert.Equal(t, imFirst.At(x, y), im.At(x, y))
+		}
+	}
+}
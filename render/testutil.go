@@ -5,8 +5,6 @@ import (
 	"image"
 	"image/color"
 	"strings"
-
-	"github.com/tidbyt/gg"
 )
 
 var DefaultPalette = map[string]color.RGBA{
@@ -106,7 +104,8 @@ func CheckImage(expected []string, actual image.Image) error {
 
 func PaintWidget(w Widget, bounds image.Rectangle, frameIdx int) image.Image {
 	pb := w.PaintBounds(bounds, frameIdx)
-	dc := gg.NewContext(pb.Dx(), pb.Dy())
+	dc, done := newCanvas(image.NewRGBA(image.Rect(0, 0, pb.Dx(), pb.Dy())))
+	defer done()
 	w.Paint(dc, bounds, frameIdx)
 	return dc.Image()
 }
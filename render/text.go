@@ -16,27 +16,35 @@ var (
 // Text draws a string of text on a single line.
 //
 // By default, the text will use the "tb-8" font, but other fonts can
-// be chosen via the `font` attribute. The `height` and `offset`
-// parameters allow fine tuning of the vertical layout of the
-// string. Take a look at the [font documentation](fonts.md) for more
-// information.
+// be chosen via the `font` attribute. Characters missing from the
+// font are drawn as its default character, unless `fallback` lists
+// fonts to draw them with instead, like `["6x13", "10x20", "cjk-12"]`.
+// The `height` and `offset` parameters allow fine tuning of the
+// vertical layout of the string. Setting `antialias`
+// smooths the jagged diagonals of the bitmap fonts, which mostly
+// helps on larger canvases. Take a look at the
+// [font documentation](fonts.md) for more information.
 //
 // DOC(Content): The text string to draw
 // DOC(Font): Desired font face
 // DOC(Height): Limits height of the area on which text is drawn
 // DOC(Offset): Shifts position of text vertically.
 // DOC(Color): Desired font color
+// DOC(Fallback): Fonts to use, in order, for characters missing from the font
+// DOC(Antialias): Smooth the diagonal edges of glyphs
 //
 // EXAMPLE BEGIN
 // render.Text(content="Tidbyt!", color="#099")
 // EXAMPLE END
 type Text struct {
 	Widget
-	Content string `starlark:"content,required"`
-	Font    string
-	Height  int
-	Offset  int
-	Color   color.Color
+	Content   string `starlark:"content,required"`
+	Font      string
+	Height    int
+	Offset    int
+	Color     color.Color
+	Fallback  []string
+	Antialias bool
 
 	img image.Image
 }
@@ -46,7 +54,7 @@ func (t *Text) Size() (int, int) {
 }
 
 func (t *Text) Paint(dc *gg.Context, bounds image.Rectangle, frameIdx int) {
-	dc.DrawImage(t.img, 0, 0)
+	drawImage(dc, t.img, 0, 0)
 }
 
 func (t *Text) PaintBounds(bounds image.Rectangle, frameIdx int) image.Rectangle {
@@ -57,15 +65,17 @@ func (t *Text) Init() error {
 	if t.Font == "" {
 		t.Font = DefaultFontFace
 	}
-	face, err := GetFont(t.Font)
+	face, err := getTextFace(t.Font, t.Fallback, t.Antialias)
 	if err != nil {
 		return err
 	}
 
+	content := shapeText(face, t.Content)
+
 	dc := gg.NewContext(0, 0)
 	dc.SetFontFace(face)
 
-	w, _ := dc.MeasureString(t.Content)
+	w, _ := dc.MeasureString(content)
 	width := int(w)
 
 	// If the width of the text is longer then the max, cut off the size of the
@@ -83,21 +93,19 @@ func (t *Text) Init() error {
 		height = t.Height
 	}
 
-	dc = gg.NewContext(width, height)
-	dc.SetFontFace(face)
-	if t.Color != nil {
-		dc.SetColor(t.Color)
-	} else {
-		dc.SetColor(DefaultFontColor)
+	col := t.Color
+	if col == nil {
+		col = DefaultFontColor
 	}
 
-	dc.DrawString(t.Content, 0, float64(height-descent-t.Offset))
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	drawString(img, face, col, content, 0, float64(height-descent-t.Offset))
 
-	t.img = dc.Image()
+	t.img = img
 
 	return nil
 }
 
-func (t Text) FrameCount() int {
+func (t Text) FrameCount(bounds image.Rectangle) int {
 	return 1
 }
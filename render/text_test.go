@@ -200,3 +200,34 @@ func TestTextMissingFont(t *testing.T) {
 	}
 	assert.Error(t, text.Init())
 }
+
+func TestTextCJK(t *testing.T) {
+	text := &Text{
+		Content: "日a",
+		Font:    "cjk-12",
+	}
+	assert.NoError(t, text.Init())
+
+	// fullwidth glyphs are twice as wide as halfwidth ones
+	w, h := text.Size()
+	assert.Equal(t, 18, w)
+	assert.Equal(t, 14, h)
+
+	im := PaintWidget(text, image.Rect(0, 0, 18, 14), 0)
+	assert.Equal(t, nil, checkImage([]string{
+		"............",
+		"..wwwwwwww..",
+		"..w......w..",
+		"..w......w..",
+		"..w......w..",
+		"..w......w..",
+		"..wwwwwwww..",
+		"..w......w..",
+		"..w......w..",
+		"..w......w..",
+		"..w......w..",
+		"..wwwwwwww..",
+		"............",
+		"............",
+	}, im.(*image.RGBA).SubImage(image.Rect(0, 0, 12, 14))))
+}
@@ -13,7 +13,7 @@ type Tracer struct {
 	TraceLength int
 }
 
-func (t Tracer) FrameCount() int {
+func (t Tracer) FrameCount(bounds image.Rectangle) int {
 	return t.Path.Length()
 }
 
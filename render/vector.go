@@ -207,15 +207,9 @@ func (v Vector) Paint(dc *gg.Context, bounds image.Rectangle, frameIdx int) {
 		dc.Push()
 		dc.Translate(float64(dx*offset+dy*crossOffset), float64(dx*crossOffset+dy*offset))
 
-		dc.DrawRectangle(
-			float64(0),
-			float64(0),
-			float64(cb.Dx()),
-			float64(cb.Dy()),
-		)
-		dc.Clip()
-
-		child.Paint(dc, image.Rect(0, 0, boundsW-dx*sumW, boundsH-dy*sumH), frameIdx)
+		withClip(dc, 0, 0, cb.Dx(), cb.Dy(), func() {
+			child.Paint(dc, image.Rect(0, 0, boundsW-dx*sumW, boundsH-dy*sumH), frameIdx)
+		})
 		dc.Pop()
 
 		sumW += imW
@@ -235,6 +229,6 @@ func (v Vector) Paint(dc *gg.Context, bounds image.Rectangle, frameIdx int) {
 	}
 }
 
-func (v Vector) FrameCount() int {
-	return MaxFrameCount(v.Children)
+func (v Vector) FrameCount(bounds image.Rectangle) int {
+	return MaxFrameCount(v.Children, bounds)
 }
@@ -11,7 +11,9 @@ type Widget interface {
 	// PaintBounds Returns the bounds of the area that will actually be drawn to when Paint() is called
 	PaintBounds(bounds image.Rectangle, frameIdx int) image.Rectangle
 	Paint(dc *gg.Context, bounds image.Rectangle, frameIdx int)
-	FrameCount() int
+	// FrameCount returns the number of frames the widget has when
+	// painted within bounds
+	FrameCount(bounds image.Rectangle) int
 }
 
 // Widgets can require initialization
@@ -34,12 +36,13 @@ func ModInt(a, m int) int {
 	return a
 }
 
-// Computes the maximum frame count of a slice of widgets.
-func MaxFrameCount(widgets []Widget) int {
+// Computes the maximum frame count of a slice of widgets painted within
+// bounds.
+func MaxFrameCount(widgets []Widget, bounds image.Rectangle) int {
 	m := 1
 
 	for _, w := range widgets {
-		if c := w.FrameCount(); c > m {
+		if c := w.FrameCount(bounds); c > m {
 			m = c
 		}
 	}
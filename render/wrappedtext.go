@@ -3,6 +3,7 @@ package render
 import (
 	"image"
 	"image/color"
+	"strings"
 
 	"github.com/tidbyt/gg"
 
@@ -27,6 +28,8 @@ import (
 // DOC(LineSpacing): Controls spacing between lines
 // DOC(Color): Desired font color
 // DOC(Align): Text Alignment
+// DOC(Fallback): Fonts to use, in order, for characters missing from the font
+// DOC(Antialias): Smooth the diagonal edges of glyphs
 // EXAMPLE BEGIN
 // render.WrappedText(
 //
@@ -46,8 +49,11 @@ type WrappedText struct {
 	LineSpacing int
 	Color       color.Color
 	Align       string
+	Fallback    []string
+	Antialias   bool
 
-	face font.Face
+	face    font.Face
+	content string
 }
 
 func (tw *WrappedText) Init() error {
@@ -55,12 +61,13 @@ func (tw *WrappedText) Init() error {
 		tw.Font = DefaultFontFace
 	}
 
-	face, err := GetFont(tw.Font)
+	face, err := getTextFace(tw.Font, tw.Fallback, tw.Antialias)
 	if err != nil {
 		return err
 	}
 
 	tw.face = face
+	tw.content = shapeLogical(face, tw.Content)
 
 	return nil
 }
@@ -87,7 +94,7 @@ func (tw *WrappedText) PaintBounds(bounds image.Rectangle, frameIdx int) image.R
 	dc.SetFontFace(tw.face)
 	w := 0.0
 	h := 0.0
-	for _, line := range dc.WordWrap(tw.Content, float64(width)) {
+	for _, line := range dc.WordWrap(tw.content, float64(width)) {
 		lw, lh := dc.MeasureString(line)
 		if lw > w {
 			w = lw
@@ -116,38 +123,68 @@ func (tw *WrappedText) PaintBounds(bounds image.Rectangle, frameIdx int) image.R
 }
 
 func (tw *WrappedText) Paint(dc *gg.Context, bounds image.Rectangle, frameIdx int) {
-	// Text alignment
-	align := gg.AlignLeft
-	if tw.Align == "center" {
-		align = gg.AlignCenter
-	} else if tw.Align == "right" {
-		align = gg.AlignRight
-	}
-
 	width := tw.PaintBounds(bounds, frameIdx).Dx()
 
 	metrics := tw.face.Metrics()
 	descent := metrics.Descent.Floor()
 
-	dc.SetFontFace(tw.face)
-	if tw.Color != nil {
-		dc.SetColor(tw.Color)
-	} else {
-		dc.SetColor(DefaultFontColor)
+	col := tw.Color
+	if col == nil {
+		col = DefaultFontColor
 	}
 
-	dc.DrawStringWrapped(
-		tw.Content,
-		0,
-		float64(-descent),
-		0,
-		0,
-		float64(width),
-		(float64(tw.LineSpacing)+dc.FontHeight())/dc.FontHeight(),
-		align,
-	)
+	// for measuring text
+	mc := gg.NewContext(0, 0)
+	mc.SetFontFace(tw.face)
+
+	// Lines are wrapped in logical order and then reordered for
+	// display, so that right-to-left text wraps correctly.
+	lines := mc.WordWrap(tw.content, float64(width))
+	for i, line := range lines {
+		lines[i] = reorderBidi(line)
+	}
+
+	// Lay the lines out like gg.Context.DrawStringWrapped, and draw
+	// them on an image with glyphs from the glyph cache, which is much
+	// quicker than drawing them on dc one by one.
+	lines = mc.WordWrap(strings.Join(lines, "\n"), float64(width))
+	fontHeight := mc.FontHeight()
+	lineSpacing := (float64(tw.LineSpacing) + fontHeight) / fontHeight
+
+	x, ax := 0.0, 0.0
+	switch tw.Align {
+	case "center":
+		x, ax = float64(width)/2, 0.5
+	case "right":
+		x, ax = float64(width), 1
+	}
+
+	type placed struct {
+		line string
+		x, y float64
+	}
+	placements := make([]placed, 0, len(lines))
+	var textBounds image.Rectangle
+	y := float64(-descent)
+	for _, line := range lines {
+		w, h := mc.MeasureString(line)
+		p := placed{line, x - ax*w, y + h}
+		placements = append(placements, p)
+		textBounds = textBounds.Union(stringBounds(tw.face, p.line, p.x, p.y))
+		y += fontHeight * lineSpacing
+	}
+
+	if textBounds.Empty() {
+		return
+	}
+
+	img := image.NewRGBA(textBounds)
+	for _, p := range placements {
+		drawString(img, tw.face, col, p.line, p.x, p.y)
+	}
+	drawImage(dc, img, 0, 0)
 }
 
-func (tw *WrappedText) FrameCount() int {
+func (tw *WrappedText) FrameCount(bounds image.Rectangle) int {
 	return 1
 }
@@ -3,13 +3,18 @@ package runtime
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/fs"
+	"log/slog"
 	"path"
 	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"testing"
 	"testing/fstest"
+	"time"
 
 	starlibbsoup "github.com/qri-io/starlib/bsoup"
 	starlibgzip "github.com/qri-io/starlib/compress/gzip"
@@ -19,6 +24,7 @@ import (
 	starlibhtml "github.com/qri-io/starlib/html"
 	starlibre "github.com/qri-io/starlib/re"
 	starlibzip "github.com/qri-io/starlib/zipfile"
+	"go.opentelemetry.io/otel/attribute"
 	starlibjson "go.starlark.net/lib/json"
 	starlibmath "go.starlark.net/lib/math"
 	starlibtime "go.starlark.net/lib/time"
@@ -27,19 +33,23 @@ import (
 	"go.starlark.net/starlarktest"
 	"go.starlark.net/syntax"
 
+	"tidbyt.dev/pixlet/metrics"
 	"tidbyt.dev/pixlet/render"
 	"tidbyt.dev/pixlet/runtime/modules/animation_runtime"
+	"tidbyt.dev/pixlet/runtime/modules/device"
 	"tidbyt.dev/pixlet/runtime/modules/file"
 	"tidbyt.dev/pixlet/runtime/modules/hmac"
 	"tidbyt.dev/pixlet/runtime/modules/humanize"
 	"tidbyt.dev/pixlet/runtime/modules/qrcode"
 	"tidbyt.dev/pixlet/runtime/modules/random"
 	"tidbyt.dev/pixlet/runtime/modules/render_runtime"
+	"tidbyt.dev/pixlet/runtime/modules/scratch"
 	"tidbyt.dev/pixlet/runtime/modules/starlarkhttp"
 	"tidbyt.dev/pixlet/runtime/modules/sunrise"
 	"tidbyt.dev/pixlet/runtime/modules/xpath"
 	"tidbyt.dev/pixlet/schema"
 	"tidbyt.dev/pixlet/starlarkutil"
+	"tidbyt.dev/pixlet/tracing"
 )
 
 type ModuleLoader func(*starlark.Thread, string) (starlark.StringDict, error)
@@ -59,12 +69,31 @@ type Applet struct {
 	MainFile string
 
 	loader       ModuleLoader
+	assets       AssetLoader
+	secrets      SecretsProvider
+	logger       *slog.Logger
 	initializers []ThreadInitializer
 	loadedPaths  map[string]bool
+	predeclared  starlark.StringDict
+	limits       Limits
+	policy       *appPolicy
+
+	// embeddedSecrets are the encrypted secrets in the app's secrets
+	// manifest
+	embeddedSecrets map[string]string
 
 	mainFun    *starlark.Function
 	schemaFile string
 
+	// fsys is what the app was loaded from, and programs are its
+	// compiled Starlark files, by path, for Snapshot
+	fsys     fs.FS
+	programs map[string]*starlark.Program
+
+	// Schema is the app's parsed schema, or nil if it doesn't have
+	// one. Hosts can use it to build their own config UI, and call
+	// its handlers with CallSchemaHandler. SchemaJSON is the same
+	// schema, as served to the Tidbyt mobile app.
 	Schema     *schema.Schema
 	SchemaJSON []byte
 }
@@ -76,6 +105,15 @@ func WithModuleLoader(loader ModuleLoader) AppletOption {
 	}
 }
 
+// WithPredeclared makes globals available in every file of the app,
+// without loading them.
+func WithPredeclared(globals starlark.StringDict) AppletOption {
+	return func(a *Applet) error {
+		a.predeclared = globals
+		return nil
+	}
+}
+
 func WithThreadInitializer(init ThreadInitializer) AppletOption {
 	return func(a *Applet) error {
 		a.initializers = append(a.initializers, init)
@@ -97,6 +135,18 @@ func WithSecretDecryptionKey(key *SecretDecryptionKey) AppletOption {
 	}
 }
 
+// WithDevice tells the app about the device it's rendered for, which
+// it can read with the device module.
+func WithDevice(d device.Device) AppletOption {
+	return func(a *Applet) error {
+		a.initializers = append(a.initializers, func(t *starlark.Thread) *starlark.Thread {
+			device.AttachToThread(t, d)
+			return t
+		})
+		return nil
+	}
+}
+
 func WithPrintFunc(print PrintFunc) AppletOption {
 	return func(a *Applet) error {
 		a.initializers = append(a.initializers, func(t *starlark.Thread) *starlark.Thread {
@@ -111,6 +161,17 @@ func WithPrintDisabled() AppletOption {
 	return WithPrintFunc(func(thread *starlark.Thread, msg string) {})
 }
 
+// WithLogger sends the app's logs to logger, tagged with the app's ID:
+// what it prints, the HTTP requests it makes, and problems the runtime
+// works around, like a failing cache. Without it, logs go to
+// slog.Default(), and what the app prints goes to stdout.
+func WithLogger(logger *slog.Logger) AppletOption {
+	return func(a *Applet) error {
+		a.logger = logger
+		return nil
+	}
+}
+
 func NewApplet(id string, src []byte, opts ...AppletOption) (*Applet, error) {
 	fn := id
 	if !strings.HasSuffix(fn, ".star") {
@@ -127,10 +188,22 @@ func NewApplet(id string, src []byte, opts ...AppletOption) (*Applet, error) {
 }
 
 func NewAppletFromFS(id string, fsys fs.FS, opts ...AppletOption) (*Applet, error) {
+	return newApplet(id, fsys, nil, opts...)
+}
+
+// newApplet loads an app from fsys, using the compiled programs given
+// for its Starlark files instead of compiling them.
+func newApplet(id string, fsys fs.FS, programs map[string]*starlark.Program, opts ...AppletOption) (*Applet, error) {
+	if programs == nil {
+		programs = make(map[string]*starlark.Program)
+	}
+
 	a := &Applet{
 		ID:          id,
 		Globals:     make(map[string]starlark.StringDict),
 		loadedPaths: make(map[string]bool),
+		fsys:        fsys,
+		programs:    programs,
 	}
 
 	for _, opt := range opts {
@@ -139,7 +212,10 @@ func NewAppletFromFS(id string, fsys fs.FS, opts ...AppletOption) (*Applet, erro
 		}
 	}
 
-	if err := a.load(fsys); err != nil {
+	_, span := tracing.Start(context.Background(), "pixlet.load", tracing.AppletID(id))
+	err := a.load(fsys)
+	tracing.End(span, err)
+	if err != nil {
 		return nil, err
 	}
 
@@ -190,14 +266,44 @@ func ExtractRoots(val starlark.Value) ([]render.Root, error) {
 
 // RunWithConfig exceutes the applet's main function, passing it configuration as a
 // starlark dict. It returns the render roots that are returned by the applet.
+//
+// If the config was saved with an older version of the schema, it's
+// migrated first. See MigrateConfig.
 func (a *Applet) RunWithConfig(ctx context.Context, config map[string]string) (roots []render.Root, err error) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, "pixlet.main", tracing.AppletID(a.ID))
+	var thread *starlark.Thread
+	defer func() {
+		var steps uint64
+		if thread != nil {
+			steps = thread.ExecutionSteps()
+		}
+		metrics.ObserveRun(a.ID, time.Since(start), steps, err)
+		tracing.End(span, err)
+		if err != nil {
+			a.log().DebugContext(ctx, "run failed", "duration", time.Since(start), "error", err)
+		} else {
+			a.log().DebugContext(ctx, "ran", "duration", time.Since(start), "steps", steps, "roots", len(roots))
+		}
+	}()
+
+	if len(config) > 0 {
+		config, err = a.MigrateConfig(ctx, config)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	var args starlark.Tuple
 	if a.mainFun.NumParams() > 0 {
 		starlarkConfig := AppletConfig(config)
 		args = starlark.Tuple{starlarkConfig}
 	}
 
-	returnValue, err := a.Call(ctx, a.mainFun, args...)
+	returnValue, err := a.call(ctx, a.mainFun, func(t *starlark.Thread) *starlark.Thread {
+		thread = t
+		return t
+	}, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -207,21 +313,102 @@ func (a *Applet) RunWithConfig(ctx context.Context, config map[string]string) (r
 		return nil, err
 	}
 
+	if err := a.limits.checkFrames(ctx, roots); err != nil {
+		return nil, err
+	}
+
 	return roots, nil
 }
 
+// GetSchema returns the app's schema as JSON, with field names and
+// descriptions translated for the locale. The locale can be a language
+// tag or the value of an Accept-Language header. If it's empty, the
+// schema is returned with all translations included.
+func (app *Applet) GetSchema(locale string) ([]byte, error) {
+	if app.Schema == nil || locale == "" {
+		return app.SchemaJSON, nil
+	}
+
+	localized, err := app.Schema.Localized(locale)
+	if err != nil {
+		return nil, err
+	}
+
+	js, err := json.Marshal(localized)
+	if err != nil {
+		return nil, fmt.Errorf("serializing schema to JSON: %w", err)
+	}
+
+	return js, nil
+}
+
 // CallSchemaHandler calls a schema handler, passing it a single
 // string parameter and returning a single string value.
 func (app *Applet) CallSchemaHandler(ctx context.Context, handlerName, parameter string) (result string, err error) {
+	return app.CallSchemaHandlerWithConfig(ctx, handlerName, parameter, nil)
+}
+
+// CallSchemaHandlerWithConfig is like CallSchemaHandler, but also passes
+// the current config to handlers that take it as a second parameter,
+// like `def search(pattern, config)`. Handlers with a single parameter
+// are called just like with CallSchemaHandler.
+func (app *Applet) CallSchemaHandlerWithConfig(
+	ctx context.Context,
+	handlerName, parameter string,
+	config map[string]string,
+) (result string, err error) {
+	ctx, span := tracing.Start(ctx, "pixlet.schema_handler",
+		tracing.AppletID(app.ID),
+		attribute.String("pixlet.handler", handlerName),
+	)
+	defer func() { tracing.End(span, err) }()
+
 	handler, found := app.Schema.Handlers[handlerName]
 	if !found {
 		return "", fmt.Errorf("no exported handler named '%s'", handlerName)
 	}
 
+	var arg starlark.Value = starlark.String(parameter)
+	if handler.ReturnType == schema.ReturnValidation || handler.TakesConfig {
+		// validators and handlers that depend on several fields are
+		// passed the config, encoded as a JSON object
+		config := AppletConfig{}
+		if err := json.Unmarshal([]byte(parameter), &config); err != nil {
+			return "", fmt.Errorf("decoding config for handler %s: %w", handlerName, err)
+		}
+		arg = config
+	}
+
+	args := starlark.Tuple{arg}
+	if handler.ReturnType == schema.ReturnMigration {
+		// migrate handlers are passed the stored config and the
+		// version it was saved with
+		var migration struct {
+			Config      map[string]string `json:"config"`
+			FromVersion int               `json:"from_version"`
+		}
+		if err := json.Unmarshal([]byte(parameter), &migration); err != nil {
+			return "", fmt.Errorf("decoding config for migrate handler %s: %w", handlerName, err)
+		}
+
+		config := starlark.NewDict(len(migration.Config))
+		for k, v := range migration.Config {
+			config.SetKey(starlark.String(k), starlark.String(v))
+		}
+		args = starlark.Tuple{config, starlark.MakeInt(migration.FromVersion)}
+	} else if !handler.TakesConfig &&
+		handler.ReturnType != schema.ReturnValidation &&
+		handler.Function.NumParams() > 1 {
+		if config == nil {
+			config = map[string]string{}
+		}
+		args = append(args, AppletConfig(config))
+	}
+
 	resultVal, err := app.Call(
 		ctx,
 		handler.Function,
-		starlark.String(parameter),
+		args...,
 	)
 	if err != nil {
 		return "", fmt.Errorf("calling schema handler %s: %v", handlerName, err)
@@ -248,6 +435,19 @@ func (app *Applet) CallSchemaHandler(ctx context.Context, handlerName, parameter
 
 		return string(s), nil
 
+	case schema.ReturnValidation:
+		return schema.EncodeValidation(resultVal)
+
+	case schema.ReturnMigration:
+		return schema.EncodeMigration(resultVal)
+
+	case schema.ReturnJSON:
+		js, err := schema.EncodeJSON(resultVal)
+		if err != nil {
+			return "", fmt.Errorf("encoding result of handler %s: %w", handlerName, err)
+		}
+		return js, nil
+
 	case schema.ReturnString:
 		str, ok := starlark.AsString(resultVal)
 		if !ok {
@@ -262,40 +462,194 @@ func (app *Applet) CallSchemaHandler(ctx context.Context, handlerName, parameter
 	return "", fmt.Errorf("a very unexpected error happened for handler \"%s\"", handlerName)
 }
 
-// RunTests runs all test functions that are defined in the applet source.
-func (app *Applet) RunTests(t *testing.T) {
-	app.initializers = append(app.initializers, func(thread *starlark.Thread) *starlark.Thread {
-		starlarktest.SetReporter(thread, t)
-		return thread
+// CallValidator runs the app's validator handler, if it has one, on
+// a proposed config. It returns a map of field IDs to error messages,
+// which is empty if the config is valid.
+func (app *Applet) CallValidator(ctx context.Context, config map[string]string) (map[string]string, error) {
+	errors := map[string]string{}
+	if app.Schema == nil || app.Schema.Validator == "" {
+		return errors, nil
+	}
+
+	param, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("encoding config: %w", err)
+	}
+
+	result, err := app.CallSchemaHandler(ctx, app.Schema.Validator, string(param))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(result), &errors); err != nil {
+		return nil, fmt.Errorf("decoding validation errors: %w", err)
+	}
+
+	return errors, nil
+}
+
+// ValidateConfig checks a config against the app's schema, and then
+// runs the app's validator on it. Problems with the config are
+// returned as schema.ConfigErrors, which is nil if the config is
+// valid. The validator only runs once the values themselves are well
+// formed, so it doesn't have to guard against malformed input. The
+// error is for failures to run the validator.
+func (app *Applet) ValidateConfig(ctx context.Context, config map[string]string) (schema.ConfigErrors, error) {
+	if app.Schema == nil {
+		return nil, nil
+	}
+
+	if errs := app.Schema.ValidateConfig(config); len(errs) > 0 {
+		return errs, nil
+	}
+
+	messages, err := app.CallValidator(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("running validator: %w", err)
+	}
+
+	var errs schema.ConfigErrors
+	for id, msg := range messages {
+		errs = append(errs, schema.ConfigError{Field: id, Message: msg})
+	}
+	sort.Slice(errs, func(i, j int) bool {
+		return errs[i].Field < errs[j].Field
 	})
 
-	for file, globals := range app.Globals {
+	return errs, nil
+}
+
+// MigrateConfig brings a stored config up to date with the app's
+// schema. The config version it was saved with is read from the
+// schema.ConfigVersionKey key, and configs without one are taken to be
+// version 1. If it's older than the schema's config version, the app's
+// migrate handler is called and the config it returns is stamped with
+// the current version. Otherwise, the config is returned unchanged.
+func (app *Applet) MigrateConfig(ctx context.Context, config map[string]string) (map[string]string, error) {
+	if app.Schema == nil || app.Schema.Migrate == "" {
+		return config, nil
+	}
+
+	fromVersion := 1
+	if v, ok := config[schema.ConfigVersionKey]; ok {
+		var err error
+		fromVersion, err = strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("malformed config version: %q", v)
+		}
+	}
+	if fromVersion >= app.Schema.ConfigVersion {
+		return config, nil
+	}
+
+	stored := make(map[string]string, len(config))
+	for k, v := range config {
+		if k != schema.ConfigVersionKey {
+			stored[k] = v
+		}
+	}
+
+	param, err := json.Marshal(map[string]interface{}{
+		"config":       stored,
+		"from_version": fromVersion,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("encoding config: %w", err)
+	}
+
+	result, err := app.CallSchemaHandler(ctx, app.Schema.Migrate, string(param))
+	if err != nil {
+		return nil, err
+	}
+
+	migrated := map[string]string{}
+	if err := json.Unmarshal([]byte(result), &migrated); err != nil {
+		return nil, fmt.Errorf("decoding migrated config: %w", err)
+	}
+	migrated[schema.ConfigVersionKey] = strconv.Itoa(app.Schema.ConfigVersion)
+
+	return migrated, nil
+}
+
+// TestFunc is a test function defined in the applet source, that is,
+// any function whose name starts with test_.
+type TestFunc struct {
+	File string
+	Name string
+
+	fun *starlark.Function
+}
+
+// TestFuncs returns the applet's test functions, sorted by file and
+// name.
+func (a *Applet) TestFuncs() []TestFunc {
+	tests := []TestFunc{}
+	for file, globals := range a.Globals {
 		for name, global := range globals {
 			if !strings.HasPrefix(name, "test_") {
 				continue
 			}
 
 			if fun, ok := global.(*starlark.Function); ok {
-				t.Run(fmt.Sprintf("%s/%s", file, name), func(t *testing.T) {
-					if _, err := app.Call(context.Background(), fun); err != nil {
-						t.Error(err)
-					}
-				})
+				tests = append(tests, TestFunc{File: file, Name: name, fun: fun})
 			}
 		}
 	}
+
+	sort.Slice(tests, func(i, j int) bool {
+		if tests[i].File != tests[j].File {
+			return tests[i].File < tests[j].File
+		}
+		return tests[i].Name < tests[j].Name
+	})
+
+	return tests
+}
+
+// RunTest runs a single test function. Failed assertions are reported
+// to reporter, while errors that stop the test are returned.
+func (a *Applet) RunTest(ctx context.Context, test TestFunc, reporter starlarktest.Reporter) error {
+	_, err := a.call(ctx, test.fun, func(thread *starlark.Thread) *starlark.Thread {
+		starlarktest.SetReporter(thread, reporter)
+		return thread
+	})
+	return err
+}
+
+// RunTests runs all test functions that are defined in the applet source.
+func (app *Applet) RunTests(t *testing.T) {
+	for _, test := range app.TestFuncs() {
+		test := test
+		t.Run(fmt.Sprintf("%s/%s", test.File, test.Name), func(t *testing.T) {
+			if err := app.RunTest(context.Background(), test, t); err != nil {
+				t.Error(err)
+			}
+		})
+	}
 }
 
 // Calls any callable from Applet.Globals. Pass args and receive a
 // starlark Value, or an error if you're unlucky.
 func (a *Applet) Call(ctx context.Context, callable *starlark.Function, args ...starlark.Value) (val starlark.Value, err error) {
+	return a.call(ctx, callable, nil, args...)
+}
+
+// call is Call, with an extra initializer for the thread, if init isn't
+// nil.
+func (a *Applet) call(ctx context.Context, callable *starlark.Function, init ThreadInitializer, args ...starlark.Value) (val starlark.Value, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			err = fmt.Errorf("panic while running %s: %v", a.ID, r)
 		}
 	}()
 
+	ctx, done := a.limits.limitContext(ctx)
+	defer done()
+
 	t := a.newThread(ctx)
+	if init != nil {
+		t = init(t)
+	}
 	defer starlarkutil.RunOnExitFuncs(t)
 
 	context.AfterFunc(ctx, func() {
@@ -330,6 +684,10 @@ func (a *Applet) PathsForBundle() []string {
 }
 
 func (a *Applet) load(fsys fs.FS) (err error) {
+	if err := a.loadSecretsManifest(fsys); err != nil {
+		return err
+	}
+
 	// list files in the root directory of fsys
 	rootDir, err := fs.ReadDir(fsys, ".")
 	if err != nil {
@@ -381,14 +739,11 @@ func (a *Applet) ensureLoaded(fsys fs.FS, pathToLoad string, currentlyLoading ..
 		a.loadedPaths[pathToLoad] = true
 	}
 
-	src, err := fs.ReadFile(fsys, pathToLoad)
-	if err != nil {
+	if _, err := fs.Stat(fsys, pathToLoad); err != nil {
 		return fmt.Errorf("reading %s: %v", pathToLoad, err)
 	}
 
-	predeclared := starlark.StringDict{
-		"struct": starlark.NewBuiltin("struct", starlarkstruct.Make),
-	}
+	predeclared := a.predeclaredGlobals()
 
 	thread := a.newThread(context.Background())
 	defer starlarkutil.RunOnExitFuncs(thread)
@@ -413,22 +768,26 @@ func (a *Applet) ensureLoaded(fsys fs.FS, pathToLoad string, currentlyLoading ..
 			}
 		}
 
+		// then assets kept elsewhere
+		if g, err := a.loadAsset(starlarkutil.ThreadContext(thread), modulePath); err == nil {
+			return g, nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+
 		// fallback to default loader
 		return a.loadModule(thread, module)
 	}
 
 	switch path.Ext(pathToLoad) {
 	case ".star":
-		globals, err := starlark.ExecFileOptions(
-			&syntax.FileOptions{
-				Set:       true,
-				Recursion: true,
-			},
-			thread,
-			path.Join(a.ID, pathToLoad),
-			src,
-			predeclared,
-		)
+		prog, err := a.compile(fsys, pathToLoad, predeclared)
+		if err != nil {
+			return fmt.Errorf("starlark.ExecFile: %v", err)
+		}
+
+		globals, err := prog.Init(thread, predeclared)
+		globals.Freeze()
 		if err != nil {
 			return fmt.Errorf("starlark.ExecFile: %v", err)
 		}
@@ -481,17 +840,78 @@ func (a *Applet) ensureLoaded(fsys fs.FS, pathToLoad string, currentlyLoading ..
 	return nil
 }
 
+// predeclaredGlobals returns what's predeclared in every file of the
+// app.
+func (a *Applet) predeclaredGlobals() starlark.StringDict {
+	predeclared := starlark.StringDict{
+		"struct": starlark.NewBuiltin("struct", starlarkstruct.Make),
+	}
+	for name, value := range a.predeclared {
+		predeclared[name] = value
+	}
+	return predeclared
+}
+
+// compile returns the compiled program for a Starlark file, compiling
+// it unless it came with a snapshot.
+func (a *Applet) compile(fsys fs.FS, pathToLoad string, predeclared starlark.StringDict) (*starlark.Program, error) {
+	if prog, ok := a.programs[pathToLoad]; ok {
+		return prog, nil
+	}
+
+	src, err := fs.ReadFile(fsys, pathToLoad)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %v", pathToLoad, err)
+	}
+
+	_, prog, err := starlark.SourceProgramOptions(
+		&syntax.FileOptions{
+			Set:       true,
+			Recursion: true,
+		},
+		path.Join(a.ID, pathToLoad),
+		src,
+		predeclared.Has,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	a.programs[pathToLoad] = prog
+	return prog, nil
+}
+
+// log returns the app's logger, tagged with its ID.
+func (a *Applet) log() *slog.Logger {
+	logger := a.logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return logger.With("app", a.ID)
+}
+
 func (a *Applet) newThread(ctx context.Context) *starlark.Thread {
+	logger := a.log()
+
 	t := &starlark.Thread{
 		Name: a.ID,
 		Load: a.loadModule,
 		Print: func(thread *starlark.Thread, msg string) {
-			fmt.Printf("[%s] %s\n", a.ID, msg)
+			if a.logger != nil {
+				logger.InfoContext(ctx, msg)
+			} else {
+				fmt.Printf("[%s] %s\n", a.ID, msg)
+			}
 		},
 	}
 
 	starlarkutil.AttachThreadContext(ctx, t)
+	starlarkutil.AttachThreadLogger(logger, t)
 	random.AttachToThread(t)
+	scratch.AttachToThread(t)
+	a.attachSecrets(t)
+	a.attachPolicy(t)
+	yieldPeriodically(t)
 
 	for _, init := range a.initializers {
 		t = init(t)
@@ -500,7 +920,48 @@ func (a *Applet) newThread(ctx context.Context) *starlark.Thread {
 	return t
 }
 
+// Modules are the modules that apps can load, besides their own files.
+// Keep this in sync with loadModule.
+var Modules = []string{
+	"animation.star",
+	"assert.star",
+	"bsoup.star",
+	"cache.star",
+	"compress/gzip.star",
+	"compress/zipfile.star",
+	"device.star",
+	"encoding/base64.star",
+	"encoding/csv.star",
+	"encoding/json.star",
+	"hash.star",
+	"hmac.star",
+	"html.star",
+	"http.star",
+	"humanize.star",
+	"math.star",
+	"qrcode.star",
+	"random.star",
+	"re.star",
+	"render.star",
+	"schema.star",
+	"scratch.star",
+	"secret.star",
+	"sunrise.star",
+	"time.star",
+	"xpath.star",
+}
+
+// LoadModule loads one of Modules as apps see it, for tools that need to
+// know what the modules contain.
+func LoadModule(module string) (starlark.StringDict, error) {
+	return (&Applet{}).loadModule(nil, module)
+}
+
 func (a *Applet) loadModule(thread *starlark.Thread, module string) (starlark.StringDict, error) {
+	if err := a.policy.checkModule(module); err != nil {
+		return nil, err
+	}
+
 	if a.loader != nil {
 		mod, err := a.loader(thread, module)
 		if err == nil {
@@ -593,6 +1054,12 @@ func (a *Applet) loadModule(thread *starlark.Thread, module string) (starlark.St
 	case "random.star":
 		return random.LoadModule()
 
+	case "scratch.star":
+		return scratch.LoadModule()
+
+	case "device.star":
+		return device.LoadModule()
+
 	case "qrcode.star":
 		return qrcode.LoadModule()
 
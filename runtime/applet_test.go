@@ -6,15 +6,20 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"testing"
 	"testing/fstest"
 
 	starlibbase64 "github.com/qri-io/starlib/encoding/base64"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"go.starlark.net/starlark"
 
 	"tidbyt.dev/pixlet/schema"
+	"tidbyt.dev/pixlet/tracing"
 )
 
 func TestLoadEmptySrc(t *testing.T) {
@@ -126,6 +131,51 @@ def main():
 	assert.NotNil(t, screens)
 }
 
+func TestRunRecordsSpans(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)))
+	defer otel.SetTracerProvider(prev)
+
+	src := `
+load("render.star", "render")
+def main():
+    return render.Root(child=render.Box())
+`
+	app, err := NewApplet("test.star", []byte(src))
+	require.NoError(t, err)
+	_, err = app.Run(context.Background())
+	require.NoError(t, err)
+
+	spans := sr.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "pixlet.load", spans[0].Name())
+	assert.Equal(t, "pixlet.main", spans[1].Name())
+	for _, span := range spans {
+		assert.Contains(t, span.Attributes(), tracing.AppletID("test.star"))
+	}
+}
+
+func TestRunLogs(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	src := `
+load("render.star", "render")
+def main():
+    print("hello")
+    return render.Root(child=render.Box())
+`
+	app, err := NewApplet("test.star", []byte(src), WithLogger(logger))
+	require.NoError(t, err)
+	_, err = app.Run(context.Background())
+	require.NoError(t, err)
+
+	// prints and runs are logged, tagged with the app
+	assert.Contains(t, buf.String(), "level=INFO msg=hello app=test.star")
+	assert.Contains(t, buf.String(), "level=DEBUG msg=ran app=test.star")
+}
+
 func TestRunMainAcceptsConfig(t *testing.T) {
 	config := map[string]string{
 		"one":     "1",
@@ -279,6 +329,14 @@ def main():
 	assert.Equal(t, 1, len(roots))
 }
 
+func TestModulesLoad(t *testing.T) {
+	app := &Applet{}
+	for _, module := range Modules {
+		_, err := app.loadModule(nil, module)
+		assert.NoError(t, err, module)
+	}
+}
+
 func TestDependency(t *testing.T) {
 	// src.star depends on hello.star
 	src := `
@@ -453,4 +511,60 @@ def main():
 	app.RunTests(t)
 }
 
+type errorCollector struct {
+	errors []string
+}
+
+func (c *errorCollector) Error(args ...interface{}) {
+	c.errors = append(c.errors, fmt.Sprint(args...))
+}
+
+func TestRunTest(t *testing.T) {
+	src := `
+load("assert.star", "assert")
+
+def test_passes():
+	assert.eq(answer(), 42)
+
+def test_fails():
+	assert.eq(answer(), 41)
+	assert.true(False)
+
+def test_errors():
+	fail("oh no")
+
+def helper():
+	pass
+
+def main():
+	pass
+`
+
+	app, err := NewApplet("test.star", []byte(src), WithPredeclared(starlark.StringDict{
+		"answer": starlark.NewBuiltin("answer", func(*starlark.Thread, *starlark.Builtin, starlark.Tuple, []starlark.Tuple) (starlark.Value, error) {
+			return starlark.MakeInt(42), nil
+		}),
+	}))
+	require.NoError(t, err)
+
+	tests := app.TestFuncs()
+	require.Len(t, tests, 3)
+	assert.Equal(t, "test_errors", tests[0].Name)
+	assert.Equal(t, "test_fails", tests[1].Name)
+	assert.Equal(t, "test_passes", tests[2].Name)
+	assert.Equal(t, "test.star", tests[0].File)
+
+	c := &errorCollector{}
+	assert.Error(t, app.RunTest(context.Background(), tests[0], c))
+	assert.Empty(t, c.errors)
+
+	c = &errorCollector{}
+	assert.NoError(t, app.RunTest(context.Background(), tests[1], c))
+	assert.Len(t, c.errors, 2)
+
+	c = &errorCollector{}
+	assert.NoError(t, app.RunTest(context.Background(), tests[2], c))
+	assert.Empty(t, c.errors)
+}
+
 // TODO: test Screens, especially Screens.Render()
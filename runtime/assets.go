@@ -0,0 +1,200 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"testing/fstest"
+	"time"
+
+	"go.starlark.net/starlark"
+
+	"tidbyt.dev/pixlet/runtime/modules/file"
+)
+
+const (
+	// DefaultMaxAssetSize is the largest asset HTTPAssetLoader loads,
+	// unless told otherwise.
+	DefaultMaxAssetSize = 10 << 20
+
+	// DefaultAssetTTL is how long HTTPAssetLoader caches assets, unless
+	// told otherwise.
+	DefaultAssetTTL = time.Hour
+)
+
+// AssetLoader loads files that apps load, but that aren't among their
+// files, like images kept in object storage or on a CDN. This lets
+// hosts keep large assets out of bundles. Apps load these assets the
+// same way as their own files:
+//
+//	load("images/background.png", background = "file")
+type AssetLoader interface {
+	// LoadAsset returns the contents of the asset at path, which is
+	// relative to the app. It returns an error wrapping fs.ErrNotExist
+	// if there's no such asset.
+	LoadAsset(ctx context.Context, appID, path string) ([]byte, error)
+}
+
+// WithAssetLoader loads files the app loads from loader, when they
+// aren't among the app's files. Starlark files are never loaded from
+// it.
+func WithAssetLoader(loader AssetLoader) AppletOption {
+	return func(a *Applet) error {
+		a.assets = loader
+		return nil
+	}
+}
+
+// loadAsset loads an asset with the app's asset loader, and returns it
+// as a module like the app's own files. It returns an error wrapping
+// fs.ErrNotExist if there's no such asset.
+func (a *Applet) loadAsset(ctx context.Context, assetPath string) (starlark.StringDict, error) {
+	if a.assets == nil || path.Ext(assetPath) == ".star" {
+		return nil, fs.ErrNotExist
+	}
+
+	if g, ok := a.Globals[assetPath]; ok {
+		return g, nil
+	}
+
+	data, err := a.assets.LoadAsset(ctx, a.ID, assetPath)
+	if err != nil {
+		return nil, err
+	}
+
+	g := starlark.StringDict{
+		"file": &file.File{
+			FS:   fstest.MapFS{assetPath: &fstest.MapFile{Data: data}},
+			Path: assetPath,
+		},
+	}
+	a.Globals[assetPath] = g
+
+	return g, nil
+}
+
+// HTTPAssetLoader loads assets over HTTP. The asset at path for the app
+// with ID id is fetched from BaseURL/id/path.
+type HTTPAssetLoader struct {
+	// BaseURL is where the assets of all apps are.
+	BaseURL string
+
+	// Client makes the requests, or http.DefaultClient if nil.
+	Client *http.Client
+
+	// MaxSize is the largest asset that's loaded, in bytes. If zero,
+	// it's DefaultMaxAssetSize.
+	MaxSize int64
+
+	// Cache caches assets for TTL, if set. If TTL is zero, it's
+	// DefaultAssetTTL.
+	Cache Cache
+	TTL   time.Duration
+}
+
+// NewHTTPAssetLoader returns a loader for the assets at base. Besides
+// HTTP and HTTPS URLs, base can be an S3 bucket as s3://bucket/prefix,
+// or a Google Cloud Storage bucket as gs://bucket/prefix. Buckets are
+// read through their public HTTPS endpoints, so the assets must be
+// readable by anyone. Hosts with private buckets can implement
+// AssetLoader with their cloud provider's SDK instead.
+func NewHTTPAssetLoader(base string) (*HTTPAssetLoader, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, fmt.Errorf("parsing asset URL: %w", err)
+	}
+
+	if u.Host == "" {
+		return nil, fmt.Errorf("asset URL has no host or bucket: %s", base)
+	}
+
+	prefix := strings.Trim(u.Path, "/")
+	switch u.Scheme {
+	case "http", "https":
+	case "s3":
+		u = &url.URL{Scheme: "https", Host: u.Host + ".s3.amazonaws.com", Path: "/" + prefix}
+	case "gs":
+		u = &url.URL{Scheme: "https", Host: "storage.googleapis.com", Path: path.Join("/", u.Host, prefix)}
+	default:
+		return nil, fmt.Errorf("unsupported asset URL: %s", base)
+	}
+
+	return &HTTPAssetLoader{BaseURL: u.String()}, nil
+}
+
+// LoadAsset implements AssetLoader.
+func (l *HTTPAssetLoader) LoadAsset(ctx context.Context, appID, assetPath string) ([]byte, error) {
+	u := strings.TrimSuffix(l.BaseURL, "/") + "/" + url.PathEscape(appID) + "/" + escapePath(assetPath)
+
+	key := "asset:" + u
+	if l.Cache != nil {
+		if data, found, err := l.Cache.Get(nil, key); err == nil && found {
+			return data, nil
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	client := l.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("loading asset %s: %w", assetPath, err)
+	}
+	defer resp.Body.Close()
+
+	// buckets answer 403 for objects that don't exist, unless listing
+	// them is allowed
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden {
+		return nil, fmt.Errorf("loading asset %s: %s: %w", assetPath, resp.Status, fs.ErrNotExist)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("loading asset %s: %s", assetPath, resp.Status)
+	}
+
+	max := l.MaxSize
+	if max <= 0 {
+		max = DefaultMaxAssetSize
+	}
+	if resp.ContentLength > max {
+		return nil, fmt.Errorf("asset %s is %d bytes, more than the limit of %d", assetPath, resp.ContentLength, max)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, max+1))
+	if err != nil {
+		return nil, fmt.Errorf("loading asset %s: %w", assetPath, err)
+	}
+	if int64(len(data)) > max {
+		return nil, fmt.Errorf("asset %s is more than the limit of %d bytes", assetPath, max)
+	}
+
+	if l.Cache != nil {
+		ttl := l.TTL
+		if ttl <= 0 {
+			ttl = DefaultAssetTTL
+		}
+		l.Cache.Set(nil, key, data, int64(ttl.Seconds()))
+	}
+
+	return data, nil
+}
+
+// escapePath escapes each element of a slash separated path.
+func escapePath(p string) string {
+	parts := strings.Split(p, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,136 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// assetServer serves assets from files, and counts the requests it gets.
+func assetServer(t *testing.T, files map[string]string) (*httptest.Server, *int) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		data, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+const assetApp = `
+load("images/big image.png", big = "file")
+load("local.txt", local = "file")
+
+def main():
+    if big.readall() != "remote":
+        fail("unexpected asset: %s" % big.readall())
+    if local.readall() != "local":
+        fail("unexpected file: %s" % local.readall())
+    return []
+`
+
+func TestAssetLoader(t *testing.T) {
+	srv, requests := assetServer(t, map[string]string{
+		"/assets/test-app/images/big image.png": "remote",
+		"/assets/test-app/local.txt":            "shadowed",
+	})
+
+	loader, err := NewHTTPAssetLoader(srv.URL + "/assets/")
+	require.NoError(t, err)
+	loader.Cache = NewInMemoryCache()
+
+	vfs := fstest.MapFS{
+		"main.star": {Data: []byte(assetApp)},
+		"local.txt": {Data: []byte("local")},
+	}
+
+	for i := 0; i < 2; i++ {
+		app, err := NewAppletFromFS("test-app", vfs, WithAssetLoader(loader))
+		require.NoError(t, err)
+		_, err = app.Run(context.Background())
+		require.NoError(t, err)
+	}
+
+	// the app's own files win, and assets are cached
+	assert.Equal(t, 1, *requests)
+}
+
+func TestAssetLoaderMissing(t *testing.T) {
+	srv, _ := assetServer(t, map[string]string{})
+	loader, err := NewHTTPAssetLoader(srv.URL)
+	require.NoError(t, err)
+
+	_, err = loader.LoadAsset(context.Background(), "test-app", "missing.png")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	vfs := fstest.MapFS{"main.star": {Data: []byte(assetApp)}}
+	_, err = NewAppletFromFS("test-app", vfs, WithAssetLoader(loader))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid module: images/big image.png")
+}
+
+func TestAssetLoaderMaxSize(t *testing.T) {
+	srv, _ := assetServer(t, map[string]string{
+		"/test-app/big.png": strings.Repeat("x", 100),
+	})
+	loader, err := NewHTTPAssetLoader(srv.URL)
+	require.NoError(t, err)
+	loader.MaxSize = 99
+
+	_, err = loader.LoadAsset(context.Background(), "test-app", "big.png")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "more than the limit of 99")
+
+	loader.MaxSize = 100
+	data, err := loader.LoadAsset(context.Background(), "test-app", "big.png")
+	require.NoError(t, err)
+	assert.Len(t, data, 100)
+}
+
+func TestAssetLoaderSkipsStarlark(t *testing.T) {
+	srv, requests := assetServer(t, map[string]string{
+		"/test-app/lib.star": "x = 1",
+	})
+	loader, err := NewHTTPAssetLoader(srv.URL)
+	require.NoError(t, err)
+
+	src := `
+load("lib.star", "x")
+def main():
+    return []
+`
+	_, err = NewApplet("test-app", []byte(src), WithAssetLoader(loader))
+	assert.Error(t, err)
+	assert.Equal(t, 0, *requests)
+}
+
+func TestNewHTTPAssetLoader(t *testing.T) {
+	for base, want := range map[string]string{
+		"https://cdn.example.com/apps/": "https://cdn.example.com/apps/",
+		"s3://my-bucket/apps":           "https://my-bucket.s3.amazonaws.com/apps",
+		"s3://my-bucket":                "https://my-bucket.s3.amazonaws.com/",
+		"gs://my-bucket/apps/":          "https://storage.googleapis.com/my-bucket/apps",
+	} {
+		loader, err := NewHTTPAssetLoader(base)
+		require.NoError(t, err, base)
+		assert.Equal(t, want, loader.BaseURL, base)
+	}
+
+	for _, base := range []string{"ftp://example.com", "s3:///apps", "/local/path"} {
+		_, err := NewHTTPAssetLoader(base)
+		assert.Error(t, err, base)
+	}
+}
@@ -2,12 +2,14 @@ package runtime
 
 import (
 	"fmt"
-	"log"
 	"sync"
 	"time"
 
 	"go.starlark.net/starlark"
 	"go.starlark.net/starlarkstruct"
+
+	"tidbyt.dev/pixlet/metrics"
+	"tidbyt.dev/pixlet/starlarkutil"
 )
 
 const DefaultExpirationSeconds = 60
@@ -112,10 +114,11 @@ func cacheGet(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple,
 
 	if err != nil {
 		// don't fail just because cache is misbehaving
-		log.Printf("getting %s from cache: %v", cacheKey, err)
+		starlarkutil.ThreadLogger(thread).Warn("getting from cache", "key", cacheKey, "error", err)
 		return starlark.None, nil
 	}
 
+	metrics.ObserveCache("app", found)
 	if !found {
 		return starlark.None, nil
 	}
@@ -160,9 +163,13 @@ func cacheSet(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple,
 		return starlark.None, nil
 	}
 
+	if err := policyForThread(thread).reserveCache(cacheKey, len(cacheKey)+len(val), time.Duration(ttl64)*time.Second); err != nil {
+		return nil, fmt.Errorf("cache.set: %w", err)
+	}
+
 	err := cache.Set(thread, cacheKey, []byte(val.GoString()), ttl64)
 	if err != nil {
-		log.Printf("setting %s in cache: %v", cacheKey, err)
+		starlarkutil.ThreadLogger(thread).Warn("setting in cache", "key", cacheKey, "error", err)
 	}
 
 	return starlark.None, nil
@@ -0,0 +1,418 @@
+package runtime
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Cassette holds HTTP requests made by apps and the responses they got,
+// so that they can be replayed later without a network, or without the
+// API keys that were used to make them.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+
+	path string
+	mu   sync.Mutex
+}
+
+// Interaction is a single request in a cassette, with its response.
+type Interaction struct {
+	Request  CassetteRequest  `json:"request"`
+	Response CassetteResponse `json:"response"`
+}
+
+// CassetteRequest is a recorded request. Headers aren't recorded, since
+// they often hold credentials, and neither are the values of query
+// parameters that look like credentials, like key or token, or those
+// of form and JSON body fields like client_secret or refresh_token.
+type CassetteRequest struct {
+	Method string        `json:"method"`
+	URL    string        `json:"url"`
+	Body   *CassetteBody `json:"body,omitempty"`
+}
+
+// CassetteResponse is a recorded response. Headers that set cookies or
+// hold credentials aren't recorded, and neither are the values of
+// credentials in form and JSON bodies, like access_token.
+type CassetteResponse struct {
+	Status  int                 `json:"status"`
+	Headers map[string][]string `json:"headers,omitempty"`
+	Body    *CassetteBody       `json:"body,omitempty"`
+}
+
+// CassetteBody is a recorded body. Text is kept as is, so that cassettes
+// can be read and edited, and anything else is base64 encoded.
+type CassetteBody struct {
+	Text   string `json:"text,omitempty"`
+	Base64 string `json:"base64,omitempty"`
+}
+
+// LoadCassette reads the cassette at path. If it doesn't exist, an empty
+// cassette is returned, which is written to path when recording.
+func LoadCassette(path string) (*Cassette, error) {
+	c := &Cassette{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading cassette: %w", err)
+	}
+
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("parsing cassette %s: %w", path, err)
+	}
+
+	return c, nil
+}
+
+// Save writes the cassette to the path it was loaded from.
+func (c *Cassette) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.save()
+}
+
+func (c *Cassette) save() error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first, so that a crash doesn't leave a
+	// cassette half written
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("writing cassette: %w", err)
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// record adds an interaction, replacing an earlier one for the same
+// request, and saves the cassette.
+func (c *Cassette) record(i *Interaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for j, existing := range c.Interactions {
+		if existing.Request.equal(i.Request) {
+			c.Interactions[j] = i
+			return c.save()
+		}
+	}
+
+	c.Interactions = append(c.Interactions, i)
+	return c.save()
+}
+
+// find returns the interaction for a request. Requests match exactly on
+// method, URL and body. Failing that, a request matches if it has the
+// same method, body and URL except for the values of query parameters,
+// which are often API keys that differ between recording and replay.
+func (c *Cassette) find(req CassetteRequest) *Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, i := range c.Interactions {
+		if i.Request.equal(req) {
+			return i
+		}
+	}
+
+	for _, i := range c.Interactions {
+		if i.Request.similar(req) {
+			return i
+		}
+	}
+
+	return nil
+}
+
+func (r CassetteRequest) equal(other CassetteRequest) bool {
+	return r.Method == other.Method && r.URL == other.URL && r.Body.equal(other.Body)
+}
+
+func (r CassetteRequest) similar(other CassetteRequest) bool {
+	if r.Method != other.Method || !r.Body.equal(other.Body) {
+		return false
+	}
+
+	a, err := url.Parse(r.URL)
+	if err != nil {
+		return false
+	}
+	b, err := url.Parse(other.URL)
+	if err != nil {
+		return false
+	}
+
+	return a.Scheme == b.Scheme && a.Host == b.Host && a.Path == b.Path &&
+		queryKeys(a.Query()) == queryKeys(b.Query())
+}
+
+func queryKeys(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, "&")
+}
+
+func (b *CassetteBody) equal(other *CassetteBody) bool {
+	if b == nil || other == nil {
+		return b == other
+	}
+	return *b == *other
+}
+
+func newCassetteBody(data []byte) *CassetteBody {
+	if len(data) == 0 {
+		return nil
+	}
+	if utf8.Valid(data) {
+		return &CassetteBody{Text: string(data)}
+	}
+	return &CassetteBody{Base64: base64.StdEncoding.EncodeToString(data)}
+}
+
+func (b *CassetteBody) bytes() ([]byte, error) {
+	if b == nil {
+		return nil, nil
+	}
+	if b.Base64 != "" {
+		return base64.StdEncoding.DecodeString(b.Base64)
+	}
+	return []byte(b.Text), nil
+}
+
+// cassetteRequest reads req into its recorded form. The body is read and
+// replaced, so that req can still be sent.
+func cassetteRequest(req *http.Request) (CassetteRequest, error) {
+	r := CassetteRequest{Method: req.Method, URL: redactURL(req.URL)}
+
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return r, fmt.Errorf("reading request body: %w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		r.Body = newCassetteBody(redactBody(req.Header.Get("Content-Type"), body))
+	}
+
+	return r, nil
+}
+
+// sensitiveParams are parts of query parameter names that suggest the
+// value is a credential.
+var sensitiveParams = []string{"key", "token", "secret", "password", "auth", "sig", "appid"}
+
+// redactURL returns u with the values of sensitive query parameters
+// replaced.
+func redactURL(u *url.URL) string {
+	q := u.Query()
+	redacted := false
+	for name := range q {
+		lower := strings.ToLower(name)
+		for _, s := range sensitiveParams {
+			if strings.Contains(lower, s) {
+				q.Set(name, "REDACTED")
+				redacted = true
+				break
+			}
+		}
+	}
+
+	if !redacted {
+		return u.String()
+	}
+
+	r := *u
+	r.RawQuery = q.Encode()
+	return r.String()
+}
+
+// sensitiveFields are the names of form and JSON body fields whose
+// values are credentials. Unlike query parameters, body fields are
+// matched exactly, since JSON responses often have fields like author
+// or key that aren't credentials.
+var sensitiveFields = map[string]bool{
+	"access_token":  true,
+	"api_key":       true,
+	"apikey":        true,
+	"client_secret": true,
+	"code_verifier": true,
+	"id_token":      true,
+	"password":      true,
+	"refresh_token": true,
+	"secret":        true,
+	"token":         true,
+}
+
+// sensitiveHeaders are response headers that aren't recorded, since they
+// hold credentials.
+var sensitiveHeaders = []string{"Set-Cookie", "Set-Cookie2", "Authorization", "Proxy-Authorization"}
+
+// redactBody returns body with the values of sensitive fields replaced,
+// if it's a form or JSON. Other bodies, and bodies without sensitive
+// fields, are returned as is.
+func redactBody(contentType string, body []byte) []byte {
+	switch {
+	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+		form, err := url.ParseQuery(string(body))
+		if err != nil || !redactForm(form) {
+			return body
+		}
+		return []byte(form.Encode())
+
+	case strings.Contains(contentType, "json"):
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		var v interface{}
+		if err := dec.Decode(&v); err != nil || !redactJSON(v) {
+			return body
+		}
+		redacted, err := json.Marshal(v)
+		if err != nil {
+			return body
+		}
+		return redacted
+	}
+
+	return body
+}
+
+func redactForm(form url.Values) bool {
+	redacted := false
+	for name := range form {
+		if sensitiveFields[strings.ToLower(name)] {
+			form.Set(name, "REDACTED")
+			redacted = true
+		}
+	}
+	return redacted
+}
+
+// redactJSON replaces the values of sensitive fields in the objects in
+// v, however deeply nested, and reports whether there were any.
+func redactJSON(v interface{}) bool {
+	redacted := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, val := range v {
+			if sensitiveFields[strings.ToLower(k)] {
+				v[k] = "REDACTED"
+				redacted = true
+			} else if redactJSON(val) {
+				redacted = true
+			}
+		}
+	case []interface{}:
+		for _, val := range v {
+			if redactJSON(val) {
+				redacted = true
+			}
+		}
+	}
+	return redacted
+}
+
+// RecordingTransport sends requests with next, and records them and their
+// responses to the cassette.
+func RecordingTransport(c *Cassette, next http.RoundTripper) http.RoundTripper {
+	return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		r, err := cassetteRequest(req)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading response body: %w", err)
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+
+		headers := resp.Header.Clone()
+		for _, h := range sensitiveHeaders {
+			headers.Del(h)
+		}
+
+		err = c.record(&Interaction{
+			Request: r,
+			Response: CassetteResponse{
+				Status:  resp.StatusCode,
+				Headers: headers,
+				Body:    newCassetteBody(redactBody(resp.Header.Get("Content-Type"), body)),
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return resp, nil
+	})
+}
+
+// ReplayingTransport answers requests with the responses recorded in the
+// cassette, and fails requests that weren't recorded.
+func ReplayingTransport(c *Cassette) http.RoundTripper {
+	return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		r, err := cassetteRequest(req)
+		if err != nil {
+			return nil, err
+		}
+
+		i := c.find(r)
+		if i == nil {
+			return nil, fmt.Errorf("no response recorded for %s %s", req.Method, req.URL.Redacted())
+		}
+
+		body, err := i.Response.Body.bytes()
+		if err != nil {
+			return nil, fmt.Errorf("decoding recorded response: %w", err)
+		}
+
+		header := http.Header{}
+		for k, v := range i.Response.Headers {
+			header[k] = append([]string{}, v...)
+		}
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", i.Response.Status, http.StatusText(i.Response.Status)),
+			StatusCode:    i.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	})
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,164 @@
+package runtime
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCassetteRecordAndReplay(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"path": %q, "body": %q}`, r.URL.Path, body)
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	c, err := LoadCassette(path)
+	require.NoError(t, err)
+	recorder := &http.Client{Transport: RecordingTransport(c, http.DefaultTransport)}
+
+	resp, err := recorder.Get(srv.URL + "/weather?city=oslo&api_key=hunter2")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, `{"path": "/weather", "body": ""}`, string(body))
+
+	resp, err = recorder.Post(srv.URL+"/search", "text/plain", strings.NewReader("trains"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 2, calls)
+
+	// credentials in the query aren't written to the cassette
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "hunter2")
+
+	c, err = LoadCassette(path)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(c.Interactions))
+	replayer := &http.Client{Transport: ReplayingTransport(c)}
+
+	// replayed with another key, as in CI
+	resp, err = replayer.Get(srv.URL + "/weather?city=oslo&api_key=other")
+	require.NoError(t, err)
+	body, _ = io.ReadAll(resp.Body)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.Equal(t, `{"path": "/weather", "body": ""}`, string(body))
+
+	resp, err = replayer.Post(srv.URL+"/search", "text/plain", strings.NewReader("trains"))
+	require.NoError(t, err)
+	body, _ = io.ReadAll(resp.Body)
+	assert.Equal(t, `{"path": "/search", "body": "trains"}`, string(body))
+	assert.Equal(t, 2, calls)
+
+	// requests that weren't recorded fail
+	_, err = replayer.Post(srv.URL+"/search", "text/plain", strings.NewReader("buses"))
+	assert.ErrorContains(t, err, "no response recorded for POST")
+	_, err = replayer.Get(srv.URL + "/weather?city=bergen&api_key=hunter2&units=metric")
+	assert.ErrorContains(t, err, "no response recorded for GET")
+}
+
+func TestCassetteRecordReplacesRequest(t *testing.T) {
+	n := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		fmt.Fprintf(w, "%d", n)
+	}))
+	defer srv.Close()
+
+	c, err := LoadCassette(filepath.Join(t.TempDir(), "cassette.json"))
+	require.NoError(t, err)
+	recorder := &http.Client{Transport: RecordingTransport(c, http.DefaultTransport)}
+
+	for i := 0; i < 2; i++ {
+		resp, err := recorder.Get(srv.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	require.Equal(t, 1, len(c.Interactions))
+	assert.Equal(t, "2", c.Interactions[0].Response.Body.Text)
+}
+
+func TestCassetteBinaryBody(t *testing.T) {
+	body := newCassetteBody([]byte{0xff, 0x00, 0x89})
+	assert.Equal(t, "", body.Text)
+	assert.Equal(t, "/wCJ", body.Base64)
+
+	data, err := body.bytes()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xff, 0x00, 0x89}, data)
+}
+
+func TestCassetteRedactsCredentials(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "cookie-secret"})
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "access-secret", "refresh_token": "refresh-secret", "expires_in": 3600, "author": "someone"}`)
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	c, err := LoadCassette(path)
+	require.NoError(t, err)
+	recorder := &http.Client{Transport: RecordingTransport(c, http.DefaultTransport)}
+
+	form := "grant_type=refresh_token&client_id=pixlet&client_secret=client-secret&refresh_token=old-refresh"
+	resp, err := recorder.Post(srv.URL+"/token", "application/x-www-form-urlencoded", strings.NewReader(form))
+	require.NoError(t, err)
+
+	// the app still gets the real response
+	body, _ := io.ReadAll(resp.Body)
+	assert.Contains(t, string(body), "access-secret")
+	assert.NotEmpty(t, resp.Header.Get("Set-Cookie"))
+
+	resp, err = recorder.Post(srv.URL+"/login", "application/json", strings.NewReader(`{"user": {"name": "someone", "password": "password-secret"}}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	for _, secret := range []string{"client-secret", "old-refresh", "access-secret", "refresh-secret", "cookie-secret", "password-secret"} {
+		assert.NotContains(t, string(data), secret)
+	}
+	assert.Contains(t, string(data), "someone")
+
+	// replaying with other credentials finds the recorded responses
+	c, err = LoadCassette(path)
+	require.NoError(t, err)
+	replayer := &http.Client{Transport: ReplayingTransport(c)}
+
+	form = "grant_type=refresh_token&client_id=pixlet&client_secret=other&refresh_token=other"
+	resp, err = replayer.Post(srv.URL+"/token", "application/x-www-form-urlencoded", strings.NewReader(form))
+	require.NoError(t, err)
+	body, _ = io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{"access_token": "REDACTED", "refresh_token": "REDACTED", "expires_in": 3600, "author": "someone"}`, string(body))
+	assert.Empty(t, resp.Header.Get("Set-Cookie"))
+
+	resp, err = replayer.Post(srv.URL+"/login", "application/json", strings.NewReader(`{"user": {"name": "someone", "password": "other"}}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+}
+
+func TestRedactBody(t *testing.T) {
+	// bodies without credentials are kept as they were sent
+	assert.Equal(t, `{"b": 1,  "a": 2}`, string(redactBody("application/json", []byte(`{"b": 1,  "a": 2}`))))
+	assert.Equal(t, "token=abc", string(redactBody("text/plain", []byte("token=abc"))))
+	assert.Equal(t, "not json", string(redactBody("application/json", []byte("not json"))))
+
+	assert.Equal(t, `[{"Token":"REDACTED","n":12345678901234567890}]`,
+		string(redactBody("application/json; charset=utf-8", []byte(`[{"Token": "abc", "n": 12345678901234567890}]`))))
+}
@@ -48,6 +48,9 @@ type Package struct {
 	CodePath       string
 	DocTemplate    string
 	DocPath        string
+	LSPTemplate    string
+	LSPPath        string
+	Module         string
 	GoRootName     string
 	GoWidgetName   string
 	Types          []reflect.Value
@@ -64,6 +67,9 @@ var Packages = []Package{
 		CodePath:       "./runtime/modules/render_runtime/generated.go",
 		DocTemplate:    "./runtime/gen/docs/render.tmpl",
 		DocPath:        "./docs/widgets.md",
+		LSPTemplate:    "./runtime/gen/lsp.tmpl",
+		LSPPath:        "./tools/lsp/render_widgets.go",
+		Module:         "render.star",
 		GoRootName:     "Root",
 		GoWidgetName:   "Widget",
 		Types: []reflect.Value{
@@ -93,6 +99,9 @@ var Packages = []Package{
 		CodePath:       "./runtime/modules/animation_runtime/generated.go",
 		DocTemplate:    "./runtime/gen/docs/animation.tmpl",
 		DocPath:        "./docs/animation.md",
+		LSPTemplate:    "./runtime/gen/lsp.tmpl",
+		LSPPath:        "./tools/lsp/animation_widgets.go",
+		Module:         "animation.star",
 		GoRootName:     "render_runtime.Root",
 		GoWidgetName:   "render_runtime.Widget",
 		Types: []reflect.Value{
@@ -183,6 +192,13 @@ var TypeMap = map[reflect.Type]Type{
 		GenerateField: true,
 	},
 
+	// Render `Text` types
+	toDecayedType(new([]string)): {
+		GoType:       "starlark.Value",
+		DocType:      `[str]`,
+		TemplatePath: "./runtime/gen/attr/strings.tmpl",
+	},
+
 	// Render `Plot` types`
 	toDecayedType(new([2]float64)): {
 		GoType:       "starlark.Tuple",
@@ -518,6 +534,22 @@ func generateDocs(pkg Package, types []*GeneratedType) {
 	renderTemplateToFile(template, types, pkg.DocPath)
 }
 
+// generateLSP writes the widgets' docs and attributes for the language
+// server, so it can complete and explain them.
+func generateLSP(pkg Package, types []*GeneratedType) {
+	tmpl := loadTemplate("lsp", pkg.LSPTemplate)
+
+	var buf bytes.Buffer
+	renderTemplateToBuffer(tmpl, struct {
+		Package
+		Types []*GeneratedType
+	}{pkg, types}, &buf)
+
+	source, err := format.Source(buf.Bytes())
+	nilOrPanic(err)
+	nilOrPanic(os.WriteFile(pkg.LSPPath, source, 0644))
+}
+
 func main() {
 	// Generate code and documentation for each package.
 	for _, pkg := range Packages {
@@ -538,5 +570,6 @@ func main() {
 		attachDocs(pkg, types)
 		generateCode(pkg, types)
 		generateDocs(pkg, types)
+		generateLSP(pkg, types)
 	}
 }
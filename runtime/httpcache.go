@@ -14,7 +14,11 @@ import (
 	"strings"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+
+	"tidbyt.dev/pixlet/metrics"
 	"tidbyt.dev/pixlet/runtime/modules/starlarkhttp"
+	"tidbyt.dev/pixlet/tracing"
 )
 
 const (
@@ -49,10 +53,20 @@ type cacheClient struct {
 	transport http.RoundTripper
 }
 
+// httpTransport sends the requests apps make that aren't cached.
+var httpTransport http.RoundTripper = http.DefaultTransport
+
+// SetHTTPTransport changes how the requests apps make are sent, like to
+// record or replay them with a Cassette. It applies from the next call to
+// InitHTTP.
+func SetHTTPTransport(transport http.RoundTripper) {
+	httpTransport = transport
+}
+
 func InitHTTP(cache Cache) {
 	cc := &cacheClient{
 		cache:     cache,
-		transport: http.DefaultTransport,
+		transport: httpTransport,
 	}
 
 	httpClient := &http.Client{
@@ -64,8 +78,21 @@ func InitHTTP(cache Cache) {
 
 // RoundTrip is an approximation of what our internal HTTP proxy does. It should
 // behave the same way, and any discrepancy should be considered a bug.
-func (c *cacheClient) RoundTrip(req *http.Request) (*http.Response, error) {
-	ctx := req.Context()
+func (c *cacheClient) RoundTrip(req *http.Request) (resp *http.Response, err error) {
+	ctx, span := tracing.Start(req.Context(), "pixlet.http",
+		tracing.AppletID(req.Header.Get("X-Tidbyt-App")),
+		attribute.String("http.method", req.Method),
+		attribute.String("http.url", req.URL.Redacted()),
+	)
+	defer func() {
+		if resp != nil {
+			span.SetAttributes(
+				attribute.Int("http.status_code", resp.StatusCode),
+				attribute.String("pixlet.cache_status", resp.Header.Get("tidbyt-cache-status")),
+			)
+		}
+		tracing.End(span, err)
+	}()
 
 	ctx, cancel := context.WithTimeout(ctx, HTTPTimeout)
 	defer cancel() // need to do this to not leak a goroutine
@@ -80,14 +107,20 @@ func (c *cacheClient) RoundTrip(req *http.Request) (*http.Response, error) {
 		if exists && err == nil {
 			if res, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(b)), req); err == nil {
 				res.Header.Set("tidbyt-cache-status", "HIT")
+				metrics.ObserveCache("http", true)
 				return res, nil
 			}
 		}
+		metrics.ObserveCache("http", false)
 	}
 
-	resp, err := c.transport.RoundTrip(req.WithContext(ctx))
+	start := time.Now()
+	resp, err = c.transport.RoundTrip(req.WithContext(ctx))
 	if err == nil {
+		metrics.ObserveHTTP(req.Method, resp.StatusCode, time.Since(start))
 		resp.Body = http.MaxBytesReader(nil, resp.Body, MaxResponseBytes)
+	} else {
+		metrics.ObserveHTTP(req.Method, 0, time.Since(start))
 	}
 
 	if err == nil && (req.Method == "GET" || req.Method == "HEAD" || req.Method == "POST") {
@@ -0,0 +1,122 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"runtime/metrics"
+	"time"
+
+	"tidbyt.dev/pixlet/globals"
+	"tidbyt.dev/pixlet/render"
+)
+
+// memorySampleInterval is how often the heap is checked against
+// Limits.MaxMemory.
+const memorySampleInterval = 5 * time.Millisecond
+
+// Limits caps the resources an app may use while it runs. Zero values
+// mean no limit.
+type Limits struct {
+	// Timeout is how long a call into the app may run.
+	Timeout time.Duration
+
+	// MaxFrames is how many frames the roots an app returns may have.
+	MaxFrames int
+
+	// MaxMemory is how many bytes the heap may grow by while the app
+	// runs. The heap is shared by the whole process, so this is only
+	// accurate when one app runs at a time. It's meant to stop runaway
+	// apps, not to account for memory precisely.
+	MaxMemory uint64
+}
+
+// WithLimits caps the resources the app may use. Calls that go over the
+// timeout or memory limit are canceled, and RunWithConfig fails if the
+// app returns more frames than allowed.
+func WithLimits(limits Limits) AppletOption {
+	return func(a *Applet) error {
+		a.limits = limits
+		return nil
+	}
+}
+
+// limitContext applies the timeout and memory limit to ctx. The returned
+// function must be called when the call is done.
+func (l Limits) limitContext(ctx context.Context) (context.Context, func()) {
+	var cancels []func()
+
+	if l.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, l.Timeout, fmt.Errorf("timeout after %s", l.Timeout))
+		cancels = append(cancels, cancel)
+	}
+
+	if l.MaxMemory > 0 {
+		var cancel context.CancelCauseFunc
+		ctx, cancel = context.WithCancelCause(ctx)
+
+		done := make(chan struct{})
+		go watchMemory(l.MaxMemory, done, cancel)
+
+		cancels = append(cancels, func() {
+			close(done)
+			cancel(nil)
+		})
+	}
+
+	return ctx, func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}
+}
+
+// checkFrames fails if roots have more frames than allowed, at the size
+// the app is run at with ctx.
+func (l Limits) checkFrames(ctx context.Context, roots []render.Root) error {
+	if l.MaxFrames <= 0 {
+		return nil
+	}
+
+	width, height := globals.Size(ctx)
+	bounds := image.Rect(0, 0, width, height)
+
+	for _, r := range roots {
+		if n := r.Child.FrameCount(bounds); n > l.MaxFrames {
+			return fmt.Errorf("app rendered %d frames, more than the limit of %d", n, l.MaxFrames)
+		}
+	}
+
+	return nil
+}
+
+// watchMemory cancels with an error when the heap has grown by more than
+// max, until done is closed.
+func watchMemory(max uint64, done chan struct{}, cancel context.CancelCauseFunc) {
+	start := heapBytes()
+
+	ticker := time.NewTicker(memorySampleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if heap := heapBytes(); heap > start && heap-start > max {
+				cancel(fmt.Errorf("memory limit of %d bytes exceeded", max))
+				return
+			}
+		}
+	}
+}
+
+func heapBytes() uint64 {
+	sample := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return sample[0].Value.Uint64()
+}
@@ -0,0 +1,80 @@
+package runtime
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitsTimeout(t *testing.T) {
+	src := `
+def main():
+    for i in range(1000000000):
+        pass
+    return []
+`
+
+	app, err := NewApplet("test.star", []byte(src), WithLimits(Limits{Timeout: 50 * time.Millisecond}))
+	require.NoError(t, err)
+
+	_, err = app.Run(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timeout after 50ms")
+}
+
+func TestLimitsMaxFrames(t *testing.T) {
+	src := `
+load("render.star", "render")
+
+def main():
+    return render.Root(child = render.Animation(children = [render.Box()] * 10))
+`
+
+	app, err := NewApplet("test.star", []byte(src), WithLimits(Limits{MaxFrames: 5}))
+	require.NoError(t, err)
+
+	_, err = app.Run(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "app rendered 10 frames, more than the limit of 5")
+
+	app, err = NewApplet("test.star", []byte(src), WithLimits(Limits{MaxFrames: 10}))
+	require.NoError(t, err)
+
+	roots, err := app.Run(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(roots))
+}
+
+func TestLimitsMaxMemory(t *testing.T) {
+	src := `
+def main():
+    chunks = []
+    for i in range(1000000):
+        chunks.append("x" * 100000)
+    return []
+`
+
+	app, err := NewApplet("test.star", []byte(src), WithLimits(Limits{MaxMemory: 64 << 20}))
+	require.NoError(t, err)
+
+	_, err = app.Run(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "memory limit of 67108864 bytes exceeded")
+}
+
+func TestLimitsUnderMemoryLimit(t *testing.T) {
+	src := `
+def main():
+    x = "x" * 1000
+    return []
+`
+
+	app, err := NewApplet("test.star", []byte(src), WithLimits(Limits{MaxMemory: 64 << 20}))
+	require.NoError(t, err)
+
+	_, err = app.Run(context.Background())
+	assert.NoError(t, err)
+}
@@ -4,15 +4,18 @@ package animation_runtime
 
 import (
 	"fmt"
+	"image"
 	"sync"
 
 	"github.com/mitchellh/hashstructure/v2"
 	"go.starlark.net/starlark"
 	"go.starlark.net/starlarkstruct"
 
+	"tidbyt.dev/pixlet/globals"
 	"tidbyt.dev/pixlet/render"
 	"tidbyt.dev/pixlet/render/animation"
 	"tidbyt.dev/pixlet/runtime/modules/render_runtime"
+	"tidbyt.dev/pixlet/starlarkutil"
 )
 
 type AnimationModule struct {
@@ -213,7 +216,8 @@ func animatedpositionedFrameCount(
 	kwargs []starlark.Tuple) (starlark.Value, error) {
 
 	w := b.Receiver().(*AnimatedPositioned)
-	count := w.FrameCount()
+	width, height := globals.Size(starlarkutil.ThreadContext(thread))
+	count := w.FrameCount(image.Rect(0, 0, width, height))
 
 	return starlark.MakeInt(count), nil
 }
@@ -783,7 +787,8 @@ func transformationFrameCount(
 	kwargs []starlark.Tuple) (starlark.Value, error) {
 
 	w := b.Receiver().(*Transformation)
-	count := w.FrameCount()
+	width, height := globals.Size(starlarkutil.ThreadContext(thread))
+	count := w.FrameCount(image.Rect(0, 0, width, height))
 
 	return starlark.MakeInt(count), nil
 }
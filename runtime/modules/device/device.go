@@ -0,0 +1,100 @@
+package device
+
+import (
+	"fmt"
+	"sync"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+
+	"tidbyt.dev/pixlet/globals"
+	"tidbyt.dev/pixlet/starlarkutil"
+)
+
+const (
+	ModuleName      = "device"
+	threadDeviceKey = "tidbyt.dev/pixlet/runtime/device"
+)
+
+var (
+	once   sync.Once
+	module starlark.StringDict
+)
+
+// Device describes the display an app is rendered for. Hosts fill in
+// what they know, and leave the rest empty.
+type Device struct {
+	// Width and Height of the display in pixels. Zero means the
+	// default canvas size.
+	Width  int
+	Height int
+
+	Model    string
+	Locale   string
+	Timezone string
+
+	// Brightness of the display in percent, or negative if unknown.
+	Brightness int
+}
+
+// Unknown is a device the host knows nothing about.
+var Unknown = Device{Brightness: -1}
+
+func AttachToThread(t *starlark.Thread, d Device) {
+	t.SetLocal(threadDeviceKey, d)
+}
+
+func LoadModule() (starlark.StringDict, error) {
+	once.Do(func() {
+		module = starlark.StringDict{
+			ModuleName: &starlarkstruct.Module{
+				Name: ModuleName,
+				Members: starlark.StringDict{
+					"info": starlark.NewBuiltin("info", deviceInfo),
+				},
+			},
+		}
+	})
+
+	return module, nil
+}
+
+func deviceInfo(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackArgs("info", args, kwargs); err != nil {
+		return nil, fmt.Errorf("unpacking arguments for info: %s", err)
+	}
+
+	d, ok := thread.Local(threadDeviceKey).(Device)
+	if !ok {
+		d = Unknown
+	}
+
+	width, height := globals.Size(starlarkutil.ThreadContext(thread))
+	if d.Width > 0 {
+		width = d.Width
+	}
+	if d.Height > 0 {
+		height = d.Height
+	}
+
+	var brightness starlark.Value = starlark.None
+	if d.Brightness >= 0 {
+		brightness = starlark.MakeInt(d.Brightness)
+	}
+
+	return starlarkstruct.FromStringDict(starlark.String("Device"), starlark.StringDict{
+		"width":      starlark.MakeInt(width),
+		"height":     starlark.MakeInt(height),
+		"model":      optionalString(d.Model),
+		"locale":     optionalString(d.Locale),
+		"timezone":   optionalString(d.Timezone),
+		"brightness": brightness,
+	}), nil
+}
+
+func optionalString(s string) starlark.Value {
+	if s == "" {
+		return starlark.None
+	}
+	return starlark.String(s)
+}
@@ -0,0 +1,114 @@
+package device_test
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"tidbyt.dev/pixlet/globals"
+	"tidbyt.dev/pixlet/runtime"
+	"tidbyt.dev/pixlet/runtime/modules/device"
+)
+
+var deviceSrc = `
+load("device.star", "device")
+
+def main(config):
+	d = device.info()
+	if d.width != int(config.get("width")):
+		fail("width is", d.width)
+	if d.height != int(config.get("height")):
+		fail("height is", d.height)
+	if d.model != config.get("model"):
+		fail("model is", d.model)
+	if d.locale != config.get("locale"):
+		fail("locale is", d.locale)
+	if d.timezone != config.get("timezone"):
+		fail("timezone is", d.timezone)
+	if config.get("brightness") == None:
+		if d.brightness != None:
+			fail("brightness is", d.brightness)
+	elif d.brightness != int(config.get("brightness")):
+		fail("brightness is", d.brightness)
+	return []
+`
+
+func TestDeviceUnknown(t *testing.T) {
+	app, err := runtime.NewApplet("device_test.star", []byte(deviceSrc))
+	require.NoError(t, err)
+
+	_, err = app.RunWithConfig(context.Background(), map[string]string{
+		"width":  "64",
+		"height": "32",
+	})
+	assert.NoError(t, err)
+}
+
+func TestDeviceContextSize(t *testing.T) {
+	app, err := runtime.NewApplet("device_test.star", []byte(deviceSrc))
+	require.NoError(t, err)
+
+	ctx := globals.ContextWithSize(context.Background(), 128, 0)
+	_, err = app.RunWithConfig(ctx, map[string]string{
+		"width":  "128",
+		"height": "32",
+	})
+	assert.NoError(t, err)
+}
+
+func TestDeviceContextSizeConcurrent(t *testing.T) {
+	app, err := runtime.NewApplet("device_test.star", []byte(deviceSrc))
+	require.NoError(t, err)
+
+	// a size left out is the default one, never one of another run
+	var wg sync.WaitGroup
+	for _, size := range [][2]int{{128, 64}, {128, 0}, {0, 0}} {
+		want := map[string]string{"width": "64", "height": "32"}
+		if size[0] > 0 {
+			want["width"] = strconv.Itoa(size[0])
+		}
+		if size[1] > 0 {
+			want["height"] = strconv.Itoa(size[1])
+		}
+
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ctx := globals.ContextWithSize(context.Background(), size[0], size[1])
+				_, err := app.RunWithConfig(ctx, want)
+				assert.NoError(t, err)
+			}()
+		}
+	}
+	wg.Wait()
+}
+
+func TestDevice(t *testing.T) {
+	app, err := runtime.NewApplet(
+		"device_test.star",
+		[]byte(deviceSrc),
+		runtime.WithDevice(device.Device{
+			Width:      128,
+			Height:     64,
+			Model:      "tidbyt_gen2",
+			Locale:     "de-DE",
+			Timezone:   "Europe/Berlin",
+			Brightness: 40,
+		}),
+	)
+	require.NoError(t, err)
+
+	_, err = app.RunWithConfig(context.Background(), map[string]string{
+		"width":      "128",
+		"height":     "64",
+		"model":      "tidbyt_gen2",
+		"locale":     "de-DE",
+		"timezone":   "Europe/Berlin",
+		"brightness": "40",
+	})
+	assert.NoError(t, err)
+}
@@ -91,3 +91,26 @@ func ColorSeriesFromStarlark(list *starlark.List) ([]color.Color, error) {
 
 	return result, nil
 }
+
+func StringsFromStarlark(value starlark.Value) ([]string, error) {
+	iterable, ok := value.(starlark.Iterable)
+	if !ok {
+		return nil, fmt.Errorf("expected list of strings, got %s", value.Type())
+	}
+
+	result := make([]string, 0)
+
+	iter := iterable.Iterate()
+	defer iter.Done()
+
+	var elem starlark.Value
+	for i := 0; iter.Next(&elem); i++ {
+		s, ok := elem.(starlark.String)
+		if !ok {
+			return nil, fmt.Errorf("[%v] is not a valid string", i)
+		}
+		result = append(result, s.GoString())
+	}
+
+	return result, nil
+}
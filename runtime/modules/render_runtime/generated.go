@@ -4,13 +4,16 @@ package render_runtime
 
 import (
 	"fmt"
+	"image"
 	"sync"
 
 	"github.com/mitchellh/hashstructure/v2"
 	"go.starlark.net/starlark"
 	"go.starlark.net/starlarkstruct"
 
+	"tidbyt.dev/pixlet/globals"
 	"tidbyt.dev/pixlet/render"
+	"tidbyt.dev/pixlet/starlarkutil"
 )
 
 type RenderModule struct {
@@ -178,7 +181,8 @@ func animationFrameCount(
 	kwargs []starlark.Tuple) (starlark.Value, error) {
 
 	w := b.Receiver().(*Animation)
-	count := w.FrameCount()
+	width, height := globals.Size(starlarkutil.ThreadContext(thread))
+	count := w.FrameCount(image.Rect(0, 0, width, height))
 
 	return starlark.MakeInt(count), nil
 }
@@ -314,7 +318,8 @@ func boxFrameCount(
 	kwargs []starlark.Tuple) (starlark.Value, error) {
 
 	w := b.Receiver().(*Box)
-	count := w.FrameCount()
+	width, height := globals.Size(starlarkutil.ThreadContext(thread))
+	count := w.FrameCount(image.Rect(0, 0, width, height))
 
 	return starlark.MakeInt(count), nil
 }
@@ -434,7 +439,8 @@ func circleFrameCount(
 	kwargs []starlark.Tuple) (starlark.Value, error) {
 
 	w := b.Receiver().(*Circle)
-	count := w.FrameCount()
+	width, height := globals.Size(starlarkutil.ThreadContext(thread))
+	count := w.FrameCount(image.Rect(0, 0, width, height))
 
 	return starlark.MakeInt(count), nil
 }
@@ -562,7 +568,8 @@ func columnFrameCount(
 	kwargs []starlark.Tuple) (starlark.Value, error) {
 
 	w := b.Receiver().(*Column)
-	count := w.FrameCount()
+	width, height := globals.Size(starlarkutil.ThreadContext(thread))
+	count := w.FrameCount(image.Rect(0, 0, width, height))
 
 	return starlark.MakeInt(count), nil
 }
@@ -691,7 +698,8 @@ func imageFrameCount(
 	kwargs []starlark.Tuple) (starlark.Value, error) {
 
 	w := b.Receiver().(*Image)
-	count := w.FrameCount()
+	width, height := globals.Size(starlarkutil.ThreadContext(thread))
+	count := w.FrameCount(image.Rect(0, 0, width, height))
 
 	return starlark.MakeInt(count), nil
 }
@@ -842,7 +850,8 @@ func marqueeFrameCount(
 	kwargs []starlark.Tuple) (starlark.Value, error) {
 
 	w := b.Receiver().(*Marquee)
-	count := w.FrameCount()
+	width, height := globals.Size(starlarkutil.ThreadContext(thread))
+	count := w.FrameCount(image.Rect(0, 0, width, height))
 
 	return starlark.MakeInt(count), nil
 }
@@ -1002,7 +1011,8 @@ func paddingFrameCount(
 	kwargs []starlark.Tuple) (starlark.Value, error) {
 
 	w := b.Receiver().(*Padding)
-	count := w.FrameCount()
+	width, height := globals.Size(starlarkutil.ThreadContext(thread))
+	count := w.FrameCount(image.Rect(0, 0, width, height))
 
 	return starlark.MakeInt(count), nil
 }
@@ -1115,7 +1125,8 @@ func piechartFrameCount(
 	kwargs []starlark.Tuple) (starlark.Value, error) {
 
 	w := b.Receiver().(*PieChart)
-	count := w.FrameCount()
+	width, height := globals.Size(starlarkutil.ThreadContext(thread))
+	count := w.FrameCount(image.Rect(0, 0, width, height))
 
 	return starlark.MakeInt(count), nil
 }
@@ -1335,7 +1346,8 @@ func plotFrameCount(
 	kwargs []starlark.Tuple) (starlark.Value, error) {
 
 	w := b.Receiver().(*Plot)
-	count := w.FrameCount()
+	width, height := globals.Size(starlarkutil.ThreadContext(thread))
+	count := w.FrameCount(image.Rect(0, 0, width, height))
 
 	return starlark.MakeInt(count), nil
 }
@@ -1569,7 +1581,8 @@ func rowFrameCount(
 	kwargs []starlark.Tuple) (starlark.Value, error) {
 
 	w := b.Receiver().(*Row)
-	count := w.FrameCount()
+	width, height := globals.Size(starlarkutil.ThreadContext(thread))
+	count := w.FrameCount(image.Rect(0, 0, width, height))
 
 	return starlark.MakeInt(count), nil
 }
@@ -1673,7 +1686,8 @@ func sequenceFrameCount(
 	kwargs []starlark.Tuple) (starlark.Value, error) {
 
 	w := b.Receiver().(*Sequence)
-	count := w.FrameCount()
+	width, height := globals.Size(starlarkutil.ThreadContext(thread))
+	count := w.FrameCount(image.Rect(0, 0, width, height))
 
 	return starlark.MakeInt(count), nil
 }
@@ -1777,7 +1791,8 @@ func stackFrameCount(
 	kwargs []starlark.Tuple) (starlark.Value, error) {
 
 	w := b.Receiver().(*Stack)
-	count := w.FrameCount()
+	width, height := globals.Size(starlarkutil.ThreadContext(thread))
+	count := w.FrameCount(image.Rect(0, 0, width, height))
 
 	return starlark.MakeInt(count), nil
 }
@@ -1789,6 +1804,8 @@ type Text struct {
 
 	starlarkColor starlark.String
 
+	starlarkFallback starlark.Value
+
 	size *starlark.Builtin
 
 	frame_count *starlark.Builtin
@@ -1802,11 +1819,13 @@ func newText(
 ) (starlark.Value, error) {
 
 	var (
-		content starlark.String
-		font    starlark.String
-		height  starlark.Int
-		offset  starlark.Int
-		color   starlark.String
+		content   starlark.String
+		font      starlark.String
+		height    starlark.Int
+		offset    starlark.Int
+		color     starlark.String
+		fallback  starlark.Value
+		antialias starlark.Bool
 	)
 
 	if err := starlark.UnpackArgs(
@@ -1817,6 +1836,8 @@ func newText(
 		"height?", &height,
 		"offset?", &offset,
 		"color?", &color,
+		"fallback?", &fallback,
+		"antialias?", &antialias,
 	); err != nil {
 		return nil, fmt.Errorf("unpacking arguments for Text: %s", err)
 	}
@@ -1840,6 +1861,19 @@ func newText(
 		w.Color = c
 	}
 
+	if fallback != nil {
+		val, err := StringsFromStarlark(fallback)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for fallback: %w", err)
+		}
+		w.Fallback = val
+		w.starlarkFallback = fallback
+	} else {
+		w.starlarkFallback = starlark.NewList(nil)
+	}
+
+	w.Antialias = bool(antialias)
+
 	w.size = starlark.NewBuiltin("size", textSize)
 
 	w.frame_count = starlark.NewBuiltin("frame_count", textFrameCount)
@@ -1857,7 +1891,7 @@ func (w *Text) AsRenderWidget() render.Widget {
 
 func (w *Text) AttrNames() []string {
 	return []string{
-		"content", "font", "height", "offset", "color",
+		"content", "font", "height", "offset", "color", "fallback", "antialias",
 	}
 }
 
@@ -1884,6 +1918,14 @@ func (w *Text) Attr(name string) (starlark.Value, error) {
 
 		return w.starlarkColor, nil
 
+	case "fallback":
+
+		return w.starlarkFallback, nil
+
+	case "antialias":
+
+		return starlark.Bool(w.Antialias), nil
+
 	case "size":
 		return w.size.BindReceiver(w), nil
 
@@ -1927,7 +1969,8 @@ func textFrameCount(
 	kwargs []starlark.Tuple) (starlark.Value, error) {
 
 	w := b.Receiver().(*Text)
-	count := w.FrameCount()
+	width, height := globals.Size(starlarkutil.ThreadContext(thread))
+	count := w.FrameCount(image.Rect(0, 0, width, height))
 
 	return starlark.MakeInt(count), nil
 }
@@ -1939,6 +1982,8 @@ type WrappedText struct {
 
 	starlarkColor starlark.String
 
+	starlarkFallback starlark.Value
+
 	frame_count *starlark.Builtin
 }
 
@@ -1957,6 +2002,8 @@ func newWrappedText(
 		linespacing starlark.Int
 		color       starlark.String
 		align       starlark.String
+		fallback    starlark.Value
+		antialias   starlark.Bool
 	)
 
 	if err := starlark.UnpackArgs(
@@ -1969,6 +2016,8 @@ func newWrappedText(
 		"linespacing?", &linespacing,
 		"color?", &color,
 		"align?", &align,
+		"fallback?", &fallback,
+		"antialias?", &antialias,
 	); err != nil {
 		return nil, fmt.Errorf("unpacking arguments for WrappedText: %s", err)
 	}
@@ -1996,6 +2045,19 @@ func newWrappedText(
 
 	w.Align = align.GoString()
 
+	if fallback != nil {
+		val, err := StringsFromStarlark(fallback)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for fallback: %w", err)
+		}
+		w.Fallback = val
+		w.starlarkFallback = fallback
+	} else {
+		w.starlarkFallback = starlark.NewList(nil)
+	}
+
+	w.Antialias = bool(antialias)
+
 	w.frame_count = starlark.NewBuiltin("frame_count", wrappedtextFrameCount)
 
 	if err := w.Init(); err != nil {
@@ -2011,7 +2073,7 @@ func (w *WrappedText) AsRenderWidget() render.Widget {
 
 func (w *WrappedText) AttrNames() []string {
 	return []string{
-		"content", "font", "height", "width", "linespacing", "color", "align",
+		"content", "font", "height", "width", "linespacing", "color", "align", "fallback", "antialias",
 	}
 }
 
@@ -2046,6 +2108,14 @@ func (w *WrappedText) Attr(name string) (starlark.Value, error) {
 
 		return starlark.String(w.Align), nil
 
+	case "fallback":
+
+		return w.starlarkFallback, nil
+
+	case "antialias":
+
+		return starlark.Bool(w.Antialias), nil
+
 	case "frame_count":
 		return w.frame_count.BindReceiver(w), nil
 
@@ -2071,7 +2141,8 @@ func wrappedtextFrameCount(
 	kwargs []starlark.Tuple) (starlark.Value, error) {
 
 	w := b.Receiver().(*WrappedText)
-	count := w.FrameCount()
+	width, height := globals.Size(starlarkutil.ThreadContext(thread))
+	count := w.FrameCount(image.Rect(0, 0, width, height))
 
 	return starlark.MakeInt(count), nil
 }
@@ -0,0 +1,248 @@
+// Package scratch gives apps a small scratch area to write files to and
+// read them back, for code that needs file semantics, like extracting an
+// archive. Files are kept in memory, and each run of an app starts with
+// an empty scratch area, which is thrown away when the run ends.
+package scratch
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+const (
+	ModuleName     = "scratch"
+	threadStoreKey = "tidbyt.dev/pixlet/runtime/scratch"
+
+	// MaxSize is how many bytes the files in a scratch area may add up
+	// to.
+	MaxSize = 4 << 20
+)
+
+var (
+	once   sync.Once
+	module starlark.StringDict
+)
+
+// store holds the files in a scratch area.
+type store struct {
+	files map[string][]byte
+	size  int
+	max   int
+}
+
+// AttachToThread gives the thread an empty scratch area. Files written
+// on the thread are kept until the thread is no longer used.
+func AttachToThread(t *starlark.Thread) {
+	t.SetLocal(threadStoreKey, &store{files: map[string][]byte{}, max: MaxSize})
+}
+
+func LoadModule() (starlark.StringDict, error) {
+	once.Do(func() {
+		module = starlark.StringDict{
+			ModuleName: &starlarkstruct.Module{
+				Name: ModuleName,
+				Members: starlark.StringDict{
+					"write":  starlark.NewBuiltin("write", write),
+					"append": starlark.NewBuiltin("append", write),
+					"read":   starlark.NewBuiltin("read", read),
+					"exists": starlark.NewBuiltin("exists", exists),
+					"remove": starlark.NewBuiltin("remove", remove),
+					"list":   starlark.NewBuiltin("list", list),
+				},
+			},
+		}
+	})
+
+	return module, nil
+}
+
+func threadStore(thread *starlark.Thread) (*store, error) {
+	s, ok := thread.Local(threadStoreKey).(*store)
+	if !ok || s == nil {
+		return nil, fmt.Errorf("scratch area not set")
+	}
+	return s, nil
+}
+
+// cleanPath turns the path an app gave into the name of a file in the
+// scratch area. Paths are relative to the scratch area, and can't leave
+// it.
+func cleanPath(p string) (string, error) {
+	name := path.Clean("/" + p)[1:]
+	if name == "" {
+		return "", fmt.Errorf("invalid path: %q", p)
+	}
+	return name, nil
+}
+
+func write(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var (
+		p    string
+		data starlark.Value
+	)
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "path", &p, "data", &data); err != nil {
+		return nil, err
+	}
+
+	var content string
+	switch data := data.(type) {
+	case starlark.String:
+		content = string(data)
+	case starlark.Bytes:
+		content = string(data)
+	default:
+		return nil, fmt.Errorf("%s: data must be string or bytes, not %s", b.Name(), data.Type())
+	}
+
+	s, err := threadStore(thread)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := cleanPath(p)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", b.Name(), err)
+	}
+
+	old := s.files[name]
+	var file []byte
+	if b.Name() == "append" {
+		file = append(old[:len(old):len(old)], content...)
+	} else {
+		file = []byte(content)
+	}
+
+	size := s.size - len(old) + len(file)
+	if size > s.max {
+		return nil, fmt.Errorf("%s: scratch area is full, it holds at most %d bytes", b.Name(), s.max)
+	}
+
+	s.files[name] = file
+	s.size = size
+
+	return starlark.None, nil
+}
+
+func read(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var p, mode string
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "path", &p, "mode?", &mode); err != nil {
+		return nil, err
+	}
+
+	s, err := threadStore(thread)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := cleanPath(p)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", b.Name(), err)
+	}
+
+	file, ok := s.files[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: no such file: %s", b.Name(), name)
+	}
+
+	switch mode {
+	case "", "r", "rt":
+		return starlark.String(file), nil
+	case "rb":
+		return starlark.Bytes(file), nil
+	default:
+		return nil, fmt.Errorf("%s: unsupported mode: %s", b.Name(), mode)
+	}
+}
+
+func exists(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var p string
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "path", &p); err != nil {
+		return nil, err
+	}
+
+	s, err := threadStore(thread)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := cleanPath(p)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", b.Name(), err)
+	}
+
+	_, ok := s.files[name]
+	return starlark.Bool(ok), nil
+}
+
+func remove(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var p string
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "path", &p); err != nil {
+		return nil, err
+	}
+
+	s, err := threadStore(thread)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := cleanPath(p)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", b.Name(), err)
+	}
+
+	// removing a directory removes everything in it
+	removed := false
+	for f, data := range s.files {
+		if f == name || strings.HasPrefix(f, name+"/") {
+			s.size -= len(data)
+			delete(s.files, f)
+			removed = true
+		}
+	}
+	if !removed {
+		return nil, fmt.Errorf("%s: no such file: %s", b.Name(), name)
+	}
+
+	return starlark.None, nil
+}
+
+func list(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var dir string
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "dir?", &dir); err != nil {
+		return nil, err
+	}
+
+	s, err := threadStore(thread)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := ""
+	if dir != "" {
+		name, err := cleanPath(dir)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", b.Name(), err)
+		}
+		prefix = name + "/"
+	}
+
+	names := []string{}
+	for f := range s.files {
+		if strings.HasPrefix(f, prefix) {
+			names = append(names, f)
+		}
+	}
+	sort.Strings(names)
+
+	values := make([]starlark.Value, len(names))
+	for i, name := range names {
+		values[i] = starlark.String(name)
+	}
+	return starlark.NewList(values), nil
+}
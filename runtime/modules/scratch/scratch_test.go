@@ -0,0 +1,112 @@
+package scratch_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tidbyt.dev/pixlet/runtime"
+	"tidbyt.dev/pixlet/runtime/modules/scratch"
+)
+
+var scratchSrc = `
+load("scratch.star", "scratch")
+
+def test_files():
+    if scratch.exists("notes.txt"):
+        fail("scratch area should start out empty")
+
+    scratch.write("notes.txt", "hello")
+    scratch.append("notes.txt", ", world")
+    if scratch.read("notes.txt") != "hello, world":
+        fail("unexpected contents: %s" % scratch.read("notes.txt"))
+
+    scratch.write("data/a.bin", b"\x00\x01")
+    scratch.write("/data/../data/b.bin", b"\x02")
+    if scratch.read("data/a.bin", "rb") != b"\x00\x01":
+        fail("unexpected binary contents")
+    if scratch.list() != ["data/a.bin", "data/b.bin", "notes.txt"]:
+        fail("unexpected files: %s" % scratch.list())
+    if scratch.list("data") != ["data/a.bin", "data/b.bin"]:
+        fail("unexpected files in data: %s" % scratch.list("data"))
+
+    scratch.remove("data")
+    if scratch.list() != ["notes.txt"]:
+        fail("unexpected files after removing data: %s" % scratch.list())
+
+def main():
+    # each run starts afresh
+    if scratch.exists("run.txt"):
+        fail("run.txt is left from an earlier run")
+    scratch.write("run.txt", "x")
+    scratch.remove("run.txt")
+
+    test_files()
+    scratch.write("run.txt", "x")
+
+    return []
+`
+
+func TestScratch(t *testing.T) {
+	app, err := runtime.NewApplet("scratch_test.star", []byte(scratchSrc))
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, err = app.Run(context.Background())
+		require.NoError(t, err)
+	}
+}
+
+func TestScratchErrors(t *testing.T) {
+	for _, tc := range []struct {
+		src string
+		err string
+	}{
+		{`scratch.read("missing.txt")`, "no such file: missing.txt"},
+		{`scratch.remove("missing.txt")`, "no such file: missing.txt"},
+		{`scratch.write("", "x")`, "invalid path"},
+		{`scratch.write("x", 1)`, "data must be string or bytes, not int"},
+		{`scratch.read("x", "w")`, "unsupported mode: w"},
+		{fmt.Sprintf(`scratch.write("big", "x" * %d)`, scratch.MaxSize+1), "scratch area is full"},
+		{fmt.Sprintf(`
+    scratch.write("a", "x" * %d)
+    scratch.append("a", "x")`, scratch.MaxSize), "scratch area is full"},
+	} {
+		src := fmt.Sprintf(`
+load("scratch.star", "scratch")
+
+def main():
+    scratch.write("x", "x")
+    %s
+    return []
+`, tc.src)
+
+		app, err := runtime.NewApplet("scratch_test.star", []byte(src))
+		require.NoError(t, err)
+
+		_, err = app.Run(context.Background())
+		require.Error(t, err, tc.src)
+		assert.Contains(t, err.Error(), tc.err)
+	}
+}
+
+func TestScratchOverwriteWithinLimit(t *testing.T) {
+	src := fmt.Sprintf(`
+load("scratch.star", "scratch")
+
+def main():
+    # rewriting a file frees the space it took before
+    for _ in range(3):
+        scratch.write("a", "x" * %d)
+    return []
+`, scratch.MaxSize)
+
+	app, err := runtime.NewApplet("scratch_test.star", []byte(src))
+	require.NoError(t, err)
+
+	_, err = app.Run(context.Background())
+	assert.NoError(t, err)
+}
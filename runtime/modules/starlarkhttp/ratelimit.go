@@ -0,0 +1,243 @@
+package starlarkhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned, wrapped, for requests a RateLimiter
+// doesn't allow.
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// RateLimiter decides when apps may make requests, so that a host
+// running many apps doesn't send more requests to an API than it
+// allows. Wait is called before every request an app makes, and blocks
+// until the request may be made, or returns an error if it may not.
+type RateLimiter interface {
+	Wait(ctx context.Context, host, appID string) error
+}
+
+// RateLimitPolicy is what a HostRateLimiter does with requests that go
+// over the limit.
+type RateLimitPolicy int
+
+const (
+	// RateLimitQueue holds requests until they're within the limit.
+	RateLimitQueue RateLimitPolicy = iota
+
+	// RateLimitFail fails requests right away.
+	RateLimitFail
+)
+
+// ParseRateLimitPolicy parses "queue" or "fail".
+func ParseRateLimitPolicy(s string) (RateLimitPolicy, error) {
+	switch s {
+	case "queue":
+		return RateLimitQueue, nil
+	case "fail":
+		return RateLimitFail, nil
+	default:
+		return 0, fmt.Errorf("unknown rate limit policy: %s", s)
+	}
+}
+
+// RateLimit is how many requests may be made Per some time, in bursts
+// of up to Burst requests. If Burst is zero, it's Requests.
+type RateLimit struct {
+	Requests int
+	Per      time.Duration
+	Burst    int
+}
+
+// ParseRateLimit parses a limit like "60/m", "10/s" or "1000/h". The
+// unit can also be a duration, like "100/30s".
+func ParseRateLimit(s string) (RateLimit, error) {
+	n, unit, ok := strings.Cut(s, "/")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q: expected requests/period, like 60/m", s)
+	}
+
+	var requests int
+	if _, err := fmt.Sscanf(n, "%d", &requests); err != nil || requests <= 0 {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q: requests must be a positive number", s)
+	}
+
+	var per time.Duration
+	switch unit {
+	case "s":
+		per = time.Second
+	case "m":
+		per = time.Minute
+	case "h":
+		per = time.Hour
+	default:
+		d, err := time.ParseDuration(unit)
+		if err != nil || d <= 0 {
+			return RateLimit{}, fmt.Errorf("invalid rate limit %q: unknown period %q", s, unit)
+		}
+		per = d
+	}
+
+	return RateLimit{Requests: requests, Per: per}, nil
+}
+
+func (l RateLimit) rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+func (l RateLimit) burst() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return float64(l.Requests)
+}
+
+// maxBuckets is how many buckets a HostRateLimiter keeps before it
+// forgets the ones that are full again.
+const maxBuckets = 10000
+
+// HostRateLimiter limits requests to each host with a token bucket.
+// Requests to hosts in Hosts are limited to their limit there, and
+// requests to other hosts to Default, if it's set. With PerApp, each app
+// gets its own limit for each host, rather than sharing one with every
+// other app.
+type HostRateLimiter struct {
+	Default RateLimit
+	Hosts   map[string]RateLimit
+	PerApp  bool
+	Policy  RateLimitPolicy
+
+	// MaxWait is how long a request is queued for at most, with
+	// RateLimitQueue. Requests that would wait longer, or past their
+	// deadline, fail right away. If zero, requests wait until their
+	// deadline.
+	MaxWait time.Duration
+
+	mu      sync.Mutex
+	buckets map[bucketKey]*bucket
+	now     func() time.Time
+}
+
+type bucketKey struct {
+	host, appID string
+}
+
+type bucket struct {
+	limit  RateLimit
+	tokens float64
+	last   time.Time
+}
+
+// take takes a token from the bucket, going into debt if there are none
+// left, and returns how long it is until the token would have been
+// there.
+func (b *bucket) take(now time.Time) time.Duration {
+	b.refill(now)
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.limit.rate() * float64(time.Second))
+}
+
+func (b *bucket) refill(now time.Time) {
+	if now.After(b.last) {
+		b.tokens += now.Sub(b.last).Seconds() * b.limit.rate()
+		b.last = now
+	}
+	if max := b.limit.burst(); b.tokens > max {
+		b.tokens = max
+	}
+}
+
+// Wait implements RateLimiter.
+func (l *HostRateLimiter) Wait(ctx context.Context, host, appID string) error {
+	host = strings.ToLower(host)
+
+	limit, ok := l.Hosts[host]
+	if !ok {
+		limit = l.Default
+	}
+	if limit.Requests <= 0 || limit.Per <= 0 {
+		return nil
+	}
+
+	key := bucketKey{host: host}
+	if l.PerApp {
+		key.appID = appID
+	}
+
+	l.mu.Lock()
+	now := l.clock()
+	b := l.bucket(key, limit, now)
+	wait := b.take(now)
+
+	fail := wait > 0 && l.Policy == RateLimitFail
+	if l.MaxWait > 0 && wait > l.MaxWait {
+		fail = true
+	}
+	if deadline, ok := ctx.Deadline(); ok && now.Add(wait).After(deadline) {
+		fail = true
+	}
+	if fail {
+		b.tokens++
+	}
+	l.mu.Unlock()
+
+	if fail {
+		return fmt.Errorf("%w for %s", ErrRateLimited, host)
+	}
+	if wait == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// give the token back, for the requests queued behind this one
+		l.mu.Lock()
+		b.tokens++
+		l.mu.Unlock()
+		return fmt.Errorf("%w for %s: %w", ErrRateLimited, host, ctx.Err())
+	}
+}
+
+func (l *HostRateLimiter) clock() time.Time {
+	if l.now != nil {
+		return l.now()
+	}
+	return time.Now()
+}
+
+// bucket returns the bucket for key. Call it with l.mu held.
+func (l *HostRateLimiter) bucket(key bucketKey, limit RateLimit, now time.Time) *bucket {
+	if b, ok := l.buckets[key]; ok {
+		b.limit = limit
+		return b
+	}
+
+	if l.buckets == nil {
+		l.buckets = map[bucketKey]*bucket{}
+	}
+
+	if len(l.buckets) >= maxBuckets {
+		for k, b := range l.buckets {
+			b.refill(now)
+			if b.tokens >= b.limit.burst() {
+				delete(l.buckets, k)
+			}
+		}
+	}
+
+	b := &bucket{limit: limit, tokens: limit.burst(), last: now}
+	l.buckets[key] = b
+	return b
+}
@@ -0,0 +1,147 @@
+package starlarkhttp_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.starlark.net/starlark"
+
+	"tidbyt.dev/pixlet/runtime/modules/starlarkhttp"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	for s, want := range map[string]starlarkhttp.RateLimit{
+		"60/m":    {Requests: 60, Per: time.Minute},
+		"10/s":    {Requests: 10, Per: time.Second},
+		"1000/h":  {Requests: 1000, Per: time.Hour},
+		"100/30s": {Requests: 100, Per: 30 * time.Second},
+	} {
+		got, err := starlarkhttp.ParseRateLimit(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, want, got, s)
+	}
+
+	for _, s := range []string{"", "60", "0/m", "-1/m", "x/m", "60/fortnight", "60/-1s"} {
+		_, err := starlarkhttp.ParseRateLimit(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestRateLimiterFail(t *testing.T) {
+	l := &starlarkhttp.HostRateLimiter{
+		Default: starlarkhttp.RateLimit{Requests: 2, Per: time.Hour},
+		Hosts: map[string]starlarkhttp.RateLimit{
+			"api.example.com": {Requests: 1, Per: time.Hour},
+		},
+		Policy: starlarkhttp.RateLimitFail,
+	}
+	ctx := context.Background()
+
+	assert.NoError(t, l.Wait(ctx, "example.com", "app"))
+	assert.NoError(t, l.Wait(ctx, "example.com", "other-app"))
+	assert.ErrorIs(t, l.Wait(ctx, "Example.com", "app"), starlarkhttp.ErrRateLimited)
+
+	// each host has its own limit
+	assert.NoError(t, l.Wait(ctx, "api.example.com", "app"))
+	assert.ErrorIs(t, l.Wait(ctx, "api.example.com", "app"), starlarkhttp.ErrRateLimited)
+	assert.NoError(t, l.Wait(ctx, "other.example.com", "app"))
+}
+
+func TestRateLimiterPerApp(t *testing.T) {
+	l := &starlarkhttp.HostRateLimiter{
+		Default: starlarkhttp.RateLimit{Requests: 1, Per: time.Hour},
+		PerApp:  true,
+		Policy:  starlarkhttp.RateLimitFail,
+	}
+	ctx := context.Background()
+
+	assert.NoError(t, l.Wait(ctx, "example.com", "app"))
+	assert.NoError(t, l.Wait(ctx, "example.com", "other-app"))
+	assert.ErrorIs(t, l.Wait(ctx, "example.com", "app"), starlarkhttp.ErrRateLimited)
+}
+
+func TestRateLimiterQueue(t *testing.T) {
+	l := &starlarkhttp.HostRateLimiter{
+		Default: starlarkhttp.RateLimit{Requests: 1, Per: 50 * time.Millisecond},
+	}
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, l.Wait(ctx, "example.com", "app"))
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+}
+
+func TestRateLimiterQueueTimeout(t *testing.T) {
+	l := &starlarkhttp.HostRateLimiter{
+		Default: starlarkhttp.RateLimit{Requests: 1, Per: time.Hour},
+		MaxWait: time.Minute,
+	}
+	ctx := context.Background()
+
+	require.NoError(t, l.Wait(ctx, "example.com", "app"))
+
+	// waiting longer than MaxWait, or past the deadline, fails right away
+	start := time.Now()
+	assert.ErrorIs(t, l.Wait(ctx, "example.com", "app"), starlarkhttp.ErrRateLimited)
+
+	l.MaxWait = 0
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	assert.ErrorIs(t, l.Wait(ctx, "example.com", "app"), starlarkhttp.ErrRateLimited)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestRateLimiterCancel(t *testing.T) {
+	l := &starlarkhttp.HostRateLimiter{
+		Default: starlarkhttp.RateLimit{Requests: 1, Per: time.Hour},
+	}
+	require.NoError(t, l.Wait(context.Background(), "example.com", "app"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	err := l.Wait(ctx, "example.com", "app")
+	assert.ErrorIs(t, err, starlarkhttp.ErrRateLimited)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestModuleRateLimit(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer ts.Close()
+
+	starlarkhttp.StarlarkHTTPRateLimiter = &starlarkhttp.HostRateLimiter{
+		Default: starlarkhttp.RateLimit{Requests: 1, Per: time.Hour},
+		Policy:  starlarkhttp.RateLimitFail,
+	}
+	defer func() { starlarkhttp.StarlarkHTTPRateLimiter = nil }()
+
+	module, err := starlarkhttp.LoadModule()
+	require.NoError(t, err)
+
+	thread := &starlark.Thread{Name: "ratelimit/abc123"}
+	globals, err := starlark.ExecFile(thread, "ratelimit.star", `
+def get():
+    return http.get(url).status_code
+`, starlark.StringDict{"http": module["http"], "url": starlark.String(ts.URL)})
+	require.NoError(t, err)
+
+	_, err = starlark.Call(thread, globals["get"], nil, nil)
+	require.NoError(t, err)
+
+	_, err = starlark.Call(thread, globals["get"], nil, nil)
+	assert.ErrorIs(t, err, starlarkhttp.ErrRateLimited)
+	assert.Equal(t, 1, requests)
+}
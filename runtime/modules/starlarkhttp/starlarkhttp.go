@@ -28,6 +28,7 @@ package starlarkhttp
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -35,10 +36,13 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	util "github.com/qri-io/starlib/util"
 	"go.starlark.net/starlark"
 	"go.starlark.net/starlarkstruct"
+
+	"tidbyt.dev/pixlet/starlarkutil"
 )
 
 // AsString unquotes a starlark string value
@@ -57,6 +61,9 @@ var (
 	// StarlarkHTTPGuard is a global RequestGuard used in LoadModule. override with a custom
 	// implementation before calling LoadModule
 	StarlarkHTTPGuard RequestGuard
+	// StarlarkHTTPRateLimiter is a global RateLimiter used in LoadModule, which
+	// every request is held back by. override before calling LoadModule
+	StarlarkHTTPRateLimiter RateLimiter
 )
 
 // Encodings for form data.
@@ -73,6 +80,9 @@ func LoadModule() (starlark.StringDict, error) {
 	if StarlarkHTTPGuard != nil {
 		m.rg = StarlarkHTTPGuard
 	}
+	if StarlarkHTTPRateLimiter != nil {
+		m.rl = StarlarkHTTPRateLimiter
+	}
 	ns := starlark.StringDict{
 		"http": m.Struct(),
 	}
@@ -85,11 +95,23 @@ type RequestGuard interface {
 	Allowed(thread *starlark.Thread, req *http.Request) (*http.Request, error)
 }
 
+// threadGuardKey is the thread-local holding the guard attached with
+// AttachGuard.
+const threadGuardKey = "tidbyt.dev/pixlet/runtime/modules/starlarkhttp/guard"
+
+// AttachGuard makes requests from thread pass guard too, besides
+// StarlarkHTTPGuard, for guards that differ between apps. Unlike
+// StarlarkHTTPGuard, it's also asked about redirects.
+func AttachGuard(thread *starlark.Thread, guard RequestGuard) {
+	thread.SetLocal(threadGuardKey, guard)
+}
+
 // Module joins http tools to a dataset, allowing dataset
 // to follow along with http requests
 type Module struct {
 	cli *http.Client
 	rg  RequestGuard
+	rl  RateLimiter
 }
 
 // Struct returns this module's methods as a starlark Struct
@@ -136,7 +158,7 @@ func (m *Module) reqMethod(method string) func(thread *starlark.Thread, _ *starl
 			return nil, err
 		}
 
-		req, err := http.NewRequest(strings.ToUpper(method), rawurl, nil)
+		req, err := http.NewRequestWithContext(starlarkutil.ThreadContext(thread), strings.ToUpper(method), rawurl, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -147,6 +169,15 @@ func (m *Module) reqMethod(method string) func(thread *starlark.Thread, _ *starl
 			}
 		}
 
+		cli := m.cli
+		if guard, ok := thread.Local(threadGuardKey).(RequestGuard); ok {
+			req, err = guard.Allowed(thread, req)
+			if err != nil {
+				return nil, err
+			}
+			cli = guardRedirects(cli, thread, guard)
+		}
+
 		if err = setHeaders(req, headers); err != nil {
 			return nil, err
 		}
@@ -160,16 +191,46 @@ func (m *Module) reqMethod(method string) func(thread *starlark.Thread, _ *starl
 			return nil, err
 		}
 
-		res, err := m.cli.Do(req)
+		if m.rl != nil {
+			if err = m.rl.Wait(req.Context(), req.URL.Hostname(), getAppIdentifier(thread)); err != nil {
+				return nil, err
+			}
+		}
+
+		// the query is left out of logs, since it often holds API keys
+		logger := starlarkutil.ThreadLogger(thread)
+		start := time.Now()
+		res, err := cli.Do(req)
 		if err != nil {
+			logger.DebugContext(req.Context(), "http request failed", "method", req.Method, "host", req.URL.Host, "path", req.URL.Path, "duration", time.Since(start), "error", err)
 			return nil, err
 		}
+		logger.DebugContext(req.Context(), "http request", "method", req.Method, "host", req.URL.Host, "path", req.URL.Path, "status", res.StatusCode, "duration", time.Since(start))
 
 		r := &Response{*res}
 		return r.Struct(), nil
 	}
 }
 
+// guardRedirects returns a copy of cli that asks guard about redirects
+// before following them.
+func guardRedirects(cli *http.Client, thread *starlark.Thread, guard RequestGuard) *http.Client {
+	guarded := *cli
+	guarded.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if _, err := guard.Allowed(thread, req); err != nil {
+			return err
+		}
+		if cli.CheckRedirect != nil {
+			return cli.CheckRedirect(req, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	return &guarded
+}
+
 func setQueryParams(rawurl *string, params *starlark.Dict) error {
 	keys := params.Keys()
 	if len(keys) == 0 {
@@ -0,0 +1,150 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.starlark.net/starlark"
+
+	"tidbyt.dev/pixlet/runtime/modules/starlarkhttp"
+)
+
+const threadPolicyKey = "tidbyt.dev/pixlet/runtime/policy"
+
+// errNotAllowed is what apps fail with when they do something their
+// policy doesn't allow.
+var errNotAllowed = errors.New("not allowed by policy")
+
+// Policy is what an app may do, for hosts that run apps they don't
+// trust, like community apps, with least privilege. The zero Policy
+// allows everything.
+type Policy struct {
+	// Modules are the modules the app may load, like "http.star". If
+	// nil, it may load any. The app's own files can always be loaded.
+	Modules []string
+
+	// Hosts are the hosts the app may make HTTP requests to, including
+	// when following redirects. "*.example.com" matches any subdomain
+	// of example.com. If nil, it may request any host.
+	Hosts []string
+
+	// CacheQuota is how many bytes the app may keep in cache.star at
+	// once, counting keys and values. If zero, there's no quota.
+	CacheQuota int
+
+	// Secrets are the names of the secrets the app may read with
+	// secret.get. If nil, it may read any. secret.decrypt isn't
+	// affected, since what it decrypts was encrypted for the app.
+	Secrets []string
+}
+
+// WithPolicy restricts what the app may do. Loading modules, requesting
+// hosts, reading secrets or caching more than the policy allows fails
+// the app, with an error saying it's not allowed by policy.
+func WithPolicy(policy Policy) AppletOption {
+	return func(a *Applet) error {
+		a.policy = &appPolicy{Policy: policy}
+		return nil
+	}
+}
+
+// appPolicy is the policy of an app, with what it has used so far.
+type appPolicy struct {
+	Policy
+
+	mu    sync.Mutex
+	cache map[string]cacheUsage
+}
+
+type cacheUsage struct {
+	size    int
+	expires time.Time
+}
+
+func (a *Applet) attachPolicy(t *starlark.Thread) {
+	if a.policy == nil {
+		return
+	}
+
+	t.SetLocal(threadPolicyKey, a.policy)
+	if a.policy.Hosts != nil {
+		starlarkhttp.AttachGuard(t, hostGuard(a.policy.Hosts))
+	}
+}
+
+func policyForThread(t *starlark.Thread) *appPolicy {
+	p, _ := t.Local(threadPolicyKey).(*appPolicy)
+	return p
+}
+
+// checkModule fails if the policy doesn't allow loading module.
+func (p *appPolicy) checkModule(module string) error {
+	if p == nil || p.Modules == nil || slices.Contains(p.Modules, module) {
+		return nil
+	}
+	return fmt.Errorf("loading %s: %w", module, errNotAllowed)
+}
+
+// checkSecret fails if the policy doesn't allow reading the secret.
+func (p *appPolicy) checkSecret(name string) error {
+	if p == nil || p.Secrets == nil || slices.Contains(p.Secrets, name) {
+		return nil
+	}
+	return fmt.Errorf("reading secret %s: %w", name, errNotAllowed)
+}
+
+// reserveCache accounts for caching size bytes under key until ttl
+// passes, failing if that would go over the quota.
+func (p *appPolicy) reserveCache(key string, size int, ttl time.Duration) error {
+	if p == nil || p.CacheQuota <= 0 {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	used := 0
+	for k, u := range p.cache {
+		if now.After(u.expires) {
+			delete(p.cache, k)
+		} else if k != key {
+			used += u.size
+		}
+	}
+
+	if used+size > p.CacheQuota {
+		return fmt.Errorf("caching %d bytes with %d of %d in use: %w", size, used, p.CacheQuota, errNotAllowed)
+	}
+
+	if p.cache == nil {
+		p.cache = map[string]cacheUsage{}
+	}
+	p.cache[key] = cacheUsage{size: size, expires: now.Add(ttl)}
+	return nil
+}
+
+// hostGuard allows HTTP requests to the hosts it lists.
+type hostGuard []string
+
+// Allowed implements starlarkhttp.RequestGuard.
+func (g hostGuard) Allowed(thread *starlark.Thread, req *http.Request) (*http.Request, error) {
+	host := strings.TrimSuffix(strings.ToLower(req.URL.Hostname()), ".")
+
+	for _, pattern := range g {
+		pattern = strings.ToLower(pattern)
+		if pattern == host {
+			return req, nil
+		}
+		if suffix, ok := strings.CutPrefix(pattern, "*"); ok && strings.HasPrefix(suffix, ".") && strings.HasSuffix(host, suffix) {
+			return req, nil
+		}
+	}
+
+	return nil, fmt.Errorf("requesting %s: %w", host, errNotAllowed)
+}
@@ -0,0 +1,137 @@
+package runtime
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyModules(t *testing.T) {
+	src := `
+load("render.star", "render")
+load("http.star", "http")
+
+def main():
+    return render.Root(child = render.Box())
+`
+	_, err := NewApplet("test.star", []byte(src), WithPolicy(Policy{Modules: []string{"render.star"}}))
+	assert.ErrorContains(t, err, "not allowed by policy")
+	assert.ErrorContains(t, err, "http.star")
+
+	app, err := NewApplet("test.star", []byte(src), WithPolicy(Policy{Modules: []string{"render.star", "http.star"}}))
+	require.NoError(t, err)
+	_, err = app.Run(context.Background())
+	assert.NoError(t, err)
+}
+
+func TestPolicyHosts(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			// the same server, under another name
+			http.Redirect(w, r, strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)+"/", http.StatusFound)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	src := `
+load("render.star", "render")
+load("http.star", "http")
+
+def main(config):
+    resp = http.get(config.get("url"))
+    return render.Root(child = render.Text(resp.body()))
+`
+	app, err := NewApplet("test.star", []byte(src), WithPolicy(Policy{Hosts: []string{"127.0.0.1", "*.example.com"}}))
+	require.NoError(t, err)
+
+	_, err = app.RunWithConfig(context.Background(), map[string]string{"url": srv.URL})
+	assert.NoError(t, err)
+
+	_, err = app.RunWithConfig(context.Background(), map[string]string{"url": strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)})
+	assert.ErrorContains(t, err, "requesting localhost: not allowed by policy")
+
+	// redirects are checked too
+	_, err = app.RunWithConfig(context.Background(), map[string]string{"url": srv.URL + "/redirect"})
+	assert.ErrorContains(t, err, "requesting localhost: not allowed by policy")
+}
+
+func TestHostGuard(t *testing.T) {
+	g := hostGuard{"api.example.com", "*.weather.gov"}
+
+	for host, allowed := range map[string]bool{
+		"api.example.com":      true,
+		"API.example.com":      true,
+		"api.example.com.":     true,
+		"example.com":          false,
+		"evil-api.example.com": false,
+		"forecast.weather.gov": true,
+		"a.b.weather.gov":      true,
+		"weather.gov":          false,
+		"notweather.gov":       false,
+	} {
+		req, err := http.NewRequest("GET", "https://"+host+"/", nil)
+		require.NoError(t, err)
+		_, err = g.Allowed(nil, req)
+		assert.Equal(t, allowed, err == nil, host)
+	}
+}
+
+func TestPolicyCacheQuota(t *testing.T) {
+	InitCache(NewInMemoryCache())
+	defer InitCache(nil)
+
+	src := `
+load("render.star", "render")
+load("cache.star", "cache")
+
+def main(config):
+    cache.set(config.get("key"), "x" * int(config.get("size")))
+    return render.Root(child = render.Box())
+`
+	app, err := NewApplet("quota", []byte(src), WithPolicy(Policy{CacheQuota: 200}))
+	require.NoError(t, err)
+
+	run := func(key, size string) error {
+		_, err := app.RunWithConfig(context.Background(), map[string]string{"key": key, "size": size})
+		return err
+	}
+
+	assert.NoError(t, run("a", "100"))
+
+	// replacing a key doesn't count what it replaces
+	assert.NoError(t, run("a", "120"))
+
+	err = run("b", "100")
+	assert.ErrorContains(t, err, "not allowed by policy")
+	assert.ErrorContains(t, err, "cache.set")
+
+	assert.NoError(t, run("b", "40"))
+}
+
+func TestPolicySecrets(t *testing.T) {
+	src := `
+load("render.star", "render")
+load("secret.star", "secret")
+
+def main(config):
+    secret.get(config.get("name"))
+    return render.Root(child = render.Box())
+`
+	provider := mapSecrets{"test": {"API_KEY": "key", "OTHER_APP_KEY": "other"}}
+	app, err := NewApplet("test", []byte(src), WithSecretsProvider(provider), WithPolicy(Policy{Secrets: []string{"API_KEY"}}))
+	require.NoError(t, err)
+
+	_, err = app.RunWithConfig(context.Background(), map[string]string{"name": "API_KEY"})
+	assert.NoError(t, err)
+
+	_, err = app.RunWithConfig(context.Background(), map[string]string{"name": "OTHER_APP_KEY"})
+	assert.ErrorContains(t, err, "not allowed by policy")
+}
@@ -63,6 +63,15 @@ assert(0 < t1.size()[0], "0 < t1.size()[0]")
 assert(0 < t1.size()[1], "0 < t1.size()[1]")
 assert(t1.frame_count() == 1, "t1.frame_count() == 1")
 
+t2 = render.Text(
+    content = "a日",
+    fallback = [render.fonts["cjk-12"]],
+)
+assert(t2.fallback == ["cjk-12"], 't2.fallback == ["cjk-12"]')
+assert(t2.size()[0] > render.Text("a", fallback = []).size()[0] + 6, "t2 uses the fallback glyph")
+assert(t1.fallback == [], "t1.fallback == []")
+assert(render.Text("a日").size() == render.Text("a日", fallback = []).size(), "no fallback by default")
+
 # WrappedText
 tw = render.WrappedText(
     height = 16,
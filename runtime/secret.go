@@ -2,28 +2,117 @@ package runtime
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"regexp"
 	"sync"
 
 	"github.com/google/tink/go/hybrid"
+	"github.com/google/tink/go/insecurecleartextkeyset"
 	"github.com/google/tink/go/keyset"
 	"github.com/google/tink/go/tink"
 	"go.starlark.net/starlark"
 	"go.starlark.net/starlarkstruct"
+
+	"tidbyt.dev/pixlet/starlarkutil"
 )
 
 const (
 	threadDecrypterKey = "tidbyt.dev/pixlet/runtime/decrypter"
+	threadSecretsKey   = "tidbyt.dev/pixlet/runtime/secrets"
+
+	// SecretsManifestName is the file in an app that secret.get reads
+	// encrypted secrets from, as written by pixlet encrypt --write.
+	SecretsManifestName = "secrets.json"
 )
 
+// SecretsProvider looks up secrets for apps, for hosts that keep API
+// keys in one place, like Vault or a cloud secrets manager, rather than
+// encrypting them into every app. secret.get asks it for secrets that
+// aren't in the app's secrets manifest, or all of them if there's no
+// key to decrypt the manifest with.
+type SecretsProvider interface {
+	// GetSecret returns the secret with the given name for the app
+	// with ID appID. It returns an error wrapping fs.ErrNotExist if
+	// there's no such secret.
+	GetSecret(ctx context.Context, appID, name string) (string, error)
+}
+
+// secretsProvider is used by apps that aren't given their own.
+var secretsProvider SecretsProvider
+
+// InitSecrets sets the provider secret.get asks for secrets, for apps
+// that aren't given one with WithSecretsProvider.
+func InitSecrets(provider SecretsProvider) {
+	secretsProvider = provider
+}
+
+// WithSecretsProvider sets the provider secret.get asks for secrets.
+func WithSecretsProvider(provider SecretsProvider) AppletOption {
+	return func(a *Applet) error {
+		a.secrets = provider
+		return nil
+	}
+}
+
+// appSecrets are the secrets an app can get with secret.get.
+type appSecrets struct {
+	appID string
+
+	// embedded are the encrypted secrets in the app's secrets
+	// manifest, by name
+	embedded map[string]string
+	provider SecretsProvider
+}
+
+// loadSecretsManifest reads the encrypted secrets in the app's secrets
+// manifest, if it has one.
+func (a *Applet) loadSecretsManifest(fsys fs.FS) error {
+	data, err := fs.ReadFile(fsys, SecretsManifestName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", SecretsManifestName, err)
+	}
+
+	var m struct {
+		Secrets map[string]string `json:"secrets"`
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("reading %s: %w", SecretsManifestName, err)
+	}
+
+	a.embeddedSecrets = m.Secrets
+	return nil
+}
+
+func (a *Applet) attachSecrets(t *starlark.Thread) {
+	provider := a.secrets
+	if provider == nil {
+		provider = secretsProvider
+	}
+
+	t.SetLocal(threadSecretsKey, &appSecrets{
+		appID:    a.ID,
+		embedded: a.embeddedSecrets,
+		provider: provider,
+	})
+}
+
 // SecretDecryptionKey is a key that can be used to decrypt secrets.
 type SecretDecryptionKey struct {
 	// EncryptedKeysetJSON is the encrypted JSON representation of a Tink keyset.
 	EncryptedKeysetJSON []byte
 
 	// KeyEncryptionKey is a Tink key that can be used to decrypt the keyset.
+	// If it's nil, the keyset is read as cleartext, which is only meant for
+	// keys that never leave the machine they were made on.
 	KeyEncryptionKey tink.AEAD
 }
 
@@ -58,6 +147,52 @@ func (sek *SecretEncryptionKey) Encrypt(appID, plaintext string) (string, error)
 	return base64.StdEncoding.EncodeToString(ciphertext), nil
 }
 
+// CheckEncrypted checks that an encrypted secret is well formed, and was
+// encrypted with this key. It can't tell which app the secret is for, or
+// whether it decrypts, since that takes the private key.
+func (sek *SecretEncryptionKey) CheckEncrypted(encrypted string) error {
+	r := bytes.NewReader(sek.PublicKeysetJSON)
+	kh, err := keyset.ReadWithNoSecrets(keyset.NewJSONReader(r))
+	if err != nil {
+		return fmt.Errorf("%s: %w", "reading keyset JSON", err)
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(regexp.MustCompile(`\s`).ReplaceAllString(encrypted, ""))
+	if err != nil {
+		return fmt.Errorf("base64 decoding of secret: %w", err)
+	}
+
+	// Tink prefixes ciphertexts with a version byte and the ID of the key
+	// they were encrypted with
+	if len(ciphertext) <= 5 || ciphertext[0] != 1 {
+		return fmt.Errorf("secret is not a Tink ciphertext")
+	}
+
+	keyID := binary.BigEndian.Uint32(ciphertext[1:5])
+	for _, k := range kh.KeysetInfo().KeyInfo {
+		if k.KeyId == keyID {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("secret was encrypted with key %d, which is not in the keyset", keyID)
+}
+
+// Decrypt decrypts a secret that was encrypted for the app with ID appID.
+func (sdk *SecretDecryptionKey) Decrypt(appID, encrypted string) (string, error) {
+	dec, err := sdk.decrypterForID(appID)
+	if err != nil {
+		return "", err
+	}
+
+	cleartext, err := dec(starlark.String(encrypted))
+	if err != nil {
+		return "", err
+	}
+
+	return cleartext.GoString(), nil
+}
+
 var (
 	secretOnce   sync.Once
 	secretModule starlark.StringDict
@@ -70,6 +205,7 @@ func LoadSecretModule() (starlark.StringDict, error) {
 				Name: "secret",
 				Members: starlark.StringDict{
 					"decrypt": starlark.NewBuiltin("decrypt", secretDecrypt),
+					"get":     starlark.NewBuiltin("get", secretGet),
 				},
 			},
 		}
@@ -81,8 +217,19 @@ func LoadSecretModule() (starlark.StringDict, error) {
 type decrypter func(starlark.String) (starlark.String, error)
 
 func (sdk *SecretDecryptionKey) decrypterForApp(a *Applet) (decrypter, error) {
+	return sdk.decrypterForID(a.ID)
+}
+
+func (sdk *SecretDecryptionKey) decrypterForID(appID string) (decrypter, error) {
 	r := bytes.NewReader(sdk.EncryptedKeysetJSON)
-	kh, err := keyset.Read(keyset.NewJSONReader(r), sdk.KeyEncryptionKey)
+
+	var kh *keyset.Handle
+	var err error
+	if sdk.KeyEncryptionKey == nil {
+		kh, err = insecurecleartextkeyset.Read(keyset.NewJSONReader(r))
+	} else {
+		kh, err = keyset.Read(keyset.NewJSONReader(r), sdk.KeyEncryptionKey)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", "reading keyset JSON", err)
 	}
@@ -92,7 +239,7 @@ func (sdk *SecretDecryptionKey) decrypterForApp(a *Applet) (decrypter, error) {
 		return nil, fmt.Errorf("%s: %w", "NewHybridDecrypt", err)
 	}
 
-	context := []byte(a.ID)
+	context := []byte(appID)
 
 	return func(s starlark.String) (starlark.String, error) {
 		v := regexp.MustCompile(`\s`).ReplaceAllString(s.GoString(), "")
@@ -143,3 +290,39 @@ func secretDecrypt(thread *starlark.Thread, _ *starlark.Builtin, args starlark.T
 
 	return dec(encryptedVal)
 }
+
+func secretGet(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var name string
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "name", &name); err != nil {
+		return nil, fmt.Errorf("unpacking arguments for secret.get: %v", err)
+	}
+
+	if err := policyForThread(thread).checkSecret(name); err != nil {
+		return nil, fmt.Errorf("secret.get: %w", err)
+	}
+
+	s, ok := thread.Local(threadSecretsKey).(*appSecrets)
+	if !ok || s == nil {
+		return starlark.None, nil
+	}
+
+	if encrypted, ok := s.embedded[name]; ok {
+		if dec := decrypterForThread(thread); dec != nil {
+			return dec(starlark.String(encrypted))
+		}
+	}
+
+	if s.provider == nil {
+		return starlark.None, nil
+	}
+
+	value, err := s.provider.GetSecret(starlarkutil.ThreadContext(thread), s.appID, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return starlark.None, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("secret.get: %s: %w", name, err)
+	}
+
+	return starlark.String(value), nil
+}
@@ -3,10 +3,14 @@ package runtime
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"testing"
+	"testing/fstest"
 
 	"github.com/google/tink/go/hybrid"
+	"github.com/google/tink/go/insecurecleartextkeyset"
 	"github.com/google/tink/go/keyset"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -132,3 +136,135 @@ def main():
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(roots))
 }
+
+func TestSecretDecryptionKeyDecrypt(t *testing.T) {
+	khPriv, err := keyset.NewHandle(hybrid.ECIESHKDFAES128CTRHMACSHA256KeyTemplate())
+	require.NoError(t, err)
+
+	// without a key encryption key, the keyset is read as cleartext
+	privJSON := &bytes.Buffer{}
+	err = insecurecleartextkeyset.Write(khPriv, keyset.NewJSONWriter(privJSON))
+	require.NoError(t, err)
+
+	khPub, err := khPriv.Public()
+	require.NoError(t, err)
+
+	pubJSON := &bytes.Buffer{}
+	err = khPub.WriteWithNoSecrets(keyset.NewJSONWriter(pubJSON))
+	require.NoError(t, err)
+
+	sek := &SecretEncryptionKey{PublicKeysetJSON: pubJSON.Bytes()}
+	encrypted, err := sek.Encrypt("testid", "h4x0rrszZ!!")
+	require.NoError(t, err)
+
+	sdk := &SecretDecryptionKey{EncryptedKeysetJSON: privJSON.Bytes()}
+
+	decrypted, err := sdk.Decrypt("testid", encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "h4x0rrszZ!!", decrypted)
+
+	// secrets only decrypt for the app they were encrypted for
+	_, err = sdk.Decrypt("otherid", encrypted)
+	assert.Error(t, err)
+}
+
+func TestSecretEncryptionKeyCheckEncrypted(t *testing.T) {
+	newPublicKeyset := func() []byte {
+		kh, err := keyset.NewHandle(hybrid.ECIESHKDFAES128CTRHMACSHA256KeyTemplate())
+		require.NoError(t, err)
+		pub, err := kh.Public()
+		require.NoError(t, err)
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, pub.WriteWithNoSecrets(keyset.NewJSONWriter(buf)))
+		return buf.Bytes()
+	}
+
+	sek := &SecretEncryptionKey{PublicKeysetJSON: newPublicKeyset()}
+	other := &SecretEncryptionKey{PublicKeysetJSON: newPublicKeyset()}
+
+	encrypted, err := sek.Encrypt("testid", "h4x0rrszZ!!")
+	require.NoError(t, err)
+
+	assert.NoError(t, sek.CheckEncrypted(encrypted))
+	assert.Error(t, other.CheckEncrypted(encrypted))
+	assert.Error(t, sek.CheckEncrypted("not base64!"))
+	assert.Error(t, sek.CheckEncrypted("aGVsbG8gd29ybGQ="))
+}
+
+// mapSecrets is a SecretsProvider backed by a map of app ID to secrets.
+type mapSecrets map[string]map[string]string
+
+func (m mapSecrets) GetSecret(ctx context.Context, appID, name string) (string, error) {
+	if name == "BROKEN" {
+		return "", errors.New("secret store is down")
+	}
+	value, ok := m[appID][name]
+	if !ok {
+		return "", fs.ErrNotExist
+	}
+	return value, nil
+}
+
+func TestSecretGet(t *testing.T) {
+	khPriv, err := keyset.NewHandle(hybrid.ECIESHKDFAES128CTRHMACSHA256KeyTemplate())
+	require.NoError(t, err)
+	privJSON := &bytes.Buffer{}
+	require.NoError(t, insecurecleartextkeyset.Write(khPriv, keyset.NewJSONWriter(privJSON)))
+	khPub, err := khPriv.Public()
+	require.NoError(t, err)
+	pubJSON := &bytes.Buffer{}
+	require.NoError(t, khPub.WriteWithNoSecrets(keyset.NewJSONWriter(pubJSON)))
+
+	encrypted, err := (&SecretEncryptionKey{PublicKeysetJSON: pubJSON.Bytes()}).Encrypt("testid", "embedded")
+	require.NoError(t, err)
+
+	src := `
+load("render.star", "render")
+load("secret.star", "secret")
+
+# secrets can be read while loading, too
+API_KEY = secret.get("API_KEY")
+
+def main(config):
+    if API_KEY != config.get("api_key"):
+        fail("API_KEY is", API_KEY)
+    if secret.get("TOKEN") != config.get("token"):
+        fail("TOKEN is", secret.get("TOKEN"))
+    if secret.get("MISSING") != None:
+        fail("MISSING is", secret.get("MISSING"))
+    if config.get("broken"):
+        secret.get("BROKEN")
+    return render.Root(child = render.Box())
+`
+	vfs := fstest.MapFS{
+		"app.star":          {Data: []byte(src)},
+		SecretsManifestName: {Data: []byte(fmt.Sprintf(`{"app_id": "testid", "secrets": {"API_KEY": %q}}`, encrypted))},
+	}
+	provider := mapSecrets{"testid": {"API_KEY": "provided", "TOKEN": "token"}}
+
+	// embedded secrets come first, and the provider fills in the rest
+	app, err := NewAppletFromFS("testid", vfs,
+		WithSecretDecryptionKey(&SecretDecryptionKey{EncryptedKeysetJSON: privJSON.Bytes()}),
+		WithSecretsProvider(provider),
+	)
+	require.NoError(t, err)
+	_, err = app.RunWithConfig(context.Background(), map[string]string{"api_key": "embedded", "token": "token"})
+	assert.NoError(t, err)
+
+	// errors other than missing secrets fail the app
+	_, err = app.RunWithConfig(context.Background(), map[string]string{"api_key": "embedded", "token": "token", "broken": "true"})
+	assert.ErrorContains(t, err, "secret store is down")
+
+	// without a decryption key, everything comes from the provider
+	app, err = NewAppletFromFS("testid", vfs, WithSecretsProvider(provider))
+	require.NoError(t, err)
+	_, err = app.RunWithConfig(context.Background(), map[string]string{"api_key": "provided", "token": "token"})
+	assert.NoError(t, err)
+
+	// and without a provider, secrets are None
+	app, err = NewAppletFromFS("testid", vfs)
+	require.NoError(t, err)
+	_, err = app.RunWithConfig(context.Background(), map[string]string{})
+	assert.NoError(t, err)
+}
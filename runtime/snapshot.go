@@ -0,0 +1,265 @@
+package runtime
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"slices"
+	"sort"
+	"testing/fstest"
+
+	"go.starlark.net/starlark"
+
+	"tidbyt.dev/pixlet/manifest"
+)
+
+// snapshotMagic starts every snapshot, followed by the length of the
+// index as a little endian uint32, the index as JSON, and then the
+// files.
+const snapshotMagic = "pixlet snapshot\n"
+
+// snapshotVersion changes when the format of snapshots does.
+const snapshotVersion = 1
+
+type snapshotIndex struct {
+	Version  int `json:"version"`
+	Compiler int `json:"compiler"`
+
+	ID          string             `json:"id"`
+	SchemaJSON  json.RawMessage    `json:"schema,omitempty"`
+	Manifest    *manifest.Manifest `json:"manifest,omitempty"`
+	Predeclared []string           `json:"predeclared"`
+	Files       []snapshotFile     `json:"files"`
+}
+
+// snapshotFile is where a file is in a snapshot, relative to the end of
+// the index. Starlark files are kept compiled.
+type snapshotFile struct {
+	Path     string `json:"path"`
+	Compiled bool   `json:"compiled,omitempty"`
+	Offset   int    `json:"offset"`
+	Size     int    `json:"size"`
+}
+
+// Snapshot writes the app to w as an archive that LoadSnapshot can
+// instantiate it from, without parsing and compiling its Starlark
+// again. The archive holds the compiled programs, the other files the
+// app loaded, its secrets, schema JSON and manifest.
+//
+// The archive only loads with the version of Starlark that made it, so
+// make snapshots when deploying, rather than keeping them around.
+func (a *Applet) Snapshot(w io.Writer) error {
+	if a.fsys == nil {
+		return fmt.Errorf("%s wasn't loaded from files, so can't be snapshotted", a.ID)
+	}
+
+	index := snapshotIndex{
+		Version:    snapshotVersion,
+		Compiler:   starlark.CompilerVersion,
+		ID:         a.ID,
+		SchemaJSON: a.SchemaJSON,
+		Files:      []snapshotFile{},
+	}
+	for name := range a.predeclaredGlobals() {
+		index.Predeclared = append(index.Predeclared, name)
+	}
+	sort.Strings(index.Predeclared)
+
+	paths := a.PathsForBundle()
+	for _, extra := range []string{SecretsManifestName, manifest.ManifestFileName} {
+		if _, err := fs.Stat(a.fsys, extra); err == nil && !slices.Contains(paths, extra) {
+			paths = append(paths, extra)
+		}
+	}
+	sort.Strings(paths)
+
+	var files bytes.Buffer
+	for _, p := range paths {
+		f := snapshotFile{Path: p, Offset: files.Len()}
+
+		if prog, ok := a.programs[p]; ok {
+			f.Compiled = true
+			if err := prog.Write(&files); err != nil {
+				return fmt.Errorf("writing %s: %w", p, err)
+			}
+		} else {
+			data, err := fs.ReadFile(a.fsys, p)
+			if err != nil {
+				return fmt.Errorf("reading %s: %w", p, err)
+			}
+			files.Write(data)
+
+			if p == manifest.ManifestFileName {
+				if index.Manifest, err = manifest.LoadManifest(bytes.NewReader(data)); err != nil {
+					return err
+				}
+			}
+		}
+
+		f.Size = files.Len() - f.Offset
+		index.Files = append(index.Files, f)
+	}
+
+	indexJSON, err := json.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("marshaling snapshot index: %w", err)
+	}
+
+	header := make([]byte, len(snapshotMagic)+4)
+	copy(header, snapshotMagic)
+	binary.LittleEndian.PutUint32(header[len(snapshotMagic):], uint32(len(indexJSON)))
+
+	for _, b := range [][]byte{header, indexJSON, files.Bytes()} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Snapshot is an archive written by Applet.Snapshot, read and ready to
+// load apps from. Its ID, schema and manifest can be read without
+// loading the app.
+type Snapshot struct {
+	// ID is the app's ID.
+	ID string
+
+	// SchemaJSON is the app's schema, as served to the Tidbyt mobile
+	// app, or nil if it doesn't have one.
+	SchemaJSON []byte
+
+	// Manifest is the app's manifest, or nil if it was loaded without
+	// one.
+	Manifest *manifest.Manifest
+
+	predeclared []string
+	fsys        fstest.MapFS
+	programs    map[string]*starlark.Program
+	close       func() error
+}
+
+// ReadSnapshot reads a snapshot written by Applet.Snapshot. Files in it
+// aren't copied, so data can be memory-mapped, but mustn't change while
+// apps loaded from it are in use.
+func ReadSnapshot(data []byte) (*Snapshot, error) {
+	if !bytes.HasPrefix(data, []byte(snapshotMagic)) || len(data) < len(snapshotMagic)+4 {
+		return nil, errors.New("not a pixlet snapshot")
+	}
+	data = data[len(snapshotMagic):]
+
+	size := binary.LittleEndian.Uint32(data)
+	data = data[4:]
+	if uint64(size) > uint64(len(data)) {
+		return nil, errors.New("snapshot is truncated")
+	}
+
+	var index snapshotIndex
+	if err := json.Unmarshal(data[:size], &index); err != nil {
+		return nil, fmt.Errorf("reading snapshot index: %w", err)
+	}
+	data = data[size:]
+
+	if index.Version != snapshotVersion {
+		return nil, fmt.Errorf("snapshot is version %d, but only version %d is supported", index.Version, snapshotVersion)
+	}
+	if index.Compiler != starlark.CompilerVersion {
+		return nil, fmt.Errorf("snapshot was compiled by another version of Starlark, make it again")
+	}
+
+	s := &Snapshot{
+		ID:          index.ID,
+		SchemaJSON:  index.SchemaJSON,
+		Manifest:    index.Manifest,
+		predeclared: index.Predeclared,
+		fsys:        fstest.MapFS{},
+		programs:    map[string]*starlark.Program{},
+	}
+
+	for _, f := range index.Files {
+		if f.Offset < 0 || f.Size < 0 || f.Offset+f.Size > len(data) {
+			return nil, fmt.Errorf("snapshot is truncated at %s", f.Path)
+		}
+		content := data[f.Offset : f.Offset+f.Size : f.Offset+f.Size]
+
+		if !f.Compiled {
+			s.fsys[f.Path] = &fstest.MapFile{Data: content}
+			continue
+		}
+
+		prog, err := starlark.CompiledProgram(bytes.NewReader(content))
+		if err != nil {
+			return nil, fmt.Errorf("reading %s from snapshot: %w", f.Path, err)
+		}
+		s.programs[f.Path] = prog
+
+		// there for loading to find, but never read
+		s.fsys[f.Path] = &fstest.MapFile{}
+	}
+
+	return s, nil
+}
+
+// Load instantiates the app in the snapshot, running the top level of
+// its files and its schema function, as NewAppletFromFS would. It can
+// be called any number of times, and from several goroutines.
+func (s *Snapshot) Load(opts ...AppletOption) (*Applet, error) {
+	a, err := newApplet(s.ID, s.fsys, s.programs, append(slices.Clip(opts), s.checkPredeclared)...)
+	if err != nil {
+		return nil, fmt.Errorf("loading %s from snapshot: %w", s.ID, err)
+	}
+	return a, nil
+}
+
+// checkPredeclared makes sure the app is loaded with the globals it was
+// compiled with, since compiled programs look them up by name.
+func (s *Snapshot) checkPredeclared(a *Applet) error {
+	predeclared := a.predeclaredGlobals()
+	for _, name := range s.predeclared {
+		if !predeclared.Has(name) {
+			return fmt.Errorf("it was compiled with %s predeclared, see WithPredeclared", name)
+		}
+	}
+	return nil
+}
+
+// Close releases the snapshot's memory, if it was opened with
+// OpenSnapshot. Apps loaded from it mustn't be used after.
+func (s *Snapshot) Close() error {
+	if s.close == nil {
+		return nil
+	}
+	return s.close()
+}
+
+// LoadSnapshot instantiates an app from a snapshot written by
+// Applet.Snapshot. It's ReadSnapshot followed by Load, for hosts that
+// load each snapshot once.
+func LoadSnapshot(data []byte, opts ...AppletOption) (*Applet, error) {
+	s, err := ReadSnapshot(data)
+	if err != nil {
+		return nil, err
+	}
+	return s.Load(opts...)
+}
+
+// OpenSnapshot reads the snapshot in a file, memory-mapping it where
+// the platform allows, so that the pages of files the app never reads
+// are never loaded. Close it when done with the apps loaded from it.
+func OpenSnapshot(path string) (*Snapshot, error) {
+	data, unmap, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := ReadSnapshot(data)
+	if err != nil {
+		unmap()
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	s.close = unmap
+	return s, nil
+}
@@ -0,0 +1,17 @@
+//go:build !unix
+
+package runtime
+
+import (
+	"os"
+)
+
+// mapFile reads the file at path, on platforms that can't memory-map
+// it.
+func mapFile(path string) ([]byte, func() error, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return nil }, nil
+}
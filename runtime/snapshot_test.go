@@ -0,0 +1,158 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.starlark.net/starlark"
+)
+
+var snapshotApp = fstest.MapFS{
+	"manifest.yaml": {Data: []byte("---\nid: snapshot-test\nname: Snapshot Test\n")},
+	"main.star": {Data: []byte(`
+load("render.star", "render")
+load("schema.star", "schema")
+load("lib/greet.star", "greet")
+load("hello.txt", hello = "file")
+
+def main(config):
+    return render.Root(child = render.Text(greet(config.get("who", hello.readall()))))
+
+def get_schema():
+    return schema.Schema(
+        version = "1",
+        fields = [
+            schema.Text(id = "who", name = "Who", desc = "Who to greet", icon = "user"),
+        ],
+    )
+`)},
+	"lib/greet.star": {Data: []byte(`
+def greet(who):
+    return "hi " + who
+`)},
+	"hello.txt":  {Data: []byte("world")},
+	"unused.txt": {Data: []byte("not loaded")},
+}
+
+func snapshot(t *testing.T, app *Applet) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, app.Snapshot(&buf))
+	return buf.Bytes()
+}
+
+func TestSnapshot(t *testing.T) {
+	app, err := NewAppletFromFS("snapshot-test", snapshotApp)
+	require.NoError(t, err)
+	data := snapshot(t, app)
+
+	s, err := ReadSnapshot(data)
+	require.NoError(t, err)
+	assert.Equal(t, "snapshot-test", s.ID)
+	assert.Equal(t, "Snapshot Test", s.Manifest.Name)
+	assert.JSONEq(t, string(app.SchemaJSON), string(s.SchemaJSON))
+	assert.NotContains(t, s.fsys, "unused.txt")
+	assert.Empty(t, s.fsys["main.star"].Data, "source isn't kept")
+
+	loaded, err := s.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "main.star", loaded.MainFile)
+	assert.Equal(t, app.SchemaJSON, loaded.SchemaJSON)
+	assert.ElementsMatch(t, app.PathsForBundle(), loaded.PathsForBundle())
+
+	for _, config := range []map[string]string{{}, {"who": "there"}} {
+		want, err := app.RunWithConfig(context.Background(), config)
+		require.NoError(t, err)
+		got, err := loaded.RunWithConfig(context.Background(), config)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	// the snapshot of a snapshot is the same
+	assert.Equal(t, data, snapshot(t, loaded))
+}
+
+func TestSnapshotPredeclared(t *testing.T) {
+	predeclared := starlark.StringDict{"greeting": starlark.String("hi")}
+	app, err := NewApplet("predeclared", []byte(`
+def main():
+    return greeting
+`), WithPredeclared(predeclared))
+	require.NoError(t, err)
+	data := snapshot(t, app)
+
+	_, err = LoadSnapshot(data)
+	assert.ErrorContains(t, err, "compiled with greeting predeclared")
+
+	_, err = LoadSnapshot(data, WithPredeclared(predeclared))
+	assert.NoError(t, err)
+}
+
+func TestSnapshotInvalid(t *testing.T) {
+	_, err := ReadSnapshot([]byte("hello"))
+	assert.ErrorContains(t, err, "not a pixlet snapshot")
+
+	app, err := NewAppletFromFS("snapshot-test", snapshotApp)
+	require.NoError(t, err)
+	data := snapshot(t, app)
+
+	_, err = ReadSnapshot(data[:len(data)-10])
+	assert.ErrorContains(t, err, "truncated")
+
+	// snapshots from other versions of Starlark won't load
+	version := fmt.Sprintf(`"compiler":%d`, starlark.CompilerVersion)
+	other := bytes.Replace(data, []byte(version), []byte(`"compiler":`+strings.Repeat("9", len(version)-11)), 1)
+	_, err = ReadSnapshot(other)
+	assert.ErrorContains(t, err, "another version of Starlark")
+}
+
+func TestOpenSnapshot(t *testing.T) {
+	app, err := NewAppletFromFS("snapshot-test", snapshotApp)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "app.snapshot")
+	require.NoError(t, os.WriteFile(path, snapshot(t, app), 0644))
+
+	s, err := OpenSnapshot(path)
+	require.NoError(t, err)
+	defer s.Close()
+
+	loaded, err := s.Load()
+	require.NoError(t, err)
+	roots, err := loaded.Run(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, roots, 1)
+}
+
+func BenchmarkLoad(b *testing.B) {
+	app, err := NewAppletFromFS("snapshot-test", snapshotApp)
+	require.NoError(b, err)
+	var buf bytes.Buffer
+	require.NoError(b, app.Snapshot(&buf))
+
+	s, err := ReadSnapshot(buf.Bytes())
+	require.NoError(b, err)
+
+	b.Run("source", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := NewAppletFromFS("snapshot-test", snapshotApp); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("snapshot", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := s.Load(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
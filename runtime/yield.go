@@ -0,0 +1,9 @@
+//go:build !js && !wasm
+
+package runtime
+
+import "go.starlark.net/starlark"
+
+// yieldPeriodically is only needed in WASM, where goroutines aren't
+// preempted.
+func yieldPeriodically(t *starlark.Thread) {}
@@ -0,0 +1,24 @@
+//go:build js && wasm
+
+package runtime
+
+import (
+	goruntime "runtime"
+
+	"go.starlark.net/starlark"
+)
+
+// yieldSteps is how many steps apps take between yielding to other
+// goroutines.
+const yieldSteps = 10000
+
+// yieldPeriodically makes t yield to other goroutines every so often.
+// WASM has a single thread and no preemption, so otherwise the
+// goroutines that enforce Limits never run while an app is busy.
+func yieldPeriodically(t *starlark.Thread) {
+	t.OnMaxSteps = func(t *starlark.Thread) {
+		goruntime.Gosched()
+		t.SetMaxExecutionSteps(t.ExecutionSteps() + yieldSteps)
+	}
+	t.SetMaxExecutionSteps(yieldSteps)
+}
@@ -0,0 +1,67 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSchedule(t *testing.T) {
+	start := time.Date(2024, 3, 15, 10, 30, 20, 0, time.UTC)
+
+	for _, tc := range []struct {
+		spec string
+		next string
+	}{
+		{"15m", "2024-03-15T10:45:20Z"},
+		{"@every 1h", "2024-03-15T11:30:20Z"},
+		{"* * * * *", "2024-03-15T10:31:00Z"},
+		{"*/15 * * * *", "2024-03-15T10:45:00Z"},
+		{"0 7 * * *", "2024-03-16T07:00:00Z"},
+		{"0 7-9,18 * * *", "2024-03-15T18:00:00Z"},
+		{"30 9 * * mon-fri", "2024-03-18T09:30:00Z"},
+		{"0 0 1 * *", "2024-04-01T00:00:00Z"},
+		{"0 12 * jun *", "2024-06-01T12:00:00Z"},
+		{"0 0 29 2 *", "2028-02-29T00:00:00Z"},
+		{"0 0 * * 7", "2024-03-17T00:00:00Z"},
+		{"@hourly", "2024-03-15T11:00:00Z"},
+		{"@weekly", "2024-03-17T00:00:00Z"},
+		{"TZ=Europe/Oslo 0 12 * * *", "2024-03-15T11:00:00Z"},
+
+		// either the day of the month or the day of the week
+		{"0 0 20 * fri", "2024-03-20T00:00:00Z"},
+	} {
+		s, err := ParseSchedule(tc.spec)
+		require.NoError(t, err, tc.spec)
+		assert.Equal(t, tc.next, s.Next(start).UTC().Format(time.RFC3339), tc.spec)
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"-5m",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"@sometimes",
+		"@every soon",
+		"TZ=Nowhere/Special * * * * *",
+	} {
+		_, err := ParseSchedule(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestCronNeverMatches(t *testing.T) {
+	s, err := ParseCron("0 0 31 2 *")
+	require.NoError(t, err)
+	assert.True(t, s.Next(time.Now()).IsZero())
+}
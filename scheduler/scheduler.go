@@ -0,0 +1,460 @@
+// Package scheduler renders apps on a schedule and sends what they
+// render to sinks, like devices, an MQTT broker or files. It's what
+// pixlet daemon is built on, and can be used by Go programs that keep
+// devices up to date themselves.
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"tidbyt.dev/pixlet/configstore"
+	"tidbyt.dev/pixlet/encode"
+)
+
+const (
+	// DefaultMaxDuration is the longest animation jobs are encoded as,
+	// in milliseconds, unless told otherwise.
+	DefaultMaxDuration = 15000
+
+	// DefaultMissedAfter is how late a run can start before it's
+	// missed, unless told otherwise.
+	DefaultMissedAfter = time.Minute
+)
+
+// MissedRunPolicy is what a Scheduler does with runs that couldn't start
+// on time, like when the computer was asleep.
+type MissedRunPolicy int
+
+const (
+	// RunMissed runs the job once, as soon as it can, however many runs
+	// were missed.
+	RunMissed MissedRunPolicy = iota
+
+	// SkipMissed skips missed runs, and waits for the next one.
+	SkipMissed
+)
+
+// ParseMissedRunPolicy parses "run" or "skip".
+func ParseMissedRunPolicy(s string) (MissedRunPolicy, error) {
+	switch s {
+	case "", "run":
+		return RunMissed, nil
+	case "skip":
+		return SkipMissed, nil
+	default:
+		return 0, fmt.Errorf("unknown missed run policy: %s", s)
+	}
+}
+
+// RenderFunc renders an app. It returns nil screens if the app returned
+// no roots.
+type RenderFunc func(ctx context.Context) (*encode.Screens, error)
+
+// StoredConfig returns a RenderFunc that renders with defaults
+// overridden by the config kept in store for key. The config is read on
+// every run, so changes made elsewhere, like in pixlet serve, show up on
+// the next one.
+func StoredConfig(store configstore.Store, key configstore.Key, defaults map[string]string, render func(ctx context.Context, config map[string]string) (*encode.Screens, error)) RenderFunc {
+	return func(ctx context.Context) (*encode.Screens, error) {
+		config, err := configstore.Load(ctx, store, key, defaults)
+		if err != nil {
+			return nil, err
+		}
+		return render(ctx, config)
+	}
+}
+
+// Job is an app to render on a schedule.
+type Job struct {
+	// ID identifies the job. It's also the name output is sent under.
+	ID string
+
+	Schedule Schedule
+	Render   RenderFunc
+	Sinks    []Sink
+}
+
+// Result is what happened when a job ran.
+type Result struct {
+	Job  string
+	Time time.Time
+
+	// Image is what the job rendered, if anything.
+	Image []byte
+
+	// Sent is whether the image was sent to every sink.
+	Sent bool
+
+	// Skipped is whether the app returned no roots, so nothing was
+	// sent.
+	Skipped bool
+
+	// Unchanged is whether the image was the same as was last sent, so
+	// it wasn't sent again.
+	Unchanged bool
+
+	// Missed is whether the run was skipped for starting too late.
+	Missed bool
+
+	Err error
+}
+
+// Scheduler runs jobs on their schedules. Set its fields before adding
+// jobs.
+type Scheduler struct {
+	// Jitter delays every run by a random amount up to it, so that jobs
+	// scheduled for the same time don't all run at once.
+	Jitter time.Duration
+
+	// MaxConcurrent is how many jobs render at once at most. If zero,
+	// there's no limit.
+	MaxConcurrent int
+
+	// Missed is what's done with runs that start more than MissedAfter
+	// late. If MissedAfter is zero, it's DefaultMissedAfter.
+	Missed      MissedRunPolicy
+	MissedAfter time.Duration
+
+	// RunAtStart runs every job when Run starts, rather than waiting
+	// for their schedules, so that there's something to show.
+	RunAtStart bool
+
+	// SendUnchanged sends images even if they're the same as were last
+	// sent.
+	SendUnchanged bool
+
+	// MaxDuration is the longest animation jobs are encoded as, in
+	// milliseconds. If zero, it's DefaultMaxDuration.
+	MaxDuration int
+
+	// OnResult, if set, is called after each run, one at a time for
+	// each job.
+	OnResult func(Result)
+
+	mu    sync.Mutex
+	jobs  []*jobState
+	byID  map[string]*jobState
+	slots chan struct{}
+}
+
+type jobState struct {
+	job     *Job
+	trigger chan struct{}
+
+	// mu is held while the job runs, so that runs of one job don't
+	// overlap
+	mu   sync.Mutex
+	last []byte
+}
+
+// Add adds a job. Jobs added after Run has started aren't run.
+func (s *Scheduler) Add(job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if job.ID == "" {
+		return fmt.Errorf("job has no ID")
+	}
+	if job.Schedule == nil || job.Render == nil {
+		return fmt.Errorf("%s: job needs a schedule and a render function", job.ID)
+	}
+	if _, ok := s.byID[job.ID]; ok {
+		return fmt.Errorf("there's already a job with ID %s", job.ID)
+	}
+
+	if s.byID == nil {
+		s.byID = map[string]*jobState{}
+	}
+	st := &jobState{job: job, trigger: make(chan struct{}, 1)}
+	s.jobs = append(s.jobs, st)
+	s.byID[job.ID] = st
+	return nil
+}
+
+// Run runs every job on its schedule until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) error {
+	s.mu.Lock()
+	jobs := s.jobs
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, st := range jobs {
+		st := st
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.loop(ctx, st)
+		}()
+	}
+	wg.Wait()
+
+	return nil
+}
+
+// RunAll runs every job once, right away, and returns the errors of
+// those that failed.
+func (s *Scheduler) RunAll(ctx context.Context) error {
+	s.mu.Lock()
+	jobs := s.jobs
+	s.mu.Unlock()
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, st := range jobs {
+		st := st
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if res := s.run(ctx, st); res.Err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", st.job.ID, res.Err))
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// Trigger runs the job with the given ID as soon as it can, like when
+// its app has changed, while Run is running. Its schedule carries on
+// from then. It reports whether there's such a job.
+func (s *Scheduler) Trigger(id string) bool {
+	s.mu.Lock()
+	st, ok := s.byID[id]
+	s.mu.Unlock()
+
+	if ok {
+		select {
+		case st.trigger <- struct{}{}:
+		default:
+		}
+	}
+	return ok
+}
+
+// Dispatch sends screens rendered outside the schedule to the sinks of
+// the job with the given ID, like for an app rendered with other config
+// in response to an event. Screens are handled as if the job had
+// rendered them, and OnResult is called.
+func (s *Scheduler) Dispatch(ctx context.Context, id string, screens *encode.Screens) Result {
+	s.mu.Lock()
+	st, ok := s.byID[id]
+	s.mu.Unlock()
+
+	if !ok {
+		return Result{Job: id, Time: s.clock(), Err: fmt.Errorf("no such job: %s", id)}
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	res := s.send(ctx, st, screens)
+	s.report(res)
+	return res
+}
+
+// Encode encodes screens as WebP, the way output is encoded for sinks.
+func (s *Scheduler) Encode(screens *encode.Screens) ([]byte, error) {
+	duration := s.MaxDuration
+	if duration <= 0 {
+		duration = DefaultMaxDuration
+	}
+	if screens.ShowFullAnimation {
+		duration = 0
+	}
+	return screens.EncodeWebP(duration)
+}
+
+// loop runs a job on its schedule until ctx is done.
+func (s *Scheduler) loop(ctx context.Context, st *jobState) {
+	var next time.Time
+	if s.RunAtStart {
+		next = s.clock().Add(s.jitter())
+	} else {
+		next = s.next(st.job, s.clock())
+	}
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		if next.IsZero() {
+			// the schedule never runs again, but triggers still work
+			stopTimer(timer)
+		} else {
+			resetTimer(timer, next.Sub(s.clock()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-st.trigger:
+
+		case <-timer.C:
+			if late := s.clock().Sub(next); late > s.missedAfter() && s.Missed == SkipMissed {
+				st.mu.Lock()
+				s.report(Result{Job: st.job.ID, Time: s.clock(), Missed: true, Err: fmt.Errorf("missed run at %s, %s late", next.Format(time.RFC3339), late.Round(time.Second))})
+				st.mu.Unlock()
+				next = s.next(st.job, s.clock())
+				continue
+			}
+		}
+
+		s.run(ctx, st)
+
+		// runs that were missed while this one ran are run once,
+		// since the next run is counted from now
+		next = s.next(st.job, s.clock())
+	}
+}
+
+// stopTimer stops t, draining it if it already fired.
+func stopTimer(t *time.Timer) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+}
+
+// resetTimer resets t to fire after d.
+func resetTimer(t *time.Timer, d time.Duration) {
+	stopTimer(t)
+	t.Reset(d)
+}
+
+// next returns when job runs after t, with jitter.
+func (s *Scheduler) next(job *Job, t time.Time) time.Time {
+	next := job.Schedule.Next(t)
+	if next.IsZero() {
+		return next
+	}
+	return next.Add(s.jitter())
+}
+
+func (s *Scheduler) jitter() time.Duration {
+	if s.Jitter <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(s.Jitter)))
+}
+
+func (s *Scheduler) missedAfter() time.Duration {
+	if s.MissedAfter > 0 {
+		return s.MissedAfter
+	}
+	return DefaultMissedAfter
+}
+
+// clock returns the wall clock time, without a monotonic reading, so that
+// time spent asleep counts towards how late runs are.
+func (s *Scheduler) clock() time.Time {
+	return time.Now().Round(0)
+}
+
+// run renders a job and sends its output, waiting for a slot if
+// MaxConcurrent jobs are already rendering.
+func (s *Scheduler) run(ctx context.Context, st *jobState) Result {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if err := s.acquire(ctx); err != nil {
+		res := Result{Job: st.job.ID, Time: s.clock(), Err: err}
+		s.report(res)
+		return res
+	}
+	screens, err := st.job.Render(ctx)
+	s.release()
+
+	var res Result
+	if err != nil {
+		res = Result{Job: st.job.ID, Time: s.clock(), Err: err}
+	} else {
+		res = s.send(ctx, st, screens)
+	}
+
+	s.report(res)
+	return res
+}
+
+// send encodes screens and sends them to the job's sinks. Call it with
+// st.mu held.
+func (s *Scheduler) send(ctx context.Context, st *jobState, screens *encode.Screens) Result {
+	res := Result{Job: st.job.ID, Time: s.clock()}
+	if screens == nil {
+		res.Skipped = true
+		return res
+	}
+
+	res.Image, res.Err = s.Encode(screens)
+	if res.Err != nil {
+		res.Err = fmt.Errorf("error rendering: %w", res.Err)
+		return res
+	}
+
+	if !s.SendUnchanged && st.last != nil && bytes.Equal(st.last, res.Image) {
+		res.Unchanged = true
+		return res
+	}
+
+	out := &Output{Job: st.job.ID, WebP: res.Image, Screens: screens}
+
+	errs := []error{}
+	for _, sink := range st.job.Sinks {
+		if err := sink.Send(ctx, out); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if res.Err = errors.Join(errs...); res.Err != nil {
+		return res
+	}
+
+	st.last = res.Image
+	res.Sent = true
+	return res
+}
+
+func (s *Scheduler) report(res Result) {
+	if s.OnResult != nil {
+		s.OnResult(res)
+	}
+}
+
+func (s *Scheduler) acquire(ctx context.Context) error {
+	if s.MaxConcurrent <= 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	if s.slots == nil {
+		s.slots = make(chan struct{}, s.MaxConcurrent)
+	}
+	slots := s.slots
+	s.mu.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Scheduler) release() {
+	if s.MaxConcurrent > 0 {
+		<-s.slots
+	}
+}
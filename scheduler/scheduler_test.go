@@ -0,0 +1,293 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tidbyt.dev/pixlet/configstore"
+	"tidbyt.dev/pixlet/encode"
+	"tidbyt.dev/pixlet/runtime"
+)
+
+const textApp = `
+load("render.star", "render")
+
+def main(config):
+    if config.get("skip"):
+        return []
+    return render.Root(child = render.Text(config.get("msg", "hi")))
+`
+
+// appRender returns a RenderFunc for textApp, which renders the message
+// *msg points to.
+func appRender(t *testing.T, msg *string) RenderFunc {
+	app, err := runtime.NewApplet("test", []byte(textApp))
+	require.NoError(t, err)
+
+	return func(ctx context.Context) (*encode.Screens, error) {
+		config := map[string]string{"msg": *msg}
+		if *msg == "" {
+			config["skip"] = "true"
+		}
+		roots, err := app.RunWithConfig(ctx, config)
+		if err != nil || len(roots) == 0 {
+			return nil, err
+		}
+		return encode.ScreensFromRoots(roots), nil
+	}
+}
+
+// recorder is a sink that remembers what it was sent.
+type recorder struct {
+	mu   sync.Mutex
+	sent []*Output
+	err  error
+}
+
+func (r *recorder) Send(ctx context.Context, out *Output) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.sent = append(r.sent, out)
+	return nil
+}
+
+func (r *recorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.sent)
+}
+
+func TestRunAll(t *testing.T) {
+	msg := "hello"
+	sink := &recorder{}
+	results := []Result{}
+
+	s := &Scheduler{OnResult: func(r Result) { results = append(results, r) }}
+	require.NoError(t, s.Add(&Job{
+		ID:       "app",
+		Schedule: Every(time.Hour),
+		Render:   appRender(t, &msg),
+		Sinks:    []Sink{sink},
+	}))
+
+	require.NoError(t, s.RunAll(context.Background()))
+	require.Len(t, sink.sent, 1)
+	assert.Equal(t, "app", sink.sent[0].Job)
+	assert.NotEmpty(t, sink.sent[0].WebP)
+	assert.True(t, results[0].Sent)
+
+	// unchanged images aren't sent again
+	require.NoError(t, s.RunAll(context.Background()))
+	assert.Len(t, sink.sent, 1)
+	assert.True(t, results[1].Unchanged)
+
+	msg = "changed"
+	require.NoError(t, s.RunAll(context.Background()))
+	assert.Len(t, sink.sent, 2)
+
+	// apps returning no roots aren't sent
+	msg = ""
+	require.NoError(t, s.RunAll(context.Background()))
+	assert.Len(t, sink.sent, 2)
+	assert.True(t, results[3].Skipped)
+
+	// failed sends are sent again on the next run
+	msg = "again"
+	sink.err = errors.New("device offline")
+	err := s.RunAll(context.Background())
+	assert.ErrorContains(t, err, "app: device offline")
+	sink.err = nil
+	require.NoError(t, s.RunAll(context.Background()))
+	assert.Len(t, sink.sent, 3)
+}
+
+func TestAdd(t *testing.T) {
+	msg := "hi"
+	s := &Scheduler{}
+	job := &Job{ID: "app", Schedule: Every(time.Hour), Render: appRender(t, &msg)}
+	require.NoError(t, s.Add(job))
+	assert.Error(t, s.Add(job))
+	assert.Error(t, s.Add(&Job{ID: "other", Render: job.Render}))
+	assert.Error(t, s.Add(&Job{Schedule: job.Schedule, Render: job.Render}))
+}
+
+func TestRunSchedule(t *testing.T) {
+	msg := "hi"
+	runs := atomic.Int32{}
+	render := appRender(t, &msg)
+
+	s := &Scheduler{RunAtStart: true, SendUnchanged: true}
+	require.NoError(t, s.Add(&Job{
+		ID:       "app",
+		Schedule: Every(20 * time.Millisecond),
+		Render: func(ctx context.Context) (*encode.Screens, error) {
+			runs.Add(1)
+			return render(ctx)
+		},
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 110*time.Millisecond)
+	defer cancel()
+	require.NoError(t, s.Run(ctx))
+
+	assert.GreaterOrEqual(t, runs.Load(), int32(3))
+}
+
+func TestTrigger(t *testing.T) {
+	msg := "hi"
+	sink := &recorder{}
+
+	s := &Scheduler{SendUnchanged: true}
+	require.NoError(t, s.Add(&Job{
+		ID:       "app",
+		Schedule: Every(time.Hour),
+		Render:   appRender(t, &msg),
+		Sinks:    []Sink{sink},
+	}))
+	assert.False(t, s.Trigger("missing"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+
+	assert.True(t, s.Trigger("app"))
+	assert.Eventually(t, func() bool { return sink.count() == 1 }, time.Second, 5*time.Millisecond)
+
+	cancel()
+	<-done
+}
+
+func TestDispatch(t *testing.T) {
+	sink := &recorder{}
+	s := &Scheduler{}
+	require.NoError(t, s.Add(&Job{
+		ID:       "app",
+		Schedule: Every(time.Hour),
+		Render: func(ctx context.Context) (*encode.Screens, error) {
+			return nil, nil
+		},
+		Sinks: []Sink{sink},
+	}))
+
+	msg := "event"
+	screens, err := appRender(t, &msg)(context.Background())
+	require.NoError(t, err)
+
+	res := s.Dispatch(context.Background(), "app", screens)
+	require.NoError(t, res.Err)
+	assert.True(t, res.Sent)
+	assert.Len(t, sink.sent, 1)
+
+	res = s.Dispatch(context.Background(), "app", nil)
+	assert.True(t, res.Skipped)
+
+	res = s.Dispatch(context.Background(), "missing", screens)
+	assert.Error(t, res.Err)
+}
+
+func TestMaxConcurrent(t *testing.T) {
+	var running, most atomic.Int32
+
+	s := &Scheduler{MaxConcurrent: 2}
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		require.NoError(t, s.Add(&Job{
+			ID:       id,
+			Schedule: Every(time.Hour),
+			Render: func(ctx context.Context) (*encode.Screens, error) {
+				n := running.Add(1)
+				for {
+					m := most.Load()
+					if n <= m || most.CompareAndSwap(m, n) {
+						break
+					}
+				}
+				time.Sleep(20 * time.Millisecond)
+				running.Add(-1)
+				return nil, nil
+			},
+		}))
+	}
+
+	require.NoError(t, s.RunAll(context.Background()))
+	assert.Equal(t, int32(2), most.Load())
+}
+
+// scheduleFunc is a function that's a Schedule.
+type scheduleFunc func(t time.Time) time.Time
+
+func (f scheduleFunc) Next(t time.Time) time.Time { return f(t) }
+
+func TestMissed(t *testing.T) {
+	for _, policy := range []MissedRunPolicy{RunMissed, SkipMissed} {
+		results := make(chan Result, 10)
+
+		// the first run is due an hour ago, like after the computer
+		// woke up from sleep
+		calls := 0
+		schedule := scheduleFunc(func(t time.Time) time.Time {
+			calls++
+			if calls == 1 {
+				return t.Add(-time.Hour)
+			}
+			return t.Add(time.Hour)
+		})
+
+		s := &Scheduler{
+			Missed:   policy,
+			OnResult: func(r Result) { results <- r },
+		}
+		require.NoError(t, s.Add(&Job{
+			ID:       "app",
+			Schedule: schedule,
+			Render: func(ctx context.Context) (*encode.Screens, error) {
+				return nil, nil
+			},
+		}))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go s.Run(ctx)
+
+		res := <-results
+		if policy == SkipMissed {
+			assert.True(t, res.Missed)
+			assert.ErrorContains(t, res.Err, "1h0m0s late")
+		} else {
+			assert.False(t, res.Missed)
+			assert.True(t, res.Skipped)
+		}
+		cancel()
+	}
+}
+
+func TestStoredConfig(t *testing.T) {
+	store := configstore.NewMemory()
+	key := configstore.Key{App: "app", Installation: configstore.DefaultInstallation}
+
+	var got []string
+	render := StoredConfig(store, key, map[string]string{"msg": "default"}, func(ctx context.Context, config map[string]string) (*encode.Screens, error) {
+		got = append(got, config["msg"])
+		return nil, nil
+	})
+
+	_, err := render(context.Background())
+	require.NoError(t, err)
+
+	require.NoError(t, store.Put(context.Background(), key, map[string]string{"msg": "stored"}))
+	_, err = render(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"default", "stored"}, got)
+}
@@ -0,0 +1,184 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"tidbyt.dev/pixlet/encode"
+	"tidbyt.dev/pixlet/tools/mqtt"
+)
+
+const (
+	// TidbytAPIPush is where the Tidbyt API accepts pushes, with %s for
+	// the device ID.
+	TidbytAPIPush = "https://api.tidbyt.com/v0/devices/%s/push"
+
+	// LocalPushPath is where devices on the local network accept
+	// pushes, relative to their address.
+	LocalPushPath = "/v0/devices/%s/push"
+)
+
+// Output is what a job rendered.
+type Output struct {
+	// Job is the ID of the job.
+	Job string
+
+	// WebP is the rendered animation.
+	WebP []byte
+
+	// Screens is what WebP was encoded from, for sinks that need
+	// another format.
+	Screens *encode.Screens
+}
+
+// Sink is somewhere the output of jobs is sent, like a device or a
+// file.
+type Sink interface {
+	Send(ctx context.Context, out *Output) error
+}
+
+// SinkFunc is a function that's a Sink.
+type SinkFunc func(ctx context.Context, out *Output) error
+
+// Send implements Sink.
+func (f SinkFunc) Send(ctx context.Context, out *Output) error {
+	return f(ctx, out)
+}
+
+// APISink pushes output to a device through the Tidbyt API.
+type APISink struct {
+	DeviceID       string
+	InstallationID string
+	Background     bool
+
+	// Token is the API token pushes are authorized with, if any.
+	Token string
+
+	// URL is where pushes are sent, with %s for the device ID. If
+	// empty, it's TidbytAPIPush.
+	URL string
+
+	// Client sends pushes, or http.DefaultClient if nil.
+	Client *http.Client
+}
+
+// LocalSink returns a sink that pushes output straight to a device on
+// the local network at addr, which is a host and port, or the URL of a
+// server accepting pushes the way devices do.
+func LocalSink(addr, deviceID string) *APISink {
+	if !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+	return &APISink{
+		DeviceID: deviceID,
+		URL:      strings.TrimSuffix(addr, "/") + LocalPushPath,
+	}
+}
+
+// pushJSON is the body of a push.
+type pushJSON struct {
+	DeviceID       string `json:"deviceID"`
+	Image          string `json:"image"`
+	InstallationID string `json:"installationID"`
+	Background     bool   `json:"background"`
+}
+
+// Send implements Sink.
+func (s *APISink) Send(ctx context.Context, out *Output) error {
+	payload, err := json.Marshal(pushJSON{
+		DeviceID:       s.DeviceID,
+		Image:          base64.StdEncoding.EncodeToString(out.WebP),
+		InstallationID: s.InstallationID,
+		Background:     s.Background,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal json: %w", err)
+	}
+
+	url := s.URL
+	if url == "" {
+		url = TidbytAPIPush
+	}
+	url = fmt.Sprintf(url, s.DeviceID)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("creating POST request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("pushing to %s: %w", s.DeviceID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("pushing to %s: %s: %s", s.DeviceID, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
+
+// MQTTSink publishes output to an MQTT broker, with a frame of it
+// scaled up by Magnify for cameras.
+type MQTTSink struct {
+	Publisher *mqtt.Publisher
+	Magnify   int
+}
+
+// Send implements Sink.
+func (s *MQTTSink) Send(ctx context.Context, out *Output) error {
+	magnify := s.Magnify
+	if magnify <= 0 {
+		magnify = 1
+	}
+
+	png, err := out.Screens.EncodePNG(encode.FrameMidpoint, encode.MagnifyFilter(magnify))
+	if err != nil {
+		return fmt.Errorf("encoding PNG: %w", err)
+	}
+
+	if err := s.Publisher.Publish(out.Job, mqtt.Image{WebP: out.WebP, PNG: png}); err != nil {
+		return fmt.Errorf("publishing to MQTT: %w", err)
+	}
+	return nil
+}
+
+// FileSink writes output to <Dir>/<job>.webp, for other programs to pick
+// up.
+type FileSink struct {
+	Dir string
+}
+
+// Send implements Sink.
+func (s *FileSink) Send(ctx context.Context, out *Output) error {
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return err
+	}
+
+	// write and rename, so that readers never see half an image
+	path := filepath.Join(s.Dir, out.Job+".webp")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, out.WebP, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
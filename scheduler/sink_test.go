@@ -0,0 +1,79 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPISink(t *testing.T) {
+	var (
+		got  pushJSON
+		auth string
+		path string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&got)
+		if got.DeviceID == "offline" {
+			http.Error(w, "device is offline", http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	out := &Output{Job: "app", WebP: []byte("webp")}
+
+	sink := &APISink{
+		DeviceID:       "device",
+		InstallationID: "clock",
+		Background:     true,
+		Token:          "token",
+		URL:            srv.URL + "/v0/devices/%s/push",
+	}
+	require.NoError(t, sink.Send(context.Background(), out))
+	assert.Equal(t, "/v0/devices/device/push", path)
+	assert.Equal(t, "Bearer token", auth)
+	assert.Equal(t, pushJSON{
+		DeviceID:       "device",
+		Image:          base64.StdEncoding.EncodeToString([]byte("webp")),
+		InstallationID: "clock",
+		Background:     true,
+	}, got)
+
+	// local devices are pushed to the same way
+	local := LocalSink(srv.Listener.Addr().String(), "offline")
+	err := local.Send(context.Background(), out)
+	assert.ErrorContains(t, err, "device is offline")
+	assert.Equal(t, "/v0/devices/offline/push", path)
+	assert.Empty(t, auth)
+}
+
+func TestLocalSinkURL(t *testing.T) {
+	assert.Equal(t, "http://10.0.0.5:8080/v0/devices/%s/push", LocalSink("10.0.0.5:8080", "d").URL)
+	assert.Equal(t, "https://example.com/v0/devices/%s/push", LocalSink("https://example.com/", "d").URL)
+}
+
+func TestFileSink(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+	sink := &FileSink{Dir: dir}
+
+	require.NoError(t, sink.Send(context.Background(), &Output{Job: "clock", WebP: []byte("first")}))
+	require.NoError(t, sink.Send(context.Background(), &Output{Job: "clock", WebP: []byte("second")}))
+
+	data, err := os.ReadFile(filepath.Join(dir, "clock.webp"))
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(data))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
@@ -0,0 +1,189 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/mitchellh/hashstructure/v2"
+	"go.starlark.net/starlark"
+)
+
+// DefaultColorPresets are offered by ColorPalette fields that don't
+// provide their own presets. They stick to saturated colors, since
+// pastels and dark shades wash out or disappear on LED displays.
+var DefaultColorPresets = []SchemaColorPreset{
+	{Name: "Rainbow", Colors: []string{"#ff0000", "#ff8000", "#ffff00", "#00ff00", "#0080ff", "#8000ff"}},
+	{Name: "Warm", Colors: []string{"#ff2000", "#ff6000", "#ffa000", "#ffd000"}},
+	{Name: "Cool", Colors: []string{"#00ffff", "#0080ff", "#4040ff", "#a000ff"}},
+	{Name: "Neon", Colors: []string{"#ff00ff", "#00ff80", "#ffff00", "#00c0ff"}},
+	{Name: "Traffic", Colors: []string{"#ff0000", "#ffc000", "#00ff00"}},
+	{Name: "Mono", Colors: []string{"#ffffff", "#a0a0a0", "#606060"}},
+}
+
+type ColorPalette struct {
+	SchemaField
+	starlarkDefault *starlark.List
+	starlarkPresets *starlark.Dict
+}
+
+// colorList normalizes a Starlark list of hex colors.
+func colorList(what string, list *starlark.List) ([]string, error) {
+	colors := make([]string, 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		col, ok := list.Index(i).(starlark.String)
+		if !ok {
+			return nil, fmt.Errorf(
+				"expected %s to be a list of string but found: %s (at index %d)",
+				what,
+				list.Index(i).Type(),
+				i,
+			)
+		}
+
+		hex, err := normalizeHexColor(col.GoString())
+		if err != nil {
+			return nil, fmt.Errorf("malformed %s color at index %d: %w", what, i, err)
+		}
+		colors = append(colors, hex)
+	}
+	return colors, nil
+}
+
+func colorsValue(colors []string) *starlark.List {
+	values := make([]starlark.Value, 0, len(colors))
+	for _, c := range colors {
+		values = append(values, starlark.String(c))
+	}
+	return starlark.NewList(values)
+}
+
+func newColorPalette(
+	thread *starlark.Thread,
+	_ *starlark.Builtin,
+	args starlark.Tuple,
+	kwargs []starlark.Tuple,
+) (starlark.Value, error) {
+	var (
+		id      starlark.String
+		name    starlark.String
+		desc    starlark.String
+		icon    starlark.String
+		def     *starlark.List
+		presets *starlark.Dict
+	)
+
+	if err := starlark.UnpackArgs(
+		"ColorPalette",
+		args, kwargs,
+		"id", &id,
+		"name", &name,
+		"desc", &desc,
+		"icon", &icon,
+		"default", &def,
+		"presets?", &presets,
+	); err != nil {
+		return nil, fmt.Errorf("unpacking arguments for ColorPalette: %s", err)
+	}
+
+	s := &ColorPalette{}
+	s.SchemaField.Type = "colorpalette"
+	s.ID = id.GoString()
+	s.Name = name.GoString()
+	s.Description = desc.GoString()
+	s.Icon = icon.GoString()
+
+	colors, err := colorList("default", def)
+	if err != nil {
+		return nil, err
+	}
+	if len(colors) == 0 {
+		return nil, fmt.Errorf("default must contain at least one color")
+	}
+
+	js, err := json.Marshal(colors)
+	if err != nil {
+		return nil, err
+	}
+	s.Default = string(js)
+	s.starlarkDefault = colorsValue(colors)
+
+	if presets == nil {
+		s.Presets = DefaultColorPresets
+	} else {
+		s.Presets = []SchemaColorPreset{}
+		for _, item := range presets.Items() {
+			presetName, ok := item[0].(starlark.String)
+			if !ok {
+				return nil, fmt.Errorf("preset names must be string, found %s", item[0].Type())
+			}
+
+			list, ok := item[1].(*starlark.List)
+			if !ok {
+				return nil, fmt.Errorf("preset %s must be a list, found %s", presetName.GoString(), item[1].Type())
+			}
+
+			presetColors, err := colorList("preset", list)
+			if err != nil {
+				return nil, fmt.Errorf("preset %s: %w", presetName.GoString(), err)
+			}
+
+			s.Presets = append(s.Presets, SchemaColorPreset{
+				Name:   presetName.GoString(),
+				Colors: presetColors,
+			})
+		}
+	}
+
+	s.starlarkPresets = starlark.NewDict(len(s.Presets))
+	for _, p := range s.Presets {
+		s.starlarkPresets.SetKey(starlark.String(p.Name), colorsValue(p.Colors))
+	}
+
+	return s, nil
+}
+
+func (s *ColorPalette) AsSchemaField() SchemaField {
+	return s.SchemaField
+}
+
+func (s *ColorPalette) AttrNames() []string {
+	return []string{
+		"id", "name", "desc", "icon", "default", "presets",
+	}
+}
+
+func (s *ColorPalette) Attr(name string) (starlark.Value, error) {
+	switch name {
+
+	case "id":
+		return starlark.String(s.ID), nil
+
+	case "name":
+		return starlark.String(s.Name), nil
+
+	case "desc":
+		return starlark.String(s.Description), nil
+
+	case "icon":
+		return starlark.String(s.Icon), nil
+
+	case "default":
+		return s.starlarkDefault, nil
+
+	case "presets":
+		return s.starlarkPresets, nil
+
+	default:
+		return nil, nil
+	}
+}
+
+func (s *ColorPalette) String() string       { return "ColorPalette(...)" }
+func (s *ColorPalette) Type() string         { return "ColorPalette" }
+func (s *ColorPalette) Freeze()              {}
+func (s *ColorPalette) Truth() starlark.Bool { return true }
+
+func (s *ColorPalette) Hash() (uint32, error) {
+	sum, err := hashstructure.Hash(s, hashstructure.FormatV2, nil)
+	return uint32(sum), err
+}
@@ -0,0 +1,126 @@
+package schema_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"tidbyt.dev/pixlet/runtime"
+)
+
+var colorPaletteSource = `
+load("schema.star", "schema")
+
+def assert(success, message=None):
+    if not success:
+        fail(message or "assertion failed")
+
+p = schema.ColorPalette(
+	id = "theme",
+	name = "Theme",
+	desc = "Colors of the display.",
+	icon = "palette",
+	default = ["#F00", "00ff00", "#0000FF"],
+)
+
+assert(p.id == "theme")
+assert(p.name == "Theme")
+assert(p.desc == "Colors of the display.")
+assert(p.icon == "palette")
+assert(p.default == ["#f00", "#00ff00", "#0000ff"])
+assert("Rainbow" in p.presets)
+
+c = schema.ColorPalette(
+	id = "custom",
+	name = "Custom",
+	desc = "Custom presets.",
+	icon = "palette",
+	default = ["#fff"],
+	presets = {
+		"Fire": ["#f00", "#ff8000"],
+		"Ice": ["#0ff"],
+	},
+)
+
+assert(c.presets.keys() == ["Fire", "Ice"])
+assert(c.presets["Fire"] == ["#f00", "#ff8000"])
+
+def main():
+	return []
+`
+
+func TestColorPalette(t *testing.T) {
+	app, err := runtime.NewApplet("color_palette.star", []byte(colorPaletteSource))
+	assert.NoError(t, err)
+
+	screens, err := app.Run(context.Background())
+	assert.NoError(t, err)
+	assert.NotNil(t, screens)
+}
+
+func TestColorPaletteBadArgs(t *testing.T) {
+	for _, args := range []string{
+		`default = []`,
+		`default = ["#nothex"]`,
+		`default = [123]`,
+		`default = ["#fff"], presets = {"Bad": ["#12"]}`,
+		`default = ["#fff"], presets = {"Bad": "#fff"}`,
+	} {
+		src := `
+load("schema.star", "schema")
+
+p = schema.ColorPalette(
+	id = "theme",
+	name = "Theme",
+	desc = "Colors of the display.",
+	icon = "palette",
+	` + args + `,
+)
+
+def main():
+	return []
+`
+		_, err := runtime.NewApplet("color_palette.star", []byte(src))
+		assert.Error(t, err, args)
+	}
+}
+
+func TestColorPaletteSchemaJSON(t *testing.T) {
+	code := `
+load("schema.star", "schema")
+
+def get_schema():
+    return schema.Schema(
+        version = "1",
+        fields = [
+            schema.ColorPalette(
+                id = "theme",
+                name = "Theme",
+                desc = "Colors of the display.",
+                icon = "palette",
+                default = ["#f00", "#0f0"],
+                presets = {"Fire": ["#f00", "#ff8000"]},
+            ),
+        ],
+    )
+
+def main():
+    return None
+`
+
+	app, err := loadApp(code)
+	assert.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"version": "1",
+		"schema": [{
+			"type": "colorpalette",
+			"id": "theme",
+			"name": "Theme",
+			"description": "Colors of the display.",
+			"icon": "palette",
+			"default": "[\"#f00\",\"#0f0\"]",
+			"presets": [{"name": "Fire", "colors": ["#f00", "#ff8000"]}]
+		}]
+	}`, string(app.SchemaJSON))
+}
@@ -0,0 +1,260 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var colorRegexp = regexp.MustCompile(colorPattern)
+
+// ConfigError describes a problem with the value of one field in a
+// config.
+type ConfigError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e ConfigError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ConfigErrors holds all problems found in a config, ordered by field.
+type ConfigErrors []ConfigError
+
+func (e ConfigErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return "invalid config: " + strings.Join(msgs, "; ")
+}
+
+// ValidateConfig checks the values in a config against the fields of
+// the schema. Values must be well formed for their field type, pick
+// from the field's options where it has them, and be present for
+// required fields. Fields hidden by visible_if aren't checked, and
+// keys that don't belong to a field are ignored. It returns nil if the
+// config is valid.
+func (s Schema) ValidateConfig(config map[string]string) ConfigErrors {
+	var errs ConfigErrors
+
+	for _, field := range s.Fields {
+		if field.hidden(s.Fields, config) {
+			continue
+		}
+
+		value, ok := config[field.ID]
+		if !ok || value == "" {
+			if field.Required {
+				errs = append(errs, ConfigError{field.ID, "is required"})
+			}
+			continue
+		}
+
+		if err := field.checkValue(value); err != nil {
+			errs = append(errs, ConfigError{field.ID, err.Error()})
+		}
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Field < errs[j].Field
+	})
+
+	return errs
+}
+
+// hidden reports whether the field is hidden by its visibility
+// condition, given the config. The value of the field the condition
+// depends on falls back to its default.
+func (f SchemaField) hidden(fields []SchemaField, config map[string]string) bool {
+	v := f.Visibility
+	if v == nil || v.Type != "invisible" {
+		return false
+	}
+
+	value, ok := config[v.Variable]
+	if !ok {
+		for _, other := range fields {
+			if other.ID == v.Variable {
+				value = other.defaultValue()
+			}
+		}
+	}
+
+	equal := value == v.Value
+	if v.Condition == "equal" {
+		return equal
+	}
+	return !equal
+}
+
+// checkValue checks that a non-empty config value is valid for the
+// field.
+func (f SchemaField) checkValue(value string) error {
+	switch f.Type {
+	case TypeOnOff:
+		if value != "true" && value != "false" {
+			return fmt.Errorf("must be true or false, found %q", value)
+		}
+
+	case TypeDropdown, TypeRadio:
+		if !f.hasOption(value) {
+			return fmt.Errorf("%q is not one of the options", value)
+		}
+
+	case TypeColor:
+		if !colorRegexp.MatchString(value) {
+			return fmt.Errorf("malformed color: %q", value)
+		}
+
+	case TypeColorPalette:
+		var colors []string
+		if err := json.Unmarshal([]byte(value), &colors); err != nil {
+			return fmt.Errorf("malformed palette: %w", err)
+		}
+		if len(colors) == 0 {
+			return fmt.Errorf("palette is empty")
+		}
+		for _, c := range colors {
+			if !colorRegexp.MatchString(c) {
+				return fmt.Errorf("malformed color: %q", c)
+			}
+		}
+
+	case TypeDateTime:
+		if f.WithTimezone {
+			if _, err := ParseDateTime(value); err != nil {
+				return err
+			}
+		} else if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return fmt.Errorf("malformed datetime: %q", value)
+		}
+
+	case TypeDuration:
+		secs, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("malformed duration: %q", value)
+		}
+		return f.checkRange(float64(secs))
+
+	case TypeSlider:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("malformed number: %q", value)
+		}
+		return f.checkRange(n)
+
+	case TypeRange:
+		r, err := ParseRange(value)
+		if err != nil {
+			return err
+		}
+		if err := f.checkRange(r.Min); err != nil {
+			return err
+		}
+		return f.checkRange(r.Max)
+
+	case TypeIcon:
+		if len(f.Icons) > 0 && !f.hasIcon(value) {
+			return fmt.Errorf("%q is not one of the icons", value)
+		}
+
+	case TypeLocation:
+		if _, err := ParseLocation(value); err != nil {
+			return err
+		}
+
+	case TypeLocationBased, TypeTypeahead:
+		var option SchemaOption
+		if err := json.Unmarshal([]byte(value), &option); err != nil {
+			return fmt.Errorf("malformed option: %w", err)
+		}
+
+	case TypeMultiSelect:
+		var options []SchemaOption
+		if err := json.Unmarshal([]byte(value), &options); err != nil {
+			return fmt.Errorf("malformed options: %w", err)
+		}
+		if f.Max != nil && *f.Max > 0 && float64(len(options)) > *f.Max {
+			return fmt.Errorf("at most %s options can be picked, found %d", formatNumber(*f.Max), len(options))
+		}
+		if f.Handler == "" {
+			for _, o := range options {
+				if !f.hasOption(o.Value) {
+					return fmt.Errorf("%q is not one of the options", o.Value)
+				}
+			}
+		}
+
+	case TypeSchedule:
+		if _, err := ParseSchedule(value); err != nil {
+			return err
+		}
+
+	case TypeNotificationSettings:
+		if _, err := ParseNotificationSettings(value); err != nil {
+			return err
+		}
+
+	case TypeRepeated:
+		var items []map[string]string
+		if err := json.Unmarshal([]byte(value), &items); err != nil {
+			return fmt.Errorf("malformed items: %w", err)
+		}
+		if f.Min != nil && float64(len(items)) < *f.Min {
+			return fmt.Errorf("needs at least %s items, found %d", formatNumber(*f.Min), len(items))
+		}
+		if f.Max != nil && float64(len(items)) > *f.Max {
+			return fmt.Errorf("can have at most %s items, found %d", formatNumber(*f.Max), len(items))
+		}
+		for i, item := range items {
+			for _, sub := range f.Fields {
+				v, ok := item[sub.ID]
+				if !ok || v == "" {
+					if sub.Required {
+						return fmt.Errorf("item %d: %s is required", i, sub.ID)
+					}
+					continue
+				}
+				if err := sub.checkValue(v); err != nil {
+					return fmt.Errorf("item %d: %s: %w", i, sub.ID, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (f SchemaField) hasOption(value string) bool {
+	for _, o := range f.Options {
+		if o.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (f SchemaField) hasIcon(name string) bool {
+	for _, ic := range f.Icons {
+		if ic.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (f SchemaField) checkRange(n float64) error {
+	if f.Min != nil && n < *f.Min {
+		return fmt.Errorf("must be at least %s, found %s", formatNumber(*f.Min), formatNumber(n))
+	}
+	if f.Max != nil && n > *f.Max {
+		return fmt.Errorf("must be at most %s, found %s", formatNumber(*f.Max), formatNumber(n))
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package schema_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"tidbyt.dev/pixlet/schema"
+)
+
+var validateConfigSource = `
+load("schema.star", "schema")
+
+def validate(config):
+    if config.str("name") == "admin":
+        return {"name": "That name is taken"}
+    return {}
+
+def get_schema():
+    return schema.Schema(
+        version = "1",
+        validator = validate,
+        fields = [
+            schema.Text(
+                id = "name",
+                name = "Name",
+                desc = "Your name.",
+                icon = "user",
+                required = True,
+            ),
+            schema.Toggle(
+                id = "show_color",
+                name = "Show Color",
+                desc = "Use a color.",
+                icon = "brush",
+            ),
+            schema.Color(
+                id = "color",
+                name = "Color",
+                desc = "The color.",
+                icon = "brush",
+                default = "#fff",
+                required = True,
+                visible_if = {"show_color": True},
+            ),
+            schema.Dropdown(
+                id = "size",
+                name = "Size",
+                desc = "Text size.",
+                icon = "textHeight",
+                default = "small",
+                options = [
+                    schema.Option(display = "Small", value = "small"),
+                    schema.Option(display = "Large", value = "large"),
+                ],
+            ),
+            schema.Slider(
+                id = "speed",
+                name = "Speed",
+                desc = "Scroll speed.",
+                icon = "gauge",
+                min = 1,
+                max = 10,
+            ),
+            schema.Location(
+                id = "location",
+                name = "Location",
+                desc = "Where you are.",
+                icon = "locationDot",
+            ),
+            schema.DateTime(
+                id = "event",
+                name = "Event",
+                desc = "When the event is.",
+                icon = "clock",
+                with_timezone = True,
+            ),
+        ],
+    )
+
+def main():
+    return None
+`
+
+func TestValidateConfig(t *testing.T) {
+	app, err := loadApp(validateConfigSource)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name   string
+		config map[string]string
+		errors schema.ConfigErrors
+	}{
+		{
+			name:   "valid",
+			config: map[string]string{"name": "Ada", "size": "large", "speed": "2.5"},
+		},
+		{
+			name: "datetime with timezone",
+			config: map[string]string{
+				"name":  "Ada",
+				"event": `{"timestamp": "2023-12-31T23:59:00-05:00", "timezone": "America/New_York"}`,
+			},
+		},
+		{
+			name:   "missing required field",
+			config: map[string]string{"size": "large"},
+			errors: schema.ConfigErrors{{Field: "name", Message: "is required"}},
+		},
+		{
+			name: "bad values",
+			config: map[string]string{
+				"name":       "Ada",
+				"show_color": "yes",
+				"size":       "huge",
+				"speed":      "11",
+				"location":   `{"lat": "100", "lng": "0"}`,
+				"event":      "2023-12-31T23:59:00-05:00",
+			},
+			errors: schema.ConfigErrors{
+				{Field: "event", Message: "malformed datetime: invalid character '-' after top-level value"},
+				{Field: "location", Message: `malformed latitude: "100"`},
+				{Field: "show_color", Message: `must be true or false, found "yes"`},
+				{Field: "size", Message: `"huge" is not one of the options`},
+				{Field: "speed", Message: "must be at most 10, found 11"},
+			},
+		},
+		{
+			name:   "visible required field",
+			config: map[string]string{"name": "Ada", "show_color": "true", "color": "red"},
+			errors: schema.ConfigErrors{{Field: "color", Message: `malformed color: "red"`}},
+		},
+		{
+			name:   "hidden fields aren't checked",
+			config: map[string]string{"name": "Ada", "show_color": "false", "color": "red"},
+		},
+		{
+			name:   "validator",
+			config: map[string]string{"name": "admin"},
+			errors: schema.ConfigErrors{{Field: "name", Message: "That name is taken"}},
+		},
+	} {
+		errs, err := app.ValidateConfig(context.Background(), tc.config)
+		require.NoError(t, err, tc.name)
+		assert.Equal(t, tc.errors, errs, tc.name)
+	}
+}
+
+func TestValidateConfigErrorMessage(t *testing.T) {
+	errs := schema.ConfigErrors{
+		{Field: "name", Message: "is required"},
+		{Field: "size", Message: `"huge" is not one of the options`},
+	}
+	assert.EqualError(t, errs, `invalid config: name: is required; size: "huge" is not one of the options`)
+}
@@ -1,7 +1,9 @@
 package schema
 
 import (
+	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/mitchellh/hashstructure/v2"
 	"go.starlark.net/starlark"
@@ -11,6 +13,49 @@ type DateTime struct {
 	SchemaField
 }
 
+// DateTimeValue is the value of a DateTime field with a timezone, as
+// provided in config. Timestamp is an RFC 3339 timestamp in the user's
+// local offset, and Timezone is the name of their timezone.
+type DateTimeValue struct {
+	Timestamp string `json:"timestamp"`
+	Timezone  string `json:"timezone"`
+}
+
+// ParseDateTime parses and validates the config value of a DateTime
+// field with a timezone.
+func ParseDateTime(value string) (*DateTimeValue, error) {
+	dt := &DateTimeValue{}
+	if err := json.Unmarshal([]byte(value), dt); err != nil {
+		return nil, fmt.Errorf("malformed datetime: %w", err)
+	}
+
+	if _, err := time.Parse(time.RFC3339, dt.Timestamp); err != nil {
+		return nil, fmt.Errorf("malformed datetime: %q", dt.Timestamp)
+	}
+
+	if _, err := time.LoadLocation(dt.Timezone); err != nil || dt.Timezone == "" {
+		return nil, fmt.Errorf("unknown timezone: %q", dt.Timezone)
+	}
+
+	return dt, nil
+}
+
+// dateTimeDefault returns the config value of a DateTime field with a
+// timezone that defaults to the RFC 3339 timestamp def. The default
+// doesn't name a timezone, so it's given in UTC.
+func dateTimeDefault(def string) string {
+	t, err := time.Parse(time.RFC3339, def)
+	if err != nil {
+		return def
+	}
+
+	js, _ := json.Marshal(DateTimeValue{
+		Timestamp: t.UTC().Format(time.RFC3339),
+		Timezone:  "UTC",
+	})
+	return string(js)
+}
+
 func newDateTime(
 	thread *starlark.Thread,
 	_ *starlark.Builtin,
@@ -22,6 +67,8 @@ func newDateTime(
 		name starlark.String
 		desc starlark.String
 		icon starlark.String
+		def  starlark.String
+		tz   starlark.Bool
 	)
 
 	if err := starlark.UnpackArgs(
@@ -31,6 +78,8 @@ func newDateTime(
 		"name", &name,
 		"desc", &desc,
 		"icon", &icon,
+		"default?", &def,
+		"with_timezone?", &tz,
 	); err != nil {
 		return nil, fmt.Errorf("unpacking arguments for DateTime: %s", err)
 	}
@@ -41,6 +90,15 @@ func newDateTime(
 	s.Name = name.GoString()
 	s.Description = desc.GoString()
 	s.Icon = icon.GoString()
+	s.WithTimezone = bool(tz)
+
+	if def != "" {
+		t, err := time.Parse(time.RFC3339, def.GoString())
+		if err != nil {
+			return nil, fmt.Errorf("malformed default time: %w", err)
+		}
+		s.Default = t.Format(time.RFC3339)
+	}
 
 	return s, nil
 }
@@ -51,7 +109,7 @@ func (s *DateTime) AsSchemaField() SchemaField {
 
 func (s *DateTime) AttrNames() []string {
 	return []string{
-		"id", "name", "desc", "icon",
+		"id", "name", "desc", "icon", "default", "with_timezone",
 	}
 }
 
@@ -70,6 +128,12 @@ func (s *DateTime) Attr(name string) (starlark.Value, error) {
 	case "icon":
 		return starlark.String(s.Icon), nil
 
+	case "default":
+		return starlark.String(s.Default), nil
+
+	case "with_timezone":
+		return starlark.Bool(s.WithTimezone), nil
+
 	default:
 		return nil, nil
 	}
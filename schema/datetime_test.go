@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"tidbyt.dev/pixlet/runtime"
+	"tidbyt.dev/pixlet/schema"
 )
 
 var dateTimeSource = `
@@ -26,6 +27,20 @@ assert(t.id == "event_name")
 assert(t.name == "Event Name")
 assert(t.desc == "The time of the event.")
 assert(t.icon == "gear")
+assert(t.default == "")
+assert(t.with_timezone == False)
+
+tz = schema.DateTime(
+	id = "countdown",
+	name = "Countdown",
+	desc = "The time to count down to.",
+	icon = "clock",
+	default = "2023-12-31T23:59:00-05:00",
+	with_timezone = True,
+)
+
+assert(tz.default == "2023-12-31T23:59:00-05:00")
+assert(tz.with_timezone == True)
 
 def main():
 	return []
@@ -39,3 +54,42 @@ func TestDateTime(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, screens)
 }
+
+func TestDateTimeBadDefault(t *testing.T) {
+	src := `
+load("schema.star", "schema")
+
+t = schema.DateTime(
+	id = "event_name",
+	name = "Event Name",
+	desc = "The time of the event.",
+	icon = "gear",
+	default = "12/31/2023",
+)
+
+def main():
+	return []
+`
+
+	_, err := runtime.NewApplet("date_time.star", []byte(src))
+	assert.Error(t, err)
+}
+
+func TestParseDateTime(t *testing.T) {
+	dt, err := schema.ParseDateTime(`{"timestamp": "2023-12-31T23:59:00-05:00", "timezone": "America/New_York"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, &schema.DateTimeValue{
+		Timestamp: "2023-12-31T23:59:00-05:00",
+		Timezone:  "America/New_York",
+	}, dt)
+
+	for _, bad := range []string{
+		`2023-12-31T23:59:00-05:00`,
+		`{"timestamp": "12/31/2023", "timezone": "America/New_York"}`,
+		`{"timestamp": "2023-12-31T23:59:00-05:00"}`,
+		`{"timestamp": "2023-12-31T23:59:00-05:00", "timezone": "Mars/Olympus_Mons"}`,
+	} {
+		_, err := schema.ParseDateTime(bad)
+		assert.Error(t, err, bad)
+	}
+}
@@ -0,0 +1,188 @@
+package schema
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/mitchellh/hashstructure/v2"
+	startime "go.starlark.net/lib/time"
+	"go.starlark.net/starlark"
+)
+
+type Duration struct {
+	SchemaField
+}
+
+// asSeconds converts an int number of seconds or a time.duration to
+// whole seconds.
+func asSeconds(name string, v starlark.Value) (int64, error) {
+	switch d := v.(type) {
+	case startime.Duration:
+		if time.Duration(d)%time.Second != 0 {
+			return 0, fmt.Errorf("%s must be a whole number of seconds, found %s", name, time.Duration(d))
+		}
+		return int64(time.Duration(d) / time.Second), nil
+
+	case starlark.Int:
+		secs, ok := d.Int64()
+		if !ok {
+			return 0, fmt.Errorf("%s is out of range", name)
+		}
+		return secs, nil
+	}
+
+	return 0, fmt.Errorf("%s must be an int or a duration, found %s", name, v.Type())
+}
+
+func newDuration(
+	thread *starlark.Thread,
+	_ *starlark.Builtin,
+	args starlark.Tuple,
+	kwargs []starlark.Tuple,
+) (starlark.Value, error) {
+	var (
+		id   starlark.String
+		name starlark.String
+		desc starlark.String
+		icon starlark.String
+		def  starlark.Value
+		min  starlark.Value
+		max  starlark.Value
+		step starlark.Value = starlark.MakeInt(60)
+	)
+
+	if err := starlark.UnpackArgs(
+		"Duration",
+		args, kwargs,
+		"id", &id,
+		"name", &name,
+		"desc", &desc,
+		"icon", &icon,
+		"default?", &def,
+		"min?", &min,
+		"max?", &max,
+		"step?", &step,
+	); err != nil {
+		return nil, fmt.Errorf("unpacking arguments for Duration: %s", err)
+	}
+
+	s := &Duration{}
+	s.SchemaField.Type = "duration"
+	s.ID = id.GoString()
+	s.Name = name.GoString()
+	s.Description = desc.GoString()
+	s.Icon = icon.GoString()
+
+	stepSecs, err := asSeconds("step", step)
+	if err != nil {
+		return nil, err
+	}
+	if stepSecs <= 0 {
+		return nil, fmt.Errorf("step must be positive, found %d", stepSecs)
+	}
+	stepVal := float64(stepSecs)
+	s.Step = &stepVal
+
+	var minSecs int64
+	if min != nil {
+		minSecs, err = asSeconds("min", min)
+		if err != nil {
+			return nil, err
+		}
+		if minSecs < 0 {
+			return nil, fmt.Errorf("min can't be negative, found %d", minSecs)
+		}
+		minVal := float64(minSecs)
+		s.Min = &minVal
+	}
+
+	maxSecs := int64(-1)
+	if max != nil {
+		maxSecs, err = asSeconds("max", max)
+		if err != nil {
+			return nil, err
+		}
+		if maxSecs <= minSecs {
+			return nil, fmt.Errorf("max (%d) must be greater than min (%d)", maxSecs, minSecs)
+		}
+		maxVal := float64(maxSecs)
+		s.Max = &maxVal
+	}
+
+	if def != nil {
+		defSecs, err := asSeconds("default", def)
+		if err != nil {
+			return nil, err
+		}
+		if defSecs < minSecs || (maxSecs >= 0 && defSecs > maxSecs) {
+			return nil, fmt.Errorf("default (%d) must be between min and max", defSecs)
+		}
+		s.Default = strconv.FormatInt(defSecs, 10)
+	}
+
+	return s, nil
+}
+
+func (s *Duration) AsSchemaField() SchemaField {
+	return s.SchemaField
+}
+
+func (s *Duration) AttrNames() []string {
+	return []string{
+		"id", "name", "desc", "icon", "default", "min", "max", "step",
+	}
+}
+
+func (s *Duration) Attr(name string) (starlark.Value, error) {
+	switch name {
+
+	case "id":
+		return starlark.String(s.ID), nil
+
+	case "name":
+		return starlark.String(s.Name), nil
+
+	case "desc":
+		return starlark.String(s.Description), nil
+
+	case "icon":
+		return starlark.String(s.Icon), nil
+
+	case "default":
+		if s.Default == "" {
+			return starlark.None, nil
+		}
+		secs, _ := strconv.ParseInt(s.Default, 10, 64)
+		return starlark.MakeInt64(secs), nil
+
+	case "min":
+		return secondsValue(s.Min), nil
+
+	case "max":
+		return secondsValue(s.Max), nil
+
+	case "step":
+		return secondsValue(s.Step), nil
+
+	default:
+		return nil, nil
+	}
+}
+
+func secondsValue(secs *float64) starlark.Value {
+	if secs == nil {
+		return starlark.None
+	}
+	return starlark.MakeInt64(int64(*secs))
+}
+
+func (s *Duration) String() string       { return "Duration(...)" }
+func (s *Duration) Type() string         { return "Duration" }
+func (s *Duration) Freeze()              {}
+func (s *Duration) Truth() starlark.Bool { return true }
+
+func (s *Duration) Hash() (uint32, error) {
+	sum, err := hashstructure.Hash(s, hashstructure.FormatV2, nil)
+	return uint32(sum), err
+}
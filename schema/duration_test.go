@@ -0,0 +1,91 @@
+package schema_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"tidbyt.dev/pixlet/runtime"
+)
+
+var durationSource = `
+load("schema.star", "schema")
+load("time.star", "time")
+
+def assert(success, message=None):
+    if not success:
+        fail(message or "assertion failed")
+
+d = schema.Duration(
+	id = "timer",
+	name = "Timer",
+	desc = "How long to count down.",
+	icon = "hourglass",
+	default = time.parse_duration("25m"),
+	max = time.parse_duration("2h"),
+)
+
+assert(d.id == "timer")
+assert(d.name == "Timer")
+assert(d.desc == "How long to count down.")
+assert(d.icon == "hourglass")
+assert(d.default == 1500)
+assert(d.min == None)
+assert(d.max == 7200)
+assert(d.step == 60)
+
+s = schema.Duration(
+	id = "lap",
+	name = "Lap Goal",
+	desc = "Target lap time.",
+	icon = "stopwatch",
+	min = 30,
+	step = 1,
+)
+
+assert(s.default == None)
+assert(s.min == 30)
+assert(s.max == None)
+assert(s.step == 1)
+
+def main():
+	return []
+`
+
+func TestDuration(t *testing.T) {
+	app, err := runtime.NewApplet("duration.star", []byte(durationSource))
+	assert.NoError(t, err)
+
+	screens, err := app.Run(context.Background())
+	assert.NoError(t, err)
+	assert.NotNil(t, screens)
+}
+
+func TestDurationBadArgs(t *testing.T) {
+	for _, args := range []string{
+		`default = "1h"`,
+		`default = time.parse_duration("1.5s")`,
+		`step = 0`,
+		`min = -1`,
+		`min = 60, max = 60`,
+		`max = 60, default = 120`,
+	} {
+		src := `
+load("schema.star", "schema")
+load("time.star", "time")
+
+d = schema.Duration(
+	id = "duration",
+	name = "Duration",
+	desc = "A duration.",
+	icon = "clock",
+	` + args + `,
+)
+
+def main():
+	return []
+`
+		_, err := runtime.NewApplet("duration.star", []byte(src))
+		assert.Error(t, err, args)
+	}
+}
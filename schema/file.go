@@ -0,0 +1,141 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mitchellh/hashstructure/v2"
+	"go.starlark.net/starlark"
+)
+
+const (
+	// DefaultFileSize is the upload size limit of File fields that
+	// don't set one.
+	DefaultFileSize = 64 * 1024
+
+	// MaxFileSize is the largest upload size limit a File field can
+	// set. Uploads are stored in the config, so they need to stay
+	// small.
+	MaxFileSize = 256 * 1024
+)
+
+// File lets the user upload a small file, provided in config as a
+// base64 encoded string.
+type File struct {
+	SchemaField
+	starlarkAccept *starlark.List
+}
+
+func newFile(
+	thread *starlark.Thread,
+	_ *starlark.Builtin,
+	args starlark.Tuple,
+	kwargs []starlark.Tuple,
+) (starlark.Value, error) {
+	var (
+		id      starlark.String
+		name    starlark.String
+		desc    starlark.String
+		icon    starlark.String
+		accept  *starlark.List
+		maxSize starlark.Int = starlark.MakeInt(DefaultFileSize)
+	)
+
+	if err := starlark.UnpackArgs(
+		"File",
+		args, kwargs,
+		"id", &id,
+		"name", &name,
+		"desc", &desc,
+		"icon", &icon,
+		"accept?", &accept,
+		"max_size?", &maxSize,
+	); err != nil {
+		return nil, fmt.Errorf("unpacking arguments for File: %s", err)
+	}
+
+	s := &File{}
+	s.SchemaField.Type = "file"
+	s.ID = id.GoString()
+	s.Name = name.GoString()
+	s.Description = desc.GoString()
+	s.Icon = icon.GoString()
+
+	size, ok := maxSize.Int64()
+	if !ok || size <= 0 || size > MaxFileSize {
+		return nil, fmt.Errorf("max_size must be between 1 and %d bytes", MaxFileSize)
+	}
+	s.MaxSize = int(size)
+
+	if accept != nil {
+		for i := 0; i < accept.Len(); i++ {
+			a, ok := accept.Index(i).(starlark.String)
+			if !ok {
+				return nil, fmt.Errorf(
+					"expected accept to be a list of string but found: %s (at index %d)",
+					accept.Index(i).Type(),
+					i,
+				)
+			}
+
+			// either a MIME type like "image/*" or an
+			// extension like ".csv"
+			if !strings.Contains(a.GoString(), "/") && !strings.HasPrefix(a.GoString(), ".") {
+				return nil, fmt.Errorf("accept entries must be MIME types or extensions, found %s", a.GoString())
+			}
+
+			s.Accept = append(s.Accept, a.GoString())
+		}
+		s.starlarkAccept = accept
+	} else {
+		s.starlarkAccept = starlark.NewList(nil)
+	}
+
+	return s, nil
+}
+
+func (s *File) AsSchemaField() SchemaField {
+	return s.SchemaField
+}
+
+func (s *File) AttrNames() []string {
+	return []string{
+		"id", "name", "desc", "icon", "accept", "max_size",
+	}
+}
+
+func (s *File) Attr(name string) (starlark.Value, error) {
+	switch name {
+
+	case "id":
+		return starlark.String(s.ID), nil
+
+	case "name":
+		return starlark.String(s.Name), nil
+
+	case "desc":
+		return starlark.String(s.Description), nil
+
+	case "icon":
+		return starlark.String(s.Icon), nil
+
+	case "accept":
+		return s.starlarkAccept, nil
+
+	case "max_size":
+		return starlark.MakeInt(s.MaxSize), nil
+
+	default:
+		return nil, nil
+	}
+}
+
+func (s *File) String() string       { return "File(...)" }
+func (s *File) Type() string         { return "File" }
+func (s *File) Freeze()              {}
+func (s *File) Truth() starlark.Bool { return true }
+
+func (s *File) Hash() (uint32, error) {
+	sum, err := hashstructure.Hash(s, hashstructure.FormatV2, nil)
+	return uint32(sum), err
+}
@@ -0,0 +1,81 @@
+package schema_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"tidbyt.dev/pixlet/runtime"
+)
+
+var fileSource = `
+load("schema.star", "schema")
+
+def assert(success, message=None):
+    if not success:
+        fail(message or "assertion failed")
+
+f = schema.File(
+	id = "logo",
+	name = "Logo",
+	desc = "Your logo.",
+	icon = "image",
+	accept = ["image/png", ".gif"],
+	max_size = 16384,
+)
+
+assert(f.id == "logo")
+assert(f.name == "Logo")
+assert(f.desc == "Your logo.")
+assert(f.icon == "image")
+assert(f.accept == ["image/png", ".gif"])
+assert(f.max_size == 16384)
+
+d = schema.File(
+	id = "data",
+	name = "Data",
+	desc = "A data file.",
+	icon = "file",
+)
+
+assert(d.accept == [])
+assert(d.max_size == 65536)
+
+def main():
+	return []
+`
+
+func TestFile(t *testing.T) {
+	app, err := runtime.NewApplet("file.star", []byte(fileSource))
+	assert.NoError(t, err)
+
+	screens, err := app.Run(context.Background())
+	assert.NoError(t, err)
+	assert.NotNil(t, screens)
+}
+
+func TestFileBadArgs(t *testing.T) {
+	for _, args := range []string{
+		`max_size = 0`,
+		`max_size = 1048576`,
+		`accept = ["png"]`,
+		`accept = [1]`,
+	} {
+		src := `
+load("schema.star", "schema")
+
+f = schema.File(
+	id = "file",
+	name = "File",
+	desc = "A file.",
+	icon = "file",
+	` + args + `,
+)
+
+def main():
+	return []
+`
+		_, err := runtime.NewApplet("file.star", []byte(src))
+		assert.Error(t, err, args)
+	}
+}
@@ -7,8 +7,12 @@ import (
 	"go.starlark.net/starlark"
 )
 
+// Generated adds fields to the schema based on the value of other
+// fields. With a single source, its handler is passed the value of that
+// field. With a list of sources, it's passed the full config instead.
 type Generated struct {
 	SchemaField
+	starlarkSources *starlark.List
 }
 
 func newGenerated(
@@ -19,7 +23,7 @@ func newGenerated(
 ) (starlark.Value, error) {
 	var (
 		id      starlark.String
-		source  starlark.String
+		source  starlark.Value
 		handler *starlark.Function
 	)
 
@@ -35,7 +39,43 @@ func newGenerated(
 
 	s := &Generated{}
 	s.StarlarkHandler = handler
-	s.Source = source.GoString()
+
+	switch src := source.(type) {
+	case starlark.String:
+		s.Source = src.GoString()
+
+	case *starlark.List:
+		if src.Len() == 0 {
+			return nil, fmt.Errorf("sources can't be empty")
+		}
+
+		seen := map[string]bool{}
+		for i := 0; i < src.Len(); i++ {
+			id, ok := src.Index(i).(starlark.String)
+			if !ok {
+				return nil, fmt.Errorf(
+					"expected source to be a list of field IDs but found: %s (at index %d)",
+					src.Index(i).Type(),
+					i,
+				)
+			}
+			if seen[id.GoString()] {
+				return nil, fmt.Errorf("duplicate source %s", id.GoString())
+			}
+			seen[id.GoString()] = true
+
+			s.Sources = append(s.Sources, id.GoString())
+		}
+
+		// hosts that only know about a single source field will at
+		// least watch the first one
+		s.Source = s.Sources[0]
+		s.starlarkSources = src
+
+	default:
+		return nil, fmt.Errorf("source must be a field ID or a list of them, found %s", source.Type())
+	}
+
 	s.Handler = handler.Name()
 	s.ID = id.GoString()
 	s.SchemaField.Type = "generated"
@@ -49,7 +89,7 @@ func (s *Generated) AsSchemaField() SchemaField {
 
 func (s *Generated) AttrNames() []string {
 	return []string{
-		"source", "handler", "id",
+		"source", "sources", "handler", "id",
 	}
 }
 
@@ -58,6 +98,12 @@ func (s *Generated) Attr(name string) (starlark.Value, error) {
 	case "source":
 		return starlark.String(s.Source), nil
 
+	case "sources":
+		if s.starlarkSources == nil {
+			return starlark.NewList([]starlark.Value{starlark.String(s.Source)}), nil
+		}
+		return s.starlarkSources, nil
+
 	case "handler":
 		return s.StarlarkHandler, nil
 
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"tidbyt.dev/pixlet/runtime"
 )
 
@@ -24,6 +25,16 @@ s = schema.Generated(
 assert(s.id == "foo")
 assert(s.source == "bar")
 assert(s.handler == assert)
+assert(s.sources == ["bar"])
+
+m = schema.Generated(
+	id = "foo",
+	source = ["bar", "baz"],
+	handler = assert,
+)
+
+assert(m.source == "bar")
+assert(m.sources == ["bar", "baz"])
 
 def main():
 	return []
@@ -37,3 +48,97 @@ func TestGenerated(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, screens)
 }
+
+var generatedSourcesSource = `
+load("schema.star", "schema")
+
+def divisions(config):
+    league = config.str("league")
+    season = config.str("season")
+    if not league or not season:
+        return []
+
+    return [
+        schema.Dropdown(
+            id = "division",
+            name = "Division",
+            desc = "%s divisions in %s." % (league, season),
+            icon = "trophy",
+            default = "east",
+            options = [
+                schema.Option(display = "East", value = "east"),
+                schema.Option(display = "West", value = "west"),
+            ],
+        ),
+    ]
+
+def get_schema():
+    return schema.Schema(
+        version = "1",
+        fields = [
+            schema.Text(id = "league", name = "League", desc = "League.", icon = "trophy"),
+            schema.Text(id = "season", name = "Season", desc = "Season.", icon = "calendar"),
+            schema.Generated(
+                id = "divisions",
+                source = ["league", "season"],
+                handler = divisions,
+            ),
+        ],
+    )
+
+def main():
+    return []
+`
+
+func TestGeneratedSources(t *testing.T) {
+	app, err := loadApp(generatedSourcesSource)
+	require.NoError(t, err)
+
+	field := app.Schema.Fields[2]
+	assert.Equal(t, "league", field.Source)
+	assert.Equal(t, []string{"league", "season"}, field.Sources)
+	assert.True(t, app.Schema.Handlers["divisions$divisions"].TakesConfig)
+
+	result, err := app.CallSchemaHandler(
+		context.Background(),
+		"divisions$divisions",
+		`{"league": "NHL", "season": "2024"}`,
+	)
+	require.NoError(t, err)
+	assert.Contains(t, result, "NHL divisions in 2024.")
+
+	result, err = app.CallSchemaHandler(
+		context.Background(),
+		"divisions$divisions",
+		`{"league": "NHL"}`,
+	)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"version": "1", "schema": []}`, result)
+
+	_, err = app.CallSchemaHandler(context.Background(), "divisions$divisions", "NHL")
+	assert.Error(t, err)
+}
+
+func TestGeneratedBadSources(t *testing.T) {
+	for _, source := range []string{
+		`[]`,
+		`["league", "league"]`,
+		`["league", 1]`,
+		`1`,
+	} {
+		src := `
+load("schema.star", "schema")
+
+s = schema.Generated(
+	id = "divisions",
+	source = ` + source + `,
+	handler = lambda config: [],
+)
+
+def main():
+	return []
+`
+		_, err := runtime.NewApplet("generated.star", []byte(src))
+		assert.Error(t, err, source)
+	}
+}
@@ -35,7 +35,9 @@ func newHandler(
 	if handlerType != ReturnSchema &&
 		handlerType != ReturnOptions &&
 		handlerType != ReturnString &&
-		handlerType != ReturnField {
+		handlerType != ReturnField &&
+		handlerType != ReturnValidation &&
+		handlerType != ReturnJSON {
 		return nil, fmt.Errorf("invalid handler type %d", int(handlerType))
 	}
 
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"tidbyt.dev/pixlet/runtime"
 )
 
@@ -76,3 +77,96 @@ def main():
 	assert.Error(t, err)
 	assert.Nil(t, app)
 }
+
+func TestHandlerWithConfig(t *testing.T) {
+	app, err := loadApp(`
+load("schema.star", "schema")
+
+def search(pattern, config):
+    region = config.str("region", "us")
+    return [
+        schema.Option(display = "%s in %s" % (pattern, region), value = pattern),
+    ]
+
+def lookup(pattern):
+    return [
+        schema.Option(display = pattern, value = pattern),
+    ]
+
+def get_schema():
+    return schema.Schema(
+        version = "1",
+        fields = [
+            schema.Text(id = "region", name = "Region", desc = "Region.", icon = "globe"),
+            schema.Typeahead(id = "station", name = "Station", desc = "Station.", icon = "train", handler = search),
+            schema.Typeahead(id = "line", name = "Line", desc = "Line.", icon = "train", handler = lookup),
+        ],
+    )
+
+def main():
+    return []
+`)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	result, err := app.CallSchemaHandlerWithConfig(ctx, "station$search", "Central", map[string]string{"region": "eu"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"display": "Central in eu", "text": "Central in eu", "value": "Central"}]`, result)
+
+	// handlers that take config get an empty one when none is passed
+	result, err = app.CallSchemaHandler(ctx, "station$search", "Central")
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"display": "Central in us", "text": "Central in us", "value": "Central"}]`, result)
+
+	// and handlers that don't are called as before
+	result, err = app.CallSchemaHandlerWithConfig(ctx, "line$lookup", "Red", map[string]string{"region": "eu"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"display": "Red", "text": "Red", "value": "Red"}]`, result)
+}
+
+func TestHandlerJSON(t *testing.T) {
+	app, err := loadApp(`
+load("schema.star", "schema")
+
+def tiles(param):
+    return {
+        "zoom": 12,
+        "center": [47.6, -122.3],
+        "tiles": [{"url": "https://example.com/%s.png" % param, "visible": True}],
+        "attribution": None,
+    }
+
+def broken(param):
+    return {"when": schema}
+
+def get_schema():
+    return schema.Schema(
+        version = "1",
+        fields = [],
+        handlers = [
+            schema.Handler(handler = tiles, type = schema.HandlerType.JSON),
+            schema.Handler(handler = broken, type = schema.HandlerType.JSON),
+        ],
+    )
+
+def main():
+    return []
+`)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	assert.Equal(t, "json", app.Schema.Handlers["tiles"].ReturnType.String())
+
+	result, err := app.CallSchemaHandler(ctx, "tiles", "seattle")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"zoom": 12,
+		"center": [47.6, -122.3],
+		"tiles": [{"url": "https://example.com/seattle.png", "visible": true}],
+		"attribution": null
+	}`, result)
+
+	_, err = app.CallSchemaHandler(ctx, "broken", "")
+	assert.Error(t, err)
+}
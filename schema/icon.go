@@ -0,0 +1,192 @@
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mitchellh/hashstructure/v2"
+	"go.starlark.net/starlark"
+
+	"tidbyt.dev/pixlet/icons"
+)
+
+// Icon lets the user pick one of the icons that can be used in schema,
+// like the ones in the `icon` parameter of every field.
+type Icon struct {
+	SchemaField
+	starlarkIcons *starlark.List
+}
+
+// iconLabel turns an icon name like "addressBook" into a label like
+// "Address Book".
+func iconLabel(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if i == 0 {
+			b.WriteRune(unicode.ToUpper(r))
+			continue
+		}
+		if unicode.IsUpper(r) {
+			b.WriteRune(' ')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// schemaIcon returns the preview metadata for an icon.
+func schemaIcon(name string) (SchemaIcon, error) {
+	appName, ok := icons.IconsMap[name]
+	if !ok {
+		return SchemaIcon{}, fmt.Errorf("unknown icon: %s", name)
+	}
+
+	return SchemaIcon{
+		Name:    name,
+		Label:   iconLabel(name),
+		AppName: appName,
+	}, nil
+}
+
+func newIcon(
+	thread *starlark.Thread,
+	_ *starlark.Builtin,
+	args starlark.Tuple,
+	kwargs []starlark.Tuple,
+) (starlark.Value, error) {
+	var (
+		id      starlark.String
+		name    starlark.String
+		desc    starlark.String
+		icon    starlark.String
+		def     starlark.String
+		choices *starlark.List
+	)
+
+	if err := starlark.UnpackArgs(
+		"Icon",
+		args, kwargs,
+		"id", &id,
+		"name", &name,
+		"desc", &desc,
+		"icon", &icon,
+		"default?", &def,
+		"icons?", &choices,
+	); err != nil {
+		return nil, fmt.Errorf("unpacking arguments for Icon: %s", err)
+	}
+
+	s := &Icon{}
+	s.SchemaField.Type = "icon"
+	s.ID = id.GoString()
+	s.Name = name.GoString()
+	s.Description = desc.GoString()
+	s.Icon = icon.GoString()
+
+	if choices != nil {
+		for i := 0; i < choices.Len(); i++ {
+			choice, ok := choices.Index(i).(starlark.String)
+			if !ok {
+				return nil, fmt.Errorf(
+					"expected icons to be a list of string but found: %s (at index %d)",
+					choices.Index(i).Type(),
+					i,
+				)
+			}
+
+			ic, err := schemaIcon(choice.GoString())
+			if err != nil {
+				return nil, err
+			}
+			s.Icons = append(s.Icons, ic)
+		}
+		s.starlarkIcons = choices
+	}
+
+	if def != "" {
+		if _, err := schemaIcon(def.GoString()); err != nil {
+			return nil, fmt.Errorf("bad default: %w", err)
+		}
+
+		found := s.Icons == nil
+		for _, ic := range s.Icons {
+			if ic.Name == def.GoString() {
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("default %s is not one of the icons", def.GoString())
+		}
+
+		s.Default = def.GoString()
+	}
+
+	return s, nil
+}
+
+// AllIcons returns preview metadata for every icon that can be
+// picked with an Icon field, sorted by name.
+func AllIcons() []SchemaIcon {
+	all := make([]SchemaIcon, 0, len(icons.IconsMap))
+	for name := range icons.IconsMap {
+		ic, _ := schemaIcon(name)
+		all = append(all, ic)
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Name < all[j].Name
+	})
+
+	return all
+}
+
+func (s *Icon) AsSchemaField() SchemaField {
+	return s.SchemaField
+}
+
+func (s *Icon) AttrNames() []string {
+	return []string{
+		"id", "name", "desc", "icon", "default", "icons",
+	}
+}
+
+func (s *Icon) Attr(name string) (starlark.Value, error) {
+	switch name {
+
+	case "id":
+		return starlark.String(s.ID), nil
+
+	case "name":
+		return starlark.String(s.Name), nil
+
+	case "desc":
+		return starlark.String(s.Description), nil
+
+	case "icon":
+		return starlark.String(s.Icon), nil
+
+	case "default":
+		return starlark.String(s.Default), nil
+
+	case "icons":
+		if s.starlarkIcons == nil {
+			return starlark.None, nil
+		}
+		return s.starlarkIcons, nil
+
+	default:
+		return nil, nil
+	}
+}
+
+func (s *Icon) String() string       { return "Icon(...)" }
+func (s *Icon) Type() string         { return "Icon" }
+func (s *Icon) Freeze()              {}
+func (s *Icon) Truth() starlark.Bool { return true }
+
+func (s *Icon) Hash() (uint32, error) {
+	sum, err := hashstructure.Hash(s, hashstructure.FormatV2, nil)
+	return uint32(sum), err
+}
@@ -0,0 +1,128 @@
+package schema_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"tidbyt.dev/pixlet/runtime"
+	"tidbyt.dev/pixlet/schema"
+)
+
+var iconSource = `
+load("schema.star", "schema")
+
+def assert(success, message=None):
+    if not success:
+        fail(message or "assertion failed")
+
+i = schema.Icon(
+	id = "symbol",
+	name = "Symbol",
+	desc = "Symbol to show.",
+	icon = "icons",
+	default = "cloudSun",
+)
+
+assert(i.id == "symbol")
+assert(i.name == "Symbol")
+assert(i.desc == "Symbol to show.")
+assert(i.icon == "icons")
+assert(i.default == "cloudSun")
+assert(i.icons == None)
+
+s = schema.Icon(
+	id = "weather",
+	name = "Weather",
+	desc = "Weather symbol.",
+	icon = "cloud",
+	icons = ["sun", "cloud", "cloudRain"],
+	default = "sun",
+)
+
+assert(s.icons == ["sun", "cloud", "cloudRain"])
+
+def main():
+	return []
+`
+
+func TestIcon(t *testing.T) {
+	app, err := runtime.NewApplet("icon.star", []byte(iconSource))
+	assert.NoError(t, err)
+
+	screens, err := app.Run(context.Background())
+	assert.NoError(t, err)
+	assert.NotNil(t, screens)
+}
+
+func TestIconBadArgs(t *testing.T) {
+	for _, args := range []string{
+		`default = "notAnIcon"`,
+		`icons = ["sun", "notAnIcon"]`,
+		`icons = ["sun"], default = "cloud"`,
+	} {
+		src := `
+load("schema.star", "schema")
+
+i = schema.Icon(
+	id = "symbol",
+	name = "Symbol",
+	desc = "Symbol to show.",
+	icon = "icons",
+	` + args + `,
+)
+
+def main():
+	return []
+`
+		_, err := runtime.NewApplet("icon.star", []byte(src))
+		assert.Error(t, err, args)
+	}
+}
+
+func TestIconSchemaJSON(t *testing.T) {
+	code := `
+load("schema.star", "schema")
+
+def get_schema():
+    return schema.Schema(
+        version = "1",
+        fields = [
+            schema.Icon(
+                id = "weather",
+                name = "Weather",
+                desc = "Weather symbol.",
+                icon = "cloud",
+                icons = ["cloudSun", "0"],
+            ),
+        ],
+    )
+
+def main():
+    return None
+`
+
+	app, err := loadApp(code)
+	assert.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"version": "1",
+		"schema": [{
+			"type": "icon",
+			"id": "weather",
+			"name": "Weather",
+			"description": "Weather symbol.",
+			"icon": "cloud",
+			"icons": [
+				{"name": "cloudSun", "label": "Cloud Sun", "app_name": "cloudSun"},
+				{"name": "0", "label": "0", "app_name": "zero"}
+			]
+		}]
+	}`, string(app.SchemaJSON))
+}
+
+func TestAllIcons(t *testing.T) {
+	all := schema.AllIcons()
+	assert.Greater(t, len(all), 1000)
+	assert.Equal(t, schema.SchemaIcon{Name: "0", Label: "0", AppName: "zero"}, all[0])
+}
@@ -0,0 +1,271 @@
+package schema
+
+import (
+	"encoding/json"
+)
+
+const (
+	// JSONSchemaDialect is the version of JSON Schema produced by
+	// Schema.JSONSchema.
+	JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+	colorPattern   = "^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$"
+	numberPattern  = "^-?[0-9]+(\\.[0-9]+)?$"
+	integerPattern = "^-?[0-9]+$"
+)
+
+// jsonSchema is the subset of JSON Schema that config fields map to.
+type jsonSchema struct {
+	Schema      string `json:"$schema,omitempty"`
+	Type        string `json:"type,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Default     string `json:"default,omitempty"`
+
+	Enum    []string `json:"enum,omitempty"`
+	Pattern string   `json:"pattern,omitempty"`
+	Format  string   `json:"format,omitempty"`
+
+	WriteOnly        bool        `json:"writeOnly,omitempty"`
+	ContentEncoding  string      `json:"contentEncoding,omitempty"`
+	ContentMediaType string      `json:"contentMediaType,omitempty"`
+	ContentSchema    *jsonSchema `json:"contentSchema,omitempty"`
+
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	MinItems             *int                   `json:"minItems,omitempty"`
+	MaxItems             *int                   `json:"maxItems,omitempty"`
+
+	// PixletType holds the original field type, so that tools can
+	// pick a matching widget.
+	PixletType string `json:"x-pixlet-type,omitempty"`
+}
+
+// jsonContent describes a config value that holds JSON encoded data.
+func jsonContent(content *jsonSchema) *jsonSchema {
+	return &jsonSchema{
+		Type:             "string",
+		ContentMediaType: "application/json",
+		ContentSchema:    content,
+	}
+}
+
+func optionsSchema() *jsonSchema {
+	return &jsonSchema{
+		Type: "array",
+		Items: &jsonSchema{
+			Type: "object",
+			Properties: map[string]*jsonSchema{
+				"display": {Type: "string"},
+				"value":   {Type: "string"},
+			},
+			Required: []string{"value"},
+		},
+	}
+}
+
+func intPtr(i int) *int { return &i }
+
+// fieldJSONSchema describes the config value of a field. It returns nil
+// for fields that aren't stored in config.
+func fieldJSONSchema(field SchemaField) *jsonSchema {
+	var s *jsonSchema
+
+	switch field.Type {
+	case "text", "typeahead":
+		s = &jsonSchema{Type: "string"}
+
+	case "secret":
+		s = &jsonSchema{Type: "string", WriteOnly: true}
+
+	case "oauth2", "oauth1", "webauth":
+		s = &jsonSchema{Type: "string", WriteOnly: true}
+
+	case "onoff":
+		s = &jsonSchema{Type: "string", Enum: []string{"true", "false"}}
+
+	case "dropdown", "radio":
+		s = &jsonSchema{Type: "string"}
+		for _, o := range field.Options {
+			s.Enum = append(s.Enum, o.Value)
+		}
+
+	case "color":
+		s = &jsonSchema{Type: "string", Pattern: colorPattern}
+
+	case "colorpalette":
+		s = jsonContent(&jsonSchema{
+			Type:     "array",
+			Items:    &jsonSchema{Type: "string", Pattern: colorPattern},
+			MinItems: intPtr(1),
+		})
+
+	case "datetime":
+		if field.WithTimezone {
+			s = jsonContent(&jsonSchema{
+				Type: "object",
+				Properties: map[string]*jsonSchema{
+					"timestamp": {Type: "string", Format: "date-time"},
+					"timezone":  {Type: "string"},
+				},
+				Required: []string{"timestamp", "timezone"},
+			})
+		} else {
+			s = &jsonSchema{Type: "string", Format: "date-time"}
+		}
+
+	case "duration":
+		s = &jsonSchema{Type: "string", Pattern: integerPattern}
+
+	case "slider":
+		s = &jsonSchema{Type: "string", Pattern: numberPattern}
+
+	case "range":
+		s = jsonContent(&jsonSchema{
+			Type: "object",
+			Properties: map[string]*jsonSchema{
+				"min": {Type: "number"},
+				"max": {Type: "number"},
+			},
+			Required: []string{"min", "max"},
+		})
+
+	case "icon":
+		s = &jsonSchema{Type: "string"}
+		for _, ic := range field.Icons {
+			s.Enum = append(s.Enum, ic.Name)
+		}
+
+	case "file", "png":
+		s = &jsonSchema{Type: "string", ContentEncoding: "base64"}
+
+	case "location":
+		s = jsonContent(&jsonSchema{
+			Type: "object",
+			Properties: map[string]*jsonSchema{
+				"lat":         {Type: "string", Pattern: numberPattern},
+				"lng":         {Type: "string", Pattern: numberPattern},
+				"description": {Type: "string"},
+				"locality":    {Type: "string"},
+				"place_id":    {Type: "string"},
+				"timezone":    {Type: "string"},
+				"country":     {Type: "string", Pattern: "^[A-Z]{2}$"},
+				"elevation":   {Type: "string", Pattern: numberPattern},
+			},
+			Required: []string{"lat", "lng"},
+		})
+
+	case "locationbased":
+		s = jsonContent(&jsonSchema{
+			Type: "object",
+			Properties: map[string]*jsonSchema{
+				"display": {Type: "string"},
+				"value":   {Type: "string"},
+			},
+			Required: []string{"value"},
+		})
+
+	case "multiselect":
+		items := optionsSchema()
+		if field.Max != nil {
+			items.MaxItems = intPtr(int(*field.Max))
+		}
+		s = jsonContent(items)
+
+	case "repeated":
+		item := &jsonSchema{
+			Type:       "object",
+			Properties: map[string]*jsonSchema{},
+		}
+		for _, f := range field.Fields {
+			if fs := fieldJSONSchema(f); fs != nil {
+				item.Properties[f.ID] = fs
+			}
+		}
+
+		items := &jsonSchema{Type: "array", Items: item}
+		if field.Min != nil {
+			items.MinItems = intPtr(int(*field.Min))
+		}
+		if field.Max != nil {
+			items.MaxItems = intPtr(int(*field.Max))
+		}
+		s = jsonContent(items)
+
+	case "schedule":
+		day := &jsonSchema{Type: "string"}
+		for _, o := range field.Options {
+			day.Enum = append(day.Enum, o.Value)
+		}
+		s = jsonContent(&jsonSchema{
+			Type: "object",
+			Properties: map[string]*jsonSchema{
+				"days":  {Type: "array", Items: day},
+				"start": {Type: "string", Pattern: "^[0-2][0-9]:[0-5][0-9]$"},
+				"end":   {Type: "string", Pattern: "^[0-2][0-9]:[0-5][0-9]$"},
+			},
+			Required: []string{"days"},
+		})
+
+	case "notificationsettings":
+		priority := &jsonSchema{Type: "string"}
+		for _, o := range field.Options {
+			priority.Enum = append(priority.Enum, o.Value)
+		}
+		s = jsonContent(&jsonSchema{
+			Type: "object",
+			Properties: map[string]*jsonSchema{
+				"enabled":  {Type: "boolean"},
+				"priority": priority,
+				"quiet_hours": {
+					Type: "object",
+					Properties: map[string]*jsonSchema{
+						"start": {Type: "string", Pattern: "^[0-2][0-9]:[0-5][0-9]$"},
+						"end":   {Type: "string", Pattern: "^[0-2][0-9]:[0-5][0-9]$"},
+					},
+					Required: []string{"start", "end"},
+				},
+			},
+			Required: []string{"enabled", "priority"},
+		})
+
+	default:
+		// generated fields and notifications don't hold config of
+		// their own
+		return nil
+	}
+
+	s.Title = field.Name
+	s.Description = field.Description
+	s.PixletType = field.Type
+	s.Default = field.defaultValue()
+
+	return s
+}
+
+// JSONSchema converts the schema to a JSON Schema describing the
+// config object that's passed to the app. Since config values are
+// always strings, structured values are described as JSON encoded
+// strings, with their shape given by contentSchema.
+func (s Schema) JSONSchema() ([]byte, error) {
+	additional := true
+	root := &jsonSchema{
+		Schema:               JSONSchemaDialect,
+		Type:                 "object",
+		Properties:           map[string]*jsonSchema{},
+		AdditionalProperties: &additional,
+	}
+
+	for _, field := range s.Fields {
+		if fs := fieldJSONSchema(field); fs != nil {
+			root.Properties[field.ID] = fs
+			if field.Required && field.Visibility == nil {
+				root.Required = append(root.Required, field.ID)
+			}
+		}
+	}
+
+	return json.Marshal(root)
+}
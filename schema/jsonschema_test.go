@@ -0,0 +1,154 @@
+package schema_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONSchema(t *testing.T) {
+	app, err := loadApp(`
+load("schema.star", "schema")
+
+def get_schema():
+    return schema.Schema(
+        version = "1",
+        fields = [
+            schema.Text(
+                id = "msg",
+                name = "Message",
+                desc = "A message.",
+                icon = "gear",
+                default = "Hello",
+            ),
+            schema.Toggle(
+                id = "party_mode",
+                name = "Party Mode",
+                desc = "Enable party mode.",
+                icon = "gear",
+            ),
+            schema.Dropdown(
+                id = "units",
+                name = "Units",
+                desc = "Units to use.",
+                icon = "ruler",
+                default = "metric",
+                options = [
+                    schema.Option(display = "Metric", value = "metric"),
+                    schema.Option(display = "Imperial", value = "imperial"),
+                ],
+            ),
+            schema.Secret(
+                id = "api_key",
+                name = "API Key",
+                desc = "Your API key.",
+                icon = "key",
+            ),
+            schema.MultiSelect(
+                id = "lines",
+                name = "Lines",
+                desc = "Lines to watch.",
+                icon = "train",
+                max = 2,
+                options = [
+                    schema.Option(display = "Red", value = "red"),
+                ],
+            ),
+            schema.DateTime(
+                id = "countdown",
+                name = "Countdown",
+                desc = "The time to count down to.",
+                icon = "clock",
+                default = "2023-12-31T23:59:00Z",
+                with_timezone = True,
+            ),
+            schema.Generated(
+                id = "generated",
+                source = "units",
+                handler = lambda units: [],
+            ),
+        ],
+    )
+
+def main(config):
+    return []
+`)
+	require.NoError(t, err)
+
+	js, err := app.Schema.JSONSchema()
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"additionalProperties": true,
+		"properties": {
+			"msg": {
+				"type": "string",
+				"title": "Message",
+				"description": "A message.",
+				"default": "Hello",
+				"x-pixlet-type": "text"
+			},
+			"party_mode": {
+				"type": "string",
+				"title": "Party Mode",
+				"description": "Enable party mode.",
+				"default": "false",
+				"enum": ["true", "false"],
+				"x-pixlet-type": "onoff"
+			},
+			"units": {
+				"type": "string",
+				"title": "Units",
+				"description": "Units to use.",
+				"default": "metric",
+				"enum": ["metric", "imperial"],
+				"x-pixlet-type": "dropdown"
+			},
+			"api_key": {
+				"type": "string",
+				"title": "API Key",
+				"description": "Your API key.",
+				"writeOnly": true,
+				"x-pixlet-type": "secret"
+			},
+			"lines": {
+				"type": "string",
+				"title": "Lines",
+				"description": "Lines to watch.",
+				"contentMediaType": "application/json",
+				"contentSchema": {
+					"type": "array",
+					"maxItems": 2,
+					"items": {
+						"type": "object",
+						"properties": {
+							"display": {"type": "string"},
+							"value": {"type": "string"}
+						},
+						"required": ["value"]
+					}
+				},
+				"x-pixlet-type": "multiselect"
+			},
+			"countdown": {
+				"type": "string",
+				"title": "Countdown",
+				"description": "The time to count down to.",
+				"default": "{\"timestamp\":\"2023-12-31T23:59:00Z\",\"timezone\":\"UTC\"}",
+				"contentMediaType": "application/json",
+				"contentSchema": {
+					"type": "object",
+					"properties": {
+						"timestamp": {"type": "string", "format": "date-time"},
+						"timezone": {"type": "string"}
+					},
+					"required": ["timestamp", "timezone"]
+				},
+				"x-pixlet-type": "datetime"
+			}
+		}
+	}`, string(js))
+}
@@ -1,7 +1,10 @@
 package schema
 
 import (
+	"encoding/json"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/mitchellh/hashstructure/v2"
 	"go.starlark.net/starlark"
@@ -11,6 +14,62 @@ type Location struct {
 	SchemaField
 }
 
+// LocationValue is the value of a Location field, as provided in
+// config. Latitude, longitude and elevation are strings, for
+// compatibility with existing hosts. Elevation is in meters above sea
+// level, and Country is an ISO 3166-1 alpha-2 code.
+type LocationValue struct {
+	Lat         string `json:"lat"`
+	Lng         string `json:"lng"`
+	Description string `json:"description,omitempty"`
+	Locality    string `json:"locality,omitempty"`
+	PlaceID     string `json:"place_id,omitempty"`
+	Timezone    string `json:"timezone,omitempty"`
+	Country     string `json:"country,omitempty"`
+	Elevation   string `json:"elevation,omitempty"`
+}
+
+// ParseLocation parses and validates the config value of a Location
+// field.
+func ParseLocation(value string) (*LocationValue, error) {
+	loc := &LocationValue{}
+	if err := json.Unmarshal([]byte(value), loc); err != nil {
+		return nil, fmt.Errorf("malformed location: %w", err)
+	}
+
+	lat, err := strconv.ParseFloat(loc.Lat, 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return nil, fmt.Errorf("malformed latitude: %q", loc.Lat)
+	}
+
+	lng, err := strconv.ParseFloat(loc.Lng, 64)
+	if err != nil || lng < -180 || lng > 180 {
+		return nil, fmt.Errorf("malformed longitude: %q", loc.Lng)
+	}
+
+	if loc.Timezone != "" {
+		if _, err := time.LoadLocation(loc.Timezone); err != nil {
+			return nil, fmt.Errorf("unknown timezone: %q", loc.Timezone)
+		}
+	}
+
+	if loc.Country != "" {
+		if len(loc.Country) != 2 ||
+			loc.Country[0] < 'A' || loc.Country[0] > 'Z' ||
+			loc.Country[1] < 'A' || loc.Country[1] > 'Z' {
+			return nil, fmt.Errorf("malformed country code: %q", loc.Country)
+		}
+	}
+
+	if loc.Elevation != "" {
+		if _, err := strconv.ParseFloat(loc.Elevation, 64); err != nil {
+			return nil, fmt.Errorf("malformed elevation: %q", loc.Elevation)
+		}
+	}
+
+	return loc, nil
+}
+
 func newLocation(
 	thread *starlark.Thread,
 	_ *starlark.Builtin,
@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"tidbyt.dev/pixlet/runtime"
+	"tidbyt.dev/pixlet/schema"
 )
 
 var locationSource = `
@@ -39,3 +40,44 @@ func TestLocation(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, screens)
 }
+
+func TestParseLocation(t *testing.T) {
+	loc, err := schema.ParseLocation(`{
+		"lat": "40.678",
+		"lng": "-73.944",
+		"description": "Brooklyn, NY, USA",
+		"locality": "Brooklyn",
+		"place_id": "ChIJCSF8lBZEwokRhngABHRcdoI",
+		"timezone": "America/New_York",
+		"country": "US",
+		"elevation": "23.5"
+	}`)
+	assert.NoError(t, err)
+	assert.Equal(t, &schema.LocationValue{
+		Lat:         "40.678",
+		Lng:         "-73.944",
+		Description: "Brooklyn, NY, USA",
+		Locality:    "Brooklyn",
+		PlaceID:     "ChIJCSF8lBZEwokRhngABHRcdoI",
+		Timezone:    "America/New_York",
+		Country:     "US",
+		Elevation:   "23.5",
+	}, loc)
+
+	// values from hosts that don't provide the new attributes
+	// are still fine
+	_, err = schema.ParseLocation(`{"lat": "40.678", "lng": "-73.944", "timezone": "America/New_York"}`)
+	assert.NoError(t, err)
+
+	for _, bad := range []string{
+		`not json`,
+		`{"lat": "91", "lng": "0"}`,
+		`{"lat": "0", "lng": "east"}`,
+		`{"lat": "0", "lng": "0", "timezone": "Mars/Olympus_Mons"}`,
+		`{"lat": "0", "lng": "0", "country": "usa"}`,
+		`{"lat": "0", "lng": "0", "elevation": "high"}`,
+	} {
+		_, err := schema.ParseLocation(bad)
+		assert.Error(t, err, bad)
+	}
+}
@@ -0,0 +1,171 @@
+package schema_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"tidbyt.dev/pixlet/runtime"
+)
+
+var migrateSource = `
+load("render.star", "render")
+load("schema.star", "schema")
+
+def migrate(config, from_version):
+    if from_version < 2:
+        # "station" was renamed to "stop"
+        if "station" in config:
+            config["stop"] = config.pop("station")
+    if from_version < 3:
+        config["units"] = "metric"
+    return config
+
+def get_schema():
+    return schema.Schema(
+        version = "1",
+        config_version = 3,
+        migrate = migrate,
+        fields = [
+            schema.Text(
+                id = "stop",
+                name = "Stop",
+                desc = "Stop ID.",
+                icon = "train",
+            ),
+        ],
+    )
+
+def main(config):
+    return render.Root(child = render.Text(config.str("stop", "none") + config.str("units", "")))
+`
+
+func TestMigrate(t *testing.T) {
+	app, err := loadApp(migrateSource)
+	require.NoError(t, err)
+	assert.Equal(t, 3, app.Schema.ConfigVersion)
+	assert.Equal(t, "migrate", app.Schema.Migrate)
+
+	// configs without a version are version 1
+	config, err := app.MigrateConfig(context.Background(), map[string]string{
+		"station": "1234",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"stop":            "1234",
+		"units":           "metric",
+		"$config_version": "3",
+	}, config)
+
+	config, err = app.MigrateConfig(context.Background(), map[string]string{
+		"stop":            "1234",
+		"$config_version": "2",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"stop":            "1234",
+		"units":           "metric",
+		"$config_version": "3",
+	}, config)
+
+	// up to date configs are left alone
+	current := map[string]string{
+		"stop":            "1234",
+		"units":           "imperial",
+		"$config_version": "3",
+	}
+	config, err = app.MigrateConfig(context.Background(), current)
+	require.NoError(t, err)
+	assert.Equal(t, current, config)
+
+	_, err = app.MigrateConfig(context.Background(), map[string]string{
+		"$config_version": "three",
+	})
+	assert.Error(t, err)
+
+	// configs are migrated before they're passed to main
+	roots, err := app.RunWithConfig(context.Background(), map[string]string{
+		"station": "1234",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(roots))
+
+	// hosts can also call it like any other handler
+	result, err := app.CallSchemaHandler(
+		context.Background(),
+		"migrate",
+		`{"config": {"station": "1234"}, "from_version": 1}`,
+	)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"stop": "1234", "units": "metric"}`, result)
+}
+
+func TestMigrateSchemaJSON(t *testing.T) {
+	app, err := loadApp(migrateSource)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"version": "1",
+		"config_version": 3,
+		"migrate": "migrate",
+		"schema": [
+			{
+				"type": "text",
+				"id": "stop",
+				"name": "Stop",
+				"description": "Stop ID.",
+				"icon": "train"
+			}
+		]
+	}`, string(app.SchemaJSON))
+}
+
+func TestMigrateBadArgs(t *testing.T) {
+	for _, args := range []string{
+		`config_version = 0`,
+		`config_version = 1, migrate = migrate`,
+		`migrate = migrate`,
+		`config_version = "2"`,
+	} {
+		src := `
+load("schema.star", "schema")
+
+def migrate(config, from_version):
+    return config
+
+s = schema.Schema(
+	version = "1",
+	` + args + `,
+)
+
+def main():
+	return []
+`
+		_, err := runtime.NewApplet("migrate.star", []byte(src))
+		assert.Error(t, err, args)
+	}
+}
+
+func TestMigrateBadReturn(t *testing.T) {
+	app, err := loadApp(`
+load("schema.star", "schema")
+
+def migrate(config, from_version):
+    return {"stop": 1234}
+
+def get_schema():
+    return schema.Schema(
+        version = "1",
+        config_version = 2,
+        migrate = migrate,
+    )
+
+def main(config):
+    return []
+`)
+	require.NoError(t, err)
+
+	_, err = app.MigrateConfig(context.Background(), map[string]string{"stop": "1234"})
+	assert.Error(t, err)
+}
@@ -23,10 +23,12 @@ func LoadModule() (starlark.StringDict, error) {
 		handlerType := starlarkstruct.FromStringDict(
 			starlark.String("HandlerType"),
 			map[string]starlark.Value{
-				"Schema":  starlark.MakeInt(int(ReturnSchema)),
-				"Options": starlark.MakeInt(int(ReturnOptions)),
-				"String":  starlark.MakeInt(int(ReturnString)),
-				"Field":   starlark.MakeInt(int(ReturnField)),
+				"Schema":     starlark.MakeInt(int(ReturnSchema)),
+				"Options":    starlark.MakeInt(int(ReturnOptions)),
+				"String":     starlark.MakeInt(int(ReturnString)),
+				"Field":      starlark.MakeInt(int(ReturnField)),
+				"Validation": starlark.MakeInt(int(ReturnValidation)),
+				"JSON":       starlark.MakeInt(int(ReturnJSON)),
 			},
 		)
 
@@ -34,23 +36,36 @@ func LoadModule() (starlark.StringDict, error) {
 			ModuleName: &starlarkstruct.Module{
 				Name: ModuleName,
 				Members: starlark.StringDict{
-					"Schema":        starlark.NewBuiltin("Schema", newSchema),
-					"Toggle":        starlark.NewBuiltin("Toggle", newToggle),
-					"Option":        starlark.NewBuiltin("Option", newOption),
-					"Dropdown":      starlark.NewBuiltin("Dropdown", newDropdown),
-					"Location":      starlark.NewBuiltin("Location", newLocation),
-					"Text":          starlark.NewBuiltin("Text", newText),
-					"LocationBased": starlark.NewBuiltin("LocationBased", newLocationBased),
-					"DateTime":      starlark.NewBuiltin("DateTime", newDateTime),
-					"OAuth2":        starlark.NewBuiltin("OAuth2", newOAuth2),
-					"PhotoSelect":   starlark.NewBuiltin("PhotoSelect", newPhotoSelect),
-					"Typeahead":     starlark.NewBuiltin("Typeahead", newTypeahead),
-					"Handler":       starlark.NewBuiltin("Handler", newHandler),
-					"HandlerType":   handlerType,
-					"Generated":     starlark.NewBuiltin("Generated", newGenerated),
-					"Color":         starlark.NewBuiltin("Color", newColor),
-					"Notification":  starlark.NewBuiltin("Notification", newNotification),
-					"Sound":         starlark.NewBuiltin("Sound", newSound),
+					"Schema":               starlark.NewBuiltin("Schema", newSchema),
+					"Toggle":               newField("Toggle", newToggle),
+					"Option":               starlark.NewBuiltin("Option", newOption),
+					"Dropdown":             newField("Dropdown", newDropdown),
+					"Location":             newField("Location", newLocation),
+					"Text":                 newField("Text", newText),
+					"LocationBased":        newField("LocationBased", newLocationBased),
+					"DateTime":             newField("DateTime", newDateTime),
+					"OAuth2":               newField("OAuth2", newOAuth2),
+					"WebAuth":              newField("WebAuth", newWebAuth),
+					"PhotoSelect":          newField("PhotoSelect", newPhotoSelect),
+					"Typeahead":            newField("Typeahead", newTypeahead),
+					"Handler":              starlark.NewBuiltin("Handler", newHandler),
+					"HandlerType":          handlerType,
+					"Generated":            newField("Generated", newGenerated),
+					"Color":                newField("Color", newColor),
+					"Notification":         starlark.NewBuiltin("Notification", withTranslations(newNotification)),
+					"Sound":                starlark.NewBuiltin("Sound", newSound),
+					"Slider":               newField("Slider", newSlider),
+					"Range":                newField("Range", newRange),
+					"Schedule":             newField("Schedule", newSchedule),
+					"Duration":             newField("Duration", newDuration),
+					"ColorPalette":         newField("ColorPalette", newColorPalette),
+					"MultiSelect":          newField("MultiSelect", newMultiSelect),
+					"Repeated":             newField("Repeated", newRepeated),
+					"File":                 newField("File", newFile),
+					"Icon":                 newField("Icon", newIcon),
+					"Secret":               newField("Secret", newSecret),
+					"NotificationSettings": newField("NotificationSettings", newNotificationSettings),
+					"Section":              starlark.NewBuiltin("Section", withTranslations(newSection)),
 				},
 			},
 		}
@@ -59,6 +74,12 @@ func LoadModule() (starlark.StringDict, error) {
 	return module, nil
 }
 
+// newField wraps a field constructor with the keyword arguments that
+// all fields accept.
+func newField(name string, fn fieldConstructor) *starlark.Builtin {
+	return starlark.NewBuiltin(name, withTranslations(withRequired(withVisibleIf(fn))))
+}
+
 type Field interface {
 	AsSchemaField() SchemaField
 }
@@ -69,6 +90,8 @@ type StarlarkSchema struct {
 	starlarkFields        *starlark.List
 	starlarkHandlers      *starlark.List
 	starlarkNotifications *starlark.List
+	starlarkValidator     *starlark.Function
+	starlarkMigrate       *starlark.Function
 }
 
 func newSchema(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
@@ -77,6 +100,9 @@ func newSchema(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple
 		fields        *starlark.List
 		handlers      *starlark.List
 		notifications *starlark.List
+		validator     *starlark.Function
+		configVersion starlark.Int = starlark.MakeInt(1)
+		migrate       *starlark.Function
 	)
 
 	if err := starlark.UnpackArgs(
@@ -86,6 +112,9 @@ func newSchema(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple
 		"fields?", &fields,
 		"handlers?", &handlers,
 		"notifications?", &notifications,
+		"validator?", &validator,
+		"config_version?", &configVersion,
+		"migrate?", &migrate,
 	); err != nil {
 		return nil, fmt.Errorf("unpacking arguments for Schema: %s", err)
 	}
@@ -94,6 +123,14 @@ func newSchema(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple
 		return nil, fmt.Errorf("only schema version 1 is supported, not: %s", version.GoString())
 	}
 
+	cv, ok := configVersion.Int64()
+	if !ok || cv < 1 {
+		return nil, fmt.Errorf("config_version must be a positive int")
+	}
+	if migrate != nil && cv < 2 {
+		return nil, fmt.Errorf("migrate requires a config_version of at least 2")
+	}
+
 	s := &StarlarkSchema{
 		Schema: Schema{
 			Version: version.GoString(),
@@ -102,6 +139,8 @@ func newSchema(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple
 		starlarkFields:        fields,
 		starlarkHandlers:      handlers,
 		starlarkNotifications: notifications,
+		starlarkValidator:     validator,
+		starlarkMigrate:       migrate,
 	}
 
 	if s.starlarkFields != nil {
@@ -114,6 +153,17 @@ func newSchema(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple
 				continue
 			}
 
+			if section, ok := fieldVal.(*Section); ok {
+				for _, existing := range s.Schema.Sections {
+					if existing.ID == section.ID {
+						return nil, fmt.Errorf("duplicate section id %s", section.ID)
+					}
+				}
+				s.Schema.Sections = append(s.Schema.Sections, section.SchemaSection)
+				s.Schema.Fields = append(s.Schema.Fields, section.Fields...)
+				continue
+			}
+
 			f, ok := fieldVal.(Field)
 			if !ok {
 				return nil, fmt.Errorf(
@@ -131,6 +181,10 @@ func newSchema(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple
 
 			s.Schema.Fields = append(s.Schema.Fields, f.AsSchemaField())
 		}
+
+		if err := validateVisibility(s.Schema.Fields); err != nil {
+			return nil, err
+		}
 	}
 
 	if s.starlarkHandlers != nil {
@@ -174,6 +228,28 @@ func newSchema(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple
 		}
 	}
 
+	if validator != nil {
+		s.Schema.Validator = validator.Name()
+		s.Handlers[validator.Name()] = SchemaHandler{
+			Function:   validator,
+			ReturnType: ReturnValidation,
+		}
+	}
+
+	// version 1 is implied, and left out of the JSON to keep existing
+	// schemas unchanged
+	if cv > 1 {
+		s.Schema.ConfigVersion = int(cv)
+	}
+
+	if migrate != nil {
+		s.Schema.Migrate = migrate.Name()
+		s.Handlers[migrate.Name()] = SchemaHandler{
+			Function:   migrate,
+			ReturnType: ReturnMigration,
+		}
+	}
+
 	return s, nil
 }
 
@@ -182,6 +258,9 @@ func (s StarlarkSchema) AttrNames() []string {
 		"version",
 		"fields",
 		"handlers",
+		"validator",
+		"config_version",
+		"migrate",
 	}
 }
 
@@ -199,6 +278,24 @@ func (s StarlarkSchema) Attr(name string) (starlark.Value, error) {
 	case "notifications":
 		return s.starlarkNotifications, nil
 
+	case "validator":
+		if s.starlarkValidator == nil {
+			return starlark.None, nil
+		}
+		return s.starlarkValidator, nil
+
+	case "config_version":
+		if s.ConfigVersion == 0 {
+			return starlark.MakeInt(1), nil
+		}
+		return starlark.MakeInt(s.ConfigVersion), nil
+
+	case "migrate":
+		if s.starlarkMigrate == nil {
+			return starlark.None, nil
+		}
+		return s.starlarkMigrate, nil
+
 	default:
 		return nil, nil
 	}
@@ -0,0 +1,205 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/mitchellh/hashstructure/v2"
+	"go.starlark.net/starlark"
+)
+
+// MultiSelect lets the user pick any number of options, either from a
+// fixed list or from the results of a typeahead handler.
+type MultiSelect struct {
+	SchemaField
+	starlarkOptions *starlark.List
+	starlarkDefault *starlark.List
+}
+
+func newMultiSelect(
+	thread *starlark.Thread,
+	_ *starlark.Builtin,
+	args starlark.Tuple,
+	kwargs []starlark.Tuple,
+) (starlark.Value, error) {
+	var (
+		id      starlark.String
+		name    starlark.String
+		desc    starlark.String
+		icon    starlark.String
+		options *starlark.List
+		handler *starlark.Function
+		def     *starlark.List
+		max     starlark.Int
+	)
+
+	if err := starlark.UnpackArgs(
+		"MultiSelect",
+		args, kwargs,
+		"id", &id,
+		"name", &name,
+		"desc", &desc,
+		"icon", &icon,
+		"options?", &options,
+		"handler?", &handler,
+		"default?", &def,
+		"max?", &max,
+	); err != nil {
+		return nil, fmt.Errorf("unpacking arguments for MultiSelect: %s", err)
+	}
+
+	if (options == nil) == (handler == nil) {
+		return nil, fmt.Errorf("MultiSelect needs exactly one of options or handler")
+	}
+
+	s := &MultiSelect{}
+	s.SchemaField.Type = "multiselect"
+	s.ID = id.GoString()
+	s.Name = name.GoString()
+	s.Description = desc.GoString()
+	s.Icon = icon.GoString()
+
+	maxVal, ok := max.Int64()
+	if !ok || maxVal < 0 {
+		return nil, fmt.Errorf("max must be a non-negative int")
+	}
+	if maxVal > 0 {
+		m := float64(maxVal)
+		s.Max = &m
+	}
+
+	if handler != nil {
+		if def != nil {
+			return nil, fmt.Errorf("default can only be used with options")
+		}
+		s.Handler = handler.Name()
+		s.StarlarkHandler = handler
+		return s, nil
+	}
+
+	var optionVal starlark.Value
+	optionIter := options.Iterate()
+	defer optionIter.Done()
+	for i := 0; optionIter.Next(&optionVal); i++ {
+		if _, isNone := optionVal.(starlark.NoneType); isNone {
+			continue
+		}
+
+		o, ok := optionVal.(*Option)
+		if !ok {
+			return nil, fmt.Errorf(
+				"expected options to be a list of Option but found: %s (at index %d)",
+				optionVal.Type(),
+				i,
+			)
+		}
+
+		s.Options = append(s.Options, o.SchemaOption)
+	}
+	s.starlarkOptions = options
+
+	if def == nil {
+		s.starlarkDefault = starlark.NewList(nil)
+		return s, nil
+	}
+	if maxVal > 0 && int64(def.Len()) > maxVal {
+		return nil, fmt.Errorf("default has %d options, but max is %d", def.Len(), maxVal)
+	}
+
+	selected := []SchemaOption{}
+	for i := 0; i < def.Len(); i++ {
+		value, ok := def.Index(i).(starlark.String)
+		if !ok {
+			return nil, fmt.Errorf(
+				"expected default to be a list of string but found: %s (at index %d)",
+				def.Index(i).Type(),
+				i,
+			)
+		}
+
+		found := false
+		for _, o := range s.Options {
+			if o.Value == value.GoString() {
+				selected = append(selected, o)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("default value %s is not one of the options", value.GoString())
+		}
+	}
+
+	js, err := json.Marshal(selected)
+	if err != nil {
+		return nil, err
+	}
+	s.Default = string(js)
+	s.starlarkDefault = def
+
+	return s, nil
+}
+
+func (s *MultiSelect) AsSchemaField() SchemaField {
+	return s.SchemaField
+}
+
+func (s *MultiSelect) AttrNames() []string {
+	return []string{
+		"id", "name", "desc", "icon", "options", "handler", "default", "max",
+	}
+}
+
+func (s *MultiSelect) Attr(name string) (starlark.Value, error) {
+	switch name {
+
+	case "id":
+		return starlark.String(s.ID), nil
+
+	case "name":
+		return starlark.String(s.Name), nil
+
+	case "desc":
+		return starlark.String(s.Description), nil
+
+	case "icon":
+		return starlark.String(s.Icon), nil
+
+	case "options":
+		if s.starlarkOptions == nil {
+			return starlark.None, nil
+		}
+		return s.starlarkOptions, nil
+
+	case "handler":
+		if s.StarlarkHandler == nil {
+			return starlark.None, nil
+		}
+		return s.StarlarkHandler, nil
+
+	case "default":
+		if s.starlarkDefault == nil {
+			return starlark.None, nil
+		}
+		return s.starlarkDefault, nil
+
+	case "max":
+		if s.Max == nil {
+			return starlark.None, nil
+		}
+		return starlark.MakeInt64(int64(*s.Max)), nil
+
+	default:
+		return nil, nil
+	}
+}
+
+func (s *MultiSelect) String() string       { return "MultiSelect(...)" }
+func (s *MultiSelect) Type() string         { return "MultiSelect" }
+func (s *MultiSelect) Freeze()              {}
+func (s *MultiSelect) Truth() starlark.Bool { return true }
+
+func (s *MultiSelect) Hash() (uint32, error) {
+	sum, err := hashstructure.Hash(s, hashstructure.FormatV2, nil)
+	return uint32(sum), err
+}
@@ -0,0 +1,143 @@
+package schema_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"tidbyt.dev/pixlet/runtime"
+	"tidbyt.dev/pixlet/schema"
+)
+
+var multiSelectSource = `
+load("schema.star", "schema")
+
+def assert(success, message=None):
+    if not success:
+        fail(message or "assertion failed")
+
+options = [
+	schema.Option(display = "Red Line", value = "red"),
+	schema.Option(display = "Blue Line", value = "blue"),
+	schema.Option(display = "Green Line", value = "green"),
+]
+
+s = schema.MultiSelect(
+	id = "lines",
+	name = "Lines",
+	desc = "Lines to watch.",
+	icon = "train",
+	options = options,
+	default = ["red", "green"],
+	max = 2,
+)
+
+assert(s.id == "lines")
+assert(s.name == "Lines")
+assert(s.desc == "Lines to watch.")
+assert(s.icon == "train")
+assert(s.options == options)
+assert(s.handler == None)
+assert(s.default == ["red", "green"])
+assert(s.max == 2)
+
+def search(pattern):
+	return []
+
+h = schema.MultiSelect(
+	id = "symbols",
+	name = "Symbols",
+	desc = "Stocks to track.",
+	icon = "chartLine",
+	handler = search,
+)
+
+assert(h.handler == search)
+assert(h.options == None)
+assert(h.default == None)
+assert(h.max == None)
+
+def main():
+	return []
+`
+
+func TestMultiSelect(t *testing.T) {
+	app, err := runtime.NewApplet("multi_select.star", []byte(multiSelectSource))
+	assert.NoError(t, err)
+
+	screens, err := app.Run(context.Background())
+	assert.NoError(t, err)
+	assert.NotNil(t, screens)
+}
+
+func TestMultiSelectBadArgs(t *testing.T) {
+	for _, args := range []string{
+		``,
+		`options = [], handler = search`,
+		`handler = search, default = ["a"]`,
+		`options = [schema.Option(display = "A", value = "a")], default = ["b"]`,
+		`options = [schema.Option(display = "A", value = "a")], default = ["a"], max = -1`,
+		`options = [schema.Option(display = "A", value = "a"), schema.Option(display = "B", value = "b")], default = ["a", "b"], max = 1`,
+	} {
+		src := `
+load("schema.star", "schema")
+
+def search(pattern):
+	return []
+
+s = schema.MultiSelect(
+	id = "multi",
+	name = "Multi",
+	desc = "A multiselect.",
+	icon = "list",
+	` + args + `
+)
+
+def main():
+	return []
+`
+		_, err := runtime.NewApplet("multi_select.star", []byte(src))
+		assert.Error(t, err, args)
+	}
+}
+
+func TestMultiSelectHandler(t *testing.T) {
+	code := `
+load("schema.star", "schema")
+
+def search(pattern):
+    return [
+        schema.Option(display = "%s Inc" % pattern.upper(), value = pattern),
+    ]
+
+def get_schema():
+    return schema.Schema(
+        version = "1",
+        fields = [
+            schema.MultiSelect(
+                id = "symbols",
+                name = "Symbols",
+                desc = "Stocks to track.",
+                icon = "chartLine",
+                handler = search,
+            ),
+        ],
+    )
+
+def main():
+    return None
+`
+
+	app, err := loadApp(code)
+	assert.NoError(t, err)
+
+	result, err := app.CallSchemaHandler(context.Background(), "symbols$search", "abc")
+	assert.NoError(t, err)
+
+	var options []schema.SchemaOption
+	assert.NoError(t, json.Unmarshal([]byte(result), &options))
+	assert.Equal(t, []schema.SchemaOption{
+		{Display: "ABC Inc", Text: "ABC Inc", Value: "abc"},
+	}, options)
+}
@@ -0,0 +1,267 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/hashstructure/v2"
+	"go.starlark.net/starlark"
+)
+
+// NotificationPriorities are the priorities a user can pick for an
+// app's notifications, from least to most urgent.
+var NotificationPriorities = []string{"low", "normal", "high"}
+
+// NotificationSettings lets the user decide whether and when an app may
+// send notifications. Its value has the same shape for every app, so
+// hosts can honor it without knowing anything about the app.
+type NotificationSettings struct {
+	SchemaField
+	starlarkQuietHours starlark.Value
+}
+
+// NotificationSettingsValue is the value of a NotificationSettings
+// field, as provided in config.
+type NotificationSettingsValue struct {
+	Enabled    bool        `json:"enabled"`
+	Priority   string      `json:"priority"`
+	QuietHours *QuietHours `json:"quiet_hours,omitempty"`
+}
+
+// QuietHours is a daily period, in the display's local time, during
+// which notifications shouldn't be shown. Start and End are formatted
+// as HH:MM, and the period wraps around midnight if End is before
+// Start.
+type QuietHours struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+func parseClock(what, value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be formatted as HH:MM, found %q", what, value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func (q *QuietHours) validate() error {
+	start, err := parseClock("quiet hours start", q.Start)
+	if err != nil {
+		return err
+	}
+	end, err := parseClock("quiet hours end", q.End)
+	if err != nil {
+		return err
+	}
+	if start == end {
+		return fmt.Errorf("quiet hours can't start and end at the same time")
+	}
+	return nil
+}
+
+// Contains reports whether t falls within the quiet hours.
+func (q *QuietHours) Contains(t time.Time) bool {
+	start, err := parseClock("start", q.Start)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock("end", q.End)
+	if err != nil {
+		return false
+	}
+
+	now := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	if start < end {
+		return now >= start && now < end
+	}
+	return now >= start || now < end
+}
+
+// Allows reports whether a notification may be shown at t.
+func (v *NotificationSettingsValue) Allows(t time.Time) bool {
+	if !v.Enabled {
+		return false
+	}
+	if v.QuietHours != nil && v.QuietHours.Contains(t) {
+		return false
+	}
+	return true
+}
+
+// ParseNotificationSettings parses and validates the config value of a
+// NotificationSettings field.
+func ParseNotificationSettings(value string) (*NotificationSettingsValue, error) {
+	v := &NotificationSettingsValue{}
+	if err := json.Unmarshal([]byte(value), v); err != nil {
+		return nil, fmt.Errorf("malformed notification settings: %w", err)
+	}
+
+	if !isNotificationPriority(v.Priority) {
+		return nil, fmt.Errorf("unknown notification priority: %q", v.Priority)
+	}
+
+	if v.QuietHours != nil {
+		if err := v.QuietHours.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return v, nil
+}
+
+func isNotificationPriority(priority string) bool {
+	for _, p := range NotificationPriorities {
+		if p == priority {
+			return true
+		}
+	}
+	return false
+}
+
+func newNotificationSettings(
+	thread *starlark.Thread,
+	_ *starlark.Builtin,
+	args starlark.Tuple,
+	kwargs []starlark.Tuple,
+) (starlark.Value, error) {
+	var (
+		id         starlark.String
+		name       starlark.String
+		desc       starlark.String
+		icon       starlark.String
+		enabled    starlark.Bool   = true
+		priority   starlark.String = "normal"
+		quietHours starlark.Value  = starlark.None
+	)
+
+	if err := starlark.UnpackArgs(
+		"NotificationSettings",
+		args, kwargs,
+		"id", &id,
+		"name", &name,
+		"desc", &desc,
+		"icon", &icon,
+		"enabled?", &enabled,
+		"priority?", &priority,
+		"quiet_hours?", &quietHours,
+	); err != nil {
+		return nil, fmt.Errorf("unpacking arguments for NotificationSettings: %s", err)
+	}
+
+	s := &NotificationSettings{}
+	s.SchemaField.Type = "notificationsettings"
+	s.ID = id.GoString()
+	s.Name = name.GoString()
+	s.Description = desc.GoString()
+	s.Icon = icon.GoString()
+
+	if !isNotificationPriority(priority.GoString()) {
+		return nil, fmt.Errorf(
+			"priority must be one of %s, found %q",
+			strings.Join(NotificationPriorities, ", "),
+			priority.GoString(),
+		)
+	}
+
+	for _, p := range NotificationPriorities {
+		display := strings.ToUpper(p[:1]) + p[1:]
+		s.Options = append(s.Options, SchemaOption{
+			Display: display,
+			Text:    display,
+			Value:   p,
+		})
+	}
+
+	def := NotificationSettingsValue{
+		Enabled:  bool(enabled),
+		Priority: priority.GoString(),
+	}
+
+	if _, isNone := quietHours.(starlark.NoneType); !isNone {
+		period, ok := quietHours.(starlark.Indexable)
+		if !ok || period.Len() != 2 {
+			return nil, fmt.Errorf("quiet_hours must be a pair of start and end times")
+		}
+
+		start, ok := period.Index(0).(starlark.String)
+		if !ok {
+			return nil, fmt.Errorf("quiet_hours start must be a string, found %s", period.Index(0).Type())
+		}
+		end, ok := period.Index(1).(starlark.String)
+		if !ok {
+			return nil, fmt.Errorf("quiet_hours end must be a string, found %s", period.Index(1).Type())
+		}
+
+		def.QuietHours = &QuietHours{Start: start.GoString(), End: end.GoString()}
+		if err := def.QuietHours.validate(); err != nil {
+			return nil, err
+		}
+	}
+	s.starlarkQuietHours = quietHours
+
+	js, err := json.Marshal(def)
+	if err != nil {
+		return nil, err
+	}
+	s.Default = string(js)
+
+	return s, nil
+}
+
+func (s *NotificationSettings) defaultValue() NotificationSettingsValue {
+	var v NotificationSettingsValue
+	json.Unmarshal([]byte(s.Default), &v)
+	return v
+}
+
+func (s *NotificationSettings) AsSchemaField() SchemaField {
+	return s.SchemaField
+}
+
+func (s *NotificationSettings) AttrNames() []string {
+	return []string{
+		"id", "name", "desc", "icon", "enabled", "priority", "quiet_hours",
+	}
+}
+
+func (s *NotificationSettings) Attr(name string) (starlark.Value, error) {
+	switch name {
+
+	case "id":
+		return starlark.String(s.ID), nil
+
+	case "name":
+		return starlark.String(s.Name), nil
+
+	case "desc":
+		return starlark.String(s.Description), nil
+
+	case "icon":
+		return starlark.String(s.Icon), nil
+
+	case "enabled":
+		return starlark.Bool(s.defaultValue().Enabled), nil
+
+	case "priority":
+		return starlark.String(s.defaultValue().Priority), nil
+
+	case "quiet_hours":
+		return s.starlarkQuietHours, nil
+
+	default:
+		return nil, nil
+	}
+}
+
+func (s *NotificationSettings) String() string       { return "NotificationSettings(...)" }
+func (s *NotificationSettings) Type() string         { return "NotificationSettings" }
+func (s *NotificationSettings) Freeze()              {}
+func (s *NotificationSettings) Truth() starlark.Bool { return true }
+
+func (s *NotificationSettings) Hash() (uint32, error) {
+	sum, err := hashstructure.Hash(s, hashstructure.FormatV2, nil)
+	return uint32(sum), err
+}
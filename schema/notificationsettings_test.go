@@ -0,0 +1,167 @@
+package schema_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"tidbyt.dev/pixlet/runtime"
+	"tidbyt.dev/pixlet/schema"
+)
+
+var notificationSettingsSource = `
+load("schema.star", "schema")
+
+def assert(success, message=None):
+    if not success:
+        fail(message or "assertion failed")
+
+n = schema.NotificationSettings(
+	id = "alerts",
+	name = "Alerts",
+	desc = "When to show alerts.",
+	icon = "bell",
+	priority = "high",
+	quiet_hours = ("22:00", "07:00"),
+)
+
+assert(n.id == "alerts")
+assert(n.name == "Alerts")
+assert(n.desc == "When to show alerts.")
+assert(n.icon == "bell")
+assert(n.enabled == True)
+assert(n.priority == "high")
+assert(n.quiet_hours == ("22:00", "07:00"))
+
+d = schema.NotificationSettings(
+	id = "alerts",
+	name = "Alerts",
+	desc = "When to show alerts.",
+	icon = "bell",
+	enabled = False,
+)
+
+assert(d.enabled == False)
+assert(d.priority == "normal")
+assert(d.quiet_hours == None)
+
+def main():
+	return []
+`
+
+func TestNotificationSettings(t *testing.T) {
+	app, err := runtime.NewApplet("notificationsettings.star", []byte(notificationSettingsSource))
+	assert.NoError(t, err)
+
+	screens, err := app.Run(context.Background())
+	assert.NoError(t, err)
+	assert.NotNil(t, screens)
+}
+
+func TestNotificationSettingsBadArgs(t *testing.T) {
+	for _, args := range []string{
+		`priority = "urgent"`,
+		`quiet_hours = ("22:00",)`,
+		`quiet_hours = ("10pm", "7am")`,
+		`quiet_hours = ("22:00", "22:00")`,
+		`quiet_hours = (22, 7)`,
+	} {
+		src := `
+load("schema.star", "schema")
+
+n = schema.NotificationSettings(
+	id = "alerts",
+	name = "Alerts",
+	desc = "When to show alerts.",
+	icon = "bell",
+	` + args + `,
+)
+
+def main():
+	return []
+`
+		_, err := runtime.NewApplet("notificationsettings.star", []byte(src))
+		assert.Error(t, err, args)
+	}
+}
+
+func TestNotificationSettingsSchemaJSON(t *testing.T) {
+	app, err := loadApp(`
+load("schema.star", "schema")
+
+def get_schema():
+    return schema.Schema(
+        version = "1",
+        fields = [
+            schema.NotificationSettings(
+                id = "alerts",
+                name = "Alerts",
+                desc = "When to show alerts.",
+                icon = "bell",
+                quiet_hours = ["22:00", "07:00"],
+            ),
+        ],
+    )
+
+def main():
+    return []
+`)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"version": "1",
+		"schema": [
+			{
+				"type": "notificationsettings",
+				"id": "alerts",
+				"name": "Alerts",
+				"description": "When to show alerts.",
+				"icon": "bell",
+				"default": "{\"enabled\":true,\"priority\":\"normal\",\"quiet_hours\":{\"start\":\"22:00\",\"end\":\"07:00\"}}",
+				"options": [
+					{"display": "Low", "text": "Low", "value": "low"},
+					{"display": "Normal", "text": "Normal", "value": "normal"},
+					{"display": "High", "text": "High", "value": "high"}
+				]
+			}
+		]
+	}`, string(app.SchemaJSON))
+}
+
+func TestParseNotificationSettings(t *testing.T) {
+	v, err := schema.ParseNotificationSettings(
+		`{"enabled": true, "priority": "low", "quiet_hours": {"start": "22:00", "end": "07:00"}}`,
+	)
+	require.NoError(t, err)
+	assert.True(t, v.Enabled)
+	assert.Equal(t, "low", v.Priority)
+
+	at := func(clock string) time.Time {
+		t, _ := time.Parse("15:04", clock)
+		return t
+	}
+	assert.True(t, v.Allows(at("12:00")))
+	assert.True(t, v.Allows(at("21:59")))
+	assert.False(t, v.Allows(at("22:00")))
+	assert.False(t, v.Allows(at("03:00")))
+	assert.True(t, v.Allows(at("07:00")))
+
+	v.QuietHours = &schema.QuietHours{Start: "01:00", End: "05:00"}
+	assert.False(t, v.Allows(at("02:30")))
+	assert.True(t, v.Allows(at("23:00")))
+
+	v.Enabled = false
+	assert.False(t, v.Allows(at("12:00")))
+
+	for _, value := range []string{
+		`{"enabled": true}`,
+		`{"enabled": true, "priority": "urgent"}`,
+		`{"enabled": true, "priority": "low", "quiet_hours": {"start": "22:00"}}`,
+		`not json`,
+	} {
+		_, err := schema.ParseNotificationSettings(value)
+		assert.Error(t, err, value)
+	}
+}
@@ -9,7 +9,37 @@ import (
 
 type OAuth2 struct {
 	SchemaField
-	starlarkScopes *starlark.List
+	starlarkScopes         *starlark.List
+	starlarkOptionalScopes *starlark.List
+}
+
+// stringList converts a Starlark list of strings, skipping None.
+func stringList(what string, list *starlark.List) ([]string, error) {
+	strs := []string{}
+
+	iter := list.Iterate()
+	defer iter.Done()
+
+	var val starlark.Value
+	for i := 0; iter.Next(&val); i++ {
+		if _, isNone := val.(starlark.NoneType); isNone {
+			continue
+		}
+
+		str, ok := val.(starlark.String)
+		if !ok {
+			return nil, fmt.Errorf(
+				"expected %s to be a list of string but found: %s (at index %d)",
+				what,
+				val.Type(),
+				i,
+			)
+		}
+
+		strs = append(strs, str.GoString())
+	}
+
+	return strs, nil
 }
 
 func newOAuth2(
@@ -27,6 +57,9 @@ func newOAuth2(
 		clientID     starlark.String
 		authEndpoint starlark.String
 		scopes       *starlark.List
+		pkce         starlark.Bool
+		refresh      *starlark.Function
+		optional     *starlark.List
 	)
 
 	if err := starlark.UnpackArgs(
@@ -40,6 +73,9 @@ func newOAuth2(
 		"client_id", &clientID,
 		"authorization_endpoint", &authEndpoint,
 		"scopes", &scopes,
+		"pkce?", &pkce,
+		"refresh_handler?", &refresh,
+		"optional_scopes?", &optional,
 	); err != nil {
 		return nil, fmt.Errorf("unpacking arguments for OAuth2: %s", err)
 	}
@@ -57,28 +93,31 @@ func newOAuth2(
 	s.starlarkScopes = scopes
 
 	if s.starlarkScopes != nil {
-		scopesIter := s.starlarkScopes.Iterate()
-		defer scopesIter.Done()
-
-		var scopeVal starlark.Value
-		for i := 0; scopesIter.Next(&scopeVal); {
-			if _, isNone := scopeVal.(starlark.NoneType); isNone {
-				continue
-			}
-
-			scope, ok := scopeVal.(starlark.String)
-			if !ok {
-				return nil, fmt.Errorf(
-					"expected fields to be a list of string but found: %s (at index %d)",
-					scopeVal.Type(),
-					i,
-				)
-			}
-
-			s.Scopes = append(s.Scopes, scope.GoString())
+		var err error
+		s.Scopes, err = stringList("scopes", s.starlarkScopes)
+		if err != nil {
+			return nil, err
 		}
 	}
 
+	s.PKCE = bool(pkce)
+
+	if refresh != nil {
+		s.RefreshHandler = refresh.Name()
+		s.StarlarkRefreshHandler = refresh
+	}
+
+	if optional != nil {
+		var err error
+		s.OptionalScopes, err = stringList("optional_scopes", optional)
+		if err != nil {
+			return nil, err
+		}
+		s.starlarkOptionalScopes = optional
+	} else {
+		s.starlarkOptionalScopes = starlark.NewList(nil)
+	}
+
 	return s, nil
 }
 
@@ -89,6 +128,7 @@ func (s *OAuth2) AsSchemaField() SchemaField {
 func (s *OAuth2) AttrNames() []string {
 	return []string{
 		"id", "name", "desc", "icon", "handler", "client_id", "authorization_endpoint", "scopes",
+		"pkce", "refresh_handler", "optional_scopes",
 	}
 }
 
@@ -119,6 +159,18 @@ func (s *OAuth2) Attr(name string) (starlark.Value, error) {
 	case "scopes":
 		return s.starlarkScopes, nil
 
+	case "pkce":
+		return starlark.Bool(s.PKCE), nil
+
+	case "refresh_handler":
+		if s.StarlarkRefreshHandler == nil {
+			return starlark.None, nil
+		}
+		return s.StarlarkRefreshHandler, nil
+
+	case "optional_scopes":
+		return s.starlarkOptionalScopes, nil
+
 	default:
 		return nil, nil
 	}
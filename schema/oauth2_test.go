@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"tidbyt.dev/pixlet/runtime"
 )
 
@@ -41,6 +42,30 @@ assert(t.handler("{}") == "foobar123")
 assert(t.client_id == "the-oauth2-client-id")
 assert(t.authorization_endpoint == "https://example.com/")
 assert(t.scopes == ["read:user"])
+assert(t.pkce == False)
+assert(t.refresh_handler == None)
+assert(t.optional_scopes == [])
+
+def refresh_handler(params):
+    return "refreshed"
+
+p = schema.OAuth2(
+    id = "spotify",
+    name = "Spotify",
+    desc = "Connect your Spotify account.",
+    icon = "spotify",
+    handler = oauth_handler,
+    client_id = "the-oauth2-client-id",
+    authorization_endpoint = "https://example.com/",
+    scopes = ["user-read-currently-playing"],
+    pkce = True,
+    refresh_handler = refresh_handler,
+    optional_scopes = ["user-read-recently-played"],
+)
+
+assert(p.pkce == True)
+assert(p.refresh_handler == refresh_handler)
+assert(p.optional_scopes == ["user-read-recently-played"])
 
 def main():
     return []
@@ -55,3 +80,60 @@ func TestOAuth2(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, screens)
 }
+
+func TestOAuth2RefreshHandler(t *testing.T) {
+	code := `
+load("encoding/json.star", "json")
+load("schema.star", "schema")
+
+def oauth_handler(params):
+    return json.encode({"access_token": "a1", "refresh_token": "r1"})
+
+def refresh_handler(params):
+    params = json.decode(params)
+    if params["grant_type"] != "refresh_token":
+        fail("unexpected grant type")
+    token = json.decode(params["value"])
+    return json.encode({"access_token": "a2", "refresh_token": token["refresh_token"]})
+
+def get_schema():
+    return schema.Schema(
+        version = "1",
+        fields = [
+            schema.OAuth2(
+                id = "auth",
+                name = "Spotify",
+                desc = "Connect your Spotify account.",
+                icon = "spotify",
+                handler = oauth_handler,
+                client_id = "client-id",
+                authorization_endpoint = "https://example.com/authorize",
+                scopes = ["user-read-currently-playing"],
+                optional_scopes = ["user-read-recently-played"],
+                pkce = True,
+                refresh_handler = refresh_handler,
+            ),
+        ],
+    )
+
+def main():
+    return None
+`
+
+	app, err := loadApp(code)
+	require.NoError(t, err)
+
+	field := app.Schema.Fields[0]
+	assert.True(t, field.PKCE)
+	assert.Equal(t, "auth$oauth_handler", field.Handler)
+	assert.Equal(t, "auth$refresh_handler", field.RefreshHandler)
+	assert.Equal(t, []string{"user-read-recently-played"}, field.OptionalScopes)
+
+	result, err := app.CallSchemaHandler(
+		context.Background(),
+		field.RefreshHandler,
+		`{"value": "{\"access_token\": \"a1\", \"refresh_token\": \"r1\"}", "client_id": "client-id", "grant_type": "refresh_token"}`,
+	)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"access_token": "a2", "refresh_token": "r1"}`, result)
+}
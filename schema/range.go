@@ -0,0 +1,209 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/mitchellh/hashstructure/v2"
+	"go.starlark.net/starlark"
+)
+
+// Range lets the user pick a lower and upper bound within min and max,
+// like a price range or a temperature band.
+type Range struct {
+	SchemaField
+	starlarkDefault starlark.Tuple
+}
+
+// RangeValue is the value of a Range field, as provided in config.
+type RangeValue struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// ParseRange parses and validates the config value of a Range field.
+func ParseRange(value string) (*RangeValue, error) {
+	var raw struct {
+		Min *float64 `json:"min"`
+		Max *float64 `json:"max"`
+	}
+	if err := json.Unmarshal([]byte(value), &raw); err != nil {
+		return nil, fmt.Errorf("malformed range: %w", err)
+	}
+	if raw.Min == nil || raw.Max == nil {
+		return nil, fmt.Errorf("malformed range: needs both min and max")
+	}
+
+	r := &RangeValue{Min: *raw.Min, Max: *raw.Max}
+	if r.Min > r.Max {
+		return nil, fmt.Errorf(
+			"range min (%s) must not be greater than max (%s)",
+			formatNumber(r.Min), formatNumber(r.Max),
+		)
+	}
+
+	return r, nil
+}
+
+func newRange(
+	thread *starlark.Thread,
+	_ *starlark.Builtin,
+	args starlark.Tuple,
+	kwargs []starlark.Tuple,
+) (starlark.Value, error) {
+	var (
+		id   starlark.String
+		name starlark.String
+		desc starlark.String
+		icon starlark.String
+		min  starlark.Value
+		max  starlark.Value
+		step starlark.Value = starlark.MakeInt(1)
+		def  starlark.Sequence
+		unit starlark.String
+	)
+
+	if err := starlark.UnpackArgs(
+		"Range",
+		args, kwargs,
+		"id", &id,
+		"name", &name,
+		"desc", &desc,
+		"icon", &icon,
+		"min", &min,
+		"max", &max,
+		"step?", &step,
+		"default?", &def,
+		"unit?", &unit,
+	); err != nil {
+		return nil, fmt.Errorf("unpacking arguments for Range: %s", err)
+	}
+
+	minVal, err := asNumber("min", min)
+	if err != nil {
+		return nil, err
+	}
+	maxVal, err := asNumber("max", max)
+	if err != nil {
+		return nil, err
+	}
+	stepVal, err := asNumber("step", step)
+	if err != nil {
+		return nil, err
+	}
+
+	if minVal >= maxVal {
+		return nil, fmt.Errorf("min (%s) must be less than max (%s)", formatNumber(minVal), formatNumber(maxVal))
+	}
+	if stepVal <= 0 || stepVal > maxVal-minVal {
+		return nil, fmt.Errorf("step must be positive and no larger than max - min, found %s", formatNumber(stepVal))
+	}
+
+	defVal := RangeValue{Min: minVal, Max: maxVal}
+	if def != nil {
+		if def.Len() != 2 {
+			return nil, fmt.Errorf("default must be a pair of numbers, found %d values", def.Len())
+		}
+
+		iter := def.Iterate()
+		var lo, hi starlark.Value
+		iter.Next(&lo)
+		iter.Next(&hi)
+		iter.Done()
+
+		if defVal.Min, err = asNumber("default", lo); err != nil {
+			return nil, err
+		}
+		if defVal.Max, err = asNumber("default", hi); err != nil {
+			return nil, err
+		}
+
+		if defVal.Min > defVal.Max {
+			return nil, fmt.Errorf(
+				"default lower bound (%s) must not be greater than upper bound (%s)",
+				formatNumber(defVal.Min), formatNumber(defVal.Max),
+			)
+		}
+		if defVal.Min < minVal || defVal.Max > maxVal {
+			return nil, fmt.Errorf("default must be between min and max")
+		}
+	}
+
+	js, err := json.Marshal(defVal)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Range{}
+	s.SchemaField.Type = "range"
+	s.ID = id.GoString()
+	s.Name = name.GoString()
+	s.Description = desc.GoString()
+	s.Icon = icon.GoString()
+	s.Default = string(js)
+	s.Min = &minVal
+	s.Max = &maxVal
+	s.Step = &stepVal
+	s.Unit = unit.GoString()
+	s.starlarkDefault = starlark.Tuple{
+		starlark.Float(defVal.Min),
+		starlark.Float(defVal.Max),
+	}
+
+	return s, nil
+}
+
+func (s *Range) AsSchemaField() SchemaField {
+	return s.SchemaField
+}
+
+func (s *Range) AttrNames() []string {
+	return []string{
+		"id", "name", "desc", "icon", "min", "max", "step", "default", "unit",
+	}
+}
+
+func (s *Range) Attr(name string) (starlark.Value, error) {
+	switch name {
+
+	case "id":
+		return starlark.String(s.ID), nil
+
+	case "name":
+		return starlark.String(s.Name), nil
+
+	case "desc":
+		return starlark.String(s.Description), nil
+
+	case "icon":
+		return starlark.String(s.Icon), nil
+
+	case "min":
+		return starlark.Float(*s.Min), nil
+
+	case "max":
+		return starlark.Float(*s.Max), nil
+
+	case "step":
+		return starlark.Float(*s.Step), nil
+
+	case "default":
+		return s.starlarkDefault, nil
+
+	case "unit":
+		return starlark.String(s.Unit), nil
+
+	default:
+		return nil, nil
+	}
+}
+
+func (s *Range) String() string       { return "Range(...)" }
+func (s *Range) Type() string         { return "Range" }
+func (s *Range) Freeze()              {}
+func (s *Range) Truth() starlark.Bool { return true }
+
+func (s *Range) Hash() (uint32, error) {
+	sum, err := hashstructure.Hash(s, hashstructure.FormatV2, nil)
+	return uint32(sum), err
+}
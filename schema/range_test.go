@@ -0,0 +1,165 @@
+package schema_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"tidbyt.dev/pixlet/runtime"
+	"tidbyt.dev/pixlet/schema"
+)
+
+var rangeSource = `
+load("schema.star", "schema")
+
+def assert(success, message=None):
+    if not success:
+        fail(message or "assertion failed")
+
+r = schema.Range(
+	id = "price",
+	name = "Price",
+	desc = "Price range to show.",
+	icon = "dollarSign",
+	min = 0,
+	max = 500,
+	step = 10,
+	default = (50, 200),
+	unit = "$",
+)
+
+assert(r.id == "price")
+assert(r.name == "Price")
+assert(r.desc == "Price range to show.")
+assert(r.icon == "dollarSign")
+assert(r.min == 0)
+assert(r.max == 500)
+assert(r.step == 10)
+assert(r.default == (50, 200))
+assert(r.unit == "$")
+
+f = schema.Range(
+	id = "temp",
+	name = "Temperature",
+	desc = "Comfortable temperatures.",
+	icon = "temperatureHalf",
+	min = -10.5,
+	max = 40,
+	step = 0.5,
+)
+
+assert(f.default == (-10.5, 40))
+
+def main():
+	return []
+`
+
+func TestRange(t *testing.T) {
+	app, err := runtime.NewApplet("range.star", []byte(rangeSource))
+	assert.NoError(t, err)
+
+	screens, err := app.Run(context.Background())
+	assert.NoError(t, err)
+	assert.NotNil(t, screens)
+}
+
+func TestRangeBadArgs(t *testing.T) {
+	for _, args := range []string{
+		`min = 10, max = 0`,
+		`min = 0, max = 10, step = 0`,
+		`min = 0, max = 10, step = 20`,
+		`min = 0, max = 10, default = (8, 2)`,
+		`min = 0, max = 10, default = (2, 11)`,
+		`min = 0, max = 10, default = (2,)`,
+		`min = 0, max = 10, default = ("2", 5)`,
+		`min = 0, max = 10, default = 5`,
+	} {
+		src := fmt.Sprintf(`
+load("schema.star", "schema")
+
+r = schema.Range(
+	id = "range",
+	name = "Range",
+	desc = "A range.",
+	icon = "ruler",
+	%s,
+)
+
+def main():
+	return []
+`, args)
+
+		_, err := runtime.NewApplet("range.star", []byte(src))
+		assert.Error(t, err, args)
+	}
+}
+
+func TestRangeSchemaJSON(t *testing.T) {
+	app, err := loadApp(`
+load("schema.star", "schema")
+
+def get_schema():
+    return schema.Schema(
+        version = "1",
+        fields = [
+            schema.Range(
+                id = "price",
+                name = "Price",
+                desc = "Price range to show.",
+                icon = "dollarSign",
+                min = 0,
+                max = 500,
+                step = 10,
+                default = (50, 200),
+            ),
+        ],
+    )
+
+def main():
+    return []
+`)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"version": "1",
+		"schema": [
+			{
+				"type": "range",
+				"id": "price",
+				"name": "Price",
+				"description": "Price range to show.",
+				"icon": "dollarSign",
+				"default": "{\"min\":50,\"max\":200}",
+				"min": 0,
+				"max": 500,
+				"step": 10
+			}
+		]
+	}`, string(app.SchemaJSON))
+
+	assert.Nil(t, app.Schema.ValidateConfig(map[string]string{"price": `{"min": 0, "max": 100}`}))
+	assert.Equal(t, schema.ConfigErrors{
+		{Field: "price", Message: "range min (300) must not be greater than max (100)"},
+	}, app.Schema.ValidateConfig(map[string]string{"price": `{"min": 300, "max": 100}`}))
+	assert.Equal(t, schema.ConfigErrors{
+		{Field: "price", Message: "must be at most 500, found 600"},
+	}, app.Schema.ValidateConfig(map[string]string{"price": `{"min": 300, "max": 600}`}))
+}
+
+func TestParseRange(t *testing.T) {
+	r, err := schema.ParseRange(`{"min": -2.5, "max": 7}`)
+	require.NoError(t, err)
+	assert.Equal(t, &schema.RangeValue{Min: -2.5, Max: 7}, r)
+
+	for _, value := range []string{
+		``,
+		`{"min": 1}`,
+		`{"min": "1", "max": "2"}`,
+		`{"min": 3, "max": 2}`,
+	} {
+		_, err := schema.ParseRange(value)
+		assert.Error(t, err, value)
+	}
+}
@@ -0,0 +1,193 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/mitchellh/hashstructure/v2"
+	"go.starlark.net/starlark"
+)
+
+// repeatedFieldTypes are the field types that can be used inside a
+// Repeated field. Fields with handlers aren't supported, since the
+// handler wouldn't know which of the items it's being called for.
+var repeatedFieldTypes = map[string]bool{
+	"color":    true,
+	"datetime": true,
+	"dropdown": true,
+	"duration": true,
+	"onoff":    true,
+	"slider":   true,
+	"text":     true,
+}
+
+// Repeated lets the user add and remove any number of items, each
+// configured with the same set of fields.
+type Repeated struct {
+	SchemaField
+	starlarkFields *starlark.List
+}
+
+func newRepeated(
+	thread *starlark.Thread,
+	_ *starlark.Builtin,
+	args starlark.Tuple,
+	kwargs []starlark.Tuple,
+) (starlark.Value, error) {
+	var (
+		id     starlark.String
+		name   starlark.String
+		desc   starlark.String
+		icon   starlark.String
+		fields *starlark.List
+		min    starlark.Int
+		max    starlark.Int
+	)
+
+	if err := starlark.UnpackArgs(
+		"Repeated",
+		args, kwargs,
+		"id", &id,
+		"name", &name,
+		"desc", &desc,
+		"icon", &icon,
+		"fields", &fields,
+		"min?", &min,
+		"max?", &max,
+	); err != nil {
+		return nil, fmt.Errorf("unpacking arguments for Repeated: %s", err)
+	}
+
+	s := &Repeated{}
+	s.SchemaField.Type = "repeated"
+	s.ID = id.GoString()
+	s.Name = name.GoString()
+	s.Description = desc.GoString()
+	s.Icon = icon.GoString()
+
+	minVal, ok := min.Int64()
+	if !ok || minVal < 0 {
+		return nil, fmt.Errorf("min must be a non-negative int")
+	}
+	maxVal, ok := max.Int64()
+	if !ok || maxVal < 0 {
+		return nil, fmt.Errorf("max must be a non-negative int")
+	}
+	if maxVal > 0 && maxVal < minVal {
+		return nil, fmt.Errorf("max (%d) can't be less than min (%d)", maxVal, minVal)
+	}
+	if minVal > 0 {
+		m := float64(minVal)
+		s.Min = &m
+	}
+	if maxVal > 0 {
+		m := float64(maxVal)
+		s.Max = &m
+	}
+
+	ids := map[string]bool{}
+	for i := 0; i < fields.Len(); i++ {
+		f, ok := fields.Index(i).(Field)
+		if !ok {
+			return nil, fmt.Errorf(
+				"expected fields to be a list of schema fields but found: %s (at index %d)",
+				fields.Index(i).Type(),
+				i,
+			)
+		}
+
+		field := f.AsSchemaField()
+		if !repeatedFieldTypes[field.Type] {
+			return nil, fmt.Errorf("fields of type %s can't be used in Repeated", fields.Index(i).Type())
+		}
+		if ids[field.ID] {
+			return nil, fmt.Errorf("duplicate field id %s", field.ID)
+		}
+		ids[field.ID] = true
+
+		s.Fields = append(s.Fields, field)
+	}
+	if len(s.Fields) == 0 {
+		return nil, fmt.Errorf("fields can't be empty")
+	}
+	s.starlarkFields = fields
+
+	// Each item starts out with the defaults of its fields, so
+	// that any required items are ready to use.
+	if minVal > 0 {
+		item := map[string]string{}
+		for _, field := range s.Fields {
+			if def := field.defaultValue(); def != "" {
+				item[field.ID] = def
+			}
+		}
+
+		items := make([]map[string]string, minVal)
+		for i := range items {
+			items[i] = item
+		}
+
+		js, err := json.Marshal(items)
+		if err != nil {
+			return nil, err
+		}
+		s.Default = string(js)
+	}
+
+	return s, nil
+}
+
+func (s *Repeated) AsSchemaField() SchemaField {
+	return s.SchemaField
+}
+
+func (s *Repeated) AttrNames() []string {
+	return []string{
+		"id", "name", "desc", "icon", "fields", "min", "max",
+	}
+}
+
+func (s *Repeated) Attr(name string) (starlark.Value, error) {
+	switch name {
+
+	case "id":
+		return starlark.String(s.ID), nil
+
+	case "name":
+		return starlark.String(s.Name), nil
+
+	case "desc":
+		return starlark.String(s.Description), nil
+
+	case "icon":
+		return starlark.String(s.Icon), nil
+
+	case "fields":
+		return s.starlarkFields, nil
+
+	case "min":
+		if s.Min == nil {
+			return starlark.MakeInt(0), nil
+		}
+		return starlark.MakeInt64(int64(*s.Min)), nil
+
+	case "max":
+		if s.Max == nil {
+			return starlark.None, nil
+		}
+		return starlark.MakeInt64(int64(*s.Max)), nil
+
+	default:
+		return nil, nil
+	}
+}
+
+func (s *Repeated) String() string       { return "Repeated(...)" }
+func (s *Repeated) Type() string         { return "Repeated" }
+func (s *Repeated) Freeze()              {}
+func (s *Repeated) Truth() starlark.Bool { return true }
+
+func (s *Repeated) Hash() (uint32, error) {
+	sum, err := hashstructure.Hash(s, hashstructure.FormatV2, nil)
+	return uint32(sum), err
+}
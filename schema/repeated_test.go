@@ -0,0 +1,167 @@
+package schema_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"tidbyt.dev/pixlet/runtime"
+)
+
+var repeatedSource = `
+load("schema.star", "schema")
+
+def assert(success, message=None):
+    if not success:
+        fail(message or "assertion failed")
+
+stop_name = schema.Text(
+	id = "name",
+	name = "Name",
+	desc = "Name of the stop.",
+	icon = "signature",
+	default = "Home",
+)
+
+stop_id = schema.Text(
+	id = "stop_id",
+	name = "Stop ID",
+	desc = "ID of the stop.",
+	icon = "bus",
+)
+
+r = schema.Repeated(
+	id = "stops",
+	name = "Stops",
+	desc = "Stops to show departures for.",
+	icon = "bus",
+	fields = [stop_name, stop_id],
+	min = 1,
+	max = 4,
+)
+
+assert(r.id == "stops")
+assert(r.name == "Stops")
+assert(r.desc == "Stops to show departures for.")
+assert(r.icon == "bus")
+assert(r.fields == [stop_name, stop_id])
+assert(r.min == 1)
+assert(r.max == 4)
+
+def main():
+	return []
+`
+
+func TestRepeated(t *testing.T) {
+	app, err := runtime.NewApplet("repeated.star", []byte(repeatedSource))
+	assert.NoError(t, err)
+
+	screens, err := app.Run(context.Background())
+	assert.NoError(t, err)
+	assert.NotNil(t, screens)
+}
+
+func TestRepeatedBadArgs(t *testing.T) {
+	for _, args := range []string{
+		`fields = []`,
+		`fields = ["text"]`,
+		`fields = [text, text]`,
+		`fields = [typeahead]`,
+		`fields = [text], min = 3, max = 2`,
+		`fields = [text], max = -1`,
+	} {
+		src := `
+load("schema.star", "schema")
+
+text = schema.Text(id = "text", name = "Text", desc = "Text.", icon = "gear")
+
+def search(pattern):
+	return []
+
+typeahead = schema.Typeahead(id = "search", name = "Search", desc = "Search.", icon = "gear", handler = search)
+
+r = schema.Repeated(
+	id = "items",
+	name = "Items",
+	desc = "Some items.",
+	icon = "list",
+	` + args + `,
+)
+
+def main():
+	return []
+`
+		_, err := runtime.NewApplet("repeated.star", []byte(src))
+		assert.Error(t, err, args)
+	}
+}
+
+func TestRepeatedSchemaJSON(t *testing.T) {
+	code := `
+load("schema.star", "schema")
+
+def get_schema():
+    return schema.Schema(
+        version = "1",
+        fields = [
+            schema.Repeated(
+                id = "stops",
+                name = "Stops",
+                desc = "Stops to show departures for.",
+                icon = "bus",
+                min = 2,
+                fields = [
+                    schema.Text(
+                        id = "stop_id",
+                        name = "Stop ID",
+                        desc = "ID of the stop.",
+                        icon = "bus",
+                    ),
+                    schema.Toggle(
+                        id = "arrivals",
+                        name = "Arrivals",
+                        desc = "Show arrivals.",
+                        icon = "clock",
+                    ),
+                ],
+            ),
+        ],
+    )
+
+def main():
+    return None
+`
+
+	app, err := loadApp(code)
+	assert.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"version": "1",
+		"schema": [{
+			"type": "repeated",
+			"id": "stops",
+			"name": "Stops",
+			"description": "Stops to show departures for.",
+			"icon": "bus",
+			"min": 2,
+			"default": "[{\"arrivals\":\"false\"},{\"arrivals\":\"false\"}]",
+			"fields": [
+				{
+					"type": "text",
+					"id": "stop_id",
+					"name": "Stop ID",
+					"description": "ID of the stop.",
+					"icon": "bus"
+				},
+				{
+					"type": "onoff",
+					"id": "arrivals",
+					"name": "Arrivals",
+					"description": "Show arrivals.",
+					"icon": "clock",
+					"default": "false"
+				}
+			]
+		}]
+	}`, string(app.SchemaJSON))
+}
@@ -0,0 +1,61 @@
+package schema
+
+import (
+	"fmt"
+
+	"go.starlark.net/starlark"
+)
+
+// required is implemented by all fields through the embedded
+// SchemaField.
+type required interface {
+	setRequired(bool)
+}
+
+func (s *SchemaField) setRequired(r bool) {
+	s.Required = r
+}
+
+// withRequired adds the `required` keyword argument to a field
+// constructor. Required fields must have a non-empty value for the
+// config to be valid, unless they're hidden by visible_if.
+func withRequired(fn fieldConstructor) fieldConstructor {
+	return func(
+		thread *starlark.Thread,
+		b *starlark.Builtin,
+		args starlark.Tuple,
+		kwargs []starlark.Tuple,
+	) (starlark.Value, error) {
+		var req starlark.Bool
+
+		rest := make([]starlark.Tuple, 0, len(kwargs))
+		for _, kw := range kwargs {
+			if kw[0] != starlark.String("required") {
+				rest = append(rest, kw)
+				continue
+			}
+
+			r, ok := kw[1].(starlark.Bool)
+			if !ok {
+				return nil, fmt.Errorf(
+					"%s: required must be a bool, found %s",
+					b.Name(), kw[1].Type(),
+				)
+			}
+			req = r
+		}
+
+		val, err := fn(thread, b, args, rest)
+		if err != nil || !req {
+			return val, err
+		}
+
+		f, ok := val.(required)
+		if !ok {
+			return nil, fmt.Errorf("%s doesn't support required", b.Name())
+		}
+		f.setRequired(bool(req))
+
+		return val, nil
+	}
+}
@@ -0,0 +1,275 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/hashstructure/v2"
+	"go.starlark.net/starlark"
+)
+
+// Weekdays are the days a Schedule can pick, in the order they're
+// shown.
+var Weekdays = []string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"}
+
+// Schedule lets the user pick days of the week, and optionally a time
+// of day, during which the app is relevant. Like "Mon-Fri, 7-9am" for
+// a commute app.
+type Schedule struct {
+	SchemaField
+	starlarkDefaultDays   *starlark.List
+	starlarkDefaultWindow starlark.Value
+}
+
+// ScheduleValue is the value of a Schedule field, as provided in
+// config. Days are taken from Weekdays. Start and End are formatted as
+// HH:MM in the display's local time, and are either both set or both
+// empty. A window that ends before it starts wraps around midnight,
+// and belongs to the day it starts on.
+type ScheduleValue struct {
+	Days  []string `json:"days"`
+	Start string   `json:"start,omitempty"`
+	End   string   `json:"end,omitempty"`
+}
+
+func weekdayIndex(day string) int {
+	for i, d := range Weekdays {
+		if d == day {
+			return i
+		}
+	}
+	return -1
+}
+
+func (v *ScheduleValue) validate() error {
+	seen := map[string]bool{}
+	for _, day := range v.Days {
+		if weekdayIndex(day) < 0 {
+			return fmt.Errorf("unknown day %q, expected one of %s", day, strings.Join(Weekdays, ", "))
+		}
+		if seen[day] {
+			return fmt.Errorf("day %q is listed twice", day)
+		}
+		seen[day] = true
+	}
+
+	if v.Start == "" && v.End == "" {
+		return nil
+	}
+
+	start, err := parseClock("schedule start", v.Start)
+	if err != nil {
+		return err
+	}
+	end, err := parseClock("schedule end", v.End)
+	if err != nil {
+		return err
+	}
+	if start == end {
+		return fmt.Errorf("schedule can't start and end at the same time")
+	}
+
+	return nil
+}
+
+func (v *ScheduleValue) hasDay(wd time.Weekday) bool {
+	// Weekdays starts on Monday, time.Weekday on Sunday
+	day := Weekdays[(int(wd)+6)%7]
+	for _, d := range v.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains reports whether t falls within the schedule.
+func (v *ScheduleValue) Contains(t time.Time) bool {
+	if v.Start == "" {
+		return v.hasDay(t.Weekday())
+	}
+
+	start, err := parseClock("start", v.Start)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock("end", v.End)
+	if err != nil {
+		return false
+	}
+
+	now := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	if start < end {
+		return v.hasDay(t.Weekday()) && now >= start && now < end
+	}
+
+	// the window wraps around midnight, so the early hours belong to
+	// the previous day
+	if now >= start {
+		return v.hasDay(t.Weekday())
+	}
+	return now < end && v.hasDay(t.AddDate(0, 0, -1).Weekday())
+}
+
+// ParseSchedule parses and validates the config value of a Schedule
+// field.
+func ParseSchedule(value string) (*ScheduleValue, error) {
+	v := &ScheduleValue{}
+	if err := json.Unmarshal([]byte(value), v); err != nil {
+		return nil, fmt.Errorf("malformed schedule: %w", err)
+	}
+
+	if err := v.validate(); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+func newSchedule(
+	thread *starlark.Thread,
+	_ *starlark.Builtin,
+	args starlark.Tuple,
+	kwargs []starlark.Tuple,
+) (starlark.Value, error) {
+	var (
+		id            starlark.String
+		name          starlark.String
+		desc          starlark.String
+		icon          starlark.String
+		defaultDays   *starlark.List
+		defaultWindow starlark.Value = starlark.None
+		withWindow    starlark.Bool
+	)
+
+	if err := starlark.UnpackArgs(
+		"Schedule",
+		args, kwargs,
+		"id", &id,
+		"name", &name,
+		"desc", &desc,
+		"icon", &icon,
+		"default_days?", &defaultDays,
+		"default_window?", &defaultWindow,
+		"with_time_window?", &withWindow,
+	); err != nil {
+		return nil, fmt.Errorf("unpacking arguments for Schedule: %s", err)
+	}
+
+	s := &Schedule{}
+	s.SchemaField.Type = "schedule"
+	s.ID = id.GoString()
+	s.Name = name.GoString()
+	s.Description = desc.GoString()
+	s.Icon = icon.GoString()
+
+	for _, d := range Weekdays {
+		display := strings.ToUpper(d[:1]) + d[1:]
+		s.Options = append(s.Options, SchemaOption{
+			Display: display,
+			Text:    display,
+			Value:   d,
+		})
+	}
+
+	def := ScheduleValue{Days: append([]string(nil), Weekdays...)}
+	if defaultDays != nil {
+		days, err := stringList("default_days", defaultDays)
+		if err != nil {
+			return nil, err
+		}
+		def.Days = days
+		s.starlarkDefaultDays = defaultDays
+	} else {
+		days := make([]starlark.Value, len(Weekdays))
+		for i, d := range Weekdays {
+			days[i] = starlark.String(d)
+		}
+		s.starlarkDefaultDays = starlark.NewList(days)
+	}
+
+	if _, isNone := defaultWindow.(starlark.NoneType); !isNone {
+		window, ok := defaultWindow.(starlark.Indexable)
+		if !ok || window.Len() != 2 {
+			return nil, fmt.Errorf("default_window must be a pair of start and end times")
+		}
+
+		start, ok := window.Index(0).(starlark.String)
+		if !ok {
+			return nil, fmt.Errorf("default_window start must be a string, found %s", window.Index(0).Type())
+		}
+		end, ok := window.Index(1).(starlark.String)
+		if !ok {
+			return nil, fmt.Errorf("default_window end must be a string, found %s", window.Index(1).Type())
+		}
+
+		def.Start = start.GoString()
+		def.End = end.GoString()
+		withWindow = true
+	}
+	s.starlarkDefaultWindow = defaultWindow
+	s.WithTimeWindow = bool(withWindow)
+
+	if err := def.validate(); err != nil {
+		return nil, err
+	}
+
+	js, err := json.Marshal(def)
+	if err != nil {
+		return nil, err
+	}
+	s.Default = string(js)
+
+	return s, nil
+}
+
+func (s *Schedule) AsSchemaField() SchemaField {
+	return s.SchemaField
+}
+
+func (s *Schedule) AttrNames() []string {
+	return []string{
+		"id", "name", "desc", "icon", "default_days", "default_window", "with_time_window",
+	}
+}
+
+func (s *Schedule) Attr(name string) (starlark.Value, error) {
+	switch name {
+
+	case "id":
+		return starlark.String(s.ID), nil
+
+	case "name":
+		return starlark.String(s.Name), nil
+
+	case "desc":
+		return starlark.String(s.Description), nil
+
+	case "icon":
+		return starlark.String(s.Icon), nil
+
+	case "default_days":
+		return s.starlarkDefaultDays, nil
+
+	case "default_window":
+		return s.starlarkDefaultWindow, nil
+
+	case "with_time_window":
+		return starlark.Bool(s.WithTimeWindow), nil
+
+	default:
+		return nil, nil
+	}
+}
+
+func (s *Schedule) String() string       { return "Schedule(...)" }
+func (s *Schedule) Type() string         { return "Schedule" }
+func (s *Schedule) Freeze()              {}
+func (s *Schedule) Truth() starlark.Bool { return true }
+
+func (s *Schedule) Hash() (uint32, error) {
+	sum, err := hashstructure.Hash(s, hashstructure.FormatV2, nil)
+	return uint32(sum), err
+}
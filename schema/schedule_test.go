@@ -0,0 +1,160 @@
+package schema_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"tidbyt.dev/pixlet/runtime"
+	"tidbyt.dev/pixlet/schema"
+)
+
+var scheduleSource = `
+load("schema.star", "schema")
+
+def assert(success, message=None):
+    if not success:
+        fail(message or "assertion failed")
+
+s = schema.Schedule(
+	id = "commute",
+	name = "Commute",
+	desc = "When to show departures.",
+	icon = "clock",
+	default_days = ["mon", "tue", "wed", "thu", "fri"],
+	default_window = ("07:00", "09:00"),
+)
+
+assert(s.id == "commute")
+assert(s.name == "Commute")
+assert(s.desc == "When to show departures.")
+assert(s.icon == "clock")
+assert(s.default_days == ["mon", "tue", "wed", "thu", "fri"])
+assert(s.default_window == ("07:00", "09:00"))
+assert(s.with_time_window == True)
+
+d = schema.Schedule(
+	id = "days",
+	name = "Days",
+	desc = "Days to show.",
+	icon = "calendar",
+)
+
+assert(len(d.default_days) == 7)
+assert(d.default_window == None)
+assert(d.with_time_window == False)
+
+def main():
+	return []
+`
+
+func TestSchedule(t *testing.T) {
+	app, err := runtime.NewApplet("schedule.star", []byte(scheduleSource))
+	assert.NoError(t, err)
+
+	screens, err := app.Run(context.Background())
+	assert.NoError(t, err)
+	assert.NotNil(t, screens)
+}
+
+func TestScheduleBadArgs(t *testing.T) {
+	for _, args := range []string{
+		`default_days = ["monday"]`,
+		`default_days = ["mon", "mon"]`,
+		`default_days = [1]`,
+		`default_window = ("07:00",)`,
+		`default_window = ("7am", "9am")`,
+		`default_window = ("07:00", "07:00")`,
+		`default_window = (7, 9)`,
+	} {
+		src := fmt.Sprintf(`
+load("schema.star", "schema")
+
+s = schema.Schedule(
+	id = "schedule",
+	name = "Schedule",
+	desc = "A schedule.",
+	icon = "clock",
+	%s,
+)
+
+def main():
+	return []
+`, args)
+
+		_, err := runtime.NewApplet("schedule.star", []byte(src))
+		assert.Error(t, err, args)
+	}
+}
+
+func TestScheduleSchemaJSON(t *testing.T) {
+	app, err := loadApp(`
+load("schema.star", "schema")
+
+def get_schema():
+    return schema.Schema(
+        version = "1",
+        fields = [
+            schema.Schedule(
+                id = "commute",
+                name = "Commute",
+                desc = "When to show departures.",
+                icon = "clock",
+                default_days = ["mon", "fri"],
+                default_window = ("07:00", "09:00"),
+            ),
+        ],
+    )
+
+def main():
+    return []
+`)
+	require.NoError(t, err)
+
+	field := app.Schema.Fields[0]
+	assert.Equal(t, "schedule", field.Type)
+	assert.True(t, field.WithTimeWindow)
+	assert.Len(t, field.Options, 7)
+	assert.JSONEq(t, `{"days": ["mon", "fri"], "start": "07:00", "end": "09:00"}`, field.Default)
+}
+
+func TestParseSchedule(t *testing.T) {
+	v, err := schema.ParseSchedule(`{"days": ["mon", "tue", "wed", "thu", "fri"], "start": "07:00", "end": "09:00"}`)
+	require.NoError(t, err)
+
+	// 2024-01-01 is a Monday
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2024, 1, day, hour, min, 0, 0, time.UTC)
+	}
+	assert.True(t, v.Contains(at(1, 7, 0)))
+	assert.True(t, v.Contains(at(5, 8, 59)))
+	assert.False(t, v.Contains(at(1, 9, 0)))
+	assert.False(t, v.Contains(at(6, 8, 0)))
+
+	// windows that wrap around midnight belong to the day they start
+	night, err := schema.ParseSchedule(`{"days": ["fri"], "start": "22:00", "end": "02:00"}`)
+	require.NoError(t, err)
+	assert.True(t, night.Contains(at(5, 23, 0)))
+	assert.True(t, night.Contains(at(6, 1, 0)))
+	assert.False(t, night.Contains(at(5, 1, 0)))
+	assert.False(t, night.Contains(at(6, 23, 0)))
+
+	// without a window, the whole day counts
+	days, err := schema.ParseSchedule(`{"days": ["sun"]}`)
+	require.NoError(t, err)
+	assert.True(t, days.Contains(at(7, 12, 0)))
+	assert.False(t, days.Contains(at(1, 12, 0)))
+
+	for _, value := range []string{
+		``,
+		`{"days": ["someday"]}`,
+		`{"days": ["mon"], "start": "07:00"}`,
+		`{"days": ["mon"], "start": "25:00", "end": "09:00"}`,
+	} {
+		_, err := schema.ParseSchedule(value)
+		assert.Error(t, err, value)
+	}
+}
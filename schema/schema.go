@@ -3,7 +3,9 @@ package schema
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"reflect"
+	"strconv"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
@@ -15,45 +17,170 @@ const (
 	ReturnOptions
 	ReturnString
 	ReturnField
+	ReturnValidation
+	ReturnMigration
+	ReturnJSON
 )
 
 const (
 	// SchemaFunctionName is the name of the function in Starlark that we expect
 	// to be able to call to get the schema for an applet.
 	SchemaFunctionName = "get_schema"
+
+	// ConfigVersionKey is the config key under which hosts store the
+	// config version of the schema a config was saved with. Field IDs
+	// can't contain $, so it never clashes with a field.
+	ConfigVersionKey = "$config_version"
+)
+
+// Field types, as found in SchemaField.Type.
+const (
+	TypeColor                = "color"
+	TypeColorPalette         = "colorpalette"
+	TypeDateTime             = "datetime"
+	TypeDropdown             = "dropdown"
+	TypeDuration             = "duration"
+	TypeFile                 = "file"
+	TypeGenerated            = "generated"
+	TypeIcon                 = "icon"
+	TypeLocation             = "location"
+	TypeLocationBased        = "locationbased"
+	TypeMultiSelect          = "multiselect"
+	TypeNotification         = "notification"
+	TypeNotificationSettings = "notificationsettings"
+	TypeOAuth1               = "oauth1"
+	TypeOAuth2               = "oauth2"
+	TypeOnOff                = "onoff"
+	TypePhotoSelect          = "png"
+	TypeRadio                = "radio"
+	TypeRange                = "range"
+	TypeRepeated             = "repeated"
+	TypeSchedule             = "schedule"
+	TypeSecret               = "secret"
+	TypeSlider               = "slider"
+	TypeText                 = "text"
+	TypeTypeahead            = "typeahead"
+	TypeWebAuth              = "webauth"
 )
 
 // Schema holds a configuration object for an applet. It holds a list of fields
 // that are exported from an applet.
 type Schema struct {
-	Version       string         `json:"version" validate:"required"`
-	Fields        []SchemaField  `json:"schema" validate:"dive"`
-	Notifications []Notification `json:"notifications,omitempty" validate:"dive"`
-
+	Version       string          `json:"version" validate:"required"`
+	Fields        []SchemaField   `json:"schema" validate:"dive"`
+	Notifications []Notification  `json:"notifications,omitempty" validate:"dive"`
+	Sections      []SchemaSection `json:"sections,omitempty" validate:"dive"`
+
+	// Validator and Migrate are the names of the app's validator and
+	// migrate handlers, if it has them.
+	Validator     string `json:"validator,omitempty"`
+	ConfigVersion int    `json:"config_version,omitempty"`
+	Migrate       string `json:"migrate,omitempty"`
+
+	// Handlers holds every handler that can be called with
+	// Applet.CallSchemaHandler, keyed by name.
 	Handlers map[string]SchemaHandler `json:"-"`
 }
 
 // SchemaField represents an item in the config used to confgure an applet.
+// Only some of its fields apply to each field type, and the rest are
+// left empty.
 type SchemaField struct {
-	Type        string            `json:"type" validate:"required,oneof=color datetime dropdown generated location locationbased onoff radio text typeahead oauth2 oauth1 png notification"`
+	// Common to all fields. Type is one of the Type constants.
+	Type        string            `json:"type" validate:"required,oneof=color colorpalette datetime dropdown duration file generated icon location locationbased multiselect notificationsettings onoff radio range repeated schedule secret slider text typeahead webauth oauth2 oauth1 png notification"`
 	ID          string            `json:"id" validate:"required,excludesall=$"`
-	Name        string            `json:"name,omitempty" validate:"required_for=datetime dropdown location locationbased onoff radio text typeahead png"`
+	Name        string            `json:"name,omitempty" validate:"required_for=colorpalette datetime dropdown duration file icon location locationbased multiselect notificationsettings onoff radio range repeated schedule secret slider text typeahead webauth png"`
 	Description string            `json:"description,omitempty"`
 	Icon        string            `json:"icon,omitempty" validate:"forbidden_for=generated"`
 	Visibility  *SchemaVisibility `json:"visibility,omitempty" validate:"omitempty"`
-
-	Default string         `json:"default,omitempty" validate:"required_for=dropdown onoff radio"`
-	Options []SchemaOption `json:"options,omitempty" validate:"required_for=dropdown radio,dive"`
-	Palette []string       `json:"palette,omitempty"`
-	Sounds  []SchemaSound  `json:"sounds,omitempty" validate:"required_for=notification,dive"`
-
+	Required    bool              `json:"required,omitempty"`
+	Sensitive   bool              `json:"sensitive,omitempty"`
+	Section     string            `json:"section,omitempty"`
+
+	Translations map[string]SchemaTranslation `json:"translations,omitempty"`
+
+	// Default is the value the field starts out with, formatted
+	// just like the value stored in config.
+	Default string              `json:"default,omitempty" validate:"required_for=colorpalette dropdown onoff radio"`
+	Options []SchemaOption      `json:"options,omitempty" validate:"required_for=dropdown radio,dive"`
+	Palette []string            `json:"palette,omitempty"`
+	Presets []SchemaColorPreset `json:"presets,omitempty" validate:"dive"`
+	Sounds  []SchemaSound       `json:"sounds,omitempty" validate:"required_for=notification,dive"`
+
+	// Limits for slider, range, duration (in seconds), multiselect
+	// and repeated fields. Unit is only shown to the user.
+	Min  *float64 `json:"min,omitempty"`
+	Max  *float64 `json:"max,omitempty"`
+	Step *float64 `json:"step,omitempty"`
+	Unit string   `json:"unit,omitempty"`
+
+	WithTimezone   bool `json:"with_timezone,omitempty"`
+	WithTimeWindow bool `json:"with_time_window,omitempty"`
+
+	// Fields holds the fields of each item in a repeated field.
+	Fields []SchemaField `json:"fields,omitempty" validate:"required_for=repeated,dive"`
+
+	Accept  []string `json:"accept,omitempty"`
+	MaxSize int      `json:"max_size,omitempty" validate:"required_for=file"`
+
+	Icons []SchemaIcon `json:"icons,omitempty"`
+
+	// Typeahead hints. Debounce is in milliseconds, and CacheTTL in
+	// seconds.
+	MinChars int `json:"min_chars,omitempty"`
+	Debounce int `json:"debounce_ms,omitempty"`
+	CacheTTL int `json:"cache_ttl,omitempty"`
+
+	// Handler is the name to call the field's handler by, if it has
+	// one. See Schema.Handlers.
 	Source          string             `json:"source,omitempty" validate:"required_for=generated"`
-	Handler         string             `json:"handler,omitempty" validate:"required_for=generated locationbased typeahead oauth2"`
+	Sources         []string           `json:"sources,omitempty"`
+	Handler         string             `json:"handler,omitempty" validate:"required_for=generated locationbased typeahead oauth2 webauth"`
 	StarlarkHandler *starlark.Function `json:"-"`
 
 	ClientID              string   `json:"client_id,omitempty" validate:"required_for=oauth2"`
 	AuthorizationEndpoint string   `json:"authorization_endpoint,omitempty" validate:"required_for=oauth2"`
 	Scopes                []string `json:"scopes,omitempty" validate:"required_for=oauth2"`
+	OptionalScopes        []string `json:"optional_scopes,omitempty"`
+	PKCE                  bool     `json:"pkce,omitempty"`
+
+	RefreshHandler         string             `json:"refresh_handler,omitempty"`
+	StarlarkRefreshHandler *starlark.Function `json:"-"`
+
+	// URL is the page a webauth field sends the user to, with the
+	// host's callback URL added as RedirectParam.
+	URL           string `json:"url,omitempty" validate:"required_for=webauth"`
+	RedirectParam string `json:"redirect_param,omitempty"`
+}
+
+// Field returns the top level field with the given ID.
+func (s Schema) Field(id string) (SchemaField, bool) {
+	for _, f := range s.Fields {
+		if f.ID == id {
+			return f, true
+		}
+	}
+	return SchemaField{}, false
+}
+
+// FieldsOfType returns all top level fields of the given type.
+func (s Schema) FieldsOfType(fieldType string) []SchemaField {
+	var fields []SchemaField
+	for _, f := range s.Fields {
+		if f.Type == fieldType {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// FieldHandler returns the handler of the field, if it has one.
+func (s Schema) FieldHandler(field SchemaField) (SchemaHandler, bool) {
+	if field.Handler == "" {
+		return SchemaHandler{}, false
+	}
+	h, ok := s.Handlers[field.Handler]
+	return h, ok
 }
 
 // SchemaOption represents an option in a field. For example, an item in a drop
@@ -71,6 +198,22 @@ type SchemaSound struct {
 	Path  string `json:"path" validate:"required"`
 }
 
+// SchemaColorPreset is a named set of colors offered by a color
+// palette field.
+type SchemaColorPreset struct {
+	Name   string   `json:"name" validate:"required"`
+	Colors []string `json:"colors" validate:"required"`
+}
+
+// SchemaIcon describes an icon offered by an icon field, so that the
+// config UI can show a preview of it. Name is what's stored in config,
+// AppName is the name of the icon in the mobile app.
+type SchemaIcon struct {
+	Name    string `json:"name"`
+	Label   string `json:"label"`
+	AppName string `json:"app_name"`
+}
+
 // SchemaVisibility enables conditional fields inside of the mobile app. For
 // example, if a field should be invisible until a login is provided.
 type SchemaVisibility struct {
@@ -84,12 +227,36 @@ type SchemaVisibility struct {
 // get back from the schema function.
 type HandlerReturnType int8
 
+func (t HandlerReturnType) String() string {
+	switch t {
+	case ReturnSchema:
+		return "schema"
+	case ReturnOptions:
+		return "options"
+	case ReturnString:
+		return "string"
+	case ReturnField:
+		return "field"
+	case ReturnValidation:
+		return "validation"
+	case ReturnMigration:
+		return "migration"
+	case ReturnJSON:
+		return "json"
+	}
+	return fmt.Sprintf("HandlerReturnType(%d)", int(t))
+}
+
 // SchemaHandler defines a function and and return type for getting the schema
 // for an applet. This can both be the predefined schema function we expect all
 // applets to have for config, but can also be used as a callback for
 type SchemaHandler struct {
 	Function   *starlark.Function
 	ReturnType HandlerReturnType
+
+	// TakesConfig is set for handlers that are passed the full
+	// config, encoded as a JSON object, instead of a single value.
+	TakesConfig bool
 }
 
 func (s Schema) MarshalJSON() ([]byte, error) {
@@ -115,6 +282,35 @@ func (s Schema) MarshalJSON() ([]byte, error) {
 	return js, err
 }
 
+// Defaults returns a config holding the default value of every field
+// that has one. Running an app with it is the same as running it with a
+// freshly installed config. The config is stamped with the schema's
+// config version, if it has one.
+func (s Schema) Defaults() map[string]string {
+	config := map[string]string{}
+
+	for _, field := range s.Fields {
+		if def := field.defaultValue(); def != "" {
+			config[field.ID] = def
+		}
+	}
+
+	if s.ConfigVersion > 1 {
+		config[ConfigVersionKey] = strconv.Itoa(s.ConfigVersion)
+	}
+
+	return config
+}
+
+// defaultValue returns the field's default, in the form its config
+// value takes.
+func (f SchemaField) defaultValue() string {
+	if f.Type == TypeDateTime && f.WithTimezone && f.Default != "" {
+		return dateTimeDefault(f.Default)
+	}
+	return f.Default
+}
+
 // FromStarlark creates a new Schema from a Starlark schema object.
 func FromStarlark(
 	val starlark.Value,
@@ -192,10 +388,14 @@ func FromStarlark(
 				handlerType = ReturnSchema
 			case "typeahead":
 				handlerType = ReturnOptions
+			case "multiselect":
+				handlerType = ReturnOptions
 			case "oauth2":
 				handlerType = ReturnString
 			case "oauth1":
 				handlerType = ReturnString
+			case "webauth":
+				handlerType = ReturnString
 			default:
 				return nil, fmt.Errorf(
 					"field %d of type \"%s\" can't have a handler function",
@@ -204,7 +404,25 @@ func FromStarlark(
 
 			// prepend the field ID to the handler name to avoid conflicts
 			schemaField.Handler = fmt.Sprintf("%s$%s", schemaField.ID, schemaField.Handler)
-			schema.Handlers[schemaField.Handler] = SchemaHandler{Function: handlerFun, ReturnType: handlerType}
+			schema.Handlers[schemaField.Handler] = SchemaHandler{
+				Function:    handlerFun,
+				ReturnType:  handlerType,
+				TakesConfig: len(schemaField.Sources) > 0,
+			}
+		}
+
+		if schemaField.StarlarkRefreshHandler != nil {
+			if schemaField.Type != "oauth2" {
+				return nil, fmt.Errorf(
+					"field %d of type \"%s\" can't have a refresh handler",
+					i, schemaField.Type)
+			}
+
+			schemaField.RefreshHandler = fmt.Sprintf("%s$%s", schemaField.ID, schemaField.RefreshHandler)
+			schema.Handlers[schemaField.RefreshHandler] = SchemaHandler{
+				Function:   schemaField.StarlarkRefreshHandler,
+				ReturnType: ReturnString,
+			}
 		}
 	}
 
@@ -245,6 +463,89 @@ func EncodeOptions(
 	return string(optionsJson), nil
 }
 
+// Encodes the result of a validator handler, a dict of field IDs to
+// error messages, into json.
+func EncodeValidation(
+	starlarkErrors starlark.Value,
+) (string, error) {
+	errors := map[string]string{}
+
+	if starlarkErrors != starlark.None {
+		dict, ok := starlarkErrors.(*starlark.Dict)
+		if !ok {
+			return "", fmt.Errorf(
+				"expected validator to return a dict, found %s",
+				starlarkErrors.Type(),
+			)
+		}
+
+		for _, item := range dict.Items() {
+			id, ok := item[0].(starlark.String)
+			if !ok {
+				return "", fmt.Errorf("validation errors must be keyed by field ID")
+			}
+
+			msg, ok := item[1].(starlark.String)
+			if !ok {
+				return "", fmt.Errorf(
+					"validation error for %s must be a string, found %s",
+					id.GoString(), item[1].Type(),
+				)
+			}
+
+			if msg != "" {
+				errors[id.GoString()] = msg.GoString()
+			}
+		}
+	}
+
+	errorsJson, err := json.Marshal(errors)
+	if err != nil {
+		return "", err
+	}
+
+	return string(errorsJson), nil
+}
+
+// EncodeMigration encodes the config returned by a migrate handler as
+// a JSON object.
+func EncodeMigration(
+	starlarkConfig starlark.Value,
+) (string, error) {
+	dict, ok := starlarkConfig.(*starlark.Dict)
+	if !ok {
+		return "", fmt.Errorf(
+			"expected migrate to return a dict, found %s",
+			starlarkConfig.Type(),
+		)
+	}
+
+	config := map[string]string{}
+	for _, item := range dict.Items() {
+		id, ok := item[0].(starlark.String)
+		if !ok {
+			return "", fmt.Errorf("migrated config must be keyed by field ID")
+		}
+
+		value, ok := item[1].(starlark.String)
+		if !ok {
+			return "", fmt.Errorf(
+				"migrated value for %s must be a string, found %s",
+				id.GoString(), item[1].Type(),
+			)
+		}
+
+		config[id.GoString()] = value.GoString()
+	}
+
+	configJson, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+
+	return string(configJson), nil
+}
+
 // Transforms a starlark value into Go objects. The value must be a
 // list, dict or string. Or a tree of these.
 func unmarshalStarlark(object starlark.Value) (interface{}, error) {
@@ -297,6 +598,81 @@ func unmarshalStarlark(object starlark.Value) (interface{}, error) {
 	return nil, fmt.Errorf("type %s not allowed in schema", object.Type())
 }
 
+// EncodeJSON encodes whatever a JSON handler returned. It can be any
+// combination of None, bools, numbers, strings, lists and dicts with
+// string keys.
+func EncodeJSON(
+	starlarkValue starlark.Value,
+) (string, error) {
+	value, err := jsonValue(starlarkValue)
+	if err != nil {
+		return "", err
+	}
+
+	js, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	return string(js), nil
+}
+
+func jsonValue(object starlark.Value) (interface{}, error) {
+	switch v := object.(type) {
+	case starlark.NoneType:
+		return nil, nil
+
+	case starlark.Bool:
+		return bool(v), nil
+
+	case starlark.Int:
+		if i, ok := v.Int64(); ok {
+			return i, nil
+		}
+		return json.Number(v.String()), nil
+
+	case starlark.Float:
+		f := float64(v)
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("can't encode %s as JSON", v.String())
+		}
+		return f, nil
+
+	case starlark.String:
+		return v.GoString(), nil
+
+	case *starlark.List, starlark.Tuple:
+		seq := v.(starlark.Indexable)
+		list := make([]interface{}, seq.Len())
+		for i := 0; i < seq.Len(); i++ {
+			val, err := jsonValue(seq.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			list[i] = val
+		}
+		return list, nil
+
+	case *starlark.Dict:
+		m := make(map[string]interface{}, v.Len())
+		for _, item := range v.Items() {
+			key, ok := item[0].(starlark.String)
+			if !ok {
+				return nil, fmt.Errorf("dict keys must be string, found %s", item[0].Type())
+			}
+
+			val, err := jsonValue(item[1])
+			if err != nil {
+				return nil, err
+			}
+			m[key.GoString()] = val
+		}
+		return m, nil
+	}
+
+	return nil, fmt.Errorf("can't encode %s as JSON", object.Type())
+}
+
 // Helper. Verifies that object is string or nil and writes it to *p
 // if string.
 func setString(p *string, object interface{}) string {
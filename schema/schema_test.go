@@ -1215,3 +1215,134 @@ def main():
 	assert.Equal(t, "L08", options[0].Value)
 	assert.Equal(t, "3rd", options[1].Value)
 }
+
+func TestSchemaDefaults(t *testing.T) {
+	app, err := loadApp(`
+load("schema.star", "schema")
+
+def get_schema():
+    return schema.Schema(
+        version = "1",
+        config_version = 2,
+        migrate = lambda config, from_version: config,
+        fields = [
+            schema.Toggle(
+                id = "party_mode",
+                name = "Party Mode",
+                desc = "Enable party mode.",
+                icon = "gear",
+                default = True,
+            ),
+            schema.Text(
+                id = "msg",
+                name = "Message",
+                desc = "A message.",
+                icon = "gear",
+            ),
+            schema.Slider(
+                id = "speed",
+                name = "Speed",
+                desc = "Scroll speed.",
+                icon = "gauge",
+                min = 1,
+                max = 10,
+            ),
+            schema.DateTime(
+                id = "event",
+                name = "Event",
+                desc = "When the event is.",
+                icon = "clock",
+                default = "2023-12-31T23:59:00-05:00",
+            ),
+            schema.DateTime(
+                id = "countdown",
+                name = "Countdown",
+                desc = "The time to count down to.",
+                icon = "clock",
+                default = "2023-12-31T23:59:00-05:00",
+                with_timezone = True,
+            ),
+        ],
+    )
+
+def main(config):
+    return []
+`)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"party_mode":      "true",
+		"speed":           "1",
+		"event":           "2023-12-31T23:59:00-05:00",
+		"countdown":       `{"timestamp":"2024-01-01T04:59:00Z","timezone":"UTC"}`,
+		"$config_version": "2",
+	}, app.Schema.Defaults())
+}
+
+func TestSchemaIntrospection(t *testing.T) {
+	app, err := loadApp(`
+load("schema.star", "schema")
+
+def search(pattern):
+    return []
+
+def get_schema():
+    return schema.Schema(
+        version = "1",
+        fields = [
+            schema.Dropdown(
+                id = "units",
+                name = "Units",
+                desc = "Units to use.",
+                icon = "ruler",
+                default = "metric",
+                options = [
+                    schema.Option(display = "Metric", value = "metric"),
+                    schema.Option(display = "Imperial", value = "imperial"),
+                ],
+            ),
+            schema.Typeahead(
+                id = "station",
+                name = "Station",
+                desc = "Station to watch.",
+                icon = "train",
+                handler = search,
+            ),
+            schema.Typeahead(
+                id = "line",
+                name = "Line",
+                desc = "Line to watch.",
+                icon = "train",
+                handler = search,
+            ),
+        ],
+    )
+
+def main(config):
+    return []
+`)
+	require.NoError(t, err)
+
+	units, ok := app.Schema.Field("units")
+	require.True(t, ok)
+	assert.Equal(t, schema.TypeDropdown, units.Type)
+	assert.Equal(t, "metric", units.Default)
+	assert.Equal(t, "imperial", units.Options[1].Value)
+
+	_, ok = app.Schema.FieldHandler(units)
+	assert.False(t, ok)
+
+	_, ok = app.Schema.Field("nope")
+	assert.False(t, ok)
+
+	typeaheads := app.Schema.FieldsOfType(schema.TypeTypeahead)
+	require.Len(t, typeaheads, 2)
+	assert.Equal(t, "station", typeaheads[0].ID)
+	assert.Equal(t, "line", typeaheads[1].ID)
+
+	handler, ok := app.Schema.FieldHandler(typeaheads[1])
+	require.True(t, ok)
+	assert.Equal(t, schema.ReturnOptions, handler.ReturnType)
+	assert.Equal(t, "options", handler.ReturnType.String())
+	assert.Equal(t, "search", handler.Function.Name())
+}
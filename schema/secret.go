@@ -0,0 +1,90 @@
+package schema
+
+import (
+	"fmt"
+
+	"github.com/mitchellh/hashstructure/v2"
+	"go.starlark.net/starlark"
+)
+
+// Secret holds a sensitive value, like an API key. It's flagged as
+// sensitive in the schema so that hosts store it encrypted and never
+// show it to the user again once it's been entered.
+type Secret struct {
+	SchemaField
+}
+
+func newSecret(
+	thread *starlark.Thread,
+	_ *starlark.Builtin,
+	args starlark.Tuple,
+	kwargs []starlark.Tuple,
+) (starlark.Value, error) {
+	var (
+		id   starlark.String
+		name starlark.String
+		desc starlark.String
+		icon starlark.String
+	)
+
+	if err := starlark.UnpackArgs(
+		"Secret",
+		args, kwargs,
+		"id", &id,
+		"name", &name,
+		"desc", &desc,
+		"icon", &icon,
+	); err != nil {
+		return nil, fmt.Errorf("unpacking arguments for Secret: %s", err)
+	}
+
+	s := &Secret{}
+	s.SchemaField.Type = "secret"
+	s.ID = id.GoString()
+	s.Name = name.GoString()
+	s.Description = desc.GoString()
+	s.Icon = icon.GoString()
+	s.Sensitive = true
+
+	return s, nil
+}
+
+func (s *Secret) AsSchemaField() SchemaField {
+	return s.SchemaField
+}
+
+func (s *Secret) AttrNames() []string {
+	return []string{
+		"id", "name", "desc", "icon",
+	}
+}
+
+func (s *Secret) Attr(name string) (starlark.Value, error) {
+	switch name {
+
+	case "id":
+		return starlark.String(s.ID), nil
+
+	case "name":
+		return starlark.String(s.Name), nil
+
+	case "desc":
+		return starlark.String(s.Description), nil
+
+	case "icon":
+		return starlark.String(s.Icon), nil
+
+	default:
+		return nil, nil
+	}
+}
+
+func (s *Secret) String() string       { return "Secret(...)" }
+func (s *Secret) Type() string         { return "Secret" }
+func (s *Secret) Freeze()              {}
+func (s *Secret) Truth() starlark.Bool { return true }
+
+func (s *Secret) Hash() (uint32, error) {
+	sum, err := hashstructure.Hash(s, hashstructure.FormatV2, nil)
+	return uint32(sum), err
+}
@@ -0,0 +1,98 @@
+package schema_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"tidbyt.dev/pixlet/runtime"
+)
+
+var secretSource = `
+load("schema.star", "schema")
+
+def assert(success, message=None):
+    if not success:
+        fail(message or "assertion failed")
+
+s = schema.Secret(
+	id = "api_key",
+	name = "API Key",
+	desc = "Your API key.",
+	icon = "key",
+)
+
+assert(s.id == "api_key")
+assert(s.name == "API Key")
+assert(s.desc == "Your API key.")
+assert(s.icon == "key")
+
+def main():
+	return []
+`
+
+func TestSecret(t *testing.T) {
+	app, err := runtime.NewApplet("secret.star", []byte(secretSource))
+	assert.NoError(t, err)
+
+	screens, err := app.Run(context.Background())
+	assert.NoError(t, err)
+	assert.NotNil(t, screens)
+}
+
+func TestSecretNoDefault(t *testing.T) {
+	src := `
+load("schema.star", "schema")
+
+s = schema.Secret(
+	id = "api_key",
+	name = "API Key",
+	desc = "Your API key.",
+	icon = "key",
+	default = "hunter2",
+)
+
+def main():
+	return []
+`
+	_, err := runtime.NewApplet("secret.star", []byte(src))
+	assert.Error(t, err)
+}
+
+func TestSecretSchemaJSON(t *testing.T) {
+	app, err := loadApp(`
+load("schema.star", "schema")
+
+def get_schema():
+    return schema.Schema(
+        version = "1",
+        fields = [
+            schema.Secret(
+                id = "api_key",
+                name = "API Key",
+                desc = "Your API key.",
+                icon = "key",
+            ),
+        ],
+    )
+
+def main():
+    return []
+`)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"version": "1",
+		"schema": [
+			{
+				"type": "secret",
+				"id": "api_key",
+				"name": "API Key",
+				"description": "Your API key.",
+				"icon": "key",
+				"sensitive": true
+			}
+		]
+	}`, string(app.SchemaJSON))
+}
@@ -0,0 +1,139 @@
+package schema
+
+import (
+	"fmt"
+
+	"github.com/mitchellh/hashstructure/v2"
+	"go.starlark.net/starlark"
+)
+
+// SchemaSection groups related fields under a heading. The fields
+// themselves stay in Schema.Fields, in order, and refer to their
+// section by ID, so hosts that don't support sections show them as a
+// flat list.
+type SchemaSection struct {
+	ID          string `json:"id" validate:"required,excludesall=$"`
+	Name        string `json:"name" validate:"required"`
+	Description string `json:"description,omitempty"`
+	Icon        string `json:"icon,omitempty"`
+	Collapsed   bool   `json:"collapsed,omitempty"`
+
+	Translations map[string]SchemaTranslation `json:"translations,omitempty"`
+}
+
+// Section is used in the list of schema fields, and holds fields of its
+// own.
+type Section struct {
+	SchemaSection
+	Fields         []SchemaField
+	starlarkFields *starlark.List
+}
+
+func (s *Section) setTranslations(t map[string]SchemaTranslation) {
+	s.Translations = t
+}
+
+func newSection(
+	thread *starlark.Thread,
+	_ *starlark.Builtin,
+	args starlark.Tuple,
+	kwargs []starlark.Tuple,
+) (starlark.Value, error) {
+	var (
+		id        starlark.String
+		name      starlark.String
+		desc      starlark.String
+		icon      starlark.String
+		fields    *starlark.List
+		collapsed starlark.Bool
+	)
+
+	if err := starlark.UnpackArgs(
+		"Section",
+		args, kwargs,
+		"id", &id,
+		"name", &name,
+		"fields", &fields,
+		"desc?", &desc,
+		"icon?", &icon,
+		"collapsed?", &collapsed,
+	); err != nil {
+		return nil, fmt.Errorf("unpacking arguments for Section: %s", err)
+	}
+
+	s := &Section{}
+	s.ID = id.GoString()
+	s.Name = name.GoString()
+	s.Description = desc.GoString()
+	s.Icon = icon.GoString()
+	s.Collapsed = bool(collapsed)
+
+	for i := 0; i < fields.Len(); i++ {
+		val := fields.Index(i)
+		if _, isNone := val.(starlark.NoneType); isNone {
+			continue
+		}
+
+		if _, ok := val.(*Section); ok {
+			return nil, fmt.Errorf("sections can't be nested (at index %d)", i)
+		}
+
+		f, ok := val.(Field)
+		if !ok || f.AsSchemaField().Type == "notification" {
+			return nil, fmt.Errorf(
+				"expected fields to be a list of Field but found: %s (at index %d)",
+				val.Type(),
+				i,
+			)
+		}
+
+		field := f.AsSchemaField()
+		field.Section = s.ID
+		s.Fields = append(s.Fields, field)
+	}
+	s.starlarkFields = fields
+
+	return s, nil
+}
+
+func (s *Section) AttrNames() []string {
+	return []string{
+		"id", "name", "desc", "icon", "fields", "collapsed",
+	}
+}
+
+func (s *Section) Attr(name string) (starlark.Value, error) {
+	switch name {
+
+	case "id":
+		return starlark.String(s.ID), nil
+
+	case "name":
+		return starlark.String(s.Name), nil
+
+	case "desc":
+		return starlark.String(s.Description), nil
+
+	case "icon":
+		return starlark.String(s.Icon), nil
+
+	case "fields":
+		return s.starlarkFields, nil
+
+	case "collapsed":
+		return starlark.Bool(s.Collapsed), nil
+
+	default:
+		return nil, nil
+	}
+}
+
+func (s *Section) String() string       { return "Section(...)" }
+func (s *Section) Type() string         { return "Section" }
+func (s *Section) Freeze()              {}
+func (s *Section) Truth() starlark.Bool { return true }
+
+func (s *Section) Hash() (uint32, error) {
+	sum, err := hashstructure.Hash(s, hashstructure.FormatV2, nil)
+	return uint32(sum), err
+}
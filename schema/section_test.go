@@ -0,0 +1,136 @@
+package schema_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var sectionSource = `
+load("schema.star", "schema")
+
+def get_schema():
+    return schema.Schema(
+        version = "1",
+        fields = [
+            schema.Text(
+                id = "title",
+                name = "Title",
+                desc = "Shown at the top.",
+                icon = "heading",
+            ),
+            schema.Section(
+                id = "appearance",
+                name = "Appearance",
+                desc = "How the app looks.",
+                icon = "palette",
+                collapsed = True,
+                translations = {
+                    "de": {"name": "Aussehen"},
+                },
+                fields = [
+                    schema.Color(
+                        id = "color",
+                        name = "Color",
+                        desc = "Text color.",
+                        icon = "brush",
+                        default = "#ffffff",
+                    ),
+                    schema.Toggle(
+                        id = "border",
+                        name = "Border",
+                        desc = "Draw a border.",
+                        icon = "square",
+                    ),
+                ],
+            ),
+        ],
+    )
+
+def main(config):
+    return []
+`
+
+func TestSection(t *testing.T) {
+	app, err := loadApp(sectionSource)
+	require.NoError(t, err)
+
+	s := app.Schema
+	require.Len(t, s.Fields, 3)
+	assert.Equal(t, "", s.Fields[0].Section)
+	assert.Equal(t, "appearance", s.Fields[1].Section)
+	assert.Equal(t, "appearance", s.Fields[2].Section)
+
+	require.Len(t, s.Sections, 1)
+	assert.Equal(t, "Appearance", s.Sections[0].Name)
+	assert.Equal(t, "How the app looks.", s.Sections[0].Description)
+	assert.Equal(t, "palette", s.Sections[0].Icon)
+	assert.True(t, s.Sections[0].Collapsed)
+
+	localized, err := s.Localized("de")
+	require.NoError(t, err)
+	assert.Equal(t, "Aussehen", localized.Sections[0].Name)
+	assert.Nil(t, localized.Sections[0].Translations)
+	assert.Equal(t, "Appearance", s.Sections[0].Name)
+}
+
+func TestSectionJSON(t *testing.T) {
+	app, err := loadApp(sectionSource)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"version": "1",
+		"schema": [
+			{"type": "text", "id": "title", "name": "Title", "description": "Shown at the top.", "icon": "heading"},
+			{"type": "color", "id": "color", "name": "Color", "description": "Text color.", "icon": "brush", "default": "#ffffff", "section": "appearance"},
+			{"type": "onoff", "id": "border", "name": "Border", "description": "Draw a border.", "icon": "square", "default": "false", "section": "appearance"}
+		],
+		"sections": [
+			{
+				"id": "appearance",
+				"name": "Appearance",
+				"description": "How the app looks.",
+				"icon": "palette",
+				"collapsed": true,
+				"translations": {"de": {"name": "Aussehen"}}
+			}
+		]
+	}`, string(app.SchemaJSON))
+}
+
+func TestSectionBadArgs(t *testing.T) {
+	for _, fields := range []string{
+		// fields must be given
+		`schema.Section(id = "a", name = "A")`,
+
+		// sections can't be nested
+		`schema.Section(id = "a", name = "A", fields = [
+            schema.Section(id = "b", name = "B", fields = []),
+        ])`,
+
+		// only fields go in sections
+		`schema.Section(id = "a", name = "A", fields = ["text"])`,
+
+		// section ids are unique
+		`schema.Section(id = "a", name = "A", fields = []),
+        schema.Section(id = "a", name = "B", fields = [])`,
+	} {
+		_, err := loadApp(fmt.Sprintf(`
+load("schema.star", "schema")
+
+def get_schema():
+    return schema.Schema(
+        version = "1",
+        fields = [
+        %s,
+        ],
+    )
+
+def main(config):
+    return []
+`, fields))
+		assert.Error(t, err, fields)
+	}
+}
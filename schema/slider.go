@@ -0,0 +1,161 @@
+package schema
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/mitchellh/hashstructure/v2"
+	"go.starlark.net/starlark"
+)
+
+type Slider struct {
+	SchemaField
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func asNumber(name string, v starlark.Value) (float64, error) {
+	f, ok := starlark.AsFloat(v)
+	if !ok {
+		return 0, fmt.Errorf("%s must be a number, found %s", name, v.Type())
+	}
+	return f, nil
+}
+
+func newSlider(
+	thread *starlark.Thread,
+	_ *starlark.Builtin,
+	args starlark.Tuple,
+	kwargs []starlark.Tuple,
+) (starlark.Value, error) {
+	var (
+		id   starlark.String
+		name starlark.String
+		desc starlark.String
+		icon starlark.String
+		min  starlark.Value
+		max  starlark.Value
+		step starlark.Value = starlark.MakeInt(1)
+		def  starlark.Value
+		unit starlark.String
+	)
+
+	if err := starlark.UnpackArgs(
+		"Slider",
+		args, kwargs,
+		"id", &id,
+		"name", &name,
+		"desc", &desc,
+		"icon", &icon,
+		"min", &min,
+		"max", &max,
+		"step?", &step,
+		"default?", &def,
+		"unit?", &unit,
+	); err != nil {
+		return nil, fmt.Errorf("unpacking arguments for Slider: %s", err)
+	}
+
+	minVal, err := asNumber("min", min)
+	if err != nil {
+		return nil, err
+	}
+	maxVal, err := asNumber("max", max)
+	if err != nil {
+		return nil, err
+	}
+	stepVal, err := asNumber("step", step)
+	if err != nil {
+		return nil, err
+	}
+
+	if minVal >= maxVal {
+		return nil, fmt.Errorf("min (%s) must be less than max (%s)", formatNumber(minVal), formatNumber(maxVal))
+	}
+	if stepVal <= 0 || stepVal > maxVal-minVal {
+		return nil, fmt.Errorf("step must be positive and no larger than max - min, found %s", formatNumber(stepVal))
+	}
+
+	defVal := minVal
+	if def != nil {
+		defVal, err = asNumber("default", def)
+		if err != nil {
+			return nil, err
+		}
+		if defVal < minVal || defVal > maxVal {
+			return nil, fmt.Errorf("default (%s) must be between min and max", formatNumber(defVal))
+		}
+	}
+
+	s := &Slider{}
+	s.SchemaField.Type = "slider"
+	s.ID = id.GoString()
+	s.Name = name.GoString()
+	s.Description = desc.GoString()
+	s.Icon = icon.GoString()
+	s.Default = formatNumber(defVal)
+	s.Min = &minVal
+	s.Max = &maxVal
+	s.Step = &stepVal
+	s.Unit = unit.GoString()
+
+	return s, nil
+}
+
+func (s *Slider) AsSchemaField() SchemaField {
+	return s.SchemaField
+}
+
+func (s *Slider) AttrNames() []string {
+	return []string{
+		"id", "name", "desc", "icon", "min", "max", "step", "default", "unit",
+	}
+}
+
+func (s *Slider) Attr(name string) (starlark.Value, error) {
+	switch name {
+
+	case "id":
+		return starlark.String(s.ID), nil
+
+	case "name":
+		return starlark.String(s.Name), nil
+
+	case "desc":
+		return starlark.String(s.Description), nil
+
+	case "icon":
+		return starlark.String(s.Icon), nil
+
+	case "min":
+		return starlark.Float(*s.Min), nil
+
+	case "max":
+		return starlark.Float(*s.Max), nil
+
+	case "step":
+		return starlark.Float(*s.Step), nil
+
+	case "default":
+		def, _ := strconv.ParseFloat(s.Default, 64)
+		return starlark.Float(def), nil
+
+	case "unit":
+		return starlark.String(s.Unit), nil
+
+	default:
+		return nil, nil
+	}
+}
+
+func (s *Slider) String() string       { return "Slider(...)" }
+func (s *Slider) Type() string         { return "Slider" }
+func (s *Slider) Freeze()              {}
+func (s *Slider) Truth() starlark.Bool { return true }
+
+func (s *Slider) Hash() (uint32, error) {
+	sum, err := hashstructure.Hash(s, hashstructure.FormatV2, nil)
+	return uint32(sum), err
+}
@@ -0,0 +1,137 @@
+package schema_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"tidbyt.dev/pixlet/runtime"
+)
+
+var sliderSource = `
+load("schema.star", "schema")
+
+def assert(success, message=None):
+    if not success:
+        fail(message or "assertion failed")
+
+s = schema.Slider(
+	id = "brightness",
+	name = "Brightness",
+	desc = "How bright the display should be.",
+	icon = "sun",
+	min = 0,
+	max = 100,
+	step = 5,
+	default = 80,
+	unit = "%",
+)
+
+assert(s.id == "brightness")
+assert(s.name == "Brightness")
+assert(s.desc == "How bright the display should be.")
+assert(s.icon == "sun")
+assert(s.min == 0)
+assert(s.max == 100)
+assert(s.step == 5)
+assert(s.default == 80)
+assert(type(s.default) == "float")
+assert(s.unit == "%")
+
+f = schema.Slider(
+	id = "threshold",
+	name = "Threshold",
+	desc = "Temperature threshold.",
+	icon = "temperatureHalf",
+	min = -10.5,
+	max = 40,
+	step = 0.5,
+)
+
+assert(f.default == -10.5)
+assert(f.unit == "")
+
+def main():
+	return []
+`
+
+func TestSlider(t *testing.T) {
+	app, err := runtime.NewApplet("slider.star", []byte(sliderSource))
+	assert.NoError(t, err)
+
+	screens, err := app.Run(context.Background())
+	assert.NoError(t, err)
+	assert.NotNil(t, screens)
+}
+
+func TestSliderBadRange(t *testing.T) {
+	for _, args := range []string{
+		`min = 10, max = 0`,
+		`min = 0, max = 10, step = 0`,
+		`min = 0, max = 10, step = 20`,
+		`min = 0, max = 10, default = 11`,
+		`min = "0", max = 10`,
+	} {
+		src := `
+load("schema.star", "schema")
+
+s = schema.Slider(
+	id = "slider",
+	name = "Slider",
+	desc = "A slider.",
+	icon = "gear",
+	` + args + `,
+)
+
+def main():
+	return []
+`
+		_, err := runtime.NewApplet("slider.star", []byte(src))
+		assert.Error(t, err, args)
+	}
+}
+
+func TestSliderSchemaJSON(t *testing.T) {
+	code := `
+load("schema.star", "schema")
+
+def get_schema():
+    return schema.Schema(
+        version = "1",
+        fields = [
+            schema.Slider(
+                id = "interval",
+                name = "Refresh interval",
+                desc = "How often to refresh.",
+                icon = "clock",
+                min = 1,
+                max = 60,
+                default = 15,
+                unit = "min",
+            ),
+        ],
+    )
+
+def main():
+    return None
+`
+
+	app, err := loadApp(code)
+	assert.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"version": "1",
+		"schema": [{
+			"type": "slider",
+			"id": "interval",
+			"name": "Refresh interval",
+			"description": "How often to refresh.",
+			"icon": "clock",
+			"default": "15",
+			"min": 1,
+			"max": 60,
+			"step": 1,
+			"unit": "min"
+		}]
+	}`, string(app.SchemaJSON))
+}
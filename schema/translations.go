@@ -0,0 +1,228 @@
+package schema
+
+import (
+	"fmt"
+
+	"go.starlark.net/starlark"
+	"golang.org/x/text/language"
+)
+
+// SchemaTranslation holds the name and description of a field in
+// another language.
+type SchemaTranslation struct {
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// translatable is implemented by all fields through the embedded
+// SchemaField, and by sections.
+type translatable interface {
+	setTranslations(map[string]SchemaTranslation)
+}
+
+func (s *SchemaField) setTranslations(t map[string]SchemaTranslation) {
+	s.Translations = t
+}
+
+// withTranslations adds the `translations` keyword argument to a field
+// constructor. It takes a dict of locales to dicts with a `name` and
+// `desc`, which replace the field's own when the schema is localized.
+func withTranslations(fn fieldConstructor) fieldConstructor {
+	return func(
+		thread *starlark.Thread,
+		b *starlark.Builtin,
+		args starlark.Tuple,
+		kwargs []starlark.Tuple,
+	) (starlark.Value, error) {
+		var translations *starlark.Dict
+
+		rest := make([]starlark.Tuple, 0, len(kwargs))
+		for _, kw := range kwargs {
+			if kw[0] != starlark.String("translations") {
+				rest = append(rest, kw)
+				continue
+			}
+
+			if _, isNone := kw[1].(starlark.NoneType); isNone {
+				continue
+			}
+
+			d, ok := kw[1].(*starlark.Dict)
+			if !ok {
+				return nil, fmt.Errorf(
+					"%s: translations must be a dict, found %s",
+					b.Name(), kw[1].Type(),
+				)
+			}
+			translations = d
+		}
+
+		val, err := fn(thread, b, args, rest)
+		if err != nil || translations == nil {
+			return val, err
+		}
+
+		t, err := parseTranslations(translations)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", b.Name(), err)
+		}
+
+		f, ok := val.(translatable)
+		if !ok {
+			return nil, fmt.Errorf("%s doesn't support translations", b.Name())
+		}
+		f.setTranslations(t)
+
+		return val, nil
+	}
+}
+
+func parseTranslations(d *starlark.Dict) (map[string]SchemaTranslation, error) {
+	translations := make(map[string]SchemaTranslation, d.Len())
+
+	for _, item := range d.Items() {
+		locale, ok := item[0].(starlark.String)
+		if !ok {
+			return nil, fmt.Errorf("translations must be keyed by locale, found %s", item[0].Type())
+		}
+
+		tag, err := language.Parse(locale.GoString())
+		if err != nil {
+			return nil, fmt.Errorf("invalid locale %q: %w", locale.GoString(), err)
+		}
+
+		values, ok := item[1].(*starlark.Dict)
+		if !ok {
+			return nil, fmt.Errorf(
+				"translation for %s must be a dict, found %s",
+				locale.GoString(), item[1].Type(),
+			)
+		}
+
+		var t SchemaTranslation
+		for _, kv := range values.Items() {
+			key, _ := starlark.AsString(kv[0])
+			value, ok := kv[1].(starlark.String)
+			if !ok {
+				return nil, fmt.Errorf(
+					"translated %s for %s must be a string, found %s",
+					key, locale.GoString(), kv[1].Type(),
+				)
+			}
+
+			switch key {
+			case "name":
+				t.Name = value.GoString()
+			case "desc":
+				t.Description = value.GoString()
+			default:
+				return nil, fmt.Errorf(
+					"translation for %s has unknown key %s, expected name or desc",
+					locale.GoString(), kv[0].String(),
+				)
+			}
+		}
+
+		translations[tag.String()] = t
+	}
+
+	return translations, nil
+}
+
+// localize replaces the name and description of the field with the
+// translation that best matches the preferred languages.
+func (s *SchemaField) localize(prefs []language.Tag) {
+	for i := range s.Fields {
+		s.Fields[i].localize(prefs)
+	}
+
+	if t, ok := bestTranslation(s.Translations, prefs); ok {
+		if t.Name != "" {
+			s.Name = t.Name
+		}
+		if t.Description != "" {
+			s.Description = t.Description
+		}
+	}
+
+	s.Translations = nil
+}
+
+// localize replaces the name and description of the section with the
+// translation that best matches the preferred languages.
+func (s *SchemaSection) localize(prefs []language.Tag) {
+	if t, ok := bestTranslation(s.Translations, prefs); ok {
+		if t.Name != "" {
+			s.Name = t.Name
+		}
+		if t.Description != "" {
+			s.Description = t.Description
+		}
+	}
+
+	s.Translations = nil
+}
+
+func bestTranslation(
+	translations map[string]SchemaTranslation,
+	prefs []language.Tag,
+) (SchemaTranslation, bool) {
+	if len(translations) == 0 {
+		return SchemaTranslation{}, false
+	}
+
+	// schemas are written in English, which is what's used when
+	// nothing better matches
+	tags := []language.Tag{language.English}
+	locales := []string{""}
+	for locale := range translations {
+		tags = append(tags, language.Make(locale))
+		locales = append(locales, locale)
+	}
+
+	_, i, confidence := language.NewMatcher(tags).Match(prefs...)
+	if confidence == language.No || i == 0 {
+		return SchemaTranslation{}, false
+	}
+
+	return translations[locales[i]], true
+}
+
+// Localized returns a copy of the schema with field names and
+// descriptions in the preferred language, and translations removed.
+// The locale can be a single language tag like "de-AT", or a list of
+// them with weights, like in an Accept-Language header.
+func (s Schema) Localized(locale string) (Schema, error) {
+	prefs, _, err := language.ParseAcceptLanguage(locale)
+	if err != nil {
+		return s, fmt.Errorf("invalid locale %q: %w", locale, err)
+	}
+
+	fields := make([]SchemaField, len(s.Fields))
+	for i, field := range s.Fields {
+		if len(field.Fields) > 0 {
+			field.Fields = append([]SchemaField(nil), field.Fields...)
+		}
+		field.localize(prefs)
+		fields[i] = field
+	}
+	s.Fields = fields
+
+	notifications := make([]Notification, len(s.Notifications))
+	for i, n := range s.Notifications {
+		n.localize(prefs)
+		notifications[i] = n
+	}
+	s.Notifications = notifications
+
+	if len(s.Sections) > 0 {
+		sections := make([]SchemaSection, len(s.Sections))
+		for i, section := range s.Sections {
+			section.localize(prefs)
+			sections[i] = section
+		}
+		s.Sections = sections
+	}
+
+	return s, nil
+}
@@ -0,0 +1,134 @@
+package schema_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"tidbyt.dev/pixlet/runtime"
+)
+
+var translationsSource = `
+load("schema.star", "schema")
+
+def get_schema():
+    return schema.Schema(
+        version = "1",
+        fields = [
+            schema.Text(
+                id = "msg",
+                name = "Message",
+                desc = "A message to display.",
+                icon = "gear",
+                translations = {
+                    "de": {"name": "Nachricht", "desc": "Eine Nachricht zum Anzeigen."},
+                    "fr": {"name": "Message", "desc": "Un message à afficher."},
+                    "pt-BR": {"desc": "Uma mensagem para exibir."},
+                },
+            ),
+            schema.Toggle(
+                id = "party_mode",
+                name = "Party Mode",
+                desc = "Enable party mode.",
+                icon = "gear",
+            ),
+        ],
+    )
+
+def main(config):
+    return []
+`
+
+func TestTranslations(t *testing.T) {
+	app, err := loadApp(translationsSource)
+	require.NoError(t, err)
+
+	field := app.Schema.Fields[0]
+	assert.Equal(t, "Nachricht", field.Translations["de"].Name)
+	assert.Equal(t, "Uma mensagem para exibir.", field.Translations["pt-BR"].Description)
+
+	for _, tc := range []struct {
+		locale string
+		name   string
+		desc   string
+	}{
+		{"de", "Nachricht", "Eine Nachricht zum Anzeigen."},
+		{"de-AT", "Nachricht", "Eine Nachricht zum Anzeigen."},
+		{"fr-CA,fr;q=0.9,en;q=0.8", "Message", "Un message à afficher."},
+		{"pt-BR", "Message", "Uma mensagem para exibir."},
+		{"en-US", "Message", "A message to display."},
+		{"ja", "Message", "A message to display."},
+	} {
+		localized, err := app.Schema.Localized(tc.locale)
+		require.NoError(t, err, tc.locale)
+
+		assert.Equal(t, tc.name, localized.Fields[0].Name, tc.locale)
+		assert.Equal(t, tc.desc, localized.Fields[0].Description, tc.locale)
+		assert.Nil(t, localized.Fields[0].Translations, tc.locale)
+		assert.Equal(t, "Party Mode", localized.Fields[1].Name, tc.locale)
+	}
+
+	// localizing doesn't change the original schema
+	assert.Equal(t, "Message", app.Schema.Fields[0].Name)
+	assert.Len(t, app.Schema.Fields[0].Translations, 3)
+}
+
+func TestTranslationsGetSchema(t *testing.T) {
+	app, err := loadApp(translationsSource)
+	require.NoError(t, err)
+
+	js, err := app.GetSchema("de-DE")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"version": "1",
+		"schema": [
+			{
+				"type": "text",
+				"id": "msg",
+				"name": "Nachricht",
+				"description": "Eine Nachricht zum Anzeigen.",
+				"icon": "gear"
+			},
+			{
+				"type": "onoff",
+				"id": "party_mode",
+				"name": "Party Mode",
+				"description": "Enable party mode.",
+				"icon": "gear",
+				"default": "false"
+			}
+		]
+	}`, string(js))
+
+	js, err = app.GetSchema("")
+	require.NoError(t, err)
+	assert.Equal(t, app.SchemaJSON, js)
+	assert.Contains(t, string(js), `"translations"`)
+}
+
+func TestTranslationsBadArgs(t *testing.T) {
+	for _, args := range []string{
+		`translations = "de"`,
+		`translations = {"not a locale!": {"name": "Nachricht"}}`,
+		`translations = {"de": "Nachricht"}`,
+		`translations = {"de": {"title": "Nachricht"}}`,
+		`translations = {"de": {"name": 1}}`,
+	} {
+		src := `
+load("schema.star", "schema")
+
+t = schema.Text(
+	id = "msg",
+	name = "Message",
+	desc = "A message to display.",
+	icon = "gear",
+	` + args + `,
+)
+
+def main():
+	return []
+`
+		_, err := runtime.NewApplet("translations.star", []byte(src))
+		assert.Error(t, err, args)
+	}
+}
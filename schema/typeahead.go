@@ -2,15 +2,46 @@ package schema
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/mitchellh/hashstructure/v2"
+	startime "go.starlark.net/lib/time"
 	"go.starlark.net/starlark"
 )
 
+const (
+	// DefaultTypeaheadDebounce is how long hosts should wait after the
+	// user stops typing before calling a typeahead handler, for fields
+	// that don't set their own debounce.
+	DefaultTypeaheadDebounce = 300
+
+	// MaxTypeaheadDebounce keeps fields from making search feel
+	// unresponsive.
+	MaxTypeaheadDebounce = 5000
+)
+
 type Typeahead struct {
 	SchemaField
 }
 
+// asMilliseconds converts an int number of milliseconds or a
+// time.duration to whole milliseconds.
+func asMilliseconds(name string, v starlark.Value) (int64, error) {
+	switch d := v.(type) {
+	case startime.Duration:
+		return int64(time.Duration(d) / time.Millisecond), nil
+
+	case starlark.Int:
+		ms, ok := d.Int64()
+		if !ok {
+			return 0, fmt.Errorf("%s is out of range", name)
+		}
+		return ms, nil
+	}
+
+	return 0, fmt.Errorf("%s must be an int or a duration, found %s", name, v.Type())
+}
+
 func newTypeahead(
 	thread *starlark.Thread,
 	_ *starlark.Builtin,
@@ -18,11 +49,14 @@ func newTypeahead(
 	kwargs []starlark.Tuple,
 ) (starlark.Value, error) {
 	var (
-		id      starlark.String
-		name    starlark.String
-		desc    starlark.String
-		icon    starlark.String
-		handler *starlark.Function
+		id       starlark.String
+		name     starlark.String
+		desc     starlark.String
+		icon     starlark.String
+		handler  *starlark.Function
+		minChars starlark.Int
+		debounce starlark.Value = starlark.MakeInt(0)
+		cacheTTL starlark.Value = starlark.MakeInt(0)
 	)
 
 	if err := starlark.UnpackArgs(
@@ -33,6 +67,9 @@ func newTypeahead(
 		"desc", &desc,
 		"icon", &icon,
 		"handler", &handler,
+		"min_chars?", &minChars,
+		"debounce?", &debounce,
+		"cache_ttl?", &cacheTTL,
 	); err != nil {
 		return nil, fmt.Errorf("unpacking arguments for Typeahead: %s", err)
 	}
@@ -46,6 +83,30 @@ func newTypeahead(
 	s.Handler = handler.Name()
 	s.StarlarkHandler = handler
 
+	min, ok := minChars.Int64()
+	if !ok || min < 0 {
+		return nil, fmt.Errorf("min_chars must be a non-negative int")
+	}
+	s.MinChars = int(min)
+
+	ms, err := asMilliseconds("debounce", debounce)
+	if err != nil {
+		return nil, err
+	}
+	if ms < 0 || ms > MaxTypeaheadDebounce {
+		return nil, fmt.Errorf("debounce must be between 0 and %dms, found %dms", MaxTypeaheadDebounce, ms)
+	}
+	s.Debounce = int(ms)
+
+	ttl, err := asSeconds("cache_ttl", cacheTTL)
+	if err != nil {
+		return nil, err
+	}
+	if ttl < 0 {
+		return nil, fmt.Errorf("cache_ttl can't be negative")
+	}
+	s.CacheTTL = int(ttl)
+
 	return s, nil
 }
 
@@ -55,7 +116,7 @@ func (s *Typeahead) AsSchemaField() SchemaField {
 
 func (s *Typeahead) AttrNames() []string {
 	return []string{
-		"id", "name", "desc", "icon", "handler",
+		"id", "name", "desc", "icon", "handler", "min_chars", "debounce", "cache_ttl",
 	}
 }
 
@@ -77,6 +138,19 @@ func (s *Typeahead) Attr(name string) (starlark.Value, error) {
 	case "handler":
 		return s.StarlarkHandler, nil
 
+	case "min_chars":
+		return starlark.MakeInt(s.MinChars), nil
+
+	case "debounce":
+		ms := s.Debounce
+		if ms == 0 {
+			ms = DefaultTypeaheadDebounce
+		}
+		return startime.Duration(time.Duration(ms) * time.Millisecond), nil
+
+	case "cache_ttl":
+		return startime.Duration(time.Duration(s.CacheTTL) * time.Second), nil
+
 	default:
 		return nil, nil
 	}
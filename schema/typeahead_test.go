@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"tidbyt.dev/pixlet/runtime"
 )
 
@@ -54,3 +55,126 @@ func TestTypeahead(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, screens)
 }
+
+func TestTypeaheadHints(t *testing.T) {
+	app, err := runtime.NewApplet("typeahead.star", []byte(`
+load("schema.star", "schema")
+load("time.star", "time")
+
+def assert(success, message = None):
+    if not success:
+        fail(message or "assertion failed")
+
+def search(pattern):
+    return []
+
+t = schema.Typeahead(
+    id = "search",
+    name = "Search",
+    desc = "Search for a station.",
+    icon = "train",
+    handler = search,
+    min_chars = 3,
+    debounce = 500,
+    cache_ttl = time.parse_duration("1h"),
+)
+
+assert(t.min_chars == 3)
+assert(t.debounce == time.parse_duration("500ms"))
+assert(t.cache_ttl == time.parse_duration("1h"))
+
+d = schema.Typeahead(
+    id = "search",
+    name = "Search",
+    desc = "Search for a station.",
+    icon = "train",
+    handler = search,
+)
+
+assert(d.min_chars == 0)
+assert(d.debounce == time.parse_duration("300ms"))
+assert(d.cache_ttl == time.parse_duration("0s"))
+
+def main():
+    return []
+`))
+	require.NoError(t, err)
+
+	_, err = app.Run(context.Background())
+	assert.NoError(t, err)
+}
+
+func TestTypeaheadHintsBadArgs(t *testing.T) {
+	for _, args := range []string{
+		`min_chars = -1`,
+		`debounce = -5`,
+		`debounce = 10000`,
+		`debounce = "1s"`,
+		`cache_ttl = -1`,
+	} {
+		src := `
+load("schema.star", "schema")
+
+t = schema.Typeahead(
+    id = "search",
+    name = "Search",
+    desc = "Search for a station.",
+    icon = "train",
+    handler = lambda pattern: [],
+    ` + args + `,
+)
+
+def main():
+    return []
+`
+		_, err := runtime.NewApplet("typeahead.star", []byte(src))
+		assert.Error(t, err, args)
+	}
+}
+
+func TestTypeaheadHintsSchemaJSON(t *testing.T) {
+	app, err := loadApp(`
+load("schema.star", "schema")
+
+def search(pattern):
+    return []
+
+def get_schema():
+    return schema.Schema(
+        version = "1",
+        fields = [
+            schema.Typeahead(
+                id = "search",
+                name = "Search",
+                desc = "Search for a station.",
+                icon = "train",
+                handler = search,
+                min_chars = 2,
+                debounce = 250,
+                cache_ttl = 3600,
+            ),
+        ],
+    )
+
+def main():
+    return []
+`)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"version": "1",
+		"schema": [
+			{
+				"type": "typeahead",
+				"id": "search",
+				"name": "Search",
+				"description": "Search for a station.",
+				"icon": "train",
+				"handler": "search$search",
+				"min_chars": 2,
+				"debounce_ms": 250,
+				"cache_ttl": 3600
+			}
+		]
+	}`, string(app.SchemaJSON))
+}
@@ -0,0 +1,122 @@
+package schema_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var validatorSource = `
+load("schema.star", "schema")
+
+def validate(config):
+    errors = {}
+    if len(config.str("api_key", "")) != 8:
+        errors["api_key"] = "API keys are 8 characters long"
+    if config.bool("strict") and not config.str("station"):
+        errors["station"] = "Pick a station"
+    return errors
+
+def get_schema():
+    return schema.Schema(
+        version = "1",
+        validator = validate,
+        fields = [
+            schema.Text(
+                id = "api_key",
+                name = "API key",
+                desc = "Your API key.",
+                icon = "key",
+            ),
+            schema.Text(
+                id = "station",
+                name = "Station",
+                desc = "Station ID.",
+                icon = "train",
+            ),
+            schema.Toggle(
+                id = "strict",
+                name = "Strict",
+                desc = "Require a station.",
+                icon = "gear",
+            ),
+        ],
+    )
+
+def main():
+    return None
+`
+
+func TestValidator(t *testing.T) {
+	app, err := loadApp(validatorSource)
+	require.NoError(t, err)
+	assert.Equal(t, "validate", app.Schema.Validator)
+
+	errors, err := app.CallValidator(context.Background(), map[string]string{
+		"api_key": "abc",
+		"strict":  "true",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"api_key": "API keys are 8 characters long",
+		"station": "Pick a station",
+	}, errors)
+
+	errors, err = app.CallValidator(context.Background(), map[string]string{
+		"api_key": "abcdefgh",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{}, errors)
+
+	// hosts can also call it like any other handler
+	result, err := app.CallSchemaHandler(context.Background(), "validate", `{"api_key": "abcdefgh", "strict": "true"}`)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"station": "Pick a station"}`, result)
+
+	_, err = app.CallSchemaHandler(context.Background(), "validate", `not json`)
+	assert.Error(t, err)
+}
+
+func TestValidatorNone(t *testing.T) {
+	app, err := loadApp(`
+load("schema.star", "schema")
+
+def get_schema():
+    return schema.Schema(version = "1")
+
+def main():
+    return None
+`)
+	require.NoError(t, err)
+
+	errors, err := app.CallValidator(context.Background(), map[string]string{"foo": "bar"})
+	require.NoError(t, err)
+	assert.Empty(t, errors)
+}
+
+func TestValidatorBadReturn(t *testing.T) {
+	for _, ret := range []string{
+		`"oops"`,
+		`{"field": 1}`,
+		`{1: "oops"}`,
+	} {
+		app, err := loadApp(`
+load("schema.star", "schema")
+
+def validate(config):
+    return ` + ret + `
+
+def get_schema():
+    return schema.Schema(version = "1", validator = validate)
+
+def main():
+    return None
+`)
+		require.NoError(t, err)
+
+		_, err = app.CallValidator(context.Background(), map[string]string{})
+		assert.Error(t, err, ret)
+	}
+}
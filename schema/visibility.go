@@ -0,0 +1,140 @@
+package schema
+
+import (
+	"fmt"
+	"strconv"
+
+	"go.starlark.net/starlark"
+)
+
+type fieldConstructor func(*starlark.Thread, *starlark.Builtin, starlark.Tuple, []starlark.Tuple) (starlark.Value, error)
+
+// visibleIf is implemented by all fields through the embedded
+// SchemaField.
+type visibleIf interface {
+	setVisibility(*SchemaVisibility)
+}
+
+func (s *SchemaField) setVisibility(v *SchemaVisibility) {
+	s.Visibility = v
+}
+
+// withVisibleIf adds the `visible_if` keyword argument to a field
+// constructor. It takes a dict with a single field ID and value, and
+// hides the field unless that field is set to the value.
+func withVisibleIf(fn fieldConstructor) fieldConstructor {
+	return func(
+		thread *starlark.Thread,
+		b *starlark.Builtin,
+		args starlark.Tuple,
+		kwargs []starlark.Tuple,
+	) (starlark.Value, error) {
+		var cond *starlark.Dict
+
+		rest := make([]starlark.Tuple, 0, len(kwargs))
+		for _, kw := range kwargs {
+			if kw[0] != starlark.String("visible_if") {
+				rest = append(rest, kw)
+				continue
+			}
+
+			if _, isNone := kw[1].(starlark.NoneType); isNone {
+				continue
+			}
+
+			d, ok := kw[1].(*starlark.Dict)
+			if !ok {
+				return nil, fmt.Errorf(
+					"%s: visible_if must be a dict, found %s",
+					b.Name(), kw[1].Type(),
+				)
+			}
+			cond = d
+		}
+
+		val, err := fn(thread, b, args, rest)
+		if err != nil || cond == nil {
+			return val, err
+		}
+
+		visibility, err := parseVisibleIf(cond)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", b.Name(), err)
+		}
+
+		f, ok := val.(visibleIf)
+		if !ok {
+			return nil, fmt.Errorf("%s doesn't support visible_if", b.Name())
+		}
+		f.setVisibility(visibility)
+
+		return val, nil
+	}
+}
+
+func parseVisibleIf(cond *starlark.Dict) (*SchemaVisibility, error) {
+	if cond.Len() != 1 {
+		return nil, fmt.Errorf(
+			"visible_if must hold exactly one field, found %d",
+			cond.Len(),
+		)
+	}
+
+	item := cond.Items()[0]
+
+	variable, ok := item[0].(starlark.String)
+	if !ok {
+		return nil, fmt.Errorf("visible_if keys must be field IDs, found %s", item[0].Type())
+	}
+
+	var value string
+	switch v := item[1].(type) {
+	case starlark.String:
+		value = v.GoString()
+	case starlark.Bool:
+		value = strconv.FormatBool(bool(v))
+	case starlark.Int:
+		value = v.String()
+	case starlark.Float:
+		value = formatNumber(float64(v))
+	default:
+		return nil, fmt.Errorf(
+			"visible_if value for %s must be a string, bool or number, found %s",
+			variable.GoString(), item[1].Type(),
+		)
+	}
+
+	return &SchemaVisibility{
+		Type:      "invisible",
+		Condition: "not_equal",
+		Variable:  variable.GoString(),
+		Value:     value,
+	}, nil
+}
+
+// validateVisibility checks that the fields referred to by visibility
+// conditions exist.
+func validateVisibility(fields []SchemaField) error {
+	ids := map[string]bool{}
+	for _, f := range fields {
+		ids[f.ID] = true
+	}
+
+	for _, f := range fields {
+		if f.Visibility == nil {
+			continue
+		}
+
+		if f.Visibility.Variable == f.ID {
+			return fmt.Errorf("field %s can't depend on its own visibility", f.ID)
+		}
+		if !ids[f.Visibility.Variable] {
+			return fmt.Errorf(
+				"field %s depends on non-existent field %s",
+				f.ID, f.Visibility.Variable,
+			)
+		}
+	}
+
+	return nil
+}
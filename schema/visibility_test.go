@@ -0,0 +1,122 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"tidbyt.dev/pixlet/schema"
+)
+
+func TestVisibleIf(t *testing.T) {
+	code := `
+load("schema.star", "schema")
+
+def get_schema():
+    return schema.Schema(
+        version = "1",
+        fields = [
+            schema.Dropdown(
+                id = "units",
+                name = "Units",
+                desc = "Units to use.",
+                icon = "ruler",
+                default = "metric",
+                options = [
+                    schema.Option(display = "Metric", value = "metric"),
+                    schema.Option(display = "Custom", value = "custom"),
+                ],
+            ),
+            schema.Text(
+                id = "unit_name",
+                name = "Unit name",
+                desc = "Name of the custom unit.",
+                icon = "pen",
+                visible_if = {"units": "custom"},
+            ),
+            schema.Toggle(
+                id = "advanced",
+                name = "Advanced",
+                desc = "Show advanced settings.",
+                icon = "gear",
+            ),
+            schema.Slider(
+                id = "scale",
+                name = "Scale",
+                desc = "Scale factor.",
+                icon = "gear",
+                min = 1,
+                max = 10,
+                visible_if = {"advanced": True},
+            ),
+        ],
+    )
+
+def main():
+    return None
+`
+
+	app, err := loadApp(code)
+	require.NoError(t, err)
+
+	var s schema.Schema
+	require.NoError(t, json.Unmarshal(app.SchemaJSON, &s))
+	require.Equal(t, 4, len(s.Fields))
+
+	assert.Nil(t, s.Fields[0].Visibility)
+	assert.Equal(t, &schema.SchemaVisibility{
+		Type:      "invisible",
+		Condition: "not_equal",
+		Variable:  "units",
+		Value:     "custom",
+	}, s.Fields[1].Visibility)
+	assert.Nil(t, s.Fields[2].Visibility)
+	assert.Equal(t, &schema.SchemaVisibility{
+		Type:      "invisible",
+		Condition: "not_equal",
+		Variable:  "advanced",
+		Value:     "true",
+	}, s.Fields[3].Visibility)
+}
+
+func TestVisibleIfMalformed(t *testing.T) {
+	for _, cond := range []string{
+		`"units"`,
+		`{}`,
+		`{"units": "a", "other": "b"}`,
+		`{"units": ["a"]}`,
+		`{"missing": "a"}`,
+		`{"text": "a"}`,
+	} {
+		code := `
+load("schema.star", "schema")
+
+def get_schema():
+    return schema.Schema(
+        version = "1",
+        fields = [
+            schema.Text(
+                id = "units",
+                name = "Units",
+                desc = "Units to use.",
+                icon = "ruler",
+            ),
+            schema.Text(
+                id = "text",
+                name = "Text",
+                desc = "Some text.",
+                icon = "pen",
+                visible_if = ` + cond + `,
+            ),
+        ],
+    )
+
+def main():
+    return None
+`
+
+		_, err := loadApp(code)
+		assert.Error(t, err, cond)
+	}
+}
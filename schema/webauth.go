@@ -0,0 +1,126 @@
+package schema
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/mitchellh/hashstructure/v2"
+	"go.starlark.net/starlark"
+)
+
+// DefaultWebAuthRedirectParam is the query parameter hosts add to a
+// WebAuth URL to tell the service where to send the user back to.
+const DefaultWebAuthRedirectParam = "redirect_uri"
+
+// WebAuth logs in through a web page that isn't an OAuth2 flow, such
+// as a plain login form or a magic link. The host opens URL, waits for
+// the page to redirect back, and passes the query parameters of that
+// redirect to the handler, which returns the value to store.
+type WebAuth struct {
+	SchemaField
+}
+
+func newWebAuth(
+	thread *starlark.Thread,
+	_ *starlark.Builtin,
+	args starlark.Tuple,
+	kwargs []starlark.Tuple,
+) (starlark.Value, error) {
+	var (
+		id            starlark.String
+		name          starlark.String
+		desc          starlark.String
+		icon          starlark.String
+		authURL       starlark.String
+		handler       *starlark.Function
+		redirectParam starlark.String = DefaultWebAuthRedirectParam
+	)
+
+	if err := starlark.UnpackArgs(
+		"WebAuth",
+		args, kwargs,
+		"id", &id,
+		"name", &name,
+		"desc", &desc,
+		"icon", &icon,
+		"url", &authURL,
+		"handler", &handler,
+		"redirect_param?", &redirectParam,
+	); err != nil {
+		return nil, fmt.Errorf("unpacking arguments for WebAuth: %s", err)
+	}
+
+	u, err := url.Parse(authURL.GoString())
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("WebAuth: url must be an absolute http or https URL, found %q", authURL.GoString())
+	}
+
+	if redirectParam.GoString() == "" {
+		return nil, fmt.Errorf("WebAuth: redirect_param can't be empty")
+	}
+
+	s := &WebAuth{}
+	s.SchemaField.Type = "webauth"
+	s.ID = id.GoString()
+	s.Name = name.GoString()
+	s.Description = desc.GoString()
+	s.Icon = icon.GoString()
+	s.URL = authURL.GoString()
+	s.Handler = handler.Name()
+	s.StarlarkHandler = handler
+	s.RedirectParam = redirectParam.GoString()
+
+	// the value is a token or session, which is as good as a password
+	s.Sensitive = true
+
+	return s, nil
+}
+
+func (s *WebAuth) AsSchemaField() SchemaField {
+	return s.SchemaField
+}
+
+func (s *WebAuth) AttrNames() []string {
+	return []string{
+		"id", "name", "desc", "icon", "url", "handler", "redirect_param",
+	}
+}
+
+func (s *WebAuth) Attr(name string) (starlark.Value, error) {
+	switch name {
+
+	case "id":
+		return starlark.String(s.ID), nil
+
+	case "name":
+		return starlark.String(s.Name), nil
+
+	case "desc":
+		return starlark.String(s.Description), nil
+
+	case "icon":
+		return starlark.String(s.Icon), nil
+
+	case "url":
+		return starlark.String(s.URL), nil
+
+	case "handler":
+		return s.StarlarkHandler, nil
+
+	case "redirect_param":
+		return starlark.String(s.RedirectParam), nil
+
+	default:
+		return nil, nil
+	}
+}
+
+func (s *WebAuth) String() string       { return "WebAuth(...)" }
+func (s *WebAuth) Type() string         { return "WebAuth" }
+func (s *WebAuth) Freeze()              {}
+func (s *WebAuth) Truth() starlark.Bool { return true }
+
+func (s *WebAuth) Hash() (uint32, error) {
+	sum, err := hashstructure.Hash(s, hashstructure.FormatV2, nil)
+	return uint32(sum), err
+}
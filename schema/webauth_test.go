@@ -0,0 +1,143 @@
+package schema_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"tidbyt.dev/pixlet/runtime"
+)
+
+var webAuthSource = `
+load("schema.star", "schema")
+
+def assert(success, message=None):
+    if not success:
+        fail(message or "assertion failed")
+
+def login(params):
+    return "token"
+
+s = schema.WebAuth(
+	id = "account",
+	name = "Account",
+	desc = "Log in to your account.",
+	icon = "user",
+	url = "https://example.com/login",
+	handler = login,
+)
+
+assert(s.id == "account")
+assert(s.name == "Account")
+assert(s.desc == "Log in to your account.")
+assert(s.icon == "user")
+assert(s.url == "https://example.com/login")
+assert(s.handler == login)
+assert(s.redirect_param == "redirect_uri")
+
+def main():
+	return []
+`
+
+func TestWebAuth(t *testing.T) {
+	app, err := runtime.NewApplet("webauth.star", []byte(webAuthSource))
+	assert.NoError(t, err)
+
+	screens, err := app.Run(context.Background())
+	assert.NoError(t, err)
+	assert.NotNil(t, screens)
+}
+
+func TestWebAuthBadArgs(t *testing.T) {
+	for _, args := range []string{
+		// url and handler are required
+		`url = "https://example.com/login"`,
+		`handler = login`,
+
+		// url must be absolute http(s)
+		`url = "/login", handler = login`,
+		`url = "ftp://example.com/login", handler = login`,
+
+		// the handler must be a function
+		`url = "https://example.com/login", handler = "login"`,
+
+		`url = "https://example.com/login", handler = login, redirect_param = ""`,
+	} {
+		_, err := runtime.NewApplet("webauth.star", []byte(fmt.Sprintf(`
+load("schema.star", "schema")
+
+def login(params):
+    return "token"
+
+s = schema.WebAuth(
+	id = "account",
+	name = "Account",
+	desc = "Log in to your account.",
+	icon = "user",
+	%s,
+)
+
+def main():
+	return []
+`, args)))
+		assert.Error(t, err, args)
+	}
+}
+
+func TestWebAuthHandler(t *testing.T) {
+	app, err := loadApp(`
+load("encoding/json.star", "json")
+load("schema.star", "schema")
+
+def login(params):
+    params = json.decode(params)
+    return params["params"]["session"]
+
+def get_schema():
+    return schema.Schema(
+        version = "1",
+        fields = [
+            schema.WebAuth(
+                id = "account",
+                name = "Account",
+                desc = "Log in to your account.",
+                icon = "user",
+                url = "https://example.com/login?app=pixlet",
+                handler = login,
+                redirect_param = "return_to",
+            ),
+        ],
+    )
+
+def main():
+    return []
+`)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"version": "1",
+		"schema": [
+			{
+				"type": "webauth",
+				"id": "account",
+				"name": "Account",
+				"description": "Log in to your account.",
+				"icon": "user",
+				"sensitive": true,
+				"handler": "account$login",
+				"url": "https://example.com/login?app=pixlet",
+				"redirect_param": "return_to"
+			}
+		]
+	}`, string(app.SchemaJSON))
+
+	value, err := app.CallSchemaHandler(
+		context.Background(),
+		"account$login",
+		`{"params": {"session": "abc123"}, "redirect_uri": "http://localhost:8080/webauth-callback"}`,
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", value)
+}
@@ -0,0 +1,361 @@
+// Package auth protects a server with a login, for when it's reachable
+// by more people than the one running it. It supports HTTP basic auth
+// and signing in with an OpenID Connect provider.
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	// CallbackPath is where the OpenID Connect provider sends users back
+	// to after they sign in.
+	CallbackPath = "/auth/callback"
+
+	sessionCookie = "pixlet_session"
+	stateCookie   = "pixlet_auth_state"
+	sessionLength = 24 * time.Hour
+)
+
+// Config picks how users sign in. Set either a username and password
+// for basic auth, or an OIDC issuer and client for OpenID Connect.
+type Config struct {
+	Username string
+	Password string
+
+	OIDCIssuer       string
+	OIDCClientID     string
+	OIDCClientSecret string
+
+	// OIDCRedirectURL is the URL of the callback, as registered with
+	// the provider. By default, it's derived from each request.
+	OIDCRedirectURL string
+
+	// AllowedEmails limits OpenID Connect sign in to these addresses.
+	// If empty, anyone who can sign in with the provider is let in.
+	AllowedEmails []string
+}
+
+// Enabled reports whether the config asks for authentication.
+func (c Config) Enabled() bool {
+	return c.Username != "" || c.Password != "" || c.OIDCIssuer != ""
+}
+
+// Auth checks that requests come from a signed in user.
+type Auth struct {
+	config      Config
+	oauth       *oauth2.Config
+	userinfoURL string
+	key         []byte
+	allowed     map[string]bool
+}
+
+// providerMetadata is the part of an OpenID Connect discovery document
+// that's needed to sign users in.
+type providerMetadata struct {
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+}
+
+type userinfo struct {
+	Email         string `json:"email"`
+	EmailVerified *bool  `json:"email_verified"`
+}
+
+// New sets up authentication for config. For OpenID Connect, it fetches
+// the provider's configuration from the issuer.
+func New(ctx context.Context, config Config) (*Auth, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("generating session key: %w", err)
+	}
+
+	a := &Auth{
+		config:  config,
+		key:     key,
+		allowed: map[string]bool{},
+	}
+	for _, email := range config.AllowedEmails {
+		a.allowed[strings.ToLower(strings.TrimSpace(email))] = true
+	}
+
+	basic := config.Username != "" || config.Password != ""
+	switch {
+	case basic && config.OIDCIssuer != "":
+		return nil, fmt.Errorf("use either basic auth or OpenID Connect, not both")
+
+	case basic:
+		if config.Username == "" || config.Password == "" {
+			return nil, fmt.Errorf("basic auth needs both a username and a password")
+		}
+
+	case config.OIDCIssuer != "":
+		if config.OIDCClientID == "" {
+			return nil, fmt.Errorf("OpenID Connect needs a client ID")
+		}
+
+		meta, err := discover(ctx, config.OIDCIssuer)
+		if err != nil {
+			return nil, err
+		}
+
+		a.userinfoURL = meta.UserinfoEndpoint
+		a.oauth = &oauth2.Config{
+			ClientID:     config.OIDCClientID,
+			ClientSecret: config.OIDCClientSecret,
+			Scopes:       []string{"openid", "email"},
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  meta.AuthorizationEndpoint,
+				TokenURL: meta.TokenEndpoint,
+			},
+		}
+
+	default:
+		return nil, fmt.Errorf("no authentication configured")
+	}
+
+	return a, nil
+}
+
+func discover(ctx context.Context, issuer string) (*providerMetadata, error) {
+	url := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching OpenID configuration: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching OpenID configuration: %s", resp.Status)
+	}
+
+	meta := &providerMetadata{}
+	if err := json.NewDecoder(resp.Body).Decode(meta); err != nil {
+		return nil, fmt.Errorf("parsing OpenID configuration: %w", err)
+	}
+
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.UserinfoEndpoint == "" {
+		return nil, fmt.Errorf("OpenID configuration of %s is missing endpoints", issuer)
+	}
+
+	return meta, nil
+}
+
+// Wrap returns a handler that only passes on requests from signed in
+// users.
+func (a *Auth) Wrap(next http.Handler) http.Handler {
+	if a.oauth == nil {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !a.checkBasic(r) {
+				w.Header().Set("WWW-Authenticate", `Basic realm="pixlet", charset="UTF-8"`)
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == CallbackPath {
+			a.callback(w, r)
+			return
+		}
+
+		if a.checkSession(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// API clients can't follow a sign in, so only pages redirect
+		if r.Method != "GET" || strings.Contains(r.URL.Path, "/api/") {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		a.signIn(w, r)
+	})
+}
+
+func (a *Auth) checkBasic(r *http.Request) bool {
+	user, pass, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+
+	userOK := subtle.ConstantTimeCompare([]byte(user), []byte(a.config.Username)) == 1
+	passOK := subtle.ConstantTimeCompare([]byte(pass), []byte(a.config.Password)) == 1
+	return userOK && passOK
+}
+
+func (a *Auth) redirectURL(r *http.Request) string {
+	if a.config.OIDCRedirectURL != "" {
+		return a.config.OIDCRedirectURL
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, r.Host, CallbackPath)
+}
+
+// signIn sends the user to the provider. The state remembers where
+// they were headed, and is kept in a cookie to check the callback.
+func (a *Auth) signIn(w http.ResponseWriter, r *http.Request) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		http.Error(w, "generating state", http.StatusInternalServerError)
+		return
+	}
+	state := base64.RawURLEncoding.EncodeToString(nonce) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(r.URL.RequestURI()))
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     stateCookie,
+		Value:    state,
+		Path:     CallbackPath,
+		MaxAge:   600,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	conf := *a.oauth
+	conf.RedirectURL = a.redirectURL(r)
+	http.Redirect(w, r, conf.AuthCodeURL(state), http.StatusFound)
+}
+
+func (a *Auth) callback(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(stateCookie)
+	state := r.URL.Query().Get("state")
+	if err != nil || state == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+		http.Error(w, "invalid sign in state, please try again", http.StatusBadRequest)
+		return
+	}
+
+	if e := r.URL.Query().Get("error"); e != "" {
+		http.Error(w, fmt.Sprintf("sign in failed: %s", e), http.StatusUnauthorized)
+		return
+	}
+
+	conf := *a.oauth
+	conf.RedirectURL = a.redirectURL(r)
+	token, err := conf.Exchange(r.Context(), r.URL.Query().Get("code"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("sign in failed: %v", err), http.StatusUnauthorized)
+		return
+	}
+
+	info, err := a.userinfo(r.Context(), &conf, token)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("sign in failed: %v", err), http.StatusUnauthorized)
+		return
+	}
+
+	if len(a.allowed) > 0 {
+		verified := info.EmailVerified == nil || *info.EmailVerified
+		if !verified || !a.allowed[strings.ToLower(info.Email)] {
+			http.Error(w, fmt.Sprintf("%s isn't allowed to use this server", info.Email), http.StatusForbidden)
+			return
+		}
+	}
+
+	expires := time.Now().Add(sessionLength)
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    a.sign(info.Email, expires),
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	target := "/"
+	if _, dest, ok := strings.Cut(state, "."); ok {
+		if d, err := base64.RawURLEncoding.DecodeString(dest); err == nil && strings.HasPrefix(string(d), "/") && !strings.HasPrefix(string(d), "//") {
+			target = string(d)
+		}
+	}
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+func (a *Auth) userinfo(ctx context.Context, conf *oauth2.Config, token *oauth2.Token) (*userinfo, error) {
+	resp, err := conf.Client(ctx, token).Get(a.userinfoURL)
+	if err != nil {
+		return nil, fmt.Errorf("fetching user info: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching user info: %s", resp.Status)
+	}
+
+	info := &userinfo{}
+	if err := json.NewDecoder(resp.Body).Decode(info); err != nil {
+		return nil, fmt.Errorf("parsing user info: %w", err)
+	}
+
+	return info, nil
+}
+
+// sign creates a session for email. Sessions are signed with a key
+// that only lives as long as the process, so restarting signs everyone
+// out.
+func (a *Auth) sign(email string, expires time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(email)) + "." + strconv.FormatInt(expires.Unix(), 10)
+	return payload + "." + a.mac(payload)
+}
+
+func (a *Auth) mac(payload string) string {
+	h := hmac.New(sha256.New, a.key)
+	h.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+func (a *Auth) checkSession(r *http.Request) bool {
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return false
+	}
+
+	i := strings.LastIndex(cookie.Value, ".")
+	if i < 0 {
+		return false
+	}
+	payload, sig := cookie.Value[:i], cookie.Value[i+1:]
+	if !hmac.Equal([]byte(sig), []byte(a.mac(payload))) {
+		return false
+	}
+
+	_, exp, ok := strings.Cut(payload, ".")
+	if !ok {
+		return false
+	}
+	expires, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	return time.Now().Unix() < expires
+}
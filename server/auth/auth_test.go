@@ -0,0 +1,264 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// okHandler is what Auth protects in tests.
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("ok"))
+})
+
+// startProvider runs a fake OpenID Connect provider, which signs
+// everyone in as email.
+func startProvider(t *testing.T, email string) string {
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(providerMetadata{
+			AuthorizationEndpoint: srv.URL + "/authorize",
+			TokenEndpoint:         srv.URL + "/token",
+			UserinfoEndpoint:      srv.URL + "/userinfo",
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "token",
+			"token_type":   "Bearer",
+		})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(userinfo{Email: email})
+	})
+
+	return srv.URL
+}
+
+func newOIDC(t *testing.T, email string, allowed ...string) *Auth {
+	a, err := New(context.Background(), Config{
+		OIDCIssuer:    startProvider(t, email),
+		OIDCClientID:  "pixlet",
+		AllowedEmails: allowed,
+	})
+	require.NoError(t, err)
+	return a
+}
+
+// signIn starts signing in on the way to target, and returns the state
+// cookie set for the callback.
+func signIn(t *testing.T, h http.Handler, target string) *http.Cookie {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+	require.Equal(t, http.StatusFound, w.Code)
+
+	loc, err := url.Parse(w.Header().Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, "/authorize", loc.Path)
+
+	for _, c := range w.Result().Cookies() {
+		if c.Name == stateCookie {
+			assert.Equal(t, loc.Query().Get("state"), c.Value)
+			return c
+		}
+	}
+	t.Fatal("no state cookie")
+	return nil
+}
+
+func callback(h http.Handler, state string, cookie *http.Cookie) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("GET", CallbackPath+"?code=code&state="+url.QueryEscape(state), nil)
+	if cookie != nil {
+		r.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func sessionFrom(w *httptest.ResponseRecorder) *http.Cookie {
+	for _, c := range w.Result().Cookies() {
+		if c.Name == sessionCookie {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestBasic(t *testing.T) {
+	a, err := New(context.Background(), Config{Username: "tidbyt", Password: "hunter2"})
+	require.NoError(t, err)
+	h := a.Wrap(okHandler)
+
+	for _, tc := range []struct {
+		name       string
+		user, pass string
+		want       int
+	}{
+		{"right", "tidbyt", "hunter2", http.StatusOK},
+		{"wrong password", "tidbyt", "hunter3", http.StatusUnauthorized},
+		{"wrong user", "tidbit", "hunter2", http.StatusUnauthorized},
+		{"prefix of password", "tidbyt", "hunter", http.StatusUnauthorized},
+		{"empty", "", "", http.StatusUnauthorized},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.SetBasicAuth(tc.user, tc.pass)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			assert.Equal(t, tc.want, w.Code)
+		})
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Header().Get("WWW-Authenticate"), "Basic")
+}
+
+func TestNewConfig(t *testing.T) {
+	_, err := New(context.Background(), Config{Username: "tidbyt"})
+	assert.Error(t, err)
+
+	_, err = New(context.Background(), Config{Username: "tidbyt", Password: "hunter2", OIDCIssuer: "https://example.com"})
+	assert.Error(t, err)
+
+	_, err = New(context.Background(), Config{})
+	assert.Error(t, err)
+}
+
+func TestOIDCSignIn(t *testing.T) {
+	h := newOIDC(t, "user@example.com").Wrap(okHandler)
+
+	state := signIn(t, h, "/apps/clock?x=1")
+	w := callback(h, state.Value, state)
+	require.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/apps/clock?x=1", w.Header().Get("Location"))
+
+	session := sessionFrom(w)
+	require.NotNil(t, session)
+
+	r := httptest.NewRequest("GET", "/apps/clock", nil)
+	r.AddCookie(session)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ok", w.Body.String())
+}
+
+func TestOIDCStateMismatch(t *testing.T) {
+	h := newOIDC(t, "user@example.com").Wrap(okHandler)
+	state := signIn(t, h, "/")
+	other := signIn(t, h, "/")
+
+	// the state must match the cookie set for this browser
+	w := callback(h, other.Value, state)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Nil(t, sessionFrom(w))
+
+	// and there must be a cookie at all
+	w = callback(h, state.Value, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Nil(t, sessionFrom(w))
+
+	w = callback(h, "", &http.Cookie{Name: stateCookie, Value: ""})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestOIDCRedirectTarget(t *testing.T) {
+	h := newOIDC(t, "user@example.com").Wrap(okHandler)
+
+	for _, tc := range []struct {
+		dest string
+		want string
+	}{
+		{"/apps/clock", "/apps/clock"},
+		{"//evil.example.com/", "/"},
+		{"https://evil.example.com/", "/"},
+		{"evil", "/"},
+	} {
+		t.Run(tc.dest, func(t *testing.T) {
+			// a state that's been tampered with still has to match its
+			// cookie, so set both
+			state := "nonce." + base64.RawURLEncoding.EncodeToString([]byte(tc.dest))
+			w := callback(h, state, &http.Cookie{Name: stateCookie, Value: state})
+			require.Equal(t, http.StatusFound, w.Code)
+			assert.Equal(t, tc.want, w.Header().Get("Location"))
+		})
+	}
+
+	state := "nonce.!!!"
+	w := callback(h, state, &http.Cookie{Name: stateCookie, Value: state})
+	assert.Equal(t, "/", w.Header().Get("Location"))
+}
+
+func TestOIDCAllowedEmails(t *testing.T) {
+	h := newOIDC(t, "Someone@Example.com", "someone@example.com").Wrap(okHandler)
+	state := signIn(t, h, "/")
+	w := callback(h, state.Value, state)
+	assert.Equal(t, http.StatusFound, w.Code)
+
+	h = newOIDC(t, "intruder@example.com", "someone@example.com").Wrap(okHandler)
+	state = signIn(t, h, "/")
+	w = callback(h, state.Value, state)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Nil(t, sessionFrom(w))
+}
+
+func TestSession(t *testing.T) {
+	a := newOIDC(t, "user@example.com")
+	h := a.Wrap(okHandler)
+
+	get := func(path, session string) int {
+		r := httptest.NewRequest("GET", path, nil)
+		if session != "" {
+			r.AddCookie(&http.Cookie{Name: sessionCookie, Value: session})
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	valid := a.sign("user@example.com", time.Now().Add(time.Hour))
+	assert.Equal(t, http.StatusOK, get("/", valid))
+
+	// expired sessions send users to sign in again, or fail API calls
+	expired := a.sign("user@example.com", time.Now().Add(-time.Second))
+	assert.Equal(t, http.StatusFound, get("/", expired))
+	assert.Equal(t, http.StatusUnauthorized, get("/api/v1/preview", expired))
+
+	// the expiry is covered by the signature
+	payload, _, _ := strings.Cut(expired, ".")
+	i := strings.LastIndex(expired, ".")
+	extended := payload + "." + "99999999999" + expired[i:]
+	assert.Equal(t, http.StatusFound, get("/", extended))
+
+	// and so is the email
+	j := strings.Index(valid, ".")
+	other := base64.RawURLEncoding.EncodeToString([]byte("other@example.com")) + valid[j:]
+	assert.Equal(t, http.StatusFound, get("/", other))
+
+	// sessions signed by another process don't count
+	b := newOIDC(t, "user@example.com")
+	assert.Equal(t, http.StatusFound, get("/", b.sign("user@example.com", time.Now().Add(time.Hour))))
+
+	assert.Equal(t, http.StatusFound, get("/", "garbage"))
+	assert.Equal(t, http.StatusUnauthorized, get("/api/v1/preview", ""))
+}
@@ -8,15 +8,21 @@ import (
 	"encoding/json"
 	"fmt"
 	"html/template"
-	"log"
+	"log/slog"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
 	"golang.org/x/sync/errgroup"
+	"tidbyt.dev/pixlet/configstore"
 	"tidbyt.dev/pixlet/dist"
+	"tidbyt.dev/pixlet/schema"
+	"tidbyt.dev/pixlet/server/auth"
 	"tidbyt.dev/pixlet/server/fanout"
 	"tidbyt.dev/pixlet/server/loader"
+	"tidbyt.dev/pixlet/server/simulator"
 )
 
 // Browser provides a structure for serving WebP or GIF images over websockets to
@@ -31,6 +37,15 @@ type Browser struct {
 	tmpl       *template.Template
 	loader     *loader.Loader
 	serveGif   bool               // True if serving GIF, false if serving WebP
+	basePath   string             // The path the browser is mounted at, if any.
+	staticPath string             // The path the frontend's static files are served at, if not /static.
+	oauthCallbackPath string      // The path providers redirect to after OAuth2 logins, if not /oauth-callback.
+	config     configStore        // Config values entered in the browser.
+	auth       *auth.Auth         // Checks requests come from a signed in user, if set.
+	tlsCert    string             // Certificate and key for serving HTTPS, if set.
+	tlsKey     string
+	webhookSecret string          // Secret webhook requests must prove they know, if set.
+	logger     *slog.Logger       // Where logs go, if not slog.Default().
 }
 
 //go:embed preview-mask.png
@@ -51,12 +66,14 @@ type previewData struct {
 	Err    string    `json:"error,omitempty"`
 }
 type handlerRequest struct {
-	ID    string `json:"id"`
-	Param string `json:"param"`
+	ID     string            `json:"id"`
+	Param  string            `json:"param"`
+	Config map[string]string `json:"config"`
 }
 
 // NewBrowser sets up a browser structure. Call Run() to kick off the main loops.
-func NewBrowser(addr string, title string, watch bool, updateChan chan loader.Update, l *loader.Loader, serveGif bool) (*Browser, error) {
+// Logs go to logger, or slog.Default() if it's nil.
+func NewBrowser(addr string, title string, watch bool, updateChan chan loader.Update, l *loader.Loader, serveGif bool, logger *slog.Logger) (*Browser, error) {
 	tmpl, err := template.New("preview").Parse(previewHTML)
 	if err != nil {
 		return nil, err
@@ -71,6 +88,7 @@ func NewBrowser(addr string, title string, watch bool, updateChan chan loader.Up
 		loader:     l,
 		watch:      watch,
 		serveGif:   serveGif,
+		logger:     logger,
 	}
 
 	r := mux.NewRouter()
@@ -78,7 +96,8 @@ func NewBrowser(addr string, title string, watch bool, updateChan chan loader.Up
 	// In order for React Router to work, all routes that React Router should
 	// manage need to return the root handler.
 	r.HandleFunc("/", b.rootHandler)
-	r.HandleFunc("/oauth-callback", b.rootHandler)
+	r.HandleFunc("/oauth-callback", HandleOAuthCallback)
+	r.HandleFunc("/webauth-callback", b.rootHandler)
 
 	// This enables the static directory containing JS and CSS to be available
 	// at /static.
@@ -92,19 +111,90 @@ func NewBrowser(addr string, title string, watch bool, updateChan chan loader.Up
 	r.HandleFunc("/favicon.png", b.faviconHandler).Methods("GET")
 	r.HandleFunc("/preview-mask.png", b.previewMaskHandler).Methods("GET")
 
+	// The device simulator, for embedding the app in other sites.
+	r.HandleFunc("/embed", b.embedHandler).Methods("GET")
+	r.Handle("/simulator.js", simulator.ScriptHandler()).Methods("GET", "HEAD")
+
 	// API endpoints to support the React frontend.
 	r.HandleFunc("/api/v1/preview", b.previewHandler)
 	r.HandleFunc("/api/v1/preview.webp", b.imageHandler)
 	r.HandleFunc("/api/v1/preview.gif", b.imageHandler)
 	r.HandleFunc("/api/v1/push", b.pushHandler)
+	r.HandleFunc("/api/v1/webhook", b.webhookHandler).Methods("POST")
 	r.HandleFunc("/api/v1/schema", b.schemaHandler).Methods("GET")
+	r.HandleFunc("/api/v1/handlers", b.handlersHandler).Methods("GET")
 	r.HandleFunc("/api/v1/handlers/{handler}", b.schemaHandlerHandler).Methods("POST")
+	r.HandleFunc("/api/v1/handlers/{handler}/inspect", b.inspectHandlerHandler).Methods("GET", "POST")
+	r.HandleFunc("/api/v1/icons", b.iconsHandler).Methods("GET")
+	r.HandleFunc("/api/v1/config", b.configHandler).Methods("GET")
+	r.HandleFunc("/api/v1/render", b.renderHandler).Methods("GET", "POST")
+	r.HandleFunc("/api/v1/render.{format:webp|gif|png|bmp}", b.renderHandler).Methods("GET", "POST")
+	r.HandleFunc("/api/v1/apps", b.appsHandler).Methods("GET")
+	r.HandleFunc("/api/v1/frames", b.framesHandler).Methods("GET")
+	r.HandleFunc("/api/v1/config", b.saveConfigHandler).Methods("PUT")
+	r.HandleFunc("/api/v1/oauth/{field}/login", b.oauthLoginHandler).Methods("GET")
+	r.HandleFunc("/api/v1/oauth/{field}/refresh", b.oauthRefreshHandler).Methods("POST")
+	r.HandleFunc("/api/v1/oauth/{field}", b.oauthLogoutHandler).Methods("DELETE")
 	r.HandleFunc("/api/v1/ws", b.websocketHandler)
 	b.r = r
 
 	return b, nil
 }
 
+// Secure makes Run require users to sign in with a, if it's not nil,
+// and serve HTTPS using certFile and keyFile, if they're not empty.
+func (b *Browser) Secure(a *auth.Auth, certFile, keyFile string) {
+	b.auth = a
+	b.tlsCert = certFile
+	b.tlsKey = keyFile
+}
+
+// SetWebhookSecret makes the webhook endpoint require requests to pass
+// secret, or sign their body with it, as with pixlet daemon. An empty
+// secret lets any request through.
+func (b *Browser) SetWebhookSecret(secret string) {
+	b.webhookSecret = secret
+}
+
+// PersistConfig saves config values entered in the browser to path, and
+// restores the values saved there by an earlier run.
+func (b *Browser) PersistConfig(path string) error {
+	return b.config.load(path)
+}
+
+// UseConfigStore keeps config values entered in the browser in store,
+// under key, and restores the values kept there.
+func (b *Browser) UseConfigStore(store configstore.Store, key configstore.Key) error {
+	return b.config.useStore(store, key)
+}
+
+// Config returns the config values entered in the browser.
+func (b *Browser) Config() map[string]string {
+	return b.config.get()
+}
+
+// Mount returns a handler that serves the browser under prefix, so that
+// several browsers can share one server. Call RunUpdates instead of Run
+// to process updates when mounted.
+func (b *Browser) Mount(prefix string) http.Handler {
+	b.basePath = strings.TrimSuffix(prefix, "/")
+	return http.StripPrefix(b.basePath, b.r)
+}
+
+// SetStaticPath tells the frontend that its static files are served at
+// path rather than /static, like when it's mounted in another service.
+// The browser doesn't serve them there itself.
+func (b *Browser) SetStaticPath(path string) {
+	b.staticPath = strings.TrimSuffix(path, "/")
+}
+
+// RunUpdates sends updates to connected browsers, without listening for
+// requests itself. It runs forever in a blocking fashion.
+func (b *Browser) RunUpdates() error {
+	defer b.fo.Quit()
+	return b.updateWatcher()
+}
+
 // Run starts the server process and runs forever in a blocking fashion. The
 // main routines include an update watcher to process incomming changes to the
 // image and running the http handlers.
@@ -130,7 +220,41 @@ func (b *Browser) previewMaskHandler(w http.ResponseWriter, r *http.Request) {
 
 func (b *Browser) schemaHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Write(b.loader.GetSchema())
+
+	// the locale can be picked with a query parameter, and otherwise
+	// follows the browser's language
+	locale := r.URL.Query().Get("locale")
+	if locale == "" {
+		locale = r.Header.Get("Accept-Language")
+	}
+
+	w.Write(b.loader.GetSchema(locale))
+}
+
+func (b *Browser) iconsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schema.AllIcons())
+}
+
+func (b *Browser) configHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(b.config.get())
+}
+
+func (b *Browser) saveConfigHandler(w http.ResponseWriter, r *http.Request) {
+	values := map[string]string{}
+	if err := json.NewDecoder(r.Body).Decode(&values); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := b.config.set(values); err != nil {
+		b.log().Error("saving config", "error", err)
+		http.Error(w, "saving config", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
 func (b *Browser) schemaHandlerHandler(w http.ResponseWriter, r *http.Request) {
@@ -150,7 +274,7 @@ func (b *Browser) schemaHandlerHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	data, err := b.loader.CallSchemaHandler(r.Context(), vars["handler"], msg.Param)
+	data, err := b.loader.CallSchemaHandler(r.Context(), vars["handler"], msg.Param, msg.Config)
 	if err != nil {
 		w.WriteHeader(500)
 		fmt.Fprintln(w, err)
@@ -161,6 +285,77 @@ func (b *Browser) schemaHandlerHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(data))
 }
 
+func (b *Browser) handlersHandler(w http.ResponseWriter, r *http.Request) {
+	d, err := json.Marshal(b.loader.Handlers())
+	if err != nil {
+		w.WriteHeader(500)
+		fmt.Fprintln(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(d)
+}
+
+// handlerInspection is the result of calling a handler through the
+// inspect endpoint. Result is exactly what the handler returned, as a
+// string.
+type handlerInspection struct {
+	Handler    string            `json:"handler"`
+	Param      string            `json:"param"`
+	Config     map[string]string `json:"config,omitempty"`
+	Result     string            `json:"result"`
+	DurationMS int64             `json:"duration_ms"`
+	Err        string            `json:"error,omitempty"`
+}
+
+// inspectHandlerHandler calls a schema handler and reports the raw
+// result, along with how long it took and any error. Besides the same
+// POST body as the handlers endpoint, it takes GET requests with the
+// param in the `param` query parameter and config in the others, which
+// makes it easy to call from a browser or curl.
+func (b *Browser) inspectHandlerHandler(w http.ResponseWriter, r *http.Request) {
+	msg := &handlerRequest{}
+	if r.Method == "GET" {
+		msg.Config = map[string]string{}
+		for k, vals := range r.URL.Query() {
+			if k == "param" {
+				msg.Param = vals[0]
+			} else {
+				msg.Config[k] = vals[0]
+			}
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(msg); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	name := mux.Vars(r)["handler"]
+	start := time.Now()
+	result, err := b.loader.CallSchemaHandler(r.Context(), name, msg.Param, msg.Config)
+
+	data := &handlerInspection{
+		Handler:    name,
+		Param:      msg.Param,
+		Config:     msg.Config,
+		Result:     result,
+		DurationMS: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		data.Err = err.Error()
+	}
+
+	d, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		w.WriteHeader(500)
+		fmt.Fprintln(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(d)
+}
+
 func (b *Browser) imageHandler(w http.ResponseWriter, r *http.Request) {
 	err := r.ParseForm()
 	if err != nil {
@@ -195,10 +390,18 @@ func (b *Browser) imageHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write(data)
 }
 
+// log returns the browser's logger.
+func (b *Browser) log() *slog.Logger {
+	if b.logger == nil {
+		return slog.Default()
+	}
+	return b.logger
+}
+
 func (b *Browser) previewHandler(w http.ResponseWriter, r *http.Request) {
 	// Parse the request form so we can use it as config values.
 	if err := r.ParseMultipartForm(100); err != nil {
-		log.Printf("form parsing failed: %+v", err)
+		b.log().Warn("form parsing failed", "error", err)
 		http.Error(w, "bad form data", http.StatusBadRequest)
 		return
 	}
@@ -243,7 +446,7 @@ func (b *Browser) websocketHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		log.Printf("error establishing a new connection %v\n", err)
+		b.log().Warn("establishing a new connection", "error", err)
 		return
 	}
 
@@ -289,7 +492,22 @@ func (b *Browser) updateWatcher() error {
 }
 func (b *Browser) rootHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/html")
-	w.Write(dist.Index)
+
+	if b.basePath == "" {
+		w.Write(dist.Index)
+		return
+	}
+
+	// tell the frontend where its API lives when mounted
+	base := fmt.Sprintf(
+		"<head><script>window.PIXLET_APP_BASE = \"%s\";</script>",
+		template.JSEscapeString(b.basePath),
+	)
+	index := strings.Replace(string(dist.Index), "<head>", base, 1)
+	if b.staticPath != "" && b.staticPath != "/static" {
+		index = strings.ReplaceAll(index, `"/static/`, `"`+b.staticPath+"/")
+	}
+	w.Write([]byte(index))
 }
 
 func (b *Browser) oldRootHandler(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,116 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"tidbyt.dev/pixlet/configstore"
+)
+
+// configStore holds the config values entered in the browser. When it
+// has a path or a store, values are written there on every change, so
+// that they survive restarts.
+type configStore struct {
+	mu     sync.Mutex
+	path   string
+	values map[string]string
+
+	store configstore.Store
+	key   configstore.Key
+}
+
+// useStore reads the values kept in store under key, and keeps values
+// there from now on.
+func (c *configStore) useStore(store configstore.Store, key configstore.Key) error {
+	values, err := store.Get(context.Background(), key)
+	if errors.Is(err, configstore.ErrNotFound) {
+		values = map[string]string{}
+	} else if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.store = store
+	c.key = key
+	c.values = values
+	return nil
+}
+
+// load reads the values saved at path. A missing file is not an error,
+// it just means nothing was saved yet.
+func (c *configStore) load(path string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.path = path
+	c.values = map[string]string{}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading config %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(data, &c.values); err != nil {
+		return fmt.Errorf("parsing config %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func (c *configStore) get() map[string]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	values := make(map[string]string, len(c.values))
+	for k, v := range c.values {
+		values[k] = v
+	}
+	return values
+}
+
+// setValue sets the value of one field, keeping the others.
+func (c *configStore) setValue(id, value string) error {
+	values := c.get()
+	values[id] = value
+	return c.set(values)
+}
+
+func (c *configStore) set(values map[string]string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.values = values
+	if c.store != nil {
+		return c.store.Put(context.Background(), c.key, values)
+	}
+	if c.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return fmt.Errorf("creating config directory: %w", err)
+	}
+
+	// config can hold secrets, so keep it private
+	if err := os.WriteFile(c.path, data, 0600); err != nil {
+		return fmt.Errorf("writing config %s: %w", c.path, err)
+	}
+
+	return nil
+}
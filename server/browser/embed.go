@@ -0,0 +1,64 @@
+package browser
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"tidbyt.dev/pixlet/server/loader"
+	"tidbyt.dev/pixlet/server/simulator"
+)
+
+// embedHandler serves a page showing nothing but the app in the device
+// simulator, for embedding in other sites with an iframe. Query
+// parameters are passed on to the frames endpoint.
+func (b *Browser) embedHandler(w http.ResponseWriter, r *http.Request) {
+	frames := b.basePath + "/api/v1/frames"
+	if r.URL.RawQuery != "" {
+		frames += "?" + r.URL.RawQuery
+	}
+
+	page := simulator.Page{
+		Title:    b.title,
+		Script:   b.basePath + "/simulator.js",
+		Frames:   frames,
+		Controls: true,
+	}
+	if b.watch {
+		page.Watch = b.basePath + "/api/v1/ws"
+	}
+
+	if err := simulator.WritePage(w, page); err != nil {
+		b.log().Warn("writing embed page", "error", err)
+	}
+}
+
+// framesHandler renders the app for the device simulator, with the
+// config saved for it overridden by query parameters. `_width` and
+// `_height` pick the size, as with the render API.
+func (b *Browser) framesHandler(w http.ResponseWriter, r *http.Request) {
+	config := b.config.get()
+	for k, vals := range r.URL.Query() {
+		config[k] = vals[0]
+	}
+
+	frames, width, height, err := b.loader.Frames(r.Context(), config)
+	if errors.Is(err, loader.ErrBadSize) {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil && !errors.Is(err, loader.ErrSkipped) {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	data, err := simulator.NewFrames(frames, width, height)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	json.NewEncoder(w).Encode(data)
+}
@@ -0,0 +1,322 @@
+package browser
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"tidbyt.dev/pixlet/schema"
+)
+
+// loginTimeout is how long users have to log in with a provider.
+const loginTimeout = 10 * time.Minute
+
+// oauthLogin is an OAuth2 login started in the browser, waiting for the
+// provider to redirect back.
+type oauthLogin struct {
+	browser     *Browser
+	field       schema.SchemaField
+	scopes      []string
+	redirectURI string
+	verifier    string
+	expires     time.Time
+}
+
+// pendingLogins are the logins in progress, by state. They're shared by
+// every browser, since providers redirect to one callback URL for the
+// whole server.
+var pendingLogins = struct {
+	sync.Mutex
+	logins map[string]*oauthLogin
+}{logins: map[string]*oauthLogin{}}
+
+// addLogin keeps login until the provider redirects back, and returns
+// the state identifying it.
+func addLogin(login *oauthLogin) string {
+	state := randomString()
+
+	pendingLogins.Lock()
+	defer pendingLogins.Unlock()
+
+	now := time.Now()
+	for s, l := range pendingLogins.logins {
+		if now.After(l.expires) {
+			delete(pendingLogins.logins, s)
+		}
+	}
+
+	login.expires = now.Add(loginTimeout)
+	pendingLogins.logins[state] = login
+	return state
+}
+
+// takeLogin returns the login identified by state, if it hasn't expired,
+// so that it can be completed only once.
+func takeLogin(state string) *oauthLogin {
+	pendingLogins.Lock()
+	defer pendingLogins.Unlock()
+
+	login, ok := pendingLogins.logins[state]
+	if !ok {
+		return nil
+	}
+	delete(pendingLogins.logins, state)
+
+	if time.Now().After(login.expires) {
+		return nil
+	}
+	return login
+}
+
+func randomString() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// SetOAuthCallbackPath tells the browser where providers redirect to
+// after OAuth2 logins, if not /oauth-callback, like when it's mounted
+// in another service. Requests there must be passed to
+// HandleOAuthCallback.
+func (b *Browser) SetOAuthCallbackPath(path string) {
+	b.oauthCallbackPath = path
+}
+
+// oauthField returns the OAuth2 field id, or writes an error if the app
+// has none.
+func (b *Browser) oauthField(w http.ResponseWriter, id string) (schema.SchemaField, bool) {
+	field, ok := b.loader.Field(id)
+	if !ok || field.Type != "oauth2" {
+		writeJSONError(w, http.StatusNotFound, fmt.Sprintf("no OAuth2 field %s", id))
+		return field, false
+	}
+	return field, true
+}
+
+// oauthLoginHandler starts logging in with the provider of an OAuth2
+// field, by redirecting to its authorization endpoint. With more=true,
+// the field's optional scopes are asked for too.
+func (b *Browser) oauthLoginHandler(w http.ResponseWriter, r *http.Request) {
+	field, ok := b.oauthField(w, mux.Vars(r)["field"])
+	if !ok {
+		return
+	}
+
+	authURL, err := url.Parse(field.AuthorizationEndpoint)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, fmt.Sprintf("parsing authorization endpoint: %v", err))
+		return
+	}
+
+	login := &oauthLogin{
+		browser:     b,
+		field:       field,
+		scopes:      field.Scopes,
+		redirectURI: b.oauthRedirectURI(r),
+	}
+	if r.URL.Query().Get("more") == "true" {
+		login.scopes = append(append([]string{}, field.Scopes...), field.OptionalScopes...)
+	}
+
+	q := authURL.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", field.ClientID)
+	q.Set("redirect_uri", login.redirectURI)
+	q.Set("scope", strings.Join(login.scopes, " "))
+	if field.PKCE {
+		login.verifier = randomString()
+		challenge := sha256.Sum256([]byte(login.verifier))
+		q.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+		q.Set("code_challenge_method", "S256")
+	}
+	q.Set("state", addLogin(login))
+	authURL.RawQuery = q.Encode()
+
+	http.Redirect(w, r, authURL.String(), http.StatusFound)
+}
+
+// oauthRedirectURI returns the callback URL providers redirect to, on
+// the host r was sent to.
+func (b *Browser) oauthRedirectURI(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+
+	path := b.oauthCallbackPath
+	if path == "" {
+		path = "/oauth-callback"
+	}
+	return scheme + "://" + r.Host + path
+}
+
+// HandleOAuthCallback completes an OAuth2 login started in any browser,
+// when the provider redirects back to it. The app's handler exchanges
+// the code for a token, which is saved as the value of the field, and
+// the page tells the preview it's done.
+func HandleOAuthCallback(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	login := takeLogin(q.Get("state"))
+	if login == nil {
+		writeLoginResult(w, http.StatusBadRequest, "", "", "login expired or unknown, try again")
+		return
+	}
+
+	b := login.browser
+	id := login.field.ID
+	if msg := q.Get("error"); msg != "" {
+		if desc := q.Get("error_description"); desc != "" {
+			msg += ": " + desc
+		}
+		writeLoginResult(w, http.StatusBadRequest, b.basePath, id, "provider refused login: "+msg)
+		return
+	}
+
+	params := map[string]interface{}{
+		"code":         q.Get("code"),
+		"grant_type":   "authorization_code",
+		"client_id":    login.field.ClientID,
+		"redirect_uri": login.redirectURI,
+		"scopes":       login.scopes,
+	}
+	if login.verifier != "" {
+		params["code_verifier"] = login.verifier
+	}
+
+	value, err := b.callOAuthHandler(r, login.field.Handler, params)
+	if err != nil {
+		b.log().Warn("completing OAuth2 login", "field", id, "error", err)
+		writeLoginResult(w, http.StatusBadGateway, b.basePath, id, err.Error())
+		return
+	}
+
+	if err := b.config.setValue(id, value); err != nil {
+		b.log().Error("saving config", "error", err)
+		writeLoginResult(w, http.StatusInternalServerError, b.basePath, id, "saving config")
+		return
+	}
+
+	writeLoginResult(w, http.StatusOK, b.basePath, id, "")
+}
+
+// oauthRefreshHandler gets a new token with the refresh handler of an
+// OAuth2 field, and saves it as the field's value.
+func (b *Browser) oauthRefreshHandler(w http.ResponseWriter, r *http.Request) {
+	field, ok := b.oauthField(w, mux.Vars(r)["field"])
+	if !ok {
+		return
+	}
+	if field.RefreshHandler == "" {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("%s has no refresh handler", field.ID))
+		return
+	}
+
+	current, ok := b.config.get()[field.ID]
+	if !ok {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("not logged in with %s", field.ID))
+		return
+	}
+
+	value, err := b.callOAuthHandler(r, field.RefreshHandler, map[string]interface{}{
+		"value":      current,
+		"grant_type": "refresh_token",
+		"client_id":  field.ClientID,
+	})
+	if err != nil {
+		writeJSONError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	if err := b.config.setValue(field.ID, value); err != nil {
+		b.log().Error("saving config", "error", err)
+		writeJSONError(w, http.StatusInternalServerError, "saving config")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"value": value})
+}
+
+// oauthLogoutHandler forgets the token of an OAuth2 field.
+func (b *Browser) oauthLogoutHandler(w http.ResponseWriter, r *http.Request) {
+	field, ok := b.oauthField(w, mux.Vars(r)["field"])
+	if !ok {
+		return
+	}
+
+	values := b.config.get()
+	delete(values, field.ID)
+	if err := b.config.set(values); err != nil {
+		b.log().Error("saving config", "error", err)
+		writeJSONError(w, http.StatusInternalServerError, "saving config")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// callOAuthHandler calls handler with params, as JSON, and the config
+// saved for the app, returning the field's new value.
+func (b *Browser) callOAuthHandler(r *http.Request, handler string, params map[string]interface{}) (string, error) {
+	param, err := json.Marshal(params)
+	if err != nil {
+		return "", err
+	}
+
+	value, err := b.loader.CallSchemaHandler(r.Context(), handler, string(param), b.config.get())
+	if err != nil {
+		return "", fmt.Errorf("calling %s: %w", handler, err)
+	}
+	if value == "" {
+		return "", fmt.Errorf("%s returned no token", handler)
+	}
+	return value, nil
+}
+
+var loginResultTmpl = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Pixlet</title></head>
+<body style="font-family: sans-serif">
+<p>{{if .Err}}Login failed: {{.Err}}{{else}}Logged in. You can close this window.{{end}}</p>
+{{if .Back}}<p><a href="{{.Back}}">Back to the app</a></p>{{end}}
+<script>
+if (window.opener) {
+	window.opener.postMessage({message: "pixlet-oauth", field: {{.Field}}, error: {{.Err}}}, window.location.origin);
+	window.close();
+}
+</script>
+</body>
+</html>
+`))
+
+// writeLoginResult shows how a login went, and tells the preview that
+// opened the login window, if any.
+func writeLoginResult(w http.ResponseWriter, status int, basePath, field, errMsg string) {
+	back := ""
+	if field != "" {
+		back = basePath + "/"
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	w.WriteHeader(status)
+	loginResultTmpl.Execute(w, struct {
+		Field string
+		Err   string
+		Back  string
+	}{field, errMsg, back})
+}
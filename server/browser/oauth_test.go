@@ -0,0 +1,77 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// expireLogin makes the login identified by state look like it was
+// started longer than loginTimeout ago.
+func expireLogin(state string) {
+	pendingLogins.Lock()
+	defer pendingLogins.Unlock()
+	pendingLogins.logins[state].expires = time.Now().Add(-time.Second)
+}
+
+func pendingLogin(state string) bool {
+	pendingLogins.Lock()
+	defer pendingLogins.Unlock()
+	_, ok := pendingLogins.logins[state]
+	return ok
+}
+
+func TestLoginSingleUse(t *testing.T) {
+	login := &oauthLogin{redirectURI: "http://localhost/oauth-callback"}
+	state := addLogin(login)
+
+	assert.Same(t, login, takeLogin(state))
+	assert.Nil(t, takeLogin(state))
+}
+
+func TestLoginStates(t *testing.T) {
+	a := addLogin(&oauthLogin{})
+	b := addLogin(&oauthLogin{})
+	assert.NotEqual(t, a, b)
+	assert.Len(t, a, 43)
+
+	assert.Nil(t, takeLogin(""))
+	assert.Nil(t, takeLogin("unknown"))
+	assert.NotNil(t, takeLogin(a))
+	assert.NotNil(t, takeLogin(b))
+}
+
+func TestLoginExpiry(t *testing.T) {
+	state := addLogin(&oauthLogin{})
+	expireLogin(state)
+	assert.Nil(t, takeLogin(state))
+
+	// expired logins are forgotten when others start, even if the
+	// provider never redirects back
+	stale := addLogin(&oauthLogin{})
+	expireLogin(stale)
+	fresh := addLogin(&oauthLogin{})
+	assert.False(t, pendingLogin(stale))
+	assert.True(t, pendingLogin(fresh))
+	assert.NotNil(t, takeLogin(fresh))
+}
+
+func TestOAuthCallbackUnknownState(t *testing.T) {
+	state := addLogin(&oauthLogin{})
+	expireLogin(state)
+
+	for _, target := range []string{
+		"/oauth-callback?code=code",
+		"/oauth-callback?code=code&state=unknown",
+		"/oauth-callback?code=code&state=" + state,
+	} {
+		w := httptest.NewRecorder()
+		HandleOAuthCallback(w, httptest.NewRequest("GET", target, nil))
+		require.Equal(t, http.StatusBadRequest, w.Code, target)
+		assert.Contains(t, w.Body.String(), "login expired or unknown")
+	}
+}
@@ -1,24 +1,17 @@
 package browser
 
 import (
-	"bytes"
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
-)
 
-const (
-	TidbytAPIPush = "https://api.tidbyt.com/v0/devices/%s/push"
+	"tidbyt.dev/pixlet/api"
 )
 
-type TidbytPushJSON struct {
-	DeviceID       string `json:"deviceID"`
-	Image          string `json:"image"`
-	InstallationID string `json:"installationID"`
-	Background     bool   `json:"background"`
-}
-
 func (b *Browser) pushHandler(w http.ResponseWriter, r *http.Request) {
 	var (
 		deviceID       string
@@ -54,54 +47,41 @@ func (b *Browser) pushHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	img, err := b.loader.LoadApplet(config)
-
-	payload, err := json.Marshal(
-		TidbytPushJSON{
-			DeviceID:       deviceID,
-			Image:          img,
-			InstallationID: installationID,
-			Background:     background,
-		},
-	)
-
 	if err != nil {
 		w.WriteHeader(500)
 		fmt.Fprintln(w, err)
 		return
 	}
 
-	client := &http.Client{}
-	req, err := http.NewRequest(
-		"POST",
-		fmt.Sprintf(TidbytAPIPush, deviceID),
-		bytes.NewReader(payload),
-	)
-
-	if err != nil {
-		w.WriteHeader(500)
-		fmt.Fprintln(w, err)
-		return
+	push := &api.Push{
+		DeviceID:       deviceID,
+		InstallationID: installationID,
+		Background:     background,
 	}
-
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", apiToken))
-
-	resp, err := client.Do(req)
-	if err != nil {
-		w.WriteHeader(500)
+	if err := pushImage(r.Context(), apiToken, push, img); err != nil {
+		status := 500
+		var apiErr *api.Error
+		if errors.As(err, &apiErr) {
+			b.log().Error("Tidbyt API returned an error", "status", apiErr.Status, "body", apiErr.Body)
+			status = apiErr.StatusCode
+		}
+		w.WriteHeader(status)
 		fmt.Fprintln(w, err)
 		return
 	}
 
-	if resp.StatusCode != 200 {
-		fmt.Printf("Tidbyt API returned status %s\n", resp.Status)
-		w.WriteHeader(resp.StatusCode)
-		fmt.Fprintln(w, err)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{}"))
+}
 
-		body, _ := ioutil.ReadAll(resp.Body)
-		fmt.Println(string(body))
-		return
+// pushImage pushes an image, base64 encoded as the loader returns it,
+// to a device through the Tidbyt API.
+func pushImage(ctx context.Context, apiToken string, push *api.Push, img string) error {
+	data, err := base64.StdEncoding.DecodeString(img)
+	if err != nil {
+		return fmt.Errorf("decoding image: %w", err)
 	}
+	push.Image = data
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Write([]byte("{}"))
+	return api.NewClient(apiToken).Push(ctx, push)
 }
@@ -0,0 +1,105 @@
+package browser
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"tidbyt.dev/pixlet/encode"
+	"tidbyt.dev/pixlet/server/loader"
+)
+
+// renderRequest is the body of a POST to the render API. Format and
+// Frame are optional.
+type renderRequest struct {
+	Config map[string]string `json:"config"`
+	Format string            `json:"format"`
+	Frame  string            `json:"frame"`
+}
+
+// appInfo describes an app for the apps endpoint.
+type appInfo struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+func writeJSONError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
+
+// renderHandler renders the app with the given config. GET requests
+// take config as query parameters, with `_frame` reserved for picking
+// the frame of a PNG, and `_width` and `_height` for the size. POST requests take a renderRequest. The format
+// comes from the path extension, the request body, or the Accept
+// header, in that order.
+func (b *Browser) renderHandler(w http.ResponseWriter, r *http.Request) {
+	req := &renderRequest{Config: map[string]string{}}
+	if r.Method == "POST" {
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("parsing request: %v", err))
+			return
+		}
+	} else {
+		for k, vals := range r.URL.Query() {
+			if k == "_frame" {
+				req.Frame = vals[0]
+			} else {
+				req.Config[k] = vals[0]
+			}
+		}
+	}
+
+	def := encode.FormatWebP
+	if b.serveGif {
+		def = encode.FormatGIF
+	}
+
+	name := mux.Vars(r)["format"]
+	if name == "" {
+		name = req.Format
+	}
+	format := encode.NegotiateFormat(r.Header.Get("Accept"), def)
+	if name != "" {
+		var err error
+		if format, err = encode.ParseFormat(name); err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	} else if format == "" {
+		writeJSONError(w, http.StatusNotAcceptable, "can only render image/webp, image/gif, image/png or image/bmp")
+		return
+	}
+
+	frame, err := encode.ParseFrameIndex(req.Frame)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	img, err := b.loader.Render(r.Context(), req.Config, string(format), frame)
+	if errors.Is(err, loader.ErrSkipped) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if errors.Is(err, loader.ErrBadSize) {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Vary", "Accept")
+	w.Write(img)
+}
+
+func (b *Browser) appsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode([]appInfo{{Name: b.title, Path: b.basePath}})
+}
@@ -3,11 +3,26 @@
 package browser
 
 import (
-	"log"
 	"net/http"
+
+	"tidbyt.dev/pixlet/metrics"
 )
 
 func (b *Browser) serveHTTP() error {
-	log.Printf("listening at http://%s\n", b.addr)
-	return http.ListenAndServe(b.addr, b.r)
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	mux.Handle("/", b.r)
+
+	var h http.Handler = mux
+	if b.auth != nil {
+		h = b.auth.Wrap(h)
+	}
+
+	if b.tlsCert != "" {
+		b.log().Info("listening", "url", "https://"+b.addr)
+		return http.ListenAndServeTLS(b.addr, b.tlsCert, b.tlsKey, h)
+	}
+
+	b.log().Info("listening", "url", "http://"+b.addr)
+	return http.ListenAndServe(b.addr, h)
 }
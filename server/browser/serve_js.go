@@ -0,0 +1,9 @@
+//go:build js && wasm
+
+package browser
+
+import "fmt"
+
+func (b *Browser) serveHTTP() error {
+	return fmt.Errorf("serving over HTTP is not supported in WASM")
+}
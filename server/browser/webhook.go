@@ -0,0 +1,102 @@
+package browser
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"tidbyt.dev/pixlet/api"
+	"tidbyt.dev/pixlet/server/webhook"
+)
+
+// Config keys reserved for webhooks, to push the image rendered to a
+// device. The app gets the rest of the config.
+const (
+	webhookDeviceID       = "_device_id"
+	webhookAPIToken       = "_api_token"
+	webhookInstallationID = "_installation_id"
+	webhookBackground     = "_background"
+)
+
+// webhookResponse is what the webhook endpoint returns.
+type webhookResponse struct {
+	Image     string `json:"image,omitempty"`
+	ImageType string `json:"image_type,omitempty"`
+	Pushed    bool   `json:"pushed"`
+	Err       string `json:"error,omitempty"`
+}
+
+// webhookHandler re-renders the app right away, with the values in the
+// request merged into the config saved for it, and updates browsers
+// showing it. If the request has a device ID and API token, the image
+// is pushed to the device too. Values come from query parameters and a
+// JSON or form body, so that services like IFTTT and GitHub can call
+// it. If a webhook secret is set, requests must prove they know it.
+func (b *Browser) webhookHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, webhook.MaxBodySize))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("reading body: %v", err))
+		return
+	}
+
+	if b.webhookSecret != "" && !webhook.Authorized(r, body, b.webhookSecret) {
+		writeJSONError(w, http.StatusUnauthorized, "bad or missing secret")
+		return
+	}
+
+	values, err := webhook.Values(r, body)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	config := b.config.get()
+	var push api.Push
+	var apiToken string
+	for k, v := range values {
+		switch k {
+		case webhookDeviceID:
+			push.DeviceID = v
+		case webhookAPIToken:
+			apiToken = v
+		case webhookInstallationID:
+			push.InstallationID = v
+		case webhookBackground:
+			push.Background = v == "true"
+		default:
+			config[k] = v
+		}
+	}
+	if (push.DeviceID == "") != (apiToken == "") {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("%s and %s must be given together", webhookDeviceID, webhookAPIToken))
+		return
+	}
+
+	img, err := b.loader.LoadApplet(config)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	res := webhookResponse{Image: img, ImageType: "webp"}
+	if b.serveGif {
+		res.ImageType = "gif"
+	}
+
+	if push.DeviceID != "" {
+		if b.serveGif {
+			writeJSONError(w, http.StatusBadRequest, "can't push GIFs, serve without --gif to push")
+			return
+		}
+		if err := pushImage(r.Context(), apiToken, &push, img); err != nil {
+			b.log().Error("webhook push", "error", err)
+			writeJSONError(w, http.StatusBadGateway, err.Error())
+			return
+		}
+		res.Pushed = true
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
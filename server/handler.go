@@ -0,0 +1,204 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+	"tidbyt.dev/pixlet/dist"
+	"tidbyt.dev/pixlet/runtime"
+	"tidbyt.dev/pixlet/server/browser"
+	"tidbyt.dev/pixlet/server/loader"
+)
+
+// AppSource is an app to be served by a Handler.
+type AppSource struct {
+	// Name identifies the app in URLs, so it must be usable in a path
+	// as is.
+	Name string
+
+	// FS holds the app's .star files and any assets it loads.
+	FS fs.FS
+
+	// Path is the file or directory FS comes from. If set, and the
+	// handler watches apps, it's watched for changes so that open
+	// previews update.
+	Path string
+}
+
+// HandlerOptions configures a Handler.
+type HandlerOptions struct {
+	// Prefix is the path the handler is mounted at, like /pixlet. The
+	// handler expects requests with the prefix still in their path,
+	// so don't strip it.
+	Prefix string
+
+	// Watch reloads apps for every render, and pushes updates to open
+	// previews when their files change.
+	Watch bool
+
+	// MaxDuration is the longest rendered animations can be, in
+	// milliseconds.
+	MaxDuration int
+
+	// Limits are applied to every app.
+	Limits runtime.Limits
+
+	// ServeGif serves previews as GIF rather than WebP.
+	ServeGif bool
+
+	// WebhookSecret, if set, is what webhook requests must pass, or
+	// sign their body with. See docs/serve_api.md.
+	WebhookSecret string
+
+	// Logger is where logs go, including the apps', tagged with the
+	// name of the app. If nil, the handler logs to slog.Default(), and
+	// what apps print goes to stdout.
+	Logger *slog.Logger
+}
+
+// Handler serves the preview UI, schema endpoints and render API for a
+// set of apps, so that previews can be mounted in another Go service.
+// Each app is served under <prefix>/apps/<name>/, with the same API as
+// pixlet serve (see docs/serve_api.md), and <prefix>/api/v1/apps lists
+// them all. OAuth2 providers redirect to <prefix>/oauth-callback.
+//
+// Call Run to process updates in the background.
+type Handler struct {
+	prefix string
+	watch  bool
+	apps   []*WorkspaceApp
+	mux    *http.ServeMux
+}
+
+// NewHandler loads apps and returns a handler serving them.
+func NewHandler(apps []AppSource, opts HandlerOptions) (*Handler, error) {
+	prefix := strings.TrimSuffix(opts.Prefix, "/")
+	if prefix != "" && !strings.HasPrefix(prefix, "/") {
+		return nil, fmt.Errorf("prefix must start with /: %s", opts.Prefix)
+	}
+
+	h := &Handler{
+		prefix: prefix,
+		watch:  opts.Watch,
+		mux:    http.NewServeMux(),
+	}
+
+	seen := map[string]bool{}
+	for _, src := range apps {
+		if src.Name == "" || url.PathEscape(src.Name) != src.Name {
+			return nil, fmt.Errorf("app name %q can't be used in a URL as is", src.Name)
+		}
+		if seen[src.Name] {
+			return nil, fmt.Errorf("more than one app named %s", src.Name)
+		}
+		seen[src.Name] = true
+
+		fileChanges := make(chan bool, 100)
+		updatesChan := make(chan loader.Update, 100)
+		var logger *slog.Logger
+		if opts.Logger != nil {
+			logger = opts.Logger.With("name", src.Name)
+		}
+
+		l, err := loader.NewLoader(src.FS, opts.Watch, fileChanges, updatesChan, opts.MaxDuration, opts.Limits, opts.ServeGif, logger)
+		if err != nil {
+			return nil, fmt.Errorf("loading %s: %w", src.Name, err)
+		}
+
+		b, err := browser.NewBrowser("", src.Name, opts.Watch, updatesChan, l, opts.ServeGif, logger)
+		if err != nil {
+			return nil, err
+		}
+		b.SetStaticPath(prefix + "/static")
+		b.SetOAuthCallbackPath(prefix + "/oauth-callback")
+		b.SetWebhookSecret(opts.WebhookSecret)
+
+		app := &WorkspaceApp{
+			Name:    src.Name,
+			Path:    prefix + "/apps/" + src.Name,
+			source:  src.Path,
+			handler: b.Mount(prefix + "/apps/" + src.Name),
+			browser: b,
+			loader:  l,
+		}
+		if src.Path != "" {
+			app.watcher = NewWatcher(src.Path, fileChanges)
+		}
+		h.apps = append(h.apps, app)
+	}
+
+	h.mux.Handle(prefix+"/static/", http.StripPrefix(prefix, http.FileServer(http.FS(dist.Static))))
+	h.mux.HandleFunc(prefix+"/apps/", h.appHandler)
+	h.mux.HandleFunc(prefix+"/api/v1/apps", h.appsHandler)
+	h.mux.HandleFunc(prefix+"/oauth-callback", browser.HandleOAuthCallback)
+
+	return h, nil
+}
+
+// Apps lists the apps served, in the order they were given.
+func (h *Handler) Apps() []*WorkspaceApp {
+	return h.apps
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// Run loads the apps and sends updates to open previews, running
+// forever in a blocking fashion.
+func (h *Handler) Run() error {
+	g := errgroup.Group{}
+
+	for _, app := range h.apps {
+		app := app
+		g.Go(app.loader.Run)
+		g.Go(app.browser.RunUpdates)
+		if h.watch {
+			if app.watcher != nil {
+				g.Go(app.watcher.Run)
+			}
+			go app.loader.LoadApplet(make(map[string]string))
+		}
+	}
+
+	return g.Wait()
+}
+
+// appHandler passes requests under <prefix>/apps/<name>/ on to that app.
+func (h *Handler) appHandler(w http.ResponseWriter, r *http.Request) {
+	base := h.prefix + "/apps/"
+	name, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, base), "/")
+	for _, app := range h.apps {
+		if app.Name != name {
+			continue
+		}
+
+		if r.URL.Path == base+name {
+			http.Redirect(w, r, app.Path+"/", http.StatusMovedPermanently)
+			return
+		}
+
+		app.handler.ServeHTTP(w, r)
+		return
+	}
+
+	http.NotFound(w, r)
+}
+
+func (h *Handler) appsHandler(w http.ResponseWriter, r *http.Request) {
+	d, err := json.Marshal(h.apps)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(d)
+}
@@ -0,0 +1,194 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"tidbyt.dev/pixlet/encode"
+	"tidbyt.dev/pixlet/globals"
+	"tidbyt.dev/pixlet/runtime"
+	"tidbyt.dev/pixlet/server/loader"
+)
+
+// Query parameters reserved by ImageHandler. The app gets the rest as
+// config.
+const (
+	imageFormatParam = "_format"
+	imageFrameParam  = "_frame"
+	imageScaleParam  = "_scale"
+)
+
+// defaultMaxScale is how far images can be scaled up, unless
+// ImageOptions says otherwise.
+const defaultMaxScale = 10
+
+// ImageOptions configures an ImageHandler.
+type ImageOptions struct {
+	// Config is what the app is rendered with. Query parameters
+	// override it.
+	Config map[string]string
+
+	// Format is sent when the request doesn't ask for one and accepts
+	// any image. It defaults to WebP.
+	Format encode.Format
+
+	// MaxDuration is the longest animations can be, in milliseconds.
+	// If zero, every frame is encoded.
+	MaxDuration int
+
+	// MaxScale is the largest _scale requests may ask for. It defaults
+	// to 10.
+	MaxScale int
+
+	// Logger is where failed renders are logged. If nil, they go to
+	// slog.Default().
+	Logger *slog.Logger
+}
+
+// ImageHandler renders an app for every request and responds with the
+// image, for showing live app output in dashboards, on e-ink displays
+// or in chat unfurls. See NewImageHandler.
+type ImageHandler struct {
+	app  *runtime.Applet
+	opts ImageOptions
+}
+
+// NewImageHandler returns a handler rendering app. Query parameters are
+// the config, merged over opts.Config, except for these:
+//
+//	_format          webp, gif, png or bmp
+//	_width, _height  the size to render at, from 1 to 512
+//	_scale           how many times to scale up the image, so that each
+//	                 pixel becomes a square
+//	_frame           the frame of a PNG or BMP, as an index or midpoint
+//
+// Without _format, the format is picked from the Accept header. Images
+// get an ETag, so that clients polling for changes can ask with
+// If-None-Match and get 304 Not Modified while the app looks the same.
+func NewImageHandler(app *runtime.Applet, opts ImageOptions) *ImageHandler {
+	if opts.Format == "" {
+		opts.Format = encode.FormatWebP
+	}
+	if opts.MaxScale <= 0 {
+		opts.MaxScale = defaultMaxScale
+	}
+	return &ImageHandler{app: app, opts: opts}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *ImageHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeImageError(w, http.StatusMethodNotAllowed, "only GET and HEAD are supported")
+		return
+	}
+
+	config := make(map[string]string, len(h.opts.Config))
+	for k, v := range h.opts.Config {
+		config[k] = v
+	}
+
+	opts := encode.Options{MaxDuration: h.opts.MaxDuration, Frame: encode.FrameMidpoint}
+	var width, height int
+	var format string
+	for k, vals := range r.URL.Query() {
+		var err error
+		switch k {
+		case imageFormatParam:
+			format = vals[0]
+		case imageFrameParam:
+			opts.Frame, err = encode.ParseFrameIndex(vals[0])
+		case imageScaleParam:
+			opts.Magnify, err = boundedInt(k, vals[0], h.opts.MaxScale)
+		case loader.WidthConfigKey:
+			width, err = boundedInt(k, vals[0], loader.MaxSize)
+		case loader.HeightConfigKey:
+			height, err = boundedInt(k, vals[0], loader.MaxSize)
+		default:
+			config[k] = vals[0]
+		}
+		if err != nil {
+			writeImageError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	opts.Format = encode.NegotiateFormat(r.Header.Get("Accept"), h.opts.Format)
+	if format != "" {
+		var err error
+		if opts.Format, err = encode.ParseFormat(format); err != nil {
+			writeImageError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	} else if opts.Format == "" {
+		writeImageError(w, http.StatusNotAcceptable, "can only render image/webp, image/gif, image/png or image/bmp")
+		return
+	}
+
+	var img []byte
+	opts.Width, opts.Height = width, height
+	ctx := globals.ContextWithSize(r.Context(), width, height)
+	roots, err := h.app.RunWithConfig(ctx, config)
+	if err == nil && len(roots) == 0 {
+		err = loader.ErrSkipped
+	}
+	if err == nil {
+		img, err = encode.EncodeContext(ctx, roots, opts)
+	}
+	if errors.Is(err, loader.ErrSkipped) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if err != nil {
+		h.log().Warn("rendering image", "app", h.app.ID, "error", err)
+		writeImageError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	sum := sha256.Sum256(img)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Vary", "Accept")
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", opts.Format.ContentType())
+	w.Header().Set("Content-Length", strconv.Itoa(len(img)))
+	if r.Method == http.MethodHead {
+		return
+	}
+	w.Write(img)
+}
+
+// log returns the handler's logger.
+func (h *ImageHandler) log() *slog.Logger {
+	if h.opts.Logger == nil {
+		return slog.Default()
+	}
+	return h.opts.Logger
+}
+
+// boundedInt parses the value of a query parameter, which must be a
+// number from 1 to max.
+func boundedInt(name, value string, max int) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 || n > max {
+		return 0, fmt.Errorf("%s must be from 1 to %d, found %q", name, max, value)
+	}
+	return n, nil
+}
+
+func writeImageError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
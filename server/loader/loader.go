@@ -6,12 +6,16 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/fs"
-	"log"
-	"time"
+	"log/slog"
+	"sort"
+	"strconv"
 
 	"tidbyt.dev/pixlet/encode"
+	"tidbyt.dev/pixlet/globals"
+	"tidbyt.dev/pixlet/render"
 	"tidbyt.dev/pixlet/runtime"
 	"tidbyt.dev/pixlet/schema"
 )
@@ -29,10 +33,31 @@ type Loader struct {
 	resultsChan      chan Update
 	maxDuration      int
 	initialLoad      chan bool
-	timeout          int
+	limits           runtime.Limits
 	renderGif		 bool
+	logger           *slog.Logger
 }
 
+// ErrSkipped is returned by Render when the applet returned no roots,
+// which tells a device to skip it in the rotation.
+var ErrSkipped = errors.New("app returned no roots, so it's skipped")
+
+const (
+	// WidthConfigKey and HeightConfigKey are config keys reserved for
+	// rendering the app at a size other than the default, like for
+	// previewing it on a larger display. The app gets the rest of the
+	// config.
+	WidthConfigKey  = "_width"
+	HeightConfigKey = "_height"
+
+	// MaxSize is the largest width or height apps can be rendered at.
+	MaxSize = 512
+)
+
+// ErrBadSize is returned when the size asked for in config isn't a
+// number from 1 to MaxSize.
+var ErrBadSize = fmt.Errorf("size must be from 1 to %d", MaxSize)
+
 type Update struct {
 	Image     string
 	ImageType string
@@ -43,15 +68,17 @@ type Update struct {
 // NewLoader instantiates a new loader structure. The loader will read off of
 // fileChanges channel and write updates to the updatesChan. Updates are base64
 // encoded WebP strings. If watch is enabled, both file changes and on demand
-// requests will send updates over the updatesChan.
+// requests will send updates over the updatesChan. Logs, including the
+// applet's, go to logger, or slog.Default() if it's nil.
 func NewLoader(
 	fs fs.FS,
 	watch bool,
 	fileChanges chan bool,
 	updatesChan chan Update,
 	maxDuration int,
-	timeout int,
+	limits runtime.Limits,
 	renderGif bool,
+	logger *slog.Logger,
 ) (*Loader, error) {
 	l := &Loader{
 		fs:               fs,
@@ -64,8 +91,9 @@ func NewLoader(
 		resultsChan:      make(chan Update, 100),
 		maxDuration:      maxDuration,
 		initialLoad:      make(chan bool),
-		timeout:          timeout,
+		limits:           limits,
 		renderGif:        renderGif,
+		logger:           logger,
 	}
 
 	cache := runtime.NewInMemoryCache()
@@ -73,7 +101,7 @@ func NewLoader(
 	runtime.InitCache(cache)
 
 	if !l.watch {
-		app, err := loadScript("app-id", l.fs)
+		app, err := loadScript("app-id", l.fs, l.limits, l.logger)
 		l.markInitialLoadComplete()
 		if err != nil {
 			return nil, err
@@ -101,7 +129,7 @@ func (l *Loader) Run() error {
 
 			img, err := l.loadApplet(config)
 			if err != nil {
-				log.Printf("error loading applet: %v", err)
+				l.log().Error("loading applet", "error", err)
 				up.Err = err
 			} else {
 				up.Image = img
@@ -114,12 +142,12 @@ func (l *Loader) Run() error {
 			l.updatesChan <- up
 			l.resultsChan <- up
 		case <-l.fileChanges:
-			log.Println("detected updates, reloading")
+			l.log().Info("detected updates, reloading")
 			up := Update{}
 
 			img, err := l.loadApplet(config)
 			if err != nil {
-				log.Printf("error loading applet: %v", err)
+				l.log().Error("loading applet", "error", err)
 				up.Err = err
 			} else {
 				up.Image = img
@@ -135,6 +163,14 @@ func (l *Loader) Run() error {
 	}
 }
 
+// log returns the loader's logger.
+func (l *Loader) log() *slog.Logger {
+	if l.logger == nil {
+		return slog.Default()
+	}
+	return l.logger
+}
+
 // LoadApplet loads the applet on demand.
 //
 // TODO: This method is thread safe, but has a pretty glaring race condition. If
@@ -149,10 +185,16 @@ func (l *Loader) LoadApplet(config map[string]string) (string, error) {
 	return result.Image, result.Err
 }
 
-func (l *Loader) GetSchema() []byte {
+// GetSchema returns the schema of the applet, localized for the given
+// locale. See runtime.Applet.GetSchema.
+func (l *Loader) GetSchema(locale string) []byte {
 	<-l.initialLoad
 
-	s := l.applet.SchemaJSON
+	s, err := l.applet.GetSchema(locale)
+	if err != nil {
+		l.log().Warn("localizing schema", "locale", locale, "error", err)
+		s = l.applet.SchemaJSON
+	}
 	if len(s) > 0 {
 		return s
 	}
@@ -161,14 +203,74 @@ func (l *Loader) GetSchema() []byte {
 	return b
 }
 
-func (l *Loader) CallSchemaHandler(ctx context.Context, handlerName, parameter string) (string, error) {
+// HandlerInfo describes one of the applet's schema handlers.
+type HandlerInfo struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Field       string `json:"field,omitempty"`
+	TakesConfig bool   `json:"takes_config,omitempty"`
+}
+
+// Handlers lists the applet's schema handlers, sorted by name. Field is
+// the ID of the field a handler belongs to, if any.
+func (l *Loader) Handlers() []HandlerInfo {
+	<-l.initialLoad
+
+	sch := l.applet.Schema
+	if sch == nil {
+		return []HandlerInfo{}
+	}
+
+	fields := map[string]string{}
+	for _, f := range sch.Fields {
+		if f.Handler != "" {
+			fields[f.Handler] = f.ID
+		}
+		if f.RefreshHandler != "" {
+			fields[f.RefreshHandler] = f.ID
+		}
+	}
+
+	handlers := make([]HandlerInfo, 0, len(sch.Handlers))
+	for name, h := range sch.Handlers {
+		handlers = append(handlers, HandlerInfo{
+			Name:        name,
+			Type:        h.ReturnType.String(),
+			Field:       fields[name],
+			TakesConfig: h.TakesConfig,
+		})
+	}
+	sort.Slice(handlers, func(i, j int) bool {
+		return handlers[i].Name < handlers[j].Name
+	})
+
+	return handlers
+}
+
+// Field returns the applet's schema field with the given ID, if it has
+// one.
+func (l *Loader) Field(id string) (schema.SchemaField, bool) {
 	<-l.initialLoad
-	return l.applet.CallSchemaHandler(ctx, handlerName, parameter)
+
+	if l.applet.Schema == nil {
+		return schema.SchemaField{}, false
+	}
+	for _, f := range l.applet.Schema.Fields {
+		if f.ID == id {
+			return f, true
+		}
+	}
+	return schema.SchemaField{}, false
+}
+
+func (l *Loader) CallSchemaHandler(ctx context.Context, handlerName, parameter string, config map[string]string) (string, error) {
+	<-l.initialLoad
+	return l.applet.CallSchemaHandlerWithConfig(ctx, handlerName, parameter, config)
 }
 
 func (l *Loader) loadApplet(config map[string]string) (string, error) {
 	if l.watch {
-		app, err := loadScript("app-id", l.fs)
+		app, err := loadScript("app-id", l.fs, l.limits, l.logger)
 		l.markInitialLoadComplete()
 		if err != nil {
 			return "", err
@@ -177,34 +279,138 @@ func (l *Loader) loadApplet(config map[string]string) (string, error) {
 		}
 	}
 
-	ctx, _ := context.WithTimeoutCause(
-		context.Background(),
-		time.Duration(l.timeout)*time.Millisecond,
-		fmt.Errorf("timeout after %dms", l.timeout),
-	)
+	format := "webp"
+	if l.renderGif {
+		format = "gif"
+	}
 
+	config, size, err := splitSize(config)
+	if err != nil {
+		return "", err
+	}
+
+	ctx := size.context(context.Background())
 	roots, err := l.applet.RunWithConfig(ctx, config)
 	if err != nil {
 		return "", fmt.Errorf("error running script: %w", err)
 	}
 
-	screens := encode.ScreensFromRoots(roots)
+	img, err := l.encode(ctx, roots, size, format, encode.FrameMidpoint)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(img), nil
+}
 
+// Render runs the applet with config and returns the result encoded as
+// format, which is one of "webp", "gif", "png" or "bmp". For PNG and
+// BMP, frame picks the frame to encode, as in encode.Screens.EncodePNG. Unlike LoadApplet,
+// this doesn't reload the applet or send out an update.
+//
+// If the applet returns no roots, ErrSkipped is returned.
+func (l *Loader) Render(ctx context.Context, config map[string]string, format string, frame int) ([]byte, error) {
+	<-l.initialLoad
+
+	config, size, err := splitSize(config)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = size.context(ctx)
+	roots, err := l.applet.RunWithConfig(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("error running script: %w", err)
+	}
+	if len(roots) == 0 {
+		return nil, ErrSkipped
+	}
+
+	return l.encode(ctx, roots, size, format, frame)
+}
+
+// Frames runs the applet with config and returns its frames, as shown
+// on a device, and the size they're rendered at. Like Render, it
+// doesn't reload the applet, and returns ErrSkipped if the applet
+// returns no roots. The size is returned whenever config gives a valid
+// one, even if the applet fails.
+func (l *Loader) Frames(ctx context.Context, config map[string]string) (frames []encode.Frame, width, height int, err error) {
+	<-l.initialLoad
+
+	config, size, err := splitSize(config)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	ctx = size.context(ctx)
+	width, height = globals.Size(ctx)
+
+	roots, err := l.applet.RunWithConfig(ctx, config)
+	if err != nil {
+		return nil, width, height, fmt.Errorf("error running script: %w", err)
+	}
+	if len(roots) == 0 {
+		return nil, width, height, ErrSkipped
+	}
+
+	screens := encode.ScreensFromRoots(roots)
+	screens.Width, screens.Height = width, height
 	maxDuration := l.maxDuration
 	if screens.ShowFullAnimation {
 		maxDuration = 0
 	}
 
-	var img []byte
-	if l.renderGif {
-		img, err = screens.EncodeGIF(maxDuration)
-	} else {
-		img, err = screens.EncodeWebP(maxDuration)
+	frames, err = screens.Frames(maxDuration)
+	return frames, width, height, err
+}
+
+// renderSize is the size apps are rendered at, as given in config. A
+// width or height of 0 leaves that one to globals.
+type renderSize struct {
+	width, height int
+}
+
+// context returns a copy of ctx telling apps the size.
+func (s renderSize) context(ctx context.Context) context.Context {
+	return globals.ContextWithSize(ctx, s.width, s.height)
+}
+
+// splitSize returns config less the size keys, and the size they give.
+func splitSize(config map[string]string) (map[string]string, renderSize, error) {
+	var size renderSize
+	rest := make(map[string]string, len(config))
+	for k, v := range config {
+		if k != WidthConfigKey && k != HeightConfigKey {
+			rest[k] = v
+			continue
+		}
+
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > MaxSize {
+			return nil, renderSize{}, fmt.Errorf("%w, found %s=%q", ErrBadSize, k, v)
+		}
+		if k == WidthConfigKey {
+			size.width = n
+		} else {
+			size.height = n
+		}
 	}
+
+	return rest, size, nil
+}
+
+func (l *Loader) encode(ctx context.Context, roots []render.Root, size renderSize, format string, frame int) ([]byte, error) {
+	f, err := encode.ParseFormat(format)
 	if err != nil {
-		return "", fmt.Errorf("error rendering: %w", err)
+		return nil, err
 	}
-	return base64.StdEncoding.EncodeToString(img), nil
+
+	return encode.EncodeContext(ctx, roots, encode.Options{
+		Format:      f,
+		Width:       size.width,
+		Height:      size.height,
+		MaxDuration: l.maxDuration,
+		Frame:       frame,
+	})
 }
 
 func (l *Loader) markInitialLoadComplete() {
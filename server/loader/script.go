@@ -2,10 +2,15 @@ package loader
 
 import (
 	"io/fs"
+	"log/slog"
 
 	"tidbyt.dev/pixlet/runtime"
 )
 
-func loadScript(appID string, fs fs.FS) (*runtime.Applet, error) {
-	return runtime.NewAppletFromFS(appID, fs)
+func loadScript(appID string, fs fs.FS, limits runtime.Limits, logger *slog.Logger) (*runtime.Applet, error) {
+	opts := []runtime.AppletOption{runtime.WithLimits(limits)}
+	if logger != nil {
+		opts = append(opts, runtime.WithLogger(logger))
+	}
+	return runtime.NewAppletFromFS(appID, fs, opts...)
 }
@@ -0,0 +1,169 @@
+package server
+
+import (
+	"context"
+	_ "embed"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sync"
+	"time"
+
+	"tidbyt.dev/pixlet/encode"
+	"tidbyt.dev/pixlet/server/loader"
+)
+
+//go:embed rotation.html
+var rotationHTML string
+
+// RotationState is what the rotation is showing.
+type RotationState struct {
+	// Seq goes up every time the rotation moves on, so that clients
+	// can tell when to swap the image.
+	Seq       int    `json:"seq"`
+	App       string `json:"app,omitempty"`
+	Path      string `json:"path,omitempty"`
+	Image     string `json:"image,omitempty"`
+	ImageType string `json:"image_type"`
+	DwellMS   int64  `json:"dwell_ms"`
+
+	// Skipped lists the apps passed over since the last app was shown,
+	// with the reason why.
+	Skipped []RotationSkip `json:"skipped"`
+}
+
+// RotationSkip is an app the rotation passed over.
+type RotationSkip struct {
+	App    string `json:"app"`
+	Reason string `json:"reason"`
+}
+
+// rotation cycles through the apps of a workspace like a device does:
+// each app is rendered with the config entered for it, and shown for the
+// dwell time. Apps that return no roots are skipped, and so are apps
+// that fail to render.
+type rotation struct {
+	ws        *Workspace
+	dwell     time.Duration
+	imageType string
+	tmpl      *template.Template
+	next      chan struct{}
+
+	mu    sync.Mutex
+	state RotationState
+}
+
+func newRotation(ws *Workspace, dwell time.Duration) (*rotation, error) {
+	tmpl, err := template.New("rotation").Parse(rotationHTML)
+	if err != nil {
+		return nil, err
+	}
+
+	imageType := "webp"
+	if ws.serveGif {
+		imageType = "gif"
+	}
+
+	return &rotation{
+		ws:        ws,
+		dwell:     dwell,
+		imageType: imageType,
+		tmpl:      tmpl,
+		next:      make(chan struct{}, 1),
+		state: RotationState{
+			ImageType: imageType,
+			DwellMS:   dwell.Milliseconds(),
+			Skipped:   []RotationSkip{},
+		},
+	}, nil
+}
+
+// Run moves through the rotation forever.
+func (r *rotation) Run() error {
+	skipped := []RotationSkip{}
+
+	for i := 0; ; i = (i + 1) % len(r.ws.apps) {
+		app := r.ws.apps[i]
+
+		img, err := app.loader.Render(context.Background(), app.browser.Config(), r.imageType, encode.FrameMidpoint)
+		if err != nil {
+			reason := err.Error()
+			if errors.Is(err, loader.ErrSkipped) {
+				reason = "returned no roots"
+			} else {
+				r.ws.log().Warn("rotation: skipping app", "name", app.Name, "error", err)
+			}
+			skipped = append(skipped, RotationSkip{App: app.Name, Reason: reason})
+
+			// when every app is skipped, wait before going around
+			// again instead of spinning
+			if len(skipped) < len(r.ws.apps) {
+				continue
+			}
+			r.show(RotationState{Skipped: skipped})
+		} else {
+			r.show(RotationState{
+				App:     app.Name,
+				Path:    app.Path,
+				Image:   base64.StdEncoding.EncodeToString(img),
+				Skipped: skipped,
+			})
+		}
+		skipped = []RotationSkip{}
+
+		select {
+		case <-time.After(r.dwell):
+		case <-r.next:
+		}
+	}
+}
+
+func (r *rotation) show(state RotationState) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state.Seq = r.state.Seq + 1
+	state.ImageType = r.imageType
+	state.DwellMS = r.dwell.Milliseconds()
+	r.state = state
+}
+
+func (r *rotation) pageHandler(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+	r.tmpl.Execute(w, struct {
+		Title string
+	}{r.ws.title})
+}
+
+func (r *rotation) stateHandler(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	state := r.state
+	r.mu.Unlock()
+
+	d, err := json.Marshal(state)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(d)
+}
+
+// nextHandler moves on to the next app right away.
+func (r *rotation) nextHandler(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, fmt.Sprintf("method %s not allowed", req.Method), http.StatusMethodNotAllowed)
+		return
+	}
+
+	select {
+	case r.next <- struct{}{}:
+	default:
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,322 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: server/rpc/pixlet.proto
+
+package rpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Pixlet_LoadApplet_FullMethodName   = "/pixlet.v1.Pixlet/LoadApplet"
+	Pixlet_UnloadApplet_FullMethodName = "/pixlet.v1.Pixlet/UnloadApplet"
+	Pixlet_Render_FullMethodName       = "/pixlet.v1.Pixlet/Render"
+	Pixlet_RenderFrames_FullMethodName = "/pixlet.v1.Pixlet/RenderFrames"
+	Pixlet_GetSchema_FullMethodName    = "/pixlet.v1.Pixlet/GetSchema"
+	Pixlet_CallHandler_FullMethodName  = "/pixlet.v1.Pixlet/CallHandler"
+)
+
+// PixletClient is the client API for Pixlet service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type PixletClient interface {
+	LoadApplet(ctx context.Context, in *LoadAppletRequest, opts ...grpc.CallOption) (*LoadAppletResponse, error)
+	UnloadApplet(ctx context.Context, in *UnloadAppletRequest, opts ...grpc.CallOption) (*UnloadAppletResponse, error)
+	Render(ctx context.Context, in *RenderRequest, opts ...grpc.CallOption) (*RenderResponse, error)
+	RenderFrames(ctx context.Context, in *RenderRequest, opts ...grpc.CallOption) (Pixlet_RenderFramesClient, error)
+	GetSchema(ctx context.Context, in *GetSchemaRequest, opts ...grpc.CallOption) (*GetSchemaResponse, error)
+	CallHandler(ctx context.Context, in *CallHandlerRequest, opts ...grpc.CallOption) (*CallHandlerResponse, error)
+}
+
+type pixletClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPixletClient(cc grpc.ClientConnInterface) PixletClient {
+	return &pixletClient{cc}
+}
+
+func (c *pixletClient) LoadApplet(ctx context.Context, in *LoadAppletRequest, opts ...grpc.CallOption) (*LoadAppletResponse, error) {
+	out := new(LoadAppletResponse)
+	err := c.cc.Invoke(ctx, Pixlet_LoadApplet_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pixletClient) UnloadApplet(ctx context.Context, in *UnloadAppletRequest, opts ...grpc.CallOption) (*UnloadAppletResponse, error) {
+	out := new(UnloadAppletResponse)
+	err := c.cc.Invoke(ctx, Pixlet_UnloadApplet_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pixletClient) Render(ctx context.Context, in *RenderRequest, opts ...grpc.CallOption) (*RenderResponse, error) {
+	out := new(RenderResponse)
+	err := c.cc.Invoke(ctx, Pixlet_Render_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pixletClient) RenderFrames(ctx context.Context, in *RenderRequest, opts ...grpc.CallOption) (Pixlet_RenderFramesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Pixlet_ServiceDesc.Streams[0], Pixlet_RenderFrames_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &pixletRenderFramesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Pixlet_RenderFramesClient interface {
+	Recv() (*Frame, error)
+	grpc.ClientStream
+}
+
+type pixletRenderFramesClient struct {
+	grpc.ClientStream
+}
+
+func (x *pixletRenderFramesClient) Recv() (*Frame, error) {
+	m := new(Frame)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *pixletClient) GetSchema(ctx context.Context, in *GetSchemaRequest, opts ...grpc.CallOption) (*GetSchemaResponse, error) {
+	out := new(GetSchemaResponse)
+	err := c.cc.Invoke(ctx, Pixlet_GetSchema_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pixletClient) CallHandler(ctx context.Context, in *CallHandlerRequest, opts ...grpc.CallOption) (*CallHandlerResponse, error) {
+	out := new(CallHandlerResponse)
+	err := c.cc.Invoke(ctx, Pixlet_CallHandler_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PixletServer is the server API for Pixlet service.
+// All implementations must embed UnimplementedPixletServer
+// for forward compatibility
+type PixletServer interface {
+	LoadApplet(context.Context, *LoadAppletRequest) (*LoadAppletResponse, error)
+	UnloadApplet(context.Context, *UnloadAppletRequest) (*UnloadAppletResponse, error)
+	Render(context.Context, *RenderRequest) (*RenderResponse, error)
+	RenderFrames(*RenderRequest, Pixlet_RenderFramesServer) error
+	GetSchema(context.Context, *GetSchemaRequest) (*GetSchemaResponse, error)
+	CallHandler(context.Context, *CallHandlerRequest) (*CallHandlerResponse, error)
+	mustEmbedUnimplementedPixletServer()
+}
+
+// UnimplementedPixletServer must be embedded to have forward compatible implementations.
+type UnimplementedPixletServer struct {
+}
+
+func (UnimplementedPixletServer) LoadApplet(context.Context, *LoadAppletRequest) (*LoadAppletResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LoadApplet not implemented")
+}
+func (UnimplementedPixletServer) UnloadApplet(context.Context, *UnloadAppletRequest) (*UnloadAppletResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnloadApplet not implemented")
+}
+func (UnimplementedPixletServer) Render(context.Context, *RenderRequest) (*RenderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Render not implemented")
+}
+func (UnimplementedPixletServer) RenderFrames(*RenderRequest, Pixlet_RenderFramesServer) error {
+	return status.Errorf(codes.Unimplemented, "method RenderFrames not implemented")
+}
+func (UnimplementedPixletServer) GetSchema(context.Context, *GetSchemaRequest) (*GetSchemaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSchema not implemented")
+}
+func (UnimplementedPixletServer) CallHandler(context.Context, *CallHandlerRequest) (*CallHandlerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CallHandler not implemented")
+}
+func (UnimplementedPixletServer) mustEmbedUnimplementedPixletServer() {}
+
+// UnsafePixletServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PixletServer will
+// result in compilation errors.
+type UnsafePixletServer interface {
+	mustEmbedUnimplementedPixletServer()
+}
+
+func RegisterPixletServer(s grpc.ServiceRegistrar, srv PixletServer) {
+	s.RegisterService(&Pixlet_ServiceDesc, srv)
+}
+
+func _Pixlet_LoadApplet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoadAppletRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PixletServer).LoadApplet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Pixlet_LoadApplet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PixletServer).LoadApplet(ctx, req.(*LoadAppletRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pixlet_UnloadApplet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnloadAppletRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PixletServer).UnloadApplet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Pixlet_UnloadApplet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PixletServer).UnloadApplet(ctx, req.(*UnloadAppletRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pixlet_Render_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PixletServer).Render(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Pixlet_Render_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PixletServer).Render(ctx, req.(*RenderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pixlet_RenderFrames_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RenderRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PixletServer).RenderFrames(m, &pixletRenderFramesServer{stream})
+}
+
+type Pixlet_RenderFramesServer interface {
+	Send(*Frame) error
+	grpc.ServerStream
+}
+
+type pixletRenderFramesServer struct {
+	grpc.ServerStream
+}
+
+func (x *pixletRenderFramesServer) Send(m *Frame) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Pixlet_GetSchema_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSchemaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PixletServer).GetSchema(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Pixlet_GetSchema_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PixletServer).GetSchema(ctx, req.(*GetSchemaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Pixlet_CallHandler_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CallHandlerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PixletServer).CallHandler(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Pixlet_CallHandler_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PixletServer).CallHandler(ctx, req.(*CallHandlerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Pixlet_ServiceDesc is the grpc.ServiceDesc for Pixlet service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Pixlet_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pixlet.v1.Pixlet",
+	HandlerType: (*PixletServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "LoadApplet",
+			Handler:    _Pixlet_LoadApplet_Handler,
+		},
+		{
+			MethodName: "UnloadApplet",
+			Handler:    _Pixlet_UnloadApplet_Handler,
+		},
+		{
+			MethodName: "Render",
+			Handler:    _Pixlet_Render_Handler,
+		},
+		{
+			MethodName: "GetSchema",
+			Handler:    _Pixlet_GetSchema_Handler,
+		},
+		{
+			MethodName: "CallHandler",
+			Handler:    _Pixlet_CallHandler_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "RenderFrames",
+			Handler:       _Pixlet_RenderFrames_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "server/rpc/pixlet.proto",
+}
@@ -0,0 +1,246 @@
+// Package rpc serves apps over gRPC, so that backends not written in Go
+// can render them. The service is defined in pixlet.proto.
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image/png"
+	"sync"
+	"testing/fstest"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"tidbyt.dev/pixlet/encode"
+	"tidbyt.dev/pixlet/globals"
+	"tidbyt.dev/pixlet/render"
+	"tidbyt.dev/pixlet/runtime"
+)
+
+// MaxSize is the largest width or height apps can be rendered at.
+const MaxSize = 512
+
+var contentTypes = map[encode.Format]string{
+	encode.FormatWebP: "image/webp",
+	encode.FormatGIF:  "image/gif",
+	encode.FormatPNG:  "image/png",
+}
+
+// Server implements the Pixlet service. Apps are kept in memory between
+// requests, by the ID they were loaded with.
+type Server struct {
+	UnimplementedPixletServer
+
+	limits  runtime.Limits
+	mu      sync.RWMutex
+	applets map[string]*runtime.Applet
+}
+
+// NewServer returns a server that runs apps within limits. Like for
+// pixlet serve, runtime.InitHTTP and runtime.InitCache should be called
+// first for apps that use the network or cache.
+func NewServer(limits runtime.Limits) *Server {
+	return &Server{
+		limits:  limits,
+		applets: map[string]*runtime.Applet{},
+	}
+}
+
+func (s *Server) LoadApplet(ctx context.Context, req *LoadAppletRequest) (*LoadAppletResponse, error) {
+	if req.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	fsys := fstest.MapFS{}
+	for path, data := range req.Files {
+		fsys[path] = &fstest.MapFile{Data: data}
+	}
+
+	applet, err := runtime.NewAppletFromFS(req.Id, fsys, runtime.WithLimits(s.limits), runtime.WithPrintDisabled())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "loading applet: %v", err)
+	}
+
+	s.mu.Lock()
+	s.applets[req.Id] = applet
+	s.mu.Unlock()
+
+	return &LoadAppletResponse{HasSchema: applet.Schema != nil}, nil
+}
+
+func (s *Server) UnloadApplet(ctx context.Context, req *UnloadAppletRequest) (*UnloadAppletResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.applets[req.Id]; !ok {
+		return nil, status.Errorf(codes.NotFound, "no applet loaded with id %q", req.Id)
+	}
+	delete(s.applets, req.Id)
+
+	return &UnloadAppletResponse{}, nil
+}
+
+func (s *Server) Render(ctx context.Context, req *RenderRequest) (*RenderResponse, error) {
+	format, err := requestFormat(req.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	var img []byte
+	roots, err := s.run(ctx, req, func(roots []render.Root) error {
+		var err error
+		img, err = encode.EncodeContext(ctx, roots, encode.Options{
+			Format:      format,
+			Width:       int(req.Width),
+			Height:      int(req.Height),
+			MaxDuration: int(req.MaxDurationMs),
+			Frame:       int(req.Frame),
+			Magnify:     int(req.Magnify),
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &RenderResponse{
+		Image:       img,
+		ContentType: contentTypes[format],
+		Skipped:     len(roots) == 0,
+	}
+	if len(roots) > 0 {
+		resp.MaxAgeSeconds = encode.ScreensFromRoots(roots).MaxAge
+	}
+
+	return resp, nil
+}
+
+func (s *Server) RenderFrames(req *RenderRequest, stream Pixlet_RenderFramesServer) error {
+	var frames []encode.Frame
+	_, err := s.run(stream.Context(), req, func(roots []render.Root) error {
+		screens := encode.ScreensFromRoots(roots)
+
+		maxDuration := int(req.MaxDurationMs)
+		if screens.ShowFullAnimation {
+			maxDuration = 0
+		}
+
+		var filters []encode.ImageFilter
+		if req.Magnify > 1 {
+			filters = append(filters, encode.MagnifyFilter(int(req.Magnify)))
+		}
+
+		screens.Width, screens.Height = int(req.Width), int(req.Height)
+
+		var err error
+		frames, err = screens.Frames(maxDuration, filters...)
+		if err != nil {
+			return fmt.Errorf("error rendering: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, f := range frames {
+		buf := &bytes.Buffer{}
+		if err := png.Encode(buf, f.Image); err != nil {
+			return status.Errorf(codes.Internal, "encoding frame %d: %v", i, err)
+		}
+
+		err := stream.Send(&Frame{
+			Index:   int32(i),
+			Png:     buf.Bytes(),
+			DelayMs: int32(f.Delay),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) GetSchema(ctx context.Context, req *GetSchemaRequest) (*GetSchemaResponse, error) {
+	applet, err := s.applet(req.AppletId)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := applet.GetSchema(req.Locale)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "localizing schema: %v", err)
+	}
+
+	return &GetSchemaResponse{SchemaJson: string(schema)}, nil
+}
+
+func (s *Server) CallHandler(ctx context.Context, req *CallHandlerRequest) (*CallHandlerResponse, error) {
+	applet, err := s.applet(req.AppletId)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := applet.CallSchemaHandlerWithConfig(ctx, req.Handler, req.Parameter, req.Config)
+	if err != nil {
+		return nil, status.Errorf(codes.Unknown, "calling handler: %v", err)
+	}
+
+	return &CallHandlerResponse{Result: result}, nil
+}
+
+func (s *Server) applet(id string) (*runtime.Applet, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	applet, ok := s.applets[id]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "no applet loaded with id %q", id)
+	}
+
+	return applet, nil
+}
+
+// run runs the applet asked for by req at the size asked for, and
+// calls f with the roots it returns. f isn't called if the applet
+// returns no roots.
+func (s *Server) run(ctx context.Context, req *RenderRequest, f func([]render.Root) error) ([]render.Root, error) {
+	applet, err := s.applet(req.AppletId)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Width < 0 || req.Width > MaxSize || req.Height < 0 || req.Height > MaxSize {
+		return nil, status.Errorf(codes.InvalidArgument, "width and height must be from 0 to %d", MaxSize)
+	}
+
+	ctx = globals.ContextWithSize(ctx, int(req.Width), int(req.Height))
+	roots, err := applet.RunWithConfig(ctx, req.Config)
+	if err != nil {
+		return nil, status.Errorf(codes.Unknown, "error running script: %v", err)
+	}
+	if len(roots) == 0 {
+		return roots, nil
+	}
+
+	if err := f(roots); err != nil {
+		return roots, status.Error(codes.Internal, err.Error())
+	}
+	return roots, nil
+}
+
+func requestFormat(f Format) (encode.Format, error) {
+	switch f {
+	case Format_FORMAT_UNSPECIFIED, Format_FORMAT_WEBP:
+		return encode.FormatWebP, nil
+	case Format_FORMAT_GIF:
+		return encode.FormatGIF, nil
+	case Format_FORMAT_PNG:
+		return encode.FormatPNG, nil
+	default:
+		return "", status.Errorf(codes.InvalidArgument, "unsupported format: %v", f)
+	}
+}
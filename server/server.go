@@ -1,13 +1,18 @@
 package server
 
 import (
+	"crypto/sha1"
 	"fmt"
 	"io/fs"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"golang.org/x/sync/errgroup"
+	"tidbyt.dev/pixlet/configstore"
+	"tidbyt.dev/pixlet/runtime"
+	"tidbyt.dev/pixlet/server/auth"
 	"tidbyt.dev/pixlet/server/browser"
 	"tidbyt.dev/pixlet/server/loader"
 	"tidbyt.dev/pixlet/tools"
@@ -20,10 +25,12 @@ type Server struct {
 	browser *browser.Browser
 	loader  *loader.Loader
 	watch   bool
+	path    string
 }
 
-// NewServer creates a new server initialized with the applet.
-func NewServer(host string, port int, watch bool, path string, maxDuration int, timeout int, serveGif bool) (*Server, error) {
+// NewServer creates a new server initialized with the applet. Logs,
+// including the applet's, go to logger, or slog.Default() if it's nil.
+func NewServer(host string, port int, watch bool, path string, maxDuration int, limits runtime.Limits, serveGif bool, logger *slog.Logger) (*Server, error) {
 	fileChanges := make(chan bool, 100)
 
 	// check if path exists, and whether it is a directory or a file
@@ -47,13 +54,13 @@ func NewServer(host string, port int, watch bool, path string, maxDuration int,
 	}
 
 	updatesChan := make(chan loader.Update, 100)
-	l, err := loader.NewLoader(fs, watch, fileChanges, updatesChan, maxDuration, timeout, serveGif)
+	l, err := loader.NewLoader(fs, watch, fileChanges, updatesChan, maxDuration, limits, serveGif, logger)
 	if err != nil {
 		return nil, err
 	}
 
 	addr := fmt.Sprintf("%s:%d", host, port)
-	b, err := browser.NewBrowser(addr, filepath.Base(path), watch, updatesChan, l, serveGif)
+	b, err := browser.NewBrowser(addr, filepath.Base(path), watch, updatesChan, l, serveGif, logger)
 	if err != nil {
 		return nil, err
 	}
@@ -63,9 +70,64 @@ func NewServer(host string, port int, watch bool, path string, maxDuration int,
 		browser: b,
 		loader:  l,
 		watch:   watch,
+		path:    path,
 	}, nil
 }
 
+// DefaultConfigPath returns where config entered for the app at path
+// is saved by default. Apps are told apart by their absolute path, so
+// that apps sharing a name don't share config.
+func DefaultConfigPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("finding config directory: %w", err)
+	}
+
+	name := strings.TrimSuffix(filepath.Base(abs), ".star")
+	sum := sha1.Sum([]byte(abs))
+	return filepath.Join(dir, "tidbyt", "serve", fmt.Sprintf("%s-%x.json", name, sum[:4])), nil
+}
+
+// PersistConfig saves the config entered in the browser to path, and
+// restores what an earlier run saved there. If path is empty, the
+// default location for the app is used.
+func (s *Server) PersistConfig(path string) error {
+	if path == "" {
+		var err error
+		if path, err = DefaultConfigPath(s.path); err != nil {
+			return err
+		}
+	}
+
+	return s.browser.PersistConfig(path)
+}
+
+// UseConfigStore keeps the config entered in the browser in store, under
+// the name of the app, and restores what's kept there.
+func (s *Server) UseConfigStore(store configstore.Store) error {
+	return s.browser.UseConfigStore(store, configstore.Key{
+		App:          strings.TrimSuffix(filepath.Base(s.path), ".star"),
+		Installation: configstore.DefaultInstallation,
+	})
+}
+
+// Secure requires users to sign in with a, if it's not nil, and serves
+// HTTPS using certFile and keyFile, if they're not empty.
+func (s *Server) Secure(a *auth.Auth, certFile, keyFile string) {
+	s.browser.Secure(a, certFile, keyFile)
+}
+
+// SetWebhookSecret makes the webhook endpoint require requests to pass
+// secret, or sign their body with it. See docs/serve_api.md.
+func (s *Server) SetWebhookSecret(secret string) {
+	s.browser.SetWebhookSecret(secret)
+}
+
 // Run serves the http server and runs forever in a blocking fashion.
 func (s *Server) Run() error {
 	g := errgroup.Group{}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"tidbyt.dev/pixlet/encode"
+	"tidbyt.dev/pixlet/globals"
+	"tidbyt.dev/pixlet/runtime"
+	"tidbyt.dev/pixlet/server/loader"
+	"tidbyt.dev/pixlet/server/simulator"
+)
+
+// SimulatorOptions configures a SimulatorHandler.
+type SimulatorOptions struct {
+	// Title is the title of the embed page. It defaults to the app's
+	// ID.
+	Title string
+
+	// Config is what the app is rendered with. Query parameters
+	// override it.
+	Config map[string]string
+
+	// MaxDuration is the longest animations can be, in milliseconds.
+	// If zero, every frame is shown.
+	MaxDuration int
+
+	// Refresh reloads the frames on the embed page every this many
+	// seconds, if not zero.
+	Refresh int
+
+	// AllowOrigin is sent as Access-Control-Allow-Origin with the
+	// script and frames, like "*" to let any site embed the simulator
+	// without an iframe.
+	AllowOrigin string
+
+	// Logger is where failed renders are logged. If nil, they go to
+	// slog.Default().
+	Logger *slog.Logger
+}
+
+// SimulatorHandler serves an app in the device simulator, the way the
+// pixlet serve preview shows it, for embedding live previews in other
+// sites. See NewSimulatorHandler.
+type SimulatorHandler struct {
+	app  *runtime.Applet
+	opts SimulatorOptions
+}
+
+// NewSimulatorHandler returns a handler serving app in the device
+// simulator. Mounted at a path ending in a slash, like /clock/, it
+// serves:
+//
+//	/clock/              a page with the simulator, for an iframe
+//	/clock/simulator.js  the <pixlet-simulator> element
+//	/clock/frames        the app's frames, as the simulator loads them
+//
+// Query parameters of the page and frames are config for the app, apart
+// from _width and _height for the size to render at.
+func NewSimulatorHandler(app *runtime.Applet, opts SimulatorOptions) *SimulatorHandler {
+	if opts.Title == "" {
+		opts.Title = app.ID
+	}
+	return &SimulatorHandler{app: app, opts: opts}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *SimulatorHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeImageError(w, http.StatusMethodNotAllowed, "only GET and HEAD are supported")
+		return
+	}
+
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/simulator.js"):
+		h.allowOrigin(w)
+		simulator.ScriptHandler().ServeHTTP(w, r)
+
+	case strings.HasSuffix(r.URL.Path, "/frames"):
+		h.allowOrigin(w)
+		h.framesHandler(w, r)
+
+	case !strings.HasSuffix(r.URL.Path, "/"):
+		// the page loads the rest relative to itself
+		u := *r.URL
+		u.Path += "/"
+		http.Redirect(w, r, u.String(), http.StatusMovedPermanently)
+
+	default:
+		frames := "frames"
+		if r.URL.RawQuery != "" {
+			frames += "?" + r.URL.RawQuery
+		}
+		err := simulator.WritePage(w, simulator.Page{
+			Title:    h.opts.Title,
+			Script:   "simulator.js",
+			Frames:   frames,
+			Controls: true,
+			Refresh:  h.opts.Refresh,
+		})
+		if err != nil {
+			h.log().Warn("writing simulator page", "error", err)
+		}
+	}
+}
+
+func (h *SimulatorHandler) allowOrigin(w http.ResponseWriter) {
+	if h.opts.AllowOrigin != "" {
+		w.Header().Set("Access-Control-Allow-Origin", h.opts.AllowOrigin)
+	}
+}
+
+func (h *SimulatorHandler) framesHandler(w http.ResponseWriter, r *http.Request) {
+	config := make(map[string]string, len(h.opts.Config))
+	for k, v := range h.opts.Config {
+		config[k] = v
+	}
+
+	var width, height int
+	for k, vals := range r.URL.Query() {
+		var err error
+		switch k {
+		case loader.WidthConfigKey:
+			width, err = boundedInt(k, vals[0], loader.MaxSize)
+		case loader.HeightConfigKey:
+			height, err = boundedInt(k, vals[0], loader.MaxSize)
+		default:
+			config[k] = vals[0]
+		}
+		if err != nil {
+			writeImageError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	ctx := globals.ContextWithSize(r.Context(), width, height)
+	width, height = globals.Size(ctx)
+
+	var data *simulator.Frames
+	roots, err := h.app.RunWithConfig(ctx, config)
+	if err == nil {
+		var frames []encode.Frame
+		if len(roots) > 0 {
+			screens := encode.ScreensFromRoots(roots)
+			screens.Width, screens.Height = width, height
+			maxDuration := h.opts.MaxDuration
+			if screens.ShowFullAnimation {
+				maxDuration = 0
+			}
+			frames, err = screens.Frames(maxDuration)
+		}
+		if err == nil {
+			data, err = simulator.NewFrames(frames, width, height)
+		}
+	}
+	if err != nil {
+		h.log().Warn("rendering frames", "app", h.app.ID, "error", err)
+		writeImageError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	json.NewEncoder(w).Encode(data)
+}
+
+// log returns the handler's logger.
+func (h *SimulatorHandler) log() *slog.Logger {
+	if h.opts.Logger == nil {
+		return slog.Default()
+	}
+	return h.opts.Logger
+}
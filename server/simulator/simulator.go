@@ -0,0 +1,107 @@
+// Package simulator holds the device simulator, a web component that
+// shows apps the way a device does, with simulated LEDs, play/pause and
+// frame scrubbing. It lets other sites, like web editors and app
+// galleries, embed live previews. The Go side serves it, see
+// server.NewSimulatorHandler.
+package simulator
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"image/png"
+	"net/http"
+	"time"
+
+	"tidbyt.dev/pixlet/encode"
+)
+
+// Script defines the <pixlet-simulator> element. It has no dependencies,
+// so it can be loaded with a plain script tag.
+//
+//go:embed simulator.js
+var Script []byte
+
+//go:embed embed.html
+var embedHTML string
+
+var pageTmpl = template.Must(template.New("embed").Parse(embedHTML))
+
+// loaded is when the script is considered modified, for caching.
+var loaded = time.Now()
+
+// Frames is what the simulator shows, as served to it in JSON.
+type Frames struct {
+	Width  int     `json:"width"`
+	Height int     `json:"height"`
+	Frames []Frame `json:"frames"`
+}
+
+// Frame is a single frame, as a base64 encoded PNG, and how long it's
+// shown in milliseconds.
+type Frame struct {
+	Image string `json:"image"`
+	Delay int    `json:"delay"`
+}
+
+// NewFrames encodes frames for the simulator. Without frames, like when
+// an app is skipped, the size is taken from width and height.
+func NewFrames(frames []encode.Frame, width, height int) (*Frames, error) {
+	f := &Frames{Width: width, Height: height, Frames: []Frame{}}
+
+	for i, frame := range frames {
+		buf := &bytes.Buffer{}
+		if err := png.Encode(buf, frame.Image); err != nil {
+			return nil, fmt.Errorf("encoding frame %d: %w", i, err)
+		}
+
+		if i == 0 {
+			f.Width = frame.Image.Bounds().Dx()
+			f.Height = frame.Image.Bounds().Dy()
+		}
+		f.Frames = append(f.Frames, Frame{
+			Image: base64.StdEncoding.EncodeToString(buf.Bytes()),
+			Delay: frame.Delay,
+		})
+	}
+
+	return f, nil
+}
+
+// ScriptHandler serves Script.
+func ScriptHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+		http.ServeContent(w, r, "simulator.js", loaded, bytes.NewReader(Script))
+	})
+}
+
+// Page describes a page showing nothing but the simulator, for
+// embedding in an iframe.
+type Page struct {
+	Title string
+
+	// Script and Frames are the URLs of Script and of the frames to
+	// show.
+	Script string
+	Frames string
+
+	// Controls shows the play/pause button and frame scrubber.
+	Controls bool
+
+	// Refresh reloads the frames every this many seconds, if not zero.
+	Refresh int
+
+	// Watch is the URL of a websocket announcing changes to the app,
+	// like pixlet serve's /api/v1/ws. The frames are reloaded for every
+	// change, if set.
+	Watch string
+}
+
+// WritePage writes p as HTML to w.
+func WritePage(w http.ResponseWriter, p Page) error {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	return pageTmpl.Execute(w, p)
+}
@@ -0,0 +1,356 @@
+// <pixlet-simulator> shows an app the way a device does, from frames
+// served by pixlet. See docs/serve_api.md for how to embed it.
+//
+//   <script src="https://example.com/pixlet/simulator.js"></script>
+//   <pixlet-simulator src="https://example.com/pixlet/frames?timezone=UTC"></pixlet-simulator>
+//
+// Attributes:
+//   src       URL of the frames, as served by pixlet
+//   scale     how many screen pixels each LED takes, 10 by default
+//   pixels    draw plain square pixels instead of simulating LEDs
+//   controls  show the play/pause button and frame scrubber
+//   paused    don't start playing once loaded
+//   refresh   reload the frames every this many seconds
+//   dot, bloom, brightness, gamma
+//             how LEDs are simulated, as in the serve preview
+(function () {
+    'use strict';
+
+    if (window.customElements.get('pixlet-simulator')) {
+        return;
+    }
+
+    const defaults = {
+        // Size of each LED, as a fraction of the pixel pitch.
+        dot: 0.8,
+        // Strength of the glow around lit LEDs, from 0 to 1.
+        bloom: 0.4,
+        // Panel brightness in percent.
+        brightness: 100,
+        // Gamma the panel applies to colors before driving the LEDs.
+        gamma: 2.2,
+    };
+
+    // Maps a color channel to what the panel shows. The panel applies
+    // gamma and brightness and then only has 8 bits to drive the LED
+    // with, so dark colors round down to nothing, like they do on
+    // hardware. The result is converted back for display on a regular
+    // monitor.
+    function panelLevel(value, gamma, brightness) {
+        const linear = Math.pow(value / 255, gamma) * brightness / 100;
+        const level = Math.round(linear * 255) / 255;
+        return Math.round(Math.pow(level, 1 / 2.2) * 255);
+    }
+
+    function decodeFrame(frame) {
+        return new Promise((resolve, reject) => {
+            const img = new Image();
+            img.onload = () => resolve(img);
+            img.onerror = () => reject(new Error('decoding frame'));
+            img.src = 'data:image/png;base64,' + frame.image;
+        });
+    }
+
+    const template = document.createElement('template');
+    template.innerHTML = `
+<style>
+    :host { display: inline-block; }
+    canvas { display: block; width: 100%; background: black; image-rendering: pixelated; }
+    .controls { display: flex; align-items: center; gap: 8px; margin-top: 4px; font: 12px sans-serif; }
+    .controls[hidden] { display: none; }
+    input { flex: 1; }
+    .error { color: #e57373; font: 12px sans-serif; }
+</style>
+<canvas part="screen"></canvas>
+<div class="controls" part="controls" hidden>
+    <button type="button" part="play"></button>
+    <input type="range" min="0" value="0" part="scrubber" aria-label="Frame">
+    <span part="position"></span>
+</div>
+<div class="error" part="error" hidden></div>
+`;
+
+    class PixletSimulator extends HTMLElement {
+        static get observedAttributes() {
+            return ['src', 'scale', 'pixels', 'controls', 'refresh', 'dot', 'bloom', 'brightness', 'gamma'];
+        }
+
+        constructor() {
+            super();
+            const root = this.attachShadow({ mode: 'open' });
+            root.appendChild(template.content.cloneNode(true));
+
+            this._canvas = root.querySelector('canvas');
+            this._controls = root.querySelector('.controls');
+            this._button = root.querySelector('button');
+            this._scrubber = root.querySelector('input');
+            this._position = root.querySelector('span');
+            this._error = root.querySelector('.error');
+
+            this._frames = [];
+            this._pixels = [];
+            this._index = 0;
+            this._playing = false;
+            this._timer = null;
+            this._refreshTimer = null;
+            this._loading = 0;
+            this._loaded = false;
+
+            this._button.addEventListener('click', () => this._playing ? this.pause() : this.play());
+            this._scrubber.addEventListener('input', () => {
+                this.pause();
+                this.seek(Number(this._scrubber.value));
+            });
+        }
+
+        connectedCallback() {
+            this._update();
+            this.reload();
+        }
+
+        disconnectedCallback() {
+            clearTimeout(this._timer);
+            clearInterval(this._refreshTimer);
+            this._refreshTimer = null;
+        }
+
+        attributeChangedCallback(name, oldValue, newValue) {
+            if (!this.isConnected || oldValue === newValue) {
+                return;
+            }
+            if (name === 'src') {
+                this.reload();
+            } else if (name === 'refresh') {
+                this._schedule();
+            } else {
+                this._update();
+                this._draw();
+            }
+        }
+
+        // The frame shown, from 0.
+        get frame() {
+            return this._index;
+        }
+
+        // How many frames the app has.
+        get frameCount() {
+            return this._frames.length;
+        }
+
+        get playing() {
+            return this._playing;
+        }
+
+        play() {
+            if (this._playing || this._frames.length === 0) {
+                return;
+            }
+            this._playing = true;
+            this._update();
+            this._tick();
+        }
+
+        pause() {
+            this._playing = false;
+            clearTimeout(this._timer);
+            this._update();
+        }
+
+        // Shows frame i.
+        seek(i) {
+            if (this._frames.length === 0) {
+                return;
+            }
+            this._index = Math.max(0, Math.min(i, this._frames.length - 1));
+            this._draw();
+            this.dispatchEvent(new CustomEvent('frame', { detail: { frame: this._index } }));
+        }
+
+        // Fetches the frames again, like after the app changed.
+        reload() {
+            this._schedule();
+
+            const src = this.getAttribute('src');
+            if (!src) {
+                return Promise.resolve();
+            }
+
+            const loading = ++this._loading;
+            return fetch(src)
+                .then(res => res.json().then(data => {
+                    if (!res.ok) {
+                        throw new Error(data.error || res.statusText);
+                    }
+                    return data;
+                }))
+                .then(data => Promise.all(data.frames.map(decodeFrame)).then(images => [data, images]))
+                .then(([data, images]) => {
+                    if (loading !== this._loading) {
+                        return;
+                    }
+                    this._load(data, images);
+                })
+                .catch(err => {
+                    if (loading !== this._loading) {
+                        return;
+                    }
+                    this._error.textContent = err.message;
+                    this._error.hidden = false;
+                    this.dispatchEvent(new CustomEvent('error', { detail: { error: err } }));
+                });
+        }
+
+        _load(data, images) {
+            const source = document.createElement('canvas');
+            source.width = data.width;
+            source.height = data.height;
+            const ctx = source.getContext('2d', { willReadFrequently: true });
+
+            this._width = data.width;
+            this._height = data.height;
+            this._frames = data.frames;
+            this._pixels = images.map(img => {
+                ctx.clearRect(0, 0, data.width, data.height);
+                ctx.drawImage(img, 0, 0);
+                return ctx.getImageData(0, 0, data.width, data.height);
+            });
+            this._error.hidden = true;
+
+            // an app that didn't change keeps playing where it was
+            if (this._index >= this._frames.length) {
+                this._index = 0;
+            }
+            this._scrubber.max = Math.max(0, this._frames.length - 1);
+            this._update();
+            this._draw();
+
+            // apps with nothing to show are skipped on devices
+            if (this._frames.length === 0) {
+                const ctx = this._canvas.getContext('2d');
+                ctx.fillStyle = 'black';
+                ctx.fillRect(0, 0, this._canvas.width, this._canvas.height);
+                this._position.textContent = 'skipped';
+            }
+            this.dispatchEvent(new CustomEvent('load', { detail: { frames: this._frames.length } }));
+
+            // after the first load, keep playing or stay paused
+            const play = this._loaded ? this._playing : !this.hasAttribute('paused');
+            this._loaded = true;
+            this.pause();
+            if (play) {
+                this.play();
+            }
+        }
+
+        _schedule() {
+            clearInterval(this._refreshTimer);
+            this._refreshTimer = null;
+
+            const seconds = Number(this.getAttribute('refresh'));
+            if (seconds > 0) {
+                this._refreshTimer = setInterval(() => this.reload(), seconds * 1000);
+            }
+        }
+
+        _tick() {
+            clearTimeout(this._timer);
+            if (!this._playing || this._frames.length === 0) {
+                return;
+            }
+
+            this._draw();
+            const delay = this._frames[this._index].delay || 50;
+            if (this._frames.length === 1) {
+                return;
+            }
+            this._timer = setTimeout(() => {
+                this._index = (this._index + 1) % this._frames.length;
+                this._tick();
+            }, delay);
+        }
+
+        _setting(name) {
+            const value = parseFloat(this.getAttribute(name));
+            return isNaN(value) ? defaults[name] : value;
+        }
+
+        _update() {
+            this._controls.hidden = !this.hasAttribute('controls');
+            this._button.textContent = this._playing ? 'Pause' : 'Play';
+            this._button.disabled = this._frames.length < 2;
+            this._scrubber.disabled = this._frames.length < 2;
+        }
+
+        _draw() {
+            const pixels = this._pixels[this._index];
+            if (!pixels) {
+                return;
+            }
+
+            const scale = Math.max(1, parseInt(this.getAttribute('scale'), 10) || 10);
+            const width = this._width;
+            const height = this._height;
+            const canvas = this._canvas;
+            if (canvas.width !== width * scale || canvas.height !== height * scale) {
+                canvas.width = width * scale;
+                canvas.height = height * scale;
+            }
+
+            this._scrubber.value = this._index;
+            this._position.textContent = `${this._index + 1} / ${this._frames.length}`;
+
+            const ctx = canvas.getContext('2d');
+            ctx.fillStyle = 'black';
+            ctx.fillRect(0, 0, canvas.width, canvas.height);
+
+            if (this.hasAttribute('pixels')) {
+                const source = document.createElement('canvas');
+                source.width = width;
+                source.height = height;
+                source.getContext('2d').putImageData(pixels, 0, 0);
+                ctx.imageSmoothingEnabled = false;
+                ctx.drawImage(source, 0, 0, canvas.width, canvas.height);
+                return;
+            }
+
+            const gamma = this._setting('gamma');
+            const brightness = this._setting('brightness');
+            const bloom = this._setting('bloom');
+            const radius = this._setting('dot') * scale / 2;
+
+            const dots = document.createElement('canvas');
+            dots.width = canvas.width;
+            dots.height = canvas.height;
+            const dotsCtx = dots.getContext('2d');
+            const data = pixels.data;
+            for (let y = 0; y < height; y++) {
+                for (let x = 0; x < width; x++) {
+                    const i = (y * width + x) * 4;
+                    const r = panelLevel(data[i], gamma, brightness);
+                    const g = panelLevel(data[i + 1], gamma, brightness);
+                    const b = panelLevel(data[i + 2], gamma, brightness);
+
+                    // Unlit LEDs are still faintly visible on the panel.
+                    dotsCtx.fillStyle = (r || g || b) ? `rgb(${r}, ${g}, ${b})` : 'rgb(12, 12, 12)';
+                    dotsCtx.beginPath();
+                    dotsCtx.arc((x + 0.5) * scale, (y + 0.5) * scale, radius, 0, 2 * Math.PI);
+                    dotsCtx.fill();
+                }
+            }
+
+            if (bloom > 0) {
+                ctx.filter = `blur(${scale * bloom}px)`;
+                ctx.globalAlpha = bloom;
+                ctx.drawImage(dots, 0, 0);
+                ctx.filter = 'none';
+                ctx.globalAlpha = 1;
+                ctx.globalCompositeOperation = 'lighter';
+            }
+            ctx.drawImage(dots, 0, 0);
+            ctx.globalCompositeOperation = 'source-over';
+        }
+    }
+
+    window.customElements.define('pixlet-simulator', PixletSimulator);
+})();
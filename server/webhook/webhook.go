@@ -0,0 +1,92 @@
+// Package webhook reads the requests that pixlet serve and pixlet
+// daemon take as webhooks.
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// MaxBodySize is how much of a request's body is read at most.
+const MaxBodySize = 1 << 20
+
+// Values returns the values in a webhook request: its query
+// parameters, overridden by the fields of body, which is either a form
+// or a JSON object. JSON fields that aren't strings are given as JSON,
+// like "3", "true" or "[1,2]", and null fields are left out. The secret
+// query parameter, if any, is left out too.
+func Values(r *http.Request, body []byte) (map[string]string, error) {
+	values := map[string]string{}
+	for k, vals := range r.URL.Query() {
+		if k != "secret" {
+			values[k] = vals[0]
+		}
+	}
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		return values, nil
+	}
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, fmt.Errorf("parsing form: %w", err)
+		}
+		for k, vals := range form {
+			values[k] = vals[0]
+		}
+		return values, nil
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, fmt.Errorf("body must be a JSON object: %w", err)
+	}
+	for k, raw := range fields {
+		if string(raw) == "null" {
+			continue
+		}
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			values[k] = s
+			continue
+		}
+
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, raw); err != nil {
+			return nil, fmt.Errorf("encoding %s: %w", k, err)
+		}
+		values[k] = compact.String()
+	}
+
+	return values, nil
+}
+
+// Authorized reports whether a webhook request passes secret as a
+// bearer token or in the secret query parameter, or signs body with it
+// in the X-Hub-Signature-256 header, as GitHub does.
+func Authorized(r *http.Request, body []byte, secret string) bool {
+	if sig, ok := strings.CutPrefix(r.Header.Get("X-Hub-Signature-256"), "sha256="); ok {
+		want, err := hex.DecodeString(sig)
+		if err != nil {
+			return false
+		}
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		return hmac.Equal(mac.Sum(nil), want)
+	}
+
+	given := r.URL.Query().Get("secret")
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		given = token
+	}
+	return given != "" && subtle.ConstantTimeCompare([]byte(given), []byte(secret)) == 1
+}
@@ -0,0 +1,79 @@
+package webhook_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tidbyt.dev/pixlet/server/webhook"
+)
+
+func TestValuesJSON(t *testing.T) {
+	body := `{"event": "doorbell", "count": 3, "big": 100000000, "on": true, "tags": ["a", "b"], "gone": null, "who": "body"}`
+	r := httptest.NewRequest("POST", "/?who=query&where=door&secret=hunter2", strings.NewReader(body))
+
+	values, err := webhook.Values(r, []byte(body))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"event": "doorbell",
+		"count": "3",
+		"big":   "100000000",
+		"on":    "true",
+		"tags":  `["a","b"]`,
+		"who":   "body",
+		"where": "door",
+	}, values)
+}
+
+func TestValuesForm(t *testing.T) {
+	body := "event=doorbell&who=body"
+	r := httptest.NewRequest("POST", "/?who=query", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	values, err := webhook.Values(r, []byte(body))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"event": "doorbell", "who": "body"}, values)
+}
+
+func TestValuesBadJSON(t *testing.T) {
+	r := httptest.NewRequest("POST", "/", nil)
+	_, err := webhook.Values(r, []byte(`["not", "an", "object"]`))
+	assert.ErrorContains(t, err, "JSON object")
+}
+
+func TestAuthorized(t *testing.T) {
+	body := []byte(`{"event": "doorbell"}`)
+	mac := hmac.New(sha256.New, []byte("hunter2"))
+	mac.Write(body)
+	sig := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	for _, tc := range []struct {
+		name   string
+		target string
+		header map[string]string
+		want   bool
+	}{
+		{"none", "/", nil, false},
+		{"query", "/?secret=hunter2", nil, true},
+		{"wrong query", "/?secret=hunter3", nil, false},
+		{"bearer", "/", map[string]string{"Authorization": "Bearer hunter2"}, true},
+		{"wrong bearer", "/?secret=hunter2", map[string]string{"Authorization": "Bearer hunter3"}, false},
+		{"signature", "/", map[string]string{"X-Hub-Signature-256": sig}, true},
+		{"wrong signature", "/?secret=hunter2", map[string]string{"X-Hub-Signature-256": "sha256=00"}, false},
+		{"bad signature", "/", map[string]string{"X-Hub-Signature-256": "sha256=zz"}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", tc.target, nil)
+			for k, v := range tc.header {
+				r.Header.Set(k, v)
+			}
+			assert.Equal(t, tc.want, webhook.Authorized(r, body, "hunter2"))
+		})
+	}
+}
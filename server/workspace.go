@@ -0,0 +1,302 @@
+package server
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"tidbyt.dev/pixlet/configstore"
+	"tidbyt.dev/pixlet/dist"
+	"tidbyt.dev/pixlet/metrics"
+	"tidbyt.dev/pixlet/runtime"
+	"tidbyt.dev/pixlet/server/auth"
+	"tidbyt.dev/pixlet/server/browser"
+	"tidbyt.dev/pixlet/server/loader"
+	"tidbyt.dev/pixlet/tools"
+)
+
+//go:embed workspace.html
+var workspaceHTML string
+
+// WorkspaceApp is one of the apps served by a Workspace or Handler.
+type WorkspaceApp struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+
+	source  string
+	handler http.Handler
+	watcher *Watcher
+	browser *browser.Browser
+	loader  *loader.Loader
+}
+
+// Workspace serves every app in a directory from a single server, with
+// a dashboard listing them all. Each app gets the usual serve UI under
+// /apps/<name>/.
+type Workspace struct {
+	addr     string
+	title    string
+	serveGif bool
+	handler  *Handler
+	apps     []*WorkspaceApp
+	rotation *rotation
+	tmpl     *template.Template
+	mux      *http.ServeMux
+	auth     *auth.Auth
+	tlsCert  string
+	tlsKey   string
+	logger   *slog.Logger
+}
+
+// FindApps lists the apps in a workspace directory, keyed by name.
+// Subdirectories holding .star files are apps, and so are .star files
+// at the top level.
+func FindApps(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading workspace %s: %w", dir, err)
+	}
+
+	apps := map[string]string{}
+	for _, e := range entries {
+		name := e.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+
+		path := filepath.Join(dir, name)
+		if !e.IsDir() {
+			if strings.HasSuffix(name, ".star") {
+				apps[strings.TrimSuffix(name, ".star")] = path
+			}
+			continue
+		}
+
+		stars, err := filepath.Glob(filepath.Join(path, "*.star"))
+		if err != nil {
+			return nil, err
+		}
+		if len(stars) > 0 {
+			apps[name] = path
+		}
+	}
+
+	return apps, nil
+}
+
+// NewWorkspace creates a server for all apps found in dir. Logs,
+// including the apps', go to logger, or slog.Default() if it's nil.
+func NewWorkspace(host string, port int, watch bool, dir string, maxDuration int, limits runtime.Limits, serveGif bool, logger *slog.Logger) (*Workspace, error) {
+	found, err := FindApps(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("no apps found in %s", dir)
+	}
+
+	tmpl, err := template.New("workspace").Parse(workspaceHTML)
+	if err != nil {
+		return nil, err
+	}
+
+	addr := fmt.Sprintf("%s:%d", host, port)
+	ws := &Workspace{
+		addr:     addr,
+		title:    filepath.Base(dir),
+		serveGif: serveGif,
+		tmpl:     tmpl,
+		mux:      http.NewServeMux(),
+		logger:   logger,
+	}
+
+	names := make([]string, 0, len(found))
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	srcs := []AppSource{}
+	for _, name := range names {
+		path := found[name]
+
+		if url.PathEscape(name) != name {
+			ws.log().Warn("skipping app, since its name can't be used in a URL as is", "path", path)
+			continue
+		}
+
+		var fsys fs.FS
+		if strings.HasSuffix(path, ".star") {
+			fsys = tools.NewSingleFileFS(path)
+		} else {
+			fsys = os.DirFS(path)
+		}
+
+		srcs = append(srcs, AppSource{Name: name, FS: fsys, Path: path})
+	}
+
+	if len(srcs) == 0 {
+		return nil, fmt.Errorf("no apps found in %s", dir)
+	}
+
+	ws.handler, err = NewHandler(srcs, HandlerOptions{
+		Watch:       watch,
+		MaxDuration: maxDuration,
+		Limits:      limits,
+		ServeGif:    serveGif,
+		Logger:      logger,
+	})
+	if err != nil {
+		return nil, err
+	}
+	ws.apps = ws.handler.Apps()
+
+	// the frontend of every app shares the same static files, and OAuth
+	// providers redirect to the root of the server
+	ws.mux.Handle("/oauth-callback", ws.handler)
+	ws.mux.HandleFunc("/webauth-callback", ws.indexHandler)
+	ws.mux.Handle("/static/", ws.handler)
+	ws.mux.Handle("/apps/", ws.handler)
+	ws.mux.Handle("/api/v1/apps", ws.handler)
+	ws.mux.Handle("/metrics", metrics.Handler())
+	ws.mux.HandleFunc("/", ws.dashboardHandler)
+
+	return ws, nil
+}
+
+// PersistConfig saves the config entered for each app, so that it
+// survives restarts. Each app's config goes to <dir>/<name>.json, or to
+// the default location for the app if dir is empty.
+func (ws *Workspace) PersistConfig(dir string) error {
+	for _, app := range ws.apps {
+		path := filepath.Join(dir, app.Name+".json")
+		if dir == "" {
+			var err error
+			if path, err = DefaultConfigPath(app.source); err != nil {
+				return err
+			}
+		}
+
+		if err := app.browser.PersistConfig(path); err != nil {
+			return fmt.Errorf("%s: %w", app.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// UseConfigStore keeps the config entered for each app in store, under
+// the app's name, and restores what's kept there.
+func (ws *Workspace) UseConfigStore(store configstore.Store) error {
+	for _, app := range ws.apps {
+		key := configstore.Key{App: app.Name, Installation: configstore.DefaultInstallation}
+		if err := app.browser.UseConfigStore(store, key); err != nil {
+			return fmt.Errorf("%s: %w", app.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// EnableRotation cycles through the apps like a device rotation does,
+// showing each for dwell, and serves the rotation at /rotation.
+func (ws *Workspace) EnableRotation(dwell time.Duration) error {
+	r, err := newRotation(ws, dwell)
+	if err != nil {
+		return err
+	}
+
+	ws.rotation = r
+	ws.mux.HandleFunc("/rotation", r.pageHandler)
+	ws.mux.HandleFunc("/api/v1/rotation", r.stateHandler)
+	ws.mux.HandleFunc("/api/v1/rotation/next", r.nextHandler)
+	return nil
+}
+
+// Secure requires users to sign in with a, if it's not nil, and serves
+// HTTPS using certFile and keyFile, if they're not empty.
+func (ws *Workspace) Secure(a *auth.Auth, certFile, keyFile string) {
+	ws.auth = a
+	ws.tlsCert = certFile
+	ws.tlsKey = keyFile
+}
+
+// SetWebhookSecret makes every app's webhook endpoint require requests
+// to pass secret, or sign their body with it. See docs/serve_api.md.
+func (ws *Workspace) SetWebhookSecret(secret string) {
+	for _, app := range ws.apps {
+		app.browser.SetWebhookSecret(secret)
+	}
+}
+
+func (ws *Workspace) indexHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+	w.Write(dist.Index)
+}
+
+func (ws *Workspace) dashboardHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	imageType := "webp"
+	if ws.serveGif {
+		imageType = "gif"
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	ws.tmpl.Execute(w, struct {
+		Title     string
+		Apps      []*WorkspaceApp
+		ImageType string
+		Rotation  bool
+	}{ws.title, ws.apps, imageType, ws.rotation != nil})
+}
+
+// Run serves the dashboard and all apps, running forever in a blocking
+// fashion.
+func (ws *Workspace) Run() error {
+	g := errgroup.Group{}
+
+	g.Go(ws.handler.Run)
+
+	if ws.rotation != nil {
+		g.Go(ws.rotation.Run)
+	}
+
+	var h http.Handler = ws.mux
+	if ws.auth != nil {
+		h = ws.auth.Wrap(h)
+	}
+
+	g.Go(func() error {
+		if ws.tlsCert != "" {
+			ws.log().Info("serving apps", "apps", len(ws.apps), "url", "https://"+ws.addr)
+			return http.ListenAndServeTLS(ws.addr, ws.tlsCert, ws.tlsKey, h)
+		}
+
+		ws.log().Info("serving apps", "apps", len(ws.apps), "url", "http://"+ws.addr)
+		return http.ListenAndServe(ws.addr, h)
+	})
+
+	return g.Wait()
+}
+
+// log returns the workspace's logger.
+func (ws *Workspace) log() *slog.Logger {
+	if ws.logger == nil {
+		return slog.Default()
+	}
+	return ws.logger
+}
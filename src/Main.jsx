@@ -11,6 +11,8 @@ import ErrorManager from './features/errors/ErrorManager';
 import ErrorSnackbar from './features/errors/ErrorSnackbar';
 import ParamSetter from './features/config/ParamSetter';
 import Preview from './features/preview/Preview';
+import SimulationControls from './features/preview/SimulationControls';
+import SizeControls from './features/preview/SizeControls';
 import Schema from './features/schema/Schema';
 import WatcherManager from './features/watcher/WatcherManager';
 import Controls from './features/controls/Controls';
@@ -38,6 +40,8 @@ export default function Main() {
                     <Grid container spacing={4}>
                         <Grid item xs={12} lg={size}>
                             <Preview scale={10} />
+                            <SizeControls />
+                            <SimulationControls />
                             <Controls />
                         </Grid>
                         <Grid item xs={12} lg={4}>
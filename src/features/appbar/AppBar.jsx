@@ -3,6 +3,7 @@ import React from 'react';
 import AppBar from '@mui/material/AppBar';
 import Toolbar from '@mui/material/Toolbar';
 
+import AppSwitcher from './AppSwitcher';
 import Logo from './logo.svg';
 import styles from './styles.css';
 import { solarized } from '../theme/colors';
@@ -15,6 +16,7 @@ export default function NavBar() {
                 <div className={styles.title}>
                     <Logo className={styles.logo} />
                 </div>
+                <AppSwitcher />
             </Toolbar>
         </AppBar>
     )
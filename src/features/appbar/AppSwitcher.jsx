@@ -0,0 +1,44 @@
+import React, { useState, useEffect } from 'react';
+import axios from 'axios';
+
+import MenuItem from '@mui/material/MenuItem';
+import Select from '@mui/material/Select';
+
+
+// AppSwitcher lets you jump between apps when pixlet serve is running a
+// workspace. It isn't shown when serving a single app.
+export default function AppSwitcher() {
+    const [apps, setApps] = useState([]);
+
+    useEffect(() => {
+        if (!PIXLET_API_BASE) {
+            return;
+        }
+
+        axios.get('/api/v1/apps')
+            .then(res => setApps(res.data))
+            .catch(err => console.log(err));
+    }, []);
+
+    if (apps.length === 0) {
+        return null;
+    }
+
+    const current = apps.find((app) => app.path === PIXLET_API_BASE);
+
+    return (
+        <Select
+            size="small"
+            value={current ? current.path : ''}
+            sx={{ color: 'inherit', minWidth: 200 }}
+            onChange={(event) => {
+                window.location.href = event.target.value ? event.target.value + '/' : '/';
+            }}
+        >
+            <MenuItem value="">All apps</MenuItem>
+            {apps.map((app) => {
+                return <MenuItem key={app.path} value={app.path}>{app.name}</MenuItem>
+            })}
+        </Select>
+    );
+}
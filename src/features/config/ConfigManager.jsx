@@ -3,12 +3,16 @@ import { useSelector } from 'react-redux';
 import { useNavigate } from 'react-router-dom';
 
 import fetchPreview from '../preview/actions';
+import { saveConfig } from './actions';
+import { defaultSize } from '../preview/sizeSlice';
 
 
 export default function ConfigManager() {
     const config = useSelector(state => state.config);
     const loading = useSelector(state => state.param.loading);
     const preview = useSelector(state => state.preview);
+    const schema = useSelector(state => state.schema);
+    const size = useSelector(state => state.size);
     const navigate = useNavigate();
 
     const updatePreviews = (formData, params) => {
@@ -19,6 +23,9 @@ export default function ConfigManager() {
     useEffect(() => {
         const formData = new FormData();
         const params = new URLSearchParams();
+        const sensitive = new Set(
+            (schema.value.schema || []).filter(f => f.sensitive).map(f => f.id)
+        );
 
         Object.entries(config).forEach((entry) => {
             const [id, item] = entry;
@@ -26,17 +33,31 @@ export default function ConfigManager() {
             // Not all config values fit inside a query parameter, most notably
             // images. If they don't fit, simply leave them out of the query
             // string. The downside is a refresh will lose that state.
-            if (item.value.length < 1024) {
+            // Secrets are left out too, so they don't end up in history.
+            if (item.value.length < 1024 && !sensitive.has(id)) {
                 params.set(id, item.value)
             }
 
             formData.set(id, item.value);
         });
 
+        // The size isn't config, so it's sent in keys reserved for it,
+        // and only when it's not the default.
+        if (size.width !== defaultSize.width || size.height !== defaultSize.height) {
+            formData.set('_width', size.width);
+            formData.set('_height', size.height);
+        }
+
         if (!loading || !('img' in preview)) {
             updatePreviews(formData, params);
         }
-    }, [config]);
+
+        // Don't save until the saved config has been restored, or it
+        // would be overwritten with nothing.
+        if (!loading) {
+            saveConfig(config);
+        }
+    }, [config, size]);
 
     return null;
 }
\ No newline at end of file
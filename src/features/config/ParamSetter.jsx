@@ -1,7 +1,8 @@
 import { useEffect } from 'react';
 import { useDispatch } from 'react-redux';
-import { set } from './configSlice';
+import { set, update } from './configSlice';
 import { loading } from './paramSlice';
+import { fetchSavedConfig } from './actions';
 
 
 export default function ParamSetter() {
@@ -9,14 +10,29 @@ export default function ParamSetter() {
     const dispatch = useDispatch();
 
     useEffect(() => {
-        params.forEach((value, key) => {
-            dispatch(set({
-                id: key,
-                value: value,
-            }));
+        // Query parameters win, so that links to a config keep working.
+        // Otherwise, pick up where the last session left off.
+        if (params.toString() !== '') {
+            params.forEach((value, key) => {
+                dispatch(set({
+                    id: key,
+                    value: value,
+                }));
+            });
+            dispatch(loading(false));
+            return;
+        }
+
+        fetchSavedConfig().then((values) => {
+            // Set everything at once, so the first preview has all of it.
+            const config = {};
+            Object.entries(values).forEach(([key, value]) => {
+                config[key] = { id: key, value: value };
+            });
+            dispatch(update(config));
+            dispatch(loading(false));
         });
-        dispatch(loading(false));
     }, []);
 
     return null;
-};
\ No newline at end of file
+};
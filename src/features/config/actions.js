@@ -1,3 +1,4 @@
+import axios from 'axios';
 import { update, clear } from './configSlice';
 import store from '../../store';
 
@@ -7,4 +8,22 @@ export function setConfig(data) {
 
 export function resetConfig() {
     store.dispatch(clear());
-}
\ No newline at end of file
+}
+
+// Fetches the config saved by serve, as a map of field IDs to values.
+export function fetchSavedConfig() {
+    return axios.get(`${PIXLET_API_BASE}/api/v1/config`)
+        .then(res => res.data || {})
+        .catch(() => ({}));
+}
+
+// Saves the config, so that serve can restore it after a restart.
+export function saveConfig(config) {
+    const values = {};
+    Object.entries(config).forEach(([id, item]) => {
+        values[id] = item.value;
+    });
+
+    axios.put(`${PIXLET_API_BASE}/api/v1/config`, values)
+        .catch(err => console.log(`saving config: ${err}`));
+}
@@ -44,7 +44,14 @@ export default function Controls() {
     function downloadConfig() {
         const date = new Date().getTime();
         const element = document.createElement("a");
-        const jsonData = config;
+
+        // Leave out secrets, so that saved configs are safe to share.
+        const jsonData = { ...config };
+        schema.value.schema.forEach((field) => {
+            if (field.sensitive) {
+                delete jsonData[field.id];
+            }
+        });
 
         // Use Blob object for JSON
         const file = new Blob([JSON.stringify(jsonData)], { type: 'application/json' });
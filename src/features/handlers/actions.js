@@ -13,16 +13,30 @@ function parseErrorMessage(handler, error) {
     return msg;
 }
 
-export function callHandler(id, handler, param) {
+// Handlers can ask for the current config as a second parameter, so
+// it's sent along with every call.
+function currentConfig() {
+    const values = {};
+    Object.entries(store.getState().config).forEach(([id, item]) => {
+        values[id] = item.value;
+    });
+    return values;
+}
+
+export function callHandler(id, handler, param, onResult) {
     let data = {
         id: id,
-        param: param
+        param: param,
+        config: currentConfig(),
     }
 
     store.dispatch(loading(true));
     axios.post(`${PIXLET_API_BASE}/api/v1/handlers/` + handler, JSON.stringify(data))
         .then(res => {
             store.dispatch(update({ id: id, value: res.data }));
+            if (onResult) {
+                onResult(res.data);
+            }
         })
         .catch(err => {
             // TODO: make sure this clears.
@@ -38,7 +52,8 @@ export function callHandler(id, handler, param) {
 export function callGeneratedHandler(id, handler, param) {
     let data = {
         id: id,
-        param: param
+        param: param,
+        config: currentConfig(),
     }
 
     store.dispatch(loading(true));
@@ -60,7 +75,8 @@ export function callGeneratedHandler(id, handler, param) {
 export function callHandlerSetValue(id, handler, param, valueHandler) {
     let data = {
         id: id,
-        param: JSON.stringify(param)
+        param: JSON.stringify(param),
+        config: currentConfig(),
     }
 
     store.dispatch(loading(true));
@@ -0,0 +1,96 @@
+import React, { useEffect, useRef } from 'react';
+import { useSelector } from 'react-redux';
+
+import styles from './styles.css';
+
+// Maps a color channel to what the panel shows. The panel applies gamma
+// and brightness and then only has 8 bits to drive the LED with, so dark
+// colors round down to nothing, like they do on hardware. The result is
+// converted back for display on a regular monitor.
+export function panelLevel(value, gamma, brightness) {
+    const linear = Math.pow(value / 255, gamma) * brightness / 100;
+    const level = Math.round(linear * 255) / 255;
+    return Math.round(Math.pow(level, 1 / 2.2) * 255);
+}
+
+export default function LEDPreview({ src, scale }) {
+    const settings = useSelector(state => state.simulation);
+    const imgRef = useRef(null);
+    const canvasRef = useRef(null);
+
+    useEffect(() => {
+        const img = imgRef.current;
+        const canvas = canvasRef.current;
+        const source = document.createElement('canvas');
+        const dots = document.createElement('canvas');
+        let frame = null;
+
+        const draw = () => {
+            frame = requestAnimationFrame(draw);
+
+            const width = img.naturalWidth;
+            const height = img.naturalHeight;
+            if (!width || !height) {
+                return;
+            }
+
+            // Drawing the image element captures its current animation
+            // frame, which lets the browser take care of decoding.
+            if (source.width !== width || source.height !== height) {
+                source.width = width;
+                source.height = height;
+                canvas.width = dots.width = width * scale;
+                canvas.height = dots.height = height * scale;
+            }
+
+            const sourceCtx = source.getContext('2d');
+            sourceCtx.clearRect(0, 0, width, height);
+            sourceCtx.drawImage(img, 0, 0);
+            const pixels = sourceCtx.getImageData(0, 0, width, height).data;
+
+            const dotsCtx = dots.getContext('2d');
+            const radius = settings.dot * scale / 2;
+            for (let y = 0; y < height; y++) {
+                for (let x = 0; x < width; x++) {
+                    const i = (y * width + x) * 4;
+                    const r = panelLevel(pixels[i], settings.gamma, settings.brightness);
+                    const g = panelLevel(pixels[i + 1], settings.gamma, settings.brightness);
+                    const b = panelLevel(pixels[i + 2], settings.gamma, settings.brightness);
+
+                    // Unlit LEDs are still faintly visible on the panel.
+                    dotsCtx.fillStyle = (r || g || b) ? `rgb(${r}, ${g}, ${b})` : 'rgb(12, 12, 12)';
+                    dotsCtx.beginPath();
+                    dotsCtx.arc((x + 0.5) * scale, (y + 0.5) * scale, radius, 0, 2 * Math.PI);
+                    dotsCtx.fill();
+                }
+            }
+
+            const ctx = canvas.getContext('2d');
+            ctx.fillStyle = 'black';
+            ctx.fillRect(0, 0, canvas.width, canvas.height);
+
+            if (settings.bloom > 0) {
+                ctx.filter = `blur(${scale * settings.bloom}px)`;
+                ctx.globalAlpha = settings.bloom;
+                ctx.drawImage(dots, 0, 0);
+                ctx.filter = 'none';
+                ctx.globalAlpha = 1;
+                ctx.globalCompositeOperation = 'lighter';
+            }
+            ctx.drawImage(dots, 0, 0);
+            ctx.globalCompositeOperation = 'source-over';
+        };
+
+        frame = requestAnimationFrame(draw);
+        return () => cancelAnimationFrame(frame);
+    }, [src, scale, settings]);
+
+    // The image stays in the page, invisible, so that it keeps animating
+    // and keeps the preview the same size as without simulation.
+    return (
+        <div className={styles.simulation}>
+            <img ref={imgRef} src={src} className={styles.hidden} />
+            <canvas ref={canvasRef} className={styles.canvas} />
+        </div>
+    );
+}
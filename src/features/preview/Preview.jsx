@@ -3,6 +3,7 @@ import { useSelector } from 'react-redux';
 
 import { Paper } from '@mui/material';
 
+import LEDPreview from './LEDPreview';
 import styles from './styles.css';
 
 const loading = `UklGRu4KAABXRUJQVlA4WAoAAAASAAAAPwAAHwAAQU5JTQYAAAD/////AABBTk1GYgAAAAAAAAAA
@@ -56,8 +57,10 @@ AGzrfm8bqGkkBY5OxdOhBS0IIaL/AWYv4EFBAwBBTk1GWgAAAA8AAAUAAAYAAAUAADIAAABWUDhM
 QQAAAC8GQAEQT6CgbRuml0g1/igHQkHaBmxo18iYgrQN2NCukbH5TwAAbOt+bxsoaCSpTc4Xmgy0
 YISI/gfcN/BhYeMAAA==`
 
-export default function Preview() {
+export default function Preview({ scale }) {
     const preview = useSelector(state => state.preview);
+    const simulation = useSelector(state => state.simulation);
+    const size = useSelector(state => state.size);
 
     let displayType = 'data:image/webp;base64,';
     if (preview.value.img_type === "gif") {
@@ -69,7 +72,15 @@ export default function Preview() {
         img = preview.value.img;
     }
 
-    let content = <img src={displayType + img} className={styles.image} />
+    // The mask draws the LEDs of a 64x32 panel, so it's tiled for other
+    // sizes.
+    const maskSize = `${100 * 64 / size.width}% ${100 * 32 / size.height}%`;
+    const maskStyle = { maskSize: maskSize, WebkitMaskSize: maskSize };
+
+    let content = <img src={displayType + img} className={styles.image} style={maskStyle} />
+    if (simulation.enabled) {
+        content = <LEDPreview src={displayType + img} scale={scale} />
+    }
     return (
         <Paper sx={{ bgcolor: "black" }}>
             {content}
@@ -0,0 +1,51 @@
+import React from 'react';
+import { useSelector, useDispatch } from 'react-redux';
+
+import Box from '@mui/material/Box';
+import FormControlLabel from '@mui/material/FormControlLabel';
+import Slider from '@mui/material/Slider';
+import Stack from '@mui/material/Stack';
+import Switch from '@mui/material/Switch';
+import Typography from '@mui/material/Typography';
+
+import { update } from './simulationSlice';
+
+
+function Setting({ label, value, min, max, step, onChange }) {
+    return (
+        <Box sx={{ width: 160 }}>
+            <Typography variant="caption">{label}</Typography>
+            <Slider
+                size="small"
+                value={value}
+                min={min}
+                max={max}
+                step={step}
+                valueLabelDisplay="auto"
+                onChange={(event, v) => onChange(v)}
+            />
+        </Box>
+    );
+}
+
+export default function SimulationControls() {
+    const settings = useSelector(state => state.simulation);
+    const dispatch = useDispatch();
+
+    const set = (key) => (value) => dispatch(update({ [key]: value }));
+
+    return (
+        <Stack sx={{ marginTop: '16px' }} spacing={3} direction="row" alignItems="center">
+            <FormControlLabel
+                control={<Switch checked={settings.enabled} onChange={(event) => set('enabled')(event.target.checked)} />}
+                label="Simulate LEDs"
+            />
+            {settings.enabled && <>
+                <Setting label="Brightness" value={settings.brightness} min={1} max={100} step={1} onChange={set('brightness')} />
+                <Setting label="Gamma" value={settings.gamma} min={1} max={3} step={0.1} onChange={set('gamma')} />
+                <Setting label="LED size" value={settings.dot} min={0.3} max={1} step={0.05} onChange={set('dot')} />
+                <Setting label="Bloom" value={settings.bloom} min={0} max={1} step={0.05} onChange={set('bloom')} />
+            </>}
+        </Stack>
+    );
+}
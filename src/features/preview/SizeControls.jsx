@@ -0,0 +1,73 @@
+import React, { useState } from 'react';
+import { useSelector, useDispatch } from 'react-redux';
+
+import Stack from '@mui/material/Stack';
+import TextField from '@mui/material/TextField';
+import ToggleButton from '@mui/material/ToggleButton';
+import ToggleButtonGroup from '@mui/material/ToggleButtonGroup';
+
+import { presets, update } from './sizeSlice';
+
+// Largest width or height serve will render at.
+const maxSize = 512;
+
+export default function SizeControls() {
+    const size = useSelector(state => state.size);
+    const dispatch = useDispatch();
+
+    const preset = presets.find(p => p.width === size.width && p.height === size.height);
+    const [custom, setCustom] = useState(!preset);
+
+    const selected = custom ? 'custom' : preset.label;
+
+    const select = (event, value) => {
+        if (value === null) {
+            return;
+        }
+
+        if (value === 'custom') {
+            setCustom(true);
+            return;
+        }
+
+        setCustom(false);
+        const p = presets.find(p => p.label === value);
+        dispatch(update({ width: p.width, height: p.height }));
+    };
+
+    const setDimension = (key) => (event) => {
+        const n = parseInt(event.target.value, 10);
+        if (n >= 1 && n <= maxSize) {
+            dispatch(update({ [key]: n }));
+        }
+    };
+
+    return (
+        <Stack sx={{ marginTop: '16px' }} spacing={2} direction="row" alignItems="center">
+            <ToggleButtonGroup size="small" exclusive value={selected} onChange={select}>
+                {presets.map(p => <ToggleButton key={p.label} value={p.label}>{p.label}</ToggleButton>)}
+                <ToggleButton value="custom">Custom</ToggleButton>
+            </ToggleButtonGroup>
+            {custom && <>
+                <TextField
+                    size="small"
+                    type="number"
+                    label="Width"
+                    sx={{ width: 100 }}
+                    inputProps={{ min: 1, max: maxSize }}
+                    defaultValue={size.width}
+                    onChange={setDimension('width')}
+                />
+                <TextField
+                    size="small"
+                    type="number"
+                    label="Height"
+                    sx={{ width: 100 }}
+                    inputProps={{ min: 1, max: maxSize }}
+                    defaultValue={size.height}
+                    onChange={setDimension('height')}
+                />
+            </>}
+        </Stack>
+    );
+}
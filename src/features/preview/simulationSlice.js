@@ -0,0 +1,25 @@
+import { createSlice } from '@reduxjs/toolkit';
+
+// Settings for simulating how the preview looks on a physical LED panel.
+export const simulationSlice = createSlice({
+    name: 'simulation',
+    initialState: {
+        enabled: false,
+        // Size of each LED, as a fraction of the pixel pitch.
+        dot: 0.8,
+        // Strength of the glow around lit LEDs, from 0 to 1.
+        bloom: 0.4,
+        // Panel brightness in percent.
+        brightness: 100,
+        // Gamma the panel applies to colors before driving the LEDs.
+        gamma: 2.2,
+    },
+    reducers: {
+        update: (state = initialState, action) => {
+            return { ...state, ...action.payload };
+        },
+    },
+});
+
+export const { update } = simulationSlice.actions;
+export default simulationSlice.reducer;
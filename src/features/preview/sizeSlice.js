@@ -0,0 +1,26 @@
+import { createSlice } from '@reduxjs/toolkit';
+
+// Sizes of the displays apps are commonly previewed on.
+export const presets = [
+    { label: '64x32', width: 64, height: 32 },
+    { label: '128x64', width: 128, height: 64 },
+];
+
+export const defaultSize = presets[0];
+
+// The size the preview is rendered at.
+export const sizeSlice = createSlice({
+    name: 'size',
+    initialState: {
+        width: defaultSize.width,
+        height: defaultSize.height,
+    },
+    reducers: {
+        update: (state = initialState, action) => {
+            return { ...state, ...action.payload };
+        },
+    },
+});
+
+export const { update } = sizeSlice.actions;
+export default sizeSlice.reducer;
@@ -19,7 +19,7 @@ export default function Field(props) {
     };
 
     return (
-        <Accordion expanded={expanded === 'panel1'} onChange={handleChange('panel1')}>
+        <Accordion disabled={props.disabled} expanded={expanded === 'panel1'} onChange={handleChange('panel1')}>
             <AccordionSummary
                 expandIcon={<ExpandMoreIcon />}
                 aria-controls="panel1bh-content"
@@ -29,9 +29,11 @@ export default function Field(props) {
                     <FieldIcon icon={field.icon} />
                 </Typography>
                 <Typography sx={{ width: '33%', flexShrink: 0 }}>
-                    {field.name}
+                    {field.name}{field.required && ' *'}
+                </Typography>
+                <Typography sx={{ color: props.error ? 'error.main' : 'text.secondary' }}>
+                    {props.error || field.description}
                 </Typography>
-                <Typography sx={{ color: 'text.secondary' }}>{field.description}</Typography>
             </AccordionSummary>
             <AccordionDetails>
                 <FieldDetails field={field} />
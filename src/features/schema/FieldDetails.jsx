@@ -5,12 +5,24 @@ import PhotoSelect from './fields/photoselect/PhotoSelect';
 import RawPhotoSelect from './fields/photoselect/RawPhotoSelect';
 import Toggle from './fields/Toggle';
 import Color from './fields/Color';
+import ColorPalette from './fields/ColorPalette';
 import DateTime from './fields/DateTime';
 import Dropdown from './fields/Dropdown';
+import Duration from './fields/Duration';
+import File from './fields/File';
+import Icon from './fields/Icon';
 import LocationBased from './fields/location/LocationBased';
 import LocationForm from './fields/location/LocationForm';
+import Range from './fields/Range';
+import Repeated from './fields/Repeated';
+import Schedule from './fields/Schedule';
+import Secret from './fields/Secret';
+import Slider from './fields/Slider';
+import MultiSelect from './fields/MultiSelect';
+import NotificationSettings from './fields/NotificationSettings';
 import TextInput from './fields/TextInput';
 import Typeahead from './fields/Typeahead';
+import WebAuth from './fields/webauth/WebAuth';
 import Typography from '@mui/material/Typography';
 
 
@@ -20,22 +32,46 @@ export default function FieldDetails({ field }) {
             return <DateTime field={field} />
         case 'dropdown':
             return <Dropdown field={field} />
+        case 'duration':
+            return <Duration field={field} />
+        case 'file':
+            return <File field={field} />
+        case 'icon':
+            return <Icon field={field} />
         case 'location':
             return <LocationForm field={field} />
         case 'locationbased':
             return <LocationBased field={field} />
+        case 'multiselect':
+            return <MultiSelect field={field} />
+        case 'notificationsettings':
+            return <NotificationSettings field={field} />
         case 'oauth2':
             return <OAuth2 field={field} />
         case 'png':
             return <PhotoSelect field={field} />
+        case 'range':
+            return <Range field={field} />
+        case 'repeated':
+            return <Repeated field={field} />
+        case 'schedule':
+            return <Schedule field={field} />
+        case 'secret':
+            return <Secret field={field} />
+        case 'slider':
+            return <Slider field={field} />
         case 'text':
             return <TextInput field={field} />
         case 'onoff':
             return <Toggle field={field} />
         case 'typeahead':
             return <Typeahead field={field} />
+        case 'webauth':
+            return <WebAuth field={field} />
         case 'color':
             return <Color field={field} />
+        case 'colorpalette':
+            return <ColorPalette field={field} />
         default:
             return <Typography>Unsupported type: {field.type}</Typography>
     }
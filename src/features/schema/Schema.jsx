@@ -2,26 +2,93 @@ import React, { useEffect } from 'react';
 import { useSelector } from 'react-redux';
 
 import refreshSchema from './actions';
+import { callHandler } from '../handlers/actions';
 import Field from './Field';
+import Section from './Section';
 import Generated from './fields/Generated';
 
 
+// Evaluates a field's visibility condition against the current config,
+// falling back to the default of the field it depends on.
+function conditionMet(visibility, fields, config) {
+    let value = '';
+    if (visibility.variable in config) {
+        value = config[visibility.variable].value;
+    } else {
+        const source = fields.find((f) => f.id === visibility.variable);
+        value = source && source.default ? source.default : '';
+    }
+
+    const equal = value === visibility.value;
+    return visibility.condition === 'equal' ? equal : !equal;
+}
+
 export default function Schema() {
     const schema = useSelector(state => state.schema);
+    const config = useSelector(state => state.config);
+    const handlerResults = useSelector(state => state.handlers);
 
     useEffect(() => {
         refreshSchema();
     }, []);
 
+    // Run the app's validator whenever the config changes.
+    useEffect(() => {
+        if (!schema.value.validator) {
+            return;
+        }
+
+        const values = {};
+        Object.values(config).forEach((c) => {
+            values[c.id] = c.value;
+        });
+        callHandler('$validator', schema.value.validator, JSON.stringify(values));
+    }, [config, schema.value.validator]);
+
+    const fields = schema.value.schema;
+    const sections = schema.value.sections || [];
+    const errors = handlerResults.values['$validator'] || {};
+
+    const renderField = (field) => {
+        let disabled = false;
+        if (field.visibility && conditionMet(field.visibility, fields, config)) {
+            if (field.visibility.type === 'invisible') {
+                return null;
+            }
+            disabled = true;
+        }
+
+        if (field.type === "generated") {
+            return <Generated key={field.id} field={field} />
+        }
+
+        return <Field key={field.id} field={field} disabled={disabled} error={errors[field.id]} />
+    };
+
+    // Fields in a section are listed together, so each section is drawn
+    // where its first field is. Fields whose section is unknown are drawn
+    // on their own.
+    const rendered = new Set();
+
     return (
         <div>
             {
-                schema.value.schema.map((field) => {
-                    if (field.type === "generated") {
-                        return <Generated key={field.id} field={field} />
+                fields.map((field) => {
+                    const section = sections.find((s) => s.id === field.section);
+                    if (!section) {
+                        return renderField(field);
                     }
 
-                    return <Field key={field.id} field={field} />
+                    if (rendered.has(section.id)) {
+                        return null;
+                    }
+                    rendered.add(section.id);
+
+                    return (
+                        <Section key={`$section-${section.id}`} section={section}>
+                            {fields.filter((f) => f.section === section.id).map(renderField)}
+                        </Section>
+                    );
                 })
             }
             {
@@ -33,4 +100,4 @@ export default function Schema() {
             }
         </div>
     );
-}
\ No newline at end of file
+}
@@ -0,0 +1,45 @@
+import React from 'react';
+
+import Box from '@mui/material/Box';
+import Collapse from '@mui/material/Collapse';
+import IconButton from '@mui/material/IconButton';
+import Typography from '@mui/material/Typography';
+import ExpandMoreIcon from '@mui/icons-material/ExpandMore';
+import ExpandLessIcon from '@mui/icons-material/ExpandLess';
+
+import FieldIcon from './FieldIcon';
+
+export default function Section(props) {
+    const section = props.section;
+
+    const [open, setOpen] = React.useState(!section.collapsed);
+
+    return (
+        <Box sx={{ marginTop: '24px', marginBottom: '8px' }}>
+            <Box
+                sx={{ display: 'flex', alignItems: 'center', cursor: 'pointer' }}
+                onClick={() => setOpen(!open)}
+            >
+                <Typography sx={{ width: '10%', flexShrink: 0 }}>
+                    <FieldIcon icon={section.icon} />
+                </Typography>
+                <Box sx={{ flexGrow: 1 }}>
+                    <Typography variant="h6">{section.name}</Typography>
+                    {section.description &&
+                        <Typography variant="body2" sx={{ color: 'text.secondary' }}>
+                            {section.description}
+                        </Typography>
+                    }
+                </Box>
+                <IconButton aria-label={open ? 'collapse' : 'expand'}>
+                    {open ? <ExpandLessIcon /> : <ExpandMoreIcon />}
+                </IconButton>
+            </Box>
+            <Collapse in={open}>
+                <Box sx={{ marginTop: '8px' }}>
+                    {props.children}
+                </Box>
+            </Collapse>
+        </Box>
+    );
+}
@@ -0,0 +1,74 @@
+import React, { useState, useEffect } from 'react';
+import { useSelector, useDispatch } from 'react-redux';
+
+import { ColorPicker, createColor } from "material-ui-color";
+import Button from '@mui/material/Button';
+import IconButton from '@mui/material/IconButton';
+import Stack from '@mui/material/Stack';
+import DeleteIcon from '@mui/icons-material/Delete';
+
+import { set } from '../../config/configSlice';
+
+
+export default function ColorPalette({ field }) {
+    const [colors, setColors] = useState(JSON.parse(field.default));
+    const config = useSelector(state => state.config);
+    const dispatch = useDispatch();
+
+    useEffect(() => {
+        if (field.id in config) {
+            setColors(JSON.parse(config[field.id].value));
+        } else {
+            dispatch(set({
+                id: field.id,
+                value: field.default,
+            }));
+        }
+    }, [config])
+
+    const update = (next) => {
+        setColors(next);
+        dispatch(set({
+            id: field.id,
+            value: JSON.stringify(next),
+        }));
+    }
+
+    const onChange = (index) => (value) => {
+        // Skip updates that contain an error.
+        if (value.hasOwnProperty("error")) {
+            return;
+        }
+
+        const next = [...colors];
+        next[index] = "#" + value.hex;
+        update(next);
+    }
+
+    const onRemove = (index) => () => {
+        update(colors.filter((_, i) => i != index));
+    }
+
+    return (
+        <Stack spacing={1}>
+            <Stack direction="row" spacing={1}>
+                {(field.presets || []).map((preset) => (
+                    <Button key={preset.name} variant="outlined" onClick={() => update(preset.colors)}>
+                        {preset.name}
+                    </Button>
+                ))}
+            </Stack>
+            {colors.map((color, i) => (
+                <Stack key={i} direction="row" alignItems="center">
+                    <ColorPicker value={createColor(color)} hideTextfield disablePlainColor onChange={onChange(i)} />
+                    <IconButton disabled={colors.length <= 1} onClick={onRemove(i)}>
+                        <DeleteIcon />
+                    </IconButton>
+                </Stack>
+            ))}
+            <Button onClick={() => update([...colors, colors[colors.length - 1]])}>
+                Add color
+            </Button>
+        </Stack>
+    );
+}
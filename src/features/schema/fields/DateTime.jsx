@@ -1,6 +1,7 @@
 import React, { useState, useEffect } from 'react';
 import { useSelector, useDispatch } from 'react-redux';
 
+import dayjs from 'dayjs';
 import { AdapterDayjs } from '@mui/x-date-pickers/AdapterDayjs';
 import { LocalizationProvider } from '@mui/x-date-pickers/LocalizationProvider';
 import { DateTimePicker } from '@mui/x-date-pickers/DateTimePicker';
@@ -10,24 +11,40 @@ import { set, remove } from '../../config/configSlice'
 
 
 export default function DateTime({ field }) {
-    const [dateTime, setDateTime] = useState(new Date());
+    const [dateTime, setDateTime] = useState(field.default ? new Date(field.default) : new Date());
     const config = useSelector(state => state.config);
     const dispatch = useDispatch();
 
     useEffect(() => {
         if (field.id in config) {
-            setDateTime(new Date(config[field.id].value));
+            let value = config[field.id].value;
+            if (field.with_timezone) {
+                value = JSON.parse(value).timestamp;
+            }
+            setDateTime(new Date(value));
         }
     }, [config]);
 
     const onChange = (timestamp) => {
         if (!timestamp) {
-            setDateTime(new Date());
+            setDateTime(field.default ? new Date(field.default) : new Date());
             dispatch(remove(field.id));
             return;
         }
 
         setDateTime(timestamp);
+
+        if (field.with_timezone) {
+            dispatch(set({
+                id: field.id,
+                value: JSON.stringify({
+                    timestamp: dayjs(timestamp).format(),
+                    timezone: Intl.DateTimeFormat().resolvedOptions().timeZone,
+                }),
+            }));
+            return;
+        }
+
         dispatch(set({
             id: field.id,
             value: timestamp.toISOString(),
@@ -45,4 +62,4 @@ export default function DateTime({ field }) {
             />
         </LocalizationProvider>
     );
-}
\ No newline at end of file
+}
@@ -0,0 +1,60 @@
+import React, { useState, useEffect } from 'react';
+import { useSelector, useDispatch } from 'react-redux';
+
+import Stack from '@mui/material/Stack';
+import TextField from '@mui/material/TextField';
+
+import { set } from '../../config/configSlice';
+
+
+const split = (seconds) => ({
+    hours: Math.floor(seconds / 3600),
+    minutes: Math.floor((seconds % 3600) / 60),
+    seconds: seconds % 60,
+});
+
+export default function Duration({ field }) {
+    const step = field.step || 60;
+    const [value, setValue] = useState(split(Number(field.default || 0)));
+    const config = useSelector(state => state.config);
+    const dispatch = useDispatch();
+
+    useEffect(() => {
+        if (field.id in config) {
+            setValue(split(Number(config[field.id].value)));
+        } else if (field.default) {
+            dispatch(set({
+                id: field.id,
+                value: field.default,
+            }));
+        }
+    }, [config])
+
+    const onChange = (unit) => (event) => {
+        const next = { ...value, [unit]: Math.max(0, parseInt(event.target.value) || 0) };
+        let total = next.hours * 3600 + next.minutes * 60 + next.seconds;
+        total = Math.round(total / step) * step;
+        if (field.min !== undefined) {
+            total = Math.max(total, field.min);
+        }
+        if (field.max !== undefined) {
+            total = Math.min(total, field.max);
+        }
+
+        setValue(split(total));
+        dispatch(set({
+            id: field.id,
+            value: String(total),
+        }));
+    }
+
+    return (
+        <Stack direction="row" spacing={2}>
+            <TextField type="number" label="Hours" value={value.hours} onChange={onChange('hours')} />
+            <TextField type="number" label="Minutes" value={value.minutes} onChange={onChange('minutes')} />
+            {step < 60 &&
+                <TextField type="number" label="Seconds" value={value.seconds} onChange={onChange('seconds')} />
+            }
+        </Stack>
+    );
+}
@@ -0,0 +1,83 @@
+import React, { useState, useEffect } from 'react';
+import { useSelector, useDispatch } from 'react-redux';
+
+import Button from '@mui/material/Button';
+import Stack from '@mui/material/Stack';
+import Typography from '@mui/material/Typography';
+import DeleteIcon from '@mui/icons-material/Delete';
+import UploadFileIcon from '@mui/icons-material/UploadFile';
+
+import { set, remove } from '../../config/configSlice';
+
+
+export default function File({ field }) {
+    const config = useSelector(state => state.config);
+    const dispatch = useDispatch();
+    const [name, setName] = useState("");
+    const [error, setError] = useState("");
+
+    useEffect(() => {
+        if (field.id in config && !name) {
+            setName("Uploaded file");
+        }
+    }, [config])
+
+    const handleCapture = ({ target }) => {
+        const file = target.files[0];
+        if (!file) {
+            return;
+        }
+
+        if (file.size > field.max_size) {
+            setError(`${file.name} is larger than ${field.max_size} bytes`);
+            return;
+        }
+
+        const fileReader = new FileReader();
+        fileReader.readAsDataURL(file);
+        fileReader.onload = (e) => {
+            setError("");
+            setName(file.name);
+            dispatch(set({
+                id: field.id,
+                value: e.target.result.split(",")[1],
+            }));
+        };
+    }
+
+    const handleClear = () => {
+        setName("");
+        dispatch(remove(field.id));
+    };
+
+    return (
+        <Stack spacing={1}>
+            <Stack spacing={2} direction="row" alignItems="center">
+                <Button
+                    variant="contained"
+                    component="label"
+                    startIcon={<UploadFileIcon htmlColor='white' />}
+                >
+                    Upload File
+                    <input
+                        accept={(field.accept || []).join(",")}
+                        type="file"
+                        hidden
+                        onChange={handleCapture}
+                    />
+                </Button>
+                {name &&
+                    <Button
+                        variant="contained"
+                        onClick={handleClear}
+                        startIcon={<DeleteIcon htmlColor='white' />}
+                    >
+                        Clear
+                    </Button>
+                }
+                <Typography>{name}</Typography>
+            </Stack>
+            {error && <Typography color="error">{error}</Typography>}
+        </Stack>
+    );
+}
@@ -20,6 +20,18 @@ export default function Generated({ field }) {
     }, [schema])
 
     const onChange = (source_field) => {
+        if (field.sources) {
+            // handlers with several sources get the whole config
+            const values = {};
+            Object.entries(config).forEach(([id, item]) => {
+                values[id] = item.value;
+            });
+            if (field.sources.some((id) => id in values)) {
+                callGeneratedHandler(field.id, field.handler, JSON.stringify(values));
+            }
+            return;
+        }
+
         if (source_field && source_field.id in config) {
             callGeneratedHandler(field.id, field.handler, config[source_field.id].value);
         }
@@ -30,13 +42,13 @@ export default function Generated({ field }) {
             return null;
         }
 
-        for (let i = 0; i < schema.value.schema.length; i++) {
-            if (schema.value.schema[i].id === field.source) {
-                return schema.value.schema[i];
-            }
+        const ids = schema.value.schema.map((f) => f.id);
+        const missing = (field.sources || [field.source]).filter((id) => !ids.includes(id));
+        if (missing.length == 0) {
+            return schema.value.schema[ids.indexOf(field.source)];
         }
 
-        let msg = `schema.Generated references source that does not exist: ${field.source}`;
+        let msg = `schema.Generated references source that does not exist: ${missing.join(', ')}`;
         dispatch(setError({ id: msg, message: msg }));
         return null;
     }
@@ -0,0 +1,72 @@
+import React, { useState, useEffect } from 'react';
+import { useSelector, useDispatch } from 'react-redux';
+
+import axios from 'axios';
+import Autocomplete from '@mui/material/Autocomplete';
+import Box from '@mui/material/Box';
+import TextField from '@mui/material/TextField';
+
+import FieldIcon from '../FieldIcon';
+import { set, remove } from '../../config/configSlice';
+
+
+export default function Icon({ field }) {
+    const [value, setValue] = useState(null);
+    const [icons, setIcons] = useState(field.icons || []);
+    const config = useSelector(state => state.config);
+    const dispatch = useDispatch();
+
+    // Fields without their own list of icons offer the full set.
+    useEffect(() => {
+        if (field.icons) {
+            return;
+        }
+        axios.get(`${PIXLET_API_BASE}/api/v1/icons`)
+            .then(res => setIcons(res.data))
+            .catch(console.log);
+    }, [field.icons]);
+
+    useEffect(() => {
+        let name = field.default;
+        if (field.id in config) {
+            name = config[field.id].value;
+        } else if (field.default) {
+            dispatch(set({
+                id: field.id,
+                value: field.default,
+            }));
+        }
+        setValue(icons.find((icon) => icon.name === name) || null);
+    }, [config, icons]);
+
+    const onChange = (event, newValue) => {
+        setValue(newValue);
+        if (newValue) {
+            dispatch(set({
+                id: field.id,
+                value: newValue.name,
+            }));
+        } else {
+            dispatch(remove(field.id));
+        }
+    }
+
+    return (
+        <Autocomplete
+            fullWidth
+            disablePortal
+            value={value}
+            onChange={onChange}
+            options={icons}
+            getOptionLabel={(icon) => icon.label}
+            isOptionEqualToValue={(icon, v) => icon.name === v.name}
+            renderOption={(props, icon) => (
+                <Box component="li" sx={{ '& > svg': { mr: 2 } }} {...props}>
+                    <FieldIcon icon={icon.name} />
+                    {icon.label}
+                </Box>
+            )}
+            renderInput={(params) => <TextField fullWidth {...params} label={field.name} />}
+        />
+    )
+}
@@ -0,0 +1,67 @@
+import React, { useState, useEffect } from 'react';
+import { useSelector, useDispatch } from 'react-redux';
+
+import Autocomplete from '@mui/material/Autocomplete';
+import TextField from '@mui/material/TextField';
+
+import { set, remove } from '../../config/configSlice';
+import { callHandler } from '../../handlers/actions';
+
+
+export default function MultiSelect({ field }) {
+    const [value, setValue] = useState(field.default ? JSON.parse(field.default) : []);
+    const config = useSelector(state => state.config);
+    const dispatch = useDispatch();
+    const handlerResults = useSelector(state => state.handlers)
+
+    useEffect(() => {
+        if (field.id in config) {
+            setValue(JSON.parse(config[field.id].value));
+        } else if (field.default) {
+            dispatch(set({
+                id: field.id,
+                value: field.default,
+            }));
+        }
+    }, [config])
+
+    const onChange = (event, newValue) => {
+        if (field.max && newValue.length > field.max) {
+            return;
+        }
+
+        setValue(newValue);
+        if (newValue.length) {
+            dispatch(set({
+                id: field.id,
+                value: JSON.stringify(newValue),
+            }))
+        } else {
+            dispatch(remove(field.id));
+        }
+    }
+
+    let options = field.options || [];
+    if (field.handler && field.id in handlerResults.values) {
+        options = handlerResults.values[field.id];
+    }
+
+    return (
+        <Autocomplete
+            multiple
+            fullWidth
+            disablePortal
+            value={value}
+            onInputChange={(event, v) => {
+                if (field.handler) {
+                    callHandler(field.id, field.handler, v);
+                }
+            }}
+            onChange={onChange}
+            options={options}
+            getOptionLabel={(option) => option.display}
+            isOptionEqualToValue={(option, v) => option.value == v.value}
+            renderInput={(params) => <TextField fullWidth {...params} label={field.name} />}
+        />
+    )
+}
@@ -0,0 +1,106 @@
+import React, { useState, useEffect } from 'react';
+import { useSelector, useDispatch } from 'react-redux';
+
+import FormControl from '@mui/material/FormControl';
+import FormControlLabel from '@mui/material/FormControlLabel';
+import InputLabel from '@mui/material/InputLabel';
+import MenuItem from '@mui/material/MenuItem';
+import Select from '@mui/material/Select';
+import Stack from '@mui/material/Stack';
+import Switch from '@mui/material/Switch';
+import TextField from '@mui/material/TextField';
+
+import { set } from '../../config/configSlice';
+
+
+const defaultQuietHours = { start: '22:00', end: '07:00' };
+
+export default function NotificationSettings({ field }) {
+    const [value, setValue] = useState(JSON.parse(field.default));
+    const config = useSelector(state => state.config);
+    const dispatch = useDispatch();
+
+    useEffect(() => {
+        if (field.id in config) {
+            setValue(JSON.parse(config[field.id].value));
+        } else {
+            dispatch(set({
+                id: field.id,
+                value: field.default,
+            }));
+        }
+    }, [config])
+
+    const update = (changes) => {
+        const updated = { ...value, ...changes };
+        if (!updated.quiet_hours) {
+            delete updated.quiet_hours;
+        }
+
+        setValue(updated);
+        dispatch(set({
+            id: field.id,
+            value: JSON.stringify(updated),
+        }));
+    }
+
+    const setQuietHours = (changes) => {
+        update({ quiet_hours: { ...value.quiet_hours, ...changes } });
+    }
+
+    return (
+        <Stack spacing={2}>
+            <FormControlLabel
+                label="Enabled"
+                control={
+                    <Switch
+                        checked={value.enabled}
+                        onChange={(event) => update({ enabled: event.target.checked })}
+                    />
+                }
+            />
+            <FormControl fullWidth disabled={!value.enabled}>
+                <InputLabel>Priority</InputLabel>
+                <Select
+                    value={value.priority}
+                    label="Priority"
+                    onChange={(event) => update({ priority: event.target.value })}
+                >
+                    {field.options.map((option) => {
+                        return <MenuItem key={option.value} value={option.value}>{option.display}</MenuItem>
+                    })}
+                </Select>
+            </FormControl>
+            <FormControlLabel
+                label="Quiet hours"
+                disabled={!value.enabled}
+                control={
+                    <Switch
+                        checked={!!value.quiet_hours}
+                        onChange={(event) => update({
+                            quiet_hours: event.target.checked ? defaultQuietHours : null,
+                        })}
+                    />
+                }
+            />
+            {value.quiet_hours &&
+                <Stack spacing={2} direction="row">
+                    <TextField
+                        type="time"
+                        label="From"
+                        disabled={!value.enabled}
+                        value={value.quiet_hours.start}
+                        onChange={(event) => setQuietHours({ start: event.target.value })}
+                    />
+                    <TextField
+                        type="time"
+                        label="Until"
+                        disabled={!value.enabled}
+                        value={value.quiet_hours.end}
+                        onChange={(event) => setQuietHours({ end: event.target.value })}
+                    />
+                </Stack>
+            }
+        </Stack>
+    );
+}
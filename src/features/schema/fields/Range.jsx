@@ -0,0 +1,61 @@
+import React, { useState, useEffect } from 'react';
+import { useSelector, useDispatch } from 'react-redux';
+
+import Box from '@mui/material/Box';
+import MuiSlider from '@mui/material/Slider';
+import Typography from '@mui/material/Typography';
+
+import { set } from '../../config/configSlice';
+
+
+function parseRange(value) {
+    const range = JSON.parse(value);
+    return [range.min, range.max];
+}
+
+export default function Range({ field }) {
+    const [value, setValue] = useState(parseRange(field.default));
+    const config = useSelector(state => state.config);
+    const dispatch = useDispatch();
+
+    useEffect(() => {
+        if (field.id in config) {
+            setValue(parseRange(config[field.id].value));
+        } else {
+            dispatch(set({
+                id: field.id,
+                value: field.default,
+            }));
+        }
+    }, [config])
+
+    const onChange = (event, newValue) => {
+        setValue(newValue);
+    }
+
+    const onChangeCommitted = (event, newValue) => {
+        dispatch(set({
+            id: field.id,
+            value: JSON.stringify({ min: newValue[0], max: newValue[1] }),
+        }));
+    }
+
+    const label = (v) => field.unit ? `${v} ${field.unit}` : `${v}`;
+
+    return (
+        <Box sx={{ width: 300 }}>
+            <Typography>{label(value[0])} – {label(value[1])}</Typography>
+            <MuiSlider
+                value={value}
+                min={field.min}
+                max={field.max}
+                step={field.step}
+                disableSwap
+                valueLabelDisplay="auto"
+                valueLabelFormat={label}
+                onChange={onChange}
+                onChangeCommitted={onChangeCommitted}
+            />
+        </Box>
+    );
+}
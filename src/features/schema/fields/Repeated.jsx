@@ -0,0 +1,127 @@
+import React, { useState, useEffect } from 'react';
+import { useSelector, useDispatch } from 'react-redux';
+
+import Button from '@mui/material/Button';
+import Card from '@mui/material/Card';
+import CardActions from '@mui/material/CardActions';
+import CardContent from '@mui/material/CardContent';
+import FormControlLabel from '@mui/material/FormControlLabel';
+import MenuItem from '@mui/material/MenuItem';
+import Stack from '@mui/material/Stack';
+import Switch from '@mui/material/Switch';
+import TextField from '@mui/material/TextField';
+
+import { set, remove } from '../../config/configSlice';
+
+
+function ItemField({ field, value, onChange }) {
+    switch (field.type) {
+        case 'onoff':
+            return (
+                <FormControlLabel
+                    label={field.name}
+                    control={<Switch
+                        checked={JSON.parse(value || 'false')}
+                        onChange={(event) => onChange(JSON.stringify(event.target.checked))}
+                    />}
+                />
+            );
+        case 'dropdown':
+            return (
+                <TextField select fullWidth label={field.name} value={value || ''} onChange={(event) => onChange(event.target.value)}>
+                    {field.options.map((option) => {
+                        return <MenuItem key={option.value} value={option.value}>{option.display}</MenuItem>
+                    })}
+                </TextField>
+            );
+        case 'slider':
+        case 'duration':
+            return (
+                <TextField
+                    fullWidth
+                    type="number"
+                    label={field.name}
+                    value={value || ''}
+                    inputProps={{ min: field.min, max: field.max, step: field.step }}
+                    onChange={(event) => onChange(event.target.value)}
+                />
+            );
+        default:
+            return <TextField fullWidth label={field.name} value={value || ''} onChange={(event) => onChange(event.target.value)} />;
+    }
+}
+
+export default function Repeated({ field }) {
+    const [items, setItems] = useState(field.default ? JSON.parse(field.default) : []);
+    const config = useSelector(state => state.config);
+    const dispatch = useDispatch();
+
+    useEffect(() => {
+        if (field.id in config) {
+            setItems(JSON.parse(config[field.id].value));
+        } else if (field.default) {
+            dispatch(set({
+                id: field.id,
+                value: field.default,
+            }));
+        }
+    }, [config])
+
+    const update = (next) => {
+        setItems(next);
+        if (next.length) {
+            dispatch(set({
+                id: field.id,
+                value: JSON.stringify(next),
+            }));
+        } else {
+            dispatch(remove(field.id));
+        }
+    }
+
+    const newItem = () => {
+        const item = {};
+        field.fields.forEach((f) => {
+            if (f.default) {
+                item[f.id] = f.default;
+            }
+        });
+        return item;
+    }
+
+    const onChange = (index, id) => (value) => {
+        const next = [...items];
+        next[index] = { ...next[index], [id]: value };
+        update(next);
+    }
+
+    return (
+        <Stack spacing={2}>
+            {items.map((item, i) => (
+                <Card key={i} variant="outlined">
+                    <CardContent>
+                        <Stack spacing={2}>
+                            {field.fields.map((f) => (
+                                <ItemField key={f.id} field={f} value={item[f.id]} onChange={onChange(i, f.id)} />
+                            ))}
+                        </Stack>
+                    </CardContent>
+                    <CardActions>
+                        <Button
+                            disabled={items.length <= (field.min || 0)}
+                            onClick={() => update(items.filter((_, j) => j != i))}
+                        >
+                            Remove
+                        </Button>
+                    </CardActions>
+                </Card>
+            ))}
+            <Button
+                disabled={field.max && items.length >= field.max}
+                onClick={() => update([...items, newItem()])}
+            >
+                Add {field.name}
+            </Button>
+        </Stack>
+    );
+}
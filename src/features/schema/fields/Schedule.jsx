@@ -0,0 +1,89 @@
+import React, { useState, useEffect } from 'react';
+import { useSelector, useDispatch } from 'react-redux';
+
+import FormControlLabel from '@mui/material/FormControlLabel';
+import Stack from '@mui/material/Stack';
+import Switch from '@mui/material/Switch';
+import TextField from '@mui/material/TextField';
+import ToggleButton from '@mui/material/ToggleButton';
+import ToggleButtonGroup from '@mui/material/ToggleButtonGroup';
+
+import { set } from '../../config/configSlice';
+
+
+const defaultWindow = { start: '07:00', end: '09:00' };
+
+export default function Schedule({ field }) {
+    const [value, setValue] = useState(JSON.parse(field.default));
+    const config = useSelector(state => state.config);
+    const dispatch = useDispatch();
+
+    useEffect(() => {
+        if (field.id in config) {
+            setValue(JSON.parse(config[field.id].value));
+        } else {
+            dispatch(set({
+                id: field.id,
+                value: field.default,
+            }));
+        }
+    }, [config])
+
+    const update = (changes) => {
+        const updated = { ...value, ...changes };
+        if (!updated.start) {
+            delete updated.start;
+            delete updated.end;
+        }
+
+        // keep the days in week order, however they were picked
+        const order = field.options.map((option) => option.value);
+        updated.days = order.filter((day) => updated.days.includes(day));
+
+        setValue(updated);
+        dispatch(set({
+            id: field.id,
+            value: JSON.stringify(updated),
+        }));
+    }
+
+    return (
+        <Stack spacing={2}>
+            <ToggleButtonGroup
+                value={value.days}
+                onChange={(event, days) => update({ days: days })}
+            >
+                {field.options.map((option) => {
+                    return <ToggleButton key={option.value} value={option.value}>{option.display}</ToggleButton>
+                })}
+            </ToggleButtonGroup>
+            {field.with_time_window &&
+                <FormControlLabel
+                    label="Only at certain times"
+                    control={
+                        <Switch
+                            checked={!!value.start}
+                            onChange={(event) => update(event.target.checked ? defaultWindow : { start: null })}
+                        />
+                    }
+                />
+            }
+            {field.with_time_window && value.start &&
+                <Stack spacing={2} direction="row">
+                    <TextField
+                        type="time"
+                        label="From"
+                        value={value.start}
+                        onChange={(event) => update({ start: event.target.value })}
+                    />
+                    <TextField
+                        type="time"
+                        label="Until"
+                        value={value.end}
+                        onChange={(event) => update({ end: event.target.value })}
+                    />
+                </Stack>
+            }
+        </Stack>
+    );
+}
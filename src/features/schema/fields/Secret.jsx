@@ -0,0 +1,59 @@
+import React, { useState, useEffect } from 'react';
+import { useSelector, useDispatch } from 'react-redux';
+
+import IconButton from '@mui/material/IconButton';
+import InputAdornment from '@mui/material/InputAdornment';
+import TextField from '@mui/material/TextField';
+import Visibility from '@mui/icons-material/Visibility';
+import VisibilityOff from '@mui/icons-material/VisibilityOff';
+
+import { set } from '../../config/configSlice';
+
+
+export default function Secret({ field }) {
+    const config = useSelector(state => state.config);
+    const [value, setValue] = useState(() => {
+        if (field.id in config) {
+            return config[field.id].value;
+        }
+
+        return "";
+    });
+    const [shown, setShown] = useState(false);
+    const dispatch = useDispatch();
+
+    useEffect(() => {
+        if (field.id in config && config[field.id].value != value) {
+            setValue(config[field.id].value);
+        }
+    }, [config])
+
+    const onChange = (event) => {
+        setValue(event.target.value);
+        dispatch(set({
+            id: field.id,
+            value: event.target.value,
+        }));
+    }
+
+    return (
+        <TextField
+            fullWidth
+            value={value}
+            label={field.name}
+            variant="outlined"
+            type={shown ? 'text' : 'password'}
+            autoComplete="off"
+            onChange={onChange}
+            InputProps={{
+                endAdornment: (
+                    <InputAdornment position="end">
+                        <IconButton onClick={() => setShown(!shown)} edge="end">
+                            {shown ? <VisibilityOff /> : <Visibility />}
+                        </IconButton>
+                    </InputAdornment>
+                ),
+            }}
+        />
+    )
+}
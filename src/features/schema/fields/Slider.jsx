@@ -0,0 +1,55 @@
+import React, { useState, useEffect } from 'react';
+import { useSelector, useDispatch } from 'react-redux';
+
+import Box from '@mui/material/Box';
+import MuiSlider from '@mui/material/Slider';
+import Typography from '@mui/material/Typography';
+
+import { set } from '../../config/configSlice';
+
+
+export default function Slider({ field }) {
+    const [value, setValue] = useState(Number(field.default));
+    const config = useSelector(state => state.config);
+    const dispatch = useDispatch();
+
+    useEffect(() => {
+        if (field.id in config) {
+            setValue(Number(config[field.id].value));
+        } else {
+            dispatch(set({
+                id: field.id,
+                value: field.default,
+            }));
+        }
+    }, [config])
+
+    const onChange = (event, newValue) => {
+        setValue(newValue);
+    }
+
+    const onChangeCommitted = (event, newValue) => {
+        dispatch(set({
+            id: field.id,
+            value: String(newValue),
+        }));
+    }
+
+    const label = (v) => field.unit ? `${v} ${field.unit}` : `${v}`;
+
+    return (
+        <Box sx={{ width: 300 }}>
+            <Typography>{label(value)}</Typography>
+            <MuiSlider
+                value={value}
+                min={field.min}
+                max={field.max}
+                step={field.step}
+                valueLabelDisplay="auto"
+                valueLabelFormat={label}
+                onChange={onChange}
+                onChangeCommitted={onChangeCommitted}
+            />
+        </Box>
+    );
+}
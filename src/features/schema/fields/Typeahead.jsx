@@ -1,4 +1,4 @@
-import React, { useState, useEffect } from 'react';
+import React, { useState, useEffect, useRef } from 'react';
 import { useSelector, useDispatch } from 'react-redux';
 
 import Autocomplete from '@mui/material/Autocomplete';
@@ -6,6 +6,11 @@ import TextField from '@mui/material/TextField';
 
 import { set, remove } from '../../config/configSlice';
 import { callHandler } from '../../handlers/actions';
+import { update } from '../../handlers/handlerSlice';
+
+
+// Used when the field doesn't ask for a debounce of its own.
+const defaultDebounce = 300;
 
 
 export default function Typeahead({ field }) {
@@ -13,6 +18,8 @@ export default function Typeahead({ field }) {
     const config = useSelector(state => state.config);
     const dispatch = useDispatch();
     const handlerResults = useSelector(state => state.handlers)
+    const timeout = useRef(null);
+    const cache = useRef(new Map());
 
     useEffect(() => {
         if (field.id in config) {
@@ -33,6 +40,36 @@ export default function Typeahead({ field }) {
         }
     }
 
+    const search = (input) => {
+        window.clearTimeout(timeout.current);
+
+        if (input.length < (field.min_chars || 0)) {
+            dispatch(update({ id: field.id, value: [] }));
+            return;
+        }
+
+        const cached = cache.current.get(input);
+        if (cached && cached.expires > Date.now()) {
+            dispatch(update({ id: field.id, value: cached.value }));
+            return;
+        }
+
+        timeout.current = window.setTimeout(() => {
+            callHandler(field.id, field.handler, input, (result) => {
+                if (field.cache_ttl) {
+                    cache.current.set(input, {
+                        value: result,
+                        expires: Date.now() + field.cache_ttl * 1000,
+                    });
+                }
+            });
+        }, field.debounce_ms || defaultDebounce);
+    }
+
+    useEffect(() => {
+        return () => window.clearTimeout(timeout.current);
+    }, [])
+
     let options = [];
     if (field.id in handlerResults.values) {
         options = handlerResults.values[field.id];
@@ -43,9 +80,7 @@ export default function Typeahead({ field }) {
             fullWidth
             disablePortal
             value={value}
-            onInputChange={(event, v) => {
-                callHandler(field.id, field.handler, v);
-            }}
+            onInputChange={(event, v) => search(v)}
             onChange={onChange}
             options={options}
             getOptionLabel={(option) => option.display}
@@ -20,7 +20,9 @@ export default function LocationBased({ field }) {
         'lat': 40.678,
         'lng': -73.944,
         'locality': 'Brooklyn, New York',
-        'timezone': 'America/New_York'
+        'timezone': 'America/New_York',
+        'country': 'US',
+        'elevation': '23'
     });
 
     const [value, setValue] = useState(field.default);
@@ -20,6 +20,8 @@ export default function LocationForm({ field }) {
         'lng': -73.944,
         'locality': 'Brooklyn, New York',
         'timezone': 'America/New_York',
+        'country': 'US',
+        'elevation': '23',
         // But overwrite with app-specific defaults set in config.
         ...field.default
     });
@@ -69,6 +71,14 @@ export default function LocationForm({ field }) {
         setPart('timezone', event.target.value);
     }
 
+    const onChangeCountry = (event) => {
+        setPart('country', event.target.value.toUpperCase());
+    }
+
+    const onChangeElevation = (event) => {
+        setPart('elevation', event.target.value);
+    }
+
     return (
         <FormControl fullWidth>
             <Typography>Latitude</Typography>
@@ -106,6 +116,23 @@ export default function LocationForm({ field }) {
                     return <MenuItem value={zone}>{zone}</MenuItem>
                 })}
             </Select>
+            <Typography>Country</Typography>
+            <TextField
+                fullWidth
+                variant="outlined"
+                onChange={onChangeCountry}
+                style={{ marginBottom: '0.5rem' }}
+                inputProps={{ maxLength: 2 }}
+                defaultValue={value['country']}
+            />
+            <Typography>Elevation (meters)</Typography>
+            <TextField
+                fullWidth
+                type="number"
+                variant="outlined"
+                onChange={onChangeElevation}
+                defaultValue={value['elevation']}
+            />
         </FormControl>
     );
 }
\ No newline at end of file
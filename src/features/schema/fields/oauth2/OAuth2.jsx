@@ -1,19 +1,23 @@
 import React, { useState, useEffect } from 'react';
 import { useDispatch, useSelector } from 'react-redux';
-import OAuth2Login from 'react-simple-oauth2-login';
+import axios from 'axios';
 
 import Button from '@mui/material/Button';
+import Stack from '@mui/material/Stack';
 
-import { callHandlerSetValue } from '../../../handlers/actions';
+import { fetchSavedConfig } from '../../../config/actions';
 import { set as setError } from '../../../errors/errorSlice';
 import { set, remove } from '../../../config/configSlice';
 
 
+// Logins go through serve, which redirects to the provider, handles the
+// redirect back, and saves the token the app's handler returns.
 export default function OAuth2({ field }) {
     const [loggedIn, setLoggedIn] = useState("");
+    const [extraScopes, setExtraScopes] = useState(false);
     const dispatch = useDispatch();
     const config = useSelector(state => state.config);
-    const redirectUri = document.location.protocol + "//" + document.location.host + "/oauth-callback"
+    const base = `${PIXLET_API_BASE}/api/v1/oauth/${encodeURIComponent(field.id)}`;
 
     useEffect(() => {
         if (field.id in config) {
@@ -21,26 +25,52 @@ export default function OAuth2({ field }) {
         }
     }, [config])
 
-    const onSuccess = (response) => {
-        if (!response.code) {
-            return onFailure("access was not granted");
+    const setValue = (value) => {
+        setLoggedIn(value);
+        dispatch(set({
+            id: field.id,
+            value: value,
+        }));
+    }
+
+    const login = (more) => {
+        const popup = window.open(`${base}/login${more ? '?more=true' : ''}`, field.id, 'width=600,height=700');
+        if (!popup) {
+            return onFailure("the login window was blocked");
         }
 
-        callHandlerSetValue(field.id, field.handler, {
-            code: response.code,
-            client_id: field.client_id,
-            redirect_uri: redirectUri,
-            grant_type: "authorization_code",
-        }, (value) => {
-            setLoggedIn(value);
-            dispatch(set({
-                id: field.id,
-                value: value,
-            }));
-        });
+        // The callback page posts how the login went, and closes itself.
+        const onMessage = (event) => {
+            if (event.origin !== document.location.origin || event.data.message !== 'pixlet-oauth' || event.data.field !== field.id) {
+                return;
+            }
+            window.removeEventListener('message', onMessage);
+
+            if (event.data.error) {
+                return onFailure(event.data.error);
+            }
+            fetchSavedConfig().then(values => {
+                if (field.id in values) {
+                    setValue(values[field.id]);
+                }
+            });
+        };
+        window.addEventListener('message', onMessage);
+    }
+
+    const refresh = () => {
+        axios.post(`${base}/refresh`)
+            .then(res => setValue(res.data.value))
+            .catch(err => onFailure((err.response && err.response.data.error) || err.message));
+    }
+
+    const requestMore = () => {
+        setExtraScopes(true);
+        login(true);
     }
 
     const logout = () => {
+        axios.delete(base).catch(err => console.error(err));
         setLoggedIn("");
         dispatch(remove(field.id));
     }
@@ -51,41 +81,32 @@ export default function OAuth2({ field }) {
         console.error(response);
     }
 
-    const renderButton = (params) => {
-        return (
-            <Button
-                variant="contained"
-                onClick={params.onClick}
-            >
-                Login
-            </Button >
-        )
-    }
-
-
     if (loggedIn) {
         return (
-            <Button
-                variant="contained"
-                onClick={logout}
-            >
-                Logout
-            </Button>
+            <Stack spacing={2} direction="row">
+                <Button
+                    variant="contained"
+                    onClick={logout}
+                >
+                    Logout
+                </Button>
+                {field.refresh_handler &&
+                    <Button variant="outlined" onClick={refresh}>
+                        Refresh Token
+                    </Button>
+                }
+                {field.optional_scopes && !extraScopes &&
+                    <Button variant="outlined" onClick={requestMore}>
+                        Request More Access
+                    </Button>
+                }
+            </Stack>
         )
     }
 
-    let scope = field.scopes.join(" ");
     return (
-        <OAuth2Login
-            isCrossOrigin={true}
-            authorizationUrl={field.authorization_endpoint}
-            responseType="code"
-            scope={scope}
-            state="abc123"
-            clientId={field.client_id}
-            redirectUri={redirectUri}
-            render={renderButton}
-            onSuccess={onSuccess}
-            onFailure={onFailure} />
+        <Button variant="contained" onClick={() => login(false)}>
+            Login
+        </Button>
     )
-}
\ No newline at end of file
+}
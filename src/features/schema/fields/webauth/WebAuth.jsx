@@ -0,0 +1,78 @@
+import React, { useState, useEffect } from 'react';
+import { useDispatch, useSelector } from 'react-redux';
+
+import Button from '@mui/material/Button';
+
+import { callHandlerSetValue } from '../../../handlers/actions';
+import { set as setError } from '../../../errors/errorSlice';
+import { set, remove } from '../../../config/configSlice';
+
+
+export default function WebAuth({ field }) {
+    const [loggedIn, setLoggedIn] = useState("");
+    const dispatch = useDispatch();
+    const config = useSelector(state => state.config);
+    const redirectUri = document.location.protocol + "//" + document.location.host + "/webauth-callback"
+
+    useEffect(() => {
+        if (field.id in config) {
+            setLoggedIn(config[field.id].value);
+        }
+    }, [config])
+
+    const login = () => {
+        const url = new URL(field.url);
+        url.searchParams.set(field.redirect_param || 'redirect_uri', redirectUri);
+
+        const popup = window.open(url.toString(), field.id, 'width=600,height=700');
+        if (!popup) {
+            return onFailure("the login window was blocked");
+        }
+
+        // The callback page posts whatever the service redirected back
+        // with, and closes itself.
+        const onMessage = (event) => {
+            if (event.origin !== document.location.origin || event.data.message !== 'webAuthResult') {
+                return;
+            }
+            window.removeEventListener('message', onMessage);
+
+            callHandlerSetValue(field.id, field.handler, {
+                params: event.data.params,
+                redirect_uri: redirectUri,
+            }, (value) => {
+                setLoggedIn(value);
+                dispatch(set({
+                    id: field.id,
+                    value: value,
+                }));
+            });
+        };
+        window.addEventListener('message', onMessage);
+    }
+
+    const logout = () => {
+        setLoggedIn("");
+        dispatch(remove(field.id));
+    }
+
+    const onFailure = (response) => {
+        let msg = `failed login: ${response}`;
+        dispatch(setError({ id: msg, message: msg }));
+        console.error(response);
+    }
+
+    if (loggedIn) {
+        return (
+            <Button variant="contained" onClick={logout}>
+                Logout
+            </Button>
+        )
+    }
+
+    return (
+        <Button variant="contained" onClick={login}>
+            Login
+        </Button>
+    )
+}
@@ -0,0 +1,38 @@
+import React from 'react';
+
+import { useEffect } from 'react';
+import CircularProgress from '@mui/material/CircularProgress';
+import Grid from '@mui/material/Grid';
+
+
+export default function WebAuthHandler() {
+    useEffect(() => {
+        // Services put the result in either the query string or the
+        // fragment, so pass along both.
+        const params = {};
+        const hash = new URLSearchParams(window.location.hash.replace(/^#/, ''));
+        const search = new URLSearchParams(window.location.search);
+        hash.forEach((value, key) => { params[key] = value; });
+        search.forEach((value, key) => { params[key] = value; });
+
+        if (window.opener) {
+            window.opener.postMessage({ message: "webAuthResult", params: params }, window.location.origin);
+            window.close();
+        }
+    }, []);
+
+    return (
+        <Grid
+            container
+            spacing={0}
+            direction="column"
+            alignItems="center"
+            justifyContent="center"
+            style={{ minHeight: '100vh' }}
+        >
+            <Grid item xs={3}>
+                <CircularProgress />
+            </Grid>
+        </Grid>
+    )
+}
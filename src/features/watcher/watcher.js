@@ -1,6 +1,6 @@
 import store from '../../store';
 import { update } from '../preview/previewSlice';
-import { update as updateSchema } from '../schema/schemaSlice';
+import refreshSchema from '../schema/actions';
 import { set as setError, clear as clearErrors } from '../errors/errorSlice';
 
 export default class Watcher {
@@ -10,7 +10,7 @@ export default class Watcher {
 
     connect() {
         const proto = document.location.protocol === "https:" ? "wss:" : "ws:";
-        this.conn = new WebSocket(proto + '//' + document.location.host + '/api/v1/ws');
+        this.conn = new WebSocket(proto + '//' + document.location.host + PIXLET_API_BASE + '/api/v1/ws');
         this.conn.open = this.open.bind(this);
         this.conn.onmessage = this.process.bind(this);
         this.conn.onclose = this.close.bind(this);
@@ -35,7 +35,9 @@ export default class Watcher {
                 store.dispatch(clearErrors());
                 break;
             case 'schema':
-                store.dispatch(updateSchema(JSON.parse(data.message)));
+                // fetch the schema rather than using the one pushed to
+                // us, so that it's translated for the browser's language
+                refreshSchema();
                 break;
             case 'error':
                 store.dispatch(setError({ id: data.message, message: data.message }));
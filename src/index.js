@@ -4,7 +4,7 @@ import { Provider } from 'react-redux';
 import { BrowserRouter, Route, Routes } from "react-router-dom";
 
 import Main from './Main';
-import OAuth2Handler from './features/schema/fields/oauth2/OAuth2Handler';
+import WebAuthHandler from './features/schema/fields/webauth/WebAuthHandler';
 import store from './store';
 import DevToolsTheme from './features/theme/DevToolsTheme';
 
@@ -12,10 +12,10 @@ const App = () => {
     return (
         <Provider store={store}>
             <DevToolsTheme>
-                <BrowserRouter>
+                <BrowserRouter basename={PIXLET_API_BASE || '/'}>
                     <Routes>
                         <Route exact path="/" element={<Main />} />
-                        <Route path="oauth-callback" element={<OAuth2Handler />} />
+                        <Route path="webauth-callback" element={<WebAuthHandler />} />
                     </Routes>
                 </BrowserRouter>
             </DevToolsTheme>
@@ -6,6 +6,8 @@ import handlerSlice from './features/handlers/handlerSlice';
 import paramSlice from './features/config/paramSlice';
 import previewSlice from './features/preview/previewSlice';
 import schemaSlice from './features/schema/schemaSlice';
+import simulationSlice from './features/preview/simulationSlice';
+import sizeSlice from './features/preview/sizeSlice';
 
 export default configureStore({
     reducer: {
@@ -15,5 +17,7 @@ export default configureStore({
         param: paramSlice,
         preview: previewSlice,
         schema: schemaSlice,
+        simulation: simulationSlice,
+        size: sizeSlice,
     },
 });
\ No newline at end of file
@@ -0,0 +1,30 @@
+package starlarkutil
+
+import (
+	"log/slog"
+
+	"go.starlark.net/starlark"
+)
+
+const (
+	// ThreadLoggerKey is the name of the Starlark thread-local that we use
+	// to pass the app's logger to modules.
+	ThreadLoggerKey = "tidbyt.dev/pixlet/starlarkutil/$logger"
+)
+
+// AttachThreadLogger attaches a logger to a Starlark thread so that modules
+// can retrieve it with `ThreadLogger`.
+func AttachThreadLogger(logger *slog.Logger, thread *starlark.Thread) {
+	thread.SetLocal(ThreadLoggerKey, logger)
+}
+
+// ThreadLogger returns the logger that was attached to a Starlark thread
+// by `AttachThreadLogger`. If no logger is attached to the thread, it
+// returns slog.Default().
+func ThreadLogger(thread *starlark.Thread) *slog.Logger {
+	logger, ok := thread.Local(ThreadLoggerKey).(*slog.Logger)
+	if !ok || logger == nil {
+		logger = slog.Default()
+	}
+	return logger
+}
@@ -0,0 +1,23 @@
+package starlarkutil
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.starlark.net/starlark"
+)
+
+func TestThreadLogger(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	thread := &starlark.Thread{}
+	AttachThreadLogger(logger, thread)
+	assert.Same(t, logger, ThreadLogger(thread))
+}
+
+func TestThreadWithoutLogger(t *testing.T) {
+	thread := &starlark.Thread{}
+	assert.Same(t, slog.Default(), ThreadLogger(thread))
+}
@@ -0,0 +1,228 @@
+// Package coverage measures which statements of a Starlark app run. It
+// rewrites the app's files so that every statement in a function body
+// first calls a counting builtin. The call goes on the same line as the
+// statement, so line numbers in errors stay the same.
+package coverage
+
+import (
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing/fstest"
+	"unicode/utf8"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+// HitFunc is the name of the builtin that instrumented files call.
+const HitFunc = "pixlet_coverage_hit"
+
+// Profile counts how often each statement ran.
+type Profile struct {
+	mu sync.Mutex
+
+	// counts maps files to the lines of their statements, and how
+	// often each ran.
+	counts map[string]map[int]int
+}
+
+// FileCoverage is the coverage of a single file.
+type FileCoverage struct {
+	File       string
+	Statements int
+	Covered    int
+
+	// Missed are the lines of the statements that never ran.
+	Missed []int
+}
+
+// Percent returns the share of statements that ran.
+func (fc FileCoverage) Percent() float64 {
+	if fc.Statements == 0 {
+		return 100
+	}
+	return 100 * float64(fc.Covered) / float64(fc.Statements)
+}
+
+func NewProfile() *Profile {
+	return &Profile{counts: map[string]map[int]int{}}
+}
+
+// Instrument returns a copy of fsys where every Starlark file counts its
+// statements into the profile. Apps loaded from it need the builtin
+// returned by Predeclared.
+func (p *Profile) Instrument(fsys fs.FS) (fs.FS, error) {
+	out := fstest.MapFS{}
+
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		data, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return err
+		}
+
+		if strings.HasSuffix(path, ".star") {
+			data, err = p.instrumentFile(path, data)
+			if err != nil {
+				return err
+			}
+		}
+
+		out[path] = &fstest.MapFile{Data: data}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// Predeclared returns the builtin that instrumented files call.
+func (p *Profile) Predeclared() starlark.StringDict {
+	return starlark.StringDict{
+		HitFunc: starlark.NewBuiltin(HitFunc, p.hit),
+	}
+}
+
+func (p *Profile) hit(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var (
+		file string
+		line int
+	)
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 2, &file, &line); err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if lines, ok := p.counts[file]; ok {
+		lines[line]++
+	}
+
+	return starlark.None, nil
+}
+
+// Files returns the coverage of each instrumented file, sorted by name.
+func (p *Profile) Files() []FileCoverage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	files := []FileCoverage{}
+	for file, lines := range p.counts {
+		fc := FileCoverage{File: file, Statements: len(lines), Missed: []int{}}
+		for line, count := range lines {
+			if count > 0 {
+				fc.Covered++
+			} else {
+				fc.Missed = append(fc.Missed, line)
+			}
+		}
+		sort.Ints(fc.Missed)
+		files = append(files, fc)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].File < files[j].File
+	})
+
+	return files
+}
+
+// Total returns the coverage of all files together.
+func (p *Profile) Total() FileCoverage {
+	total := FileCoverage{}
+	for _, fc := range p.Files() {
+		total.Statements += fc.Statements
+		total.Covered += fc.Covered
+	}
+	return total
+}
+
+func (p *Profile) instrumentFile(path string, src []byte) ([]byte, error) {
+	opts := &syntax.FileOptions{
+		Set:       true,
+		Recursion: true,
+	}
+	f, err := opts.Parse(path, src, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// only statements in functions count, since top level statements
+	// always run when the file is loaded
+	positions := []syntax.Position{}
+	var visit func(stmts []syntax.Stmt, inFunction bool)
+	visit = func(stmts []syntax.Stmt, inFunction bool) {
+		for _, stmt := range stmts {
+			switch stmt := stmt.(type) {
+			case *syntax.DefStmt:
+				visit(stmt.Body, true)
+			case *syntax.IfStmt:
+				visit(stmt.True, inFunction)
+				visit(stmt.False, inFunction)
+			case *syntax.ForStmt:
+				visit(stmt.Body, inFunction)
+			case *syntax.WhileStmt:
+				visit(stmt.Body, inFunction)
+			case *syntax.AssignStmt, *syntax.ExprStmt, *syntax.ReturnStmt, *syntax.BranchStmt:
+				if inFunction {
+					start, _ := stmt.Span()
+					positions = append(positions, start)
+				}
+			}
+		}
+	}
+	visit(f.Stmts, false)
+
+	lines := strings.SplitAfter(string(src), "\n")
+	counts := map[int]int{}
+
+	// insert from the end, so earlier columns stay valid
+	sort.Slice(positions, func(i, j int) bool {
+		if positions[i].Line != positions[j].Line {
+			return positions[i].Line > positions[j].Line
+		}
+		return positions[i].Col > positions[j].Col
+	})
+
+	for _, pos := range positions {
+		line := int(pos.Line)
+		if line < 1 || line > len(lines) {
+			return nil, fmt.Errorf("%s: statement outside of file at %s", path, pos)
+		}
+
+		text := lines[line-1]
+		offset := byteOffset(text, int(pos.Col))
+		call := fmt.Sprintf("%s(%s, %d); ", HitFunc, strconv.Quote(path), line)
+		lines[line-1] = text[:offset] + call + text[offset:]
+		counts[line] = 0
+	}
+
+	p.mu.Lock()
+	p.counts[path] = counts
+	p.mu.Unlock()
+
+	return []byte(strings.Join(lines, "")), nil
+}
+
+// byteOffset converts a 1-based rune column into a byte offset.
+func byteOffset(line string, col int) int {
+	offset := 0
+	for i := 1; i < col && offset < len(line); i++ {
+		_, size := utf8.DecodeRuneInString(line[offset:])
+		offset += size
+	}
+	return offset
+}
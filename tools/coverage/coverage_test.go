@@ -0,0 +1,69 @@
+package coverage
+
+import (
+	"context"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tidbyt.dev/pixlet/runtime"
+)
+
+const src = `load("render.star", "render")
+
+def label(n):
+    if n > 1:
+        return "many"
+    elif n == 1: return "one"
+    x = "none"; return x
+
+def main(config):
+    label(2)
+    label(1)
+    return render.Root(child = render.Text("é " + label(2)))
+`
+
+func TestInstrument(t *testing.T) {
+	p := NewProfile()
+
+	fsys, err := p.Instrument(fstest.MapFS{
+		"app.star":   {Data: []byte(src)},
+		"readme.txt": {Data: []byte("hello")},
+	})
+	require.NoError(t, err)
+
+	// other files are copied as is
+	readme, err := fs.ReadFile(fsys, "readme.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(readme))
+
+	app, err := runtime.NewAppletFromFS("app", fsys, runtime.WithPredeclared(p.Predeclared()))
+	require.NoError(t, err)
+
+	// nothing has run yet
+	assert.Equal(t, 0, p.Total().Covered)
+
+	_, err = app.Run(context.Background())
+	require.NoError(t, err)
+
+	files := p.Files()
+	require.Len(t, files, 1)
+
+	fc := files[0]
+	assert.Equal(t, "app.star", fc.File)
+	assert.Equal(t, 6, fc.Statements)
+	assert.Equal(t, 5, fc.Covered)
+	assert.Equal(t, []int{7}, fc.Missed)
+	assert.InDelta(t, 83.3, fc.Percent(), 0.1)
+}
+
+func TestInstrumentSyntaxError(t *testing.T) {
+	p := NewProfile()
+	_, err := p.Instrument(fstest.MapFS{
+		"app.star": {Data: []byte("def main(:\n")},
+	})
+	assert.Error(t, err)
+}
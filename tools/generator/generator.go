@@ -1,12 +1,16 @@
 package generator
 
 import (
-	_ "embed"
+	"bytes"
+	"embed"
 	"fmt"
 	"os"
 	"path"
+	"strings"
 	"text/template"
 
+	"github.com/bazelbuild/buildtools/build"
+	"github.com/bazelbuild/buildtools/warn"
 	"tidbyt.dev/pixlet/manifest"
 )
 
@@ -30,16 +34,43 @@ const (
 	Internal
 )
 
-//go:embed templates/source.star.tmpl
-var starSource string
+//go:embed templates
+var templates embed.FS
+
+// Template describes a starting point for a new app. Each template has a
+// Starlark source file and a test file for it.
+type Template struct {
+	Name string
+	Desc string
+}
+
+// Templates lists the templates apps can be generated from.
+var Templates = []Template{
+	{Name: "hello", Desc: "Says hello to whoever is configured"},
+	{Name: "api", Desc: "Shows data from a JSON API, with caching"},
+	{Name: "clock", Desc: "Shows the time in a configured location"},
+	{Name: "sports", Desc: "Shows the scores of today's games in a league"},
+	{Name: "slideshow", Desc: "Shows a series of images from URLs"},
+}
+
+// DefaultTemplate is the template used unless another one is picked.
+const DefaultTemplate = "hello"
 
 // Generator provides a structure for generating apps.
 type Generator struct {
 	starTmpl *template.Template
+	testTmpl *template.Template
 	appType  AppType
 	root     string
 }
 
+// templateData is what the templates are executed with. Module is the
+// file name of the app's source, for the test file to load.
+type templateData struct {
+	*manifest.Manifest
+	Module string
+}
+
 type appsDef struct {
 	Imports  []string
 	Packages []string
@@ -47,20 +78,47 @@ type appsDef struct {
 
 // NewGenerator creates an instantiated generator with the templates parsed.
 func NewGenerator(appType AppType, root string) (*Generator, error) {
-	starTmpl, err := template.New("star").Parse(starSource)
-	if err != nil {
+	g := &Generator{
+		appType: appType,
+		root:    root,
+	}
+
+	if err := g.UseTemplate(DefaultTemplate); err != nil {
 		return nil, err
 	}
 
-	return &Generator{
-		starTmpl: starTmpl,
-		appType:  appType,
-		root:     root,
-	}, nil
+	return g, nil
+}
+
+// UseTemplate sets the template that apps are generated from.
+func (g *Generator) UseTemplate(name string) error {
+	found := false
+	known := []string{}
+	for _, t := range Templates {
+		found = found || t.Name == name
+		known = append(known, t.Name)
+	}
+	if !found {
+		return fmt.Errorf("unknown template %q, pick one of: %s", name, strings.Join(known, ", "))
+	}
+
+	starTmpl, err := template.ParseFS(templates, path.Join("templates", name, "source.star.tmpl"))
+	if err != nil {
+		return err
+	}
+
+	testTmpl, err := template.ParseFS(templates, path.Join("templates", name, "test.star.tmpl"))
+	if err != nil {
+		return err
+	}
+
+	g.starTmpl = starTmpl
+	g.testTmpl = testTmpl
+	return nil
 }
 
-// GenerateApp creates the base app starlark, go package, and updates the app
-// list.
+// GenerateApp creates the app's manifest, its starlark source and a test file
+// for it, and returns the path of the source.
 func (g *Generator) GenerateApp(app *manifest.Manifest) (string, error) {
 	if g.appType == Community || g.appType == Internal {
 		err := g.createDir(app)
@@ -113,25 +171,45 @@ func (g *Generator) writeManifest(app *manifest.Manifest) error {
 func (g *Generator) generateStarlark(app *manifest.Manifest) (string, error) {
 	dir := manifest.GenerateDirName(app.Name)
 	fn := manifest.GenerateFileName(app.Name)
+	testFn := strings.TrimSuffix(fn, ".star") + "_test.star"
 
-	var p string
+	var p, testPath string
 	switch g.appType {
 	case Community, Internal:
 		p = path.Join(g.root, appsDir, dir, fn)
+		testPath = path.Join(g.root, appsDir, dir, testFn)
 	default:
 		p = path.Join(g.root, fn)
+		testPath = path.Join(g.root, testFn)
 	}
 
-	file, err := os.Create(p)
-	if err != nil {
+	data := templateData{Manifest: app, Module: fn}
+
+	if err := executeTemplate(g.starTmpl, p, data); err != nil {
 		return "", err
 	}
-	defer file.Close()
 
-	err = g.starTmpl.Execute(file, app)
-	if err != nil {
+	if err := executeTemplate(g.testTmpl, testPath, data); err != nil {
 		return "", err
 	}
 
 	return p, nil
 }
+
+// executeTemplate writes tmpl to p, formatted the way pixlet format
+// would. Loads are sorted here, since where the app's own module goes
+// depends on its name.
+func executeTemplate(tmpl *template.Template, p string, data templateData) error {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return err
+	}
+
+	f, err := build.ParseDefault(p, buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("parsing generated %s: %w", p, err)
+	}
+	warn.FixWarnings(f, []string{"out-of-order-load"}, false, nil)
+
+	return os.WriteFile(p, build.Format(f), 0644)
+}
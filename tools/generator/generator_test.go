@@ -0,0 +1,58 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tidbyt.dev/pixlet/manifest"
+	"tidbyt.dev/pixlet/runtime"
+	"tidbyt.dev/pixlet/tools/lint"
+)
+
+func TestGenerateTemplates(t *testing.T) {
+	for _, tmpl := range Templates {
+		t.Run(tmpl.Name, func(t *testing.T) {
+			root := t.TempDir()
+
+			g, err := NewGenerator(Local, root)
+			require.NoError(t, err)
+			require.NoError(t, g.UseTemplate(tmpl.Name))
+
+			p, err := g.GenerateApp(&manifest.Manifest{
+				ID:      "my-app",
+				Name:    "My App",
+				Summary: "Does things",
+				Desc:    "It does many things.",
+				Author:  "Tidbyt",
+			})
+			require.NoError(t, err)
+			assert.Equal(t, filepath.Join(root, "my_app.star"), p)
+			assert.FileExists(t, filepath.Join(root, "manifest.yaml"))
+
+			for _, fn := range []string{"my_app.star", "my_app_test.star"} {
+				src, err := os.ReadFile(filepath.Join(root, fn))
+				require.NoError(t, err)
+
+				findings, err := lint.File(fn, src)
+				require.NoError(t, err)
+				assert.Empty(t, findings)
+			}
+
+			app, err := runtime.NewAppletFromFS("my-app", os.DirFS(root))
+			require.NoError(t, err)
+			assert.Equal(t, "my_app.star", app.MainFile)
+			assert.NotEmpty(t, app.TestFuncs())
+			app.RunTests(t)
+		})
+	}
+}
+
+func TestUseUnknownTemplate(t *testing.T) {
+	g, err := NewGenerator(Local, t.TempDir())
+	require.NoError(t, err)
+	assert.ErrorContains(t, g.UseTemplate("weather"), "unknown template")
+}
@@ -0,0 +1,511 @@
+// Package lint finds mistakes in Tidbyt apps that Starlark itself
+// accepts, but that break apps or get them turned down in review.
+package lint
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"go.starlark.net/syntax"
+	"tidbyt.dev/pixlet/render"
+)
+
+// maxFixPasses bounds how often Fix goes over a file.
+const maxFixPasses = 10
+
+// DefaultTTLSeconds is how long responses are cached for when Fix adds a
+// missing ttl_seconds.
+const DefaultTTLSeconds = 300
+
+// Finding is a single problem found in an app.
+type Finding struct {
+	Pos     syntax.Position
+	Rule    string
+	Message string
+
+	// edits fix the problem, if it can be fixed without a person
+	// looking at it.
+	edits []edit
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s (%s)", f.Pos, f.Message, f.Rule)
+}
+
+// Fixable reports whether Fix can fix the problem.
+func (f Finding) Fixable() bool {
+	return len(f.edits) > 0
+}
+
+// edit replaces the bytes of the source from start to end.
+type edit struct {
+	start, end int
+	text       string
+}
+
+// deprecated lists the members of modules that shouldn't be used in new
+// apps anymore, with what to use instead.
+var deprecated = map[string]map[string]string{
+	"animation.star": {
+		"AnimatedPositioned": "animation.Transformation",
+	},
+}
+
+// movedModules maps module paths that other Starlark tools use, or that
+// Pixlet used before, to the path to load instead.
+var movedModules = map[string]string{
+	"base64.star":  "encoding/base64.star",
+	"csv.star":     "encoding/csv.star",
+	"gzip.star":    "compress/gzip.star",
+	"json.star":    "encoding/json.star",
+	"zipfile.star": "compress/zipfile.star",
+}
+
+// httpMethods are the members of the http module that make requests.
+var httpMethods = map[string]bool{
+	"get":    true,
+	"post":   true,
+	"put":    true,
+	"patch":  true,
+	"head":   true,
+	"delete": true,
+}
+
+// File lints the Starlark source in src, reporting problems sorted by
+// position.
+func File(filename string, src []byte) ([]Finding, error) {
+	opts := &syntax.FileOptions{
+		Set:       true,
+		Recursion: true,
+	}
+	f, err := opts.Parse(filename, src, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &linter{
+		src:     src,
+		loads:   map[string]loaded{},
+		defined: map[string]bool{},
+	}
+	l.collect(f)
+	l.check(f)
+
+	sort.SliceStable(l.findings, func(i, j int) bool {
+		return l.findings[i].Pos.Line < l.findings[j].Pos.Line ||
+			(l.findings[i].Pos.Line == l.findings[j].Pos.Line && l.findings[i].Pos.Col < l.findings[j].Pos.Col)
+	})
+
+	return l.findings, nil
+}
+
+// Fix rewrites src to fix the problems that don't need a person to look
+// at them: it removes unused loads, caches HTTP requests and cache.set
+// calls for DefaultTTLSeconds, and loads moved modules from their new
+// path. It returns the fixed source, which may need formatting, and the
+// problems that are left.
+func Fix(filename string, src []byte) ([]byte, []Finding, error) {
+	// edits that overlap are applied one at a time, so keep going until
+	// there's nothing left to fix
+	for pass := 0; ; pass++ {
+		findings, err := File(filename, src)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		edits := []edit{}
+		seen := map[edit]bool{}
+		for _, f := range findings {
+			for _, e := range f.edits {
+				// an edit can fix several findings, like a load with
+				// more than one unused symbol
+				if !seen[e] {
+					seen[e] = true
+					edits = append(edits, e)
+				}
+			}
+		}
+
+		if len(edits) == 0 || pass == maxFixPasses {
+			return src, findings, nil
+		}
+
+		src = applyEdits(src, edits)
+	}
+}
+
+// applyEdits applies edits to src, skipping those that overlap an edit
+// that was already applied.
+func applyEdits(src []byte, edits []edit) []byte {
+	// apply from the end, so earlier offsets stay valid
+	sort.Slice(edits, func(i, j int) bool {
+		return edits[i].start > edits[j].start
+	})
+
+	fixed := string(src)
+	limit := len(fixed)
+	for _, e := range edits {
+		if e.end > limit {
+			continue
+		}
+		fixed = fixed[:e.start] + e.text + fixed[e.end:]
+		limit = e.start
+	}
+
+	return []byte(fixed)
+}
+
+// loaded is a symbol brought in with load().
+type loaded struct {
+	module string
+	name   string
+	ident  *syntax.Ident
+}
+
+type linter struct {
+	src []byte
+
+	// loads maps local names to the symbols they were loaded as.
+	loads map[string]loaded
+
+	// defined holds every name the file binds, anywhere.
+	defined map[string]bool
+
+	// used holds the names that are referenced outside of load().
+	used map[string]bool
+
+	findings []Finding
+}
+
+func (l *linter) report(pos syntax.Position, rule string, format string, args ...interface{}) {
+	l.reportFix(pos, rule, nil, format, args...)
+}
+
+func (l *linter) reportFix(pos syntax.Position, rule string, edits []edit, format string, args ...interface{}) {
+	l.findings = append(l.findings, Finding{
+		Pos:     pos,
+		Rule:    rule,
+		Message: fmt.Sprintf(format, args...),
+		edits:   edits,
+	})
+}
+
+// offset converts a position in the source to a byte offset.
+func (l *linter) offset(pos syntax.Position) int {
+	offset := 0
+	for line := int32(1); line < pos.Line && offset < len(l.src); line++ {
+		next := bytes.IndexByte(l.src[offset:], '\n')
+		if next < 0 {
+			return len(l.src)
+		}
+		offset += next + 1
+	}
+
+	// columns count runes
+	for col := int32(1); col < pos.Col && offset < len(l.src); col++ {
+		_, size := utf8.DecodeRune(l.src[offset:])
+		offset += size
+	}
+
+	return offset
+}
+
+// collect records what the file loads and defines, and which names it
+// uses.
+func (l *linter) collect(f *syntax.File) {
+	loadIdents := map[*syntax.Ident]bool{}
+	for _, stmt := range f.Stmts {
+		load, ok := stmt.(*syntax.LoadStmt)
+		if !ok {
+			continue
+		}
+
+		module, _ := load.Module.Value.(string)
+		for i, to := range load.To {
+			l.loads[to.Name] = loaded{module: module, name: load.From[i].Name, ident: to}
+			l.defined[to.Name] = true
+			loadIdents[to] = true
+			loadIdents[load.From[i]] = true
+		}
+	}
+
+	// attribute names and keyword arguments aren't uses either, as in
+	// resp.json() and f(json = x)
+	l.used = map[string]bool{}
+	syntax.Walk(f, func(n syntax.Node) bool {
+		switch n := n.(type) {
+		case *syntax.DotExpr:
+			loadIdents[n.Name] = true
+		case *syntax.CallExpr:
+			for _, arg := range n.Args {
+				if bin, ok := arg.(*syntax.BinaryExpr); ok && bin.Op == syntax.EQ {
+					if ident, ok := bin.X.(*syntax.Ident); ok {
+						loadIdents[ident] = true
+					}
+				}
+			}
+		case *syntax.Ident:
+			if !loadIdents[n] {
+				l.used[n.Name] = true
+			}
+		case *syntax.DefStmt:
+			l.defined[n.Name.Name] = true
+			for _, param := range n.Params {
+				l.bind(param)
+			}
+		case *syntax.LambdaExpr:
+			for _, param := range n.Params {
+				l.bind(param)
+			}
+		case *syntax.AssignStmt:
+			l.bind(n.LHS)
+		case *syntax.ForStmt:
+			l.bind(n.Vars)
+		case *syntax.ForClause:
+			l.bind(n.Vars)
+		}
+		return true
+	})
+}
+
+func (l *linter) bind(e syntax.Expr) {
+	switch e := e.(type) {
+	case *syntax.Ident:
+		l.defined[e.Name] = true
+	case *syntax.TupleExpr:
+		for _, x := range e.List {
+			l.bind(x)
+		}
+	case *syntax.ListExpr:
+		for _, x := range e.List {
+			l.bind(x)
+		}
+	case *syntax.ParenExpr:
+		l.bind(e.X)
+	case *syntax.BinaryExpr:
+		// a parameter with a default value
+		l.bind(e.X)
+	case *syntax.UnaryExpr:
+		// *args and **kwargs
+		if e.X != nil {
+			l.bind(e.X)
+		}
+	}
+}
+
+func (l *linter) check(f *syntax.File) {
+	for _, stmt := range f.Stmts {
+		if load, ok := stmt.(*syntax.LoadStmt); ok {
+			l.checkLoad(load)
+		}
+	}
+
+	syntax.Walk(f, func(n syntax.Node) bool {
+		switch n := n.(type) {
+		case *syntax.DotExpr:
+			if module, member, ok := l.member(n); ok {
+				if instead, ok := deprecated[module][member]; ok {
+					start, _ := n.Span()
+					l.report(start, "deprecated", "%s is deprecated, use %s instead", member, instead)
+				}
+			}
+		case *syntax.CallExpr:
+			l.checkCall(n)
+		}
+		return true
+	})
+}
+
+func (l *linter) checkLoad(load *syntax.LoadStmt) {
+	module, _ := load.Module.Value.(string)
+
+	if moved, ok := movedModules[module]; ok {
+		fix := edit{
+			start: l.offset(load.Module.TokenPos),
+			end:   l.offset(load.Module.TokenPos) + len(load.Module.Raw),
+			text:  strconv.Quote(moved),
+		}
+		l.reportFix(load.Module.TokenPos, "module-path", []edit{fix}, "%s isn't a Pixlet module, load %s instead", module, moved)
+	}
+
+	// keep the symbols that are used
+	kept := []string{}
+	unused := []*syntax.Ident{}
+	for i, to := range load.To {
+		if !l.used[to.Name] {
+			unused = append(unused, to)
+			continue
+		}
+
+		if to.Name == load.From[i].Name {
+			kept = append(kept, strconv.Quote(to.Name))
+		} else {
+			kept = append(kept, fmt.Sprintf("%s = %s", to.Name, strconv.Quote(load.From[i].Name)))
+		}
+	}
+
+	if len(unused) == 0 {
+		return
+	}
+
+	start := l.offset(load.Load)
+	end := l.offset(load.Rparen) + 1
+
+	var fix edit
+	if len(kept) == 0 {
+		// drop the whole statement, with its line if it has one to
+		// itself
+		lineStart := strings.LastIndexByte(string(l.src[:start]), '\n') + 1
+		lineEnd := end + strings.IndexByte(string(l.src[end:])+"\n", '\n')
+		if strings.TrimSpace(string(l.src[lineStart:start])) == "" && strings.TrimSpace(string(l.src[end:lineEnd])) == "" {
+			start = lineStart
+			end = min(lineEnd+1, len(l.src))
+		}
+		fix = edit{start: start, end: end}
+	} else {
+		fix = edit{
+			start: start,
+			end:   end,
+			text:  fmt.Sprintf("load(%s, %s)", load.Module.Raw, strings.Join(kept, ", ")),
+		}
+	}
+
+	for _, ident := range unused {
+		module := l.loads[ident.Name].module
+		l.reportFix(ident.NamePos, "unused-load", []edit{fix}, "%s is loaded from %s but never used", ident.Name, module)
+	}
+}
+
+// member resolves x.y where x was loaded from a module, returning the
+// module and y.
+func (l *linter) member(dot *syntax.DotExpr) (string, string, bool) {
+	ident, ok := dot.X.(*syntax.Ident)
+	if !ok {
+		return "", "", false
+	}
+
+	sym, ok := l.loads[ident.Name]
+	if !ok {
+		return "", "", false
+	}
+
+	return sym.module, dot.Name.Name, true
+}
+
+func (l *linter) checkCall(call *syntax.CallExpr) {
+	dot, ok := call.Fn.(*syntax.DotExpr)
+	if !ok {
+		return
+	}
+
+	module, member, ok := l.member(dot)
+	if !ok {
+		return
+	}
+
+	positional, kwargs := splitArgs(call)
+	start, _ := call.Span()
+
+	switch module {
+	case "cache.star":
+		// cache.set(key, value, ttl_seconds = 60)
+		if member == "set" && positional < 3 && kwargs["ttl_seconds"] == nil {
+			l.reportFix(start, "cache-ttl", l.addTTL(call), "cache.set without ttl_seconds falls back to the default expiration, set it to how long the value stays fresh")
+		}
+
+	case "http.star":
+		if httpMethods[member] && kwargs["ttl_seconds"] == nil {
+			l.reportFix(start, "http-ttl", l.addTTL(call), "http.%s without ttl_seconds is only cached as long as the server says, often just a few seconds", member)
+		}
+
+	case "schema.star":
+		if handler, ok := kwargs["handler"]; ok {
+			l.checkHandler(handler)
+		}
+
+	case "render.star":
+		if font, ok := kwargs["font"]; ok {
+			l.checkFont(font)
+		}
+		if fallback, ok := kwargs["fallback"].(*syntax.ListExpr); ok {
+			for _, font := range fallback.List {
+				l.checkFont(font)
+			}
+		}
+	}
+}
+
+// addTTL returns the edit that passes ttl_seconds to call, or nothing if
+// it might already be passed through *args or **kwargs.
+func (l *linter) addTTL(call *syntax.CallExpr) []edit {
+	arg := fmt.Sprintf("ttl_seconds = %d", DefaultTTLSeconds)
+
+	if len(call.Args) == 0 {
+		at := l.offset(call.Lparen) + 1
+		return []edit{{start: at, end: at, text: arg}}
+	}
+
+	for _, a := range call.Args {
+		if u, ok := a.(*syntax.UnaryExpr); ok && (u.Op == syntax.STAR || u.Op == syntax.STARSTAR) {
+			return nil
+		}
+	}
+
+	_, end := call.Args[len(call.Args)-1].Span()
+	at := l.offset(end)
+	return []edit{{start: at, end: at, text: ", " + arg}}
+}
+
+// splitArgs counts the positional arguments of call, and maps its
+// keyword arguments to their values.
+func splitArgs(call *syntax.CallExpr) (int, map[string]syntax.Expr) {
+	positional := 0
+	kwargs := map[string]syntax.Expr{}
+	for _, arg := range call.Args {
+		if bin, ok := arg.(*syntax.BinaryExpr); ok && bin.Op == syntax.EQ {
+			if ident, ok := bin.X.(*syntax.Ident); ok {
+				kwargs[ident.Name] = bin.Y
+				continue
+			}
+		}
+		positional++
+	}
+	return positional, kwargs
+}
+
+func (l *linter) checkHandler(handler syntax.Expr) {
+	start, _ := handler.Span()
+
+	switch h := handler.(type) {
+	case *syntax.Literal:
+		l.report(start, "schema-handler", "handler must be a function, not %s", h.Raw)
+	case *syntax.Ident:
+		if !l.defined[h.Name] {
+			l.report(start, "schema-handler", "handler %s doesn't exist", h.Name)
+		}
+	}
+}
+
+func (l *linter) checkFont(font syntax.Expr) {
+	lit, ok := font.(*syntax.Literal)
+	if !ok {
+		return
+	}
+
+	name, ok := lit.Value.(string)
+	if !ok {
+		return
+	}
+
+	for _, known := range render.GetFontList() {
+		if name == known {
+			return
+		}
+	}
+
+	l.report(lit.TokenPos, "unknown-font", "font %q doesn't exist, see docs/fonts.md for the available fonts", name)
+}
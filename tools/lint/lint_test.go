@@ -0,0 +1,179 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rules(findings []Finding) []string {
+	rules := []string{}
+	for _, f := range findings {
+		rules = append(rules, f.Rule)
+	}
+	return rules
+}
+
+func TestClean(t *testing.T) {
+	src := `
+load("render.star", "render")
+load("http.star", "http")
+load("cache.star", "cache")
+load("schema.star", "schema")
+
+def main(config):
+    resp = http.get("https://example.com", ttl_seconds = 60)
+    cache.set("key", resp.body(), ttl_seconds = 300)
+    return render.Root(child = render.Text("hi", font = "tb-8", fallback = ["6x13"]))
+
+def search(pattern):
+    return []
+
+def get_schema():
+    return schema.Schema(
+        version = "1",
+        fields = [schema.Typeahead(id = "q", name = "Q", desc = "Q", icon = "gear", handler = search)],
+    )
+`
+	findings, err := File("app.star", []byte(src))
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+}
+
+func TestFindings(t *testing.T) {
+	src := `
+load("render.star", "render")
+load("http.star", "http")
+load("cache.star", "cache")
+load("schema.star", "schema")
+load("animation.star", "animation")
+load("time.star", "time")
+load("encoding/json.star", j = "json")
+
+def main(config):
+    resp = http.post("https://example.com")
+    cache.set("key", resp.body())
+    return render.Root(
+        child = animation.AnimatedPositioned(
+            child = render.Text("hi", font = "comic-sans"),
+        ),
+    )
+
+def get_schema():
+    return schema.Schema(
+        version = "1",
+        fields = [
+            schema.Generated(id = "a", source = "b", handler = "more_options"),
+            schema.LocationBased(id = "c", name = "C", desc = "C", icon = "gear", handler = nearby),
+        ],
+    )
+`
+	findings, err := File("app.star", []byte(src))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"unused-load",
+		"unused-load",
+		"http-ttl",
+		"cache-ttl",
+		"deprecated",
+		"unknown-font",
+		"schema-handler",
+		"schema-handler",
+	}, rules(findings))
+
+	assert.Equal(t, `app.star:7:20: time is loaded from time.star but never used (unused-load)`, findings[0].String())
+	assert.Contains(t, findings[1].Message, "j is loaded from encoding/json.star")
+	assert.Contains(t, findings[6].Message, `"more_options"`)
+	assert.Contains(t, findings[7].Message, "nearby doesn't exist")
+}
+
+func TestUnusedLoadAttributes(t *testing.T) {
+	findings, err := File("app.star", []byte(`
+load("encoding/json.star", "json")
+load("http.star", "http")
+load("render.star", "render")
+
+def main(config):
+    data = http.get("https://example.com", ttl_seconds = 60).json()
+    opts = dict(json = True)
+    return render.Root(child = render.Text(data["name"] if opts else "", font = "tb-8"))
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"unused-load"}, rules(findings))
+	assert.Contains(t, findings[0].Message, "json is loaded")
+}
+
+func TestCacheTTLPositional(t *testing.T) {
+	findings, err := File("app.star", []byte(`
+load("cache.star", "cache")
+
+def main(config):
+    cache.set("key", "value", 60)
+`))
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+}
+
+func TestSyntaxError(t *testing.T) {
+	_, err := File("app.star", []byte("def main(:\n"))
+	assert.Error(t, err)
+}
+
+func TestFix(t *testing.T) {
+	src := `load("cache.star", "cache")
+load("encoding/base64.star", "base64")
+load("http.star", "http")
+load("json.star", "json")
+load("render.star", "render")
+load("time.star", "time", t = "parse_time")
+
+def main(config):
+    resp = http.get("https://example.com")
+    cache.set("key", resp.body())
+    now = t("2024-01-01T00:00:00Z")
+    return render.Root(child = render.Text(json.encode(now)))
+
+def get(url, **kwargs):
+    return http.post(url, **kwargs)
+`
+	fixed, remaining, err := Fix("app.star", []byte(src))
+	require.NoError(t, err)
+
+	assert.Equal(t, `load("cache.star", "cache")
+load("http.star", "http")
+load("encoding/json.star", "json")
+load("render.star", "render")
+load("time.star", t = "parse_time")
+
+def main(config):
+    resp = http.get("https://example.com", ttl_seconds = 300)
+    cache.set("key", resp.body(), ttl_seconds = 300)
+    now = t("2024-01-01T00:00:00Z")
+    return render.Root(child = render.Text(json.encode(now)))
+
+def get(url, **kwargs):
+    return http.post(url, **kwargs)
+`, string(fixed))
+
+	// ttl_seconds might be in kwargs, so that's left to a person
+	assert.Equal(t, []string{"http-ttl"}, rules(remaining))
+	assert.False(t, remaining[0].Fixable())
+}
+
+func TestFixUnusedMovedModule(t *testing.T) {
+	fixed, remaining, err := Fix("app.star", []byte(`load("json.star", "json")
+load("render.star", "render")
+
+def main(config):
+    return render.Root(child = render.Text("hi"))
+`))
+	require.NoError(t, err)
+	assert.Empty(t, remaining)
+	assert.Equal(t, `load("render.star", "render")
+
+def main(config):
+    return render.Root(child = render.Text("hi"))
+`, string(fixed))
+}
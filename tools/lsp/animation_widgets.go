@@ -0,0 +1,80 @@
+// Code generated by runtime/gen. DO NOT EDIT.
+
+package lsp
+
+func init() {
+	addWidgets("animation.star", []Widget{
+		{
+			Name: "AnimatedPositioned",
+			Doc:  "Animate a widget from start to end coordinates.\n\n**DEPRECATED**: Please use `animation.Transformation` instead.",
+			Attributes: []Attribute{
+				{Name: "child", Type: "Widget", Doc: "Widget to animate", Required: true},
+				{Name: "duration", Type: "int", Doc: "Duration of animation in frames", Required: true},
+				{Name: "curve", Type: "str / function", Doc: "Easing curve to use, default is 'linear'", Required: true},
+				{Name: "x_start", Type: "int", Doc: "Horizontal start coordinate", Required: false},
+				{Name: "x_end", Type: "int", Doc: "Horizontal end coordinate", Required: false},
+				{Name: "y_start", Type: "int", Doc: "Vertical start coordinate", Required: false},
+				{Name: "y_end", Type: "int", Doc: "Vertical end coordinate", Required: false},
+				{Name: "delay", Type: "int", Doc: "Delay before animation in frames", Required: false},
+				{Name: "hold", Type: "int", Doc: "Delay after animation in frames", Required: false},
+			},
+		},
+		{
+			Name: "Keyframe",
+			Doc:  "A keyframe defining specific point in time in the animation.\n\nThe keyframe _percentage_ can is expressed as a floating point value between `0.0` and `1.0`.",
+			Attributes: []Attribute{
+				{Name: "percentage", Type: "float", Doc: "Percentage of the time at which this keyframe occurs through the animation.", Required: true},
+				{Name: "transforms", Type: "[Transform]", Doc: "List of transforms at this keyframe to interpolate to or from.", Required: true},
+				{Name: "curve", Type: "str / function", Doc: "Easing curve to use, default is 'linear'", Required: false},
+			},
+		},
+		{
+			Name: "Origin",
+			Doc:  "An relative anchor point to use for scaling and rotation transforms.",
+			Attributes: []Attribute{
+				{Name: "x", Type: "float", Doc: "Horizontal anchor point", Required: true},
+				{Name: "y", Type: "float", Doc: "Vertical anchor point", Required: true},
+			},
+		},
+		{
+			Name: "Rotate",
+			Doc:  "Transform by rotating by a given angle in degrees.",
+			Attributes: []Attribute{
+				{Name: "angle", Type: "float / int", Doc: "Angle to rotate by in degrees", Required: true},
+			},
+		},
+		{
+			Name: "Scale",
+			Doc:  "Transform by scaling by a given factor.",
+			Attributes: []Attribute{
+				{Name: "x", Type: "float / int", Doc: "Horizontal scale factor", Required: true},
+				{Name: "y", Type: "float / int", Doc: "Vertical scale factor", Required: true},
+			},
+		},
+		{
+			Name: "Transformation",
+			Doc:  "Transformation makes it possible to animate a child widget by\ntransitioning between transforms which are applied to the child wiget.\n\nIt supports animating translation, scale and rotation of its child.\n\nIf you have used CSS transforms and animations before, some of the\nfollowing concepts will be familiar to you.\n\nKeyframes define a list of transforms to apply at a specific point in\ntime, which is given as a percentage of the total animation duration.\n\nA keyframe is created via `animation.Keyframe(percentage, transforms, curve)`.\n\nThe `percentage` specifies its point in time and can be expressed as\na floating point number in the range `0.0` to `1.0`.\n\nIn case a keyframe at percentage 0% or 100% is missing, a default\nkeyframe without transforms and with a \"linear\" easing curve is inserted.\n\nAs the animation progresses, transforms defined by the previous and\nnext keyframe will be interpolated to determine the transform to apply\nat the current frame.\n\nThe `duration` and `delay` of the animation are expressed as a number\nof frames.\n\nBy default a transform `origin` of `animation.Origin(0.5, 0.5)` is used,\nwhich defines the anchor point for scaling and rotation to be exactly the\ncenter of the child widget. A different `origin` can be specified by\nproviding a custom `animation.Origin`.\n\nThe animation `direction` defaults to `normal`, playing the animation\nforwards. Other possible values are `reverse` to play it backwards,\n`alternate` to play it forwards, then backwards or `alternate-reverse`\nto play it backwards, then forwards.\n\nThe animation `fill_mode` defaults to `forwards`, and controls which\ntransforms will be applied to the child widget after the animation\nfinishes. A value of `forwards` will retain the transforms of the last\nkeyframe, while a value of `backwards` will rever to the transforms\nof the first keyframe.\n\nWhen translating the child widget on the X- or Y-axis, it often is\ndesireable to round to even integers, which can be controlled via\n`rounding`, which defaults to `round`. Possible values are `round` to\nround to the nearest integer, `floor` to round down, `ceil` to round\nup or `none` to not perform any rounding. Rounding only is applied for\ntranslation transforms, but not to scaling or rotation transforms.\n\nIf `wait_for_child` is set to `True`, the animation will finish and\nthen wait for all child frames to play before restarting. If it is set\nto `False`, it will not wait.",
+			Attributes: []Attribute{
+				{Name: "child", Type: "Widget", Doc: "Widget to animate", Required: true},
+				{Name: "keyframes", Type: "[Keyframe]", Doc: "List of animation keyframes", Required: true},
+				{Name: "duration", Type: "int", Doc: "Duration of animation (in frames)", Required: true},
+				{Name: "delay", Type: "int", Doc: "Duration to wait before animation (in frames)", Required: false},
+				{Name: "width", Type: "int", Doc: "Width of the animation canvas", Required: false},
+				{Name: "height", Type: "int", Doc: "Height of the animation canvas", Required: false},
+				{Name: "origin", Type: "Origin", Doc: "Origin for transforms, default is '50%, 50%'", Required: false},
+				{Name: "direction", Type: "str", Doc: "Direction of the animation, default is 'normal'", Required: false},
+				{Name: "fill_mode", Type: "str", Doc: "Fill mode of the animation, default is 'forwards'", Required: false},
+				{Name: "rounding", Type: "str", Doc: "Rounding to use for interpolated translation coordinates (not used for scale and rotate), default is 'round'", Required: false},
+				{Name: "wait_for_child", Type: "bool", Doc: "Wait for all child frames to play after finishing", Required: false},
+			},
+		},
+		{
+			Name: "Translate",
+			Doc:  "Transform by translating by a given offset.",
+			Attributes: []Attribute{
+				{Name: "x", Type: "float / int", Doc: "Horizontal offset", Required: true},
+				{Name: "y", Type: "float / int", Doc: "Vertical offset", Required: true},
+			},
+		},
+	})
+}
@@ -0,0 +1,235 @@
+package lsp
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.starlark.net/starlark"
+
+	"tidbyt.dev/pixlet/runtime"
+)
+
+var (
+	// dottedPrefix matches a dotted name being typed, like render.Bo
+	dottedPrefix = regexp.MustCompile(`([A-Za-z_]\w*(?:\.[A-Za-z_]\w*)*)\.(\w*)$`)
+
+	// calleeSuffix matches the name of the function being called,
+	// before its opening parenthesis
+	calleeSuffix = regexp.MustCompile(`([A-Za-z_]\w*(?:\.[A-Za-z_]\w*)*)\s*$`)
+
+	// loadModuleArg matches the module argument of a load, after its
+	// opening parenthesis
+	loadModuleArg = regexp.MustCompile(`^\(\s*"([^"]*)"`)
+)
+
+// cursor is where the cursor is in the source: which brackets it's
+// inside of, and whether it's in a string.
+type cursor struct {
+	// brackets are the offsets of the brackets that are still open
+	brackets []int
+
+	// stringStart is the offset of the opening quote of the string the
+	// cursor is in, or -1
+	stringStart int
+}
+
+// scan finds what offset is inside of in text.
+func scan(text string, offset int) cursor {
+	c := cursor{stringStart: -1}
+
+	for i := 0; i < offset; i++ {
+		switch ch := text[i]; ch {
+		case '#':
+			for i < offset && text[i] != '\n' {
+				i++
+			}
+		case '"', '\'':
+			quote := string(ch)
+			if strings.HasPrefix(text[i:], strings.Repeat(quote, 3)) {
+				quote = strings.Repeat(quote, 3)
+			}
+			start := i
+			i += len(quote)
+			for ; i < offset; i++ {
+				if text[i] == '\\' {
+					i++
+					continue
+				}
+				if len(quote) == 1 && text[i] == '\n' {
+					break
+				}
+				if strings.HasPrefix(text[i:], quote) {
+					i += len(quote) - 1
+					break
+				}
+			}
+			if i >= offset {
+				c.stringStart = start
+			}
+		case '(', '[', '{':
+			c.brackets = append(c.brackets, i)
+		case ')', ']', '}':
+			if len(c.brackets) > 0 {
+				c.brackets = c.brackets[:len(c.brackets)-1]
+			}
+		}
+	}
+
+	return c
+}
+
+// call returns the name of the function whose arguments the cursor is
+// in, and the offset of its opening parenthesis.
+func (c cursor) call(text string) (string, int, bool) {
+	if len(c.brackets) == 0 {
+		return "", 0, false
+	}
+
+	open := c.brackets[len(c.brackets)-1]
+	if text[open] != '(' {
+		return "", 0, false
+	}
+
+	m := calleeSuffix.FindStringSubmatch(text[:open])
+	if m == nil {
+		return "", 0, false
+	}
+	return m[1], open, true
+}
+
+// complete returns what could be typed at pos.
+func (d *document) complete(pos Position) []CompletionItem {
+	offset := d.offset(pos)
+	c := scan(d.text, offset)
+
+	if c.stringStart >= 0 {
+		return d.completeLoad(c, offset)
+	}
+
+	lineStart := strings.LastIndexByte(d.text[:offset], '\n') + 1
+	if m := dottedPrefix.FindStringSubmatch(d.text[lineStart:offset]); m != nil {
+		return d.completeMembers(m[1])
+	}
+
+	items := []CompletionItem{}
+
+	// keyword arguments of the widget being constructed
+	if name, _, ok := c.call(d.text); ok {
+		if w, ok := d.widget(name); ok {
+			for _, a := range w.Attributes {
+				items = append(items, CompletionItem{
+					Label:         a.Name,
+					Kind:          KindField,
+					Detail:        a.Type,
+					Documentation: markdown(a.Markdown()),
+					InsertText:    a.Name + " = ",
+				})
+			}
+		}
+	}
+
+	names := []string{}
+	for name := range d.loads {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		items = append(items, CompletionItem{
+			Label:  name,
+			Kind:   KindModule,
+			Detail: "loaded from " + d.loads[name].module,
+		})
+	}
+
+	builtins := []string{}
+	for name := range starlark.Universe {
+		builtins = append(builtins, name)
+	}
+	sort.Strings(builtins)
+	for _, name := range builtins {
+		kind, detail := describe(starlark.Universe[name])
+		items = append(items, CompletionItem{Label: name, Kind: kind, Detail: detail})
+	}
+
+	return items
+}
+
+// completeLoad completes the module and symbols of a load statement.
+func (d *document) completeLoad(c cursor, offset int) []CompletionItem {
+	name, open, ok := c.call(d.text)
+	if !ok || name != "load" {
+		return nil
+	}
+
+	// replace what's been typed of the string so far, since paths have
+	// characters that editors don't consider part of a word
+	replace := Range{Start: d.position(c.stringStart + 1), End: d.position(offset)}
+
+	items := []CompletionItem{}
+	if strings.TrimSpace(d.text[open+1:c.stringStart]) == "" {
+		for _, module := range runtime.Modules {
+			items = append(items, CompletionItem{
+				Label:    module,
+				Kind:     KindFile,
+				TextEdit: &TextEdit{Range: replace, NewText: module},
+			})
+		}
+		return items
+	}
+
+	m := loadModuleArg.FindStringSubmatch(d.text[open:])
+	if m == nil {
+		return nil
+	}
+
+	syms := moduleSymbols(m[1])
+	names := []string{}
+	for name := range syms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		kind, detail := describe(syms[name])
+		items = append(items, CompletionItem{
+			Label:    name,
+			Kind:     kind,
+			Detail:   detail,
+			TextEdit: &TextEdit{Range: replace, NewText: name},
+		})
+	}
+
+	return items
+}
+
+// completeMembers completes the members of a module or struct, like
+// render.
+func (d *document) completeMembers(name string) []CompletionItem {
+	module, v, ok := d.resolve(name)
+	if !ok {
+		return nil
+	}
+
+	items := []CompletionItem{}
+	for _, member := range attrNames(v) {
+		item := CompletionItem{Label: member}
+
+		if w, ok := d.widget(name + "." + member); ok {
+			item.Kind = KindClass
+			item.Detail = w.Signature(name)
+			item.Documentation = markdown(w.Markdown(name))
+		} else if val, ok := attr(v, member); ok {
+			kind, detail := describe(val)
+			item.Kind = kind
+			item.Detail = detail + " from " + module
+		}
+
+		items = append(items, item)
+	}
+
+	return items
+}
+
+func markdown(s string) *MarkupContent {
+	return &MarkupContent{Kind: "markdown", Value: s}
+}
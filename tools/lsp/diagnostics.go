@@ -0,0 +1,172 @@
+package lsp
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"go.starlark.net/syntax"
+
+	"tidbyt.dev/pixlet/tools/lint"
+)
+
+// diagnostics finds the problems in the document: syntax errors, what
+// pixlet check reports, and uses of modules that don't match what they
+// contain.
+func (d *document) diagnostics() []Diagnostic {
+	diags := []Diagnostic{}
+
+	if d.err != nil {
+		var serr syntax.Error
+		if !errors.As(d.err, &serr) {
+			return diags
+		}
+		pos := d.syntaxPosition(serr.Pos)
+		return append(diags, Diagnostic{
+			Range:    Range{Start: pos, End: pos},
+			Severity: SeverityError,
+			Source:   "pixlet",
+			Message:  serr.Msg,
+		})
+	}
+
+	// what check reports, as warnings since the app still runs
+	findings, err := lint.File(d.uri, []byte(d.text))
+	if err != nil {
+		return diags
+	}
+	// positions from separate parses have different files
+	type lineCol struct{ line, col int32 }
+	linted := map[lineCol]bool{}
+	for _, f := range findings {
+		linted[lineCol{f.Pos.Line, f.Pos.Col}] = true
+		pos := d.syntaxPosition(f.Pos)
+		diags = append(diags, Diagnostic{
+			Range:    Range{Start: pos, End: d.wordEnd(pos)},
+			Severity: SeverityWarning,
+			Code:     f.Rule,
+			Source:   "pixlet",
+			Message:  f.Message,
+		})
+	}
+
+	report := func(n syntax.Node, format string, args ...interface{}) {
+		diags = append(diags, Diagnostic{
+			Range:    d.syntaxRange(n),
+			Severity: SeverityError,
+			Source:   "pixlet",
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	for _, stmt := range d.file.Stmts {
+		load, ok := stmt.(*syntax.LoadStmt)
+		if !ok || linted[lineCol{load.Module.TokenPos.Line, load.Module.TokenPos.Col}] {
+			continue
+		}
+
+		module, _ := load.Module.Value.(string)
+		syms := moduleSymbols(module)
+		if syms == nil {
+			if !d.appFileExists(module) {
+				report(load.Module, "%s isn't a Pixlet module", module)
+			}
+			continue
+		}
+
+		for _, from := range load.From {
+			if _, ok := syms[from.Name]; !ok {
+				report(from, "%s has no %s", module, from.Name)
+			}
+		}
+	}
+
+	syntax.Walk(d.file, func(n syntax.Node) bool {
+		switch n := n.(type) {
+		case *syntax.DotExpr:
+			name, ok := dottedName(n.X)
+			if !ok {
+				return true
+			}
+			if _, v, ok := d.resolve(name); ok && attrNames(v) != nil {
+				if _, ok := attr(v, n.Name.Name); !ok {
+					report(n.Name, "%s has no member %s", name, n.Name.Name)
+				}
+			}
+
+		case *syntax.CallExpr:
+			name, ok := dottedName(n.Fn)
+			if !ok {
+				return true
+			}
+			w, ok := d.widget(name)
+			if !ok {
+				return true
+			}
+			for _, arg := range n.Args {
+				bin, ok := arg.(*syntax.BinaryExpr)
+				if !ok || bin.Op != syntax.EQ {
+					continue
+				}
+				if ident, ok := bin.X.(*syntax.Ident); ok {
+					if _, ok := w.Attribute(ident.Name); !ok {
+						report(ident, "%s has no attribute %s", name, ident.Name)
+					}
+				}
+			}
+		}
+		return true
+	})
+
+	return diags
+}
+
+// appFileExists reports whether module is a file next to the document,
+// that apps with several files can load.
+func (d *document) appFileExists(module string) bool {
+	u, err := url.Parse(d.uri)
+	if err != nil || u.Scheme != "file" {
+		// can't tell, so don't complain
+		return true
+	}
+
+	path := filepath.Join(filepath.Dir(filepath.FromSlash(u.Path)), filepath.FromSlash(module))
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// wordEnd returns the end of the word that starts at pos, so that
+// diagnostics underline something.
+func (d *document) wordEnd(pos Position) Position {
+	offset := d.offset(pos)
+	end := offset
+	for end < len(d.text) && isIdentByte(d.text[end]) {
+		end++
+	}
+	if end == offset {
+		return pos
+	}
+	return d.position(end)
+}
+
+// dottedName returns the name of an expression like render.Box.
+func dottedName(e syntax.Expr) (string, bool) {
+	switch e := e.(type) {
+	case *syntax.Ident:
+		return e.Name, true
+	case *syntax.DotExpr:
+		x, ok := dottedName(e.X)
+		if !ok {
+			return "", false
+		}
+		return x + "." + e.Name.Name, true
+	default:
+		return "", false
+	}
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
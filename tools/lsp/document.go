@@ -0,0 +1,180 @@
+package lsp
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+// loaded is a symbol brought in with load().
+type loaded struct {
+	module string
+	name   string
+}
+
+// document is a file open in the editor.
+type document struct {
+	uri  string
+	text string
+
+	// file is the text parsed, or nil if it doesn't parse.
+	file *syntax.File
+	err  error
+
+	// loads maps local names to what they were loaded as.
+	loads map[string]loaded
+}
+
+// loadStmt matches a load statement, for files that don't parse.
+var loadStmt = regexp.MustCompile(`(?m)^load\([^)]*\)`)
+
+func newDocument(uri, text string) *document {
+	d := &document{uri: uri, text: text, loads: map[string]loaded{}}
+
+	opts := &syntax.FileOptions{
+		Set:       true,
+		Recursion: true,
+	}
+	d.file, d.err = opts.Parse(uri, text, 0)
+
+	stmts := []syntax.Stmt{}
+	if d.err == nil {
+		stmts = d.file.Stmts
+	} else {
+		// files usually don't parse while they're being edited, but
+		// their loads still tell what names are
+		d.file = nil
+		for _, src := range loadStmt.FindAllString(text, -1) {
+			if f, err := opts.Parse(uri, src, 0); err == nil {
+				stmts = append(stmts, f.Stmts...)
+			}
+		}
+	}
+
+	for _, stmt := range stmts {
+		load, ok := stmt.(*syntax.LoadStmt)
+		if !ok {
+			continue
+		}
+		module, _ := load.Module.Value.(string)
+		for i, to := range load.To {
+			d.loads[to.Name] = loaded{module: module, name: load.From[i].Name}
+		}
+	}
+
+	return d
+}
+
+// resolve looks up a dotted name like render.Box, through what the
+// document loads. It returns the module the name comes from and its
+// value.
+func (d *document) resolve(name string) (string, starlark.Value, bool) {
+	parts := strings.Split(name, ".")
+
+	sym, ok := d.loads[parts[0]]
+	if !ok {
+		return "", nil, false
+	}
+
+	v, ok := moduleSymbols(sym.module)[sym.name]
+	if !ok {
+		return "", nil, false
+	}
+
+	for _, part := range parts[1:] {
+		if v, ok = attr(v, part); !ok {
+			return "", nil, false
+		}
+	}
+
+	return sym.module, v, true
+}
+
+// widget looks up a dotted name like render.Box as a widget.
+func (d *document) widget(name string) (Widget, bool) {
+	parts := strings.Split(name, ".")
+	if len(parts) != 2 {
+		return Widget{}, false
+	}
+
+	sym, ok := d.loads[parts[0]]
+	if !ok {
+		return Widget{}, false
+	}
+
+	w, ok := widgets[sym.module][parts[1]]
+	return w, ok
+}
+
+// offset converts an LSP position to a byte offset in the text.
+func (d *document) offset(pos Position) int {
+	offset := 0
+	for line := 0; line < pos.Line; line++ {
+		next := strings.IndexByte(d.text[offset:], '\n')
+		if next < 0 {
+			return len(d.text)
+		}
+		offset += next + 1
+	}
+
+	// characters count UTF-16 code units
+	for units := 0; units < pos.Character && offset < len(d.text); {
+		r, size := utf8.DecodeRuneInString(d.text[offset:])
+		if r == '\n' {
+			break
+		}
+		units += utf16Len(r)
+		offset += size
+	}
+
+	return offset
+}
+
+// position converts a byte offset in the text to an LSP position.
+func (d *document) position(offset int) Position {
+	offset = min(offset, len(d.text))
+	lineStart := strings.LastIndexByte(d.text[:offset], '\n') + 1
+
+	pos := Position{Line: strings.Count(d.text[:lineStart], "\n")}
+	for _, r := range d.text[lineStart:offset] {
+		pos.Character += utf16Len(r)
+	}
+	return pos
+}
+
+// syntaxPosition converts a position from the Starlark parser, with
+// one based lines and columns counting runes, to an LSP position.
+func (d *document) syntaxPosition(pos syntax.Position) Position {
+	offset := 0
+	for line := int32(1); line < pos.Line; line++ {
+		next := strings.IndexByte(d.text[offset:], '\n')
+		if next < 0 {
+			return d.position(len(d.text))
+		}
+		offset += next + 1
+	}
+
+	for col := int32(1); col < pos.Col && offset < len(d.text); col++ {
+		_, size := utf8.DecodeRuneInString(d.text[offset:])
+		offset += size
+	}
+
+	return d.position(offset)
+}
+
+// syntaxRange is the range of a node.
+func (d *document) syntaxRange(n syntax.Node) Range {
+	start, end := n.Span()
+	return Range{Start: d.syntaxPosition(start), End: d.syntaxPosition(end)}
+}
+
+// utf16Len is how many UTF-16 code units r takes.
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,178 @@
+package main
+
+// Generates the parameters of the schema module's constructors for the
+// language server, by reading the starlark.UnpackArgs calls in the
+// schema package. Run it with go generate from tools/lsp.
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+const (
+	schemaDir  = "../../schema"
+	outputPath = "schema_fields.go"
+)
+
+var output = template.Must(template.New("schema").Parse(`// Code generated by tools/lsp/gen. DO NOT EDIT.
+
+package lsp
+
+func init() {
+	addWidgets("schema.star", []Widget{
+{{- range .}}
+		{
+			Name: {{printf "%q" .Name}},
+			Attributes: []Attribute{
+{{- range .Attributes}}
+				{Name: {{printf "%q" .Name}}, Required: {{.Required}}},
+{{- end}}
+			},
+		},
+{{- end}}
+	})
+}
+`))
+
+type attribute struct {
+	Name     string
+	Required bool
+}
+
+type constructor struct {
+	Name       string
+	Attributes []attribute
+}
+
+func nilOrPanic(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+
+// unpacked returns the parameters of each call to starlark.UnpackArgs,
+// by the name passed to it.
+func unpacked(files map[string]*ast.File) map[string][]attribute {
+	params := map[string][]attribute{}
+
+	for _, f := range files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok || sel.Sel.Name != "UnpackArgs" || len(call.Args) < 3 {
+				return true
+			}
+
+			name, ok := stringLit(call.Args[0])
+			if !ok {
+				return true
+			}
+
+			attrs := []attribute{}
+			for i := 3; i < len(call.Args); i += 2 {
+				param, ok := stringLit(call.Args[i])
+				if !ok {
+					continue
+				}
+				attrs = append(attrs, attribute{
+					Name:     strings.TrimSuffix(param, "?"),
+					Required: !strings.HasSuffix(param, "?"),
+				})
+			}
+			params[name] = attrs
+
+			return true
+		})
+	}
+
+	return params
+}
+
+// wrapped returns the keyword arguments each member of the module takes
+// besides its own, from the wrappers it's built with in module.go.
+func wrapped(fset *token.FileSet, files map[string]*ast.File) map[string][]attribute {
+	extra := map[string][]attribute{}
+
+	for _, f := range files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			kv, ok := n.(*ast.KeyValueExpr)
+			if !ok {
+				return true
+			}
+
+			name, ok := stringLit(kv.Key)
+			if !ok {
+				return true
+			}
+
+			var buf bytes.Buffer
+			printer.Fprint(&buf, fset, kv.Value)
+			value := buf.String()
+
+			if strings.HasPrefix(value, "newField(") {
+				extra[name] = append(extra[name],
+					attribute{Name: "required"},
+					attribute{Name: "visible_if"},
+				)
+			}
+			if strings.HasPrefix(value, "newField(") || strings.Contains(value, "withTranslations(") {
+				extra[name] = append(extra[name], attribute{Name: "translations"})
+			}
+
+			return true
+		})
+	}
+
+	return extra
+}
+
+func stringLit(e ast.Expr) (string, bool) {
+	lit, ok := e.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	return s, err == nil
+}
+
+func main() {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, schemaDir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	nilOrPanic(err)
+
+	files := pkgs["schema"].Files
+	params := unpacked(files)
+	extra := wrapped(fset, files)
+
+	constructors := []constructor{}
+	for name, attrs := range params {
+		constructors = append(constructors, constructor{
+			Name:       name,
+			Attributes: append(attrs, extra[name]...),
+		})
+	}
+	sort.Slice(constructors, func(i, j int) bool {
+		return constructors[i].Name < constructors[j].Name
+	})
+
+	var buf bytes.Buffer
+	nilOrPanic(output.Execute(&buf, constructors))
+	source, err := format.Source(buf.Bytes())
+	nilOrPanic(err)
+	nilOrPanic(os.WriteFile(outputPath, source, 0644))
+}
@@ -0,0 +1,70 @@
+package lsp
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// keywordSuffix matches what follows the name of a keyword argument
+var keywordSuffix = regexp.MustCompile(`^\s*=[^=]`)
+
+// hover documents the name at pos.
+func (d *document) hover(pos Position) *Hover {
+	offset := d.offset(pos)
+
+	// the name up to the end of the word at pos, so that hovering over
+	// render in render.Box is about render
+	start := offset
+	for start > 0 && (isIdentByte(d.text[start-1]) || d.text[start-1] == '.') {
+		start--
+	}
+	end := offset
+	for end < len(d.text) && isIdentByte(d.text[end]) {
+		end++
+	}
+	for start < end && d.text[start] == '.' {
+		start++
+	}
+	name := d.text[start:end]
+	if name == "" {
+		return nil
+	}
+
+	rng := &Range{Start: d.position(start), End: d.position(end)}
+	hover := func(s string) *Hover {
+		return &Hover{Contents: MarkupContent{Kind: "markdown", Value: s}, Range: rng}
+	}
+
+	// keyword argument of a widget
+	if !strings.Contains(name, ".") && keywordSuffix.MatchString(d.text[end:]) {
+		if call, _, ok := scan(d.text, start).call(d.text); ok {
+			if w, ok := d.widget(call); ok {
+				if a, ok := w.Attribute(name); ok {
+					return hover(a.Markdown())
+				}
+			}
+		}
+	}
+
+	if w, ok := d.widget(name); ok {
+		namespace, _, _ := strings.Cut(name, ".")
+		return hover(w.Markdown(namespace))
+	}
+
+	module, v, ok := d.resolve(name)
+	if !ok {
+		return nil
+	}
+
+	if !strings.Contains(name, ".") {
+		s := fmt.Sprintf("`%s` is loaded from `%s`", name, module)
+		if members := attrNames(v); len(members) > 0 {
+			s += fmt.Sprintf("\n\nMembers: `%s`", strings.Join(members, "`, `"))
+		}
+		return hover(s)
+	}
+
+	_, detail := describe(v)
+	return hover(fmt.Sprintf("`%s`: %s from `%s`", name, detail, module))
+}
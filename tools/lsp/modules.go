@@ -0,0 +1,186 @@
+package lsp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.starlark.net/starlark"
+
+	"tidbyt.dev/pixlet/runtime"
+)
+
+// Widget is a constructor in a module, like render.Box or
+// schema.Toggle, with the attributes it takes as keyword arguments.
+type Widget struct {
+	Name       string
+	Doc        string
+	Attributes []Attribute
+}
+
+// Attribute is a keyword argument of a Widget.
+type Attribute struct {
+	Name     string
+	Type     string
+	Doc      string
+	Required bool
+}
+
+// Attribute returns the attribute called name.
+func (w Widget) Attribute(name string) (Attribute, bool) {
+	for _, a := range w.Attributes {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Attribute{}, false
+}
+
+// Signature describes how to call the widget, with the required
+// attributes first.
+func (w Widget) Signature(namespace string) string {
+	params := []string{}
+	for _, a := range w.Attributes {
+		if a.Required {
+			params = append(params, a.Name)
+		}
+	}
+	for _, a := range w.Attributes {
+		if !a.Required {
+			params = append(params, a.Name+" = ...")
+		}
+	}
+	return fmt.Sprintf("%s.%s(%s)", namespace, w.Name, strings.Join(params, ", "))
+}
+
+// Markdown documents the widget, with a table of its attributes.
+func (w Widget) Markdown(namespace string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "```python\n%s\n```\n", w.Signature(namespace))
+	if w.Doc != "" {
+		fmt.Fprintf(&b, "\n%s\n", w.Doc)
+	}
+
+	if len(w.Attributes) > 0 {
+		b.WriteString("\n| Name | Type | Description | Required |\n| --- | --- | --- | --- |\n")
+		for _, a := range w.Attributes {
+			required := "N"
+			if a.Required {
+				required = "**Y**"
+			}
+			fmt.Fprintf(&b, "| `%s` | `%s` | %s | %s |\n", a.Name, a.Type, a.Doc, required)
+		}
+	}
+
+	return b.String()
+}
+
+// Markdown documents the attribute.
+func (a Attribute) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%s**", a.Name)
+	if a.Type != "" {
+		fmt.Fprintf(&b, " `%s`", a.Type)
+	}
+	if a.Required {
+		b.WriteString(" (required)")
+	}
+	if a.Doc != "" {
+		fmt.Fprintf(&b, "\n\n%s", a.Doc)
+	}
+	return b.String()
+}
+
+// widgets maps modules to their widgets, by name. The widgets are
+// generated from the render package and the schema module.
+var widgets = map[string]map[string]Widget{}
+
+func addWidgets(module string, ws []Widget) {
+	if widgets[module] == nil {
+		widgets[module] = map[string]Widget{}
+	}
+	for _, w := range ws {
+		widgets[module][w.Name] = w
+	}
+}
+
+var (
+	modulesMu sync.Mutex
+	modules   = map[string]starlark.StringDict{}
+)
+
+// isModule reports whether apps can load module.
+func isModule(module string) bool {
+	for _, m := range runtime.Modules {
+		if m == module {
+			return true
+		}
+	}
+	return false
+}
+
+// moduleSymbols returns what module exports, as in load(module, name),
+// or nil if it's not a module.
+func moduleSymbols(module string) starlark.StringDict {
+	if !isModule(module) {
+		return nil
+	}
+
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+
+	if syms, ok := modules[module]; ok {
+		return syms
+	}
+
+	syms, err := runtime.LoadModule(module)
+	if err != nil {
+		syms = nil
+	}
+	modules[module] = syms
+	return syms
+}
+
+// attrNames returns the attributes of v, if it's a module or struct.
+func attrNames(v starlark.Value) []string {
+	hasAttrs, ok := v.(starlark.HasAttrs)
+	if !ok {
+		return nil
+	}
+
+	names := hasAttrs.AttrNames()
+	sort.Strings(names)
+	return names
+}
+
+// attr returns v.name, if v is a module or struct that has it.
+func attr(v starlark.Value, name string) (starlark.Value, bool) {
+	hasAttrs, ok := v.(starlark.HasAttrs)
+	if !ok {
+		return nil, false
+	}
+
+	val, err := hasAttrs.Attr(name)
+	if err != nil || val == nil {
+		return nil, false
+	}
+	return val, true
+}
+
+// describe says what kind of value v is, for completion and hover.
+func describe(v starlark.Value) (CompletionItemKind, string) {
+	switch v.(type) {
+	case *starlark.Builtin, *starlark.Function:
+		return KindFunction, "function"
+	case starlark.HasAttrs:
+		if _, ok := v.(starlark.Callable); ok {
+			return KindFunction, "function"
+		}
+		return KindModule, v.Type()
+	case starlark.Callable:
+		return KindFunction, "function"
+	default:
+		return KindProperty, v.Type()
+	}
+}
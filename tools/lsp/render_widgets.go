@@ -0,0 +1,166 @@
+// Code generated by runtime/gen. DO NOT EDIT.
+
+package lsp
+
+func init() {
+	addWidgets("render.star", []Widget{
+		{
+			Name: "Animation",
+			Doc:  "Animations turns a list of children into an animation, where each\nchild is a frame.\n\nFIXME: Behaviour when children themselves are animated is a bit\nweird. Think and fix.",
+			Attributes: []Attribute{
+				{Name: "children", Type: "[Widget]", Doc: "Children to use as frames in the animation", Required: false},
+			},
+		},
+		{
+			Name: "Box",
+			Doc:  "A Box is a rectangular widget that can hold a child widget.\n\nBoxes are transparent unless `color` is provided. They expand to\nfill all available space, unless `width` and/or `height` is\nprovided. Boxes can have a `child`, which will be centered in the\nbox, and the child can be padded (via `padding`).",
+			Attributes: []Attribute{
+				{Name: "child", Type: "Widget", Doc: "Child to center inside box", Required: false},
+				{Name: "width", Type: "int", Doc: "Limits Box width", Required: false},
+				{Name: "height", Type: "int", Doc: "Limits Box height", Required: false},
+				{Name: "padding", Type: "int", Doc: "Padding around the child widget", Required: false},
+				{Name: "color", Type: "color", Doc: "Background color", Required: false},
+			},
+		},
+		{
+			Name: "Circle",
+			Doc:  "Circle draws a circle with the given `diameter` and `color`. If a\n`child` widget is provided, it is drawn in the center of the\ncircle.",
+			Attributes: []Attribute{
+				{Name: "color", Type: "color", Doc: "Fill color", Required: true},
+				{Name: "diameter", Type: "int", Doc: "Diameter of the circle", Required: true},
+				{Name: "child", Type: "Widget", Doc: "Widget to place in the center of the circle", Required: false},
+			},
+		},
+		{
+			Name: "Column",
+			Doc:  "Column lays out and draws its children vertically (in a column).\n\nBy default, a Column is as small as possible, while still holding\nall its children. However, if `expanded` is set, the Column will\nfill all available space vertically. The width of a Column is\nalways that of its widest child.\n\nAlignment along the vertical main axis is controlled by passing\none of the following `main_align` values:\n- `\"start\"`: place children at the beginning of the column\n- `\"end\"`: place children at the end of the column\n- `\"center\"`: place children in the middle of the column\n- `\"space_between\"`: place equal space between children\n- `\"space_evenly\"`: equal space between children and before/after first/last child\n- `\"space_around\"`: equal space between children, and half of that before/after first/last child\n\nAlignment along the horizontal cross axis is controlled by passing\none of the following `cross_align` values:\n- `\"start\"`: place children at the left\n- `\"end\"`: place children at the right\n- `\"center\"`: place children in the center",
+			Attributes: []Attribute{
+				{Name: "children", Type: "[Widget]", Doc: "Child widgets to lay out", Required: true},
+				{Name: "main_align", Type: "str", Doc: "Alignment along vertical main axis", Required: false},
+				{Name: "cross_align", Type: "str", Doc: "Alignment along horizontal cross axis", Required: false},
+				{Name: "expanded", Type: "bool", Doc: "Column should expand to fill all available vertical space", Required: false},
+			},
+		},
+		{
+			Name: "Image",
+			Doc:  "Image renders the binary image data passed via `src`. Supported\nformats include PNG, JPEG, GIF, and SVG.\n\nIf `width` or `height` are set, the image will be scaled\naccordingly, with nearest neighbor interpolation. Otherwise the\nimage's original dimensions are used.\n\nIf the image data encodes an animated GIF, the Image instance will\nalso be animated. Frame delay (in milliseconds) can be read from\nthe `delay` attribute.",
+			Attributes: []Attribute{
+				{Name: "src", Type: "str", Doc: "Binary image data or SVG text", Required: true},
+				{Name: "width", Type: "int", Doc: "Scale image to this width", Required: false},
+				{Name: "height", Type: "int", Doc: "Scale image to this height", Required: false},
+			},
+		},
+		{
+			Name: "Marquee",
+			Doc:  "Marquee scrolls its child horizontally or vertically.\n\nThe `scroll_direction` will be 'horizontal' and will scroll from right\nto left if left empty, if specified as 'vertical' the Marquee will\nscroll from bottom to top.\n\nIn horizontal mode the height of the Marquee will be that of its child,\nbut its `width` must be specified explicitly. In vertical mode the width\nwill be that of its child but the `height` must be specified explicitly.\n\nIf the child's width fits fully, it will not scroll.\n\nThe `offset_start` and `offset_end` parameters control the position\nof the child in the beginning and the end of the animation.\n\nAlignment for a child that fits fully along the horizontal/vertical axis is controlled by passing\none of the following `align` values:\n- `\"start\"`: place child at the left/top\n- `\"end\"`: place child at the right/bottom\n- `\"center\"`: place child at the center",
+			Attributes: []Attribute{
+				{Name: "child", Type: "Widget", Doc: "Widget to potentially scroll", Required: true},
+				{Name: "width", Type: "int", Doc: "Width of the Marquee, required for horizontal", Required: false},
+				{Name: "height", Type: "int", Doc: "Height of the Marquee, required for vertical", Required: false},
+				{Name: "offset_start", Type: "int", Doc: "Position of child at beginning of animation", Required: false},
+				{Name: "offset_end", Type: "int", Doc: "Position of child at end of animation", Required: false},
+				{Name: "scroll_direction", Type: "str", Doc: "Direction to scroll, 'vertical' or 'horizontal', default is horizontal", Required: false},
+				{Name: "align", Type: "str", Doc: "Alignment when contents fit on screen, 'start', 'center' or 'end', default is start", Required: false},
+				{Name: "delay", Type: "int", Doc: "Delay the scroll of the animation by a certain number of frames, default is 0", Required: false},
+			},
+		},
+		{
+			Name: "Padding",
+			Doc:  "Padding places padding around its child.\n\nIf the `pad` attribute is a single integer, that amount of padding\nwill be placed on all sides of the child. If it's a 4-tuple `(left,\ntop, right, bottom)`, then padding will be placed on the sides\naccordingly.",
+			Attributes: []Attribute{
+				{Name: "child", Type: "Widget", Doc: "The Widget to place padding around", Required: true},
+				{Name: "pad", Type: "int / (int, int, int, int)", Doc: "Padding around the child", Required: false},
+				{Name: "expanded", Type: "bool", Doc: "This is a confusing parameter", Required: false},
+				{Name: "color", Type: "color", Doc: "Background color", Required: false},
+			},
+		},
+		{
+			Name: "PieChart",
+			Doc:  "PieChart draws a circular pie chart of size `diameter`. It takes two\narguments for the data: parallel lists `colors` and `weights` representing\nthe shading and relative sizes of each data entry.",
+			Attributes: []Attribute{
+				{Name: "colors", Type: "[color]", Doc: "List of color hex codes", Required: true},
+				{Name: "weights", Type: "[float]", Doc: "List of numbers corresponding to the relative size of each color", Required: true},
+				{Name: "diameter", Type: "int", Doc: "Diameter of the circle", Required: true},
+			},
+		},
+		{
+			Name: "Plot",
+			Doc:  "Plot is a widget that draws a data series.",
+			Attributes: []Attribute{
+				{Name: "data", Type: "[(float, float)]", Doc: "A list of 2-tuples of numbers", Required: true},
+				{Name: "width", Type: "int", Doc: "Limits Plot width", Required: true},
+				{Name: "height", Type: "int", Doc: "Limits Plot height", Required: true},
+				{Name: "color", Type: "color", Doc: "Line color, default is '#fff'", Required: false},
+				{Name: "color_inverted", Type: "color", Doc: "Line color for Y-values below 0", Required: false},
+				{Name: "x_lim", Type: "(float, float)", Doc: "Limit X-axis to a range", Required: false},
+				{Name: "y_lim", Type: "(float, float)", Doc: "Limit Y-axis to a range", Required: false},
+				{Name: "fill", Type: "bool", Doc: "Paint surface between line and X-axis", Required: false},
+				{Name: "chart_type", Type: "str", Doc: "Specifies the type of chart to render, \"scatter\" or \"line\", default is \"line\"", Required: false},
+				{Name: "fill_color", Type: "color", Doc: "Fill color for Y-values above 0", Required: false},
+				{Name: "fill_color_inverted", Type: "color", Doc: "Fill color for Y-values below 0", Required: false},
+			},
+		},
+		{
+			Name: "Root",
+			Doc:  "Every Widget tree has a Root.\n\nThe child widget, and all its descendants, will be drawn on a 64x32\ncanvas. Root places its child in the upper left corner of the\ncanvas.\n\nIf the tree contains animated widgets, the resulting animation will\nrun with _delay_ milliseconds per frame.\n\nIf the tree holds time sensitive information which must never be\ndisplayed past a certain point in time, pass _MaxAge_ to specify\nan expiration time in seconds. Display devices use this to avoid\ndisplaying stale data in the event of e.g. connectivity issues.",
+			Attributes: []Attribute{
+				{Name: "child", Type: "Widget", Doc: "Widget to render", Required: true},
+				{Name: "delay", Type: "int", Doc: "Frame delay in milliseconds", Required: false},
+				{Name: "max_age", Type: "int", Doc: "Expiration time in seconds", Required: false},
+				{Name: "show_full_animation", Type: "bool", Doc: "Request animation is shown in full, regardless of app cycle speed", Required: false},
+			},
+		},
+		{
+			Name: "Row",
+			Doc:  "Row lays out and draws its children horizontally (in a row).\n\nBy default, a Row is as small as possible, while still holding all\nits children. However, if `expanded` is set, the Row will fill all\navailable space horizontally. The height of a Row is always that of\nits tallest child.\n\nAlignment along the horizontal main axis is controlled by passing\none of the following `main_align` values:\n- `\"start\"`: place children at the beginning of the row\n- `\"end\"`: place children at the end of the row\n- `\"center\"`: place children in the middle of the row\n- `\"space_between\"`: place equal space between children\n- `\"space_evenly\"`: equal space between children and before/after first/last child\n- `\"space_around\"`: equal space between children, and half of that before/after first/last child\n\nAlignment along the vertical cross axis is controlled by passing\none of the following `cross_align` values:\n- `\"start\"`: place children at the top\n- `\"end\"`: place children at the bottom\n- `\"center\"`: place children at the center",
+			Attributes: []Attribute{
+				{Name: "children", Type: "[Widget]", Doc: "Child widgets to lay out", Required: true},
+				{Name: "main_align", Type: "str", Doc: "Alignment along horizontal main axis", Required: false},
+				{Name: "cross_align", Type: "str", Doc: "Alignment along vertical cross axis", Required: false},
+				{Name: "expanded", Type: "bool", Doc: "Row should expand to fill all available horizontal space", Required: false},
+			},
+		},
+		{
+			Name: "Sequence",
+			Doc:  "Sequence renders a list of child widgets in sequence.\n\nEach child widget is rendered for the duration of its\nframe count, then the next child wiget in the list will\nbe rendered and so on.\n\nIt comes in quite useful when chaining animations.\nIf you want to know more about that, go check\nout the [animation](animation.md) documentation.",
+			Attributes: []Attribute{
+				{Name: "children", Type: "[Widget]", Doc: "List of child widgets", Required: true},
+			},
+		},
+		{
+			Name: "Stack",
+			Doc:  "Stack draws its children on top of each other.\n\nJust like a stack of pancakes, except with Widgets instead of\npancakes. The Stack will be given a width and height sufficient to\nfit all its children.",
+			Attributes: []Attribute{
+				{Name: "children", Type: "[Widget]", Doc: "Widgets to stack", Required: true},
+			},
+		},
+		{
+			Name: "Text",
+			Doc:  "Text draws a string of text on a single line.\n\nBy default, the text will use the \"tb-8\" font, but other fonts can\nbe chosen via the `font` attribute. Characters missing from the\nfont are drawn as its default character, unless `fallback` lists\nfonts to draw them with instead, like `[\"6x13\", \"10x20\", \"cjk-12\"]`.\nThe `height` and `offset` parameters allow fine tuning of the\nvertical layout of the string. Setting `antialias`\nsmooths the jagged diagonals of the bitmap fonts, which mostly\nhelps on larger canvases. Take a look at the\n[font documentation](fonts.md) for more information.",
+			Attributes: []Attribute{
+				{Name: "content", Type: "str", Doc: "The text string to draw", Required: true},
+				{Name: "font", Type: "str", Doc: "Desired font face", Required: false},
+				{Name: "height", Type: "int", Doc: "Limits height of the area on which text is drawn", Required: false},
+				{Name: "offset", Type: "int", Doc: "Shifts position of text vertically.", Required: false},
+				{Name: "color", Type: "color", Doc: "Desired font color", Required: false},
+				{Name: "fallback", Type: "[str]", Doc: "Fonts to use, in order, for characters missing from the font", Required: false},
+				{Name: "antialias", Type: "bool", Doc: "Smooth the diagonal edges of glyphs", Required: false},
+			},
+		},
+		{
+			Name: "WrappedText",
+			Doc:  "WrappedText draws multi-line text.\n\nThe optional `width` and `height` parameters limit the drawing\narea. If not set, WrappedText will use as much vertical and\nhorizontal space as possible to fit the text.\n\nAlignment of the text is controlled by passing one of the following `align` values:\n- `\"left\"`: align text to the left\n- `\"center\"`: align text in the center\n- `\"right\"`: align text to the right",
+			Attributes: []Attribute{
+				{Name: "content", Type: "str", Doc: "The text string to draw", Required: true},
+				{Name: "font", Type: "str", Doc: "Desired font face", Required: false},
+				{Name: "height", Type: "int", Doc: "Limits height of the area on which text may be drawn", Required: false},
+				{Name: "width", Type: "int", Doc: "Limits width of the area on which text may be drawn", Required: false},
+				{Name: "linespacing", Type: "int", Doc: "Controls spacing between lines", Required: false},
+				{Name: "color", Type: "color", Doc: "Desired font color", Required: false},
+				{Name: "align", Type: "str", Doc: "Text Alignment", Required: false},
+				{Name: "fallback", Type: "[str]", Doc: "Fonts to use, in order, for characters missing from the font", Required: false},
+				{Name: "antialias", Type: "bool", Doc: "Smooth the diagonal edges of glyphs", Required: false},
+			},
+		},
+	})
+}
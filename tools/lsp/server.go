@@ -0,0 +1,217 @@
+// Package lsp is a language server for Pixlet apps. It knows the modules
+// apps can load, so editors can complete load paths, module members and
+// the attributes of widgets and schema fields, and show their docs on
+// hover. As an app is edited, the server reports syntax errors, the
+// problems pixlet check finds, and uses of modules that don't match
+// what they contain.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+)
+
+// Server speaks the Language Server Protocol over a pair of streams,
+// usually the standard input and output of pixlet lsp.
+type Server struct {
+	r       *bufio.Reader
+	w       io.Writer
+	version string
+
+	docs     map[string]*document
+	shutdown bool
+}
+
+// NewServer returns a server that reads requests from r and writes
+// responses to w. The version is reported to the editor.
+func NewServer(r io.Reader, w io.Writer, version string) *Server {
+	return &Server{
+		r:       bufio.NewReader(r),
+		w:       w,
+		version: version,
+		docs:    map[string]*document{},
+	}
+}
+
+// Run handles requests until the editor asks the server to exit, or
+// closes its input.
+func (s *Server) Run() error {
+	for {
+		msg, err := readMessage(s.r)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		var rerr *responseError
+		if errors.As(err, &rerr) {
+			if err := s.reply(nil, nil, rerr); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("reading message: %w", err)
+		}
+
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return fmt.Errorf("exit without shutdown")
+			}
+			return nil
+		}
+
+		result, err := s.handle(msg)
+		if msg.ID == nil {
+			// a notification, which gets no response
+			if err != nil {
+				log.Printf("handling %s: %v\n", msg.Method, err)
+			}
+			continue
+		}
+
+		if errors.As(err, &rerr) {
+			err = s.reply(msg.ID, nil, rerr)
+		} else if err != nil {
+			err = s.reply(msg.ID, nil, &responseError{Code: codeInvalidRequest, Message: err.Error()})
+		} else {
+			err = s.reply(msg.ID, result, nil)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(msg *message) (interface{}, error) {
+	switch msg.Method {
+	case "initialize":
+		return InitializeResult{
+			Capabilities: ServerCapabilities{
+				TextDocumentSync: 1,
+				CompletionProvider: CompletionOptions{
+					TriggerCharacters: []string{".", "\"", "("},
+				},
+				HoverProvider: true,
+			},
+			ServerInfo: ServerInfo{Name: "pixlet", Version: s.version},
+		}, nil
+
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		return nil, s.update(params.TextDocument.URI, params.TextDocument.Text)
+
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		if len(params.ContentChanges) == 0 {
+			return nil, nil
+		}
+		// the whole text is sent, since that's the sync we asked for
+		text := params.ContentChanges[len(params.ContentChanges)-1].Text
+		return nil, s.update(params.TextDocument.URI, text)
+
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		delete(s.docs, params.TextDocument.URI)
+		return nil, s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+			URI:         params.TextDocument.URI,
+			Diagnostics: []Diagnostic{},
+		})
+
+	case "textDocument/completion":
+		var params TextDocumentPositionParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		doc, ok := s.docs[params.TextDocument.URI]
+		if !ok {
+			return nil, nil
+		}
+		items := doc.complete(params.Position)
+		if items == nil {
+			items = []CompletionItem{}
+		}
+		return CompletionList{Items: items}, nil
+
+	case "textDocument/hover":
+		var params TextDocumentPositionParams
+		if err := unmarshalParams(msg, &params); err != nil {
+			return nil, err
+		}
+		doc, ok := s.docs[params.TextDocument.URI]
+		if !ok {
+			return nil, nil
+		}
+		if h := doc.hover(params.Position); h != nil {
+			return h, nil
+		}
+		return nil, nil
+
+	case "initialized", "$/cancelRequest", "$/setTrace", "workspace/didChangeConfiguration":
+		return nil, nil
+
+	default:
+		return nil, &responseError{Code: codeMethodNotFound, Message: "method not found: " + msg.Method}
+	}
+}
+
+// update replaces the text of a document, and publishes its problems.
+func (s *Server) update(uri, text string) error {
+	doc := newDocument(uri, text)
+	s.docs[uri] = doc
+
+	return s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: doc.diagnostics(),
+	})
+}
+
+func (s *Server) reply(id *json.RawMessage, result interface{}, rerr *responseError) error {
+	msg := &message{ID: id, Error: rerr}
+	if id == nil {
+		// errors for messages that couldn't be read have a null ID
+		null := json.RawMessage("null")
+		msg.ID = &null
+	}
+
+	if rerr == nil {
+		// a response must have a result, even if it's null
+		data, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		msg.Result = data
+	}
+
+	return writeMessage(s.w, msg)
+}
+
+func (s *Server) notify(method string, params interface{}) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return writeMessage(s.w, &message{Method: method, Params: data})
+}
+
+func unmarshalParams(msg *message, v interface{}) error {
+	if err := json.Unmarshal(msg.Params, v); err != nil {
+		return &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testApp = `
+load("render.star", "render")
+load("http.star", "http")
+load("encoding/json.star", "json")
+
+def main(config):
+    return render.Root(
+        child = render.Box(colour = "#f00"),
+    )
+`
+
+// at returns the position of the first match of marker in text, plus
+// skip characters.
+func at(text, marker string, skip int) Position {
+	d := &document{text: text}
+	return d.position(strings.Index(text, marker) + skip)
+}
+
+func labels(items []CompletionItem) []string {
+	ls := []string{}
+	for _, item := range items {
+		ls = append(ls, item.Label)
+	}
+	return ls
+}
+
+func TestDiagnostics(t *testing.T) {
+	d := newDocument("file:///app/app.star", testApp)
+	diags := d.diagnostics()
+
+	messages := []string{}
+	for _, diag := range diags {
+		messages = append(messages, diag.Message)
+	}
+	assert.Equal(t, []string{
+		"http is loaded from http.star but never used",
+		"json is loaded from encoding/json.star but never used",
+		"render.Box has no attribute colour",
+	}, messages)
+
+	assert.Equal(t, SeverityWarning, diags[0].Severity)
+	assert.Equal(t, "unused-load", diags[0].Code)
+	assert.Equal(t, SeverityError, diags[2].Severity)
+	assert.Equal(t, at(testApp, "colour", 0), diags[2].Range.Start)
+	assert.Equal(t, at(testApp, "colour", 6), diags[2].Range.End)
+}
+
+func TestDiagnosticsModules(t *testing.T) {
+	src := `
+load("render.star", "render", "Box")
+load("nope.star", "nope")
+
+def main():
+    return render.Root(child = render.Bx())
+`
+	d := newDocument("untitled:app.star", src)
+
+	messages := []string{}
+	for _, diag := range d.diagnostics() {
+		messages = append(messages, diag.Message)
+	}
+	assert.Equal(t, []string{
+		"Box is loaded from render.star but never used",
+		"nope is loaded from nope.star but never used",
+		"render.star has no Box",
+		"render has no member Bx",
+	}, messages)
+
+	// files next to the app can be loaded, but there's no way to tell
+	// if they exist for documents that aren't files
+	d = newDocument("file:///nonexistent/app.star", src)
+	found := false
+	for _, diag := range d.diagnostics() {
+		found = found || diag.Message == "nope.star isn't a Pixlet module"
+	}
+	assert.True(t, found)
+}
+
+func TestDiagnosticsSyntaxError(t *testing.T) {
+	d := newDocument("untitled:app.star", "def main(:\n")
+	diags := d.diagnostics()
+	require.Len(t, diags, 1)
+	assert.Equal(t, SeverityError, diags[0].Severity)
+	assert.Equal(t, Position{Line: 0, Character: 10}, diags[0].Range.Start)
+}
+
+func TestCompleteLoad(t *testing.T) {
+	src := `load("enc`
+	d := newDocument("untitled:app.star", src)
+	items := d.complete(at(src, "enc", 3))
+	assert.Contains(t, labels(items), "encoding/json.star")
+	assert.Contains(t, labels(items), "render.star")
+	assert.Equal(t, Range{Start: at(src, "enc", 0), End: at(src, "enc", 3)}, items[0].TextEdit.Range)
+
+	src = `load("render.star", "`
+	d = newDocument("untitled:app.star", src)
+	assert.Equal(t, []string{"render"}, labels(d.complete(d.position(len(src)))))
+}
+
+func TestCompleteMembers(t *testing.T) {
+	// names are resolved even though the file doesn't parse
+	src := testApp + "\nx = render.\n"
+	d := newDocument("untitled:app.star", src)
+	require.Error(t, d.err)
+
+	items := d.complete(at(src, "render.\n", 7))
+	assert.Contains(t, labels(items), "Box")
+	assert.Contains(t, labels(items), "Root")
+	for _, item := range items {
+		if item.Label == "Box" {
+			assert.Equal(t, KindClass, item.Kind)
+			assert.Equal(t, "render.Box(child = ..., width = ..., height = ..., padding = ..., color = ...)", item.Detail)
+		}
+	}
+
+	src = "load(\"schema.star\", \"schema\")\nschema.HandlerType.\n"
+	d = newDocument("untitled:app.star", src)
+	items = d.complete(at(src, "Type.", 5))
+	assert.Equal(t, []string{"Field", "JSON", "Options", "Schema", "String", "Validation"}, labels(items))
+}
+
+func TestCompleteAttributes(t *testing.T) {
+	src := testApp + `
+def schema():
+    return schema.Toggle(
+        id = "a",
+        req
+`
+	src = strings.Replace(src, `load("http.star", "http")`, `load("schema.star", "schema")`, 1)
+	d := newDocument("untitled:app.star", src)
+	items := d.complete(at(src, "req", 3))
+
+	assert.Contains(t, labels(items), "required")
+	assert.Contains(t, labels(items), "visible_if")
+	assert.Contains(t, labels(items), "schema")
+	assert.Contains(t, labels(items), "len")
+	assert.Equal(t, "id = ", items[0].InsertText)
+}
+
+func TestHover(t *testing.T) {
+	d := newDocument("untitled:app.star", testApp)
+
+	h := d.hover(at(testApp, "Box", 1))
+	require.NotNil(t, h)
+	assert.Contains(t, h.Contents.Value, "render.Box(child = ..., width = ...")
+	assert.Contains(t, h.Contents.Value, "A Box is a rectangular widget")
+	assert.Equal(t, at(testApp, "render.Box", 0), h.Range.Start)
+
+	h = d.hover(at(testApp, "child", 1))
+	require.NotNil(t, h)
+	assert.Contains(t, h.Contents.Value, "**child** `Widget` (required)")
+
+	h = d.hover(at(testApp, "render.Root", 1))
+	require.NotNil(t, h)
+	assert.Contains(t, h.Contents.Value, "`render` is loaded from `render.star`")
+
+	assert.Nil(t, d.hover(at(testApp, "main", 1)))
+}
+
+func TestOffsetPosition(t *testing.T) {
+	d := &document{text: "a = \"ü😀\"\nb"}
+	for offset, pos := range map[int]Position{
+		0:  {0, 0},
+		5:  {0, 5},
+		7:  {0, 6},
+		11: {0, 8},
+		13: {1, 0},
+	} {
+		assert.Equal(t, pos, d.position(offset), offset)
+		assert.Equal(t, offset, d.offset(pos), pos)
+	}
+}
+
+// session sends requests to a server, and returns what it writes back.
+func session(t *testing.T, requests ...string) []message {
+	in := &bytes.Buffer{}
+	for _, req := range requests {
+		fmt.Fprintf(in, "Content-Length: %d\r\n\r\n%s", len(req), req)
+	}
+
+	out := &bytes.Buffer{}
+	require.NoError(t, NewServer(in, out, "test").Run())
+
+	msgs := []message{}
+	r := bufio.NewReader(out)
+	for {
+		msg, err := readMessage(r)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		msgs = append(msgs, *msg)
+	}
+	return msgs
+}
+
+func TestServer(t *testing.T) {
+	open, err := json.Marshal(DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: "untitled:app.star", Text: testApp},
+	})
+	require.NoError(t, err)
+
+	msgs := session(t,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+		`{"jsonrpc":"2.0","method":"initialized","params":{}}`,
+		`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":`+string(open)+`}`,
+		`{"jsonrpc":"2.0","id":2,"method":"textDocument/hover","params":{"textDocument":{"uri":"untitled:app.star"},"position":{"line":7,"character":24}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"textDocument/completion","params":{"textDocument":{"uri":"untitled:app.star"},"position":{"line":7,"character":27}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"workspace/symbol","params":{}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+	)
+	require.Len(t, msgs, 6)
+
+	var init InitializeResult
+	require.NoError(t, json.Unmarshal(msgs[0].Result, &init))
+	assert.True(t, init.Capabilities.HoverProvider)
+	assert.Equal(t, "test", init.ServerInfo.Version)
+
+	assert.Equal(t, "textDocument/publishDiagnostics", msgs[1].Method)
+	var diags PublishDiagnosticsParams
+	require.NoError(t, json.Unmarshal(msgs[1].Params, &diags))
+	assert.Len(t, diags.Diagnostics, 3)
+
+	var hover Hover
+	require.NoError(t, json.Unmarshal(msgs[2].Result, &hover))
+	assert.Contains(t, hover.Contents.Value, "A Box is a rectangular widget")
+
+	var list CompletionList
+	require.NoError(t, json.Unmarshal(msgs[3].Result, &list))
+	assert.Contains(t, labels(list.Items), "color")
+
+	require.NotNil(t, msgs[4].Error)
+	assert.Equal(t, codeMethodNotFound, msgs[4].Error.Code)
+
+	assert.Equal(t, json.RawMessage("null"), msgs[5].Result)
+}
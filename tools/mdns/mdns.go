@@ -0,0 +1,196 @@
+// Package mdns finds services on the local network using multicast DNS
+// service discovery.
+package mdns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+var mdnsAddr = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// Service is an instance of a service found on the network.
+type Service struct {
+	// Instance is the name of the instance, like "Kitchen".
+	Instance string
+
+	// Host is the host name the instance runs on, and Port its port.
+	Host string
+	Port int
+
+	// Addrs are the addresses of the host.
+	Addrs []net.IP
+
+	// Text holds the key value pairs from the instance's TXT record.
+	Text map[string]string
+}
+
+// Browse looks for instances of service, like "_http._tcp", until ctx
+// is done. Instances are sorted by name.
+func Browse(ctx context.Context, service string) ([]Service, error) {
+	query, err := buildQuery(service)
+	if err != nil {
+		return nil, err
+	}
+
+	// Queries sent from a port other than 5353 are answered directly to
+	// that port, so there's no need to join the multicast group.
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4zero})
+	if err != nil {
+		return nil, fmt.Errorf("opening socket: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.WriteToUDP(query, mdnsAddr); err != nil {
+		return nil, fmt.Errorf("sending query: %w", err)
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(2 * time.Second)
+	}
+	conn.SetReadDeadline(deadline)
+
+	r := newResults(service)
+	buf := make([]byte, 9000)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				break
+			}
+			return nil, fmt.Errorf("reading responses: %w", err)
+		}
+
+		// ignore anything that doesn't parse, it's not for us
+		r.add(buf[:n])
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return r.services(), nil
+}
+
+func fqdn(name string) string {
+	name = strings.TrimSuffix(name, ".")
+	if !strings.HasSuffix(name, ".local") {
+		name += ".local"
+	}
+	return name + "."
+}
+
+func buildQuery(service string) ([]byte, error) {
+	name, err := dnsmessage.NewName(fqdn(service))
+	if err != nil {
+		return nil, fmt.Errorf("invalid service name %s: %w", service, err)
+	}
+
+	msg := dnsmessage.Message{
+		Questions: []dnsmessage.Question{{
+			Name:  name,
+			Type:  dnsmessage.TypePTR,
+			Class: dnsmessage.ClassINET,
+		}},
+	}
+
+	return msg.Pack()
+}
+
+// results collects the records from all responses, since instances,
+// hosts and addresses can come in separate messages.
+type results struct {
+	service   string
+	instances map[string]bool
+	srv       map[string]dnsmessage.SRVResource
+	txt       map[string][]string
+	addrs     map[string][]net.IP
+}
+
+func newResults(service string) *results {
+	return &results{
+		service:   fqdn(service),
+		instances: map[string]bool{},
+		srv:       map[string]dnsmessage.SRVResource{},
+		txt:       map[string][]string{},
+		addrs:     map[string][]net.IP{},
+	}
+}
+
+func (r *results) add(packet []byte) error {
+	msg := dnsmessage.Message{}
+	if err := msg.Unpack(packet); err != nil {
+		return err
+	}
+
+	records := append(msg.Answers, msg.Additionals...)
+	for _, rr := range records {
+		name := strings.ToLower(rr.Header.Name.String())
+
+		switch body := rr.Body.(type) {
+		case *dnsmessage.PTRResource:
+			if name == strings.ToLower(r.service) {
+				r.instances[body.PTR.String()] = true
+			}
+		case *dnsmessage.SRVResource:
+			r.srv[name] = *body
+		case *dnsmessage.TXTResource:
+			r.txt[name] = body.TXT
+		case *dnsmessage.AResource:
+			r.addrs[name] = appendIP(r.addrs[name], net.IP(body.A[:]))
+		case *dnsmessage.AAAAResource:
+			r.addrs[name] = appendIP(r.addrs[name], net.IP(body.AAAA[:]))
+		}
+	}
+
+	return nil
+}
+
+func appendIP(ips []net.IP, ip net.IP) []net.IP {
+	for _, existing := range ips {
+		if existing.Equal(ip) {
+			return ips
+		}
+	}
+	return append(ips, ip)
+}
+
+func (r *results) services() []Service {
+	services := []Service{}
+	for instance := range r.instances {
+		key := strings.ToLower(instance)
+
+		s := Service{
+			Instance: strings.TrimSuffix(instance, "."+r.service),
+			Text:     map[string]string{},
+		}
+
+		if srv, ok := r.srv[key]; ok {
+			s.Host = strings.TrimSuffix(srv.Target.String(), ".")
+			s.Port = int(srv.Port)
+			s.Addrs = r.addrs[strings.ToLower(srv.Target.String())]
+		}
+
+		for _, entry := range r.txt[key] {
+			k, v, _ := strings.Cut(entry, "=")
+			s.Text[strings.ToLower(k)] = v
+		}
+
+		services = append(services, s)
+	}
+
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Instance < services[j].Instance
+	})
+
+	return services
+}
@@ -0,0 +1,77 @@
+package mdns
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func rr(name string, body dnsmessage.ResourceBody) dnsmessage.Resource {
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{
+			Name:  dnsmessage.MustNewName(name),
+			Class: dnsmessage.ClassINET,
+			TTL:   120,
+		},
+		Body: body,
+	}
+}
+
+func pack(t *testing.T, answers, additionals []dnsmessage.Resource) []byte {
+	msg := dnsmessage.Message{
+		Header:      dnsmessage.Header{Response: true, Authoritative: true},
+		Answers:     answers,
+		Additionals: additionals,
+	}
+	b, err := msg.Pack()
+	require.NoError(t, err)
+	return b
+}
+
+func TestBuildQuery(t *testing.T) {
+	b, err := buildQuery("_tidbyt._tcp")
+	require.NoError(t, err)
+
+	msg := dnsmessage.Message{}
+	require.NoError(t, msg.Unpack(b))
+	require.Len(t, msg.Questions, 1)
+	assert.Equal(t, "_tidbyt._tcp.local.", msg.Questions[0].Name.String())
+	assert.Equal(t, dnsmessage.TypePTR, msg.Questions[0].Type)
+}
+
+func TestResults(t *testing.T) {
+	r := newResults("_tidbyt._tcp")
+
+	// the instance and its details arrive in separate responses
+	require.NoError(t, r.add(pack(t, []dnsmessage.Resource{
+		rr("_tidbyt._tcp.local.", &dnsmessage.PTRResource{PTR: dnsmessage.MustNewName("Kitchen._tidbyt._tcp.local.")}),
+		rr("_tidbyt._tcp.local.", &dnsmessage.PTRResource{PTR: dnsmessage.MustNewName("Attic._tidbyt._tcp.local.")}),
+		rr("_other._tcp.local.", &dnsmessage.PTRResource{PTR: dnsmessage.MustNewName("Printer._other._tcp.local.")}),
+	}, nil)))
+
+	require.NoError(t, r.add(pack(t, []dnsmessage.Resource{
+		rr("Kitchen._tidbyt._tcp.local.", &dnsmessage.SRVResource{Target: dnsmessage.MustNewName("tidbyt-1.local."), Port: 80}),
+		rr("Kitchen._tidbyt._tcp.local.", &dnsmessage.TXTResource{TXT: []string{"id=brave-kitten-1a2", "model=gen2"}}),
+	}, []dnsmessage.Resource{
+		rr("tidbyt-1.local.", &dnsmessage.AResource{A: [4]byte{192, 168, 1, 20}}),
+		rr("tidbyt-1.local.", &dnsmessage.AResource{A: [4]byte{192, 168, 1, 20}}),
+	})))
+
+	assert.Error(t, r.add([]byte("garbage")))
+
+	services := r.services()
+	require.Len(t, services, 2)
+
+	assert.Equal(t, "Attic", services[0].Instance)
+	assert.Equal(t, "", services[0].Host)
+
+	kitchen := services[1]
+	assert.Equal(t, "Kitchen", kitchen.Instance)
+	assert.Equal(t, "tidbyt-1.local", kitchen.Host)
+	assert.Equal(t, 80, kitchen.Port)
+	assert.Equal(t, []net.IP{net.IPv4(192, 168, 1, 20).To4()}, kitchen.Addrs)
+	assert.Equal(t, map[string]string{"id": "brave-kitten-1a2", "model": "gen2"}, kitchen.Text)
+}
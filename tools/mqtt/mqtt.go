@@ -0,0 +1,294 @@
+// Package mqtt publishes images rendered from apps to an MQTT broker,
+// and announces them with Home Assistant's MQTT discovery, so that each
+// app shows up in Home Assistant as an image entity and a camera.
+package mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+const (
+	// DefaultTopicPrefix is where images are published if Config
+	// doesn't say.
+	DefaultTopicPrefix = "pixlet"
+
+	// DefaultDiscoveryPrefix is where Home Assistant looks for discovery
+	// messages, unless it's been configured otherwise.
+	DefaultDiscoveryPrefix = "homeassistant"
+
+	// DefaultClientID identifies the publisher to the broker, and to Home
+	// Assistant as a device, if Config doesn't say.
+	DefaultClientID = "pixlet"
+
+	timeout = 10 * time.Second
+)
+
+// Config says how to reach the broker, and where to publish.
+type Config struct {
+	// Broker is the URL of the broker, like tcp://localhost:1883. The
+	// schemes ssl, ws and wss work too.
+	Broker   string
+	Username string
+	Password string
+
+	// ClientID identifies the publisher to the broker. Several
+	// publishers sharing a broker need different IDs. It's also the
+	// ID of the device the apps' entities belong to in Home Assistant.
+	ClientID string
+
+	// TopicPrefix is prepended to the topics images are published to.
+	TopicPrefix string
+
+	// DiscoveryPrefix is the topic prefix Home Assistant watches for
+	// discovery messages.
+	DiscoveryPrefix string
+
+	// Version is reported to Home Assistant as the device's software
+	// version.
+	Version string
+
+	// SkipAvailability leaves out telling Home Assistant whether the
+	// publisher is connected, for publishers that disconnect after
+	// publishing once. Otherwise, the apps' entities are unavailable
+	// while it's disconnected.
+	SkipAvailability bool
+}
+
+// Image is what's published for an app.
+type Image struct {
+	// WebP is the whole animation, for the image entity.
+	WebP []byte
+
+	// PNG is a single frame, for the camera, since cameras can't show
+	// WebP in every browser. It's left unpublished if nil.
+	PNG []byte
+}
+
+// Publisher publishes images for apps. The first time an app is
+// published, and whenever Home Assistant restarts, the app is announced
+// to Home Assistant. Images are retained, so that Home Assistant shows
+// the latest ones straight away.
+type Publisher struct {
+	cfg  Config
+	node string
+
+	// send publishes a message. It's replaced in tests.
+	send func(topic string, retained bool, payload []byte) error
+
+	mu        sync.Mutex
+	announced map[string]bool
+
+	client paho.Client
+}
+
+// objectIDChars are the characters allowed in Home Assistant's IDs.
+var objectIDChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// objectID turns a name into an ID Home Assistant accepts.
+func objectID(name string) string {
+	return strings.Trim(objectIDChars.ReplaceAllString(name, "_"), "_")
+}
+
+func newPublisher(cfg Config) *Publisher {
+	if cfg.TopicPrefix == "" {
+		cfg.TopicPrefix = DefaultTopicPrefix
+	}
+	if cfg.DiscoveryPrefix == "" {
+		cfg.DiscoveryPrefix = DefaultDiscoveryPrefix
+	}
+	if cfg.ClientID == "" {
+		cfg.ClientID = DefaultClientID
+	}
+	cfg.TopicPrefix = strings.TrimSuffix(cfg.TopicPrefix, "/")
+	cfg.DiscoveryPrefix = strings.TrimSuffix(cfg.DiscoveryPrefix, "/")
+
+	return &Publisher{
+		cfg:       cfg,
+		node:      objectID(cfg.ClientID),
+		announced: map[string]bool{},
+	}
+}
+
+// Connect connects to the broker in cfg. The connection is kept up, and
+// reestablished if it drops, until Close is called.
+func Connect(cfg Config) (*Publisher, error) {
+	if cfg.Broker == "" {
+		return nil, fmt.Errorf("no MQTT broker given")
+	}
+
+	p := newPublisher(cfg)
+
+	opts := paho.NewClientOptions().
+		AddBroker(cfg.Broker).
+		SetClientID(p.cfg.ClientID).
+		SetUsername(cfg.Username).
+		SetPassword(cfg.Password).
+		SetConnectTimeout(timeout).
+		SetAutoReconnect(true).
+		SetOrderMatters(false).
+		SetOnConnectHandler(func(c paho.Client) {
+			// tell Home Assistant the entities are available again, and
+			// find out when it restarts, since it forgets them then
+			if !p.cfg.SkipAvailability {
+				c.Publish(p.availabilityTopic(), 1, true, "online")
+			}
+			c.Subscribe(p.cfg.DiscoveryPrefix+"/status", 1, func(c paho.Client, m paho.Message) {
+				if string(m.Payload()) == "online" {
+					p.reannounce()
+				}
+			})
+		})
+	if !cfg.SkipAvailability {
+		opts.SetWill(p.availabilityTopic(), "offline", 1, true)
+	}
+
+	p.client = paho.NewClient(opts)
+	p.send = func(topic string, retained bool, payload []byte) error {
+		t := p.client.Publish(topic, 1, retained, payload)
+		if !t.WaitTimeout(timeout) {
+			return fmt.Errorf("publishing to %s timed out", topic)
+		}
+		return t.Error()
+	}
+
+	t := p.client.Connect()
+	if !t.WaitTimeout(timeout) {
+		return nil, fmt.Errorf("connecting to %s timed out", cfg.Broker)
+	}
+	if err := t.Error(); err != nil {
+		return nil, fmt.Errorf("connecting to %s: %w", cfg.Broker, err)
+	}
+
+	return p, nil
+}
+
+// Close marks the apps' entities as unavailable, and disconnects.
+func (p *Publisher) Close() {
+	if !p.cfg.SkipAvailability {
+		p.send(p.availabilityTopic(), true, []byte("offline"))
+	}
+	p.client.Disconnect(250)
+}
+
+// Publish publishes img for the app with the given name.
+func (p *Publisher) Publish(app string, img Image) error {
+	p.mu.Lock()
+	announced := p.announced[app]
+	p.mu.Unlock()
+
+	if !announced {
+		if err := p.announce(app); err != nil {
+			return err
+		}
+	}
+
+	if err := p.send(p.topic(app, "image"), true, img.WebP); err != nil {
+		return err
+	}
+	if img.PNG != nil {
+		if err := p.send(p.topic(app, "camera"), true, img.PNG); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// announce sends the discovery messages for an app.
+func (p *Publisher) announce(app string) error {
+	for _, msg := range p.discovery(app) {
+		payload, err := json.Marshal(msg.config)
+		if err != nil {
+			return err
+		}
+		if err := p.send(msg.topic, true, payload); err != nil {
+			return fmt.Errorf("announcing %s: %w", app, err)
+		}
+	}
+
+	p.mu.Lock()
+	p.announced[app] = true
+	p.mu.Unlock()
+
+	return nil
+}
+
+// reannounce sends the discovery messages for every app published so
+// far again.
+func (p *Publisher) reannounce() {
+	p.mu.Lock()
+	apps := make([]string, 0, len(p.announced))
+	for app := range p.announced {
+		apps = append(apps, app)
+	}
+	p.mu.Unlock()
+
+	for _, app := range apps {
+		p.announce(app)
+	}
+}
+
+// discoveryMessage is a discovery config and the topic it goes to.
+type discoveryMessage struct {
+	topic  string
+	config map[string]interface{}
+}
+
+// discovery returns the discovery messages for an app: one for an image
+// entity showing the animation, and one for a camera showing a frame.
+func (p *Publisher) discovery(app string) []discoveryMessage {
+	id := objectID(app)
+	device := map[string]interface{}{
+		"identifiers":  []string{p.node},
+		"name":         "Pixlet",
+		"manufacturer": "Tidbyt",
+		"model":        "Pixlet",
+	}
+	if p.cfg.Version != "" {
+		device["sw_version"] = p.cfg.Version
+	}
+
+	msgs := []discoveryMessage{
+		{
+			topic: fmt.Sprintf("%s/image/%s/%s/config", p.cfg.DiscoveryPrefix, p.node, id),
+			config: map[string]interface{}{
+				"name":         app,
+				"unique_id":    fmt.Sprintf("%s_%s_image", p.node, id),
+				"image_topic":  p.topic(app, "image"),
+				"content_type": "image/webp",
+				"device":       device,
+			},
+		},
+		{
+			topic: fmt.Sprintf("%s/camera/%s/%s/config", p.cfg.DiscoveryPrefix, p.node, id),
+			config: map[string]interface{}{
+				"name":      app,
+				"unique_id": fmt.Sprintf("%s_%s_camera", p.node, id),
+				"topic":     p.topic(app, "camera"),
+				"device":    device,
+			},
+		},
+	}
+	if !p.cfg.SkipAvailability {
+		for _, msg := range msgs {
+			msg.config["availability_topic"] = p.availabilityTopic()
+		}
+	}
+
+	return msgs
+}
+
+func (p *Publisher) topic(app, kind string) string {
+	return fmt.Sprintf("%s/%s/%s", p.cfg.TopicPrefix, objectID(app), kind)
+}
+
+func (p *Publisher) availabilityTopic() string {
+	return fmt.Sprintf("%s/%s/status", p.cfg.TopicPrefix, p.node)
+}
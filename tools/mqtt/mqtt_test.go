@@ -0,0 +1,118 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sent struct {
+	topic    string
+	retained bool
+	payload  []byte
+}
+
+func testPublisher(cfg Config) (*Publisher, *[]sent) {
+	p := newPublisher(cfg)
+	msgs := &[]sent{}
+	p.send = func(topic string, retained bool, payload []byte) error {
+		*msgs = append(*msgs, sent{topic, retained, payload})
+		return nil
+	}
+	return p, msgs
+}
+
+func topics(msgs []sent) []string {
+	ts := []string{}
+	for _, m := range msgs {
+		ts = append(ts, m.topic)
+	}
+	return ts
+}
+
+func TestObjectID(t *testing.T) {
+	assert.Equal(t, "clock", objectID("clock"))
+	assert.Equal(t, "bus_times", objectID("bus times!"))
+	assert.Equal(t, "apps_weather-2", objectID("apps/weather-2"))
+}
+
+func TestPublish(t *testing.T) {
+	p, msgs := testPublisher(Config{Version: "v1.2.3"})
+
+	require.NoError(t, p.Publish("bus times", Image{WebP: []byte("webp"), PNG: []byte("png")}))
+	assert.Equal(t, []string{
+		"homeassistant/image/pixlet/bus_times/config",
+		"homeassistant/camera/pixlet/bus_times/config",
+		"pixlet/bus_times/image",
+		"pixlet/bus_times/camera",
+	}, topics(*msgs))
+
+	for _, m := range *msgs {
+		assert.True(t, m.retained, m.topic)
+	}
+	assert.Equal(t, "webp", string((*msgs)[2].payload))
+	assert.Equal(t, "png", string((*msgs)[3].payload))
+
+	var image map[string]interface{}
+	require.NoError(t, json.Unmarshal((*msgs)[0].payload, &image))
+	assert.Equal(t, "bus times", image["name"])
+	assert.Equal(t, "pixlet_bus_times_image", image["unique_id"])
+	assert.Equal(t, "pixlet/bus_times/image", image["image_topic"])
+	assert.Equal(t, "image/webp", image["content_type"])
+	assert.Equal(t, "pixlet/pixlet/status", image["availability_topic"])
+	assert.Equal(t, "v1.2.3", image["device"].(map[string]interface{})["sw_version"])
+
+	var camera map[string]interface{}
+	require.NoError(t, json.Unmarshal((*msgs)[1].payload, &camera))
+	assert.Equal(t, "pixlet/bus_times/camera", camera["topic"])
+
+	// apps are only announced once, and cameras are left out without a
+	// PNG
+	*msgs = nil
+	require.NoError(t, p.Publish("bus times", Image{WebP: []byte("webp")}))
+	assert.Equal(t, []string{"pixlet/bus_times/image"}, topics(*msgs))
+}
+
+func TestPublishPrefixes(t *testing.T) {
+	p, msgs := testPublisher(Config{
+		ClientID:        "kitchen display",
+		TopicPrefix:     "home/pixlet/",
+		DiscoveryPrefix: "ha",
+	})
+
+	require.NoError(t, p.Publish("clock", Image{WebP: []byte("webp")}))
+	assert.Equal(t, []string{
+		"ha/image/kitchen_display/clock/config",
+		"ha/camera/kitchen_display/clock/config",
+		"home/pixlet/clock/image",
+	}, topics(*msgs))
+	assert.Equal(t, "home/pixlet/kitchen_display/status", p.availabilityTopic())
+}
+
+func TestSkipAvailability(t *testing.T) {
+	p, msgs := testPublisher(Config{SkipAvailability: true})
+	require.NoError(t, p.Publish("clock", Image{WebP: []byte("webp")}))
+
+	var image map[string]interface{}
+	require.NoError(t, json.Unmarshal((*msgs)[0].payload, &image))
+	assert.NotContains(t, image, "availability_topic")
+}
+
+func TestReannounce(t *testing.T) {
+	p, msgs := testPublisher(Config{})
+	require.NoError(t, p.Publish("clock", Image{WebP: []byte("webp")}))
+
+	*msgs = nil
+	p.reannounce()
+	assert.Equal(t, []string{
+		"homeassistant/image/pixlet/clock/config",
+		"homeassistant/camera/pixlet/clock/config",
+	}, topics(*msgs))
+}
+
+func TestConnectNoBroker(t *testing.T) {
+	_, err := Connect(Config{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,226 @@
+//go:build !js && !wasm
+
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	vault "github.com/hashicorp/vault/api"
+)
+
+// VaultProvider reads secrets from a HashiCorp Vault KV version 2
+// secrets engine. Each app's secrets are the keys of one Vault secret.
+type VaultProvider struct {
+	// Addr is the address of the Vault server. If empty, it's
+	// $VAULT_ADDR.
+	Addr string
+
+	// Token authenticates with Vault. If empty, it's $VAULT_TOKEN.
+	Token string
+
+	// Path is the secret holding an app's secrets, starting with the
+	// mount of the secrets engine, with {app} for the app ID. If empty,
+	// it's DefaultVaultPath.
+	Path string
+
+	// CacheTTL is how long secrets are kept. If zero, it's
+	// DefaultCacheTTL.
+	CacheTTL time.Duration
+
+	// Client talks to Vault. If nil, one is made from Addr, Token and
+	// the standard Vault environment variables.
+	Client *vault.Client
+
+	once  sync.Once
+	cache cache
+
+	mu sync.Mutex
+}
+
+// GetSecret implements Provider.
+func (p *VaultProvider) GetSecret(ctx context.Context, appID, name string) (string, error) {
+	p.once.Do(func() { p.cache.ttl = p.CacheTTL })
+	return p.cache.get(ctx, appID, name, p.fetch)
+}
+
+// client returns Client, making it first if it's nil.
+func (p *VaultProvider) client() (*vault.Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.Client != nil {
+		return p.Client, nil
+	}
+
+	config := vault.DefaultConfig()
+	if config.Error != nil {
+		return nil, fmt.Errorf("vault: %w", config.Error)
+	}
+	if p.Addr != "" {
+		config.Address = p.Addr
+	}
+
+	client, err := vault.NewClient(config)
+	if err != nil {
+		return nil, fmt.Errorf("vault: %w", err)
+	}
+	if p.Token != "" {
+		client.SetToken(p.Token)
+	}
+
+	p.Client = client
+	return client, nil
+}
+
+func (p *VaultProvider) fetch(ctx context.Context, appID string) (map[string]string, error) {
+	client, err := p.client()
+	if err != nil {
+		return nil, err
+	}
+
+	path := p.Path
+	if path == "" {
+		path = DefaultVaultPath
+	}
+	path = strings.ReplaceAll(strings.Trim(path, "/"), "{app}", appID)
+
+	mount, rest, ok := strings.Cut(path, "/")
+	if !ok {
+		return nil, fmt.Errorf("vault: path %s has no mount", path)
+	}
+
+	secret, err := client.KVv2(mount).Get(ctx, rest)
+	if errors.Is(err, vault.ErrSecretNotFound) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("vault: %w", err)
+	}
+
+	secrets := map[string]string{}
+	for key, value := range secret.Data {
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("vault: %s in %s is not a string", key, path)
+		}
+		secrets[key] = s
+	}
+	return secrets, nil
+}
+
+// AWSProvider reads secrets from AWS Secrets Manager. Each app's secrets
+// are the keys of one secret, stored as a JSON object. Credentials and
+// the region are found like the AWS CLI finds them, from the
+// environment, shared config files, or the instance's role.
+type AWSProvider struct {
+	// SecretID is the name or ARN of the secret holding an app's
+	// secrets, with {app} for the app ID. If empty, it's
+	// DefaultAWSSecretID.
+	SecretID string
+
+	// Region is the AWS region. If empty, it's the region of the
+	// default AWS config, like $AWS_REGION.
+	Region string
+
+	// Endpoint overrides the Secrets Manager endpoint, like for a VPC
+	// endpoint.
+	Endpoint string
+
+	// CacheTTL is how long secrets are kept. If zero, it's
+	// DefaultCacheTTL.
+	CacheTTL time.Duration
+
+	// Client talks to Secrets Manager. If nil, one is made from the
+	// default AWS config, with Region and Endpoint.
+	Client *secretsmanager.Client
+
+	once  sync.Once
+	cache cache
+
+	mu sync.Mutex
+}
+
+// GetSecret implements Provider.
+func (p *AWSProvider) GetSecret(ctx context.Context, appID, name string) (string, error) {
+	p.once.Do(func() { p.cache.ttl = p.CacheTTL })
+	return p.cache.get(ctx, appID, name, p.fetch)
+}
+
+// client returns Client, making it first if it's nil.
+func (p *AWSProvider) client(ctx context.Context) (*secretsmanager.Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.Client != nil {
+		return p.Client, nil
+	}
+
+	var opts []func(*config.LoadOptions) error
+	if p.Region != "" {
+		opts = append(opts, config.WithRegion(p.Region))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("aws: %w", err)
+	}
+	if cfg.Region == "" {
+		return nil, fmt.Errorf("aws: no region, set AWS_REGION")
+	}
+
+	p.Client = secretsmanager.NewFromConfig(cfg, func(o *secretsmanager.Options) {
+		if p.Endpoint != "" {
+			o.BaseEndpoint = aws.String(p.Endpoint)
+		}
+	})
+	return p.Client, nil
+}
+
+func (p *AWSProvider) fetch(ctx context.Context, appID string) (map[string]string, error) {
+	client, err := p.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	id := p.SecretID
+	if id == "" {
+		id = DefaultAWSSecretID
+	}
+	id = strings.ReplaceAll(id, "{app}", appID)
+
+	out, err := client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(id),
+	})
+	var missing *types.ResourceNotFoundException
+	if errors.As(err, &missing) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("aws: %w", err)
+	}
+	if out.SecretString == nil {
+		return nil, fmt.Errorf("aws: %s is not a JSON object of strings: it's binary", id)
+	}
+
+	secrets := map[string]string{}
+	if err := json.Unmarshal([]byte(*out.SecretString), &secrets); err != nil {
+		return nil, fmt.Errorf("aws: %s is not a JSON object of strings: %w", id, err)
+	}
+	return secrets, nil
+}
+
+func newCloudProvider(kind, arg string) (Provider, error) {
+	if kind == "vault" {
+		return &VaultProvider{Path: arg}, nil
+	}
+	return &AWSProvider{SecretID: arg}, nil
+}
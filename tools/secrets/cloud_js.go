@@ -0,0 +1,9 @@
+//go:build js && wasm
+
+package secrets
+
+import "fmt"
+
+func newCloudProvider(kind, arg string) (Provider, error) {
+	return nil, fmt.Errorf("%s secrets providers are not supported in WASM", kind)
+}
@@ -0,0 +1,112 @@
+//go:build !js && !wasm
+
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVaultProvider(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("X-Vault-Token") != "token" {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/kv/data/apps/clock":
+			w.Write([]byte(`{"data": {"data": {"API_KEY": "abc"}, "metadata": {}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("VAULT_TOKEN", "")
+
+	p := &VaultProvider{Addr: srv.URL, Token: "token", Path: "kv/apps/{app}"}
+
+	value, err := p.GetSecret(context.Background(), "clock", "API_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, "abc", value)
+
+	// the app's secrets are cached
+	_, err = p.GetSecret(context.Background(), "clock", "MISSING")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.Equal(t, 1, requests)
+
+	_, err = p.GetSecret(context.Background(), "weather", "API_KEY")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	p = &VaultProvider{Addr: srv.URL, Token: "wrong", Path: "kv/apps/{app}"}
+	_, err = p.GetSecret(context.Background(), "clock", "API_KEY")
+	assert.ErrorContains(t, err, "permission denied")
+	assert.NotErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestAWSProvider(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	t.Setenv("AWS_CONFIG_FILE", t.TempDir()+"/config")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", t.TempDir()+"/credentials")
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") || !strings.Contains(auth, "/eu-west-1/secretsmanager/aws4_request") {
+			http.Error(w, "bad signature", http.StatusForbidden)
+			return
+		}
+		if r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" {
+			http.Error(w, "bad target", http.StatusBadRequest)
+			return
+		}
+
+		var req struct{ SecretId string }
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.SecretId != "pixlet/clock" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type": "ResourceNotFoundException", "message": "Secrets Manager can't find the specified secret."}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{
+			"Name":         req.SecretId,
+			"SecretString": `{"API_KEY": "abc"}`,
+		})
+	}))
+	defer srv.Close()
+
+	p := &AWSProvider{Endpoint: srv.URL}
+
+	value, err := p.GetSecret(context.Background(), "clock", "API_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, "abc", value)
+
+	_, err = p.GetSecret(context.Background(), "weather", "API_KEY")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestParseProvider(t *testing.T) {
+	p, err := ParseProvider("vault:kv/{app}")
+	require.NoError(t, err)
+	assert.Equal(t, "kv/{app}", p.(*VaultProvider).Path)
+
+	p, err = ParseProvider("aws")
+	require.NoError(t, err)
+	assert.IsType(t, &AWSProvider{}, p)
+
+	_, err = ParseProvider("gcp")
+	assert.Error(t, err)
+}
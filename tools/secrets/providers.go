@@ -0,0 +1,161 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultEnvPrefix starts the names of environment variables
+	// EnvProvider reads secrets from.
+	DefaultEnvPrefix = "PIXLET_SECRET_"
+
+	// DefaultVaultPath is where VaultProvider reads secrets from, with
+	// {app} for the app ID.
+	DefaultVaultPath = "secret/pixlet/{app}"
+
+	// DefaultAWSSecretID is the secret AWSProvider reads secrets from,
+	// with {app} for the app ID.
+	DefaultAWSSecretID = "pixlet/{app}"
+
+	// DefaultCacheTTL is how long secrets fetched from Vault or AWS are
+	// kept, unless told otherwise.
+	DefaultCacheTTL = 5 * time.Minute
+)
+
+// Provider looks up secrets for apps. It's runtime.SecretsProvider, so
+// providers here can be given to the runtime.
+type Provider interface {
+	GetSecret(ctx context.Context, appID, name string) (string, error)
+}
+
+// notFound returns an error wrapping fs.ErrNotExist for a missing secret.
+func notFound(appID, name string) error {
+	return fmt.Errorf("secret %s for %s: %w", name, appID, fs.ErrNotExist)
+}
+
+// EnvProvider reads secrets from environment variables, trying
+// <Prefix><APP_ID>_<NAME> and then <Prefix><NAME>. App IDs are upper
+// cased, with dashes replaced by underscores.
+type EnvProvider struct {
+	// Prefix starts the names of the variables. If empty, it's
+	// DefaultEnvPrefix.
+	Prefix string
+}
+
+// GetSecret implements Provider.
+func (p *EnvProvider) GetSecret(ctx context.Context, appID, name string) (string, error) {
+	prefix := p.Prefix
+	if prefix == "" {
+		prefix = DefaultEnvPrefix
+	}
+
+	app := strings.ToUpper(strings.ReplaceAll(appID, "-", "_"))
+	for _, key := range []string{prefix + app + "_" + name, prefix + name} {
+		if value, ok := os.LookupEnv(key); ok {
+			return value, nil
+		}
+	}
+
+	return "", notFound(appID, name)
+}
+
+// cache keeps the secrets of each app for a while, so that secret
+// stores aren't asked on every render.
+type cache struct {
+	ttl time.Duration
+
+	mu   sync.Mutex
+	apps map[string]cached
+}
+
+type cached struct {
+	secrets map[string]string
+	expires time.Time
+}
+
+// get returns the secret with the given name for appID, calling fetch
+// for all of the app's secrets if they aren't cached.
+func (c *cache) get(ctx context.Context, appID, name string, fetch func(ctx context.Context, appID string) (map[string]string, error)) (string, error) {
+	c.mu.Lock()
+	entry, ok := c.apps[appID]
+	c.mu.Unlock()
+
+	if !ok || time.Now().After(entry.expires) {
+		secrets, err := fetch(ctx, appID)
+		if err != nil {
+			return "", err
+		}
+
+		ttl := c.ttl
+		if ttl == 0 {
+			ttl = DefaultCacheTTL
+		}
+		entry = cached{secrets: secrets, expires: time.Now().Add(ttl)}
+
+		c.mu.Lock()
+		if c.apps == nil {
+			c.apps = map[string]cached{}
+		}
+		c.apps[appID] = entry
+		c.mu.Unlock()
+	}
+
+	value, ok := entry.secrets[name]
+	if !ok {
+		return "", notFound(appID, name)
+	}
+	return value, nil
+}
+
+// Chain asks each of its providers in turn, returning the first secret
+// found.
+type Chain []Provider
+
+// GetSecret implements Provider.
+func (c Chain) GetSecret(ctx context.Context, appID, name string) (string, error) {
+	for _, p := range c {
+		value, err := p.GetSecret(ctx, appID, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		return value, err
+	}
+	return "", notFound(appID, name)
+}
+
+// ParseProvider parses a comma separated list of providers, which are
+// asked in order:
+//
+//	env[:PREFIX]        environment variables, see EnvProvider
+//	vault[:PATH]        Vault, see VaultProvider
+//	aws[:SECRET_ID]     AWS Secrets Manager, see AWSProvider
+func ParseProvider(spec string) (Provider, error) {
+	var chain Chain
+	for _, part := range strings.Split(spec, ",") {
+		kind, arg, _ := strings.Cut(strings.TrimSpace(part), ":")
+		switch kind {
+		case "env":
+			chain = append(chain, &EnvProvider{Prefix: arg})
+		case "vault", "aws":
+			p, err := newCloudProvider(kind, arg)
+			if err != nil {
+				return nil, err
+			}
+			chain = append(chain, p)
+		default:
+			return nil, fmt.Errorf("unknown secrets provider: %q, use env, vault or aws", part)
+		}
+	}
+
+	if len(chain) == 1 {
+		return chain[0], nil
+	}
+	return chain, nil
+}
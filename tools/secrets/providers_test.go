@@ -0,0 +1,52 @@
+package secrets
+
+import (
+	"context"
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvProvider(t *testing.T) {
+	t.Setenv("PIXLET_SECRET_API_KEY", "shared")
+	t.Setenv("PIXLET_SECRET_MY_APP_API_KEY", "mine")
+	t.Setenv("CUSTOM_TOKEN", "custom")
+
+	p := &EnvProvider{}
+	value, err := p.GetSecret(context.Background(), "my-app", "API_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, "mine", value)
+
+	value, err = p.GetSecret(context.Background(), "other", "API_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, "shared", value)
+
+	_, err = p.GetSecret(context.Background(), "other", "TOKEN")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	value, err = (&EnvProvider{Prefix: "CUSTOM_"}).GetSecret(context.Background(), "other", "TOKEN")
+	require.NoError(t, err)
+	assert.Equal(t, "custom", value)
+}
+
+func TestChain(t *testing.T) {
+	t.Setenv("A_TOKEN", "a")
+	t.Setenv("B_TOKEN", "b")
+	t.Setenv("B_API_KEY", "b")
+
+	p, err := ParseProvider("env:A_, env:B_")
+	require.NoError(t, err)
+
+	value, err := p.GetSecret(context.Background(), "app", "TOKEN")
+	require.NoError(t, err)
+	assert.Equal(t, "a", value)
+
+	value, err = p.GetSecret(context.Background(), "app", "API_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, "b", value)
+
+	_, err = p.GetSecret(context.Background(), "app", "MISSING")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
@@ -0,0 +1,292 @@
+// Package secrets manages the encrypted secrets of an app in bulk: it
+// reads plaintext secrets from .env files, and reads and writes the
+// encrypted values as a block of Starlark constants or as a manifest. It
+// also has providers that look secrets up in the environment, Vault or
+// AWS Secrets Manager for the runtime, so they needn't be encrypted into
+// every app.
+package secrets
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.starlark.net/starlark"
+)
+
+const (
+	// BlockStart and BlockEnd surround the block of encrypted secrets
+	// in a Starlark file, so that it can be found and updated.
+	BlockStart = "# BEGIN pixlet encrypt, do not edit"
+	BlockEnd   = "# END pixlet encrypt"
+)
+
+var (
+	nameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	lineRegexp = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)\s*=\s*(".*")$`)
+)
+
+// Secret is a named secret, either plaintext or encrypted.
+type Secret struct {
+	Name  string
+	Value string
+}
+
+// ParseEnv reads secrets from a .env file. Each line is NAME=value, and
+// may start with export. Values can be quoted with single or double
+// quotes, and double quoted values can hold escapes like \n. Blank lines
+// and lines starting with # are skipped. Names must be valid Starlark
+// identifiers, since they name the constants in the block.
+func ParseEnv(r io.Reader) ([]Secret, error) {
+	var secrets []Secret
+	seen := map[string]bool{}
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected NAME=value", n)
+		}
+
+		name = strings.TrimSpace(name)
+		if !nameRegexp.MatchString(name) {
+			return nil, fmt.Errorf("line %d: %q is not a valid name, use letters, digits and underscores", n, name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("line %d: %s is set twice", n, name)
+		}
+		seen[name] = true
+
+		value, err := unquoteEnv(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+
+		secrets = append(secrets, Secret{Name: name, Value: value})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return secrets, nil
+}
+
+func unquoteEnv(value string) (string, error) {
+	switch {
+	case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+		return strconv.Unquote(value)
+	case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+		return value[1 : len(value)-1], nil
+	case strings.HasPrefix(value, `"`) || strings.HasPrefix(value, `'`):
+		return "", fmt.Errorf("unterminated quote")
+	}
+
+	// unquoted values end at a comment
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value, nil
+}
+
+// FormatBlock formats encrypted secrets as a block of Starlark
+// constants, sorted by name. Pass each constant to secret.decrypt in
+// the app.
+func FormatBlock(secrets []Secret) []byte {
+	secrets = sorted(secrets)
+
+	var buf bytes.Buffer
+	fmt.Fprintln(&buf, BlockStart)
+	for _, s := range secrets {
+		fmt.Fprintf(&buf, "%s = %s\n", s.Name, starlark.String(s.Value).String())
+	}
+	fmt.Fprintln(&buf, BlockEnd)
+	return buf.Bytes()
+}
+
+// ReadBlock reads the encrypted secrets in the block of a Starlark file.
+// It returns nil if the file has no block.
+func ReadBlock(src []byte) ([]Secret, error) {
+	start, end, err := findBlock(src)
+	if err != nil || start < 0 {
+		return nil, err
+	}
+
+	secrets := []Secret{}
+	for _, line := range strings.Split(string(src[start:end]), "\n")[1:] {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		m := lineRegexp.FindStringSubmatch(line)
+		if m == nil {
+			return nil, fmt.Errorf("unexpected line in secrets block: %s", line)
+		}
+
+		value, err := strconv.Unquote(m[2])
+		if err != nil {
+			return nil, fmt.Errorf("reading %s in secrets block: %w", m[1], err)
+		}
+		secrets = append(secrets, Secret{Name: m[1], Value: value})
+	}
+
+	return secrets, nil
+}
+
+// UpdateBlock sets encrypted secrets in the block of a Starlark file,
+// and returns the updated file. Secrets already in the block that
+// aren't being set are kept. If the file has no block, one is added
+// after its load statements.
+func UpdateBlock(src []byte, secrets []Secret) ([]byte, error) {
+	existing, err := ReadBlock(src)
+	if err != nil {
+		return nil, err
+	}
+
+	block := FormatBlock(Merge(existing, secrets))
+
+	start, end, err := findBlock(src)
+	if err != nil {
+		return nil, err
+	}
+
+	if start < 0 {
+		start = afterLoads(src)
+		end = start
+		if start > 0 {
+			block = append([]byte("\n"), block...)
+		}
+		if start < len(src) && src[start] != '\n' {
+			block = append(block, '\n')
+		}
+	} else {
+		// replace the end marker's line too
+		end += len(BlockEnd)
+		if end < len(src) && src[end] == '\n' {
+			end++
+		}
+	}
+
+	out := append([]byte{}, src[:start]...)
+	out = append(out, block...)
+	return append(out, src[end:]...), nil
+}
+
+// findBlock returns the offsets of the start marker and of the end
+// marker, or -1 if there is no block.
+func findBlock(src []byte) (int, int, error) {
+	start := bytes.Index(src, []byte(BlockStart))
+	if start < 0 {
+		return -1, -1, nil
+	}
+
+	end := bytes.Index(src[start:], []byte(BlockEnd))
+	if end < 0 {
+		return -1, -1, fmt.Errorf("secrets block is missing its end line: %s", BlockEnd)
+	}
+
+	return start, start + end, nil
+}
+
+// afterLoads returns the offset of the line after the last top level
+// load statement, or 0 if there are none.
+func afterLoads(src []byte) int {
+	offset := 0
+	pos := 0
+	inLoad := false
+
+	for _, line := range strings.SplitAfter(string(src), "\n") {
+		pos += len(line)
+		if strings.HasPrefix(line, "load(") {
+			inLoad = true
+		}
+		if inLoad && strings.Contains(line, ")") {
+			inLoad = false
+			offset = pos
+		}
+	}
+
+	return offset
+}
+
+// Manifest holds the encrypted secrets of an app, for tools that deploy
+// apps and pass secrets to them outside of their source.
+type Manifest struct {
+	AppID   string            `json:"app_id"`
+	Secrets map[string]string `json:"secrets"`
+}
+
+// ReadManifest reads a manifest of encrypted secrets.
+func ReadManifest(r io.Reader) (*Manifest, error) {
+	m := &Manifest{}
+	if err := json.NewDecoder(r).Decode(m); err != nil {
+		return nil, fmt.Errorf("reading secrets manifest: %w", err)
+	}
+	if m.Secrets == nil {
+		m.Secrets = map[string]string{}
+	}
+	return m, nil
+}
+
+// Set sets encrypted secrets in the manifest.
+func (m *Manifest) Set(secrets []Secret) {
+	if m.Secrets == nil {
+		m.Secrets = map[string]string{}
+	}
+	for _, s := range secrets {
+		m.Secrets[s.Name] = s.Value
+	}
+}
+
+// List returns the secrets in the manifest, sorted by name.
+func (m *Manifest) List() []Secret {
+	secrets := []Secret{}
+	for name, value := range m.Secrets {
+		secrets = append(secrets, Secret{Name: name, Value: value})
+	}
+	return sorted(secrets)
+}
+
+// Write writes the manifest as indented JSON.
+func (m *Manifest) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
+
+// Merge returns existing with the secrets in updates set, sorted by
+// name.
+func Merge(existing []Secret, updates []Secret) []Secret {
+	byName := map[string]string{}
+	for _, s := range existing {
+		byName[s.Name] = s.Value
+	}
+	for _, s := range updates {
+		byName[s.Name] = s.Value
+	}
+
+	merged := []Secret{}
+	for name, value := range byName {
+		merged = append(merged, Secret{Name: name, Value: value})
+	}
+	return sorted(merged)
+}
+
+func sorted(secrets []Secret) []Secret {
+	secrets = append([]Secret{}, secrets...)
+	sort.Slice(secrets, func(i, j int) bool { return secrets[i].Name < secrets[j].Name })
+	return secrets
+}
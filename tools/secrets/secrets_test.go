@@ -0,0 +1,104 @@
+package secrets
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEnv(t *testing.T) {
+	env := `
+# API keys
+API_KEY=abc123
+export TOKEN = "two\nlines"
+SINGLE='it''s raw \n'
+EMPTY=
+WITH_COMMENT=value # a comment
+`
+
+	secrets, err := ParseEnv(strings.NewReader(env))
+	require.NoError(t, err)
+
+	assert.Equal(t, []Secret{
+		{Name: "API_KEY", Value: "abc123"},
+		{Name: "TOKEN", Value: "two\nlines"},
+		{Name: "SINGLE", Value: `it''s raw \n`},
+		{Name: "EMPTY", Value: ""},
+		{Name: "WITH_COMMENT", Value: "value"},
+	}, secrets)
+}
+
+func TestParseEnvErrors(t *testing.T) {
+	for name, env := range map[string]string{
+		"no equals":    "API_KEY\n",
+		"bad name":     "API-KEY=1\n",
+		"set twice":    "A=1\nA=2\n",
+		"unterminated": `A="abc` + "\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseEnv(strings.NewReader(env))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestUpdateBlock(t *testing.T) {
+	src := `load("render.star", "render")
+load("secret.star", "secret")
+
+def main(config):
+    return render.Root(child = render.Text(secret.decrypt(API_KEY) or ""))
+`
+
+	// the block goes after the loads
+	out, err := UpdateBlock([]byte(src), []Secret{{Name: "API_KEY", Value: "AV6+aaa"}})
+	require.NoError(t, err)
+	assert.Equal(t, `load("render.star", "render")
+load("secret.star", "secret")
+
+`+BlockStart+`
+API_KEY = "AV6+aaa"
+`+BlockEnd+`
+
+def main(config):
+    return render.Root(child = render.Text(secret.decrypt(API_KEY) or ""))
+`, string(out))
+
+	// updating replaces values and keeps the others
+	out, err = UpdateBlock(out, []Secret{{Name: "TOKEN", Value: "AV6+ccc"}, {Name: "API_KEY", Value: "AV6+bbb"}})
+	require.NoError(t, err)
+
+	secrets, err := ReadBlock(out)
+	require.NoError(t, err)
+	assert.Equal(t, []Secret{
+		{Name: "API_KEY", Value: "AV6+bbb"},
+		{Name: "TOKEN", Value: "AV6+ccc"},
+	}, secrets)
+	assert.Equal(t, 1, strings.Count(string(out), BlockStart))
+	assert.True(t, strings.HasSuffix(string(out), "or \"\"))\n"))
+}
+
+func TestReadBlockWithoutBlock(t *testing.T) {
+	secrets, err := ReadBlock([]byte("def main():\n    return []\n"))
+	require.NoError(t, err)
+	assert.Nil(t, secrets)
+
+	_, err = ReadBlock([]byte(BlockStart + "\nA = \"1\"\n"))
+	assert.Error(t, err)
+}
+
+func TestManifest(t *testing.T) {
+	m := &Manifest{AppID: "weather"}
+	m.Set([]Secret{{Name: "B", Value: "2"}, {Name: "A", Value: "1"}})
+
+	var buf bytes.Buffer
+	require.NoError(t, m.Write(&buf))
+
+	read, err := ReadManifest(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, "weather", read.AppID)
+	assert.Equal(t, []Secret{{Name: "A", Value: "1"}, {Name: "B", Value: "2"}}, read.List())
+}
@@ -25,3 +25,24 @@ func (sfs *SingleFileFS) Open(name string) (fs.File, error) {
 
 	return sfs.baseFS.Open(name)
 }
+
+// ReadDir lists only the single file, so that apps loaded from it don't
+// try to load the other Starlark files next to it.
+func (sfs *SingleFileFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name != "." {
+		return nil, fs.ErrNotExist
+	}
+
+	entries, err := fs.ReadDir(sfs.baseFS, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if entry.Name() == filepath.Base(sfs.Path) {
+			return []fs.DirEntry{entry}, nil
+		}
+	}
+
+	return nil, fs.ErrNotExist
+}
@@ -0,0 +1,31 @@
+package tools
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSingleFileFS(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.star"), []byte("app"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app_test.star"), []byte("test"), 0644))
+
+	sfs := NewSingleFileFS(filepath.Join(dir, "app.star"))
+
+	data, err := fs.ReadFile(sfs, "app.star")
+	require.NoError(t, err)
+	assert.Equal(t, "app", string(data))
+
+	_, err = fs.ReadFile(sfs, "app_test.star")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	entries, err := fs.ReadDir(sfs, ".")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "app.star", entries[0].Name())
+}
@@ -0,0 +1,88 @@
+// Package tracing records OpenTelemetry spans for loading and running
+// apps, the HTTP requests they make, their schema handlers and encoding
+// their output. Spans carry the applet ID in the pixlet.applet.id
+// attribute.
+//
+// Spans go to the global tracer provider, so they're dropped unless a
+// program embedding pixlet installs one with otel.SetTracerProvider, or
+// calls Init.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "tidbyt.dev/pixlet"
+
+// AppletIDKey is the attribute with the ID of the applet a span is for.
+const AppletIDKey = attribute.Key("pixlet.applet.id")
+
+// AppletID returns the attribute for an applet's ID.
+func AppletID(id string) attribute.KeyValue {
+	return AppletIDKey.String(id)
+}
+
+// Start starts a span called name, as a child of any span in ctx.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End ends span, marking it as failed if err isn't nil.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Enabled reports whether Init would export spans, because an OTLP
+// endpoint is set in the environment.
+func Enabled() bool {
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" ||
+		os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// Init exports spans over OTLP/HTTP, if an endpoint is set with the
+// standard OTEL_EXPORTER_OTLP_* environment variables, and otherwise
+// does nothing. The exporter is configured by the same variables. Call
+// the returned function before exiting to send the spans that are left.
+func Init(ctx context.Context, service string) (shutdown func(context.Context) error, err error) {
+	if !Enabled() {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(
+		resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(service)),
+	)
+	if err != nil && !errors.Is(err, resource.ErrPartialResource) {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return tp.Shutdown, nil
+}
@@ -0,0 +1,245 @@
+//go:build js && wasm
+
+// Command wasm is pixlet's runtime and renderer compiled to WebAssembly,
+// so that web editors can preview apps without a server. Build it with
+// make wasm, and load it with the wasm_exec.js that comes with Go:
+//
+//	const go = new Go();
+//	const { instance } = await WebAssembly.instantiateStreaming(fetch("pixlet.wasm"), go.importObject);
+//	go.run(instance);
+//
+//	const { image, contentType } = await pixlet.render(
+//		{ "clock.star": source },
+//		{ timezone: "America/New_York" },
+//		{ format: "gif", magnify: 10 },
+//	);
+//
+// See docs/wasm.md for the options.
+package main
+
+import (
+	"context"
+	"fmt"
+	"syscall/js"
+	"testing/fstest"
+	"time"
+
+	"go.starlark.net/starlark"
+
+	"tidbyt.dev/pixlet/encode"
+	"tidbyt.dev/pixlet/globals"
+	"tidbyt.dev/pixlet/runtime"
+)
+
+// DefaultTimeout is how long apps may run, unless the options say
+// otherwise.
+const DefaultTimeout = 30 * time.Second
+
+var contentTypes = map[encode.Format]string{
+	encode.FormatGIF: "image/gif",
+	encode.FormatPNG: "image/png",
+}
+
+func main() {
+	cache := runtime.NewInMemoryCache()
+	runtime.InitHTTP(cache)
+	runtime.InitCache(cache)
+
+	js.Global().Set("pixlet", js.ValueOf(map[string]any{
+		"render": js.FuncOf(renderJS),
+	}))
+
+	// keep the exported functions around
+	select {}
+}
+
+// renderJS is pixlet.render(files, config, options). It returns a promise,
+// since apps may make HTTP requests, which can't finish while the
+// browser's event loop is blocked.
+func renderJS(this js.Value, args []js.Value) any {
+	arg := func(i int) js.Value {
+		if i < len(args) {
+			return args[i]
+		}
+		return js.Undefined()
+	}
+
+	return promise(func() (js.Value, error) {
+		files, err := jsFiles(arg(0))
+		if err != nil {
+			return js.Undefined(), err
+		}
+
+		return renderApp(files, jsConfig(arg(1)), arg(2))
+	})
+}
+
+func renderApp(files fstest.MapFS, config map[string]string, options js.Value) (js.Value, error) {
+	id := stringOption(options, "id", "app")
+
+	format, err := encode.ParseFormat(stringOption(options, "format", string(encode.FormatGIF)))
+	if err != nil {
+		return js.Undefined(), err
+	}
+	if _, ok := contentTypes[format]; !ok {
+		return js.Undefined(), fmt.Errorf("%s is not supported in WASM", format)
+	}
+
+	opts := []runtime.AppletOption{
+		runtime.WithLimits(runtime.Limits{
+			Timeout:   time.Duration(intOption(options, "timeout", int(DefaultTimeout/time.Millisecond))) * time.Millisecond,
+			MaxFrames: intOption(options, "maxFrames", 0),
+		}),
+	}
+	if onPrint := jsOption(options, "onPrint"); onPrint.Type() == js.TypeFunction {
+		opts = append(opts, runtime.WithPrintFunc(func(thread *starlark.Thread, msg string) {
+			onPrint.Invoke(msg)
+		}))
+	}
+
+	applet, err := runtime.NewAppletFromFS(id, files, opts...)
+	if err != nil {
+		return js.Undefined(), fmt.Errorf("error loading applet: %w", err)
+	}
+
+	// apps lay themselves out for the size, so run them at it too
+	width, height := intOption(options, "width", 0), intOption(options, "height", 0)
+	ctx := globals.ContextWithSize(context.Background(), width, height)
+	roots, err := applet.RunWithConfig(ctx, config)
+	if err != nil {
+		return js.Undefined(), fmt.Errorf("error running script: %w", err)
+	}
+
+	img, err := encode.EncodeContext(ctx, roots, encode.Options{
+		Format:      format,
+		Width:       width,
+		Height:      height,
+		MaxDuration: intOption(options, "maxDuration", 15000),
+		Frame:       intOption(options, "frame", encode.FrameMidpoint),
+		Magnify:     intOption(options, "magnify", 1),
+	})
+	if err != nil {
+		return js.Undefined(), err
+	}
+
+	image := js.Global().Get("Uint8Array").New(len(img))
+	js.CopyBytesToJS(image, img)
+
+	return js.ValueOf(map[string]any{
+		"image":       image,
+		"contentType": contentTypes[format],
+		"empty":       len(roots) == 0,
+		"maxAge":      int(encode.ScreensFromRoots(roots).MaxAge),
+	}), nil
+}
+
+// promise runs f in a goroutine, and returns a promise for its result.
+func promise(f func() (js.Value, error)) js.Value {
+	executor := js.FuncOf(func(this js.Value, args []js.Value) any {
+		resolve, reject := args[0], args[1]
+
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					reject.Invoke(jsError(fmt.Errorf("panic: %v", r)))
+				}
+			}()
+
+			val, err := f()
+			if err != nil {
+				reject.Invoke(jsError(err))
+				return
+			}
+			resolve.Invoke(val)
+		}()
+
+		return nil
+	})
+	defer executor.Release()
+
+	return js.Global().Get("Promise").New(executor)
+}
+
+func jsError(err error) js.Value {
+	return js.Global().Get("Error").New(err.Error())
+}
+
+// jsFiles converts the app's files to a file system. They can be given
+// as the source of a single file, or as an object from paths to their
+// contents, as strings or Uint8Arrays.
+func jsFiles(v js.Value) (fstest.MapFS, error) {
+	switch v.Type() {
+	case js.TypeString:
+		return fstest.MapFS{
+			"app.star": &fstest.MapFile{Data: []byte(v.String())},
+		}, nil
+
+	case js.TypeObject:
+		files := fstest.MapFS{}
+		keys := js.Global().Get("Object").Call("keys", v)
+		for i := 0; i < keys.Length(); i++ {
+			path := keys.Index(i).String()
+			data, err := jsBytes(v.Get(path))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			files[path] = &fstest.MapFile{Data: data}
+		}
+		return files, nil
+
+	default:
+		return nil, fmt.Errorf("files must be a string or an object, not %s", v.Type())
+	}
+}
+
+func jsBytes(v js.Value) ([]byte, error) {
+	if v.Type() == js.TypeString {
+		return []byte(v.String()), nil
+	}
+
+	if v.InstanceOf(js.Global().Get("Uint8Array")) {
+		data := make([]byte, v.Length())
+		js.CopyBytesToGo(data, v)
+		return data, nil
+	}
+
+	return nil, fmt.Errorf("contents must be a string or a Uint8Array, not %s", v.Type())
+}
+
+// jsConfig converts an object to the config an app is run with. Values
+// that aren't strings are converted with String, as in JavaScript.
+func jsConfig(v js.Value) map[string]string {
+	config := map[string]string{}
+	if v.Type() != js.TypeObject {
+		return config
+	}
+
+	keys := js.Global().Get("Object").Call("keys", v)
+	for i := 0; i < keys.Length(); i++ {
+		k := keys.Index(i).String()
+		config[k] = js.Global().Call("String", v.Get(k)).String()
+	}
+
+	return config
+}
+
+func jsOption(options js.Value, name string) js.Value {
+	if options.Type() != js.TypeObject {
+		return js.Undefined()
+	}
+	return options.Get(name)
+}
+
+func stringOption(options js.Value, name string, def string) string {
+	if v := jsOption(options, name); v.Type() == js.TypeString {
+		return v.String()
+	}
+	return def
+}
+
+func intOption(options js.Value, name string, def int) int {
+	if v := jsOption(options, name); v.Type() == js.TypeNumber {
+		return v.Int()
+	}
+	return def
+}
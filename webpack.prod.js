@@ -21,7 +21,9 @@ const copyPlugin = new CopyWebpackPlugin({
 let plugins = [htmlPlugin, copyPlugin];
 plugins.push(
     new webpack.DefinePlugin({
-        'PIXLET_API_BASE': JSON.stringify(''),
+        // Set by pixlet serve when the app is one of several in a
+        // workspace, and served under its own path.
+        'PIXLET_API_BASE': '(window.PIXLET_APP_BASE || "")',
     })
 );
 
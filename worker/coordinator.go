@@ -0,0 +1,202 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"tidbyt.dev/pixlet/encode"
+	"tidbyt.dev/pixlet/server/loader"
+)
+
+const (
+	// DefaultTimeout is how long jobs have to render, unless the
+	// Coordinator says otherwise.
+	DefaultTimeout = 30 * time.Second
+
+	// DefaultMaxBundleSize is the largest bundle the Coordinator takes
+	// over HTTP, unless it says otherwise.
+	DefaultMaxBundleSize = 16 << 20
+)
+
+// Query parameters reserved by the Coordinator. The app gets the rest as
+// config.
+const (
+	formatParam      = "_format"
+	frameParam       = "_frame"
+	maxDurationParam = "_max_duration"
+)
+
+// Coordinator submits render jobs to workers, for Go programs or over
+// HTTP. Set its fields before using it.
+type Coordinator struct {
+	Queue Queue
+
+	// Timeout is how long jobs have to render, including the time they
+	// wait for a worker. It defaults to DefaultTimeout.
+	Timeout time.Duration
+
+	// MaxBundleSize is the largest request body ServeHTTP takes, in
+	// bytes. It defaults to DefaultMaxBundleSize.
+	MaxBundleSize int64
+
+	// Logger is where failed jobs are logged. If nil, they go to
+	// slog.Default().
+	Logger *slog.Logger
+}
+
+// Render submits job and waits for a worker to render it, within the
+// timeout.
+func (c *Coordinator) Render(ctx context.Context, job *Job) (*Result, error) {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return c.Queue.Submit(ctx, job)
+}
+
+// ServeHTTP renders the app posted to it. The body is either a bundle,
+// with the config as query parameters, or a Job as JSON, with the bundle
+// in base64. Apart from config, the query can have:
+//
+//	_format          webp, gif, png or bmp
+//	_width, _height  the size to render at, from 1 to 512
+//	_frame           the frame of a PNG or BMP, as an index or midpoint
+//	_max_duration    the longest the animation can be, in milliseconds
+//
+// Without a format, it's picked from the Accept header. The response is
+// the image, with the worker that rendered it in X-Pixlet-Worker, or 204
+// No Content if the app returned no roots.
+func (c *Coordinator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		writeError(w, http.StatusMethodNotAllowed, "only POST is supported")
+		return
+	}
+
+	maxSize := c.MaxBundleSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxBundleSize
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSize))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("bundle is larger than %d bytes", maxSize))
+		return
+	}
+
+	job, err := parseJob(r, body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if job.Format == "" {
+		job.Format = encode.NegotiateFormat(r.Header.Get("Accept"), encode.FormatWebP)
+		if job.Format == "" {
+			writeError(w, http.StatusNotAcceptable, "can only render image/webp, image/gif, image/png or image/bmp")
+			return
+		}
+	}
+
+	res, err := c.Render(r.Context(), job)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		writeError(w, http.StatusGatewayTimeout, "no worker rendered the app in time")
+		return
+	case err != nil:
+		c.log().Warn("submitting job", "job", job.ID, "error", err)
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	w.Header().Set("X-Pixlet-Worker", res.Worker)
+	switch {
+	case res.Error != "":
+		writeError(w, http.StatusUnprocessableEntity, res.Error)
+	case res.Skipped:
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Content-Type", res.Format.ContentType())
+		w.Header().Set("Content-Length", strconv.Itoa(len(res.Image)))
+		w.Write(res.Image)
+	}
+}
+
+// parseJob reads the job from a request to ServeHTTP.
+func parseJob(r *http.Request, body []byte) (*Job, error) {
+	job := &Job{}
+	if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt == "application/json" {
+		if err := json.Unmarshal(body, job); err != nil {
+			return nil, fmt.Errorf("decoding job: %w", err)
+		}
+		// the coordinator decides these
+		job.ID, job.ReplyTo, job.Deadline = "", "", time.Time{}
+	} else {
+		job.Bundle = body
+		job.Frame = encode.FrameMidpoint
+	}
+	if len(job.Bundle) == 0 {
+		return nil, errors.New("no bundle")
+	}
+
+	for k, vals := range r.URL.Query() {
+		var err error
+		switch k {
+		case formatParam:
+			job.Format, err = encode.ParseFormat(vals[0])
+		case frameParam:
+			job.Frame, err = encode.ParseFrameIndex(vals[0])
+		case maxDurationParam:
+			job.MaxDuration, err = strconv.Atoi(vals[0])
+		case loader.WidthConfigKey:
+			job.Width, err = boundedInt(k, vals[0], loader.MaxSize)
+		case loader.HeightConfigKey:
+			job.Height, err = boundedInt(k, vals[0], loader.MaxSize)
+		default:
+			if job.Config == nil {
+				job.Config = map[string]string{}
+			}
+			job.Config[k] = vals[0]
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if job.Width < 0 || job.Width > loader.MaxSize || job.Height < 0 || job.Height > loader.MaxSize {
+		return nil, fmt.Errorf("width and height must be from 1 to %d", loader.MaxSize)
+	}
+	return job, nil
+}
+
+// boundedInt parses the value of a query parameter, which must be a
+// number from 1 to max.
+func boundedInt(name, value string, max int) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 || n > max {
+		return 0, fmt.Errorf("%s must be from 1 to %d, found %q", name, max, value)
+	}
+	return n, nil
+}
+
+// log returns the coordinator's logger.
+func (c *Coordinator) log() *slog.Logger {
+	if c.Logger == nil {
+		return slog.Default()
+	}
+	return c.Logger
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
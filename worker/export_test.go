@@ -0,0 +1,14 @@
+package worker
+
+import (
+	"testing"
+	"time"
+)
+
+// SetRedisRenewInterval sets how often workers renew their claim on
+// Redis jobs, for the rest of the test.
+func SetRedisRenewInterval(t testing.TB, d time.Duration) {
+	old := redisRenewInterval
+	redisRenewInterval = d
+	t.Cleanup(func() { redisRenewInterval = old })
+}
@@ -0,0 +1,100 @@
+// Package worker renders apps on other machines, so that hosted
+// deployments can scale rendering out rather than up. A Coordinator
+// puts render jobs, each an app bundle and the config to render it
+// with, on a Queue shared with any number of Workers, and waits for one
+// of them to push back the image.
+package worker
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"tidbyt.dev/pixlet/encode"
+)
+
+// Job is an app to render, and how to render it.
+type Job struct {
+	// ID identifies the job. Queues set it when it's empty.
+	ID string `json:"id"`
+
+	// Bundle is the app, as a bundle written by pixlet bundle.
+	Bundle []byte `json:"bundle"`
+
+	// Config is what the app is run with.
+	Config map[string]string `json:"config,omitempty"`
+
+	// Format is the format of the image. It defaults to WebP.
+	Format encode.Format `json:"format,omitempty"`
+
+	// Width and Height are the size to render at. If zero, it's 64x32.
+	Width  int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
+
+	// MaxDuration is the longest the animation can be, in milliseconds.
+	// If zero, every frame is encoded.
+	MaxDuration int `json:"max_duration,omitempty"`
+
+	// Frame picks the frame of PNGs and BMPs, as in encode.Options.
+	Frame int `json:"frame,omitempty"`
+
+	// Deadline is when the submitter stops waiting for the result.
+	// Workers drop jobs they get after it, and stop apps that are still
+	// running when it passes. Queues set it from the context given to
+	// Submit when it's zero.
+	Deadline time.Time `json:"deadline"`
+
+	// ReplyTo is where the queue sends the result. It's set by the
+	// queue.
+	ReplyTo string `json:"reply_to,omitempty"`
+
+	// ack acknowledges the job, once its result is sent. It's set by
+	// queues that deliver jobs again if their worker doesn't reply.
+	ack func(ctx context.Context) error
+
+	// release stops the queue holding on to the job for its worker,
+	// once it's replying. It's set by queues that hold on to jobs while
+	// they're worked on.
+	release func()
+}
+
+// Result is the outcome of a Job.
+type Result struct {
+	JobID string `json:"job_id"`
+
+	// Image is the rendered app, in Format.
+	Image  []byte        `json:"image,omitempty"`
+	Format encode.Format `json:"format,omitempty"`
+
+	// Skipped is whether the app returned no roots, which tells a
+	// device to skip it in the rotation.
+	Skipped bool `json:"skipped,omitempty"`
+
+	// Error is why the job failed, if it did.
+	Error string `json:"error,omitempty"`
+
+	// Worker is the name of the worker that rendered the job.
+	Worker string `json:"worker,omitempty"`
+
+	// Duration is how long the worker took.
+	Duration time.Duration `json:"duration"`
+}
+
+// prepare sets what queues fill in on jobs before sending them.
+func (j *Job) prepare(deadline time.Time, ok bool) {
+	if j.ID == "" {
+		j.ID = newID()
+	}
+	if j.Deadline.IsZero() && ok {
+		j.Deadline = deadline
+	}
+}
+
+func newID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,270 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	// natsTimeout is how long the NATS server has to answer when
+	// connecting.
+	natsTimeout = 10 * time.Second
+
+	// natsConsumer is the durable consumer workers share, so that each
+	// job goes to one of them.
+	natsConsumer = "pixlet-workers"
+
+	// natsPollInterval is how long fetches wait for a job at a time, so
+	// that the context is checked in between.
+	natsPollInterval = time.Second
+
+	// natsAckWait is how long a job can go unacknowledged before it's
+	// delivered again, as when its worker died.
+	natsAckWait = time.Minute
+
+	// natsMaxDeliver is how many times a job is delivered at most, so
+	// that jobs that bring down their workers don't go round forever.
+	natsMaxDeliver = 3
+)
+
+// NATS is a Queue on a NATS server with JetStream. Jobs are published
+// on a subject named after the queue, and kept in a work queue stream
+// until a worker acknowledges them, which it does once it has sent the
+// result. Jobs left unacknowledged for a minute, by workers that died,
+// are delivered again, up to three times. Results are published on an
+// inbox of the submitter's.
+type NATS struct {
+	conn    *nats.Conn
+	js      jetstream.JetStream
+	subject string
+	stream  string
+	inbox   *nats.Subscription
+
+	mu       sync.Mutex
+	pending  map[string]chan *Result
+	consumer jetstream.Consumer
+
+	closed chan struct{}
+	once   sync.Once
+}
+
+// DialNATS connects to the NATS server at u, a nats:// or tls:// URL as
+// taken by Open, and returns the queue on subject name.
+func DialNATS(u *url.URL, name string) (*NATS, error) {
+	stripped := *u
+	stripped.RawQuery = ""
+
+	conn, err := nats.Connect(stripped.String(), nats.Name("pixlet"), nats.Timeout(natsTimeout))
+	if err != nil {
+		return nil, fmt.Errorf("connecting to nats at %s: %w", u.Host, err)
+	}
+
+	n := &NATS{
+		conn:    conn,
+		subject: name,
+		// stream names can't hold dots
+		stream:  strings.ReplaceAll(name, ".", "_"),
+		pending: map[string]chan *Result{},
+		closed:  make(chan struct{}),
+	}
+
+	if err := n.setup(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("connecting to nats at %s: %w", u.Host, err)
+	}
+	return n, nil
+}
+
+// setup creates the stream jobs are kept in, unless it exists, and
+// subscribes to the inbox results come back on.
+func (n *NATS) setup() error {
+	ctx, cancel := context.WithTimeout(context.Background(), natsTimeout)
+	defer cancel()
+
+	js, err := jetstream.New(n.conn)
+	if err != nil {
+		return err
+	}
+	n.js = js
+
+	if _, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:      n.stream,
+		Subjects:  []string{n.subject},
+		Retention: jetstream.WorkQueuePolicy,
+	}); err != nil {
+		return fmt.Errorf("creating stream %s: %w", n.stream, err)
+	}
+
+	n.inbox, err = n.conn.Subscribe(n.conn.NewInbox()+".*", n.deliver)
+	if err != nil {
+		return fmt.Errorf("subscribing to inbox: %w", err)
+	}
+	return nil
+}
+
+// Submit implements Queue.
+func (n *NATS) Submit(ctx context.Context, job *Job) (*Result, error) {
+	job.prepare(ctx.Deadline())
+	job.ReplyTo = strings.TrimSuffix(n.inbox.Subject, "*") + job.ID
+
+	data, err := json.Marshal(job)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(chan *Result, 1)
+	n.mu.Lock()
+	n.pending[job.ID] = results
+	n.mu.Unlock()
+
+	defer func() {
+		n.mu.Lock()
+		delete(n.pending, job.ID)
+		n.mu.Unlock()
+	}()
+
+	if _, err := n.js.Publish(ctx, n.subject, data); err != nil {
+		return nil, fmt.Errorf("queueing job: %w", natsError(err))
+	}
+
+	select {
+	case res := <-results:
+		return res, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-n.closed:
+		return nil, ErrClosed
+	}
+}
+
+// Receive implements Queue. Jobs that can't be decoded, and jobs whose
+// deadline has passed, are dropped.
+func (n *NATS) Receive(ctx context.Context) (*Job, error) {
+	consumer, err := n.jobConsumer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		select {
+		case <-n.closed:
+			return nil, ErrClosed
+		default:
+		}
+
+		batch, err := consumer.Fetch(1, jetstream.FetchMaxWait(natsPollInterval))
+		if err != nil {
+			return nil, natsError(err)
+		}
+
+		for msg := range batch.Messages() {
+			var job Job
+			if json.Unmarshal(msg.Data(), &job) != nil ||
+				(!job.Deadline.IsZero() && time.Now().After(job.Deadline)) {
+				msg.Term()
+				continue
+			}
+
+			job.ack = msg.DoubleAck
+			return &job, nil
+		}
+		if err := batch.Error(); err != nil && !errors.Is(err, nats.ErrTimeout) {
+			return nil, natsError(err)
+		}
+	}
+}
+
+// Reply implements Queue. It acknowledges the job once the result is
+// sent.
+func (n *NATS) Reply(ctx context.Context, job *Job, result *Result) error {
+	if job.ReplyTo == "" {
+		return fmt.Errorf("job %s has nowhere to reply to", job.ID)
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	if err := n.conn.Publish(job.ReplyTo, data); err != nil {
+		return fmt.Errorf("sending result: %w", natsError(err))
+	}
+
+	if job.ack != nil {
+		if err := job.ack(ctx); err != nil {
+			return fmt.Errorf("acknowledging job: %w", natsError(err))
+		}
+	}
+	return nil
+}
+
+// Close implements Queue.
+func (n *NATS) Close() error {
+	n.once.Do(func() {
+		close(n.closed)
+		n.conn.Close()
+	})
+	return nil
+}
+
+// jobConsumer returns the consumer workers fetch jobs with, creating
+// it on first use.
+func (n *NATS) jobConsumer(ctx context.Context) (jetstream.Consumer, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.consumer != nil {
+		return n.consumer, nil
+	}
+
+	consumer, err := n.js.CreateOrUpdateConsumer(ctx, n.stream, jetstream.ConsumerConfig{
+		Durable:    natsConsumer,
+		AckPolicy:  jetstream.AckExplicitPolicy,
+		AckWait:    natsAckWait,
+		MaxDeliver: natsMaxDeliver,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating consumer: %w", natsError(err))
+	}
+	n.consumer = consumer
+	return consumer, nil
+}
+
+// deliver passes a result to the submitter waiting for it. Results that
+// can't be decoded are dropped, since there's no one to tell.
+func (n *NATS) deliver(msg *nats.Msg) {
+	var res Result
+	if json.Unmarshal(msg.Data, &res) != nil {
+		return
+	}
+
+	n.mu.Lock()
+	results, ok := n.pending[res.JobID]
+	n.mu.Unlock()
+
+	if ok {
+		select {
+		case results <- &res:
+		default:
+		}
+	}
+}
+
+// natsError returns ErrClosed for errors from a closed connection.
+func natsError(err error) error {
+	if errors.Is(err, nats.ErrConnectionClosed) {
+		return ErrClosed
+	}
+	return err
+}
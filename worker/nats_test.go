@@ -0,0 +1,101 @@
+package worker_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tidbyt.dev/pixlet/worker"
+)
+
+// startNATS runs a NATS server with JetStream, and returns its URL.
+func startNATS(t *testing.T, maxPayload int32) string {
+	s, err := server.NewServer(&server.Options{
+		Host:       "127.0.0.1",
+		Port:       server.RANDOM_PORT,
+		JetStream:  true,
+		StoreDir:   t.TempDir(),
+		MaxPayload: maxPayload,
+		NoLog:      true,
+		NoSigs:     true,
+	})
+	require.NoError(t, err)
+
+	go s.Start()
+	require.True(t, s.ReadyForConnections(10*time.Second))
+	t.Cleanup(s.Shutdown)
+	return s.ClientURL()
+}
+
+func TestNATS(t *testing.T) {
+	url := startNATS(t, 1<<20)
+
+	coordinator, err := worker.Open(url + "?queue=render.test")
+	require.NoError(t, err)
+	defer coordinator.Close()
+
+	w, err := worker.Open(url + "?queue=render.test")
+	require.NoError(t, err)
+	defer w.Close()
+
+	runWorker(t, w)
+	testQueue(t, coordinator)
+}
+
+func TestNATSJobsWait(t *testing.T) {
+	url := startNATS(t, 1<<20)
+
+	coordinator, err := worker.Open(url)
+	require.NoError(t, err)
+	defer coordinator.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// the job is kept until a worker comes along
+	results := make(chan *worker.Result, 1)
+	go func() {
+		res, err := coordinator.Submit(ctx, &worker.Job{ID: "job-1"})
+		assert.NoError(t, err)
+		results <- res
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	w, err := worker.Open(url)
+	require.NoError(t, err)
+	defer w.Close()
+
+	job, err := w.Receive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "job-1", job.ID)
+	require.NoError(t, w.Reply(ctx, job, &worker.Result{JobID: job.ID, Worker: "late"}))
+
+	select {
+	case res := <-results:
+		assert.Equal(t, "late", res.Worker)
+	case <-ctx.Done():
+		t.Fatal("no result")
+	}
+
+	// and it's gone once it's acknowledged
+	short, cancelShort := context.WithTimeout(ctx, 1500*time.Millisecond)
+	defer cancelShort()
+	_, err = w.Receive(short)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestNATSMaxPayload(t *testing.T) {
+	url := startNATS(t, 1024)
+
+	q, err := worker.Open(url)
+	require.NoError(t, err)
+	defer q.Close()
+
+	_, err = q.Submit(context.Background(), &worker.Job{Bundle: make([]byte, 2048)})
+	assert.ErrorIs(t, err, nats.ErrMaxPayload)
+}
@@ -0,0 +1,155 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+)
+
+// DefaultQueueName is the queue jobs are put on, unless the queue URL
+// names another one.
+const DefaultQueueName = "pixlet.render"
+
+// ErrClosed is returned by queues that were closed.
+var ErrClosed = errors.New("queue closed")
+
+// Queue carries jobs from coordinators to workers, and results back.
+// Queues are safe for concurrent use.
+type Queue interface {
+	// Submit puts job on the queue and waits for its result, until ctx
+	// is done.
+	Submit(ctx context.Context, job *Job) (*Result, error)
+
+	// Receive waits for a job, until ctx is done. Each job is received
+	// by one worker.
+	Receive(ctx context.Context) (*Job, error)
+
+	// Reply sends the result of a received job to its submitter.
+	Reply(ctx context.Context, job *Job, result *Result) error
+
+	Close() error
+}
+
+// Open connects to the queue at rawURL:
+//
+//	redis://[:PASSWORD@]HOST[:PORT][/DB]   jobs are kept in a Redis
+//	                                       stream until a worker has
+//	                                       sent the result
+//	nats://[USER:PASSWORD@]HOST[:PORT]     jobs are kept in a NATS
+//	                                       JetStream stream until a
+//	                                       worker has sent the result
+//
+// rediss:// and tls:// URLs connect to Redis and NATS over TLS. The
+// queue is named by the queue query parameter, like
+// redis://localhost/?queue=render.large, so that several sets of workers
+// can share a server. It's DefaultQueueName if not given.
+func Open(rawURL string) (Queue, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing queue URL: %w", err)
+	}
+
+	name := u.Query().Get("queue")
+	if name == "" {
+		name = DefaultQueueName
+	}
+
+	switch u.Scheme {
+	case "redis", "rediss":
+		return OpenRedis(u, name)
+	case "nats", "tls":
+		return DialNATS(u, name)
+	default:
+		return nil, fmt.Errorf("unknown queue: %q, use a redis:// or nats:// URL", rawURL)
+	}
+}
+
+// Memory is a Queue within a process, for tests and for programs that
+// run workers alongside their coordinator.
+type Memory struct {
+	jobs   chan *Job
+	closed chan struct{}
+	once   sync.Once
+
+	mu      sync.Mutex
+	pending map[string]chan *Result
+}
+
+// NewMemory returns an empty Memory queue.
+func NewMemory() *Memory {
+	return &Memory{
+		jobs:    make(chan *Job),
+		closed:  make(chan struct{}),
+		pending: map[string]chan *Result{},
+	}
+}
+
+// Submit implements Queue.
+func (m *Memory) Submit(ctx context.Context, job *Job) (*Result, error) {
+	job.prepare(ctx.Deadline())
+	job.ReplyTo = job.ID
+
+	results := make(chan *Result, 1)
+	m.mu.Lock()
+	m.pending[job.ID] = results
+	m.mu.Unlock()
+
+	defer func() {
+		m.mu.Lock()
+		delete(m.pending, job.ID)
+		m.mu.Unlock()
+	}()
+
+	select {
+	case m.jobs <- job:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-m.closed:
+		return nil, ErrClosed
+	}
+
+	select {
+	case res := <-results:
+		return res, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-m.closed:
+		return nil, ErrClosed
+	}
+}
+
+// Receive implements Queue.
+func (m *Memory) Receive(ctx context.Context) (*Job, error) {
+	select {
+	case job := <-m.jobs:
+		return job, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-m.closed:
+		return nil, ErrClosed
+	}
+}
+
+// Reply implements Queue. Results for jobs whose submitter stopped
+// waiting are dropped.
+func (m *Memory) Reply(ctx context.Context, job *Job, result *Result) error {
+	m.mu.Lock()
+	results, ok := m.pending[job.ReplyTo]
+	m.mu.Unlock()
+
+	if ok {
+		select {
+		case results <- result:
+		default:
+		}
+	}
+	return nil
+}
+
+// Close implements Queue.
+func (m *Memory) Close() error {
+	m.once.Do(func() { close(m.closed) })
+	return nil
+}
@@ -0,0 +1,324 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// redisGroup is the consumer group workers read jobs in, so that
+	// each job goes to one of them.
+	redisGroup = "pixlet-workers"
+
+	// redisPollInterval is how long reads block for at a time, so that
+	// the context is checked in between.
+	redisPollInterval = time.Second
+
+	// redisClaimIdle is how long a job can go unacknowledged before
+	// another worker takes it over, as when its worker died. Workers
+	// renew their claim on jobs while they render them, so jobs that
+	// take longer than this are still only rendered once.
+	redisClaimIdle = time.Minute
+
+	// redisResultTTL is how long results are kept for submitters, which
+	// only matters if they stopped waiting.
+	redisResultTTL = time.Minute
+)
+
+// redisRenewInterval is how often workers renew their claim on the job
+// they're rendering, well within redisClaimIdle.
+var redisRenewInterval = redisClaimIdle / 4
+
+// Redis is a Queue kept in Redis. Jobs are added to a stream named
+// after the queue, which workers read in a consumer group, so that each
+// job goes to one of them. Workers acknowledge and delete jobs once
+// they've sent the result, and renew their claim on them every 15
+// seconds until then, or until the job's deadline. Jobs left
+// unclaimed for a minute, by workers that died, are taken over by
+// others. Each result is pushed
+// on a list of its own, named after the queue and the job ID, which
+// expires a minute after the result is pushed.
+type Redis struct {
+	client   *redis.Client
+	name     string
+	consumer string
+
+	mu      sync.Mutex
+	grouped bool
+}
+
+// OpenRedis connects to the Redis server at u, a redis:// or rediss://
+// URL as taken by Open, and returns the queue called name.
+func OpenRedis(u *url.URL, name string) (*Redis, error) {
+	// go-redis rejects query parameters it doesn't know
+	stripped := *u
+	query := u.Query()
+	query.Del("queue")
+	stripped.RawQuery = query.Encode()
+
+	opts, err := redis.ParseURL(stripped.String())
+	if err != nil {
+		return nil, fmt.Errorf("parsing redis URL: %w", err)
+	}
+
+	r := &Redis{
+		client:   redis.NewClient(opts),
+		name:     name,
+		consumer: newID(),
+	}
+	if err := r.client.Ping(context.Background()).Err(); err != nil {
+		r.client.Close()
+		return nil, fmt.Errorf("connecting to redis at %s: %w", opts.Addr, err)
+	}
+	return r, nil
+}
+
+// Submit implements Queue.
+func (r *Redis) Submit(ctx context.Context, job *Job) (*Result, error) {
+	job.prepare(ctx.Deadline())
+	job.ReplyTo = r.name + ".results." + job.ID
+
+	data, err := json.Marshal(job)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: r.name,
+		Values: map[string]interface{}{"job": data},
+	}).Err(); err != nil {
+		return nil, fmt.Errorf("queueing job: %w", redisError(err))
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		// BRPOP answers with the key and the value
+		vals, err := r.client.BRPop(ctx, redisPollInterval, job.ReplyTo).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, redisError(err)
+		}
+
+		var res Result
+		if err := json.Unmarshal([]byte(vals[1]), &res); err != nil {
+			return nil, fmt.Errorf("decoding result: %w", err)
+		}
+		return &res, nil
+	}
+}
+
+// Receive implements Queue. Jobs that can't be decoded, and jobs whose
+// deadline has passed, are acknowledged and dropped.
+func (r *Redis) Receive(ctx context.Context) (*Job, error) {
+	if err := r.createGroup(ctx); err != nil {
+		return nil, err
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		msg, err := r.next(ctx)
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, redisError(err)
+		}
+
+		id := msg.ID
+		data, _ := msg.Values["job"].(string)
+		var job Job
+		if json.Unmarshal([]byte(data), &job) != nil ||
+			(!job.Deadline.IsZero() && time.Now().After(job.Deadline)) {
+			if err := r.ack(ctx, id); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		job.ack = func(ctx context.Context) error { return r.ack(ctx, id) }
+		job.release = r.keepClaimed(id, job.Deadline)
+		return &job, nil
+	}
+}
+
+// Reply implements Queue. It acknowledges the job once the result is
+// sent. If the result can't be sent, the job is left for another worker
+// to take over.
+func (r *Redis) Reply(ctx context.Context, job *Job, result *Result) error {
+	if job.release != nil {
+		job.release()
+	}
+
+	if job.ReplyTo == "" {
+		return fmt.Errorf("job %s has nowhere to reply to", job.ID)
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	if _, err := r.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.LPush(ctx, job.ReplyTo, data)
+		p.Expire(ctx, job.ReplyTo, redisResultTTL)
+		return nil
+	}); err != nil {
+		return fmt.Errorf("sending result: %w", redisError(err))
+	}
+
+	if job.ack != nil {
+		if err := job.ack(ctx); err != nil {
+			return fmt.Errorf("acknowledging job: %w", err)
+		}
+	}
+	return nil
+}
+
+// Close implements Queue.
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
+
+// createGroup creates the stream and the workers' consumer group, if
+// they don't exist yet.
+func (r *Redis) createGroup(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.grouped {
+		return nil
+	}
+
+	err := r.client.XGroupCreateMkStream(ctx, r.name, redisGroup, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("creating consumer group: %w", redisError(err))
+	}
+	r.grouped = true
+	return nil
+}
+
+// next returns a job that's been left unacknowledged for too long, or
+// else waits for a new one. It returns redis.Nil if there's none yet.
+func (r *Redis) next(ctx context.Context) (redis.XMessage, error) {
+	claimed, _, err := r.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   r.name,
+		Group:    redisGroup,
+		Consumer: r.consumer,
+		MinIdle:  redisClaimIdle,
+		Start:    "0-0",
+		Count:    1,
+	}).Result()
+	if err != nil {
+		return redis.XMessage{}, err
+	}
+	if len(claimed) > 0 {
+		return claimed[0], nil
+	}
+
+	streams, err := r.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    redisGroup,
+		Consumer: r.consumer,
+		Streams:  []string{r.name, ">"},
+		Count:    1,
+		Block:    redisPollInterval,
+	}).Result()
+	if err != nil {
+		return redis.XMessage{}, err
+	}
+	if len(streams) == 0 || len(streams[0].Messages) == 0 {
+		return redis.XMessage{}, redis.Nil
+	}
+	return streams[0].Messages[0], nil
+}
+
+// keepClaimed renews this worker's claim on the job with the stream ID
+// id every redisRenewInterval, until the returned function is called or
+// deadline passes, so that other workers don't take it over while it's
+// rendered. It stops early if the job is no longer this worker's.
+func (r *Redis) keepClaimed(id string, deadline time.Time) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	if !deadline.IsZero() {
+		ctx, cancel = context.WithDeadline(context.Background(), deadline)
+	}
+
+	interval := redisRenewInterval
+	go func() {
+		defer cancel()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			pending, err := r.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+				Stream:   r.name,
+				Group:    redisGroup,
+				Start:    id,
+				End:      id,
+				Count:    1,
+				Consumer: r.consumer,
+			}).Result()
+			if errors.Is(err, redis.ErrClosed) {
+				return
+			}
+			if err != nil {
+				// try again next time, there's time left before the
+				// claim lapses
+				continue
+			}
+			if len(pending) == 0 {
+				// acknowledged, or taken over
+				return
+			}
+
+			// claiming the job resets its idle time
+			r.client.XClaimJustID(ctx, &redis.XClaimArgs{
+				Stream:   r.name,
+				Group:    redisGroup,
+				Consumer: r.consumer,
+				Messages: []string{id},
+			})
+		}
+	}()
+
+	return cancel
+}
+
+// ack acknowledges the job with the stream ID id, and deletes it.
+func (r *Redis) ack(ctx context.Context, id string) error {
+	if _, err := r.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.XAck(ctx, r.name, redisGroup, id)
+		p.XDel(ctx, r.name, id)
+		return nil
+	}); err != nil {
+		return redisError(err)
+	}
+	return nil
+}
+
+// redisError returns ErrClosed for errors from a closed client.
+func redisError(err error) error {
+	if errors.Is(err, redis.ErrClosed) {
+		return ErrClosed
+	}
+	return err
+}
@@ -0,0 +1,144 @@
+package worker_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tidbyt.dev/pixlet/worker"
+)
+
+func TestRedis(t *testing.T) {
+	s := miniredis.RunT(t)
+	s.RequireAuth("secret")
+
+	q, err := worker.Open("redis://:secret@" + s.Addr() + "/2?queue=render.test")
+	require.NoError(t, err)
+	defer q.Close()
+
+	testQueue(t, q)
+
+	// jobs are deleted once their results are sent
+	s.Select(2)
+	assert.Eventually(t, func() bool {
+		entries, err := s.Stream("render.test")
+		return err == nil && len(entries) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestRedisAuth(t *testing.T) {
+	s := miniredis.RunT(t)
+	s.RequireAuth("secret")
+
+	_, err := worker.Open("redis://:wrong@" + s.Addr())
+	assert.ErrorContains(t, err, "WRONGPASS")
+}
+
+func TestRedisRedelivery(t *testing.T) {
+	s := miniredis.RunT(t)
+	now := time.Now()
+	s.SetTime(now)
+
+	coordinator, err := worker.Open("redis://" + s.Addr())
+	require.NoError(t, err)
+	defer coordinator.Close()
+
+	results := make(chan *worker.Result, 1)
+	go func() {
+		res, err := coordinator.Submit(context.Background(), &worker.Job{ID: "job-1"})
+		assert.NoError(t, err)
+		results <- res
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// a worker takes the job, and dies before replying
+	dead, err := worker.Open("redis://" + s.Addr())
+	require.NoError(t, err)
+	job, err := dead.Receive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "job-1", job.ID)
+	dead.Close()
+
+	// until it's been left for long enough, no one else gets it
+	w, err := worker.Open("redis://" + s.Addr())
+	require.NoError(t, err)
+	defer w.Close()
+
+	short, cancelShort := context.WithTimeout(ctx, 1500*time.Millisecond)
+	_, err = w.Receive(short)
+	cancelShort()
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	s.SetTime(now.Add(2 * time.Minute))
+	job, err = w.Receive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "job-1", job.ID)
+	require.NoError(t, w.Reply(ctx, job, &worker.Result{JobID: job.ID, Worker: "second"}))
+
+	select {
+	case res := <-results:
+		assert.Equal(t, "second", res.Worker)
+	case <-ctx.Done():
+		t.Fatal("no result")
+	}
+
+	entries, err := s.Stream(worker.DefaultQueueName)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestRedisRenewsClaim(t *testing.T) {
+	worker.SetRedisRenewInterval(t, 20*time.Millisecond)
+
+	s := miniredis.RunT(t)
+	now := time.Now()
+	s.SetTime(now)
+
+	coordinator, err := worker.Open("redis://" + s.Addr())
+	require.NoError(t, err)
+	defer coordinator.Close()
+
+	results := make(chan *worker.Result, 1)
+	go func() {
+		res, err := coordinator.Submit(context.Background(), &worker.Job{ID: "job-1"})
+		assert.NoError(t, err)
+		results <- res
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	slow, err := worker.Open("redis://" + s.Addr())
+	require.NoError(t, err)
+	defer slow.Close()
+	job, err := slow.Receive(ctx)
+	require.NoError(t, err)
+
+	// the job takes longer to render than a claim lasts, but the
+	// worker renews its claim meanwhile
+	s.SetTime(now.Add(2 * time.Minute))
+	time.Sleep(200 * time.Millisecond)
+
+	w, err := worker.Open("redis://" + s.Addr())
+	require.NoError(t, err)
+	defer w.Close()
+
+	short, cancelShort := context.WithTimeout(ctx, 1500*time.Millisecond)
+	_, err = w.Receive(short)
+	cancelShort()
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	require.NoError(t, slow.Reply(ctx, job, &worker.Result{JobID: job.ID, Worker: "slow"}))
+	select {
+	case res := <-results:
+		assert.Equal(t, "slow", res.Worker)
+	case <-ctx.Done():
+		t.Fatal("no result")
+	}
+}
@@ -0,0 +1,212 @@
+package worker
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"tidbyt.dev/pixlet/bundle"
+	"tidbyt.dev/pixlet/encode"
+	"tidbyt.dev/pixlet/globals"
+	"tidbyt.dev/pixlet/runtime"
+)
+
+// DefaultCacheSize is how many apps a Worker keeps loaded, unless it
+// says otherwise.
+const DefaultCacheSize = 32
+
+// retryDelay is how long Run waits after failing to receive a job.
+const retryDelay = time.Second
+
+// Worker renders jobs from a queue. Set its fields before calling Run.
+//
+// Apps run alongside those of other workers in the process, but
+// painting takes turns, since the size apps are painted at is global to
+// the process.
+type Worker struct {
+	Queue Queue
+
+	// Name is sent with results, to tell which worker rendered what. It
+	// defaults to the host name.
+	Name string
+
+	// AppletOptions are what apps are loaded with, like limits on the
+	// time and memory they take.
+	AppletOptions []runtime.AppletOption
+
+	// CacheSize is how many apps are kept loaded, so that jobs for the
+	// same bundle don't load it again. It defaults to DefaultCacheSize.
+	CacheSize int
+
+	// Logger is where failed jobs are logged. If nil, they go to
+	// slog.Default().
+	Logger *slog.Logger
+
+	mu   sync.Mutex
+	apps map[[sha256.Size]byte]*list.Element
+	lru  *list.List
+}
+
+// cachedApp is a loaded app, kept by the hash of its bundle.
+type cachedApp struct {
+	key    [sha256.Size]byte
+	applet *runtime.Applet
+	err    error
+}
+
+// Run renders jobs from the queue until ctx is done or the queue is
+// closed.
+func (w *Worker) Run(ctx context.Context) error {
+	for {
+		job, err := w.Queue.Receive(ctx)
+		if ctx.Err() != nil || errors.Is(err, ErrClosed) {
+			return err
+		}
+		if err != nil {
+			w.log().Warn("receiving job", "error", err)
+			select {
+			case <-time.After(retryDelay):
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		if !job.Deadline.IsZero() && time.Now().After(job.Deadline) {
+			w.log().Info("dropping expired job", "job", job.ID)
+			continue
+		}
+
+		res := w.Render(ctx, job)
+		if res.Error != "" {
+			w.log().Warn("rendering job", "job", job.ID, "error", res.Error)
+		}
+		if err := w.Queue.Reply(ctx, job, res); err != nil {
+			w.log().Warn("replying to job", "job", job.ID, "error", err)
+		}
+	}
+}
+
+// Render renders job, with its deadline if it has one.
+func (w *Worker) Render(ctx context.Context, job *Job) *Result {
+	start := time.Now()
+	res := &Result{JobID: job.ID, Worker: w.name()}
+	defer func() {
+		res.Duration = time.Since(start)
+	}()
+
+	if !job.Deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, job.Deadline)
+		defer cancel()
+	}
+
+	applet, err := w.applet(job.Bundle)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	opts := encode.Options{
+		Format:      job.Format,
+		Width:       job.Width,
+		Height:      job.Height,
+		MaxDuration: job.MaxDuration,
+		Frame:       job.Frame,
+	}
+	if opts.Format == "" {
+		opts.Format = encode.FormatWebP
+	}
+
+	ctx = globals.ContextWithSize(ctx, job.Width, job.Height)
+	roots, err := applet.RunWithConfig(ctx, job.Config)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	if len(roots) == 0 {
+		res.Skipped = true
+		return res
+	}
+	if res.Image, err = encode.EncodeContext(ctx, roots, opts); err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	res.Format = opts.Format
+	return res
+}
+
+// applet returns the app in data, loading it unless it's cached. Apps
+// that fail to load are cached too, with the error.
+func (w *Worker) applet(data []byte) (*runtime.Applet, error) {
+	key := sha256.Sum256(data)
+
+	w.mu.Lock()
+	if w.apps == nil {
+		w.apps = map[[sha256.Size]byte]*list.Element{}
+		w.lru = list.New()
+	}
+	if el, ok := w.apps[key]; ok {
+		w.lru.MoveToFront(el)
+		app := el.Value.(*cachedApp)
+		w.mu.Unlock()
+		return app.applet, app.err
+	}
+	w.mu.Unlock()
+
+	app := &cachedApp{key: key}
+	app.applet, app.err = loadBundle(data, w.AppletOptions)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, ok := w.apps[key]; !ok {
+		w.apps[key] = w.lru.PushFront(app)
+	}
+	size := w.CacheSize
+	if size <= 0 {
+		size = DefaultCacheSize
+	}
+	for w.lru.Len() > size {
+		oldest := w.lru.Back()
+		w.lru.Remove(oldest)
+		delete(w.apps, oldest.Value.(*cachedApp).key)
+	}
+	return app.applet, app.err
+}
+
+func loadBundle(data []byte, opts []runtime.AppletOption) (*runtime.Applet, error) {
+	b, err := bundle.LoadBundle(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("loading bundle: %w", err)
+	}
+
+	applet, err := runtime.NewAppletFromFS(b.Manifest.ID, b.Source, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("loading %s: %w", b.Manifest.ID, err)
+	}
+	return applet, nil
+}
+
+func (w *Worker) name() string {
+	if w.Name != "" {
+		return w.Name
+	}
+	name, _ := os.Hostname()
+	return name
+}
+
+// log returns the worker's logger.
+func (w *Worker) log() *slog.Logger {
+	if w.Logger == nil {
+		return slog.Default()
+	}
+	return w.Logger
+}
@@ -0,0 +1,217 @@
+package worker_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tidbyt.dev/pixlet/bundle"
+	"tidbyt.dev/pixlet/encode"
+	"tidbyt.dev/pixlet/worker"
+)
+
+const textApp = `
+load("render.star", "render")
+
+def main(config):
+    return render.Root(child = render.Text(config.get("msg", "hi")))
+`
+
+const skippedApp = `
+def main():
+    return []
+`
+
+const testManifest = `---
+id: test-app
+name: Test App
+summary: For Testing
+desc: It's an app for testing.
+author: Test Dev
+`
+
+func testBundle(t *testing.T, src string) []byte {
+	ab, err := bundle.FromFS(fstest.MapFS{
+		"manifest.yaml": {Data: []byte(testManifest)},
+		"app.star":      {Data: []byte(src)},
+	})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, ab.WriteBundle(&buf, bundle.WithoutRuntime()))
+	return buf.Bytes()
+}
+
+// runWorker renders jobs from q until the test ends.
+func runWorker(t *testing.T, q worker.Queue) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w := &worker.Worker{Queue: q, Name: "test-worker"}
+		w.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+// testQueue submits jobs to a worker through q.
+func testQueue(t *testing.T, q worker.Queue) {
+	runWorker(t, q)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	res, err := q.Submit(ctx, &worker.Job{
+		Bundle: testBundle(t, textApp),
+		Config: map[string]string{"msg": "hello"},
+		Format: encode.FormatPNG,
+		Width:  32,
+		Height: 16,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, res.Error)
+	assert.Equal(t, "test-worker", res.Worker)
+	assert.Equal(t, encode.FormatPNG, res.Format)
+	assert.True(t, bytes.HasPrefix(res.Image, []byte("\x89PNG")))
+
+	res, err = q.Submit(ctx, &worker.Job{Bundle: testBundle(t, skippedApp)})
+	require.NoError(t, err)
+	assert.True(t, res.Skipped)
+	assert.Empty(t, res.Image)
+
+	res, err = q.Submit(ctx, &worker.Job{Bundle: []byte("not a bundle")})
+	require.NoError(t, err)
+	assert.Contains(t, res.Error, "loading bundle")
+}
+
+func TestMemory(t *testing.T) {
+	q := worker.NewMemory()
+	defer q.Close()
+	testQueue(t, q)
+}
+
+func TestSubmitTimeout(t *testing.T) {
+	q := worker.NewMemory()
+	defer q.Close()
+
+	// nothing takes the job
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := q.Submit(ctx, &worker.Job{Bundle: testBundle(t, textApp)})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRender(t *testing.T) {
+	w := &worker.Worker{Name: "w"}
+	b := testBundle(t, textApp)
+
+	res := w.Render(context.Background(), &worker.Job{ID: "1", Bundle: b, Format: encode.FormatGIF})
+	assert.Equal(t, "1", res.JobID)
+	assert.Empty(t, res.Error)
+	assert.True(t, bytes.HasPrefix(res.Image, []byte("GIF8")))
+	assert.Positive(t, res.Duration)
+
+	// webp is the default
+	res = w.Render(context.Background(), &worker.Job{ID: "2", Bundle: b})
+	assert.Equal(t, encode.FormatWebP, res.Format)
+
+	res = w.Render(context.Background(), &worker.Job{ID: "3", Bundle: testBundle(t, "def main(:")})
+	assert.Contains(t, res.Error, "loading test-app")
+}
+
+func TestCoordinator(t *testing.T) {
+	q := worker.NewMemory()
+	defer q.Close()
+	runWorker(t, q)
+
+	srv := httptest.NewServer(&worker.Coordinator{Queue: q, Timeout: 10 * time.Second})
+	defer srv.Close()
+
+	b := testBundle(t, textApp)
+	resp, err := http.Post(srv.URL+"/?_format=png&_width=16&msg=hello", "application/gzip", bytes.NewReader(b))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "image/png", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "test-worker", resp.Header.Get("X-Pixlet-Worker"))
+
+	// the format is negotiated without _format
+	req, err := http.NewRequest(http.MethodPost, srv.URL, bytes.NewReader(b))
+	require.NoError(t, err)
+	req.Header.Set("Accept", "image/gif")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "image/gif", resp.Header.Get("Content-Type"))
+
+	// jobs can be JSON
+	job, err := json.Marshal(map[string]interface{}{
+		"bundle": base64.StdEncoding.EncodeToString(testBundle(t, skippedApp)),
+	})
+	require.NoError(t, err)
+	resp, err = http.Post(srv.URL, "application/json", bytes.NewReader(job))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	for name, tc := range map[string]struct {
+		query  string
+		body   []byte
+		status int
+		err    string
+	}{
+		"no bundle":  {"", nil, http.StatusBadRequest, "no bundle"},
+		"bad format": {"?_format=tiff", b, http.StatusBadRequest, "tiff"},
+		"too wide":   {"?_width=1000", b, http.StatusBadRequest, "_width"},
+		"bad app":    {"", testBundle(t, "def main(:"), http.StatusUnprocessableEntity, "loading test-app"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp, err := http.Post(srv.URL+tc.query, "application/gzip", bytes.NewReader(tc.body))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tc.status, resp.StatusCode)
+
+			var body struct{ Error string }
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			assert.Contains(t, body.Error, tc.err)
+		})
+	}
+
+	resp, err = http.Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestCoordinatorTimeout(t *testing.T) {
+	q := worker.NewMemory()
+	defer q.Close()
+
+	srv := httptest.NewServer(&worker.Coordinator{Queue: q, Timeout: 50 * time.Millisecond})
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL, "application/gzip", strings.NewReader("bundle"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusGatewayTimeout, resp.StatusCode)
+}
+
+func TestOpen(t *testing.T) {
+	for _, spec := range []string{"memory", "amqp://localhost", "redis://localhost/x"} {
+		_, err := worker.Open(spec)
+		assert.Error(t, err, spec)
+	}
+}